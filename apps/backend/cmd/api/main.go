@@ -9,15 +9,22 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/rs/zerolog/log"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/config"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/handler"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/database"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/diskcache"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/logger"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/mailer"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/oidc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/scanner"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/router"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+	"github.com/rs/zerolog/log"
 )
 
 func main() {
@@ -51,47 +58,223 @@ func main() {
 		cfg.JWT.RefreshExpiry,
 		cfg.JWT.Issuer,
 	)
+	if cfg.JWT.Algorithm != auth.AlgorithmHS256 {
+		keys, err := auth.LoadKeySet(auth.KeySetConfig{
+			Algorithm:     cfg.JWT.Algorithm,
+			Dir:           cfg.JWT.KeyDir,
+			PrivateKeyPEM: cfg.JWT.PrivateKey,
+			ActiveKeyID:   cfg.JWT.ActiveKeyID,
+		})
+		if err != nil {
+			appLogger.Fatal().Err(err).Msg("failed to load JWT signing keys")
+		}
+		jwtManager.WithKeySet(keys)
+		appLogger.Info().
+			Str("algorithm", keys.Algorithm()).
+			Str("kid", keys.Active().ID).
+			Msg("access tokens signed with asymmetric key")
+	}
+
+	// Initialize mailer
+	mail, err := mailer.New(cfg.Mail, appLogger)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("failed to set up mailer")
+	}
+
+	// Initialize media storage
+	store, err := storage.New(cfg.Storage, cfg.Supabase)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("failed to set up media storage")
+	}
+
+	// Initialize the upload malware scanner
+	uploadScanner, err := scanner.New(cfg.Scan)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("failed to set up upload scanner")
+	}
+
+	// Initialize the cache of transformed images
+	imageSizes, err := domain.ParseImageSizes(cfg.Media.TransformSizes)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("invalid MEDIA_TRANSFORM_SIZES")
+	}
+	imageCache, err := diskcache.Open(cfg.Media.CacheDir, cfg.Media.CacheMaxBytes)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("failed to open image cache")
+	}
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	pageRepo := repository.NewPageRepository(db)
 	siteRepo := repository.NewSiteRepository(db)
 	compRepo := repository.NewComponentRepository(db)
+	revisionRepo := repository.NewRevisionRepository(db)
+	templateRepo := repository.NewTemplateRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	memberRepo := repository.NewSiteMembershipRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
 
 	// Initialize services
-	authSvc := service.NewAuthService(userRepo, jwtManager, appLogger)
-	pageSvc := service.NewPageService(pageRepo, appLogger)
-	siteSvc := service.NewSiteService(siteRepo, appLogger)
+	roleSvc := service.NewRoleService(roleRepo, appLogger)
+	twoFactorSvc := service.NewTwoFactorService(twoFactorRepo, userRepo, roleSvc, cfg.TwoFactor.Issuer, appLogger)
+	authOpts := service.AuthOptions{
+		ResetURL:        cfg.Reset.URL,
+		ResetExpiry:     cfg.Reset.Expiry,
+		ChallengeExpiry: cfg.TwoFactor.ChallengeExpiry,
+		SessionLifetime: cfg.Sessions.AbsoluteLifetime,
+		ReuseGrace:      cfg.Sessions.ReuseGrace,
+		OnTokenReuse:    service.MailTokenReuseHook(mail, appLogger),
+		BcryptCost:      cfg.Security.BcryptCost,
+	}
+	if cfg.OIDC.Enabled {
+		mappings := make([]domain.OIDCRoleMapping, 0, len(cfg.OIDC.RoleMappings))
+		for _, m := range cfg.OIDC.RoleMappings {
+			mappings = append(mappings, domain.OIDCRoleMapping{Value: m.Value, Role: domain.UserRole(m.Role)})
+		}
+		authOpts.OIDC = &service.OIDCOptions{
+			Provider: oidc.NewClient(oidc.Config{
+				IssuerURL:    cfg.OIDC.IssuerURL,
+				ClientID:     cfg.OIDC.ClientID,
+				ClientSecret: cfg.OIDC.ClientSecret,
+				RedirectURL:  cfg.OIDC.RedirectURL,
+				Scopes:       cfg.OIDC.Scopes,
+			}),
+			Identities:   identityRepo,
+			Roles:        roleSvc,
+			RoleClaim:    cfg.OIDC.RoleClaim,
+			RoleMappings: mappings,
+			DefaultRole:  domain.UserRole(cfg.OIDC.DefaultRole),
+			LinkByEmail:  cfg.OIDC.LinkByEmail,
+			StateExpiry:  cfg.OIDC.StateExpiry,
+		}
+		appLogger.Info().Str("issuer", cfg.OIDC.IssuerURL).Msg("single sign-on enabled")
+	}
+	authSvc := service.NewAuthService(userRepo, jwtManager, twoFactorSvc, mail, compRepo, authOpts, appLogger)
+	revisionSvc := service.NewRevisionService(pageRepo, revisionRepo, appLogger)
+	seoSvc := service.NewSEOService(siteRepo, pageRepo, appLogger)
+	pageSvc := service.NewPageService(pageRepo, revisionSvc, appLogger, seoSvc.InvalidateSite)
+	imageSvc := service.NewImageService(compRepo, siteRepo, store, imageCache, service.ImageOptions{
+		DefaultSizes:   imageSizes,
+		DefaultQuality: cfg.Media.JPEGQuality,
+		MaxPixels:      cfg.Media.MaxPixels,
+	}, appLogger)
+	siteSvc := service.NewSiteService(siteRepo, appLogger, seoSvc.InvalidateSite, imageSvc.InvalidateSite)
+
+	renderer, err := render.New(cfg.Render.TemplatesDir)
+	if err != nil {
+		appLogger.Fatal().Err(err).Msg("failed to load page templates")
+	}
+	renderSvc := service.NewRenderService(siteRepo, compRepo, pageSvc, renderer, appLogger)
+	exportSvc := service.NewExportService(siteRepo, pageRepo, compRepo, store, renderer, appLogger)
+	bundleSvc := service.NewBundleService(siteRepo, pageRepo, compRepo, appLogger)
+	templateSvc := service.NewTemplateService(templateRepo, pageRepo, revisionSvc, appLogger)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, userRepo, siteRepo, roleSvc, appLogger)
+	sessionSvc := service.NewSessionService(userRepo, appLogger)
+	invitationSvc := service.NewInvitationService(invitationRepo, userRepo, roleSvc, mail, service.InvitationOptions{
+		AcceptURL:  cfg.Invite.URL,
+		Expiry:     cfg.Invite.Expiry,
+		BcryptCost: cfg.Security.BcryptCost,
+	}, appLogger)
+	siteMemberSvc := service.NewSiteMemberService(memberRepo, userRepo, siteRepo, roleSvc, appLogger)
+	mediaSvc := service.NewMediaService(compRepo, store, uploadScanner, service.MediaOptions{
+		ThumbnailSize:    cfg.Media.ThumbnailSize,
+		ResponsiveWidths: cfg.Media.ResponsiveWidths,
+		JPEGQuality:      cfg.Media.JPEGQuality,
+		MaxPixels:        cfg.Media.MaxPixels,
+		AllowedMimeTypes: cfg.Security.AllowedMimeTypes,
+	}, appLogger)
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authSvc, cfg, appLogger)
-	pageHandler := handler.NewPageHandler(pageSvc, appLogger)
+	pageHandler := handler.NewPageHandler(pageSvc, mediaSvc, appLogger)
+	revisionHandler := handler.NewRevisionHandler(revisionSvc, appLogger)
 	siteHandler := handler.NewSiteHandler(siteSvc, appLogger)
-	userHandler := handler.NewUserHandler(userRepo, appLogger, cfg.Security.BcryptCost)
+	renderHandler := handler.NewRenderHandler(renderSvc, appLogger)
+	exportHandler := handler.NewExportHandler(exportSvc, appLogger)
+	seoHandler := handler.NewSEOHandler(seoSvc, appLogger)
+	bundleHandler := handler.NewBundleHandler(bundleSvc, appLogger)
+	templateHandler := handler.NewTemplateHandler(templateSvc, appLogger)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorSvc, appLogger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeySvc, appLogger)
+	sessionHandler := handler.NewSessionHandler(sessionSvc, appLogger)
+	jwksHandler := handler.NewJWKSHandler(jwtManager)
+	siteMemberHandler := handler.NewSiteMemberHandler(siteMemberSvc, appLogger)
+	roleHandler := handler.NewRoleHandler(roleSvc, appLogger)
+	invitationHandler := handler.NewInvitationHandler(invitationSvc, appLogger)
+	imageHandler := handler.NewImageHandler(imageSvc, cfg.Storage.Driver != "local" || cfg.Storage.LocalPublic, appLogger)
+	userHandler := handler.NewUserHandler(userRepo, roleSvc, appLogger, cfg.Security.BcryptCost)
 	componentHandler := handler.NewComponentHandler(
 		compRepo,
-		cfg.Supabase.URL,
-		cfg.Supabase.StorageBucket,
-		cfg.Supabase.ServiceKey,
+		store,
+		mediaSvc,
 		cfg.Security.MaxUploadSize,
-		cfg.Security.AllowedMimeTypes,
 		appLogger,
 	)
 
+	var mediaFileHandler *handler.MediaFileHandler
+	if cfg.Storage.Driver == "local" {
+		mediaFileHandler = handler.NewMediaFileHandler(store, cfg.Storage.LocalPublic, appLogger)
+	}
+
 	// Setup router
 	deps := &router.Dependencies{
-		AuthHandler:      authHandler,
-		PageHandler:      pageHandler,
-		SiteHandler:      siteHandler,
-		UserHandler:      userHandler,
-		ComponentHandler: componentHandler,
-		JWTManager:       jwtManager,
-		Config:           cfg,
-		Logger:           appLogger,
-		DB:               db,
+		AuthHandler:       authHandler,
+		PageHandler:       pageHandler,
+		RevisionHandler:   revisionHandler,
+		SiteHandler:       siteHandler,
+		UserHandler:       userHandler,
+		ComponentHandler:  componentHandler,
+		RenderHandler:     renderHandler,
+		SEOHandler:        seoHandler,
+		ExportHandler:     exportHandler,
+		BundleHandler:     bundleHandler,
+		TemplateHandler:   templateHandler,
+		TwoFactorHandler:  twoFactorHandler,
+		APIKeyHandler:     apiKeyHandler,
+		SessionHandler:    sessionHandler,
+		JWKSHandler:       jwksHandler,
+		SiteMemberHandler: siteMemberHandler,
+		RoleHandler:       roleHandler,
+		InvitationHandler: invitationHandler,
+		MediaFileHandler:  mediaFileHandler,
+		ImageHandler:      imageHandler,
+		APIKeys:           apiKeySvc,
+		Permissions:       roleSvc,
+		SiteAccess:        siteMemberSvc,
+		MediaKeys:         siteMemberSvc,
+		Audit:             compRepo,
+		SiteResolver:      siteSvc,
+		JWTManager:        jwtManager,
+		Config:            cfg,
+		Logger:            appLogger,
+		DB:                db,
 	}
 	r := router.Setup(deps)
 
+	// Start page scheduler (scheduled publish/unpublish)
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if cfg.Scheduler.Enabled {
+		pageScheduler := service.NewPageScheduler(
+			pageSvc,
+			pageRepo,
+			compRepo,
+			cfg.Scheduler.Interval,
+			cfg.Scheduler.BatchSize,
+			appLogger,
+		)
+		go pageScheduler.Start(schedulerCtx)
+	}
+
+	// Start session janitor (expired refresh token cleanup)
+	if cfg.Sessions.CleanupInterval > 0 {
+		sessionJanitor := service.NewSessionJanitor(userRepo, cfg.Sessions.CleanupInterval, appLogger)
+		go sessionJanitor.Start(schedulerCtx)
+	}
+
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         ":" + cfg.App.Port,
@@ -118,6 +301,7 @@ func main() {
 	<-quit
 
 	appLogger.Info().Msg("shutting down server...")
+	stopScheduler()
 
 	// Graceful shutdown with 30 second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
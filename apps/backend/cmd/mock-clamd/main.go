@@ -0,0 +1,44 @@
+// Command mock-clamd runs a local stand-in for the ClamAV daemon to try
+// upload scanning without installing ClamAV. Files containing the EICAR test
+// string are reported as infected; everything else is clean.
+//
+//	go run ./cmd/mock-clamd -addr 127.0.0.1:3310
+//
+// Point the API at it with UPLOAD_SCANNER=clamav and
+// CLAMAV_ADDRESS=127.0.0.1:3310.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/scanner/clamdtest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:3310", "listen address, or unix:<path> for a socket")
+	flag.Parse()
+
+	network, address := "tcp", *addr
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "listen: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := clamdtest.Serve(listener)
+	fmt.Printf("mock clamd listening on %s\n", *addr)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	srv.Close()
+}
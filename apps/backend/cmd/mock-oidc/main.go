@@ -0,0 +1,64 @@
+// Command mock-oidc runs a local OpenID provider to try single sign-on
+// without a real identity provider. Every sign-in is approved as the user
+// given by the flags.
+//
+//	go run ./cmd/mock-oidc -addr 127.0.0.1:9000 -email jane@example.com -groups cms-admins
+//
+// Point the API at it with OIDC_ISSUER_URL=http://127.0.0.1:9000,
+// OIDC_CLIENT_ID=cms and OIDC_CLIENT_SECRET=secret.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9000", "listen address")
+	clientID := flag.String("client-id", "cms", "client ID")
+	clientSecret := flag.String("client-secret", "secret", "client secret, empty for a public client")
+	subject := flag.String("sub", "mock-user-1", "subject of the signed-in user")
+	email := flag.String("email", "jane@example.com", "email of the signed-in user")
+	name := flag.String("name", "Jane Doe", "name of the signed-in user")
+	groups := flag.String("groups", "cms-admins", "comma separated groups claim")
+	flag.Parse()
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "listen: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := oidctest.NewUnstartedServer(*clientID, *clientSecret)
+	srv.Listener.Close()
+	srv.Listener = listener
+	srv.Start()
+	defer srv.Close()
+
+	var groupClaim []interface{}
+	for _, g := range strings.Split(*groups, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groupClaim = append(groupClaim, g)
+		}
+	}
+	srv.SetClaims(map[string]interface{}{
+		"sub":            *subject,
+		"email":          *email,
+		"email_verified": true,
+		"name":           *name,
+		"groups":         groupClaim,
+	})
+
+	fmt.Printf("mock OpenID provider at %s (client %q), signing in %s\n", srv.Issuer(), *clientID, *email)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+}
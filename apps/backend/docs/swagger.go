@@ -9,6 +9,14 @@
 // The API uses JWT Bearer tokens for authentication. Include the token in the
 // Authorization header: `Authorization: Bearer <token>`
 //
+// Admin endpoints also accept scoped API keys (`Authorization: Bearer cms_…`).
+// A key acts as its owner, limited to resource:read or resource:write scopes
+// and optionally to one site.
+//
+// With JWT_ALGORITHM=RS256 or EdDSA, access tokens carry a kid header and can
+// be verified without the server's secrets using the keys published at
+// GET /.well-known/jwks.json.
+//
 // ## Base URL
 //
 // All API endpoints are prefixed with `/api/v1`
@@ -18,98 +26,185 @@
 // ### Public Endpoints (no auth required)
 //   - GET /api/v1/public/sites/:id - Get site info with public settings
 //   - GET /api/v1/public/site/:slug - Get site by slug
-//   - GET /api/v1/public/pages/:slug - Get published page with content
+//   - GET /api/v1/public/site - Get the site resolved from the Host header
+//   - GET /api/v1/public/pages/:slug - Get published snapshot of a page with content, hero and gallery images with srcset data
 //   - GET /api/v1/public/pages - Get homepage
 //   - GET /api/v1/public/navigation/:siteId/:identifier - Get navigation menu
 //   - GET /api/v1/public/navigation/:siteId - Get default navigation
+//   - GET /api/v1/public/navigation?identifier= - Get navigation of the site resolved from the Host header
+//
+// Public endpoints resolve the site from the Host header (X-Forwarded-Host from
+// TRUSTED_PROXIES) when no site_id is given. Hosts match a site's domain or one of its domain aliases.
+//
+// ### Rendered Pages (HTML, no auth required)
+//   - GET /render/:slug - Published page rendered as a full HTML document
+//   - GET /render - Homepage rendered as HTML
+//
+// The site comes from ?site_id= or the Host header. Pages pick a template set
+// with their "template" field; see RENDER_TEMPLATES_DIR for per-site overrides.
+//
+// ### Crawler Files (no auth required, site from the Host header)
+//   - GET /sitemap.xml - Published, indexable pages; a sitemap index past 50,000 URLs (?page=N for parts)
+//   - GET /robots.txt - Built from the robots_* site settings
 //
 // ### Auth Endpoints
 //   - POST /api/v1/auth/login - Login with email/password
 //   - POST /api/v1/auth/logout - Logout (revoke refresh token)
 //   - POST /api/v1/auth/refresh - Refresh access token
+//   - POST /api/v1/auth/forgot-password - Email a single-use password reset link
+//   - POST /api/v1/auth/reset-password - Reset password with a reset token
+//   - POST /api/v1/auth/accept-invite - Accept an invitation and set a password
+//   - GET /api/v1/auth/oidc/login - Start single sign-on (redirects to the identity provider)
+//   - GET /api/v1/auth/oidc/callback - Single sign-on callback: sets the refresh cookie and redirects
 //   - GET /api/v1/auth/me - Get current user info (requires auth)
 //   - POST /api/v1/auth/change-password - Change password (requires auth)
+//   - POST /api/v1/auth/2fa/verify - Second login step: challenge token + TOTP or recovery code
+//   - POST /api/v1/auth/2fa/challenge/setup - Start enrollment demanded by a login challenge
+//   - GET /api/v1/auth/2fa - Two-factor status (requires auth)
+//   - POST /api/v1/auth/2fa/setup - Start TOTP enrollment: secret + otpauth URI (requires auth)
+//   - POST /api/v1/auth/2fa/enable - Confirm enrollment with a code, returns recovery codes (requires auth)
+//   - POST /api/v1/auth/2fa/disable - Turn off with password + code (requires auth)
+//   - POST /api/v1/auth/2fa/recovery-codes - Replace recovery codes (requires auth)
+//   - GET /api/v1/auth/sessions - List active sessions with device info (requires auth)
+//   - DELETE /api/v1/auth/sessions/:id - Revoke one session (requires auth)
+//   - DELETE /api/v1/auth/sessions - Sign out of all other sessions (requires auth)
 //
 // ### Admin Endpoints (requires auth + role)
 //
-// #### Sites (admin+)
-//   - GET /api/v1/admin/sites - List all sites
+// #### Sites (content.view, changes site.manage)
+//   - GET /api/v1/admin/sites - List the sites the caller can access
 //   - POST /api/v1/admin/sites - Create a new site
 //   - GET /api/v1/admin/sites/:id - Get site by ID
 //   - PUT /api/v1/admin/sites/:id - Update site
-//   - DELETE /api/v1/admin/sites/:id - Delete site (super_admin only)
+//   - DELETE /api/v1/admin/sites/:id - Delete site (site.delete)
 //   - GET /api/v1/admin/sites/:id/settings - Get site settings
 //   - PUT /api/v1/admin/sites/:id/settings - Bulk update settings
 //   - PUT /api/v1/admin/sites/:id/settings/:key - Update single setting
-//
-// #### Pages (editor+)
+//   - GET /api/v1/admin/sites/:id/domains - List domain aliases
+//   - POST /api/v1/admin/sites/:id/domains - Add a domain alias
+//   - DELETE /api/v1/admin/sites/:id/domains/:domainId - Remove a domain alias
+//   - POST /api/v1/admin/sites/:id/export - Export published pages as a static site zip
+//   - GET /api/v1/admin/sites/:id/bundle - Export the site and all its content as a JSON bundle
+//   - POST /api/v1/admin/sites/import - Import a JSON bundle as a new site (?slug=, ?name=, ?domain=, ?dry_run=true)
+//   - POST /api/v1/admin/sites/:id/duplicate - Copy the site with all its content (body: name, slug, domain; optional)
+//   - GET /api/v1/admin/sites/:id/members - List the site's members
+//   - PUT /api/v1/admin/sites/:id/members/:userId - Give an editor a role on the site (body: role)
+//   - DELETE /api/v1/admin/sites/:id/members/:userId - Remove a member from the site
+//
+// #### Pages (content.view, changes page.edit / page.publish / page.delete)
 //   - GET /api/v1/admin/pages - List pages
 //   - POST /api/v1/admin/pages - Create page
+//   - POST /api/v1/admin/pages/from-template/:templateId - Create a draft page from a template
 //   - GET /api/v1/admin/pages/:id - Get page
 //   - PUT /api/v1/admin/pages/:id - Update page
-//   - DELETE /api/v1/admin/pages/:id - Delete page (admin+)
-//   - PATCH /api/v1/admin/pages/:id/publish - Publish page
+//   - DELETE /api/v1/admin/pages/:id - Delete page (page.delete)
+//   - POST /api/v1/admin/pages/:id/duplicate - Copy the page with its sections and components as a draft (body: title, slug; optional)
+//   - PATCH /api/v1/admin/pages/:id/publish - Publish page (promote draft to published snapshot)
 //   - PATCH /api/v1/admin/pages/:id/unpublish - Unpublish page
+//   - PUT /api/v1/admin/pages/:id/schedule - Set scheduled publish_at/unpublish_at
+//   - DELETE /api/v1/admin/pages/:id/schedule - Clear page schedule
 //   - GET /api/v1/admin/pages/:id/sections - List page sections
 //   - POST /api/v1/admin/pages/:id/sections - Create section
+//   - GET /api/v1/admin/pages/:id/revisions - List page revisions
+//   - GET /api/v1/admin/pages/:id/revisions/diff?from=&to= - Diff two revisions
+//   - GET /api/v1/admin/pages/:id/revisions/:rev - Get revision snapshot
+//   - POST /api/v1/admin/pages/:id/revisions/:rev/restore - Restore revision
 //
-// #### Sections (editor+)
+// #### Sections (content.view, changes page.edit)
 //   - PUT /api/v1/admin/sections/:id - Update section
 //   - DELETE /api/v1/admin/sections/:id - Delete section
 //   - PATCH /api/v1/admin/sections/reorder - Reorder sections
 //   - GET /api/v1/admin/sections/:id/contents - List section contents
-//   - POST /api/v1/admin/sections/:id/contents - Upsert content
+//   - POST /api/v1/admin/sections/:id/contents - Upsert content (keys checked against the section's content schema)
 //   - POST /api/v1/admin/sections/:id/contents/bulk - Bulk upsert contents
 //
-// #### Contents (editor+)
+// #### Contents (page.edit)
 //   - DELETE /api/v1/admin/contents/:id - Delete content
 //
-// #### Features (editor+)
+// #### Templates (content.view, changes template.manage)
+//   - GET /api/v1/admin/templates - List page templates
+//   - GET /api/v1/admin/templates/presets - List built-in section presets
+//   - GET /api/v1/admin/templates/:id - Get template
+//   - POST /api/v1/admin/templates - Create template
+//   - PUT /api/v1/admin/templates/:id - Update template
+//   - DELETE /api/v1/admin/templates/:id - Delete template
+//
+// #### Features (content.view, changes component.edit)
 //   - GET /api/v1/admin/features - List features
 //   - POST /api/v1/admin/features - Create feature
 //   - PUT /api/v1/admin/features/:id - Update feature
 //   - DELETE /api/v1/admin/features/:id - Delete feature
 //
-// #### Testimonials (editor+)
+// #### Testimonials (content.view, changes component.edit)
 //   - GET /api/v1/admin/testimonials - List testimonials
 //   - POST /api/v1/admin/testimonials - Create testimonial
 //   - PUT /api/v1/admin/testimonials/:id - Update testimonial
 //   - DELETE /api/v1/admin/testimonials/:id - Delete testimonial
 //
-// #### Pricing Plans (editor+)
+// #### Pricing Plans (content.view, changes component.edit)
 //   - GET /api/v1/admin/pricing - List pricing plans
 //   - POST /api/v1/admin/pricing - Create pricing plan
 //   - PUT /api/v1/admin/pricing/:id - Update pricing plan
 //   - DELETE /api/v1/admin/pricing/:id - Delete pricing plan
 //
-// #### FAQs (editor+)
+// #### FAQs (content.view, changes component.edit)
 //   - GET /api/v1/admin/faqs - List FAQs
 //   - POST /api/v1/admin/faqs - Create FAQ
 //   - PUT /api/v1/admin/faqs/:id - Update FAQ
 //   - DELETE /api/v1/admin/faqs/:id - Delete FAQ
 //
-// #### Navigation (editor+)
+// #### Navigation (content.view, changes component.edit)
 //   - GET /api/v1/admin/navigation - List navigation menus
 //   - POST /api/v1/admin/navigation/:menuId/items - Create navigation item
 //   - PUT /api/v1/admin/navigation/items/:id - Update navigation item
 //   - DELETE /api/v1/admin/navigation/items/:id - Delete navigation item
 //
-// #### Media (editor+)
+// #### Media (content.view, changes media.upload / media.delete)
 //   - GET /api/v1/admin/media - List media files
-//   - POST /api/v1/admin/media/upload - Upload media file
+//   - POST /api/v1/admin/media/upload - Upload media file; the type is detected from the content, JPEG metadata is stripped, SVGs are sanitized, and images get dimensions, a thumbnail and responsive variants
 //   - PUT /api/v1/admin/media/:id - Update media metadata
-//   - DELETE /api/v1/admin/media/:id - Delete media file
+//   - DELETE /api/v1/admin/media/:id - Delete media file and, unless still referenced, the stored object and its variants
 //
-// #### Users (admin+)
+// #### Media files (STORAGE_DRIVER=local)
+//   - GET /media/*key - Serve an uploaded file (auth required unless STORAGE_LOCAL_PUBLIC)
+//
+// #### Image transforms
+//   - GET /img/:mediaId?w=&h=&fit=&fmt=&q= - Resized, cropped or converted image; sizes limited to the site's allowlist (auth required unless STORAGE_LOCAL_PUBLIC)
+//
+// #### Users (user.manage)
 //   - GET /api/v1/admin/users - List users
 //   - POST /api/v1/admin/users - Create user
 //   - GET /api/v1/admin/users/:id - Get user
 //   - PUT /api/v1/admin/users/:id - Update user
-//   - DELETE /api/v1/admin/users/:id - Delete user (super_admin only)
-//
-// #### Audit Logs (admin+)
+//   - DELETE /api/v1/admin/users/:id - Delete user (user.delete)
+//   - GET /api/v1/admin/users/:id/sessions - List active sessions of a user
+//   - DELETE /api/v1/admin/users/:id/sessions - Force logout of a user everywhere
+//   - DELETE /api/v1/admin/users/:id/sessions/:sessionId - Revoke one session of a user
+//   - POST /api/v1/admin/users/invite - Invite a user by email
+//   - GET /api/v1/admin/users/invitations - List pending invitations
+//   - POST /api/v1/admin/users/invitations/:id/resend - Resend an invitation with a new link
+//   - DELETE /api/v1/admin/users/invitations/:id - Revoke an invitation
+//
+// #### Audit Logs (audit.view)
 //   - GET /api/v1/admin/audit-logs - List audit logs
 //
+// #### Two-Factor Policies (security.manage)
+//   - GET /api/v1/admin/2fa/policies - Two-factor requirement per role
+//   - PUT /api/v1/admin/2fa/policies/:role - Require two-factor authentication for a role
+//
+// #### Roles (user.manage, changes role.manage)
+//   - GET /api/v1/admin/roles - List roles with their permissions
+//   - GET /api/v1/admin/roles/permissions - List every permission
+//   - GET /api/v1/admin/roles/:id - Get role
+//   - POST /api/v1/admin/roles - Create a custom role
+//   - PUT /api/v1/admin/roles/:id - Update the description or permissions of a role
+//   - DELETE /api/v1/admin/roles/:id - Delete a custom role no user has
+//
+// #### API Keys (apikey.manage)
+//   - GET /api/v1/admin/api-keys - List API keys (filter by user_id, site_id)
+//   - POST /api/v1/admin/api-keys - Create an API key, returned once
+//   - DELETE /api/v1/admin/api-keys/:id - Revoke an API key
+//
 // ## Response Format
 //
 // All responses follow this structure:
@@ -140,9 +235,16 @@
 //	  "total_pages": 5
 //	}
 //
-// ## Roles
+// ## Roles and Permissions
+//
+// Each route requires a permission of the caller's role. The built-in roles:
+//
+//   - editor: Can view, edit and publish content, and upload and delete media
+//   - admin: Editor permissions plus page deletion, sites, templates, users,
+//     API keys, two-factor policies and audit logs
+//   - super_admin: Every permission, including deleting sites and users and
+//     managing roles
 //
-//   - editor: Can manage content (pages, sections, features, etc.)
-//   - admin: Can manage users, sites, and all content
-//   - super_admin: Full access including delete operations
+// Custom roles bundle any permissions. Roles without site.all only reach the
+// sites they are members of, with the membership role's permissions added.
 package docs
@@ -13,6 +13,7 @@ require (
 	github.com/spf13/viper v1.19.0
 	github.com/go-playground/validator/v10 v10.22.0
 	golang.org/x/crypto v0.25.0
+	golang.org/x/image v0.18.0
 	golang.org/x/time v0.5.0
 )
 
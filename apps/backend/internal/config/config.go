@@ -2,6 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,10 +19,21 @@ type Config struct {
 	JWT       JWTConfig
 	CORS      CORSConfig
 	Supabase  SupabaseConfig
+	Storage   StorageConfig
+	Media     MediaConfig
 	RateLimit RateLimitConfig
 	Log       LogConfig
 	Security  SecurityConfig
 	Cookie    CookieConfig
+	Scheduler SchedulerConfig
+	Render    RenderConfig
+	Mail      MailConfig
+	Scan      ScanConfig
+	Reset     PasswordResetConfig
+	Invite    InvitationConfig
+	OIDC      OIDCConfig
+	TwoFactor TwoFactorConfig
+	Sessions  SessionConfig
 }
 
 // AppConfig holds application-level configuration
@@ -45,6 +60,16 @@ type JWTConfig struct {
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
 	Issuer        string
+	// Algorithm signs access tokens: HS256 (AccessSecret), RS256 or EdDSA
+	Algorithm string
+	// KeyDir holds <kid>.pem keys for RS256/EdDSA; public-only files are
+	// retired keys that still verify
+	KeyDir string
+	// PrivateKey is an inline PEM key, an alternative to KeyDir
+	PrivateKey string
+	// ActiveKeyID picks the signing key; defaults to the inline key or the
+	// private key in KeyDir whose name sorts last
+	ActiveKeyID string
 }
 
 // CORSConfig holds CORS configuration
@@ -61,6 +86,48 @@ type SupabaseConfig struct {
 	StorageBucket string
 }
 
+// StorageConfig holds media storage configuration
+type StorageConfig struct {
+	// Driver is supabase (default), local or s3
+	Driver string
+	// LocalDir is where the local driver keeps files
+	LocalDir string
+	// LocalURL is the public base URL of the /media route
+	LocalURL string
+	// LocalPublic serves /media without authentication
+	LocalPublic bool
+	// S3Endpoint is the API URL of an S3-compatible store such as MinIO or R2
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	// S3PathStyle addresses objects as <endpoint>/<bucket>/<key>
+	S3PathStyle bool
+	// S3PublicURL is where the bucket is readable, e.g. a CDN
+	S3PublicURL string
+	// S3PartSize is the multipart part size; larger bodies are uploaded in parts
+	S3PartSize int64
+}
+
+// MediaConfig holds image processing configuration
+type MediaConfig struct {
+	// ThumbnailSize bounds the width and height of thumbnails
+	ThumbnailSize int
+	// ResponsiveWidths are the widths of the responsive variants, ascending
+	ResponsiveWidths []int
+	// JPEGQuality is the quality of JPEG variants, 1-100
+	JPEGQuality int
+	// MaxPixels is the largest image decoded; larger images keep no variants
+	MaxPixels int
+	// TransformSizes is the default size allowlist of /img, e.g.
+	// "480,1280,320x320"; sites override it with the image_sizes setting
+	TransformSizes string
+	// CacheDir holds transformed images, at most CacheMaxBytes of them
+	CacheDir      string
+	CacheMaxBytes int64
+}
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	Enabled      bool
@@ -81,6 +148,10 @@ type SecurityConfig struct {
 	BcryptCost       int
 	MaxUploadSize    int64
 	AllowedMimeTypes []string
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Forwarded-Host headers are believed; requests from anywhere else
+	// are taken at face value
+	TrustedProxies []netip.Prefix
 }
 
 // CookieConfig holds cookie configuration
@@ -90,6 +161,93 @@ type CookieConfig struct {
 	SameSite string
 }
 
+// SchedulerConfig holds background scheduler configuration
+type SchedulerConfig struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+}
+
+// SessionConfig holds session lifetime and housekeeping configuration
+type SessionConfig struct {
+	// CleanupInterval is how often expired refresh tokens are deleted; 0 disables it
+	CleanupInterval time.Duration
+	// AbsoluteLifetime caps a session from login on top of the sliding
+	// JWT_REFRESH_EXPIRY; 0 disables it
+	AbsoluteLifetime time.Duration
+	// ReuseGrace lets a just-rotated refresh token be sent again without
+	// revoking the session, for concurrent refreshes
+	ReuseGrace time.Duration
+}
+
+// RenderConfig holds server-side rendering configuration
+type RenderConfig struct {
+	TemplatesDir string
+}
+
+// MailConfig holds outgoing email configuration. Driver is smtp, log or file.
+type MailConfig struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+}
+
+// ScanConfig holds upload malware scanning configuration. Driver is none or
+// clamav.
+type ScanConfig struct {
+	Driver string
+	// ClamAVAddress is the clamd socket: host:port, or unix:<path>
+	ClamAVAddress string
+	ClamAVTimeout time.Duration
+}
+
+// PasswordResetConfig holds self-service password reset configuration
+type PasswordResetConfig struct {
+	URL    string
+	Expiry time.Duration
+}
+
+// InvitationConfig holds user invitation configuration
+type InvitationConfig struct {
+	URL    string
+	Expiry time.Duration
+}
+
+// OIDCConfig holds OpenID Connect single sign-on configuration
+type OIDCConfig struct {
+	Enabled      bool
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// RoleClaim is the ID token claim roles are mapped from
+	RoleClaim    string
+	RoleMappings []OIDCRoleMapping
+	DefaultRole  string
+	LinkByEmail  bool
+	StateExpiry  time.Duration
+	// SuccessURL and ErrorURL are where the callback sends the browser
+	SuccessURL string
+	ErrorURL   string
+}
+
+// OIDCRoleMapping maps a value of the role claim to a CMS role
+type OIDCRoleMapping struct {
+	Value string
+	Role  string
+}
+
+// TwoFactorConfig holds TOTP two-factor authentication configuration
+type TwoFactorConfig struct {
+	Issuer          string
+	ChallengeExpiry time.Duration
+}
+
 // Load reads configuration from environment variables and .env file
 func Load() (*Config, error) {
 	viper.SetConfigFile(".env")
@@ -122,6 +280,10 @@ func Load() (*Config, error) {
 			AccessExpiry:  viper.GetDuration("JWT_ACCESS_EXPIRY"),
 			RefreshExpiry: viper.GetDuration("JWT_REFRESH_EXPIRY"),
 			Issuer:        viper.GetString("JWT_ISSUER"),
+			Algorithm:     viper.GetString("JWT_ALGORITHM"),
+			KeyDir:        viper.GetString("JWT_KEY_DIR"),
+			PrivateKey:    viper.GetString("JWT_PRIVATE_KEY"),
+			ActiveKeyID:   viper.GetString("JWT_ACTIVE_KEY_ID"),
 		},
 		CORS: CORSConfig{
 			Origins:          strings.Split(viper.GetString("CORS_ORIGINS"), ","),
@@ -133,6 +295,30 @@ func Load() (*Config, error) {
 			ServiceKey:    viper.GetString("SUPABASE_SERVICE_KEY"),
 			StorageBucket: viper.GetString("SUPABASE_STORAGE_BUCKET"),
 		},
+		Storage: StorageConfig{
+			Driver:      viper.GetString("STORAGE_DRIVER"),
+			LocalDir:    viper.GetString("STORAGE_LOCAL_DIR"),
+			LocalURL:    viper.GetString("STORAGE_LOCAL_URL"),
+			LocalPublic: viper.GetBool("STORAGE_LOCAL_PUBLIC"),
+
+			S3Endpoint:        viper.GetString("STORAGE_S3_ENDPOINT"),
+			S3Region:          viper.GetString("STORAGE_S3_REGION"),
+			S3Bucket:          viper.GetString("STORAGE_S3_BUCKET"),
+			S3AccessKeyID:     viper.GetString("STORAGE_S3_ACCESS_KEY_ID"),
+			S3SecretAccessKey: viper.GetString("STORAGE_S3_SECRET_ACCESS_KEY"),
+			S3PathStyle:       viper.GetBool("STORAGE_S3_PATH_STYLE"),
+			S3PublicURL:       viper.GetString("STORAGE_S3_PUBLIC_URL"),
+			S3PartSize:        viper.GetInt64("STORAGE_S3_PART_SIZE"),
+		},
+		Media: MediaConfig{
+			ThumbnailSize: viper.GetInt("MEDIA_THUMBNAIL_SIZE"),
+			JPEGQuality:   viper.GetInt("MEDIA_JPEG_QUALITY"),
+			MaxPixels:     viper.GetInt("MEDIA_MAX_PIXELS"),
+
+			TransformSizes: viper.GetString("MEDIA_TRANSFORM_SIZES"),
+			CacheDir:       viper.GetString("MEDIA_CACHE_DIR"),
+			CacheMaxBytes:  viper.GetInt64("MEDIA_CACHE_MAX_BYTES"),
+		},
 		RateLimit: RateLimitConfig{
 			Enabled:      viper.GetBool("RATE_LIMIT_ENABLED"),
 			Requests:     viper.GetInt("RATE_LIMIT_REQUESTS"),
@@ -154,7 +340,78 @@ func Load() (*Config, error) {
 			Secure:   viper.GetBool("COOKIE_SECURE"),
 			SameSite: viper.GetString("COOKIE_SAME_SITE"),
 		},
+		Scheduler: SchedulerConfig{
+			Enabled:   viper.GetBool("SCHEDULER_ENABLED"),
+			Interval:  viper.GetDuration("SCHEDULER_INTERVAL"),
+			BatchSize: viper.GetInt("SCHEDULER_BATCH_SIZE"),
+		},
+		Render: RenderConfig{
+			TemplatesDir: viper.GetString("RENDER_TEMPLATES_DIR"),
+		},
+		Mail: MailConfig{
+			Driver:       viper.GetString("MAIL_DRIVER"),
+			From:         viper.GetString("MAIL_FROM"),
+			SMTPHost:     viper.GetString("SMTP_HOST"),
+			SMTPPort:     viper.GetInt("SMTP_PORT"),
+			SMTPUsername: viper.GetString("SMTP_USERNAME"),
+			SMTPPassword: viper.GetString("SMTP_PASSWORD"),
+			FileDir:      viper.GetString("MAIL_FILE_DIR"),
+		},
+		Scan: ScanConfig{
+			Driver:        viper.GetString("UPLOAD_SCANNER"),
+			ClamAVAddress: viper.GetString("CLAMAV_ADDRESS"),
+			ClamAVTimeout: viper.GetDuration("CLAMAV_TIMEOUT"),
+		},
+		Reset: PasswordResetConfig{
+			URL:    viper.GetString("PASSWORD_RESET_URL"),
+			Expiry: viper.GetDuration("PASSWORD_RESET_EXPIRY"),
+		},
+		Invite: InvitationConfig{
+			URL:    viper.GetString("INVITE_URL"),
+			Expiry: viper.GetDuration("INVITE_EXPIRY"),
+		},
+		OIDC: OIDCConfig{
+			Enabled:      viper.GetBool("OIDC_ENABLED"),
+			IssuerURL:    viper.GetString("OIDC_ISSUER_URL"),
+			ClientID:     viper.GetString("OIDC_CLIENT_ID"),
+			ClientSecret: viper.GetString("OIDC_CLIENT_SECRET"),
+			RedirectURL:  viper.GetString("OIDC_REDIRECT_URL"),
+			Scopes:       strings.Fields(viper.GetString("OIDC_SCOPES")),
+			RoleClaim:    viper.GetString("OIDC_ROLE_CLAIM"),
+			DefaultRole:  viper.GetString("OIDC_DEFAULT_ROLE"),
+			LinkByEmail:  viper.GetBool("OIDC_LINK_BY_EMAIL"),
+			StateExpiry:  viper.GetDuration("OIDC_STATE_EXPIRY"),
+			SuccessURL:   viper.GetString("OIDC_SUCCESS_URL"),
+			ErrorURL:     viper.GetString("OIDC_ERROR_URL"),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer:          viper.GetString("TOTP_ISSUER"),
+			ChallengeExpiry: viper.GetDuration("TWO_FACTOR_CHALLENGE_EXPIRY"),
+		},
+		Sessions: SessionConfig{
+			CleanupInterval:  viper.GetDuration("SESSION_CLEANUP_INTERVAL"),
+			AbsoluteLifetime: viper.GetDuration("SESSION_ABSOLUTE_LIFETIME"),
+			ReuseGrace:       viper.GetDuration("REFRESH_REUSE_GRACE"),
+		},
+	}
+
+	mappings, err := parseRoleMappings(viper.GetString("OIDC_ROLE_MAPPING"))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.OIDC.RoleMappings = mappings
+
+	widths, err := parseWidths(viper.GetString("MEDIA_RESPONSIVE_WIDTHS"))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
+	cfg.Media.ResponsiveWidths = widths
+
+	proxies, err := parseTrustedProxies(viper.GetString("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.Security.TrustedProxies = proxies
 
 	if err := cfg.validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -177,9 +434,120 @@ func (c *Config) validate() error {
 	if c.JWT.AccessSecret == c.JWT.RefreshSecret {
 		return fmt.Errorf("JWT_ACCESS_SECRET and JWT_REFRESH_SECRET must be different")
 	}
+	switch c.JWT.Algorithm {
+	case "HS256":
+	case "RS256", "EdDSA":
+		if c.JWT.KeyDir == "" && c.JWT.PrivateKey == "" {
+			return fmt.Errorf("JWT_KEY_DIR or JWT_PRIVATE_KEY is required when JWT_ALGORITHM is %s", c.JWT.Algorithm)
+		}
+	default:
+		return fmt.Errorf("JWT_ALGORITHM must be HS256, RS256 or EdDSA")
+	}
+	if c.Mail.Driver == "smtp" && c.Mail.SMTPHost == "" {
+		return fmt.Errorf("SMTP_HOST is required when MAIL_DRIVER is smtp")
+	}
+	switch c.Scan.Driver {
+	case "none":
+	case "clamav":
+		if c.Scan.ClamAVAddress == "" {
+			return fmt.Errorf("CLAMAV_ADDRESS is required when UPLOAD_SCANNER is clamav")
+		}
+	default:
+		return fmt.Errorf("UPLOAD_SCANNER must be none or clamav")
+	}
+	switch c.Storage.Driver {
+	case "supabase", "local":
+	case "s3":
+		if c.Storage.S3Endpoint == "" || c.Storage.S3Bucket == "" {
+			return fmt.Errorf("STORAGE_S3_ENDPOINT and STORAGE_S3_BUCKET are required when STORAGE_DRIVER is s3")
+		}
+		if c.Storage.S3AccessKeyID == "" || c.Storage.S3SecretAccessKey == "" {
+			return fmt.Errorf("STORAGE_S3_ACCESS_KEY_ID and STORAGE_S3_SECRET_ACCESS_KEY are required when STORAGE_DRIVER is s3")
+		}
+		if c.Storage.S3PartSize < 5<<20 {
+			return fmt.Errorf("STORAGE_S3_PART_SIZE must be at least 5242880 (5 MiB)")
+		}
+	default:
+		return fmt.Errorf("STORAGE_DRIVER must be supabase, local or s3")
+	}
+	if c.Media.ThumbnailSize < 16 {
+		return fmt.Errorf("MEDIA_THUMBNAIL_SIZE must be at least 16")
+	}
+	if c.Media.JPEGQuality < 1 || c.Media.JPEGQuality > 100 {
+		return fmt.Errorf("MEDIA_JPEG_QUALITY must be between 1 and 100")
+	}
+	if c.Media.MaxPixels < 1 {
+		return fmt.Errorf("MEDIA_MAX_PIXELS must be positive")
+	}
+	if c.Media.CacheDir == "" || c.Media.CacheMaxBytes < 1<<20 {
+		return fmt.Errorf("MEDIA_CACHE_DIR is required and MEDIA_CACHE_MAX_BYTES must be at least 1048576 (1 MiB)")
+	}
+	if c.OIDC.Enabled && (c.OIDC.IssuerURL == "" || c.OIDC.ClientID == "") {
+		return fmt.Errorf("OIDC_ISSUER_URL and OIDC_CLIENT_ID are required when OIDC_ENABLED is true")
+	}
 	return nil
 }
 
+// parseRoleMappings parses OIDC_ROLE_MAPPING, a comma separated list of
+// claim value=role pairs in priority order, e.g. "cms-admins=admin,staff=editor"
+func parseRoleMappings(raw string) ([]OIDCRoleMapping, error) {
+	var mappings []OIDCRoleMapping
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		value, role, ok := strings.Cut(pair, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !ok || value == "" || role == "" {
+			return nil, fmt.Errorf("OIDC_ROLE_MAPPING entry %q must be value=role", pair)
+		}
+		mappings = append(mappings, OIDCRoleMapping{Value: value, Role: role})
+	}
+	return mappings, nil
+}
+
+// parseTrustedProxies parses TRUSTED_PROXIES, a comma separated list of IP
+// addresses and CIDR ranges, e.g. "10.0.0.0/8,127.0.0.1"
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(field); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("TRUSTED_PROXIES entry %q must be an IP address or CIDR range", field)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// parseWidths parses MEDIA_RESPONSIVE_WIDTHS, a comma separated list of
+// pixel widths, e.g. "480,768,1280". The result is sorted without duplicates.
+func parseWidths(raw string) ([]int, error) {
+	var widths []int
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		width, err := strconv.Atoi(field)
+		if err != nil || width < 16 || width > 8192 {
+			return nil, fmt.Errorf("MEDIA_RESPONSIVE_WIDTHS entry %q must be a width between 16 and 8192", field)
+		}
+		widths = append(widths, width)
+	}
+	sort.Ints(widths)
+	return slices.Compact(widths), nil
+}
+
 // IsProduction returns true if the app is running in production mode
 func (c *Config) IsProduction() bool {
 	return c.App.Env == "production"
@@ -204,10 +572,25 @@ func setDefaults() {
 	viper.SetDefault("JWT_ACCESS_EXPIRY", "15m")
 	viper.SetDefault("JWT_REFRESH_EXPIRY", "168h")
 	viper.SetDefault("JWT_ISSUER", "landing-cms-api")
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
 
 	viper.SetDefault("CORS_ALLOW_CREDENTIALS", true)
 
 	viper.SetDefault("SUPABASE_STORAGE_BUCKET", "media")
+	viper.SetDefault("STORAGE_DRIVER", "supabase")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "./storage/media")
+	viper.SetDefault("STORAGE_LOCAL_URL", "http://localhost:8080/media")
+	viper.SetDefault("STORAGE_LOCAL_PUBLIC", true)
+	viper.SetDefault("STORAGE_S3_REGION", "us-east-1")
+	viper.SetDefault("STORAGE_S3_PATH_STYLE", true)
+	viper.SetDefault("STORAGE_S3_PART_SIZE", 16777216) // 16MB
+	viper.SetDefault("MEDIA_THUMBNAIL_SIZE", 320)
+	viper.SetDefault("MEDIA_RESPONSIVE_WIDTHS", "480,768,1280,1920")
+	viper.SetDefault("MEDIA_JPEG_QUALITY", 82)
+	viper.SetDefault("MEDIA_MAX_PIXELS", 40000000)
+	viper.SetDefault("MEDIA_TRANSFORM_SIZES", "160x160,320,320x320,480,768,1280,1920")
+	viper.SetDefault("MEDIA_CACHE_DIR", "./storage/image-cache")
+	viper.SetDefault("MEDIA_CACHE_MAX_BYTES", 268435456) // 256MB
 
 	viper.SetDefault("RATE_LIMIT_ENABLED", true)
 	viper.SetDefault("RATE_LIMIT_REQUESTS", 100)
@@ -221,8 +604,41 @@ func setDefaults() {
 	viper.SetDefault("BCRYPT_COST", 12)
 	viper.SetDefault("MAX_UPLOAD_SIZE", 10485760) // 10MB
 	viper.SetDefault("ALLOWED_MIME_TYPES", "image/jpeg,image/png,image/gif,image/webp,image/svg+xml,video/mp4,application/pdf")
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("UPLOAD_SCANNER", "none")
+	viper.SetDefault("CLAMAV_ADDRESS", "127.0.0.1:3310")
+	viper.SetDefault("CLAMAV_TIMEOUT", "30s")
 
 	viper.SetDefault("COOKIE_DOMAIN", "localhost")
 	viper.SetDefault("COOKIE_SECURE", false)
 	viper.SetDefault("COOKIE_SAME_SITE", "strict")
+
+	viper.SetDefault("SCHEDULER_ENABLED", true)
+	viper.SetDefault("SCHEDULER_INTERVAL", "15s")
+	viper.SetDefault("SCHEDULER_BATCH_SIZE", 50)
+
+	viper.SetDefault("MAIL_DRIVER", "log")
+	viper.SetDefault("MAIL_FROM", "no-reply@localhost")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("MAIL_FILE_DIR", "./tmp/mail")
+
+	viper.SetDefault("PASSWORD_RESET_URL", "http://localhost:3001/reset-password")
+	viper.SetDefault("PASSWORD_RESET_EXPIRY", "1h")
+	viper.SetDefault("INVITE_URL", "http://localhost:3001/accept-invite")
+	viper.SetDefault("INVITE_EXPIRY", "72h")
+
+	viper.SetDefault("OIDC_ENABLED", false)
+	viper.SetDefault("OIDC_REDIRECT_URL", "http://localhost:8080/api/v1/auth/oidc/callback")
+	viper.SetDefault("OIDC_SCOPES", "openid email profile")
+	viper.SetDefault("OIDC_ROLE_CLAIM", "groups")
+	viper.SetDefault("OIDC_LINK_BY_EMAIL", true)
+	viper.SetDefault("OIDC_STATE_EXPIRY", "10m")
+	viper.SetDefault("OIDC_SUCCESS_URL", "http://localhost:3001/admin")
+	viper.SetDefault("OIDC_ERROR_URL", "http://localhost:3001/login")
+
+	viper.SetDefault("TOTP_ISSUER", "Landing CMS")
+	viper.SetDefault("TWO_FACTOR_CHALLENGE_EXPIRY", "5m")
+	viper.SetDefault("SESSION_CLEANUP_INTERVAL", "1h")
+	viper.SetDefault("SESSION_ABSOLUTE_LIFETIME", "720h")
+	viper.SetDefault("REFRESH_REUSE_GRACE", "10s")
 }
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyPrefix starts every API key so it can be told apart from a JWT
+const APIKeyPrefix = "cms_"
+
+// APIKeyScope grants read or write access to one resource
+type APIKeyScope string
+
+// Known resources an API key can be scoped to. Sections and contents belong
+// to pages; features, testimonials, pricing, FAQs and navigation are components.
+const (
+	ScopeResourceSites      = "sites"
+	ScopeResourcePages      = "pages"
+	ScopeResourceComponents = "components"
+	ScopeResourceMedia      = "media"
+	ScopeResourceTemplates  = "templates"
+)
+
+// ScopeResources lists the resources in the order they are documented
+func ScopeResources() []string {
+	return []string{
+		ScopeResourceSites,
+		ScopeResourcePages,
+		ScopeResourceComponents,
+		ScopeResourceMedia,
+		ScopeResourceTemplates,
+	}
+}
+
+// ReadScope returns the resource:read scope
+func ReadScope(resource string) APIKeyScope {
+	return APIKeyScope(resource + ":read")
+}
+
+// WriteScope returns the resource:write scope
+func WriteScope(resource string) APIKeyScope {
+	return APIKeyScope(resource + ":write")
+}
+
+// IsValid reports whether the scope names a known resource and access level
+func (s APIKeyScope) IsValid() bool {
+	resource, access, ok := strings.Cut(string(s), ":")
+	if !ok || (access != "read" && access != "write") {
+		return false
+	}
+	for _, r := range ScopeResources() {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a long-lived credential for machine clients. It acts with the
+// current role of its owner, limited to its scopes and, when SiteID is set,
+// to one site. Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID         uuid.UUID   `db:"id" json:"id"`
+	UserID     uuid.UUID   `db:"user_id" json:"user_id"`
+	SiteID     *uuid.UUID  `db:"site_id" json:"site_id"`
+	Name       string      `db:"name" json:"name"`
+	KeyPrefix  string      `db:"key_prefix" json:"key_prefix"`
+	KeyHash    string      `db:"key_hash" json:"-"`
+	Scopes     StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time  `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time  `db:"last_used_at" json:"last_used_at"`
+	LastUsedIP *string     `db:"last_used_ip" json:"last_used_ip"`
+	RevokedAt  *time.Time  `db:"revoked_at" json:"revoked_at"`
+	CreatedBy  *uuid.UUID  `db:"created_by" json:"created_by"`
+	CreatedAt  time.Time   `db:"created_at" json:"created_at"`
+}
+
+// IsValid reports whether the key is neither revoked nor expired
+func (k *APIKey) IsValid() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+// HasScope reports whether the key carries the scope. Write access implies read.
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	resource, access, _ := strings.Cut(string(scope), ":")
+	for _, s := range k.Scopes {
+		if s == string(scope) || (access == "read" && s == string(WriteScope(resource))) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyPrincipal is the identity an API key authenticates as
+type APIKeyPrincipal struct {
+	Key  *APIKey
+	User *User
+}
+
+// CreatedAPIKey is returned once when a key is created. Key is the only copy
+// of the secret.
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyInput holds data for creating an API key. UserID defaults to the
+// caller.
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" validate:"required,min=1,max=255"`
+	UserID    *uuid.UUID `json:"user_id"`
+	SiteID    *uuid.UUID `json:"site_id"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyFilter holds filter parameters for listing API keys
+type APIKeyFilter struct {
+	UserID *uuid.UUID `form:"user_id"`
+	SiteID *uuid.UUID `form:"site_id"`
+}
+
+// APIKeyError lists why an API key could not be created. It matches
+// ErrValidation.
+type APIKeyError struct {
+	Problems []string
+}
+
+// Error implements error
+func (e *APIKeyError) Error() string {
+	return ErrValidation.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Is makes errors.Is(err, ErrValidation) match
+func (e *APIKeyError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// SiteBundleVersion is the bundle format written by this version. Bundles with
+// another version are rejected on import.
+const SiteBundleVersion = 1
+
+// SiteBundle is a portable JSON document holding a site and all of its content.
+// IDs are those of the source database; they only link records within the
+// bundle and are replaced on import.
+type SiteBundle struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Site       *Site          `json:"site"`
+	Settings   []*SiteSetting `json:"settings"`
+	Pages      []*Page        `json:"pages"`
+	// Components are flattened into features, testimonials, pricing_plans and faqs
+	ComponentSet
+	NavigationMenus []*NavigationMenu `json:"navigation_menus"`
+	Media           []*Media          `json:"media"`
+}
+
+// ImportSiteBundleInput holds the options of a bundle import. Name, slug and
+// domain override the bundle's site, e.g. when the slug is taken.
+type ImportSiteBundleInput struct {
+	Name   *string `form:"name" validate:"omitempty,min=1,max=255"`
+	Slug   *string `form:"slug" validate:"omitempty,min=1,max=255"`
+	Domain *string `form:"domain" validate:"omitempty,hostname"`
+	DryRun bool    `form:"dry_run"`
+}
+
+// SiteBundleCounts counts the records of a bundle by type
+type SiteBundleCounts struct {
+	Settings        int `json:"settings"`
+	Pages           int `json:"pages"`
+	Sections        int `json:"sections"`
+	Contents        int `json:"contents"`
+	Features        int `json:"features"`
+	Testimonials    int `json:"testimonials"`
+	PricingPlans    int `json:"pricing_plans"`
+	FAQs            int `json:"faqs"`
+	NavigationMenus int `json:"navigation_menus"`
+	NavigationItems int `json:"navigation_items"`
+	Media           int `json:"media"`
+}
+
+// SiteBundleImportResult describes an import. On a dry run nothing was saved
+// and the site's ID is the one it would have had.
+type SiteBundleImportResult struct {
+	DryRun bool             `json:"dry_run"`
+	Site   *Site            `json:"site"`
+	Counts SiteBundleCounts `json:"counts"`
+}
+
+// ErrInvalidBundle is matched by every BundleError
+var ErrInvalidBundle = errors.New("invalid site bundle")
+
+// BundleError lists the problems that prevent a bundle from being imported
+type BundleError struct {
+	Problems []string
+}
+
+// Error implements error
+func (e *BundleError) Error() string {
+	return ErrInvalidBundle.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Is makes errors.Is(err, ErrInvalidBundle) match
+func (e *BundleError) Is(target error) bool {
+	return target == ErrInvalidBundle
+}
@@ -162,5 +162,6 @@ var (
 	ErrForbidden         = errors.New("forbidden")
 	ErrInvalidToken      = errors.New("invalid or expired token")
 	ErrTokenRevoked      = errors.New("token has been revoked")
+	ErrTokenReused       = errors.New("refresh token reuse detected")
 	ErrValidation        = errors.New("validation error")
 )
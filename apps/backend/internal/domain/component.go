@@ -126,6 +126,27 @@ type NavigationItem struct {
 	Children    []*NavigationItem `db:"-" json:"children,omitempty"`
 }
 
+// BuildNavigationTree nests flat menu items under their parents and returns the roots
+func BuildNavigationTree(items []*NavigationItem) []*NavigationItem {
+	itemMap := make(map[uuid.UUID]*NavigationItem)
+	for _, item := range items {
+		itemMap[item.ID] = item
+		item.Children = []*NavigationItem{}
+	}
+
+	var roots []*NavigationItem
+	for _, item := range items {
+		if item.ParentID == nil {
+			roots = append(roots, item)
+		} else {
+			if parent, ok := itemMap[*item.ParentID]; ok {
+				parent.Children = append(parent.Children, item)
+			}
+		}
+	}
+	return roots
+}
+
 // Media represents an uploaded media file
 type Media struct {
 	ID           uuid.UUID  `db:"id" json:"id"`
@@ -172,9 +193,20 @@ type AuditLog struct {
 	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
 }
 
+// ComponentSet groups features, testimonials, pricing plans and FAQs, e.g.
+// those attached to the sections of one page
+type ComponentSet struct {
+	Features     []*Feature     `json:"features"`
+	Testimonials []*Testimonial `json:"testimonials"`
+	PricingPlans []*PricingPlan `json:"pricing_plans"`
+	FAQs         []*FAQ         `json:"faqs"`
+}
+
 // Component filter types
 type ComponentFilter struct {
-	SiteID    *uuid.UUID
+	SiteID *uuid.UUID
+	// SiteIDs limits the result to these sites; nil means no limit
+	SiteIDs   []uuid.UUID `form:"-"`
 	SectionID *uuid.UUID
 	IsActive  *bool
 	Search    *string
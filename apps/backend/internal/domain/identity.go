@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to their account at an OpenID provider. Issuer
+// and Subject identify the account; the email is only recorded.
+type UserIdentity struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	UserID      uuid.UUID  `db:"user_id" json:"user_id"`
+	Issuer      string     `db:"issuer" json:"issuer"`
+	Subject     string     `db:"subject" json:"subject"`
+	Email       *string    `db:"email" json:"email"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	LastLoginAt *time.Time `db:"last_login_at" json:"last_login_at"`
+}
+
+// OIDCLoginRequest is a started single sign-on login. Only the hash of the
+// state is stored; the nonce and PKCE verifier never leave the server.
+type OIDCLoginRequest struct {
+	ID           uuid.UUID `db:"id"`
+	StateHash    string    `db:"state_hash"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// OIDCLoginStart is where to send the browser to sign in at the provider.
+// State must also be kept in the browser, e.g. a cookie, and given back with
+// the callback.
+type OIDCLoginStart struct {
+	AuthURL   string
+	State     string
+	ExpiresAt time.Time
+}
+
+// OIDCCallbackInput holds the parameters the provider redirected back with
+// and the state the browser kept
+type OIDCCallbackInput struct {
+	Code         string `form:"code"`
+	State        string `form:"state"`
+	Error        string `form:"error"`
+	BrowserState string `form:"-"`
+}
+
+// OIDCRoleMapping gives Role to users whose role claim contains Value
+type OIDCRoleMapping struct {
+	Value string
+	Role  UserRole
+}
+
+var (
+	// ErrSSODisabled is returned when single sign-on is not configured
+	ErrSSODisabled = errors.New("single sign-on is not enabled")
+	// ErrSSOFailed is returned when the provider refused the login or its
+	// response could not be verified
+	ErrSSOFailed = errors.New("single sign-on failed")
+	// ErrPasswordLoginDisabled is returned by a password login of a user who
+	// must sign in with single sign-on
+	ErrPasswordLoginDisabled = errors.New("password login is disabled for this account")
+)
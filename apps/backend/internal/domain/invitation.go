@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation is a single-use link that lets an invited user choose their
+// password. The user is created with StatusPending when invited and becomes
+// active on acceptance. Only the hash of the token is stored; resending
+// replaces it.
+type Invitation struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     uuid.UUID  `db:"user_id" json:"user_id"`
+	Email      string     `db:"email" json:"email"`
+	FullName   string     `db:"full_name" json:"full_name"`
+	Role       UserRole   `db:"role" json:"role"`
+	TokenHash  string     `db:"token_hash" json:"-"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	SentAt     time.Time  `db:"sent_at" json:"sent_at"`
+	SendCount  int        `db:"send_count" json:"send_count"`
+	AcceptedAt *time.Time `db:"accepted_at" json:"accepted_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at"`
+	InvitedBy  *uuid.UUID `db:"invited_by" json:"invited_by"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// IsPending reports whether the invitation was neither accepted nor revoked.
+// A pending invitation may have expired; resending renews it.
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil
+}
+
+// IsValid reports whether the invitation can still be accepted
+func (i *Invitation) IsValid() bool {
+	return i.IsPending() && time.Now().Before(i.ExpiresAt)
+}
+
+// InviteUserInput holds data for inviting a user
+type InviteUserInput struct {
+	Email    string   `json:"email" validate:"required,email"`
+	FullName string   `json:"full_name" validate:"required,min=2,max=255"`
+	Role     UserRole `json:"role" validate:"required,max=50"`
+}
+
+// AcceptInvitationInput holds data for accepting an invitation
+type AcceptInvitationInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
@@ -0,0 +1,236 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidImage is returned for an upload with an image MIME type whose
+	// content cannot be decoded
+	ErrInvalidImage = errors.New("file is not a valid image")
+	// ErrImageSizeNotAllowed is returned for a transform to a size missing
+	// from the site's allowlist
+	ErrImageSizeNotAllowed = errors.New("image size not allowed")
+	// ErrImageNotTransformable is returned for a transform of media that is
+	// not a still JPEG, PNG or GIF image within the pixel limit
+	ErrImageNotTransformable = errors.New("image cannot be transformed")
+	// ErrUploadRejected is matched by every UploadError
+	ErrUploadRejected = errors.New("upload rejected")
+)
+
+// Upload rejection codes, returned to the client with an UploadError
+const (
+	// UploadTypeNotAllowed: the detected type is not in ALLOWED_MIME_TYPES
+	UploadTypeNotAllowed = "type_not_allowed"
+	// UploadTypeMismatch: the file extension is not one of the detected type
+	UploadTypeMismatch = "type_mismatch"
+	// UploadInvalidFile: the content is damaged or cannot be sanitized
+	UploadInvalidFile = "invalid_file"
+	// UploadMalwareDetected: the malware scanner reported the file
+	UploadMalwareDetected = "malware_detected"
+)
+
+// UploadError explains why an uploaded file was refused. It matches
+// ErrUploadRejected.
+type UploadError struct {
+	Code    string
+	Message string
+}
+
+// Error implements error
+func (e *UploadError) Error() string {
+	return ErrUploadRejected.Error() + ": " + e.Message
+}
+
+// Is makes errors.Is(err, ErrUploadRejected) match
+func (e *UploadError) Is(target error) bool {
+	return target == ErrUploadRejected
+}
+
+// ImageVariant is a resized copy of an uploaded image, stored next to it and
+// listed under "variants" in the media metadata
+type ImageVariant struct {
+	// Kind is "thumbnail" or "responsive"
+	Kind     string `json:"kind"`
+	Key      string `json:"key"`
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	Size     int64  `json:"size"`
+	// WebPSkipped says why the variant has no WebP copy, e.g. WebPSkippedLarger
+	WebPSkipped string `json:"webp_skipped,omitempty"`
+}
+
+// Image variant kinds
+const (
+	ImageVariantThumbnail  = "thumbnail"
+	ImageVariantResponsive = "responsive"
+)
+
+// WebPSkippedLarger means the lossless WebP copy came out larger than the
+// variant and was not stored
+const WebPSkippedLarger = "larger"
+
+// ResponsiveImage is the srcset data of an image, ready for a <picture>
+// element: one source per MIME type, preferred types first, and the original
+// as the fallback
+type ResponsiveImage struct {
+	URL          string        `json:"url"`
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	ThumbnailURL *string       `json:"thumbnail_url,omitempty"`
+	Sources      []ImageSource `json:"sources"`
+}
+
+// ImageSource is a srcset of one MIME type
+type ImageSource struct {
+	Type   string `json:"type"`
+	Srcset string `json:"srcset"`
+}
+
+// ImageVariants returns the variants listed in the metadata
+func (m *Media) ImageVariants() []ImageVariant {
+	raw, ok := m.Metadata["variants"]
+	if !ok {
+		return nil
+	}
+	// Metadata read from the database holds decoded JSON, not the structs
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var variants []ImageVariant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil
+	}
+	return variants
+}
+
+// SetImageVariants lists the variants in the metadata
+func (m *Media) SetImageVariants(variants []ImageVariant) {
+	if m.Metadata == nil {
+		m.Metadata = JSONMap{}
+	}
+	m.Metadata["variants"] = variants
+}
+
+// StoredKeys returns the storage keys of the file and its variants
+func (m *Media) StoredKeys() []string {
+	keys := []string{m.FilePath}
+	for _, v := range m.ImageVariants() {
+		keys = append(keys, v.Key)
+	}
+	return keys
+}
+
+// ResponsiveImage returns the srcset data of an image with responsive
+// variants, or nil when it has none
+func (m *Media) ResponsiveImage() *ResponsiveImage {
+	if m.Width == nil || m.Height == nil {
+		return nil
+	}
+	byType := map[string][]ImageVariant{}
+	for _, v := range m.ImageVariants() {
+		if v.Kind == ImageVariantResponsive {
+			byType[v.MimeType] = append(byType[v.MimeType], v)
+		}
+	}
+	if len(byType) == 0 {
+		return nil
+	}
+	// The original is the widest candidate of its own type
+	byType[m.MimeType] = append(byType[m.MimeType], ImageVariant{URL: m.PublicURL, MimeType: m.MimeType, Width: *m.Width})
+
+	img := &ResponsiveImage{URL: m.PublicURL, Width: *m.Width, Height: *m.Height, ThumbnailURL: m.ThumbnailURL}
+	for mimeType, variants := range byType {
+		sort.Slice(variants, func(i, j int) bool { return variants[i].Width < variants[j].Width })
+		candidates := make([]string, len(variants))
+		for i, v := range variants {
+			candidates[i] = fmt.Sprintf("%s %dw", v.URL, v.Width)
+		}
+		img.Sources = append(img.Sources, ImageSource{Type: mimeType, Srcset: strings.Join(candidates, ", ")})
+	}
+	// WebP first, as browsers use the first source they support
+	sort.Slice(img.Sources, func(i, j int) bool {
+		iWebP, jWebP := img.Sources[i].Type == "image/webp", img.Sources[j].Type == "image/webp"
+		if iWebP != jWebP {
+			return iWebP
+		}
+		return img.Sources[i].Type < img.Sources[j].Type
+	})
+	return img
+}
+
+// ImageSize is a transform size; a zero dimension is not limited
+type ImageSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// String formats the size as ParseImageSizes reads it
+func (s ImageSize) String() string {
+	switch {
+	case s.Height == 0:
+		return strconv.Itoa(s.Width)
+	case s.Width == 0:
+		return "x" + strconv.Itoa(s.Height)
+	}
+	return fmt.Sprintf("%dx%d", s.Width, s.Height)
+}
+
+// ParseImageSizes parses a comma separated size allowlist such as
+// "480,1280,x600,320x320": a width, a height after an "x", or both.
+// Dimensions are 1 to 8192 pixels.
+func ParseImageSizes(raw string) ([]ImageSize, error) {
+	var sizes []ImageSize
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		w, h, hasX := strings.Cut(strings.ToLower(field), "x")
+		var size ImageSize
+		var err error
+		if w != "" {
+			size.Width, err = strconv.Atoi(w)
+		}
+		if err == nil && h != "" {
+			size.Height, err = strconv.Atoi(h)
+		}
+		if err != nil || (w == "" && h == "") || (hasX && h == "") || size.Width < 0 || size.Width > 8192 ||
+			size.Height < 0 || size.Height > 8192 || (w != "" && size.Width == 0) || (h != "" && size.Height == 0) {
+			return nil, fmt.Errorf("image size %q must be WIDTH, xHEIGHT or WIDTHxHEIGHT, 1 to 8192 pixels", field)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+// ImageTransform describes a resized, cropped or converted copy of an image
+type ImageTransform struct {
+	Width  int
+	Height int
+	// Fit is "contain" (default) or "cover"
+	Fit string
+	// Format is "jpeg", "png", "webp" or empty to negotiate: WebP when
+	// AcceptWebP and smaller, else the format of the original
+	Format     string
+	Quality    int
+	AcceptWebP bool
+}
+
+// TransformedImage is the result of an ImageTransform
+type TransformedImage struct {
+	Data        []byte
+	ContentType string
+	// ETag is a strong entity tag derived from the content
+	ETag string
+	// Negotiated reports whether the format depended on AcceptWebP
+	Negotiated bool
+}
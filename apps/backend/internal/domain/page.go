@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -87,6 +88,8 @@ type Page struct {
 	Template     *string    `db:"template" json:"template"`
 	SortOrder    int        `db:"sort_order" json:"sort_order"`
 	PublishedAt  *time.Time `db:"published_at" json:"published_at"`
+	PublishAt    *time.Time `db:"publish_at" json:"publish_at"`
+	UnpublishAt  *time.Time `db:"unpublish_at" json:"unpublish_at"`
 	Metadata     JSONMap    `db:"metadata" json:"metadata,omitempty"`
 	CreatedBy    *uuid.UUID `db:"created_by" json:"created_by"`
 	UpdatedBy    *uuid.UUID `db:"updated_by" json:"updated_by"`
@@ -122,10 +125,15 @@ type PageSection struct {
 	CSSClass  *string `db:"css_class" json:"css_class"`
 	CustomCSS *string `db:"custom_css" json:"custom_css"`
 	Metadata  JSONMap `db:"metadata" json:"metadata,omitempty"`
+	// Content keys the section accepts; nil allows any key
+	ContentSchema ContentSchema `db:"content_schema" json:"content_schema,omitempty"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 	// Relations
 	Contents []*SectionContent `db:"-" json:"contents,omitempty"`
+	// BGImageSet is the srcset data of an uploaded background image, set on
+	// the public API
+	BGImageSet *ResponsiveImage `db:"-" json:"bg_image_set,omitempty"`
 }
 
 // SectionContent represents a key-value content item within a section
@@ -151,11 +159,15 @@ type SectionContent struct {
 	Metadata   JSONMap `db:"metadata" json:"metadata,omitempty"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	// Image is the srcset data of an uploaded image, set on the public API
+	Image *ResponsiveImage `db:"-" json:"image,omitempty"`
 }
 
 // PageFilter holds filter parameters for page queries
 type PageFilter struct {
-	SiteID     *uuid.UUID
+	SiteID *uuid.UUID
+	// SiteIDs limits the result to these sites; nil means no limit
+	SiteIDs    []uuid.UUID `form:"-"`
 	Status     *PageStatus
 	IsHomepage *bool
 	Search     *string
@@ -226,6 +238,8 @@ type CreateSectionInput struct {
 	Identifier *string     `json:"identifier" validate:"omitempty,max=100"`
 	IsVisible  bool        `json:"is_visible"`
 	SortOrder  int         `json:"sort_order"`
+	// Preset restricts the section's content keys to the built-in preset of its type
+	Preset bool `json:"preset"`
 }
 
 // UpdateSectionInput holds data for updating a page section
@@ -266,6 +280,43 @@ type UpsertContentInput struct {
 	LinkTarget  *string     `json:"link_target"`
 }
 
+// DuplicatePageInput holds data for duplicating a page. The copy gets the
+// source's title and slug with a "copy" suffix unless they are given.
+type DuplicatePageInput struct {
+	Title *string `json:"title" validate:"omitempty,min=1,max=255"`
+	Slug  *string `json:"slug" validate:"omitempty,min=1,max=255"`
+}
+
+// SchedulePageInput holds data for scheduling a page's publish and unpublish times.
+// A nil value clears that side of the schedule.
+type SchedulePageInput struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// ScheduleKind is the side of a page's schedule a change belongs to
+type ScheduleKind string
+
+const (
+	SchedulePublish   ScheduleKind = "publish"
+	ScheduleUnpublish ScheduleKind = "unpublish"
+)
+
+// ScheduledPageChange is a due publish or unpublish claimed by the scheduler
+type ScheduledPageChange struct {
+	PageID      uuid.UUID    `db:"id"`
+	SiteID      uuid.UUID    `db:"site_id"`
+	Slug        string       `db:"slug"`
+	Kind        ScheduleKind `db:"kind"`
+	ScheduledAt time.Time    `db:"scheduled_at"`
+}
+
+// Page schedule errors
+var (
+	ErrScheduleInPast = errors.New("scheduled time must be in the future")
+	ErrScheduleOrder  = errors.New("unpublish_at must be after publish_at")
+)
+
 // ReorderSectionsInput holds data for reordering sections
 type ReorderSectionsInput struct {
 	Sections []SectionOrder `json:"sections" validate:"required,min=1"`
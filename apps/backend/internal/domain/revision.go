@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PageSnapshot is a frozen copy of a page tree (page, sections and contents)
+type PageSnapshot Page
+
+// Value implements the driver.Valuer interface for database serialization
+func (s PageSnapshot) Value() (driver.Value, error) {
+	b, err := json.Marshal(Page(s))
+	if err != nil {
+		return nil, fmt.Errorf("PageSnapshot.Value: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (s *PageSnapshot) Scan(value interface{}) error {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("PageSnapshot.Scan: unsupported type")
+	}
+	var page Page
+	if err := json.Unmarshal(bytes, &page); err != nil {
+		return fmt.Errorf("PageSnapshot.Scan: %w", err)
+	}
+	*s = PageSnapshot(page)
+	return nil
+}
+
+// PageRevision represents an immutable snapshot of a page at a point in time
+type PageRevision struct {
+	ID             uuid.UUID    `db:"id" json:"id"`
+	PageID         uuid.UUID    `db:"page_id" json:"page_id"`
+	RevisionNumber int          `db:"revision_number" json:"revision_number"`
+	Snapshot       PageSnapshot `db:"snapshot" json:"snapshot"`
+	ChangeSummary  *string      `db:"change_summary" json:"change_summary"`
+	CreatedBy      *uuid.UUID   `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+}
+
+// PageRevisionSummary is a lightweight revision listing entry without the snapshot
+type PageRevisionSummary struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	PageID         uuid.UUID  `db:"page_id" json:"page_id"`
+	RevisionNumber int        `db:"revision_number" json:"revision_number"`
+	ChangeSummary  *string    `db:"change_summary" json:"change_summary"`
+	CreatedBy      *uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// DiffChange describes how an item differs between two revisions
+type DiffChange string
+
+const (
+	DiffAdded    DiffChange = "added"
+	DiffRemoved  DiffChange = "removed"
+	DiffModified DiffChange = "modified"
+)
+
+// FieldChange describes a single field that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ContentDiff describes the changes to a single section content item
+type ContentDiff struct {
+	Key    string        `json:"key"`
+	Change DiffChange    `json:"change"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// SectionDiff describes the changes to a single page section
+type SectionDiff struct {
+	SectionID uuid.UUID     `json:"section_id"`
+	Name      string        `json:"name"`
+	Change    DiffChange    `json:"change"`
+	Fields    []FieldChange `json:"fields,omitempty"`
+	Contents  []ContentDiff `json:"contents,omitempty"`
+}
+
+// PageRevisionDiff holds the structured difference between two page revisions
+type PageRevisionDiff struct {
+	PageID       uuid.UUID     `json:"page_id"`
+	FromRevision int           `json:"from_revision"`
+	ToRevision   int           `json:"to_revision"`
+	Page         []FieldChange `json:"page"`
+	Sections     []SectionDiff `json:"sections"`
+}
+
+// HasChanges returns true if the two revisions differ in any way
+func (d *PageRevisionDiff) HasChanges() bool {
+	return len(d.Page) > 0 || len(d.Sections) > 0
+}
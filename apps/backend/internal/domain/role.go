@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permission names one action a role can allow
+type Permission string
+
+// Known permissions. Site-bound permissions are checked against the site a
+// request acts on; the others apply to the whole installation.
+const (
+	PermissionContentView    Permission = "content.view"
+	PermissionPageEdit       Permission = "page.edit"
+	PermissionPagePublish    Permission = "page.publish"
+	PermissionPageDelete     Permission = "page.delete"
+	PermissionComponentEdit  Permission = "component.edit"
+	PermissionMediaUpload    Permission = "media.upload"
+	PermissionMediaDelete    Permission = "media.delete"
+	PermissionSiteManage     Permission = "site.manage"
+	PermissionSiteDelete     Permission = "site.delete"
+	PermissionSiteCreate     Permission = "site.create"
+	PermissionSiteAll        Permission = "site.all"
+	PermissionTemplateManage Permission = "template.manage"
+	PermissionUserManage     Permission = "user.manage"
+	PermissionUserDelete     Permission = "user.delete"
+	PermissionRoleManage     Permission = "role.manage"
+	PermissionAPIKeyManage   Permission = "apikey.manage"
+	PermissionSecurityManage Permission = "security.manage"
+	PermissionAuditView      Permission = "audit.view"
+)
+
+// PermissionInfo describes a permission for the role editor
+type PermissionInfo struct {
+	Name        Permission `json:"name"`
+	Description string     `json:"description"`
+}
+
+// Permissions lists every permission in the order they are documented
+func Permissions() []PermissionInfo {
+	return []PermissionInfo{
+		{PermissionContentView, "View sites, pages, sections, components, media and templates"},
+		{PermissionPageEdit, "Create and edit pages, sections and contents, restore revisions"},
+		{PermissionPagePublish, "Publish, unpublish and schedule pages"},
+		{PermissionPageDelete, "Delete pages"},
+		{PermissionComponentEdit, "Edit features, testimonials, pricing plans, FAQs and navigation"},
+		{PermissionMediaUpload, "Upload media and edit media details"},
+		{PermissionMediaDelete, "Delete media"},
+		{PermissionSiteManage, "Edit a site, its settings, domains and members; export it"},
+		{PermissionSiteDelete, "Delete sites"},
+		{PermissionSiteCreate, "Create, import and duplicate sites"},
+		{PermissionSiteAll, "Reach every site without a site membership"},
+		{PermissionTemplateManage, "Create, edit and delete page templates"},
+		{PermissionUserManage, "Create and edit users and their sessions"},
+		{PermissionUserDelete, "Delete users"},
+		{PermissionRoleManage, "Create, edit and delete roles"},
+		{PermissionAPIKeyManage, "Create and revoke API keys"},
+		{PermissionSecurityManage, "Set two-factor policies"},
+		{PermissionAuditView, "View the audit log"},
+	}
+}
+
+// IsValid reports whether the permission is known
+func (p Permission) IsValid() bool {
+	for _, info := range Permissions() {
+		if info.Name == p {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is the set of permissions a role allows
+type PermissionSet map[Permission]bool
+
+// NewPermissionSet creates a set of the given permissions
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
+
+// AllPermissions returns a set of every known permission
+func AllPermissions() PermissionSet {
+	set := make(PermissionSet)
+	for _, info := range Permissions() {
+		set[info.Name] = true
+	}
+	return set
+}
+
+// Has reports whether the set allows a permission
+func (s PermissionSet) Has(p Permission) bool {
+	return s[p]
+}
+
+// Covers reports whether the set allows every permission of other
+func (s PermissionSet) Covers(other PermissionSet) bool {
+	for p := range other {
+		if !s[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns a set of the permissions of both sets
+func (s PermissionSet) Union(other PermissionSet) PermissionSet {
+	set := make(PermissionSet, len(s)+len(other))
+	for p := range s {
+		set[p] = true
+	}
+	for p := range other {
+		set[p] = true
+	}
+	return set
+}
+
+// Role bundles permissions under the name stored on users. The built-in
+// super_admin, admin and editor roles cannot be renamed or deleted, and
+// super_admin always has every permission.
+type Role struct {
+	ID          uuid.UUID   `db:"id" json:"id"`
+	Name        UserRole    `db:"name" json:"name"`
+	Description string      `db:"description" json:"description"`
+	Permissions StringArray `db:"permissions" json:"permissions"`
+	IsBuiltIn   bool        `db:"is_built_in" json:"is_built_in"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at" json:"updated_at"`
+	UserCount   int         `db:"user_count" json:"user_count"`
+}
+
+// PermissionSet returns the permissions the role allows
+func (r *Role) PermissionSet() PermissionSet {
+	if r.Name == RoleSuperAdmin {
+		return AllPermissions()
+	}
+	set := make(PermissionSet, len(r.Permissions))
+	for _, p := range r.Permissions {
+		set[Permission(p)] = true
+	}
+	return set
+}
+
+// BuiltInRolePermissions returns the permissions the built-in roles are
+// created with, matching what each role could do before custom roles
+func BuiltInRolePermissions() map[UserRole][]Permission {
+	editor := []Permission{
+		PermissionContentView,
+		PermissionPageEdit,
+		PermissionPagePublish,
+		PermissionComponentEdit,
+		PermissionMediaUpload,
+		PermissionMediaDelete,
+	}
+	admin := append(append([]Permission{}, editor...),
+		PermissionPageDelete,
+		PermissionSiteManage,
+		PermissionSiteCreate,
+		PermissionSiteAll,
+		PermissionTemplateManage,
+		PermissionUserManage,
+		PermissionAPIKeyManage,
+		PermissionSecurityManage,
+		PermissionAuditView,
+	)
+	all := make([]Permission, 0, len(Permissions()))
+	for _, info := range Permissions() {
+		all = append(all, info.Name)
+	}
+	return map[UserRole][]Permission{
+		RoleSuperAdmin: all,
+		RoleAdmin:      admin,
+		RoleEditor:     editor,
+	}
+}
+
+// roleNamePattern restricts role names to lowercase slugs
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// IsValidRoleName reports whether a name can be used for a custom role
+func IsValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// CreateRoleInput holds data for creating a custom role
+type CreateRoleInput struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description" validate:"max=500"`
+	Permissions []string `json:"permissions" validate:"required"`
+}
+
+// UpdateRoleInput holds data for updating a role. Nil fields are left unchanged.
+type UpdateRoleInput struct {
+	Description *string  `json:"description" validate:"omitempty,max=500"`
+	Permissions []string `json:"permissions"`
+}
+
+// ErrRoleInUse is returned when deleting a role that users still have
+var ErrRoleInUse = errors.New("role is assigned to users")
+
+// RoleError lists why a role could not be saved. It matches ErrValidation.
+type RoleError struct {
+	Problems []string
+}
+
+// Error implements error
+func (e *RoleError) Error() string {
+	return ErrValidation.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Is makes errors.Is(err, ErrValidation) match
+func (e *RoleError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -42,11 +44,21 @@ type SiteSetting struct {
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// SiteDomain is an additional domain (alias) that serves a site
+type SiteDomain struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	SiteID    uuid.UUID `db:"site_id" json:"site_id"`
+	Domain    string    `db:"domain" json:"domain"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
 // SiteSettingsMap is a convenience type for settings as a map
 type SiteSettingsMap map[string]string
 
 // SiteFilter holds filter parameters for site queries
 type SiteFilter struct {
+	// IDs limits the result to these sites; nil means no limit
+	IDs      []uuid.UUID `form:"-"`
 	IsActive *bool
 	Search   *string
 	Pagination
@@ -73,6 +85,23 @@ type UpdateSiteInput struct {
 	IsActive    *bool   `json:"is_active"`
 }
 
+// DuplicateSiteInput holds data for duplicating a site. Name and slug default
+// to the source's with a "copy" suffix; domains are unique, so the copy only
+// gets one when it is given.
+type DuplicateSiteInput struct {
+	Name   *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Slug   *string `json:"slug" validate:"omitempty,min=1,max=255"`
+	Domain *string `json:"domain" validate:"omitempty,hostname"`
+}
+
+// AddSiteDomainInput holds data for adding a domain alias to a site
+type AddSiteDomainInput struct {
+	Domain string `json:"domain" validate:"required,hostname"`
+}
+
+// ErrInvalidDomain is returned when a domain is not a valid hostname
+var ErrInvalidDomain = errors.New("invalid domain")
+
 // UpdateSettingInput holds data for updating a site setting
 type UpdateSettingInput struct {
 	Value     *string `json:"value"`
@@ -83,3 +112,32 @@ type UpdateSettingInput struct {
 type BulkUpdateSettingsInput struct {
 	Settings map[string]string `json:"settings" validate:"required"`
 }
+
+// ExportSiteInput holds options for a static site export
+type ExportSiteInput struct {
+	// BaseURL is the public URL the export will be served from, used for
+	// sitemap.xml and robots.txt. Defaults to https://<site domain>.
+	BaseURL *string `json:"base_url" validate:"omitempty,url"`
+}
+
+// ErrExportBaseURL is returned when an export has no base URL to build absolute URLs from
+var ErrExportBaseURL = errors.New("base_url is required when the site has no domain")
+
+// ErrExportMediaMissing is matched by every ExportMediaError
+var ErrExportMediaMissing = errors.New("referenced media files are missing from storage")
+
+// ExportMediaError lists the storage paths of referenced media that could not
+// be copied into an export
+type ExportMediaError struct {
+	Files []string
+}
+
+// Error implements error
+func (e *ExportMediaError) Error() string {
+	return ErrExportMediaMissing.Error() + ": " + strings.Join(e.Files, ", ")
+}
+
+// Is makes errors.Is(err, ErrExportMediaMissing) match
+func (e *ExportMediaError) Is(target error) bool {
+	return target == ErrExportMediaMissing
+}
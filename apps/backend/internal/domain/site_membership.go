@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SiteMembership gives a user a role on one site. Users whose role has the
+// site.all permission work on every site; others only reach the sites they
+// are members of, where they also get the permissions of the membership role.
+type SiteMembership struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	SiteID    uuid.UUID  `db:"site_id" json:"site_id"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	Role      UserRole   `db:"role" json:"role"`
+	CreatedBy *uuid.UUID `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+
+	// Joined from users when listing the members of a site
+	Email    string `db:"email" json:"email,omitempty"`
+	FullName string `db:"full_name" json:"full_name,omitempty"`
+}
+
+// SetSiteMemberInput holds the role to give a user on a site
+type SetSiteMemberInput struct {
+	Role UserRole `json:"role" validate:"required,oneof=admin editor"`
+}
+
+// SiteResource names a kind of record whose site can be looked up from its ID
+type SiteResource string
+
+const (
+	SiteResourceSite           SiteResource = "site"
+	SiteResourcePage           SiteResource = "page"
+	SiteResourceSection        SiteResource = "section"
+	SiteResourceContent        SiteResource = "content"
+	SiteResourceFeature        SiteResource = "feature"
+	SiteResourceTestimonial    SiteResource = "testimonial"
+	SiteResourcePricingPlan    SiteResource = "pricing_plan"
+	SiteResourceFAQ            SiteResource = "faq"
+	SiteResourceNavigationMenu SiteResource = "navigation_menu"
+	SiteResourceNavigationItem SiteResource = "navigation_item"
+	SiteResourceMedia          SiteResource = "media"
+)
@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentField describes one content key a section expects
+type ContentField struct {
+	Key         string      `json:"key"`
+	Type        ContentType `json:"type"`
+	Label       *string     `json:"label,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Placeholder *string     `json:"placeholder,omitempty"`
+	IsRequired  bool        `json:"is_required"`
+}
+
+// ContentSchema is the ordered list of content keys of a section. A nil schema
+// allows any key.
+type ContentSchema []ContentField
+
+// Field returns the field with the given key
+func (s ContentSchema) Field(key string) (ContentField, bool) {
+	for _, f := range s {
+		if f.Key == key {
+			return f, true
+		}
+	}
+	return ContentField{}, false
+}
+
+// Value implements the driver.Valuer interface
+func (s ContentSchema) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("ContentSchema.Value: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (s *ContentSchema) Scan(value interface{}) error {
+	if value == nil {
+		*s = nil
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("ContentSchema.Scan: unsupported type")
+	}
+	return json.Unmarshal(bytes, s)
+}
+
+// TemplateSection is one section of a page template. Without fields the
+// built-in preset of the section type applies.
+type TemplateSection struct {
+	Name       string        `json:"name"`
+	Type       SectionType   `json:"type"`
+	Identifier *string       `json:"identifier,omitempty"`
+	Fields     ContentSchema `json:"fields,omitempty"`
+}
+
+// TemplateSections is the JSONB list of sections of a page template
+type TemplateSections []TemplateSection
+
+// Value implements the driver.Valuer interface
+func (t TemplateSections) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("TemplateSections.Value: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface
+func (t *TemplateSections) Scan(value interface{}) error {
+	if value == nil {
+		*t = nil
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("TemplateSections.Scan: unsupported type")
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+// PageTemplate is a named page blueprint. Layout becomes the page's render
+// template.
+type PageTemplate struct {
+	ID          uuid.UUID        `db:"id" json:"id"`
+	Name        string           `db:"name" json:"name"`
+	Description *string          `db:"description" json:"description"`
+	Layout      *string          `db:"layout" json:"layout"`
+	Sections    TemplateSections `db:"sections" json:"sections"`
+	CreatedBy   *uuid.UUID       `db:"created_by" json:"created_by"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time        `db:"updated_at" json:"updated_at"`
+}
+
+// SectionPreset is the built-in content schema of a section type
+type SectionPreset struct {
+	Type   SectionType   `json:"type"`
+	Fields ContentSchema `json:"fields"`
+}
+
+// CreateTemplateInput holds data for creating a page template
+type CreateTemplateInput struct {
+	Name        string            `json:"name" validate:"required,min=1,max=255"`
+	Description *string           `json:"description"`
+	Layout      *string           `json:"layout" validate:"omitempty,max=100"`
+	Sections    []TemplateSection `json:"sections" validate:"required,min=1"`
+}
+
+// UpdateTemplateInput holds data for updating a page template. Sections
+// replace the template's sections when given.
+type UpdateTemplateInput struct {
+	Name        *string           `json:"name" validate:"omitempty,min=1,max=255"`
+	Description *string           `json:"description"`
+	Layout      *string           `json:"layout" validate:"omitempty,max=100"`
+	Sections    []TemplateSection `json:"sections"`
+}
+
+// CreatePageFromTemplateInput holds data for creating a page from a template
+type CreatePageFromTemplateInput struct {
+	SiteID      uuid.UUID `json:"site_id" validate:"required"`
+	Title       string    `json:"title" validate:"required,min=1,max=255"`
+	Slug        string    `json:"slug" validate:"omitempty,min=1,max=255"`
+	Description *string   `json:"description" validate:"omitempty,max=500"`
+}
+
+// ErrContentSchema is matched by every ContentSchemaError
+var ErrContentSchema = errors.New("content does not match the section schema")
+
+// ContentSchemaError lists why content or a template was rejected
+type ContentSchemaError struct {
+	Problems []string
+}
+
+// Error implements error
+func (e *ContentSchemaError) Error() string {
+	return ErrContentSchema.Error() + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Is makes errors.Is(err, ErrContentSchema) match
+func (e *ContentSchemaError) Is(target error) bool {
+	return target == ErrContentSchema
+}
+
+// field builds a ContentField with a label
+func field(key string, contentType ContentType, label string, required bool) ContentField {
+	return ContentField{Key: key, Type: contentType, Label: &label, IsRequired: required}
+}
+
+// headingFields are the keys rendered by the shared section heading
+func headingFields() ContentSchema {
+	return ContentSchema{
+		field("badge_text", ContentTypeText, "Badge Text", false),
+		field("title", ContentTypeText, "Section Title", true),
+		field("subtitle", ContentTypeText, "Section Subtitle", false),
+	}
+}
+
+// buttonFields are the keys rendered as call-to-action buttons
+func buttonFields() ContentSchema {
+	return ContentSchema{
+		field("cta_primary_text", ContentTypeText, "Primary Button Text", false),
+		field("cta_primary_link", ContentTypeLink, "Primary Button Link", false),
+		field("cta_secondary_text", ContentTypeText, "Secondary Button Text", false),
+		field("cta_secondary_link", ContentTypeLink, "Secondary Button Link", false),
+	}
+}
+
+// SectionPresets returns the built-in content schemas, one per section type
+// that the default templates render with fixed keys. Other types are
+// free-form.
+func SectionPresets() []SectionPreset {
+	hero := ContentSchema{
+		field("badge_text", ContentTypeText, "Badge Text", false),
+		field("title", ContentTypeText, "Main Title", true),
+		field("title_highlight", ContentTypeText, "Highlighted Word", false),
+		field("subtitle", ContentTypeText, "Subtitle", false),
+	}
+	hero = append(hero, buttonFields()...)
+	hero = append(hero,
+		field("hero_image", ContentTypeImage, "Hero Image", false),
+		field("hero_image_alt", ContentTypeText, "Hero Image Alt Text", false),
+		field("social_proof_text", ContentTypeText, "Social Proof Text", false),
+	)
+
+	var stats ContentSchema
+	for i := 1; i <= 4; i++ {
+		stats = append(stats,
+			field(fmt.Sprintf("stat_%d_value", i), ContentTypeText, fmt.Sprintf("Stat %d Value", i), false),
+			field(fmt.Sprintf("stat_%d_label", i), ContentTypeText, fmt.Sprintf("Stat %d Label", i), false),
+		)
+	}
+
+	return []SectionPreset{
+		{Type: SectionTypeHero, Fields: hero},
+		{Type: SectionTypeFeatures, Fields: headingFields()},
+		{Type: SectionTypeTestimonials, Fields: headingFields()},
+		{Type: SectionTypePricing, Fields: headingFields()},
+		{Type: SectionTypeFAQ, Fields: headingFields()},
+		{Type: SectionTypeCTA, Fields: append(headingFields(), buttonFields()...)},
+		{Type: SectionTypeStats, Fields: stats},
+	}
+}
+
+// PresetFields returns the built-in content schema of a section type, or nil
+// when the type is free-form
+func PresetFields(sectionType SectionType) ContentSchema {
+	for _, preset := range SectionPresets() {
+		if preset.Type == sectionType {
+			return preset.Fields
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTOTP holds the TOTP enrollment of a user. The secret is pending until
+// the first code is confirmed.
+type UserTOTP struct {
+	UserID       uuid.UUID  `db:"user_id" json:"user_id"`
+	Secret       string     `db:"secret" json:"-"`
+	IsEnabled    bool       `db:"is_enabled" json:"is_enabled"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	EnabledAt    *time.Time `db:"enabled_at" json:"enabled_at"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// TwoFactorPolicy states whether users of a role must use two-factor authentication
+type TwoFactorPolicy struct {
+	Role       UserRole   `db:"role" json:"role"`
+	IsRequired bool       `db:"is_required" json:"is_required"`
+	UpdatedBy  *uuid.UUID `db:"updated_by" json:"updated_by"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// TwoFactorStatus describes the two-factor state of the current user
+type TwoFactorStatus struct {
+	IsEnabled         bool       `json:"is_enabled"`
+	IsRequired        bool       `json:"is_required"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TOTPSetup is returned when enrollment starts. The URI is shown as a QR code.
+type TOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeInput holds a TOTP or recovery code
+type TwoFactorCodeInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorInput holds data for turning two-factor authentication off
+type DisableTwoFactorInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// TwoFactorLoginInput holds data for the second login step
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+// TwoFactorChallengeInput holds a login challenge token
+type TwoFactorChallengeInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// SetTwoFactorPolicyInput holds data for changing the policy of a role
+type SetTwoFactorPolicyInput struct {
+	IsRequired bool `json:"is_required"`
+}
+
+var (
+	// ErrTwoFactorRequired is matched by every TwoFactorRequiredError
+	ErrTwoFactorRequired = errors.New("two-factor authentication required")
+	// ErrInvalidTwoFactorCode is returned for a wrong, reused or expired code
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// TwoFactorRequiredError is returned by a login whose password was correct
+// but which needs a second factor. Tokens are issued once the challenge is
+// answered with a valid code.
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	ExpiresAt      time.Time
+	// SetupRequired means the role requires two-factor authentication and the
+	// user must enroll before signing in
+	SetupRequired bool
+}
+
+// Error implements error
+func (e *TwoFactorRequiredError) Error() string {
+	return ErrTwoFactorRequired.Error()
+}
+
+// Is makes errors.Is(err, ErrTwoFactorRequired) match
+func (e *TwoFactorRequiredError) Is(target error) bool {
+	return target == ErrTwoFactorRequired
+}
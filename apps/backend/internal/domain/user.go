@@ -6,7 +6,8 @@ import (
 	"github.com/google/uuid"
 )
 
-// UserRole represents the role of a user
+// UserRole is the name of the role of a user. The built-in roles are listed
+// here; custom roles are stored in the roles table.
 type UserRole string
 
 const (
@@ -22,6 +23,8 @@ const (
 	StatusActive    UserStatus = "active"
 	StatusInactive  UserStatus = "inactive"
 	StatusSuspended UserStatus = "suspended"
+	// StatusPending is an invited user who has not chosen a password yet
+	StatusPending UserStatus = "pending"
 )
 
 // User represents an admin user
@@ -38,10 +41,12 @@ type User struct {
 	FailedAttempts int        `db:"failed_attempts" json:"-"`
 	LockedUntil    *time.Time `db:"locked_until" json:"-"`
 	EmailVerified  bool       `db:"email_verified" json:"email_verified"`
-	Metadata       JSONMap    `db:"metadata" json:"metadata,omitempty"`
-	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
-	DeletedAt      *time.Time `db:"deleted_at" json:"-"`
+	// PasswordLoginDisabled makes the user sign in with single sign-on only
+	PasswordLoginDisabled bool       `db:"password_login_disabled" json:"password_login_disabled"`
+	Metadata              JSONMap    `db:"metadata" json:"metadata,omitempty"`
+	CreatedAt             time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt             time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt             *time.Time `db:"deleted_at" json:"-"`
 }
 
 // IsActive returns true if the user is active and not locked
@@ -57,35 +62,79 @@ func (u *User) IsLocked() bool {
 	return time.Now().Before(*u.LockedUntil)
 }
 
-// HasRole returns true if the user has the specified role or higher
-func (u *User) HasRole(role UserRole) bool {
-	switch role {
-	case RoleEditor:
-		return u.Role == RoleEditor || u.Role == RoleAdmin || u.Role == RoleSuperAdmin
-	case RoleAdmin:
-		return u.Role == RoleAdmin || u.Role == RoleSuperAdmin
-	case RoleSuperAdmin:
-		return u.Role == RoleSuperAdmin
+// RefreshToken represents a JWT refresh token stored in the database. Tokens
+// rotated from the same login share a FamilyID.
+type RefreshToken struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	UserID    uuid.UUID `db:"user_id" json:"user_id"`
+	FamilyID  uuid.UUID `db:"family_id" json:"family_id"`
+	TokenHash string    `db:"token_hash" json:"-"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	// FamilyExpiresAt is the absolute end of the session, nil for none
+	FamilyExpiresAt *time.Time `db:"family_expires_at" json:"family_expires_at"`
+	IsRevoked       bool       `db:"is_revoked" json:"is_revoked"`
+	IPAddress       *string    `db:"ip_address" json:"ip_address"`
+	UserAgent       *string    `db:"user_agent" json:"user_agent"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	RevokedAt       *time.Time `db:"revoked_at" json:"revoked_at"`
+	// RotatedAt is set once the token has been exchanged for a new one
+	RotatedAt *time.Time `db:"rotated_at" json:"rotated_at"`
+}
+
+// IsValid returns true if the token is not expired and not revoked
+func (rt *RefreshToken) IsValid() bool {
+	now := time.Now()
+	if rt.FamilyExpiresAt != nil && !now.Before(*rt.FamilyExpiresAt) {
+		return false
 	}
-	return false
+	return !rt.IsRevoked && now.Before(rt.ExpiresAt)
 }
 
-// RefreshToken represents a JWT refresh token stored in the database
-type RefreshToken struct {
+// TokenReuseEvent describes a rotated refresh token being presented again,
+// which means the token was copied. The whole family is revoked.
+type TokenReuseEvent struct {
+	UserID    uuid.UUID `json:"user_id"`
+	FamilyID  uuid.UUID `json:"family_id"`
+	TokenID   uuid.UUID `json:"token_id"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	// Revoked is the number of still active tokens the family lost
+	Revoked    int64     `json:"revoked"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Session is the active refresh token of a token family, shown to its owner.
+// ID is the family ID, which stays the same across refreshes. Device fields
+// are parsed from the user agent that signed in.
+type Session struct {
+	ID        uuid.UUID `json:"id"`
+	IPAddress *string   `json:"ip_address"`
+	UserAgent *string   `json:"user_agent"`
+	Browser   string    `json:"browser"`
+	OS        string    `json:"os"`
+	Device    string    `json:"device"`
+	// LastRefreshedAt is when the current token of the session was issued
+	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	// IsCurrent marks the session of the refresh cookie sent with the request
+	IsCurrent bool `json:"is_current"`
+}
+
+// PasswordResetToken represents a single-use password reset token. Only the
+// hash of the token is stored.
+type PasswordResetToken struct {
 	ID        uuid.UUID  `db:"id" json:"id"`
 	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
 	TokenHash string     `db:"token_hash" json:"-"`
 	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
-	IsRevoked bool       `db:"is_revoked" json:"is_revoked"`
-	IPAddress *string    `db:"ip_address" json:"ip_address"`
-	UserAgent *string    `db:"user_agent" json:"user_agent"`
+	IsUsed    bool       `db:"is_used" json:"is_used"`
 	CreatedAt time.Time  `db:"created_at" json:"created_at"`
-	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at"`
 }
 
-// IsValid returns true if the token is not expired and not revoked
-func (rt *RefreshToken) IsValid() bool {
-	return !rt.IsRevoked && time.Now().Before(rt.ExpiresAt)
+// IsValid returns true if the token is not expired and has not been used
+func (t *PasswordResetToken) IsValid() bool {
+	return !t.IsUsed && time.Now().Before(t.ExpiresAt)
 }
 
 // UserFilter holds filter parameters for user queries
@@ -101,15 +150,17 @@ type CreateUserInput struct {
 	Email    string   `json:"email" validate:"required,email"`
 	Password string   `json:"password" validate:"required,min=8,max=72"`
 	FullName string   `json:"full_name" validate:"required,min=2,max=255"`
-	Role     UserRole `json:"role" validate:"required,oneof=super_admin admin editor"`
+	Role     UserRole `json:"role" validate:"required,max=50"`
 }
 
 // UpdateUserInput holds data for updating a user
 type UpdateUserInput struct {
-	FullName  *string    `json:"full_name" validate:"omitempty,min=2,max=255"`
-	AvatarURL *string    `json:"avatar_url" validate:"omitempty,url"`
-	Role      *UserRole  `json:"role" validate:"omitempty,oneof=super_admin admin editor"`
+	FullName  *string     `json:"full_name" validate:"omitempty,min=2,max=255"`
+	AvatarURL *string     `json:"avatar_url" validate:"omitempty,url"`
+	Role      *UserRole   `json:"role" validate:"omitempty,max=50"`
 	Status    *UserStatus `json:"status" validate:"omitempty,oneof=active inactive suspended"`
+	// PasswordLoginDisabled restricts the user to single sign-on
+	PasswordLoginDisabled *bool `json:"password_login_disabled"`
 }
 
 // ChangePasswordInput holds data for changing a user's password
@@ -118,6 +169,17 @@ type ChangePasswordInput struct {
 	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
 }
 
+// ForgotPasswordInput holds data for requesting a password reset
+type ForgotPasswordInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordInput holds data for resetting a password with a reset token
+type ResetPasswordInput struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
 // LoginInput holds data for user login
 type LoginInput struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -130,6 +192,8 @@ type AuthTokens struct {
 	RefreshToken string    `json:"refresh_token"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	TokenType    string    `json:"token_type"`
+	// RefreshExpiresAt is when the refresh token stops working
+	RefreshExpiresAt time.Time `json:"-"`
 }
 
 // JWTClaims holds the JWT claims
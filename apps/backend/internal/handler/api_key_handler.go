@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// APIKeyHandler handles API key management endpoints
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	logger        zerolog.Logger
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler
+func NewAPIKeyHandler(apiKeyService service.APIKeyService, logger zerolog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// ListAPIKeys handles GET /api/v1/admin/api-keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	var filter domain.APIKeyFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "invalid query parameters")
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("list API keys error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, keys)
+}
+
+// CreateAPIKey handles POST /api/v1/admin/api-keys
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), input, userID)
+	if err != nil {
+		var keyErr *domain.APIKeyError
+		switch {
+		case errors.As(err, &keyErr):
+			response.UnprocessableEntity(c, "invalid API key", keyErr.Problems)
+		case errors.Is(err, domain.ErrForbidden):
+			response.Forbidden(c, "cannot create an API key for a user with permissions you do not hold")
+		default:
+			h.logger.Error().Err(err).Msg("create API key error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	response.Created(c, key)
+}
+
+// RevokeAPIKey handles DELETE /api/v1/admin/api-keys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "API key not found or already revoked")
+			return
+		}
+		h.logger.Error().Err(err).Str("id", id.String()).Msg("revoke API key error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
@@ -3,6 +3,8 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +17,14 @@ import (
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
 )
 
-const refreshTokenCookieName = "refresh_token"
+const (
+	refreshTokenCookieName = "refresh_token"
+
+	// oidcStateCookieName keeps the state of a single sign-on login until
+	// the provider redirects back
+	oidcStateCookieName = "oidc_state"
+	oidcCookiePath      = "/api/v1/auth/oidc"
+)
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
@@ -46,13 +55,24 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	user, tokens, err := h.authService.Login(c.Request.Context(), input, ipAddress, userAgent)
 	if err != nil {
+		var challenge *domain.TwoFactorRequiredError
 		switch {
+		case errors.As(err, &challenge):
+			// Password verified; tokens follow once a code is submitted
+			response.OKWithMessage(c, "two-factor authentication required", gin.H{
+				"two_factor_required": true,
+				"setup_required":      challenge.SetupRequired,
+				"challenge_token":     challenge.ChallengeToken,
+				"expires_at":          challenge.ExpiresAt,
+			})
 		case errors.Is(err, domain.ErrInvalidCredentials):
 			response.Unauthorized(c, "invalid email or password")
 		case errors.Is(err, domain.ErrAccountLocked):
 			response.Unauthorized(c, "account is temporarily locked due to too many failed attempts")
 		case errors.Is(err, domain.ErrAccountInactive):
 			response.Forbidden(c, "account is inactive")
+		case errors.Is(err, domain.ErrPasswordLoginDisabled):
+			response.Forbidden(c, "password sign-in is disabled for this account, use single sign-on")
 		default:
 			h.logger.Error().Err(err).Msg("login error")
 			response.InternalError(c, err)
@@ -60,11 +80,181 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	h.respondLoggedIn(c, user, tokens, nil)
+}
+
+// VerifyTwoFactor handles POST /api/v1/auth/2fa/verify
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var input domain.TwoFactorLoginInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.ChallengeToken == "" || input.Code == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	user, tokens, recoveryCodes, err := h.authService.CompleteTwoFactorLogin(
+		c.Request.Context(), input, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidTwoFactorCode):
+			response.Unauthorized(c, "invalid two-factor code")
+		case errors.Is(err, domain.ErrInvalidToken):
+			response.Unauthorized(c, "invalid or expired challenge, log in again")
+		case errors.Is(err, domain.ErrNotFound):
+			response.BadRequest(c, "two-factor setup has not been started")
+		case errors.Is(err, domain.ErrAccountLocked):
+			response.Unauthorized(c, "account is temporarily locked due to too many failed attempts")
+		case errors.Is(err, domain.ErrAccountInactive):
+			response.Forbidden(c, "account is inactive")
+		default:
+			h.logger.Error().Err(err).Msg("two-factor login error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	h.respondLoggedIn(c, user, tokens, recoveryCodes)
+}
+
+// StartTwoFactorSetup handles POST /api/v1/auth/2fa/challenge/setup
+func (h *AuthHandler) StartTwoFactorSetup(c *gin.Context) {
+	var input domain.TwoFactorChallengeInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.ChallengeToken == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	setup, err := h.authService.StartTwoFactorSetup(c.Request.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidToken):
+			response.Unauthorized(c, "invalid or expired challenge, log in again")
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "two-factor authentication is already enabled")
+		case errors.Is(err, domain.ErrAccountLocked):
+			response.Unauthorized(c, "account is temporarily locked due to too many failed attempts")
+		case errors.Is(err, domain.ErrAccountInactive):
+			response.Forbidden(c, "account is inactive")
+		default:
+			h.logger.Error().Err(err).Msg("two-factor setup error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, setup)
+}
+
+// OIDCLogin handles GET /api/v1/auth/oidc/login. It remembers the state in a
+// cookie and redirects the browser to the identity provider.
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	start, err := h.authService.StartOIDCLogin(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, domain.ErrSSODisabled) {
+			response.NotFound(c, "single sign-on is not enabled")
+			return
+		}
+		h.logger.Error().Err(err).Msg("single sign-on start error")
+		response.InternalError(c, err)
+		return
+	}
+
+	// Lax, so the cookie comes along when the provider redirects back
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(
+		oidcStateCookieName,
+		start.State,
+		int(time.Until(start.ExpiresAt).Seconds()),
+		oidcCookiePath,
+		h.cfg.Cookie.Domain,
+		h.cfg.Cookie.Secure,
+		true, // httpOnly
+	)
+	c.Redirect(http.StatusFound, start.AuthURL)
+}
+
+// OIDCCallback handles GET /api/v1/auth/oidc/callback. On success it sets the
+// refresh cookie and redirects to the admin, where the client refreshes to
+// get an access token; failures redirect to the error page with ?error=.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	var input domain.OIDCCallbackInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		h.redirectOIDCError(c, "invalid_request")
+		return
+	}
+	input.BrowserState, _ = c.Cookie(oidcStateCookieName)
+	c.SetCookie(oidcStateCookieName, "", -1, oidcCookiePath, h.cfg.Cookie.Domain, h.cfg.Cookie.Secure, true)
+
+	_, tokens, err := h.authService.CompleteOIDCLogin(c.Request.Context(), input, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		var challenge *domain.TwoFactorRequiredError
+		switch {
+		case errors.As(err, &challenge):
+			h.redirectOIDCChallenge(c, challenge)
+		case errors.Is(err, domain.ErrSSODisabled):
+			response.NotFound(c, "single sign-on is not enabled")
+		case errors.Is(err, domain.ErrInvalidToken):
+			h.redirectOIDCError(c, "invalid_state")
+		case errors.Is(err, domain.ErrSSOFailed):
+			h.redirectOIDCError(c, "sso_failed")
+		case errors.Is(err, domain.ErrAccountInactive):
+			h.redirectOIDCError(c, "account_inactive")
+		case errors.Is(err, domain.ErrAccountLocked):
+			h.redirectOIDCError(c, "account_locked")
+		case errors.Is(err, domain.ErrForbidden):
+			h.redirectOIDCError(c, "no_role")
+		case errors.Is(err, domain.ErrAlreadyExists):
+			h.redirectOIDCError(c, "account_exists")
+		default:
+			h.logger.Error().Err(err).Msg("single sign-on callback error")
+			h.redirectOIDCError(c, "server_error")
+		}
+		return
+	}
+
+	h.setRefreshTokenCookie(c, tokens.RefreshToken, tokens.RefreshExpiresAt)
+	c.Redirect(http.StatusFound, h.cfg.OIDC.SuccessURL)
+}
+
+// redirectOIDCError sends the browser to the single sign-on error page
+func (h *AuthHandler) redirectOIDCError(c *gin.Context, code string) {
+	target, err := url.Parse(h.cfg.OIDC.ErrorURL)
+	if err != nil {
+		response.BadRequest(c, "single sign-on failed: "+code)
+		return
+	}
+	params := target.Query()
+	params.Set("error", code)
+	target.RawQuery = params.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+// redirectOIDCChallenge sends the browser to the login page to answer a
+// two-factor challenge. The token goes in the fragment, which browsers do not
+// send to servers or in the Referer header.
+func (h *AuthHandler) redirectOIDCChallenge(c *gin.Context, challenge *domain.TwoFactorRequiredError) {
+	target, err := url.Parse(h.cfg.OIDC.ErrorURL)
+	if err != nil {
+		response.BadRequest(c, "single sign-on failed: two_factor_required")
+		return
+	}
+	params := target.Query()
+	params.Set("error", "two_factor_required")
+	target.RawQuery = params.Encode()
+	target.Fragment = url.Values{
+		"challenge_token": {challenge.ChallengeToken},
+		"setup_required":  {strconv.FormatBool(challenge.SetupRequired)},
+	}.Encode()
+	c.Redirect(http.StatusFound, target.String())
+}
+
+// respondLoggedIn sets the refresh cookie and returns the access token. New
+// recovery codes are included when enrollment just completed.
+func (h *AuthHandler) respondLoggedIn(c *gin.Context, user *domain.User, tokens *domain.AuthTokens, recoveryCodes []string) {
 	// Set refresh token as httpOnly cookie
-	h.setRefreshTokenCookie(c, tokens.RefreshToken, tokens.ExpiresAt)
+	h.setRefreshTokenCookie(c, tokens.RefreshToken, tokens.RefreshExpiresAt)
 
 	// Return access token in response body
-	response.OKWithMessage(c, "login successful", gin.H{
+	data := gin.H{
 		"access_token": tokens.AccessToken,
 		"expires_at":   tokens.ExpiresAt,
 		"token_type":   tokens.TokenType,
@@ -75,7 +265,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			"role":       user.Role,
 			"avatar_url": user.AvatarURL,
 		},
-	})
+	}
+	if recoveryCodes != nil {
+		data["recovery_codes"] = recoveryCodes
+	}
+	response.OKWithMessage(c, "login successful", data)
 }
 
 // Logout handles POST /api/v1/auth/logout
@@ -105,7 +299,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshTokens(c.Request.Context(), refreshToken)
+	tokens, err := h.authService.RefreshTokens(c.Request.Context(), refreshToken, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrInvalidToken):
@@ -114,6 +308,9 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		case errors.Is(err, domain.ErrTokenRevoked):
 			h.clearRefreshTokenCookie(c)
 			response.Unauthorized(c, "refresh token has been revoked")
+		case errors.Is(err, domain.ErrTokenReused):
+			h.clearRefreshTokenCookie(c)
+			response.Unauthorized(c, "refresh token was already used, the session has been signed out")
 		case errors.Is(err, domain.ErrAccountInactive):
 			h.clearRefreshTokenCookie(c)
 			response.Forbidden(c, "account is inactive")
@@ -125,7 +322,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// Set new refresh token cookie
-	h.setRefreshTokenCookie(c, tokens.RefreshToken, tokens.ExpiresAt)
+	h.setRefreshTokenCookie(c, tokens.RefreshToken, tokens.RefreshExpiresAt)
 
 	response.OK(c, gin.H{
 		"access_token": tokens.AccessToken,
@@ -165,6 +362,54 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	response.OKWithMessage(c, "password changed successfully", nil)
 }
 
+// ForgotPassword handles POST /api/v1/auth/forgot-password
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var input domain.ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Email == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.authService.ForgotPassword(c.Request.Context(), input); err != nil {
+		h.logger.Error().Err(err).Msg("forgot password error")
+		response.InternalError(c, err)
+		return
+	}
+
+	// Same answer whether or not the email is registered
+	response.OKWithMessage(c, "if an account with this email exists, a password reset link has been sent", nil)
+}
+
+// ResetPassword handles POST /api/v1/auth/reset-password
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var input domain.ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Token == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), input); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			response.UnprocessableEntity(c, "password must be 8 to 72 characters", nil)
+		case errors.Is(err, domain.ErrInvalidToken):
+			response.BadRequest(c, "invalid or expired reset token")
+		case errors.Is(err, domain.ErrAccountInactive):
+			response.Forbidden(c, "account is inactive")
+		case errors.Is(err, domain.ErrPasswordLoginDisabled):
+			response.Forbidden(c, "password sign-in is disabled for this account, use single sign-on")
+		default:
+			h.logger.Error().Err(err).Msg("reset password error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	// Every session was revoked; drop this browser's cookie too
+	h.clearRefreshTokenCookie(c)
+	response.OKWithMessage(c, "password reset successfully", nil)
+}
+
 // Me handles GET /api/v1/auth/me
 func (h *AuthHandler) Me(c *gin.Context) {
 	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// BundleHandler handles site bundle import/export and duplication endpoints
+type BundleHandler struct {
+	bundleService service.BundleService
+	logger        zerolog.Logger
+}
+
+// NewBundleHandler creates a new BundleHandler
+func NewBundleHandler(bundleService service.BundleService, logger zerolog.Logger) *BundleHandler {
+	return &BundleHandler{
+		bundleService: bundleService,
+		logger:        logger,
+	}
+}
+
+// ExportBundle handles GET /api/v1/admin/sites/:id/bundle. The bundle is sent
+// as a bare JSON document so it can be posted to the import endpoint unchanged.
+func (h *BundleHandler) ExportBundle(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+
+	bundle, err := h.bundleService.ExportBundle(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "site not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("site_id", id.String()).Msg("export site bundle error")
+		response.InternalError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-bundle.json"`, bundle.Site.Slug))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportBundle handles POST /api/v1/admin/sites/import. The body is a bundle;
+// ?name=, ?slug= and ?domain= override the site and ?dry_run=true validates
+// the import without saving it.
+func (h *BundleHandler) ImportBundle(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.ImportSiteBundleInput
+	if err := c.ShouldBindQuery(&input); err != nil {
+		response.BadRequest(c, "invalid query parameters")
+		return
+	}
+	var bundle domain.SiteBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	result, err := h.bundleService.ImportBundle(c.Request.Context(), &bundle, input, userID)
+	if err != nil {
+		var bundleErr *domain.BundleError
+		switch {
+		case errors.As(err, &bundleErr):
+			response.UnprocessableEntity(c, domain.ErrInvalidBundle.Error(), bundleErr.Problems)
+		case errors.Is(err, domain.ErrInvalidDomain):
+			response.UnprocessableEntity(c, domain.ErrInvalidDomain.Error(), nil)
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "a site with this slug or domain already exists")
+		default:
+			h.logger.Error().Err(err).Msg("import site bundle error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	if result.DryRun {
+		response.OK(c, result)
+		return
+	}
+	response.Created(c, result)
+}
+
+// DuplicatePage handles POST /api/v1/admin/pages/:id/duplicate. The copy is
+// created as a draft; title and slug may be given in the body.
+func (h *BundleHandler) DuplicatePage(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	var input domain.DuplicatePageInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			response.BadRequest(c, "invalid request body")
+			return
+		}
+	}
+
+	page, err := h.bundleService.DuplicatePage(c.Request.Context(), id, input, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "page not found")
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "a page with this slug already exists")
+		default:
+			h.logger.Error().Err(err).Str("page_id", id.String()).Msg("duplicate page error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.Created(c, page)
+}
+
+// DuplicateSite handles POST /api/v1/admin/sites/:id/duplicate. Name, slug and
+// domain of the copy may be given in the body.
+func (h *BundleHandler) DuplicateSite(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+
+	var input domain.DuplicateSiteInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			response.BadRequest(c, "invalid request body")
+			return
+		}
+	}
+
+	site, err := h.bundleService.DuplicateSite(c.Request.Context(), id, input, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "site not found")
+		case errors.Is(err, domain.ErrInvalidDomain):
+			response.UnprocessableEntity(c, domain.ErrInvalidDomain.Error(), nil)
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "a site with this slug or domain already exists")
+		default:
+			h.logger.Error().Err(err).Str("site_id", id.String()).Msg("duplicate site error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.Created(c, site)
+}
@@ -1,14 +1,13 @@
 package handler
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
-	"net/http"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -16,36 +15,34 @@ import (
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
 )
 
 // ComponentHandler handles component-related endpoints
 type ComponentHandler struct {
-	compRepo        repository.ComponentRepository
-	supabaseURL     string
-	supabaseBucket  string
-	supabaseKey     string
-	maxUploadSize   int64
-	allowedMimeTypes []string
-	logger          zerolog.Logger
+	compRepo      repository.ComponentRepository
+	storage       storage.Backend
+	mediaSvc      service.MediaService
+	maxUploadSize int64
+	logger        zerolog.Logger
 }
 
 // NewComponentHandler creates a new ComponentHandler
 func NewComponentHandler(
 	compRepo repository.ComponentRepository,
-	supabaseURL, supabaseBucket, supabaseKey string,
+	store storage.Backend,
+	mediaSvc service.MediaService,
 	maxUploadSize int64,
-	allowedMimeTypes []string,
 	logger zerolog.Logger,
 ) *ComponentHandler {
 	return &ComponentHandler{
-		compRepo:        compRepo,
-		supabaseURL:     supabaseURL,
-		supabaseBucket:  supabaseBucket,
-		supabaseKey:     supabaseKey,
-		maxUploadSize:   maxUploadSize,
-		allowedMimeTypes: allowedMimeTypes,
-		logger:          logger,
+		compRepo:      compRepo,
+		storage:       store,
+		mediaSvc:      mediaSvc,
+		maxUploadSize: maxUploadSize,
+		logger:        logger,
 	}
 }
 
@@ -64,6 +61,13 @@ func (h *ComponentHandler) ListFeatures(c *gin.Context) {
 		filter.SiteID = &siteID
 	}
 
+	if filter.SiteID == nil {
+		filter.SiteID = siteIDFromContext(c)
+	}
+	if filter.SiteID == nil {
+		filter.SiteIDs = accessibleSiteIDsFromContext(c)
+	}
+
 	features, total, err := h.compRepo.FindFeaturesByFilter(c.Request.Context(), filter)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("list features error")
@@ -173,6 +177,13 @@ func (h *ComponentHandler) ListTestimonials(c *gin.Context) {
 		filter.SiteID = &siteID
 	}
 
+	if filter.SiteID == nil {
+		filter.SiteID = siteIDFromContext(c)
+	}
+	if filter.SiteID == nil {
+		filter.SiteIDs = accessibleSiteIDsFromContext(c)
+	}
+
 	testimonials, total, err := h.compRepo.FindTestimonialsByFilter(c.Request.Context(), filter)
 	if err != nil {
 		response.InternalError(c, err)
@@ -278,6 +289,13 @@ func (h *ComponentHandler) ListPricingPlans(c *gin.Context) {
 		filter.SiteID = &siteID
 	}
 
+	if filter.SiteID == nil {
+		filter.SiteID = siteIDFromContext(c)
+	}
+	if filter.SiteID == nil {
+		filter.SiteIDs = accessibleSiteIDsFromContext(c)
+	}
+
 	plans, total, err := h.compRepo.FindPricingPlansByFilter(c.Request.Context(), filter)
 	if err != nil {
 		response.InternalError(c, err)
@@ -396,6 +414,13 @@ func (h *ComponentHandler) ListFAQs(c *gin.Context) {
 		filter.SiteID = &siteID
 	}
 
+	if filter.SiteID == nil {
+		filter.SiteID = siteIDFromContext(c)
+	}
+	if filter.SiteID == nil {
+		filter.SiteIDs = accessibleSiteIDsFromContext(c)
+	}
+
 	faqs, total, err := h.compRepo.FindFAQsByFilter(c.Request.Context(), filter)
 	if err != nil {
 		response.InternalError(c, err)
@@ -488,15 +513,24 @@ func (h *ComponentHandler) DeleteFAQ(c *gin.Context) {
 
 // GetNavigation handles GET /api/v1/public/navigation/:siteId/:identifier
 func (h *ComponentHandler) GetNavigation(c *gin.Context) {
-	siteID, err := uuid.Parse(c.Param("siteId"))
-	if err != nil {
-		response.BadRequest(c, "invalid site ID")
+	var siteID uuid.UUID
+	if siteIDStr := c.Param("siteId"); siteIDStr != "" {
+		parsed, err := uuid.Parse(siteIDStr)
+		if err != nil {
+			response.BadRequest(c, "invalid site ID")
+			return
+		}
+		siteID = parsed
+	} else if resolved := siteIDFromContext(c); resolved != nil {
+		siteID = *resolved
+	} else {
+		response.BadRequest(c, "site could not be resolved from host")
 		return
 	}
 
 	identifier := c.Param("identifier")
 	if identifier == "" {
-		identifier = "header"
+		identifier = c.DefaultQuery("identifier", "header")
 	}
 
 	menu, err := h.compRepo.FindMenuByIdentifier(c.Request.Context(), siteID, identifier)
@@ -516,7 +550,7 @@ func (h *ComponentHandler) GetNavigation(c *gin.Context) {
 	}
 
 	// Build tree structure
-	menu.Items = buildNavigationTree(items)
+	menu.Items = domain.BuildNavigationTree(items)
 	response.OK(c, menu)
 }
 
@@ -605,7 +639,7 @@ func (h *ComponentHandler) ListNavigation(c *gin.Context) {
 	for _, menu := range menus {
 		items, err := h.compRepo.FindItemsByMenuID(c.Request.Context(), menu.ID)
 		if err == nil {
-			menu.Items = buildNavigationTree(items)
+			menu.Items = domain.BuildNavigationTree(items)
 		}
 	}
 
@@ -737,10 +771,26 @@ func (h *ComponentHandler) UploadMedia(c *gin.Context) {
 		return
 	}
 
-	// Validate MIME type
-	mimeType := header.Header.Get("Content-Type")
-	if !h.isAllowedMimeType(mimeType) {
-		response.BadRequest(c, "file type not allowed")
+	// The type is detected from the content; the declared Content-Type is
+	// ignored
+	data, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		response.BadRequest(c, "failed to read file")
+		return
+	}
+	if int64(len(data)) > h.maxUploadSize {
+		response.BadRequest(c, fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", h.maxUploadSize))
+		return
+	}
+	mimeType, data, err := h.mediaSvc.CheckUpload(c.Request.Context(), header.Filename, data)
+	if err != nil {
+		var uploadErr *domain.UploadError
+		if errors.As(err, &uploadErr) {
+			response.UnprocessableEntity(c, uploadErr.Message, gin.H{"code": uploadErr.Code})
+			return
+		}
+		h.logger.Error().Err(err).Msg("check upload error")
+		response.InternalError(c, fmt.Errorf("failed to check file"))
 		return
 	}
 
@@ -751,30 +801,29 @@ func (h *ComponentHandler) UploadMedia(c *gin.Context) {
 	if folder == "" {
 		folder = "/"
 	}
-	filePath := fmt.Sprintf("%s/%s", strings.TrimPrefix(folder, "/"), fileName)
-
-	// Upload to Supabase Storage
-	publicURL, err := h.uploadToSupabase(file, filePath, mimeType)
+	filePath := fileName
+	if dir := strings.Trim(folder, "/"); dir != "" {
+		filePath = dir + "/" + fileName
+	}
+	filePath, err = storage.CleanKey(filePath)
 	if err != nil {
-		h.logger.Error().Err(err).Msg("upload to supabase error")
-		response.InternalError(c, fmt.Errorf("failed to upload file"))
+		response.BadRequest(c, "invalid folder")
 		return
 	}
 
 	// Determine media type
 	mediaType := getMediaType(mimeType)
 
-	// Save to database
 	media := &domain.Media{
 		ID:           uuid.New(),
 		SiteID:       siteID,
 		Name:         strings.TrimSuffix(header.Filename, ext),
 		OriginalName: header.Filename,
 		FilePath:     filePath,
-		PublicURL:    publicURL,
+		PublicURL:    h.storage.PublicURL(filePath),
 		Type:         mediaType,
 		MimeType:     mimeType,
-		FileSize:     header.Size,
+		FileSize:     int64(len(data)),
 		Folder:       folder,
 		UploadedBy:   &userID,
 	}
@@ -784,8 +833,30 @@ func (h *ComponentHandler) UploadMedia(c *gin.Context) {
 		media.AltText = &altText
 	}
 
+	// Images get their dimensions recorded and variants stored
+	if mediaType == "image" {
+		if err := h.mediaSvc.ProcessImage(c.Request.Context(), media, data); err != nil {
+			if errors.Is(err, domain.ErrInvalidImage) {
+				response.UnprocessableEntity(c, domain.ErrInvalidImage.Error(), gin.H{"code": domain.UploadInvalidFile})
+				return
+			}
+			h.logger.Error().Err(err).Msg("process image error")
+			response.InternalError(c, fmt.Errorf("failed to process image"))
+			return
+		}
+	}
+
+	if err := h.storage.Put(c.Request.Context(), filePath, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
+		h.logger.Error().Err(err).Msg("upload to storage error")
+		h.removeFiles(c.Request.Context(), media.StoredKeys()[1:])
+		response.InternalError(c, fmt.Errorf("failed to upload file"))
+		return
+	}
+
+	// Save to database
 	if err := h.compRepo.CreateMedia(c.Request.Context(), media); err != nil {
 		h.logger.Error().Err(err).Msg("save media error")
+		h.removeFiles(c.Request.Context(), media.StoredKeys())
 		response.InternalError(c, err)
 		return
 	}
@@ -848,6 +919,16 @@ func (h *ComponentHandler) DeleteMedia(c *gin.Context) {
 		return
 	}
 
+	media, err := h.compRepo.FindMediaByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "media not found")
+			return
+		}
+		response.InternalError(c, err)
+		return
+	}
+
 	if err := h.compRepo.DeleteMedia(c.Request.Context(), id); err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			response.NotFound(c, "media not found")
@@ -857,9 +938,34 @@ func (h *ComponentHandler) DeleteMedia(c *gin.Context) {
 		return
 	}
 
+	// The row is gone either way; a file left behind is only logged
+	h.deleteStoredFiles(c, media)
 	response.NoContent(c)
 }
 
+// deleteStoredFiles removes a file and its image variants from storage
+// unless another media row, e.g. of a cloned site, still uses the file
+func (h *ComponentHandler) deleteStoredFiles(c *gin.Context, media *domain.Media) {
+	count, err := h.compRepo.CountMediaByFilePath(c.Request.Context(), media.FilePath)
+	if err != nil {
+		h.logger.Error().Err(err).Str("file_path", media.FilePath).Msg("failed to count media using file")
+		return
+	}
+	if count > 0 {
+		return
+	}
+	h.removeFiles(c.Request.Context(), media.StoredKeys())
+}
+
+// removeFiles deletes stored files, logging failures
+func (h *ComponentHandler) removeFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := h.storage.Delete(ctx, key); err != nil {
+			h.logger.Error().Err(err).Str("file_path", key).Msg("failed to delete media file")
+		}
+	}
+}
+
 // ─── Audit Logs ───────────────────────────────────────────────────────────────
 
 // ListAuditLogs handles GET /api/v1/admin/audit-logs
@@ -894,47 +1000,6 @@ func (h *ComponentHandler) ListAuditLogs(c *gin.Context) {
 
 // ─── Helpers ──────────────────────────────────────────────────────────────────
 
-func (h *ComponentHandler) isAllowedMimeType(mimeType string) bool {
-	for _, allowed := range h.allowedMimeTypes {
-		if strings.EqualFold(allowed, mimeType) {
-			return true
-		}
-	}
-	return false
-}
-
-func (h *ComponentHandler) uploadToSupabase(file multipart.File, filePath, mimeType string) (string, error) {
-	fileBytes, err := io.ReadAll(file)
-	if err != nil {
-		return "", fmt.Errorf("read file: %w", err)
-	}
-
-	uploadURL := fmt.Sprintf("%s/storage/v1/object/%s/%s", h.supabaseURL, h.supabaseBucket, filePath)
-
-	req, err := http.NewRequest(http.MethodPost, uploadURL, strings.NewReader(string(fileBytes)))
-	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+h.supabaseKey)
-	req.Header.Set("Content-Type", mimeType)
-	req.Header.Set("x-upsert", "true")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("upload request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return "", fmt.Errorf("upload failed with status: %d", resp.StatusCode)
-	}
-
-	publicURL := fmt.Sprintf("%s/storage/v1/object/public/%s/%s", h.supabaseURL, h.supabaseBucket, filePath)
-	return publicURL, nil
-}
-
 func getMediaType(mimeType string) string {
 	switch {
 	case strings.HasPrefix(mimeType, "image/"):
@@ -949,23 +1014,3 @@ func getMediaType(mimeType string) string {
 		return "other"
 	}
 }
-
-func buildNavigationTree(items []*domain.NavigationItem) []*domain.NavigationItem {
-	itemMap := make(map[uuid.UUID]*domain.NavigationItem)
-	for _, item := range items {
-		itemMap[item.ID] = item
-		item.Children = []*domain.NavigationItem{}
-	}
-
-	var roots []*domain.NavigationItem
-	for _, item := range items {
-		if item.ParentID == nil {
-			roots = append(roots, item)
-		} else {
-			if parent, ok := itemMap[*item.ParentID]; ok {
-				parent.Children = append(parent.Children, item)
-			}
-		}
-	}
-	return roots
-}
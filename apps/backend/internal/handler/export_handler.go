@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ExportHandler handles static site export endpoints
+type ExportHandler struct {
+	exportService service.ExportService
+	logger        zerolog.Logger
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(exportService service.ExportService, logger zerolog.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		logger:        logger,
+	}
+}
+
+// ExportSite handles POST /api/v1/admin/sites/:id/export
+func (h *ExportHandler) ExportSite(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+
+	// The body is optional
+	var input domain.ExportSiteInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			response.BadRequest(c, "invalid request body")
+			return
+		}
+	}
+
+	export, err := h.exportService.ExportSite(c.Request.Context(), id, input)
+	if err != nil {
+		var mediaErr *domain.ExportMediaError
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "site not found")
+		case errors.Is(err, domain.ErrExportBaseURL):
+			response.UnprocessableEntity(c, domain.ErrExportBaseURL.Error(), nil)
+		case errors.As(err, &mediaErr):
+			response.UnprocessableEntity(c, domain.ErrExportMediaMissing.Error(), mediaErr.Files)
+		default:
+			h.logger.Error().Err(err).Str("site_id", id.String()).Msg("export site error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteZip(&buf); err != nil {
+		h.logger.Error().Err(err).Str("site_id", id.String()).Msg("export site archive error")
+		response.InternalError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-site.zip"`, export.Site.Slug))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
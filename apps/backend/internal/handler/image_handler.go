@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/imageproc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// imageCacheControl lets browsers and CDNs keep a transformed image for a
+// year: the URL names the media and every parameter, and an upload gets a
+// new media ID. Images of private storage are kept by browsers only.
+const (
+	imageCacheControl        = "public, max-age=31536000, immutable"
+	privateImageCacheControl = "private, max-age=31536000, immutable"
+)
+
+// ImageHandler serves resized, cropped and converted copies of uploaded images
+type ImageHandler struct {
+	imageService service.ImageService
+	public       bool
+	logger       zerolog.Logger
+}
+
+// NewImageHandler creates a new ImageHandler. public only changes caching;
+// whether the route needs authentication is up to the router.
+func NewImageHandler(imageService service.ImageService, public bool, logger zerolog.Logger) *ImageHandler {
+	return &ImageHandler{
+		imageService: imageService,
+		public:       public,
+		logger:       logger,
+	}
+}
+
+// Transform handles GET /img/:mediaId?w=&h=&fit=&fmt=&q=. w and h must be
+// an allowed size of the media's site; fit is contain or cover; fmt is jpeg,
+// png, webp or auto (default), which picks WebP for clients that accept it
+// when that is smaller; q is the JPEG quality, rounded to a multiple of 5.
+func (h *ImageHandler) Transform(c *gin.Context) {
+	mediaID, err := uuid.Parse(c.Param("mediaId"))
+	if err != nil {
+		response.NotFound(c, "image not found")
+		return
+	}
+
+	t, msg := parseImageTransform(c)
+	if msg != "" {
+		response.BadRequest(c, msg)
+		return
+	}
+	t.AcceptWebP = acceptsWebP(c.GetHeader("Accept"))
+
+	img, err := h.imageService.Transform(c.Request.Context(), mediaID, t)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "image not found")
+		case errors.Is(err, domain.ErrImageSizeNotAllowed):
+			response.BadRequest(c, "image size not allowed for this site")
+		case errors.Is(err, domain.ErrImageNotTransformable):
+			response.UnprocessableEntity(c, domain.ErrImageNotTransformable.Error(), nil)
+		default:
+			h.logger.Error().Err(err).Str("media_id", mediaID.String()).Msg("transform image error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	if img.Negotiated {
+		c.Header("Vary", "Accept")
+	}
+	c.Header("ETag", img.ETag)
+	if h.public {
+		c.Header("Cache-Control", imageCacheControl)
+	} else {
+		c.Header("Cache-Control", privateImageCacheControl)
+	}
+	c.Header("Content-Security-Policy", mediaFileCSP)
+	if etagMatches(c.GetHeader("If-None-Match"), img.ETag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, img.ContentType, img.Data)
+}
+
+// parseImageTransform reads the query of a transform, returning a message
+// for invalid parameters
+func parseImageTransform(c *gin.Context) (domain.ImageTransform, string) {
+	var t domain.ImageTransform
+	dimension := func(name string) (int, bool) {
+		raw := c.Query(name)
+		if raw == "" {
+			return 0, true
+		}
+		v, err := strconv.Atoi(raw)
+		return v, err == nil && v > 0 && v <= 8192
+	}
+	var ok bool
+	if t.Width, ok = dimension("w"); !ok {
+		return t, "w must be a width between 1 and 8192"
+	}
+	if t.Height, ok = dimension("h"); !ok {
+		return t, "h must be a height between 1 and 8192"
+	}
+	if t.Width == 0 && t.Height == 0 {
+		return t, "w or h is required"
+	}
+
+	switch fit := c.Query("fit"); fit {
+	case "", imageproc.FitContain, imageproc.FitCover:
+		t.Fit = fit
+	default:
+		return t, "fit must be contain or cover"
+	}
+
+	switch format := strings.ToLower(c.Query("fmt")); format {
+	case "", "auto":
+	case "jpg", imageproc.FormatJPEG:
+		t.Format = imageproc.FormatJPEG
+	case imageproc.FormatPNG, imageproc.FormatWebP:
+		t.Format = format
+	default:
+		return t, "fmt must be jpeg, png, webp or auto"
+	}
+
+	if raw := c.Query("q"); raw != "" {
+		q, err := strconv.Atoi(raw)
+		if err != nil || q < 1 || q > 100 {
+			return t, "q must be between 1 and 100"
+		}
+		// Fewer distinct qualities keep the cache from being flooded
+		t.Quality = max(5, (q+2)/5*5)
+	}
+	return t, ""
+}
+
+// acceptsWebP reports whether an Accept header lists image/webp with a
+// non-zero quality
+func acceptsWebP(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), "image/webp") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if name, value, ok := strings.Cut(param, "="); ok && strings.TrimSpace(name) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. Weak
+// comparison applies, as for GET in RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
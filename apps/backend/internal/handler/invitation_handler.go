@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// InvitationHandler handles user invitation endpoints
+type InvitationHandler struct {
+	invitationService service.InvitationService
+	logger            zerolog.Logger
+}
+
+// NewInvitationHandler creates a new InvitationHandler
+func NewInvitationHandler(invitationService service.InvitationService, logger zerolog.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		logger:            logger,
+	}
+}
+
+// ListInvitations handles GET /api/v1/admin/users/invitations
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	invitations, err := h.invitationService.ListPending(c.Request.Context())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("list invitations error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, invitations)
+}
+
+// InviteUser handles POST /api/v1/admin/users/invite
+func (h *InvitationHandler) InviteUser(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.InviteUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	invitation, err := h.invitationService.InviteUser(c.Request.Context(), input, userID, actorRole(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			response.UnprocessableEntity(c, "a valid email, a full name of 2 to 255 characters and an existing role are required", nil)
+		case errors.Is(err, domain.ErrForbidden):
+			response.Forbidden(c, "cannot assign a role with permissions you do not hold")
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "a user with this email already exists")
+		default:
+			h.logger.Error().Err(err).Msg("invite user error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.Created(c, invitation)
+}
+
+// ResendInvitation handles POST /api/v1/admin/users/invitations/:id/resend
+func (h *InvitationHandler) ResendInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid invitation ID")
+		return
+	}
+
+	invitation, err := h.invitationService.ResendInvitation(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "invitation not found or no longer pending")
+			return
+		}
+		h.logger.Error().Err(err).Str("id", id.String()).Msg("resend invitation error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, invitation)
+}
+
+// RevokeInvitation handles DELETE /api/v1/admin/users/invitations/:id
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid invitation ID")
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(c.Request.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "invitation not found or no longer pending")
+			return
+		}
+		h.logger.Error().Err(err).Str("id", id.String()).Msg("revoke invitation error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// AcceptInvitation handles POST /api/v1/auth/accept-invite
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	var input domain.AcceptInvitationInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Token == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	user, err := h.invitationService.AcceptInvitation(c.Request.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrValidation):
+			response.UnprocessableEntity(c, "password must be 8 to 72 characters", nil)
+		case errors.Is(err, domain.ErrInvalidToken):
+			response.BadRequest(c, "invalid or expired invitation")
+		default:
+			h.logger.Error().Err(err).Msg("accept invitation error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.OKWithMessage(c, "invitation accepted, you can now sign in", user)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+)
+
+// jwksCacheControl lets verifiers cache the key set; a rotated-in key is
+// published before it signs, so keep this shorter than the rotation overlap
+const jwksCacheControl = "public, max-age=300"
+
+// JWKSHandler publishes the public keys access tokens are signed with
+type JWKSHandler struct {
+	jwtManager *auth.JWTManager
+}
+
+// NewJWKSHandler creates a new JWKSHandler
+func NewJWKSHandler(jwtManager *auth.JWTManager) *JWKSHandler {
+	return &JWKSHandler{jwtManager: jwtManager}
+}
+
+// JWKS handles GET /.well-known/jwks.json. The body is a bare JWK Set
+// (RFC 7517), not the usual response envelope, so standard JWT libraries can
+// consume it directly.
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", jwksCacheControl)
+	c.JSON(http.StatusOK, h.jwtManager.JWKS())
+}
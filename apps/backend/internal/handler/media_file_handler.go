@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+)
+
+// mediaFileCSP keeps uploaded files from running scripts on the API origin,
+// e.g. an SVG or HTML file opened directly
+const mediaFileCSP = "default-src 'none'; img-src 'self' data:; style-src 'unsafe-inline'; sandbox"
+
+// MediaFileHandler serves the files of the local storage backend
+type MediaFileHandler struct {
+	storage storage.Backend
+	public  bool
+	logger  zerolog.Logger
+}
+
+// NewMediaFileHandler creates a new MediaFileHandler. public only changes
+// caching; whether the route needs authentication is up to the router.
+func NewMediaFileHandler(store storage.Backend, public bool, logger zerolog.Logger) *MediaFileHandler {
+	return &MediaFileHandler{storage: store, public: public, logger: logger}
+}
+
+// ServeFile handles GET /media/*key. Range and conditional requests are
+// supported when the backend returns a seekable file.
+func (h *MediaFileHandler) ServeFile(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	body, info, err := h.storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+			response.NotFound(c, "file not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("key", key).Msg("read media file error")
+		response.InternalError(c, err)
+		return
+	}
+	defer body.Close()
+
+	c.Header("Content-Type", info.ContentType)
+	c.Header("Content-Security-Policy", mediaFileCSP)
+	if h.public {
+		c.Header("Cache-Control", "public, max-age=86400")
+	} else {
+		c.Header("Cache-Control", "private, max-age=86400")
+	}
+
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, path.Base(info.Key), info.ModTime, seeker)
+		return
+	}
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, body, nil)
+}
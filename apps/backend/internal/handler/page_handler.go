@@ -14,15 +14,17 @@ import (
 
 // PageHandler handles page-related endpoints
 type PageHandler struct {
-	pageService service.PageService
-	logger      zerolog.Logger
+	pageService  service.PageService
+	mediaService service.MediaService
+	logger       zerolog.Logger
 }
 
 // NewPageHandler creates a new PageHandler
-func NewPageHandler(pageService service.PageService, logger zerolog.Logger) *PageHandler {
+func NewPageHandler(pageService service.PageService, mediaService service.MediaService, logger zerolog.Logger) *PageHandler {
 	return &PageHandler{
-		pageService: pageService,
-		logger:      logger,
+		pageService:  pageService,
+		mediaService: mediaService,
+		logger:       logger,
 	}
 }
 
@@ -39,10 +41,10 @@ func (h *PageHandler) GetPublicPage(c *gin.Context) {
 			response.BadRequest(c, "invalid site_id")
 			return
 		}
+	} else if resolved := siteIDFromContext(c); resolved != nil {
+		siteID = *resolved
 	} else {
-		// Use default site - in production, resolve from domain
-		// For now, we'll require site_id or use a default
-		response.BadRequest(c, "site_id is required")
+		response.BadRequest(c, "site could not be resolved from host; site_id is required")
 		return
 	}
 
@@ -63,6 +65,11 @@ func (h *PageHandler) GetPublicPage(c *gin.Context) {
 		return
 	}
 
+	// The page is still usable without srcset data
+	if err := h.mediaService.AttachResponsiveImages(c.Request.Context(), page); err != nil {
+		h.logger.Error().Err(err).Str("slug", slug).Msg("attach responsive images error")
+	}
+
 	response.OK(c, page)
 }
 
@@ -82,6 +89,8 @@ func (h *PageHandler) ListPages(c *gin.Context) {
 			return
 		}
 		filter.SiteID = &siteID
+	} else {
+		filter.SiteIDs = accessibleSiteIDsFromContext(c)
 	}
 
 	statusStr := c.Query("status")
@@ -263,6 +272,67 @@ func (h *PageHandler) UnpublishPage(c *gin.Context) {
 	response.OKWithMessage(c, "page unpublished successfully", page)
 }
 
+// SchedulePage handles PUT /api/v1/admin/pages/:id/schedule
+func (h *PageHandler) SchedulePage(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	var input domain.SchedulePageInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	page, err := h.pageService.SchedulePage(c.Request.Context(), id, input, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "page not found")
+		case errors.Is(err, domain.ErrScheduleInPast):
+			response.UnprocessableEntity(c, domain.ErrScheduleInPast.Error(), nil)
+		case errors.Is(err, domain.ErrScheduleOrder):
+			response.UnprocessableEntity(c, domain.ErrScheduleOrder.Error(), nil)
+		default:
+			h.logger.Error().Err(err).Str("id", id.String()).Msg("schedule page error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.OKWithMessage(c, "page schedule updated successfully", page)
+}
+
+// ClearSchedule handles DELETE /api/v1/admin/pages/:id/schedule
+func (h *PageHandler) ClearSchedule(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	page, err := h.pageService.ClearSchedule(c.Request.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "page not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("id", id.String()).Msg("clear page schedule error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OKWithMessage(c, "page schedule cleared successfully", page)
+}
+
 // ListSections handles GET /api/v1/admin/pages/:id/sections
 func (h *PageHandler) ListSections(c *gin.Context) {
 	pageID, err := uuid.Parse(c.Param("id"))
@@ -296,7 +366,10 @@ func (h *PageHandler) CreateSection(c *gin.Context) {
 	}
 	input.PageID = pageID
 
-	section, err := h.pageService.CreateSection(c.Request.Context(), input)
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	section, err := h.pageService.CreateSection(c.Request.Context(), input, userID)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("create section error")
 		response.InternalError(c, err)
@@ -320,7 +393,10 @@ func (h *PageHandler) UpdateSection(c *gin.Context) {
 		return
 	}
 
-	section, err := h.pageService.UpdateSection(c.Request.Context(), id, input)
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	section, err := h.pageService.UpdateSection(c.Request.Context(), id, input, userID)
 	if err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			response.NotFound(c, "section not found")
@@ -342,7 +418,10 @@ func (h *PageHandler) DeleteSection(c *gin.Context) {
 		return
 	}
 
-	if err := h.pageService.DeleteSection(c.Request.Context(), id); err != nil {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	if err := h.pageService.DeleteSection(c.Request.Context(), id, userID); err != nil {
 		if errors.Is(err, domain.ErrNotFound) {
 			response.NotFound(c, "section not found")
 			return
@@ -363,7 +442,10 @@ func (h *PageHandler) ReorderSections(c *gin.Context) {
 		return
 	}
 
-	if err := h.pageService.ReorderSections(c.Request.Context(), input); err != nil {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	if err := h.pageService.ReorderSections(c.Request.Context(), input, userID); err != nil {
 		h.logger.Error().Err(err).Msg("reorder sections error")
 		response.InternalError(c, err)
 		return
@@ -404,8 +486,16 @@ func (h *PageHandler) UpsertContent(c *gin.Context) {
 		return
 	}
 
-	content, err := h.pageService.UpsertContent(c.Request.Context(), sectionID, input)
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	content, err := h.pageService.UpsertContent(c.Request.Context(), sectionID, input, userID)
 	if err != nil {
+		var schemaErr *domain.ContentSchemaError
+		if errors.As(err, &schemaErr) {
+			response.UnprocessableEntity(c, domain.ErrContentSchema.Error(), schemaErr.Problems)
+			return
+		}
 		h.logger.Error().Err(err).Msg("upsert content error")
 		response.InternalError(c, err)
 		return
@@ -428,8 +518,16 @@ func (h *PageHandler) BulkUpsertContents(c *gin.Context) {
 		return
 	}
 
-	contents, err := h.pageService.BulkUpsertContents(c.Request.Context(), sectionID, inputs)
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	contents, err := h.pageService.BulkUpsertContents(c.Request.Context(), sectionID, inputs, userID)
 	if err != nil {
+		var schemaErr *domain.ContentSchemaError
+		if errors.As(err, &schemaErr) {
+			response.UnprocessableEntity(c, domain.ErrContentSchema.Error(), schemaErr.Problems)
+			return
+		}
 		h.logger.Error().Err(err).Msg("bulk upsert contents error")
 		response.InternalError(c, err)
 		return
@@ -446,10 +544,18 @@ func (h *PageHandler) DeleteContent(c *gin.Context) {
 		return
 	}
 
-	if err := h.pageService.DeleteContent(c.Request.Context(), id); err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	if err := h.pageService.DeleteContent(c.Request.Context(), id, userID); err != nil {
+		var schemaErr *domain.ContentSchemaError
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
 			response.NotFound(c, "content not found")
 			return
+		case errors.As(err, &schemaErr):
+			response.UnprocessableEntity(c, domain.ErrContentSchema.Error(), schemaErr.Problems)
+			return
 		}
 		h.logger.Error().Err(err).Str("id", id.String()).Msg("delete content error")
 		response.InternalError(c, err)
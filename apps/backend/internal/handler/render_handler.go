@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+const htmlContentType = "text/html; charset=utf-8"
+
+// RenderHandler serves server-rendered HTML for published pages
+type RenderHandler struct {
+	renderService service.RenderService
+	logger        zerolog.Logger
+}
+
+// NewRenderHandler creates a new RenderHandler
+func NewRenderHandler(renderService service.RenderService, logger zerolog.Logger) *RenderHandler {
+	return &RenderHandler{
+		renderService: renderService,
+		logger:        logger,
+	}
+}
+
+// RenderPage handles GET /render/:slug and GET /render (homepage)
+func (h *RenderHandler) RenderPage(c *gin.Context) {
+	var siteID uuid.UUID
+	if siteIDStr := c.Query("site_id"); siteIDStr != "" {
+		parsed, err := uuid.Parse(siteIDStr)
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid site_id")
+			return
+		}
+		siteID = parsed
+	} else if resolved := siteIDFromContext(c); resolved != nil {
+		siteID = *resolved
+	} else {
+		c.String(http.StatusNotFound, "site not found")
+		return
+	}
+
+	slug := c.Param("slug")
+	body, err := h.renderService.RenderPage(c.Request.Context(), siteID, slug)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			c.String(http.StatusNotFound, "page not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("slug", slug).Msg("render page error")
+		c.String(http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	c.Data(http.StatusOK, htmlContentType, body)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// RevisionHandler handles page revision history endpoints
+type RevisionHandler struct {
+	revisionService service.RevisionService
+	logger          zerolog.Logger
+}
+
+// NewRevisionHandler creates a new RevisionHandler
+func NewRevisionHandler(revisionService service.RevisionService, logger zerolog.Logger) *RevisionHandler {
+	return &RevisionHandler{
+		revisionService: revisionService,
+		logger:          logger,
+	}
+}
+
+// ListRevisions handles GET /api/v1/admin/pages/:id/revisions
+func (h *RevisionHandler) ListRevisions(c *gin.Context) {
+	pageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	var pagination domain.Pagination
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		response.BadRequest(c, "invalid query parameters")
+		return
+	}
+
+	result, err := h.revisionService.ListRevisions(c.Request.Context(), pageID, pagination)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "page not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("page_id", pageID.String()).Msg("list revisions error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OKPaginated(c, result.Data, gin.H{
+		"page":        result.Page,
+		"per_page":    result.PerPage,
+		"total":       result.Total,
+		"total_pages": result.TotalPages,
+	})
+}
+
+// GetRevision handles GET /api/v1/admin/pages/:id/revisions/:rev
+func (h *RevisionHandler) GetRevision(c *gin.Context) {
+	pageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		response.BadRequest(c, "invalid revision number")
+		return
+	}
+
+	revision, err := h.revisionService.GetRevision(c.Request.Context(), pageID, number)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "revision not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("page_id", pageID.String()).Msg("get revision error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, revision)
+}
+
+// DiffRevisions handles GET /api/v1/admin/pages/:id/revisions/diff?from=&to=
+func (h *RevisionHandler) DiffRevisions(c *gin.Context) {
+	pageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		response.BadRequest(c, "from must be a revision number")
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		response.BadRequest(c, "to must be a revision number")
+		return
+	}
+
+	diff, err := h.revisionService.DiffRevisions(c.Request.Context(), pageID, from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "revision not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("page_id", pageID.String()).Msg("diff revisions error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, diff)
+}
+
+// RestoreRevision handles POST /api/v1/admin/pages/:id/revisions/:rev/restore
+func (h *RevisionHandler) RestoreRevision(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	pageID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid page ID")
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil {
+		response.BadRequest(c, "invalid revision number")
+		return
+	}
+
+	page, err := h.revisionService.RestoreRevision(c.Request.Context(), pageID, number, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "revision not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("page_id", pageID.String()).Int("revision", number).Msg("restore revision error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OKWithMessage(c, "revision restored successfully", page)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// RoleHandler handles role management endpoints
+type RoleHandler struct {
+	roleService service.RoleService
+	logger      zerolog.Logger
+}
+
+// NewRoleHandler creates a new RoleHandler
+func NewRoleHandler(roleService service.RoleService, logger zerolog.Logger) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// ListRoles handles GET /api/v1/admin/roles
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("list roles error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, roles)
+}
+
+// ListPermissions handles GET /api/v1/admin/roles/permissions
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	response.OK(c, domain.Permissions())
+}
+
+// GetRole handles GET /api/v1/admin/roles/:id
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid role ID")
+		return
+	}
+
+	role, err := h.roleService.GetRole(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err, "get role error")
+		return
+	}
+
+	response.OK(c, role)
+}
+
+// CreateRole handles POST /api/v1/admin/roles
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var input domain.CreateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), input, actorRole(c))
+	if err != nil {
+		h.handleError(c, err, "create role error")
+		return
+	}
+
+	response.Created(c, role)
+}
+
+// UpdateRole handles PUT /api/v1/admin/roles/:id
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid role ID")
+		return
+	}
+
+	var input domain.UpdateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), id, input, actorRole(c))
+	if err != nil {
+		h.handleError(c, err, "update role error")
+		return
+	}
+
+	response.OK(c, role)
+}
+
+// DeleteRole handles DELETE /api/v1/admin/roles/:id
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid role ID")
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), id); err != nil {
+		h.handleError(c, err, "delete role error")
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// handleError maps role errors to responses
+func (h *RoleHandler) handleError(c *gin.Context, err error, msg string) {
+	var roleErr *domain.RoleError
+	switch {
+	case errors.As(err, &roleErr):
+		response.UnprocessableEntity(c, "invalid role", roleErr.Problems)
+	case errors.Is(err, domain.ErrNotFound):
+		response.NotFound(c, "role not found")
+	case errors.Is(err, domain.ErrForbidden):
+		response.Forbidden(c, "cannot grant permissions you do not hold")
+	case errors.Is(err, domain.ErrRoleInUse):
+		response.Conflict(c, "role is still assigned to users")
+	default:
+		h.logger.Error().Err(err).Msg(msg)
+		response.InternalError(c, err)
+	}
+}
+
+// actorRole returns the role of the authenticated user
+func actorRole(c *gin.Context) domain.UserRole {
+	roleVal, _ := c.Get(middleware.ContextKeyRole)
+	role, _ := roleVal.(domain.UserRole)
+	return role
+}
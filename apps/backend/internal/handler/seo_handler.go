@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// seoCacheControl lets crawlers and CDNs reuse the files briefly; the server
+// side cache is invalidated on publish
+const seoCacheControl = "public, max-age=300"
+
+// SEOHandler serves sitemap.xml and robots.txt for the site resolved from the Host header
+type SEOHandler struct {
+	seoService service.SEOService
+	logger     zerolog.Logger
+}
+
+// NewSEOHandler creates a new SEOHandler
+func NewSEOHandler(seoService service.SEOService, logger zerolog.Logger) *SEOHandler {
+	return &SEOHandler{
+		seoService: seoService,
+		logger:     logger,
+	}
+}
+
+// Sitemap handles GET /sitemap.xml. Large sites serve a sitemap index whose
+// entries are fetched with ?page=N.
+func (h *SEOHandler) Sitemap(c *gin.Context) {
+	siteID := siteIDFromContext(c)
+	if siteID == nil {
+		c.String(http.StatusNotFound, "site not found")
+		return
+	}
+
+	part := 0
+	if pageStr := c.Query("page"); pageStr != "" {
+		parsed, err := strconv.Atoi(pageStr)
+		if err != nil || parsed < 1 {
+			c.String(http.StatusNotFound, "sitemap not found")
+			return
+		}
+		part = parsed
+	}
+
+	body, err := h.seoService.Sitemap(c.Request.Context(), *siteID, part)
+	if err != nil {
+		h.respondError(c, err, *siteID, "sitemap not found")
+		return
+	}
+
+	c.Header("Cache-Control", seoCacheControl)
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// Robots handles GET /robots.txt
+func (h *SEOHandler) Robots(c *gin.Context) {
+	siteID := siteIDFromContext(c)
+	if siteID == nil {
+		c.String(http.StatusNotFound, "site not found")
+		return
+	}
+
+	body, err := h.seoService.Robots(c.Request.Context(), *siteID)
+	if err != nil {
+		h.respondError(c, err, *siteID, "site not found")
+		return
+	}
+
+	c.Header("Cache-Control", seoCacheControl)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", body)
+}
+
+func (h *SEOHandler) respondError(c *gin.Context, err error, siteID uuid.UUID, notFound string) {
+	if errors.Is(err, domain.ErrNotFound) {
+		c.String(http.StatusNotFound, notFound)
+		return
+	}
+	h.logger.Error().Err(err).Str("site_id", siteID.String()).Str("path", c.Request.URL.Path).Msg("seo file error")
+	c.String(http.StatusInternalServerError, "internal server error")
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// SessionHandler handles session listing and revocation endpoints
+type SessionHandler struct {
+	sessionService service.SessionService
+	logger         zerolog.Logger
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(sessionService service.SessionService, logger zerolog.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		logger:         logger,
+	}
+}
+
+// ListSessions handles GET /api/v1/auth/sessions
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	currentToken, _ := c.Cookie(refreshTokenCookieName)
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID, currentToken)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("list sessions error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, sessions)
+}
+
+// RevokeSession handles DELETE /api/v1/auth/sessions/:id
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid session ID")
+		return
+	}
+
+	h.revokeSession(c, userID, sessionID)
+}
+
+// RevokeOtherSessions handles DELETE /api/v1/auth/sessions
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	currentToken, _ := c.Cookie(refreshTokenCookieName)
+	revoked, err := h.sessionService.RevokeOtherSessions(c.Request.Context(), userID, currentToken)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("revoke other sessions error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OKWithMessage(c, "signed out of all other sessions", gin.H{"revoked": revoked})
+}
+
+// ListUserSessions handles GET /api/v1/admin/users/:id/sessions
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user ID")
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID, "")
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "user not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("user_id", userID.String()).Msg("list user sessions error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, sessions)
+}
+
+// RevokeUserSession handles DELETE /api/v1/admin/users/:id/sessions/:sessionId
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user ID")
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		response.BadRequest(c, "invalid session ID")
+		return
+	}
+
+	h.revokeSession(c, userID, sessionID)
+}
+
+// RevokeUserSessions handles DELETE /api/v1/admin/users/:id/sessions
+func (h *SessionHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid user ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeAllSessions(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "user not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("user_id", userID.String()).Msg("revoke user sessions error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OKWithMessage(c, "user signed out of all sessions", nil)
+}
+
+// revokeSession revokes one session of userID
+func (h *SessionHandler) revokeSession(c *gin.Context, userID, sessionID uuid.UUID) {
+	if err := h.sessionService.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "session not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("session_id", sessionID.String()).Msg("revoke session error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
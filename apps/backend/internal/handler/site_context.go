@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+)
+
+// siteIDFromContext returns the ID of the site resolved from the request host, if any
+func siteIDFromContext(c *gin.Context) *uuid.UUID {
+	val, exists := c.Get(middleware.ContextKeySiteID)
+	if !exists {
+		return nil
+	}
+	siteID, ok := val.(uuid.UUID)
+	if !ok {
+		return nil
+	}
+	return &siteID
+}
+
+// accessibleSiteIDsFromContext returns the sites a list may return, or nil
+// when the caller can access every site
+func accessibleSiteIDsFromContext(c *gin.Context) []uuid.UUID {
+	val, exists := c.Get(middleware.ContextKeySiteIDs)
+	if !exists {
+		return nil
+	}
+	siteIDs, _ := val.([]uuid.UUID)
+	return siteIDs
+}
+
+// siteFromContext returns the site resolved from the request host, if any
+func siteFromContext(c *gin.Context) *domain.Site {
+	val, exists := c.Get(middleware.ContextKeySite)
+	if !exists {
+		return nil
+	}
+	site, _ := val.(*domain.Site)
+	return site
+}
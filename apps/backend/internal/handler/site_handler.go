@@ -26,23 +26,29 @@ func NewSiteHandler(siteService service.SiteService, logger zerolog.Logger) *Sit
 	}
 }
 
-// GetPublicSite handles GET /api/v1/public/site/:slug
+// GetPublicSite handles GET /api/v1/public/site/:slug and GET /api/v1/public/site
+// (the site resolved from the request host)
 func (h *SiteHandler) GetPublicSite(c *gin.Context) {
 	slug := c.Param("slug")
-	if slug == "" {
-		slug = "default"
-	}
 
-	// Find site by slug
-	siteBySlug, err := h.siteService.GetSiteBySlug(c.Request.Context(), slug)
-	if err != nil {
-		if errors.Is(err, domain.ErrNotFound) {
-			response.NotFound(c, "site not found")
+	siteBySlug := siteFromContext(c)
+	if slug != "" || siteBySlug == nil {
+		if slug == "" {
+			slug = "default"
+		}
+
+		// Find site by slug
+		var err error
+		siteBySlug, err = h.siteService.GetSiteBySlug(c.Request.Context(), slug)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				response.NotFound(c, "site not found")
+				return
+			}
+			h.logger.Error().Err(err).Str("slug", slug).Msg("get public site error")
+			response.InternalError(c, err)
 			return
 		}
-		h.logger.Error().Err(err).Str("slug", slug).Msg("get public site error")
-		response.InternalError(c, err)
-		return
 	}
 
 	// Get with public settings only
@@ -90,6 +96,7 @@ func (h *SiteHandler) ListSites(c *gin.Context) {
 	if search != "" {
 		filter.Search = &search
 	}
+	filter.IDs = accessibleSiteIDsFromContext(c)
 
 	result, err := h.siteService.ListSites(c.Request.Context(), filter)
 	if err != nil {
@@ -202,6 +209,87 @@ func (h *SiteHandler) DeleteSite(c *gin.Context) {
 	response.NoContent(c)
 }
 
+// ListDomains handles GET /api/v1/admin/sites/:id/domains
+func (h *SiteHandler) ListDomains(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+
+	domains, err := h.siteService.ListDomains(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "site not found")
+			return
+		}
+		h.logger.Error().Err(err).Msg("list site domains error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, domains)
+}
+
+// AddDomain handles POST /api/v1/admin/sites/:id/domains
+func (h *SiteHandler) AddDomain(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+
+	var input domain.AddSiteDomainInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	siteDomain, err := h.siteService.AddDomain(c.Request.Context(), id, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "site not found")
+		case errors.Is(err, domain.ErrInvalidDomain):
+			response.UnprocessableEntity(c, domain.ErrInvalidDomain.Error(), nil)
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "domain is already used by a site")
+		default:
+			h.logger.Error().Err(err).Msg("add site domain error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.Created(c, siteDomain)
+}
+
+// RemoveDomain handles DELETE /api/v1/admin/sites/:id/domains/:domainId
+func (h *SiteHandler) RemoveDomain(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+	domainID, err := uuid.Parse(c.Param("domainId"))
+	if err != nil {
+		response.BadRequest(c, "invalid domain ID")
+		return
+	}
+
+	if err := h.siteService.RemoveDomain(c.Request.Context(), id, domainID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "domain not found")
+			return
+		}
+		h.logger.Error().Err(err).Msg("remove site domain error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
 // GetSettings handles GET /api/v1/admin/sites/:id/settings
 func (h *SiteHandler) GetSettings(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
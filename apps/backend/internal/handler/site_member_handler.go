@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// SiteMemberHandler handles site membership endpoints
+type SiteMemberHandler struct {
+	memberService service.SiteMemberService
+	logger        zerolog.Logger
+}
+
+// NewSiteMemberHandler creates a new SiteMemberHandler
+func NewSiteMemberHandler(memberService service.SiteMemberService, logger zerolog.Logger) *SiteMemberHandler {
+	return &SiteMemberHandler{
+		memberService: memberService,
+		logger:        logger,
+	}
+}
+
+// ListMembers handles GET /api/v1/admin/sites/:id/members
+func (h *SiteMemberHandler) ListMembers(c *gin.Context) {
+	siteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+
+	members, err := h.memberService.ListMembers(c.Request.Context(), siteID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "site not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("site_id", siteID.String()).Msg("list site members error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, members)
+}
+
+// SetMember handles PUT /api/v1/admin/sites/:id/members/:userId
+func (h *SiteMemberHandler) SetMember(c *gin.Context) {
+	actorIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	actorID, _ := actorIDVal.(uuid.UUID)
+
+	siteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "invalid user ID")
+		return
+	}
+
+	var input domain.SetSiteMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+	if input.Role != domain.RoleAdmin && input.Role != domain.RoleEditor {
+		response.BadRequest(c, "role must be admin or editor")
+		return
+	}
+
+	membership, err := h.memberService.SetMember(c.Request.Context(), siteID, userID, input, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "site or user not found")
+		case errors.Is(err, domain.ErrValidation):
+			response.UnprocessableEntity(c, "users whose role has site.all already have access to every site", nil)
+		default:
+			h.logger.Error().Err(err).Str("site_id", siteID.String()).Msg("set site member error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.OK(c, membership)
+}
+
+// RemoveMember handles DELETE /api/v1/admin/sites/:id/members/:userId
+func (h *SiteMemberHandler) RemoveMember(c *gin.Context) {
+	siteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid site ID")
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		response.BadRequest(c, "invalid user ID")
+		return
+	}
+
+	if err := h.memberService.RemoveMember(c.Request.Context(), siteID, userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "membership not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("site_id", siteID.String()).Msg("remove site member error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
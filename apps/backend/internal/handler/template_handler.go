@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// TemplateHandler handles page template and section preset endpoints
+type TemplateHandler struct {
+	templateService service.TemplateService
+	logger          zerolog.Logger
+}
+
+// NewTemplateHandler creates a new TemplateHandler
+func NewTemplateHandler(templateService service.TemplateService, logger zerolog.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+// ListTemplates handles GET /api/v1/admin/templates
+func (h *TemplateHandler) ListTemplates(c *gin.Context) {
+	templates, err := h.templateService.ListTemplates(c.Request.Context())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("list templates error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, templates)
+}
+
+// ListPresets handles GET /api/v1/admin/templates/presets
+func (h *TemplateHandler) ListPresets(c *gin.Context) {
+	response.OK(c, h.templateService.ListPresets())
+}
+
+// GetTemplate handles GET /api/v1/admin/templates/:id
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid template ID")
+		return
+	}
+
+	tmpl, err := h.templateService.GetTemplate(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "template not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("id", id.String()).Msg("get template error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, tmpl)
+}
+
+// CreateTemplate handles POST /api/v1/admin/templates
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.CreateTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	tmpl, err := h.templateService.CreateTemplate(c.Request.Context(), input, userID)
+	if err != nil {
+		h.handleTemplateError(c, err, "create template error")
+		return
+	}
+
+	response.Created(c, tmpl)
+}
+
+// UpdateTemplate handles PUT /api/v1/admin/templates/:id
+func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid template ID")
+		return
+	}
+
+	var input domain.UpdateTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	tmpl, err := h.templateService.UpdateTemplate(c.Request.Context(), id, input)
+	if err != nil {
+		h.handleTemplateError(c, err, "update template error")
+		return
+	}
+
+	response.OK(c, tmpl)
+}
+
+// DeleteTemplate handles DELETE /api/v1/admin/templates/:id
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "invalid template ID")
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(c.Request.Context(), id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			response.NotFound(c, "template not found")
+			return
+		}
+		h.logger.Error().Err(err).Str("id", id.String()).Msg("delete template error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.NoContent(c)
+}
+
+// CreatePageFromTemplate handles POST /api/v1/admin/pages/from-template/:templateId
+func (h *TemplateHandler) CreatePageFromTemplate(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	templateID, err := uuid.Parse(c.Param("templateId"))
+	if err != nil {
+		response.BadRequest(c, "invalid template ID")
+		return
+	}
+
+	var input domain.CreatePageFromTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	page, err := h.templateService.CreatePageFromTemplate(c.Request.Context(), templateID, input, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			response.NotFound(c, "template not found")
+		case errors.Is(err, domain.ErrValidation):
+			response.UnprocessableEntity(c, "title is required", nil)
+		case errors.Is(err, domain.ErrAlreadyExists):
+			response.Conflict(c, "a page with this slug already exists")
+		default:
+			h.logger.Error().Err(err).Msg("create page from template error")
+			response.InternalError(c, err)
+		}
+		return
+	}
+
+	response.Created(c, page)
+}
+
+// handleTemplateError maps template save errors to responses
+func (h *TemplateHandler) handleTemplateError(c *gin.Context, err error, msg string) {
+	var schemaErr *domain.ContentSchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		response.UnprocessableEntity(c, "invalid template", schemaErr.Problems)
+	case errors.Is(err, domain.ErrNotFound):
+		response.NotFound(c, "template not found")
+	case errors.Is(err, domain.ErrAlreadyExists):
+		response.Conflict(c, "a template with this name already exists")
+	default:
+		h.logger.Error().Err(err).Msg(msg)
+		response.InternalError(c, err)
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// TwoFactorHandler handles two-factor enrollment and policy endpoints
+type TwoFactorHandler struct {
+	twoFactorService service.TwoFactorService
+	logger           zerolog.Logger
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler
+func NewTwoFactorHandler(twoFactorService service.TwoFactorService, logger zerolog.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		logger:           logger,
+	}
+}
+
+// Status handles GET /api/v1/auth/2fa
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	status, err := h.twoFactorService.Status(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("two-factor status error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, status)
+}
+
+// Setup handles POST /api/v1/auth/2fa/setup
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	setup, err := h.twoFactorService.Setup(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err, "two-factor setup error")
+		return
+	}
+
+	response.OK(c, setup)
+}
+
+// Enable handles POST /api/v1/auth/2fa/enable
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		h.handleError(c, err, "two-factor enable error")
+		return
+	}
+
+	response.OKWithMessage(c, "two-factor authentication enabled, store the recovery codes safely", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// Disable handles POST /api/v1/auth/2fa/disable
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), userID, input); err != nil {
+		h.handleError(c, err, "two-factor disable error")
+		return
+	}
+
+	response.OKWithMessage(c, "two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes handles POST /api/v1/auth/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Code == "" {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		h.handleError(c, err, "regenerate recovery codes error")
+		return
+	}
+
+	response.OK(c, gin.H{"recovery_codes": codes})
+}
+
+// ListPolicies handles GET /api/v1/admin/2fa/policies
+func (h *TwoFactorHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.twoFactorService.ListPolicies(c.Request.Context())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("list two-factor policies error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, policies)
+}
+
+// SetPolicy handles PUT /api/v1/admin/2fa/policies/:role
+func (h *TwoFactorHandler) SetPolicy(c *gin.Context) {
+	userIDVal, _ := c.Get(middleware.ContextKeyUserID)
+	userID, _ := userIDVal.(uuid.UUID)
+
+	var input domain.SetTwoFactorPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		response.BadRequest(c, "invalid request body")
+		return
+	}
+
+	policy, err := h.twoFactorService.SetPolicy(c.Request.Context(), domain.UserRole(c.Param("role")), input, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrValidation) {
+			response.BadRequest(c, "unknown role")
+			return
+		}
+		h.logger.Error().Err(err).Msg("set two-factor policy error")
+		response.InternalError(c, err)
+		return
+	}
+
+	response.OK(c, policy)
+}
+
+// handleError maps two-factor errors to responses
+func (h *TwoFactorHandler) handleError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTwoFactorCode):
+		response.BadRequest(c, "invalid two-factor code")
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		response.BadRequest(c, "password is incorrect")
+	case errors.Is(err, domain.ErrAlreadyExists):
+		response.Conflict(c, "two-factor authentication is already enabled")
+	case errors.Is(err, domain.ErrNotFound):
+		response.BadRequest(c, "two-factor setup has not been started")
+	case errors.Is(err, domain.ErrForbidden):
+		response.Forbidden(c, "two-factor authentication is required for your role")
+	case errors.Is(err, domain.ErrAccountLocked):
+		response.Unauthorized(c, "account is temporarily locked due to too many failed attempts")
+	default:
+		h.logger.Error().Err(err).Msg(msg)
+		response.InternalError(c, err)
+	}
+}
@@ -12,21 +12,24 @@ import (
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
 )
 
 // UserHandler handles user management endpoints
 type UserHandler struct {
-	userRepo   repository.UserRepository
-	logger     zerolog.Logger
-	bcryptCost int
+	userRepo    repository.UserRepository
+	roleService service.RoleService
+	logger      zerolog.Logger
+	bcryptCost  int
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userRepo repository.UserRepository, logger zerolog.Logger, bcryptCost int) *UserHandler {
+func NewUserHandler(userRepo repository.UserRepository, roleService service.RoleService, logger zerolog.Logger, bcryptCost int) *UserHandler {
 	return &UserHandler{
-		userRepo:   userRepo,
-		logger:     logger,
-		bcryptCost: bcryptCost,
+		userRepo:    userRepo,
+		roleService: roleService,
+		logger:      logger,
+		bcryptCost:  bcryptCost,
 	}
 }
 
@@ -104,6 +107,10 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	if !h.checkAssign(c, input.Role) {
+		return
+	}
+
 	// Check if email already exists
 	_, err := h.userRepo.FindByEmail(c.Request.Context(), input.Email)
 	if err == nil {
@@ -174,12 +181,19 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	if input.AvatarURL != nil {
 		user.AvatarURL = input.AvatarURL
 	}
-	if input.Role != nil {
+	if input.Role != nil && *input.Role != user.Role {
+		// Changing a role takes the permissions of both roles
+		if !h.checkAssign(c, user.Role) || !h.checkAssign(c, *input.Role) {
+			return
+		}
 		user.Role = *input.Role
 	}
 	if input.Status != nil {
 		user.Status = *input.Status
 	}
+	if input.PasswordLoginDisabled != nil {
+		user.PasswordLoginDisabled = *input.PasswordLoginDisabled
+	}
 
 	if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
 		h.logger.Error().Err(err).Msg("update user error")
@@ -210,3 +224,21 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	response.NoContent(c)
 }
+
+// checkAssign responds and returns false unless the caller may give a user
+// role: the role must exist and the caller must hold all its permissions
+func (h *UserHandler) checkAssign(c *gin.Context, role domain.UserRole) bool {
+	err := h.roleService.CheckAssign(c.Request.Context(), actorRole(c), role)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrNotFound):
+		response.UnprocessableEntity(c, "unknown role", nil)
+	case errors.Is(err, domain.ErrForbidden):
+		response.Forbidden(c, "cannot assign a role with permissions you do not hold")
+	default:
+		h.logger.Error().Err(err).Msg("check role error")
+		response.InternalError(c, err)
+	}
+	return false
+}
@@ -12,11 +12,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
-	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
 )
 
+// AuditRecorder stores audit log entries
+type AuditRecorder interface {
+	CreateAuditLog(ctx context.Context, log *domain.AuditLog) error
+}
+
 // AuditLogger creates a middleware that automatically logs admin actions to the audit log
-func AuditLogger(compRepo repository.ComponentRepository, logger zerolog.Logger) gin.HandlerFunc {
+func AuditLogger(recorder AuditRecorder, logger zerolog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Only audit write operations
 		method := c.Request.Method
@@ -25,9 +29,9 @@ func AuditLogger(compRepo repository.ComponentRepository, logger zerolog.Logger)
 			return
 		}
 
-		// Capture request body for audit
+		// Capture request body for audit; uploads are not copied into memory
 		var requestBody []byte
-		if c.Request.Body != nil {
+		if c.Request.Body != nil && c.ContentType() == "application/json" {
 			requestBody, _ = io.ReadAll(c.Request.Body)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
@@ -67,8 +71,9 @@ func AuditLogger(compRepo repository.ComponentRepository, logger zerolog.Logger)
 		}
 
 		// Parse request body for new values
+		// Imported site bundles are too large to copy into the audit log
 		var newValues domain.JSONMap
-		if len(requestBody) > 0 {
+		if len(requestBody) > 0 && !strings.HasSuffix(c.FullPath(), "/import") {
 			if err := json.Unmarshal(requestBody, &newValues); err != nil {
 				logger.Debug().Err(err).Msg("failed to parse request body for audit log")
 			}
@@ -115,7 +120,7 @@ func AuditLogger(compRepo repository.ComponentRepository, logger zerolog.Logger)
 		go func() {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			defer cancel()
-			if err := compRepo.CreateAuditLog(ctx, log); err != nil {
+			if err := recorder.CreateAuditLog(ctx, log); err != nil {
 				logger.Error().Err(err).Msg("failed to create audit log")
 			}
 		}()
@@ -141,8 +146,12 @@ func extractAuditInfo(method, path, id string) (action, resourceType, resourceNa
 		action = "publish"
 	} else if strings.Contains(path, "/unpublish") {
 		action = "unpublish"
+	} else if strings.Contains(path, "/restore") {
+		action = "restore"
 	} else if strings.Contains(path, "/upload") {
 		action = "upload"
+	} else if strings.Contains(path, "/export") {
+		action = "download"
 	} else if strings.Contains(path, "/change-password") {
 		action = "password_change"
 	} else if strings.Contains(path, "/login") {
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
@@ -14,10 +18,20 @@ const (
 	ContextKeyEmail  = "user_email"
 	ContextKeyRole   = "user_role"
 	ContextKeyClaims = "jwt_claims"
+	ContextKeyAPIKey = "api_key"
+
+	// contextKeyScopeChecked marks an API key request that passed RequireScope
+	contextKeyScopeChecked = "api_key_scope_checked"
 )
 
-// AuthMiddleware validates JWT access tokens
-func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
+// APIKeyAuthenticator resolves an API key presented as a bearer token
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key, ip string) (*domain.APIKeyPrincipal, error)
+}
+
+// AuthMiddleware validates JWT access tokens and, when apiKeys is not nil,
+// API keys. An API key acts with the current role of its owner.
+func AuthMiddleware(jwtManager *auth.JWTManager, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := extractBearerToken(c)
 		if token == "" {
@@ -26,6 +40,27 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 			return
 		}
 
+		if apiKeys != nil && strings.HasPrefix(token, domain.APIKeyPrefix) {
+			principal, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), token, c.ClientIP())
+			if err != nil {
+				if errors.Is(err, domain.ErrInvalidToken) {
+					response.Unauthorized(c, "invalid, revoked or expired API key")
+				} else {
+					response.InternalError(c, err)
+				}
+				c.Abort()
+				return
+			}
+
+			c.Set(ContextKeyUserID, principal.User.ID)
+			c.Set(ContextKeyEmail, principal.User.Email)
+			c.Set(ContextKeyRole, principal.User.Role)
+			c.Set(ContextKeyAPIKey, principal.Key)
+
+			c.Next()
+			return
+		}
+
 		claims, err := jwtManager.ValidateAccessToken(token)
 		if err != nil {
 			response.Unauthorized(c, "invalid or expired token")
@@ -43,44 +78,68 @@ func AuthMiddleware(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	}
 }
 
-// RequireRole creates a middleware that requires a specific role
-func RequireRole(roles ...domain.UserRole) gin.HandlerFunc {
+// RequireScope checks the scope of API key requests: GET and HEAD need
+// resource:read, other methods resource:write. A key bound to a site is
+// refused when the request names another site, and its site is stored as
+// ContextKeySiteID so lists default to it. JWT requests pass through.
+func RequireScope(resource string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		roleVal, exists := c.Get(ContextKeyRole)
-		if !exists {
-			response.Unauthorized(c, "authentication required")
-			c.Abort()
+		key := apiKeyFromContext(c)
+		if key == nil {
+			c.Next()
 			return
 		}
 
-		userRole, ok := roleVal.(domain.UserRole)
-		if !ok {
-			response.Unauthorized(c, "invalid token claims")
+		scope := domain.WriteScope(resource)
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = domain.ReadScope(resource)
+		}
+		if !key.HasScope(scope) {
+			response.Forbidden(c, "API key lacks the "+string(scope)+" scope")
 			c.Abort()
 			return
 		}
 
-		// Check if user has any of the required roles
-		hasRole := false
-		for _, requiredRole := range roles {
-			// Create a temporary user to use the HasRole method
-			tempUser := &domain.User{Role: userRole}
-			if tempUser.HasRole(requiredRole) {
-				hasRole = true
-				break
+		if key.SiteID != nil {
+			if !requestStaysOnSite(c, *key.SiteID, resource) {
+				response.Forbidden(c, "API key is restricted to another site")
+				c.Abort()
+				return
 			}
+			c.Set(ContextKeySiteID, *key.SiteID)
 		}
 
-		if !hasRole {
-			response.Forbidden(c, "insufficient permissions")
-			c.Abort()
-			return
-		}
-
+		c.Set(contextKeyScopeChecked, true)
 		c.Next()
 	}
 }
 
+// apiKeyFromContext returns the API key that authenticated the request, if any
+func apiKeyFromContext(c *gin.Context) *domain.APIKey {
+	val, exists := c.Get(ContextKeyAPIKey)
+	if !exists {
+		return nil
+	}
+	key, _ := val.(*domain.APIKey)
+	return key
+}
+
+// requestStaysOnSite reports whether every site the request names is siteID.
+// Site routes must address the site by :id; other routes may name it in the
+// site_id query parameter, form field or JSON body field.
+func requestStaysOnSite(c *gin.Context, siteID uuid.UUID, resource string) bool {
+	if resource == domain.ScopeResourceSites {
+		return c.Param("id") == siteID.String()
+	}
+
+	for _, value := range namedSiteIDs(c) {
+		if value != siteID.String() {
+			return false
+		}
+	}
+	return true
+}
+
 // extractBearerToken extracts the Bearer token from the Authorization header
 func extractBearerToken(c *gin.Context) string {
 	authHeader := c.GetHeader("Authorization")
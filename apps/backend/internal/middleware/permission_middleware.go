@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+)
+
+// contextKeyPermissions holds the permissions of the caller's role
+const contextKeyPermissions = "permissions"
+
+// PermissionResolver resolves the permissions of a role
+type PermissionResolver interface {
+	Permissions(ctx context.Context, role domain.UserRole) (domain.PermissionSet, error)
+}
+
+// RequirePermission requires the caller's role to have a permission. Use
+// RequireSitePermission for routes that act on the content of a site.
+func RequirePermission(perms PermissionResolver, permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, role, ok := principalFromContext(c)
+		if !ok {
+			response.Unauthorized(c, "authentication required")
+			c.Abort()
+			return
+		}
+
+		// API keys only reach routes whose scope has been checked
+		if apiKeyFromContext(c) != nil && !c.GetBool(contextKeyScopeChecked) {
+			response.Forbidden(c, "API keys cannot access this endpoint")
+			c.Abort()
+			return
+		}
+
+		permissions, ok := globalPermissions(c, perms, role)
+		if !ok {
+			return
+		}
+		if !permissions.Has(permission) {
+			response.Forbidden(c, "insufficient permissions")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// globalPermissions returns the permissions of the caller's role, looked up
+// once per request. It responds and returns false when the lookup fails.
+func globalPermissions(c *gin.Context, perms PermissionResolver, role domain.UserRole) (domain.PermissionSet, bool) {
+	if val, exists := c.Get(contextKeyPermissions); exists {
+		if permissions, ok := val.(domain.PermissionSet); ok {
+			return permissions, true
+		}
+	}
+
+	permissions, err := perms.Permissions(c.Request.Context(), role)
+	if err != nil {
+		response.InternalError(c, err)
+		c.Abort()
+		return nil, false
+	}
+	c.Set(contextKeyPermissions, permissions)
+	return permissions, true
+}
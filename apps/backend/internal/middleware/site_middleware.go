@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// Context keys for the site resolved from the request host
+const (
+	ContextKeySite   = "site"
+	ContextKeySiteID = "site_id"
+)
+
+// SiteHostResolver maps a request host to a site
+type SiteHostResolver interface {
+	ResolveSiteByHost(ctx context.Context, host string) (*domain.Site, error)
+}
+
+// SiteResolver resolves the requested site from the Host header and stores it
+// in the context. X-Forwarded-Host is used instead only on requests from one
+// of trustedProxies; from anyone else it would let the client pick the site a
+// host serves, and poison caches keyed on the host. Unknown hosts are not an
+// error: the request continues without a site so an explicit site_id
+// parameter still works.
+func SiteResolver(resolver SiteHostResolver, trustedProxies []netip.Prefix, logger zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		host := c.Request.Host
+		if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" && fromTrustedProxy(c, trustedProxies) {
+			host = strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+
+		site, err := resolver.ResolveSiteByHost(c.Request.Context(), host)
+		if err != nil {
+			if !errors.Is(err, domain.ErrNotFound) {
+				logger.Error().Err(err).Str("host", host).Msg("failed to resolve site from host")
+			}
+			c.Next()
+			return
+		}
+
+		c.Set(ContextKeySite, site)
+		c.Set(ContextKeySiteID, site.ID)
+		c.Next()
+	}
+}
+
+// fromTrustedProxy reports whether the peer of a request is a trusted proxy
+func fromTrustedProxy(c *gin.Context, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(c.RemoteIP())
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,355 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/response"
+)
+
+const (
+	// ContextKeySiteIDs holds the sites a list request may return, set by
+	// RequireSitePermission when the request names no site. It is absent when
+	// the caller can access every site.
+	ContextKeySiteIDs = "site_ids"
+
+	// contextKeySitePermissions holds the permissions RequireSitePermission
+	// resolved, by site
+	contextKeySitePermissions = "site_permissions"
+)
+
+// SiteAccess resolves per-site permissions
+type SiteAccess interface {
+	PermissionResolver
+	SitePermissions(ctx context.Context, userID uuid.UUID, role domain.UserRole, siteID uuid.UUID) (domain.PermissionSet, bool, error)
+	AccessibleSiteIDs(ctx context.Context, userID uuid.UUID, role domain.UserRole) ([]uuid.UUID, error)
+	ResourceSiteID(ctx context.Context, resource domain.SiteResource, id uuid.UUID) (uuid.UUID, error)
+}
+
+// MediaKeyResolver finds the sites a stored file belongs to
+type MediaKeyResolver interface {
+	MediaSiteIDsByKey(ctx context.Context, key string) ([]uuid.UUID, error)
+}
+
+// SiteRef extracts the IDs of records a request acts on, whose site is then
+// looked up. A ref that cannot resolve the request may respond and abort it.
+type SiteRef func(c *gin.Context) (domain.SiteResource, []string)
+
+// SiteParam refers to the record whose ID is in a path parameter
+func SiteParam(param string, resource domain.SiteResource) SiteRef {
+	return func(c *gin.Context) (domain.SiteResource, []string) {
+		if id := c.Param(param); id != "" {
+			return resource, []string{id}
+		}
+		return resource, nil
+	}
+}
+
+// SiteJSONField refers to the record whose ID is in a top-level field of a
+// JSON body, e.g. {"section_id": ...}
+func SiteJSONField(field string, resource domain.SiteResource) SiteRef {
+	return func(c *gin.Context) (domain.SiteResource, []string) {
+		var payload map[string]interface{}
+		_ = json.Unmarshal(peekBody(c), &payload)
+
+		if id, _ := payload[field].(string); id != "" {
+			return resource, []string{id}
+		}
+		return resource, nil
+	}
+}
+
+// SiteMediaKey refers to the site of the media stored under the storage key
+// in a path parameter, e.g. a file served from /media/*key. Media of cloned
+// sites share their files, so a site the caller can access is preferred.
+// Files that belong to no media item are not found.
+func SiteMediaKey(param string, access SiteAccess, media MediaKeyResolver) SiteRef {
+	return func(c *gin.Context) (domain.SiteResource, []string) {
+		key := strings.TrimPrefix(c.Param(param), "/")
+		siteIDs, err := media.MediaSiteIDsByKey(c.Request.Context(), key)
+		if err != nil {
+			response.InternalError(c, err)
+			c.Abort()
+			return domain.SiteResourceSite, nil
+		}
+		if len(siteIDs) == 0 {
+			response.NotFound(c, "file not found")
+			c.Abort()
+			return domain.SiteResourceSite, nil
+		}
+
+		userID, role, _ := principalFromContext(c)
+		accessible, err := access.AccessibleSiteIDs(c.Request.Context(), userID, role)
+		if err != nil {
+			response.InternalError(c, err)
+			c.Abort()
+			return domain.SiteResourceSite, nil
+		}
+		if key := apiKeyFromContext(c); key != nil && key.SiteID != nil {
+			accessible = keepSite(accessible, *key.SiteID)
+		}
+		for _, siteID := range siteIDs {
+			if accessible == nil || containsSite(accessible, siteID) {
+				return domain.SiteResourceSite, []string{siteID.String()}
+			}
+		}
+		// RequireSitePermission refuses it
+		return domain.SiteResourceSite, []string{siteIDs[0].String()}
+	}
+}
+
+// SiteJSONList refers to the records whose IDs are in the idField of each
+// object of a JSON body array, e.g. {"sections": [{"id": ...}]}
+func SiteJSONList(field, idField string, resource domain.SiteResource) SiteRef {
+	return func(c *gin.Context) (domain.SiteResource, []string) {
+		var payload map[string][]map[string]interface{}
+		_ = json.Unmarshal(peekBody(c), &payload)
+
+		var ids []string
+		for _, item := range payload[field] {
+			id, _ := item[idField].(string)
+			ids = append(ids, id)
+		}
+		return resource, ids
+	}
+}
+
+// RequireSitePermission requires the caller to hold permission on every site
+// the request acts on: the site named by site_id (query, form or JSON body)
+// and the sites of the records given by refs. A list request that names no
+// site passes and ContextKeySiteIDs limits it to the caller's sites; any other
+// request must name a site unless the caller's role has site.all. Sites
+// resolved by an earlier RequireSitePermission of the same request are
+// reused, so routes can add a stricter permission without repeating refs.
+func RequireSitePermission(access SiteAccess, permission domain.Permission, refs ...SiteRef) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, role, ok := principalFromContext(c)
+		if !ok {
+			response.Unauthorized(c, "authentication required")
+			c.Abort()
+			return
+		}
+
+		key := apiKeyFromContext(c)
+		if key != nil && !c.GetBool(contextKeyScopeChecked) {
+			response.Forbidden(c, "API keys cannot access this endpoint")
+			c.Abort()
+			return
+		}
+
+		sites, resolved := sitePermissionsFromContext(c)
+		if !resolved {
+			siteIDs, ok := requestSiteIDs(c, access, refs)
+			if !ok {
+				return
+			}
+
+			sites = make(map[uuid.UUID]domain.PermissionSet, len(siteIDs))
+			for _, siteID := range siteIDs {
+				permissions, ok, err := access.SitePermissions(c.Request.Context(), userID, role, siteID)
+				if err != nil {
+					response.InternalError(c, err)
+					c.Abort()
+					return
+				}
+				if !ok {
+					response.Forbidden(c, "you do not have access to this site")
+					c.Abort()
+					return
+				}
+				if key != nil && key.SiteID != nil && *key.SiteID != siteID {
+					response.Forbidden(c, "API key is restricted to another site")
+					c.Abort()
+					return
+				}
+				sites[siteID] = permissions
+			}
+			c.Set(contextKeySitePermissions, sites)
+		}
+
+		if len(sites) == 0 {
+			global, ok := globalPermissions(c, access, role)
+			if !ok {
+				return
+			}
+			allSites := global.Has(domain.PermissionSiteAll)
+			if allSites && !global.Has(permission) {
+				response.Forbidden(c, "insufficient permissions")
+				c.Abort()
+				return
+			}
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				if !allSites {
+					response.Forbidden(c, "request must name a site you belong to")
+					c.Abort()
+					return
+				}
+			} else if _, exists := c.Get(ContextKeySiteIDs); !exists {
+				siteIDs, err := access.AccessibleSiteIDs(c.Request.Context(), userID, role)
+				if err != nil {
+					response.InternalError(c, err)
+					c.Abort()
+					return
+				}
+				if key != nil && key.SiteID != nil {
+					siteIDs = keepSite(siteIDs, *key.SiteID)
+				}
+				if siteIDs != nil {
+					c.Set(ContextKeySiteIDs, siteIDs)
+				}
+			}
+		}
+
+		for _, permissions := range sites {
+			if !permissions.Has(permission) {
+				response.Forbidden(c, "insufficient permissions")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// requestSiteIDs collects the distinct sites a request acts on. It responds
+// and returns false for a malformed or unknown reference.
+func requestSiteIDs(c *gin.Context, access SiteAccess, refs []SiteRef) ([]uuid.UUID, bool) {
+	seen := make(map[uuid.UUID]bool)
+	var siteIDs []uuid.UUID
+	add := func(id uuid.UUID) {
+		if !seen[id] {
+			seen[id] = true
+			siteIDs = append(siteIDs, id)
+		}
+	}
+
+	for _, value := range namedSiteIDs(c) {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			response.BadRequest(c, "invalid site_id")
+			c.Abort()
+			return nil, false
+		}
+		add(id)
+	}
+
+	for _, ref := range refs {
+		resource, values := ref(c)
+		if c.IsAborted() {
+			return nil, false
+		}
+		for _, value := range values {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				response.BadRequest(c, "invalid "+string(resource)+" ID")
+				c.Abort()
+				return nil, false
+			}
+			siteID, err := access.ResourceSiteID(c.Request.Context(), resource, id)
+			if err != nil {
+				if errors.Is(err, domain.ErrNotFound) {
+					response.NotFound(c, string(resource)+" not found")
+				} else {
+					response.InternalError(c, err)
+				}
+				c.Abort()
+				return nil, false
+			}
+			add(siteID)
+		}
+	}
+	return siteIDs, true
+}
+
+// namedSiteIDs returns the non-empty site_id values of the query and of the
+// form or JSON body
+func namedSiteIDs(c *gin.Context) []string {
+	named := []string{c.Query("site_id")}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		if strings.HasPrefix(c.ContentType(), "application/json") {
+			named = append(named, jsonBodySiteID(c))
+		} else {
+			named = append(named, c.PostForm("site_id"))
+		}
+	}
+
+	values := named[:0]
+	for _, value := range named {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// jsonBodySiteID peeks at the site_id field of a JSON body
+func jsonBodySiteID(c *gin.Context) string {
+	var payload struct {
+		SiteID string `json:"site_id"`
+	}
+	_ = json.Unmarshal(peekBody(c), &payload)
+	return payload.SiteID
+}
+
+// peekBody reads the request body and restores it for the handler
+func peekBody(c *gin.Context) []byte {
+	if c.Request.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// keepSite narrows siteIDs to siteID; nil stands for every site
+func keepSite(siteIDs []uuid.UUID, siteID uuid.UUID) []uuid.UUID {
+	if siteIDs == nil || containsSite(siteIDs, siteID) {
+		return []uuid.UUID{siteID}
+	}
+	return []uuid.UUID{}
+}
+
+// containsSite reports whether siteIDs holds siteID
+func containsSite(siteIDs []uuid.UUID, siteID uuid.UUID) bool {
+	for _, id := range siteIDs {
+		if id == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// principalFromContext returns the authenticated user and their global role
+func principalFromContext(c *gin.Context) (uuid.UUID, domain.UserRole, bool) {
+	userIDVal, _ := c.Get(ContextKeyUserID)
+	userID, ok := userIDVal.(uuid.UUID)
+	if !ok {
+		return uuid.Nil, "", false
+	}
+	roleVal, _ := c.Get(ContextKeyRole)
+	role, ok := roleVal.(domain.UserRole)
+	return userID, role, ok
+}
+
+// sitePermissionsFromContext returns the permissions resolved earlier in the
+// request
+func sitePermissionsFromContext(c *gin.Context) (map[uuid.UUID]domain.PermissionSet, bool) {
+	val, exists := c.Get(contextKeySitePermissions)
+	if !exists {
+		return nil, false
+	}
+	sites, ok := val.(map[uuid.UUID]domain.PermissionSet)
+	return sites, ok
+}
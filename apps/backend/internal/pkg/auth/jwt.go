@@ -1,7 +1,9 @@
 package auth
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -19,6 +21,9 @@ type JWTManager struct {
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
 	issuer        string
+	// keys signs access tokens asymmetrically when set; otherwise they are
+	// signed with accessSecret (HS256)
+	keys *KeySet
 }
 
 // AccessClaims holds the JWT claims for access tokens
@@ -35,6 +40,18 @@ type RefreshClaims struct {
 	jwt.RegisteredClaims
 }
 
+// ChallengeClaims holds the JWT claims of a two-factor login challenge. It is
+// signed with a key derived from the access secret, so it is never accepted
+// as an access token.
+type ChallengeClaims struct {
+	UserID        uuid.UUID `json:"user_id"`
+	SetupRequired bool      `json:"setup_required,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// challengeAudience marks two-factor challenge tokens
+const challengeAudience = "2fa-challenge"
+
 // NewJWTManager creates a new JWTManager
 func NewJWTManager(
 	accessSecret, refreshSecret string,
@@ -50,6 +67,23 @@ func NewJWTManager(
 	}
 }
 
+// WithKeySet makes the manager sign access tokens with the active key of keys
+// and verify them by their kid header. Refresh and challenge tokens are only
+// read by this service and stay on the HMAC secrets.
+func (m *JWTManager) WithKeySet(keys *KeySet) *JWTManager {
+	m.keys = keys
+	return m
+}
+
+// JWKS returns the public keys access tokens can be verified with. It is
+// empty when access tokens are signed with the shared secret.
+func (m *JWTManager) JWKS() JWKS {
+	if m.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return m.keys.JWKS()
+}
+
 // GenerateAccessToken generates a new JWT access token for the given user
 func (m *JWTManager) GenerateAccessToken(user *domain.User) (string, time.Time, error) {
 	expiresAt := time.Now().Add(m.accessExpiry)
@@ -67,8 +101,17 @@ func (m *JWTManager) GenerateAccessToken(user *domain.User) (string, time.Time,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(m.accessSecret))
+	var tokenString string
+	var err error
+	if m.keys != nil {
+		active := m.keys.Active()
+		token := jwt.NewWithClaims(m.keys.signingMethod(), claims)
+		token.Header["kid"] = active.ID
+		tokenString, err = token.SignedString(active.Private)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenString, err = token.SignedString([]byte(m.accessSecret))
+	}
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("GenerateAccessToken: %w", err)
 	}
@@ -102,12 +145,7 @@ func (m *JWTManager) GenerateRefreshToken(user *domain.User) (string, time.Time,
 
 // ValidateAccessToken validates a JWT access token and returns the claims
 func (m *JWTManager) ValidateAccessToken(tokenString string) (*AccessClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &AccessClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(m.accessSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &AccessClaims{}, m.accessKey)
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -124,6 +162,28 @@ func (m *JWTManager) ValidateAccessToken(tokenString string) (*AccessClaims, err
 	return claims, nil
 }
 
+// accessKey returns the key an access token is verified with. With a key set
+// only its algorithm is accepted, so an HMAC token can never be checked
+// against a public key.
+func (m *JWTManager) accessKey(token *jwt.Token) (interface{}, error) {
+	if m.keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(m.accessSecret), nil
+	}
+
+	if token.Method.Alg() != m.keys.Algorithm() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := m.keys.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID: %q", kid)
+	}
+	return key.Public, nil
+}
+
 // ValidateRefreshToken validates a JWT refresh token and returns the claims
 func (m *JWTManager) ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
@@ -145,8 +205,73 @@ func (m *JWTManager) ValidateRefreshToken(tokenString string) (*RefreshClaims, e
 	return claims, nil
 }
 
+// GenerateChallengeToken generates a short-lived token proving that the
+// password of a user was verified. setupRequired means the user must enroll
+// in two-factor authentication before signing in.
+func (m *JWTManager) GenerateChallengeToken(userID uuid.UUID, setupRequired bool, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl)
+
+	claims := &ChallengeClaims{
+		UserID:        userID,
+		SetupRequired: setupRequired,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{challengeAudience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(m.challengeKey())
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("GenerateChallengeToken: %w", err)
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// ValidateChallengeToken validates a two-factor challenge token and returns the claims
+func (m *JWTManager) ValidateChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return m.challengeKey(), nil
+	}, jwt.WithAudience(challengeAudience))
+
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok || !token.Valid {
+		return nil, domain.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// challengeKey derives the signing key of challenge tokens from the access secret
+func (m *JWTManager) challengeKey() []byte {
+	sum := sha256.Sum256([]byte(challengeAudience + ":" + m.accessSecret))
+	return sum[:]
+}
+
 // HashToken creates a SHA-256 hash of a token string for secure storage
 func HashToken(token string) string {
 	hash := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(hash[:])
 }
+
+// GenerateOpaqueToken creates a random URL-safe token for single-use links.
+// Store only its HashToken.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("GenerateOpaqueToken: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms for access tokens
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA modulus accepted for RS256
+const minRSABits = 2048
+
+// SigningKey is one asymmetric key of a KeySet. Private is nil for a retired
+// key kept only to verify tokens it signed.
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+}
+
+// KeySet holds the keys access tokens are signed and verified with. The
+// active key signs; every key verifies, so a new key can be rolled out while
+// tokens of the previous one are still valid.
+type KeySet struct {
+	algorithm string
+	active    *SigningKey
+	keys      map[string]*SigningKey
+}
+
+// KeySetConfig says where to load keys from. Keys in Dir are named by file:
+// <kid>.pem. PrivateKeyPEM is an inline key, e.g. from an environment variable,
+// whose kid is ActiveKeyID or, when empty, its JWK thumbprint. Without
+// ActiveKeyID the inline key is active, else the private key whose kid sorts
+// last (name files by date to rotate by adding a file).
+type KeySetConfig struct {
+	Algorithm     string
+	Dir           string
+	PrivateKeyPEM string
+	ActiveKeyID   string
+}
+
+// LoadKeySet reads and checks the keys described by cfg
+func LoadKeySet(cfg KeySetConfig) (*KeySet, error) {
+	if cfg.Algorithm != AlgorithmRS256 && cfg.Algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("LoadKeySet: unsupported algorithm %q", cfg.Algorithm)
+	}
+	ks := &KeySet{algorithm: cfg.Algorithm, keys: make(map[string]*SigningKey)}
+
+	if cfg.Dir != "" {
+		paths, err := filepath.Glob(filepath.Join(cfg.Dir, "*.pem"))
+		if err != nil {
+			return nil, fmt.Errorf("LoadKeySet: %w", err)
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("LoadKeySet: %w", err)
+			}
+			kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+			if err := ks.add(kid, data); err != nil {
+				return nil, fmt.Errorf("LoadKeySet %s: %w", filepath.Base(path), err)
+			}
+		}
+	}
+
+	inlineKID := ""
+	if cfg.PrivateKeyPEM != "" {
+		key, err := parseKey(cfg.Algorithm, []byte(cfg.PrivateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("LoadKeySet inline key: %w", err)
+		}
+		if key.Private == nil {
+			return nil, errors.New("LoadKeySet inline key: a private key is required")
+		}
+		inlineKID = cfg.ActiveKeyID
+		if inlineKID == "" {
+			inlineKID = thumbprint(key.Public)
+		}
+		if _, exists := ks.keys[inlineKID]; exists {
+			return nil, fmt.Errorf("LoadKeySet: duplicate key ID %q", inlineKID)
+		}
+		key.ID = inlineKID
+		ks.keys[inlineKID] = key
+	}
+
+	activeKID := cfg.ActiveKeyID
+	if activeKID == "" {
+		activeKID = inlineKID
+	}
+	if activeKID == "" {
+		kids := make([]string, 0, len(ks.keys))
+		for kid, key := range ks.keys {
+			if key.Private != nil {
+				kids = append(kids, kid)
+			}
+		}
+		sort.Strings(kids)
+		if len(kids) > 0 {
+			activeKID = kids[len(kids)-1]
+		}
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok || active.Private == nil {
+		return nil, fmt.Errorf("LoadKeySet: no private key found for active key %q", activeKID)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+// add parses a PEM file into the set under kid
+func (ks *KeySet) add(kid string, data []byte) error {
+	key, err := parseKey(ks.algorithm, data)
+	if err != nil {
+		return err
+	}
+	key.ID = kid
+	ks.keys[kid] = key
+	return nil
+}
+
+// Algorithm returns the JWT alg of the set
+func (ks *KeySet) Algorithm() string {
+	return ks.algorithm
+}
+
+// Active returns the key new tokens are signed with
+func (ks *KeySet) Active() *SigningKey {
+	return ks.active
+}
+
+// Key returns the key with the given kid
+func (ks *KeySet) Key(kid string) (*SigningKey, bool) {
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// signingMethod returns the jwt signing method of the set
+func (ks *KeySet) signingMethod() jwt.SigningMethod {
+	if ks.algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWK is the public part of a key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, ordered by kid
+func (ks *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := publicJWK(key.Public)
+		jwk.KeyID = kid
+		jwk.Use = "sig"
+		jwk.Algorithm = key.Algorithm
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// parseKey reads a PEM private or public key and checks it fits algorithm
+func parseKey(algorithm string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &SigningKey{Algorithm: algorithm}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Private, key.Public = k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Public = k
+	case ed25519.PrivateKey:
+		key.Private, key.Public = k, k.Public()
+	case ed25519.PublicKey:
+		key.Public = k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if algorithm != AlgorithmRS256 {
+			return nil, fmt.Errorf("RSA key cannot be used with %s", algorithm)
+		}
+		if pub.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key must have at least %d bits", minRSABits)
+		}
+	case ed25519.PublicKey:
+		if algorithm != AlgorithmEdDSA {
+			return nil, fmt.Errorf("Ed25519 key cannot be used with %s", algorithm)
+		}
+	}
+	return key, nil
+}
+
+// publicJWK encodes the key material of a public key
+func publicJWK(pub crypto.PublicKey) JWK {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			N:       base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: "OKP",
+			Curve:   "Ed25519",
+			X:       base64.RawURLEncoding.EncodeToString(k),
+		}
+	}
+	return JWK{}
+}
+
+// thumbprint returns the RFC 7638 JWK thumbprint of a public key
+func thumbprint(pub crypto.PublicKey) string {
+	jwk := publicJWK(pub)
+	var members map[string]string
+	if jwk.KeyType == "RSA" {
+		members = map[string]string{"e": jwk.E, "kty": jwk.KeyType, "n": jwk.N}
+	} else {
+		members = map[string]string{"crv": jwk.Curve, "kty": jwk.KeyType, "x": jwk.X}
+	}
+	// encoding/json sorts map keys, giving the required lexicographic order
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
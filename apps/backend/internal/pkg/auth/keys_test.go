@@ -0,0 +1,216 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+)
+
+// writeKey stores a PKCS#8 private key, or only its public part, as <kid>.pem
+func writeKey(t *testing.T, dir, kid string, key interface{}, publicOnly bool) {
+	t.Helper()
+	var block *pem.Block
+	if publicOnly {
+		var pub interface{}
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			pub = &k.PublicKey
+		case ed25519.PrivateKey:
+			pub = k.Public()
+		}
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			t.Fatalf("marshal public key: %v", err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	} else {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("marshal private key: %v", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	return key
+}
+
+func managerWithKeys(t *testing.T, cfg auth.KeySetConfig) *auth.JWTManager {
+	t.Helper()
+	keys, err := auth.LoadKeySet(cfg)
+	if err != nil {
+		t.Fatalf("load key set: %v", err)
+	}
+	return createTestJWTManager().WithKeySet(keys)
+}
+
+func TestJWTManager_RS256_KeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := newRSAKey(t)
+	writeKey(t, dir, "2024-01", oldKey, false)
+
+	before := managerWithKeys(t, auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: dir})
+	user := createTestUser()
+	oldToken, _, err := before.GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+
+	// Rotate: add a newer key and keep only the public part of the old one
+	writeKey(t, dir, "2024-06", newRSAKey(t), false)
+	writeKey(t, dir, "2024-01", oldKey, true)
+	after := managerWithKeys(t, auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: dir})
+
+	newToken, _, err := after.GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &auth.AccessClaims{})
+	if err != nil {
+		t.Fatalf("parse token failed: %v", err)
+	}
+	if parsed.Header["kid"] != "2024-06" || parsed.Header["alg"] != "RS256" {
+		t.Errorf("expected the newest key to sign, got header %v", parsed.Header)
+	}
+
+	for name, token := range map[string]string{"old": oldToken, "new": newToken} {
+		claims, err := after.ValidateAccessToken(token)
+		if err != nil {
+			t.Fatalf("expected %s token to stay valid, got %v", name, err)
+		}
+		if claims.UserID != user.ID {
+			t.Errorf("expected user ID %s, got %s", user.ID, claims.UserID)
+		}
+	}
+
+	jwks := after.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys in JWKS, got %d", len(jwks.Keys))
+	}
+	first := jwks.Keys[0]
+	if first.KeyID != "2024-01" || first.KeyType != "RSA" || first.Use != "sig" || first.Algorithm != "RS256" {
+		t.Errorf("unexpected JWK: %+v", first)
+	}
+	n, _ := base64.RawURLEncoding.DecodeString(first.N)
+	if new(big.Int).SetBytes(n).Cmp(oldKey.N) != 0 {
+		t.Error("expected JWK modulus to match the public key")
+	}
+	if first.E != "AQAB" {
+		t.Errorf("expected exponent AQAB, got %s", first.E)
+	}
+}
+
+func TestJWTManager_RS256_RejectsForeignTokens(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "current", newRSAKey(t), false)
+	manager := managerWithKeys(t, auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: dir})
+	user := createTestUser()
+
+	// An HS256 token signed with the old shared secret
+	hmacToken, _, err := createTestJWTManager().GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+	if _, err := manager.ValidateAccessToken(hmacToken); err == nil {
+		t.Error("expected HS256 token to be rejected")
+	}
+
+	// A token from a key that is not in the set
+	otherDir := t.TempDir()
+	writeKey(t, otherDir, "current", newRSAKey(t), false)
+	other := managerWithKeys(t, auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: otherDir})
+	foreign, _, _ := other.GenerateAccessToken(user)
+	if _, err := manager.ValidateAccessToken(foreign); err == nil {
+		t.Error("expected token signed by an unknown key to be rejected")
+	}
+}
+
+func TestJWTManager_EdDSA_InlineKey(t *testing.T) {
+	key := newEd25519Key(t)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	manager := managerWithKeys(t, auth.KeySetConfig{Algorithm: auth.AlgorithmEdDSA, PrivateKeyPEM: keyPEM})
+	user := createTestUser()
+	token, _, err := manager.GenerateAccessToken(user)
+	if err != nil {
+		t.Fatalf("generate token failed: %v", err)
+	}
+
+	jwks := manager.JWKS()
+	if len(jwks.Keys) != 1 {
+		t.Fatalf("expected 1 key in JWKS, got %d", len(jwks.Keys))
+	}
+	jwk := jwks.Keys[0]
+	if jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.KeyID == "" {
+		t.Fatalf("unexpected JWK: %+v", jwk)
+	}
+
+	// Verify the way an edge function would: only with the published key
+	x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+	parsed, err := jwt.ParseWithClaims(token, &auth.AccessClaims{}, func(tok *jwt.Token) (interface{}, error) {
+		if tok.Header["kid"] != jwk.KeyID {
+			t.Errorf("expected kid %s, got %v", jwk.KeyID, tok.Header["kid"])
+		}
+		return ed25519.PublicKey(x), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	if err != nil || !parsed.Valid {
+		t.Fatalf("expected token to verify against the JWKS, got %v", err)
+	}
+}
+
+func TestLoadKeySet_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "ed", newEd25519Key(t), false)
+	if _, err := auth.LoadKeySet(auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: dir}); err == nil {
+		t.Error("expected an Ed25519 key to be rejected for RS256")
+	}
+
+	publicOnly := t.TempDir()
+	writeKey(t, publicOnly, "retired", newRSAKey(t), true)
+	if _, err := auth.LoadKeySet(auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: publicOnly}); err == nil {
+		t.Error("expected an error without a private signing key")
+	}
+
+	if _, err := auth.LoadKeySet(auth.KeySetConfig{Algorithm: auth.AlgorithmRS256, Dir: dir, ActiveKeyID: "missing"}); err == nil {
+		t.Error("expected an error for an unknown active key")
+	}
+
+	if _, err := auth.LoadKeySet(auth.KeySetConfig{Algorithm: "HS512"}); err == nil {
+		t.Error("expected an error for an unsupported algorithm")
+	}
+}
+
+func TestJWTManager_JWKS_EmptyForHMAC(t *testing.T) {
+	if keys := createTestJWTManager().JWKS().Keys; len(keys) != 0 {
+		t.Errorf("expected no keys for HS256, got %d", len(keys))
+	}
+}
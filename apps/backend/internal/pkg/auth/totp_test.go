@@ -0,0 +1,108 @@
+package auth_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+)
+
+// rfcSecret is the SHA-1 test key of RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; the 6-digit code is their last six digits
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		got, err := auth.TOTPCode(rfcSecret, auth.TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		if got != want {
+			t.Errorf("T=%d: expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	now := time.Now()
+	step := auth.TOTPStep(now)
+
+	previous, _ := auth.TOTPCode(secret, step-1)
+	if got, ok := auth.ValidateTOTP(secret, previous, now); !ok || got != step-1 {
+		t.Errorf("expected the previous step to be accepted as %d, got %d %v", step-1, got, ok)
+	}
+	old, _ := auth.TOTPCode(secret, step-3)
+	if _, ok := auth.ValidateTOTP(secret, old, now); ok {
+		t.Error("expected a code three steps old to be rejected")
+	}
+	if _, ok := auth.ValidateTOTP(secret, "12345", now); ok {
+		t.Error("expected a short code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := auth.TOTPURI("Landing CMS", "admin@example.com", "ABC")
+	for _, want := range []string{"otpauth://totp/Landing%20CMS:admin@example.com?", "secret=ABC", "issuer=Landing+CMS", "period=30"} {
+		if !strings.Contains(uri, want) {
+			t.Errorf("expected %q in %s", want, uri)
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := auth.GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if len(c) != 11 || c[5] != '-' || seen[c] {
+			t.Errorf("unexpected code %q", c)
+		}
+		seen[c] = true
+	}
+	if auth.NormalizeRecoveryCode(" ABCDE-fghij") != "abcdefghij" {
+		t.Error("expected separators and case to be ignored")
+	}
+}
+
+func TestJWTManager_ChallengeToken(t *testing.T) {
+	manager := createTestJWTManager()
+	userID := uuid.New()
+
+	token, _, err := manager.GenerateChallengeToken(userID, true, time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	claims, err := manager.ValidateChallengeToken(token)
+	if err != nil || claims.UserID != userID || !claims.SetupRequired {
+		t.Fatalf("expected valid claims, got %+v, %v", claims, err)
+	}
+
+	if _, err := manager.ValidateAccessToken(token); err == nil {
+		t.Error("expected a challenge token not to be accepted as access token")
+	}
+	access, _, _ := manager.GenerateAccessToken(createTestUser())
+	if _, err := manager.ValidateChallengeToken(access); err == nil {
+		t.Error("expected an access token not to be accepted as challenge")
+	}
+
+	expired, _, _ := manager.GenerateChallengeToken(userID, false, -time.Minute)
+	if _, err := manager.ValidateChallengeToken(expired); err == nil {
+		t.Error("expected an expired challenge to be rejected")
+	}
+}
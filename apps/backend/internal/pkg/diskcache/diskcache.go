@@ -0,0 +1,242 @@
+// Package diskcache keeps generated files in a directory, bounded by total
+// size and evicting the least recently used first.
+package diskcache
+
+import (
+	"bufio"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileExt marks cache files; anything else in the directory is left alone
+const fileExt = ".cache"
+
+// Entry describes a cached file
+type Entry struct {
+	ContentType string
+	// Digest is the hex SHA-256 of the data
+	Digest string
+	Size   int64
+}
+
+// Cache is a size-bounded LRU of files. Entries are files named after the
+// hash of their key, holding a header line with the content type and digest
+// followed by the data. The index lives in memory and is rebuilt from the
+// directory on Open, oldest files first. It is safe for concurrent use.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu    sync.Mutex
+	items map[string]*list.Element // by file name
+	order *list.List               // front is most recently used
+	size  int64
+}
+
+// item is an entry of the index
+type item struct {
+	name string
+	size int64 // of the file, header included
+}
+
+// Open creates the directory if needed and indexes the files already in it,
+// evicting the oldest when they exceed maxBytes
+func Open(dir string, maxBytes int64) (*Cache, error) {
+	if maxBytes < 1 {
+		return nil, errors.New("diskcache.Open: maxBytes must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("diskcache.Open: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("diskcache.Open: %w", err)
+	}
+
+	type found struct {
+		name string
+		size int64
+		mod  int64
+	}
+	var files []found
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if strings.HasPrefix(e.Name(), ".tmp-") {
+			// Left by a write that never finished
+			os.Remove(filepath.Join(dir, e.Name()))
+			continue
+		}
+		if !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, found{e.Name(), info.Size(), info.ModTime().UnixNano()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod < files[j].mod })
+
+	c := &Cache{dir: dir, maxBytes: maxBytes, items: make(map[string]*list.Element), order: list.New()}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range files {
+		c.items[f.name] = c.order.PushFront(&item{name: f.name, size: f.size})
+		c.size += f.size
+	}
+	c.evictLocked()
+	return c, nil
+}
+
+// Get returns the data of a cached key and marks it recently used
+func (c *Cache) Get(key string) ([]byte, Entry, bool) {
+	name := fileName(key)
+	c.mu.Lock()
+	elem, ok := c.items[name]
+	if ok {
+		c.order.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, Entry{}, false
+	}
+
+	data, entry, err := c.read(name)
+	if err != nil {
+		// Evicted meanwhile, or damaged; either way it is gone
+		c.remove(name)
+		return nil, Entry{}, false
+	}
+	return data, entry, true
+}
+
+// Put stores data under key, replacing any entry with the same key, and
+// evicts the least recently used entries to stay within the size bound. Data
+// larger than the whole cache is not stored.
+func (c *Cache) Put(key, contentType string, data []byte) (Entry, error) {
+	sum := sha256.Sum256(data)
+	entry := Entry{ContentType: contentType, Digest: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+	if strings.ContainsAny(contentType, " \n") {
+		return entry, errors.New("diskcache.Put: invalid content type")
+	}
+	header := contentType + " " + entry.Digest + "\n"
+	size := int64(len(header) + len(data))
+	if size > c.maxBytes {
+		return entry, nil
+	}
+
+	name := fileName(key)
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return entry, fmt.Errorf("diskcache.Put: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	_, err = io.WriteString(tmp, header)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return entry, fmt.Errorf("diskcache.Put write: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, name)); err != nil {
+		return entry, fmt.Errorf("diskcache.Put rename: %w", err)
+	}
+	if elem, ok := c.items[name]; ok {
+		c.size -= elem.Value.(*item).size
+		c.order.Remove(elem)
+	}
+	c.items[name] = c.order.PushFront(&item{name: name, size: size})
+	c.size += size
+	c.evictLocked()
+	return entry, nil
+}
+
+// Size returns the bytes used by the cached files
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Len returns the number of cached entries
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// read reads a cache file and checks its header
+func (c *Cache) read(name string) ([]byte, Entry, error) {
+	f, err := os.Open(filepath.Join(c.dir, name))
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	contentType, digest, ok := strings.Cut(strings.TrimSuffix(header, "\n"), " ")
+	if !ok || len(digest) != sha256.Size*2 {
+		return nil, Entry{}, fs.ErrInvalid
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, Entry{}, err
+	}
+	return data, Entry{ContentType: contentType, Digest: digest, Size: int64(len(data))}, nil
+}
+
+// remove drops an entry and its file
+func (c *Cache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[name]; ok {
+		c.size -= elem.Value.(*item).size
+		c.order.Remove(elem)
+		delete(c.items, name)
+	}
+	os.Remove(filepath.Join(c.dir, name))
+}
+
+// evictLocked removes the least recently used entries until the cache fits
+func (c *Cache) evictLocked() {
+	for c.size > c.maxBytes {
+		elem := c.order.Back()
+		if elem == nil {
+			return
+		}
+		it := elem.Value.(*item)
+		c.order.Remove(elem)
+		delete(c.items, it.name)
+		c.size -= it.size
+		os.Remove(filepath.Join(c.dir, it.name))
+	}
+}
+
+// fileName returns the file of a key
+func fileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:]) + fileExt
+}
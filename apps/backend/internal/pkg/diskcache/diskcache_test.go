@@ -0,0 +1,146 @@
+package diskcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCache_PutGet(t *testing.T) {
+	c, err := Open(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := c.Get("a"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	entry, err := c.Put("a", "image/png", []byte("png-data"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	sum := sha256.Sum256([]byte("png-data"))
+	if entry.Digest != hex.EncodeToString(sum[:]) || entry.Size != 8 || entry.ContentType != "image/png" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	data, got, ok := c.Get("a")
+	if !ok || string(data) != "png-data" || got != entry {
+		t.Errorf("Get = %q, %+v, %v", data, got, ok)
+	}
+
+	if _, err := c.Put("a", "image/webp", []byte("webp")); err != nil {
+		t.Fatal(err)
+	}
+	if data, got, _ := c.Get("a"); string(data) != "webp" || got.ContentType != "image/webp" {
+		t.Errorf("expected the replaced entry, got %q %+v", data, got)
+	}
+	if c.Len() != 1 {
+		t.Errorf("expected 1 entry after replacing, got %d", c.Len())
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("x"), 100)
+	// Room for three entries of 100 bytes plus their headers
+	c, err := Open(dir, int64(3*(100+len("image/jpeg ")+64+1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if _, err := c.Put(key, "image/jpeg", data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a becomes the most recently used, so b is evicted next
+	if _, _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	if _, err := c.Put("d", "image/jpeg", data); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true, "d": true} {
+		if _, _, ok := c.Get(key); ok != want {
+			t.Errorf("Get(%q): cached = %v, want %v", key, ok, want)
+		}
+	}
+	if c.Size() > c.maxBytes {
+		t.Errorf("size %d exceeds the bound %d", c.Size(), c.maxBytes)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+fileExt))
+	if len(files) != 3 {
+		t.Errorf("expected 3 files on disk, got %d", len(files))
+	}
+}
+
+func TestCache_SkipsOversizedData(t *testing.T) {
+	c, err := Open(t.TempDir(), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := c.Put("big", "image/png", bytes.Repeat([]byte("x"), 100))
+	if err != nil || entry.Size != 100 {
+		t.Fatalf("expected the entry without an error, got %+v, %v", entry, err)
+	}
+	if _, _, ok := c.Get("big"); ok || c.Len() != 0 {
+		t.Error("expected data larger than the cache not to be stored")
+	}
+}
+
+func TestOpen_IndexesExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Put("a", "image/png", []byte("png-data")); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, ".tmp-123"), []byte("partial"), 0o600)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("not ours"), 0o600)
+
+	reopened, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, entry, ok := reopened.Get("a"); !ok || string(data) != "png-data" || entry.ContentType != "image/png" {
+		t.Errorf("expected the entry to survive a restart, got %q %+v %v", data, entry, ok)
+	}
+	if reopened.Len() != 1 || reopened.Size() != c.Size() {
+		t.Errorf("expected 1 entry of %d bytes, got %d of %d", c.Size(), reopened.Len(), reopened.Size())
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".tmp-123")); !os.IsNotExist(err) {
+		t.Error("expected the partial write to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README")); err != nil {
+		t.Error("expected unrelated files to be left alone")
+	}
+}
+
+func TestCache_DropsDamagedFiles(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Put("a", "image/png", []byte("png-data")); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, fileName("a")), []byte("no header"), 0o600)
+
+	if _, _, ok := c.Get("a"); ok {
+		t.Fatal("expected a damaged file to be a miss")
+	}
+	if c.Len() != 0 || c.Size() != 0 {
+		t.Errorf("expected the damaged entry to be dropped, got %d entries of %d bytes", c.Len(), c.Size())
+	}
+	if _, err := c.Put("a", "bad type", nil); err == nil || !strings.Contains(err.Error(), "content type") {
+		t.Errorf("expected a content type with a space to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package filecheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encode(t *testing.T, format string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	cases := map[string]struct {
+		data []byte
+		want string
+	}{
+		"jpeg":        {encode(t, "jpeg"), "image/jpeg"},
+		"png":         {encode(t, "png"), "image/png"},
+		"gif":         {encode(t, "gif"), "image/gif"},
+		"webp":        {[]byte("RIFF\x10\x00\x00\x00WEBPVP8L"), "image/webp"},
+		"wav":         {[]byte("RIFF\x10\x00\x00\x00WAVEfmt "), "audio/wav"},
+		"pdf":         {[]byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"), "application/pdf"},
+		"mp4":         {[]byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00isomiso2"), "video/mp4"},
+		"mov":         {[]byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00"), "video/quicktime"},
+		"avif":        {[]byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00"), "image/avif"},
+		"heic":        {[]byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "application/octet-stream"},
+		"webm":        {[]byte("\x1a\x45\xdf\xa3\x9f\x42\x86\x81\x01\x42\x82\x84webm"), "video/webm"},
+		"mp3":         {[]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), "audio/mpeg"},
+		"svg":         {[]byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), "image/svg+xml"},
+		"svg prolog":  {[]byte("\ufeff<?xml version=\"1.0\"?>\n<!-- logo -->\n<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"x\">\n<svg>"), "image/svg+xml"},
+		"not svg":     {[]byte("<svgx/>"), "text/plain"},
+		"html":        {[]byte("<!DOCTYPE html><html><script>alert(1)</script>"), "text/html"},
+		"html in svg": {[]byte("<html><svg></svg></html>"), "text/html"},
+		"xml":         {[]byte("<?xml version=\"1.0\"?><feed/>"), "text/xml"},
+		"php":         {[]byte("<?php echo 1; ?>"), "text/plain"},
+		"text":        {[]byte("hello"), "text/plain"},
+		"binary":      {[]byte{0x00, 0x01, 0x02, 0xfe}, "application/octet-stream"},
+		"empty":       {nil, "text/plain"},
+	}
+	for name, tc := range cases {
+		if got := Detect(tc.data); got != tc.want {
+			t.Errorf("%s: got %s, want %s", name, got, tc.want)
+		}
+	}
+}
+
+func TestExtensionMatches(t *testing.T) {
+	cases := []struct {
+		name, mimeType string
+		want           bool
+	}{
+		{"photo.JPG", "image/jpeg", true},
+		{"photo.jpeg", "image/jpeg", true},
+		{"photo.png", "image/jpeg", false},
+		{"logo.svg", "image/svg+xml", true},
+		{"logo.svg", "text/html", false},
+		{"page.html", "text/html", true},
+		{"shell.php.png", "image/png", true},
+		{"shell.png.php", "image/png", false},
+		{"noext", "image/png", false},
+		{"clip.m4v", "video/mp4", true},
+	}
+	for _, tc := range cases {
+		if got := ExtensionMatches(tc.name, tc.mimeType); got != tc.want {
+			t.Errorf("ExtensionMatches(%q, %q) = %v, want %v", tc.name, tc.mimeType, got, tc.want)
+		}
+	}
+}
+
+// segment builds a JPEG marker segment
+func segment(marker byte, payload string) []byte {
+	out := []byte{0xff, marker}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	return append(out, payload...)
+}
+
+// exifWithGPS builds a little-endian Exif payload with an orientation and a
+// pointer to a GPS IFD holding a latitude reference
+func exifWithGPS(orientation uint16) string {
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = le.AppendUint32(tiff, 8)
+	tiff = le.AppendUint16(tiff, 2)
+	// Orientation, SHORT
+	tiff = le.AppendUint16(tiff, exifOrientation)
+	tiff = le.AppendUint16(tiff, 3)
+	tiff = le.AppendUint32(tiff, 1)
+	tiff = le.AppendUint16(tiff, orientation)
+	tiff = le.AppendUint16(tiff, 0)
+	// GPSInfo, LONG offset
+	tiff = le.AppendUint16(tiff, 0x8825)
+	tiff = le.AppendUint16(tiff, 4)
+	tiff = le.AppendUint32(tiff, 1)
+	tiff = le.AppendUint32(tiff, 38)
+	tiff = le.AppendUint32(tiff, 0)
+	// GPS IFD: GPSLatitudeRef "N"
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = le.AppendUint16(tiff, 2)
+	tiff = le.AppendUint32(tiff, 2)
+	tiff = append(tiff, 'N', 0, 0, 0)
+	tiff = le.AppendUint32(tiff, 0)
+	return "Exif\x00\x00" + string(tiff) + "GPS-SECRET"
+}
+
+// withSegments inserts segments after the SOI of a JPEG
+func withSegments(data []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, data[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, data[2:]...)
+}
+
+func TestStripJPEGMetadata(t *testing.T) {
+	original := encode(t, "jpeg")
+	data := withSegments(original,
+		segment(markerAPP1, exifWithGPS(6)),
+		segment(markerAPP1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta>GPS-SECRET</x:xmpmeta>"),
+		segment(markerAPP2, "ICC_PROFILE\x00\x01\x01profile"),
+		segment(markerAPP2, "FPXR\x00GPS-SECRET"),
+		segment(0xed, "Photoshop 3.0\x00GPS-SECRET"),
+		segment(markerCOM, "GPS-SECRET"),
+	)
+	data = append(data, "trailer GPS-SECRET"...)
+
+	stripped, err := StripJPEGMetadata(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte("GPS-SECRET")) || bytes.Contains(stripped, []byte("xmpmeta")) {
+		t.Error("expected metadata to be removed")
+	}
+	if !bytes.Contains(stripped, []byte("ICC_PROFILE\x00")) {
+		t.Error("expected the ICC profile to be kept")
+	}
+	if !bytes.HasSuffix(stripped, []byte{0xff, markerEOI}) {
+		t.Error("expected data after the end of the image to be removed")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+		t.Errorf("expected a decodable JPEG, got %v", err)
+	}
+
+	// The orientation survives in a segment of its own
+	start := bytes.Index(stripped, []byte("Exif\x00\x00"))
+	if start < 0 {
+		t.Fatal("expected an Exif segment with the orientation")
+	}
+	length := int(binary.BigEndian.Uint16(stripped[start-2:]))
+	if o := readExifOrientation(stripped[start : start-2+length]); o != 6 {
+		t.Errorf("expected orientation 6, got %d", o)
+	}
+
+	// The default orientation needs no Exif at all
+	plain, err := StripJPEGMetadata(withSegments(original, segment(markerAPP1, exifWithGPS(1))))
+	if err != nil || bytes.Contains(plain, []byte("Exif")) {
+		t.Errorf("expected no Exif segment, got %v", err)
+	}
+	if !bytes.Equal(plain, original) {
+		t.Error("expected a JPEG without metadata to be unchanged")
+	}
+}
+
+func TestStripJPEGMetadata_Malformed(t *testing.T) {
+	original := encode(t, "jpeg")
+	for name, data := range map[string][]byte{
+		"not jpeg":     []byte("GIF89a"),
+		"truncated":    original[:5],
+		"bad length":   withSegments(original, []byte{0xff, markerCOM, 0xff, 0xff}),
+		"no marker":    append([]byte{0xff, markerSOI}, "garbage"...),
+		"restart":      append([]byte{0xff, markerSOI}, 0xff, 0xd0),
+		"short length": withSegments(original, []byte{0xff, markerCOM, 0x00, 0x01}),
+	} {
+		if _, err := StripJPEGMetadata(data); !errors.Is(err, ErrMalformedJPEG) {
+			t.Errorf("%s: expected ErrMalformedJPEG, got %v", name, err)
+		}
+	}
+}
+
+func TestSanitizeSVG(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE svg [<!ENTITY x "boom">]>
+<!-- exported by an editor -->
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape" onload="alert(1)" viewBox="0 0 10 10" inkscape:version="1.0">
+  <script>alert(2)</script>
+  <script xlink:href="https://evil.example/x.js"/>
+  <style>.a { fill: red } .b > .c { stroke: blue }</style>
+  <style>@import url(https://evil.example/x.css);</style>
+  <defs><linearGradient id="g"><stop offset="0" stop-color="#fff"/></linearGradient></defs>
+  <a href="javascript:alert(3)"><rect class="a" width="5" height="5" fill="url(#g)" ONCLICK="alert(4)"/></a>
+  <use xlink:href="#g"/>
+  <use href="https://evil.example/sprite.svg#icon"/>
+  <image href="data:image/png;base64,iVBORw0KGgo=" width="1" height="1"/>
+  <image href=" JaVa&#x09;Script:alert(5)"/>
+  <foreignObject><div xmlns="http://www.w3.org/1999/xhtml"><iframe src="x"/></div></foreignObject>
+  <animate attributeName="href" to="javascript:alert(6)"/>
+  <set attributeName="onclick" to="alert(7)"/>
+  <g style="background: url(javascript:alert(8))"><text x="1" y="2">a &lt; b &amp; "c"</text></g>
+  <inkscape:namedview><rect/></inkscape:namedview>
+</svg>
+`
+	out, err := SanitizeSVG([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	for _, banned := range []string{
+		"alert", "script", "evil.example", "javascript", "onload", "ONCLICK", "foreignObject",
+		"iframe", "animate", "<set", "inkscape", "ENTITY", "<!--", "<?xml", "<a",
+	} {
+		if strings.Contains(got, banned) {
+			t.Errorf("expected %q to be removed, got:\n%s", banned, got)
+		}
+	}
+	for _, kept := range []string{
+		`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0 0 10 10">`,
+		`<style>.a { fill: red } .b &gt; .c { stroke: blue }</style>`,
+		`<stop offset="0" stop-color="#fff"></stop>`,
+		`<rect class="a" width="5" height="5" fill="url(#g)"></rect>`,
+		`<use xlink:href="#g"></use>`,
+		`<use></use>`,
+		`<image href="data:image/png;base64,iVBORw0KGgo=" width="1" height="1"></image>`,
+		`<g><text x="1" y="2">a &lt; b &amp; &#34;c&#34;</text></g>`,
+	} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("expected %s in:\n%s", kept, got)
+		}
+	}
+	if Detect(out) != "image/svg+xml" {
+		t.Errorf("expected the output to still be detected as SVG")
+	}
+}
+
+func TestSanitizeSVG_Malformed(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`<svg><g></svg>`,
+		`<svg></svg><svg></svg>`,
+		`<svg></svg>trailing`,
+		`<html><svg></svg></html>`,
+		`<svg>&undefined;</svg>`,
+		`<svg attr=unquoted></svg>`,
+	} {
+		if _, err := SanitizeSVG([]byte(input)); !errors.Is(err, ErrMalformedSVG) {
+			t.Errorf("SanitizeSVG(%q): expected ErrMalformedSVG, got %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package filecheck
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrMalformedJPEG is returned by StripJPEGMetadata for data that is not a
+// well-formed sequence of JPEG segments
+var ErrMalformedJPEG = errors.New("filecheck: malformed JPEG")
+
+// JPEG markers
+const (
+	markerSOI  = 0xd8
+	markerEOI  = 0xd9
+	markerSOS  = 0xda
+	markerAPP0 = 0xe0
+	markerAPP1 = 0xe1
+	markerAPP2 = 0xe2
+	markerAPPE = 0xee
+	markerAPPF = 0xef
+	markerCOM  = 0xfe
+)
+
+// exifOrientation is the TIFF tag of the image orientation
+const exifOrientation = 0x0112
+
+// StripJPEGMetadata removes Exif (camera, date and GPS position), XMP, IPTC
+// and comment segments from a JPEG. The JFIF and Adobe segments and the ICC
+// color profile, which change how the image is decoded, are kept, and so is
+// an Exif orientation other than the default, in a minimal Exif segment of
+// its own. The compressed image data is copied as is and anything after its
+// end is dropped.
+func StripJPEGMetadata(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != markerSOI {
+		return nil, ErrMalformedJPEG
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xff, markerSOI)
+	orientation := uint16(0)
+
+	pos := 2
+	for {
+		if pos >= len(data) || data[pos] != 0xff {
+			return nil, ErrMalformedJPEG
+		}
+		// Any number of 0xff may pad a marker
+		for pos < len(data) && data[pos] == 0xff {
+			pos++
+		}
+		if pos >= len(data) {
+			return nil, ErrMalformedJPEG
+		}
+		marker := data[pos]
+		pos++
+		if marker == markerEOI {
+			return append(out, 0xff, markerEOI), nil
+		}
+		if marker == markerSOI || (marker >= 0xd0 && marker <= 0xd7) || marker == 0x01 || marker == 0x00 {
+			// Restart markers only appear in scan data
+			return nil, ErrMalformedJPEG
+		}
+		if pos+2 > len(data) {
+			return nil, ErrMalformedJPEG
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		if length < 2 || pos+length > len(data) {
+			return nil, ErrMalformedJPEG
+		}
+		segment := data[pos-2 : pos+length]
+		payload := data[pos+2 : pos+length]
+		pos += length
+
+		switch {
+		case marker == markerSOS:
+			if orientation > 1 {
+				out = appendOrientationSegment(out, orientation)
+			}
+			out = append(out, segment...)
+			return appendScanData(out, data[pos:]), nil
+		case marker == markerAPP1:
+			if o := readExifOrientation(payload); o > 0 && orientation == 0 {
+				orientation = o
+			}
+		case marker == markerAPP2:
+			if bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00")) {
+				out = append(out, segment...)
+			}
+		case marker == markerAPP0, marker == markerAPPE:
+			out = append(out, segment...)
+		case marker >= markerAPP0 && marker <= markerAPPF, marker == markerCOM:
+			// Other application data and comments are dropped
+		default:
+			out = append(out, segment...)
+		}
+	}
+}
+
+// appendScanData appends the entropy-coded data of a JPEG up to and
+// including its last end-of-image marker. Later scans of a progressive JPEG
+// and the tables between them are part of it.
+func appendScanData(out, rest []byte) []byte {
+	if end := bytes.LastIndex(rest, []byte{0xff, markerEOI}); end >= 0 {
+		return append(out, rest[:end+2]...)
+	}
+	// A truncated file is left for the decoder to judge
+	return append(out, rest...)
+}
+
+// readExifOrientation returns the orientation tag of an APP1 Exif payload,
+// or 0 when it has none
+func readExifOrientation(payload []byte) uint16 {
+	tiff, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00"))
+	if !ok || len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := range count {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientation {
+			continue
+		}
+		// A SHORT with a count of 1, stored in the value field
+		if order.Uint16(tiff[entry+2:]) != 3 || order.Uint32(tiff[entry+4:]) != 1 {
+			return 0
+		}
+		if o := order.Uint16(tiff[entry+8:]); o >= 1 && o <= 8 {
+			return o
+		}
+		return 0
+	}
+	return 0
+}
+
+// appendOrientationSegment appends an APP1 Exif segment holding only the
+// orientation tag
+func appendOrientationSegment(out []byte, orientation uint16) []byte {
+	var tiff []byte
+	tiff = append(tiff, "MM\x00*"...)
+	tiff = binary.BigEndian.AppendUint32(tiff, 8)
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0)
+	tiff = binary.BigEndian.AppendUint32(tiff, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	out = append(out, 0xff, markerAPP1)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	return append(out, payload...)
+}
@@ -0,0 +1,145 @@
+// Package filecheck inspects uploaded files: it detects their type from
+// their content, checks the file extension against it, and removes what
+// should not be published, such as JPEG location metadata and SVG scripts.
+package filecheck
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLen is how much of a file Detect reads
+const sniffLen = 4096
+
+// extensions lists the extensions accepted for each detected type
+var extensions = map[string][]string{
+	"image/jpeg":      {".jpg", ".jpeg", ".jpe", ".jfif"},
+	"image/png":       {".png"},
+	"image/gif":       {".gif"},
+	"image/webp":      {".webp"},
+	"image/avif":      {".avif"},
+	"image/svg+xml":   {".svg"},
+	"application/pdf": {".pdf"},
+	"video/mp4":       {".mp4", ".m4v"},
+	"video/quicktime": {".mov"},
+	"video/webm":      {".webm"},
+	"audio/mp4":       {".m4a"},
+	"audio/mpeg":      {".mp3"},
+	"audio/ogg":       {".ogg", ".oga"},
+	"audio/wav":       {".wav"},
+}
+
+// Detect returns the MIME type of data from its content, without parameters,
+// or "application/octet-stream" when it is not recognized. Only a document
+// whose root element is <svg> is SVG; HTML is reported as text/html, so a
+// renamed page cannot pass as an image.
+func Detect(data []byte) string {
+	head := data[:min(len(data), sniffLen)]
+	switch {
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif"
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return "image/webp"
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return "audio/wav"
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+	case bytes.HasPrefix(head, []byte("OggS\x00")):
+		return "audio/ogg"
+	case bytes.HasPrefix(head, []byte("ID3")), len(head) >= 2 && head[0] == 0xff && head[1]&0xe6 == 0xe2:
+		// ID3 tag, or an MPEG-1/2 layer III frame sync
+		return "audio/mpeg"
+	case bytes.HasPrefix(head, []byte("\x1a\x45\xdf\xa3")):
+		// EBML; Matroska files that are not WebM stay unrecognized
+		if bytes.Contains(head[:min(len(head), 64)], []byte("webm")) {
+			return "video/webm"
+		}
+		return "application/octet-stream"
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		return isoBaseMediaType(head)
+	case isSVG(head):
+		return "image/svg+xml"
+	}
+
+	// Leave the rest to the WHATWG sniffing rules, which know HTML, XML,
+	// archives and plain text
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if detected == "text/plain" && !utf8.Valid(head[:max(0, len(head)-utf8.UTFMax)]) {
+		return "application/octet-stream"
+	}
+	return detected
+}
+
+// isoBaseMediaType tells MP4, QuickTime, M4A and AVIF apart by the major
+// brand of their ftyp box
+func isoBaseMediaType(head []byte) string {
+	switch brand := string(head[8:12]); brand {
+	case "qt  ":
+		return "video/quicktime"
+	case "M4A ", "M4B ":
+		return "audio/mp4"
+	case "avif", "avis":
+		return "image/avif"
+	case "heic", "heix", "mif1", "msf1":
+		return "application/octet-stream"
+	}
+	return "video/mp4"
+}
+
+// isSVG reports whether the first element of a text document is <svg>,
+// after an optional BOM, XML declaration, comments and doctype
+func isSVG(head []byte) bool {
+	s := strings.TrimPrefix(string(head), "\ufeff")
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		switch {
+		case strings.HasPrefix(s, "<?"):
+			end := strings.Index(s, "?>")
+			if end < 0 {
+				return false
+			}
+			s = s[end+2:]
+		case strings.HasPrefix(s, "<!--"):
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				return false
+			}
+			s = s[end+3:]
+		case strings.HasPrefix(strings.ToUpper(s), "<!DOCTYPE"):
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				return false
+			}
+			s = s[end+1:]
+		default:
+			if !strings.HasPrefix(s, "<svg") || len(s) < 5 {
+				return false
+			}
+			return strings.ContainsRune(" \t\r\n>/", rune(s[4]))
+		}
+	}
+}
+
+// ExtensionMatches reports whether the extension of fileName is one used for
+// mimeType. Types outside the built-in list fall back to the system MIME
+// table.
+func ExtensionMatches(fileName, mimeType string) bool {
+	ext := strings.ToLower(path.Ext(fileName))
+	if ext == "" {
+		return false
+	}
+	if exts, ok := extensions[mimeType]; ok {
+		return slices.Contains(exts, ext)
+	}
+	exts, _ := mime.ExtensionsByType(mimeType)
+	return slices.Contains(exts, ext)
+}
@@ -0,0 +1,218 @@
+package filecheck
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrMalformedSVG is returned by SanitizeSVG for a document that is not
+// well-formed XML with an <svg> root element
+var ErrMalformedSVG = errors.New("filecheck: malformed SVG")
+
+// svgElements lists the elements kept by SanitizeSVG. Scripts, foreign
+// content, animations (which can rewrite links) and editor metadata are not
+// among them and are dropped with everything inside.
+var svgElements = map[string]bool{
+	"svg": true, "g": true, "defs": true, "symbol": true, "use": true,
+	"title": true, "desc": true, "style": true, "view": true,
+	"path": true, "rect": true, "circle": true, "ellipse": true,
+	"line": true, "polyline": true, "polygon": true, "image": true,
+	"text": true, "tspan": true, "textPath": true,
+	"linearGradient": true, "radialGradient": true, "stop": true,
+	"pattern": true, "clipPath": true, "mask": true, "marker": true,
+	"filter": true, "feBlend": true, "feColorMatrix": true,
+	"feComponentTransfer": true, "feComposite": true,
+	"feConvolveMatrix": true, "feDiffuseLighting": true,
+	"feDisplacementMap": true, "feDistantLight": true,
+	"feDropShadow": true, "feFlood": true, "feFuncA": true, "feFuncB": true,
+	"feFuncG": true, "feFuncR": true, "feGaussianBlur": true,
+	"feMerge": true, "feMergeNode": true, "feMorphology": true,
+	"feOffset": true, "fePointLight": true, "feSpecularLighting": true,
+	"feSpotLight": true, "feTile": true, "feTurbulence": true,
+}
+
+// svgUnwrapped lists elements whose tags are dropped but whose content is kept
+var svgUnwrapped = map[string]bool{"a": true}
+
+// svgNamespacedAttrs lists the prefixed attributes that are kept
+var svgNamespacedAttrs = map[string]bool{
+	"xmlns:xlink": true, "xlink:href": true, "xlink:title": true,
+	"xml:space": true, "xml:lang": true,
+}
+
+// unsafeCSS lists what may not appear in an attribute value or stylesheet,
+// compared in lower case without whitespace
+var unsafeCSS = []string{
+	"javascript:", "vbscript:", "data:text/html", "expression(",
+	"@import", "-moz-binding", "behavior:",
+}
+
+// SanitizeSVG rewrites an SVG document without scripts, event handler
+// attributes, links to other documents and other active content. Comments,
+// processing instructions and doctypes, which could define entities, are
+// removed. Links may only point within the document or to an embedded
+// raster image.
+func SanitizeSVG(data []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = true
+
+	var out bytes.Buffer
+	// stack holds the open elements: kept ones are written out, skipped ones
+	// are dropped with their content, and unwrapped ones are neither
+	type open struct {
+		name    string
+		kept    bool
+		skipped bool
+		// style is the output offset of a <style> element, which is dropped
+		// once its content turns out to be unsafe
+		style int
+	}
+	var stack []open
+	rootSeen := false
+	skipping := 0
+
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrMalformedSVG
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := qualifiedName(t.Name)
+			if len(stack) == 0 {
+				if rootSeen || name != "svg" {
+					return nil, ErrMalformedSVG
+				}
+				rootSeen = true
+			}
+			entry := open{name: name, style: -1}
+			switch {
+			case skipping > 0 || (!svgElements[name] && !svgUnwrapped[name]):
+				entry.skipped = true
+				skipping++
+			case svgUnwrapped[name]:
+			default:
+				entry.kept = true
+				if name == "style" {
+					entry.style = out.Len()
+				}
+				writeSVGStart(&out, t)
+			}
+			stack = append(stack, entry)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != qualifiedName(t.Name) {
+				return nil, ErrMalformedSVG
+			}
+			entry := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			switch {
+			case entry.skipped:
+				skipping--
+			case entry.style >= 0 && unsafeValue(out.String()[entry.style:]):
+				out.Truncate(entry.style)
+			case entry.kept:
+				out.WriteString("</" + entry.name + ">")
+			}
+		case xml.CharData:
+			if len(stack) == 0 {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, ErrMalformedSVG
+				}
+				continue
+			}
+			if skipping == 0 {
+				xml.EscapeText(&out, t)
+			}
+		case xml.Comment, xml.ProcInst, xml.Directive:
+			// Dropped
+		}
+	}
+	if !rootSeen || len(stack) > 0 {
+		return nil, ErrMalformedSVG
+	}
+	return out.Bytes(), nil
+}
+
+// writeSVGStart writes a start tag with its safe attributes
+func writeSVGStart(out *bytes.Buffer, t xml.StartElement) {
+	out.WriteString("<" + qualifiedName(t.Name))
+	for _, attr := range t.Attr {
+		name := qualifiedName(attr.Name)
+		if !safeSVGAttr(name, attr.Value) {
+			continue
+		}
+		out.WriteString(" " + name + `="`)
+		xml.EscapeText(out, []byte(attr.Value))
+		out.WriteString(`"`)
+	}
+	out.WriteString(">")
+}
+
+// safeSVGAttr reports whether an attribute is kept
+func safeSVGAttr(name, value string) bool {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasPrefix(lower, "on"):
+		return false
+	case strings.Contains(name, ":") && !svgNamespacedAttrs[name]:
+		return false
+	case lower == "href" || lower == "xlink:href":
+		return safeSVGLink(value)
+	}
+	return !unsafeValue(value)
+}
+
+// safeSVGLink reports whether a link stays within the document or embeds a
+// raster image
+func safeSVGLink(value string) bool {
+	v := compact(value)
+	if strings.HasPrefix(v, "#") {
+		return true
+	}
+	for _, prefix := range []string{"data:image/png;", "data:image/jpeg;", "data:image/gif;", "data:image/webp;"} {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsafeValue reports whether a value holds something from unsafeCSS
+func unsafeValue(value string) bool {
+	v := compact(value)
+	for _, s := range unsafeCSS {
+		if strings.Contains(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// compact lowers s and removes the whitespace and control characters that
+// browsers ignore within a URL scheme or CSS keyword
+func compact(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s)
+}
+
+// qualifiedName returns the name as written, with its prefix
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
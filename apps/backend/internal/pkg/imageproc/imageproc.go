@@ -0,0 +1,172 @@
+// Package imageproc decodes uploaded images, scales them and encodes the
+// results as JPEG, PNG or lossless WebP. WebP is decoded by golang.org/x/image;
+// everything else uses the standard library.
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/webp"
+)
+
+// Formats, as named by image.Decode
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+var (
+	// ErrUnsupported is returned for data that is not an image of a known
+	// format
+	ErrUnsupported = errors.New("imageproc: unsupported image format")
+	// ErrTooLarge is returned for images with more pixels than allowed
+	ErrTooLarge = errors.New("imageproc: image has too many pixels")
+)
+
+// Config is the format and size of an image
+type Config struct {
+	Format string
+	Width  int
+	Height int
+}
+
+// DecodeConfig reads the format and size without decoding the pixels
+func DecodeConfig(data []byte) (Config, error) {
+	if cfg, ok := webpConfig(data); ok {
+		return cfg, nil
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Config{}, ErrUnsupported
+	}
+	return Config{Format: format, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Decode decodes a JPEG, PNG, GIF or WebP image of at most maxPixels pixels;
+// the size is checked before the pixels are decoded. A GIF decodes to its
+// first frame.
+func Decode(data []byte, maxPixels int) (image.Image, string, error) {
+	cfg, err := DecodeConfig(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width < 1 || cfg.Height < 1 || (maxPixels > 0 && cfg.Width*cfg.Height > maxPixels) {
+		return nil, "", ErrTooLarge
+	}
+	if cfg.Format == FormatWebP {
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("imageproc.Decode: %w", err)
+		}
+		return img, FormatWebP, nil
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("imageproc.Decode: %w", err)
+	}
+	return img, format, nil
+}
+
+// IsAnimated reports whether data is a GIF with more than one frame or an
+// animated WebP, which cannot be decoded
+func IsAnimated(data []byte) bool {
+	if _, ok := webpConfig(data); ok {
+		// The VP8X flags byte has the animation bit
+		return string(data[12:16]) == "VP8X" && data[20]&0x02 != 0
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	return err == nil && len(anim.Image) > 1
+}
+
+// Encode writes img in format. quality applies to JPEG. GIF is written as
+// PNG, which keeps the colors the quantizer of image/gif would reduce.
+func Encode(w io.Writer, img *image.NRGBA, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case FormatPNG, FormatGIF:
+		return png.Encode(w, img)
+	case FormatWebP:
+		return EncodeWebP(w, img)
+	}
+	return ErrUnsupported
+}
+
+// EncodedFormat returns the format Encode writes for format
+func EncodedFormat(format string) string {
+	if format == FormatGIF {
+		return FormatPNG
+	}
+	return format
+}
+
+// MimeType returns the MIME type of a format
+func MimeType(format string) string {
+	return "image/" + format
+}
+
+// Extension returns the file extension of a format
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// Fit returns the size of a width x height image scaled down to fit within
+// maxWidth x maxHeight, keeping the aspect ratio. Images that fit are not
+// scaled up; a zero bound is not limited.
+func Fit(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && float64(height)*scale > float64(maxHeight) {
+		scale = float64(maxHeight) / float64(height)
+	}
+	if scale == 1 {
+		return width, height
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
+
+// webpConfig reads the canvas size of a lossy, lossless or extended WebP
+func webpConfig(data []byte) (Config, bool) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return Config{}, false
+	}
+	chunk := data[20:]
+	cfg := Config{Format: FormatWebP}
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// Frame tag, then the start code 9d 01 2a and 14-bit sizes
+		if chunk[3] != 0x9d || chunk[4] != 0x01 || chunk[5] != 0x2a {
+			return Config{}, false
+		}
+		cfg.Width = int(binary.LittleEndian.Uint16(chunk[6:8]) & 0x3fff)
+		cfg.Height = int(binary.LittleEndian.Uint16(chunk[8:10]) & 0x3fff)
+	case "VP8L":
+		if chunk[0] != vp8lSignature {
+			return Config{}, false
+		}
+		bits := binary.LittleEndian.Uint32(chunk[1:5])
+		cfg.Width = int(bits&0x3fff) + 1
+		cfg.Height = int(bits>>14&0x3fff) + 1
+	case "VP8X":
+		cfg.Width = int(uint32(chunk[4])|uint32(chunk[5])<<8|uint32(chunk[6])<<16) + 1
+		cfg.Height = int(uint32(chunk[7])|uint32(chunk[8])<<8|uint32(chunk[9])<<16) + 1
+	default:
+		return Config{}, false
+	}
+	return cfg, true
+}
@@ -0,0 +1,196 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	cases := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{4000, 3000, 1280, 0, 1280, 960},
+		{4000, 3000, 320, 320, 320, 240},
+		{3000, 4000, 320, 320, 240, 320},
+		{800, 600, 1280, 0, 800, 600},
+		{10000, 1, 100, 0, 100, 1},
+		{640, 480, 0, 0, 640, 480},
+	}
+	for _, tc := range cases {
+		if w, h := Fit(tc.w, tc.h, tc.maxW, tc.maxH); w != tc.wantW || h != tc.wantH {
+			t.Errorf("Fit(%d, %d, %d, %d) = %dx%d, want %dx%d", tc.w, tc.h, tc.maxW, tc.maxH, w, h, tc.wantW, tc.wantH)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	t.Run("solid color stays solid", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(10, 10, 411, 211))
+		for i := 0; i < len(src.Pix); i += 4 {
+			copy(src.Pix[i:], []uint8{30, 140, 250, 255})
+		}
+		for _, size := range [][2]int{{100, 50}, {401, 200}, {1000, 3}, {1, 1}} {
+			dst := Resize(src, size[0], size[1])
+			if dst.Rect.Dx() != size[0] || dst.Rect.Dy() != size[1] {
+				t.Fatalf("expected %v, got %v", size, dst.Rect)
+			}
+			for i := 0; i < len(dst.Pix); i += 4 {
+				if !bytes.Equal(dst.Pix[i:i+4], []uint8{30, 140, 250, 255}) {
+					t.Fatalf("%v: unexpected pixel %v at %d", size, dst.Pix[i:i+4], i/4)
+				}
+			}
+		}
+	})
+
+	t.Run("averages when shrinking", func(t *testing.T) {
+		// Alternating black and white columns average to grey
+		src := image.NewGray(image.Rect(0, 0, 64, 64))
+		for y := 0; y < 64; y++ {
+			for x := 0; x < 64; x += 2 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+		dst := Resize(src, 8, 8)
+		for i := 0; i < len(dst.Pix); i += 4 {
+			if v := dst.Pix[i]; v < 120 || v > 135 {
+				t.Fatalf("expected grey, got %d", v)
+			}
+		}
+	})
+
+	t.Run("transparent pixels do not bleed", func(t *testing.T) {
+		// Opaque red next to transparent green keeps its color
+		src := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+		copy(src.Pix, []uint8{255, 0, 0, 255, 255, 0, 0, 255, 0, 255, 0, 0, 0, 255, 0, 0})
+		dst := Resize(src, 2, 1)
+		if got := dst.Pix[0:4]; got[0] != 255 || got[1] != 0 || got[3] < 200 {
+			t.Errorf("expected opaque red, got %v", got)
+		}
+		if got := dst.Pix[4:8]; got[1] != 0 || got[3] > 60 {
+			t.Errorf("expected mostly transparent red, got %v", got)
+		}
+	})
+}
+
+func TestTransform(t *testing.T) {
+	// Left half red, right half blue, on bounds not starting at the origin
+	src := image.NewNRGBA(image.Rect(5, 5, 405, 205))
+	for y := 5; y < 205; y++ {
+		for x := 5; x < 405; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 205 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	cases := []struct {
+		w, h         int
+		fit          string
+		wantW, wantH int
+	}{
+		{100, 100, FitContain, 100, 50},
+		{100, 0, FitCover, 100, 50},
+		{100, 100, FitCover, 100, 100},
+		{50, 100, FitCover, 50, 100},
+		{1000, 1000, FitCover, 200, 200},
+		{1000, 0, FitContain, 400, 200},
+	}
+	for _, tc := range cases {
+		dst := Transform(src, tc.w, tc.h, tc.fit)
+		if dst.Rect.Dx() != tc.wantW || dst.Rect.Dy() != tc.wantH {
+			t.Errorf("Transform(%d, %d, %s) = %dx%d, want %dx%d", tc.w, tc.h, tc.fit, dst.Rect.Dx(), dst.Rect.Dy(), tc.wantW, tc.wantH)
+		}
+	}
+
+	// A square cover crop keeps the middle, where red meets blue
+	dst := Transform(src, 100, 100, FitCover)
+	if left := dst.NRGBAAt(0, 50); left.R != 255 || left.B != 0 {
+		t.Errorf("expected red on the left, got %v", left)
+	}
+	if right := dst.NRGBAAt(99, 50); right.B != 255 || right.R != 0 {
+		t.Errorf("expected blue on the right, got %v", right)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	var jpg, pngData bytes.Buffer
+	jpeg.Encode(&jpg, img, nil)
+	png.Encode(&pngData, img)
+
+	for name, data := range map[string][]byte{"jpeg": jpg.Bytes(), "png": pngData.Bytes()} {
+		decoded, format, err := Decode(data, 60000)
+		if err != nil || format != name || decoded.Bounds().Dx() != 300 {
+			t.Errorf("%s: unexpected result %q %v", name, format, err)
+		}
+		if _, _, err := Decode(data, 59999); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("%s: expected ErrTooLarge, got %v", name, err)
+		}
+	}
+	if _, _, err := Decode([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), 0); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+
+	// A 1x1 lossy WebP
+	lossy, _ := base64.StdEncoding.DecodeString("UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA")
+	if decoded, format, err := Decode(lossy, 0); err != nil || format != FormatWebP || decoded.Bounds().Dx() != 1 {
+		t.Errorf("webp: unexpected result %q %v", format, err)
+	}
+}
+
+func TestIsAnimated(t *testing.T) {
+	frame := image.NewPaletted(image.Rect(0, 0, 2, 2), palette.Plan9)
+	for frames, want := range map[int]bool{1: false, 2: true} {
+		anim := &gif.GIF{}
+		for i := 0; i < frames; i++ {
+			anim.Image = append(anim.Image, frame)
+			anim.Delay = append(anim.Delay, 10)
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			t.Fatal(err)
+		}
+		if got := IsAnimated(buf.Bytes()); got != want {
+			t.Errorf("%d frames: expected %v, got %v", frames, want, got)
+		}
+	}
+
+	// VP8X flags with and without the animation bit
+	for flags, want := range map[byte]bool{0x10: false, 0x12: true} {
+		data := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00"), flags, 0, 0, 0, 1, 0, 0, 1, 0, 0)
+		data = append(data, make([]byte, 16)...)
+		if got := IsAnimated(data); got != want {
+			t.Errorf("WebP flags %#x: expected %v, got %v", flags, want, got)
+		}
+	}
+}
+
+func TestDecodeConfig_WebP(t *testing.T) {
+	riff := func(chunk string, payload []byte) []byte {
+		data := append([]byte("RIFF\x00\x00\x00\x00WEBP"+chunk+"\x00\x00\x00\x00"), payload...)
+		return append(data, make([]byte, 16)...)
+	}
+	cases := map[string][]byte{
+		// Key frame tag, start code, 14-bit width and height
+		"lossy": riff("VP8 ", []byte{0x10, 0x02, 0x00, 0x9d, 0x01, 0x2a, 0x80, 0x02, 0xe0, 0x01}),
+		// 24-bit width-1 and height-1 after the flags
+		"extended": riff("VP8X", []byte{0x10, 0, 0, 0, 0x7f, 0x02, 0x00, 0xdf, 0x01, 0x00}),
+	}
+	for name, data := range cases {
+		cfg, err := DecodeConfig(data)
+		if err != nil || cfg != (Config{Format: FormatWebP, Width: 640, Height: 480}) {
+			t.Errorf("%s: unexpected config %+v %v", name, cfg, err)
+		}
+	}
+}
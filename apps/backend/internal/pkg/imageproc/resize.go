@@ -0,0 +1,225 @@
+package imageproc
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Resize scales img to width x height. It uses a triangle filter whose
+// support grows with the scale factor, so every source pixel contributes
+// when shrinking. Colors are averaged premultiplied, which keeps transparent
+// pixels from bleeding into their neighbours. Source rows are read once and
+// only the output rows being filled are kept in memory.
+func Resize(img image.Image, width, height int) *image.NRGBA {
+	src := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if src.Empty() || width < 1 || height < 1 {
+		return dst
+	}
+	columns := filterWeights(src.Dx(), width)
+	rows := filterWeights(src.Dy(), height)
+
+	srcRow := make([]float32, src.Dx()*4)
+	scaledRow := make([]float32, width*4)
+	open := make(map[int][]float32) // accumulators of output rows being filled
+	next := 0                       // first output row not yet written
+
+	for sy := 0; sy < src.Dy(); sy++ {
+		readRow(img, src.Min.Y+sy, srcRow)
+		for x, w := range columns {
+			var r, g, b, a float32
+			for i, weight := range w.weights {
+				p := srcRow[(w.start+i)*4:]
+				r += p[0] * weight
+				g += p[1] * weight
+				b += p[2] * weight
+				a += p[3] * weight
+			}
+			scaledRow[x*4], scaledRow[x*4+1], scaledRow[x*4+2], scaledRow[x*4+3] = r, g, b, a
+		}
+
+		for y := next; y < height && rows[y].start <= sy; y++ {
+			i := sy - rows[y].start
+			if i >= len(rows[y].weights) {
+				continue
+			}
+			acc, ok := open[y]
+			if !ok {
+				acc = make([]float32, width*4)
+				open[y] = acc
+			}
+			weight := rows[y].weights[i]
+			for j, v := range scaledRow {
+				acc[j] += v * weight
+			}
+		}
+
+		// Rows whose last source row has been read are done
+		for next < height && rows[next].start+len(rows[next].weights)-1 <= sy {
+			writeRow(dst, next, open[next])
+			delete(open, next)
+			next++
+		}
+	}
+	return dst
+}
+
+// weights are the contributions of consecutive source pixels to one output
+// pixel, summing to 1
+type weights struct {
+	start   int
+	weights []float32
+}
+
+func filterWeights(srcSize, dstSize int) []weights {
+	scale := float64(srcSize) / float64(dstSize)
+	support := math.Max(scale, 1)
+	result := make([]weights, dstSize)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+		start = max(start, 0)
+		end = min(end, srcSize-1)
+
+		var ws []float32
+		var sum float64
+		for s := start; s <= end; s++ {
+			w := 1 - math.Abs(float64(s)-center)/support
+			if w < 0 {
+				w = 0
+			}
+			ws = append(ws, float32(w))
+			sum += w
+		}
+		if sum == 0 {
+			// A destination pixel exactly between samples at scale 1
+			nearest := min(max(int(math.Round(center)), 0), srcSize-1)
+			result[i] = weights{start: nearest, weights: []float32{1}}
+			continue
+		}
+		for j := range ws {
+			ws[j] = float32(float64(ws[j]) / sum)
+		}
+		result[i] = weights{start: start, weights: ws}
+	}
+	return result
+}
+
+// readRow reads a row as premultiplied RGBA in the range 0-255, with fast
+// paths for the types the standard decoders return
+func readRow(img image.Image, y int, row []float32) {
+	b := img.Bounds()
+	switch src := img.(type) {
+	case *image.YCbCr:
+		for x := 0; x < b.Dx(); x++ {
+			yi := src.YOffset(b.Min.X+x, y)
+			ci := src.COffset(b.Min.X+x, y)
+			r, g, bl := color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = float32(r), float32(g), float32(bl), 255
+		}
+	case *image.NRGBA:
+		pix := src.Pix[src.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x++ {
+			p := pix[x*4 : x*4+4]
+			alpha := float32(p[3]) / 255
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = float32(p[0])*alpha, float32(p[1])*alpha, float32(p[2])*alpha, float32(p[3])
+		}
+	case *image.RGBA:
+		pix := src.Pix[src.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x++ {
+			p := pix[x*4 : x*4+4]
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = float32(p[0]), float32(p[1]), float32(p[2]), float32(p[3])
+		}
+	case *image.Gray:
+		pix := src.Pix[src.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x++ {
+			v := float32(pix[x])
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = v, v, v, 255
+		}
+	case *image.Paletted:
+		palette := make([][4]float32, len(src.Palette))
+		for i, c := range src.Palette {
+			r, g, bl, a := c.RGBA()
+			palette[i] = [4]float32{float32(r >> 8), float32(g >> 8), float32(bl >> 8), float32(a >> 8)}
+		}
+		pix := src.Pix[src.PixOffset(b.Min.X, y):]
+		for x := 0; x < b.Dx(); x++ {
+			var p [4]float32
+			if int(pix[x]) < len(palette) {
+				p = palette[pix[x]]
+			}
+			copy(row[x*4:x*4+4], p[:])
+		}
+	default:
+		for x := 0; x < b.Dx(); x++ {
+			r, g, bl, a := img.At(b.Min.X+x, y).RGBA()
+			row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = float32(r)/257, float32(g)/257, float32(bl)/257, float32(a)/257
+		}
+	}
+}
+
+// writeRow stores a premultiplied row as straight alpha
+func writeRow(dst *image.NRGBA, y int, row []float32) {
+	pix := dst.Pix[y*dst.Stride:]
+	for x := 0; x < dst.Rect.Dx(); x++ {
+		r, g, b, a := row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]
+		if a <= 0 {
+			pix[x*4], pix[x*4+1], pix[x*4+2], pix[x*4+3] = 0, 0, 0, 0
+			continue
+		}
+		scale := 255 / a
+		pix[x*4] = clamp8(r * scale)
+		pix[x*4+1] = clamp8(g * scale)
+		pix[x*4+2] = clamp8(b * scale)
+		pix[x*4+3] = clamp8(a)
+	}
+}
+
+func clamp8(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// Fit modes of Transform, named after CSS object-fit
+const (
+	FitContain = "contain"
+	FitCover   = "cover"
+)
+
+// Transform scales img to a width x height box. FitContain keeps the whole
+// image within the box; FitCover fills the box, cropping what overflows
+// around the center. Neither scales up: a cover box larger than the image
+// shrinks to the largest box of the same aspect ratio the image fills. A
+// zero bound is not limited, and cover with a single bound is contain.
+func Transform(img image.Image, width, height int, fit string) *image.NRGBA {
+	b := img.Bounds()
+	if fit != FitCover || width < 1 || height < 1 {
+		w, h := Fit(b.Dx(), b.Dy(), width, height)
+		return Resize(img, w, h)
+	}
+
+	cropW, cropH := b.Dx(), b.Dy()
+	if b.Dx()*height > b.Dy()*width {
+		cropW = max(1, int(float64(b.Dy())*float64(width)/float64(height)+0.5))
+	} else {
+		cropH = max(1, int(float64(b.Dx())*float64(height)/float64(width)+0.5))
+	}
+	if cropW < width {
+		width, height = cropW, cropH
+	}
+
+	crop := image.Rect(0, 0, cropW, cropH).Add(b.Min).Add(image.Pt((b.Dx()-cropW)/2, (b.Dy()-cropH)/2))
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		img = sub.SubImage(crop)
+	}
+	return Resize(img, width, height)
+}
@@ -0,0 +1,662 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// WebP lossless (VP8L) encoding, RFC 9649. Neither the standard library nor
+// golang.org/x/image can encode WebP, and the libwebp bindings need cgo, which
+// the static CGO_ENABLED=0 build rules out; a lossy VP8 encoder would be far
+// larger than this one, so variants are lossless only.
+//
+// The encoder applies the subtract green and predictor transforms, finds
+// backward references with a hash chain and entropy codes the result with one
+// set of prefix codes. It skips the color cache, the color transforms and meta
+// prefix codes, which keeps it small at the cost of some compression. Its
+// output is checked against the golang.org/x/image/webp decoder in the tests.
+
+const (
+	vp8lSignature   = 0x2f
+	vp8lMaxSize     = 1 << 14
+	numLiteralCodes = 256
+	numLengthCodes  = 24
+	numDistanceCode = 40
+	// predictorBits is the log2 block size of the predictor transform
+	predictorBits = 4
+
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+
+	// Backward reference search
+	minMatchLength = 3
+	maxMatchLength = 4096
+	hashBits       = 16
+	maxChain       = 32
+	// chainWindow bounds how far back the hash chain is followed, keeping
+	// the search in cache; flat areas match nearby anyway
+	chainWindow = 1 << 16
+)
+
+// Predictor modes tried per block: L, T, Select and ClampAddSubtractFull
+const (
+	predictorLeft     = 1
+	predictorTop      = 2
+	predictorSelect   = 11
+	predictorGradient = 12
+)
+
+var predictorModes = []int{predictorLeft, predictorTop, predictorSelect, predictorGradient}
+
+// codeLengthCodeOrder is the order code length code lengths are written in
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// distanceMap lists the (x, y) offsets of the 120 short distance codes
+var distanceMap = [120][2]int{
+	{0, 1}, {1, 0}, {1, 1}, {-1, 1}, {0, 2}, {2, 0}, {1, 2}, {-1, 2},
+	{2, 1}, {-2, 1}, {2, 2}, {-2, 2}, {0, 3}, {3, 0}, {1, 3}, {-1, 3},
+	{3, 1}, {-3, 1}, {2, 3}, {-2, 3}, {3, 2}, {-3, 2}, {0, 4}, {4, 0},
+	{1, 4}, {-1, 4}, {4, 1}, {-4, 1}, {3, 3}, {-3, 3}, {2, 4}, {-2, 4},
+	{4, 2}, {-4, 2}, {0, 5}, {3, 4}, {-3, 4}, {4, 3}, {-4, 3}, {5, 0},
+	{1, 5}, {-1, 5}, {5, 1}, {-5, 1}, {2, 5}, {-2, 5}, {5, 2}, {-5, 2},
+	{4, 4}, {-4, 4}, {3, 5}, {-3, 5}, {5, 3}, {-5, 3}, {0, 6}, {6, 0},
+	{1, 6}, {-1, 6}, {6, 1}, {-6, 1}, {2, 6}, {-2, 6}, {6, 2}, {-6, 2},
+	{4, 5}, {-4, 5}, {5, 4}, {-5, 4}, {3, 6}, {-3, 6}, {6, 3}, {-6, 3},
+	{0, 7}, {7, 0}, {1, 7}, {-1, 7}, {5, 5}, {-5, 5}, {7, 1}, {-7, 1},
+	{4, 6}, {-4, 6}, {6, 4}, {-6, 4}, {2, 7}, {-2, 7}, {7, 2}, {-7, 2},
+	{3, 7}, {-3, 7}, {7, 3}, {-7, 3}, {5, 6}, {-5, 6}, {6, 5}, {-6, 5},
+	{8, 0}, {4, 7}, {-4, 7}, {7, 4}, {-7, 4}, {8, 1}, {8, 2}, {6, 6},
+	{-6, 6}, {8, 3}, {5, 7}, {-5, 7}, {7, 5}, {-7, 5}, {8, 4}, {6, 7},
+	{-6, 7}, {7, 6}, {-7, 6}, {8, 5}, {7, 7}, {-7, 7}, {8, 6}, {8, 7},
+}
+
+// EncodeWebP writes img as a lossless WebP file
+func EncodeWebP(w io.Writer, img *image.NRGBA) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxSize || height > vp8lMaxSize {
+		return errors.New("imageproc.EncodeWebP: image must be 1 to 16384 pixels wide and high")
+	}
+
+	// ARGB pixels, then the transforms in the order they are written
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			p := row[x*4 : x*4+4]
+			argb[y*width+x] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+			if p[3] != 0xff {
+				hasAlpha = true
+			}
+		}
+	}
+	subtractGreen(argb)
+	residuals, modes := predict(argb, width, height)
+
+	bw := &bitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	bw.writeBits(1, 1)
+	bw.writeBits(transformSubtractGreen, 2)
+
+	bw.writeBits(1, 1)
+	bw.writeBits(transformPredictor, 2)
+	bw.writeBits(predictorBits-2, 3)
+	bw.writeBits(0, 1) // sub-image: no color cache
+	writeImageData(bw, modes, subSampleSize(width, predictorBits))
+
+	bw.writeBits(0, 1) // no more transforms
+	bw.writeBits(0, 1) // no color cache
+	bw.writeBits(0, 1) // no meta prefix codes
+	writeImageData(bw, residuals, width)
+	data := bw.bytes()
+
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	riffSize := 4 + 8 + len(data) + len(data)&1
+	binary.LittleEndian.PutUint32(header[4:8], uint32(riffSize))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(data)&1 == 1 {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// subtractGreen subtracts the green value from red and blue
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := (p >> 8) & 0xff
+		red := ((p >> 16) - green) & 0xff
+		blue := (p - green) & 0xff
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// predict returns the residuals of the predictor transform and its
+// sub-image, with the mode giving the smallest residuals in each block. As
+// in the decoder, the top-left pixel is predicted as opaque black, the rest
+// of the top row from the left and the left column from the top.
+func predict(argb []uint32, width, height int) ([]uint32, []uint32) {
+	blocksW, blocksH := subSampleSize(width, predictorBits), subSampleSize(height, predictorBits)
+	modes := make([]uint32, blocksW*blocksH)
+	residuals := make([]uint32, len(argb))
+	for by := 0; by < blocksH; by++ {
+		for bx := 0; bx < blocksW; bx++ {
+			x0, y0 := bx<<predictorBits, by<<predictorBits
+			x1, y1 := min(x0+1<<predictorBits, width), min(y0+1<<predictorBits, height)
+			best, bestCost := predictorSelect, -1
+			for _, mode := range predictorModes {
+				cost := 0
+				for y := max(y0, 1); y < y1; y++ {
+					for x := max(x0, 1); x < x1; x++ {
+						i := y*width + x
+						cost += residualCost(subPixels(argb[i], predictPixel(mode, argb[i-1], argb[i-width], argb[i-width-1])))
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			// The mode is stored in the green channel
+			modes[by*blocksW+bx] = 0xff000000 | uint32(best)<<8
+
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					i := y*width + x
+					var pred uint32
+					switch {
+					case x == 0 && y == 0:
+						pred = 0xff000000
+					case y == 0:
+						pred = argb[i-1]
+					case x == 0:
+						pred = argb[i-width]
+					default:
+						pred = predictPixel(best, argb[i-1], argb[i-width], argb[i-width-1])
+					}
+					residuals[i] = subPixels(argb[i], pred)
+				}
+			}
+		}
+	}
+	return residuals, modes
+}
+
+func predictPixel(mode int, left, top, topLeft uint32) uint32 {
+	switch mode {
+	case predictorLeft:
+		return left
+	case predictorTop:
+		return top
+	case predictorSelect:
+		return selectPredictor(left, top, topLeft)
+	}
+	return clampAddSubtractFull(left, top, topLeft)
+}
+
+// selectPredictor returns the left or top pixel, whichever is closer to the
+// gradient estimate L + T - TL
+func selectPredictor(left, top, topLeft uint32) uint32 {
+	distLeft, distTop := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		l := int(left>>shift) & 0xff
+		t := int(top>>shift) & 0xff
+		tl := int(topLeft>>shift) & 0xff
+		estimate := l + t - tl
+		distLeft += abs(estimate - l)
+		distTop += abs(estimate - t)
+	}
+	if distLeft < distTop {
+		return left
+	}
+	return top
+}
+
+// clampAddSubtractFull returns L + T - TL per channel, clamped to 0-255
+func clampAddSubtractFull(left, top, topLeft uint32) uint32 {
+	var p uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(left>>shift&0xff) + int(top>>shift&0xff) - int(topLeft>>shift&0xff)
+		p |= uint32(min(max(v, 0), 255)) << shift
+	}
+	return p
+}
+
+// residualCost estimates the cost of a residual: its channels as signed
+// distances from zero
+func residualCost(p uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		cost += abs(int(int8(p >> shift)))
+	}
+	return cost
+}
+
+// subPixels subtracts each channel modulo 256
+func subPixels(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return alphaGreen&0xff00ff00 | redBlue&0x00ff00ff
+}
+
+func subSampleSize(size, bits int) int {
+	return (size + 1<<bits - 1) >> bits
+}
+
+// symbol is a literal pixel or, when length is positive, a backward
+// reference to length pixels at distance code dist
+type symbol struct {
+	pixel  uint32
+	length int
+	dist   int
+}
+
+// backwardReferences greedily replaces runs of pixels seen before by
+// references to them, using a hash chain over pairs of pixels
+func backwardReferences(argb []uint32, width int) []symbol {
+	// Distances with a short code
+	shortCodes := make(map[int]int, len(distanceMap))
+	for i := len(distanceMap) - 1; i >= 0; i-- {
+		if d := distanceMap[i][0] + distanceMap[i][1]*width; d >= 1 {
+			shortCodes[d] = i + 1
+		}
+	}
+
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, len(argb))
+	hash := func(i int) uint32 {
+		return (argb[i]*0x9e3779b1 ^ argb[i+1]*0x85ebca6b) >> (32 - hashBits)
+	}
+	insert := func(i int) {
+		if i+1 < len(argb) {
+			h := hash(i)
+			prev[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+
+	symbols := make([]symbol, 0, len(argb)/4)
+	for i := 0; i < len(argb); {
+		bestLength, bestDistance := 0, 0
+		limit := min(len(argb)-i, maxMatchLength)
+		try := func(j int) {
+			// A candidate must beat the best match at its last pixel
+			if j < 0 || bestLength == limit || argb[j+bestLength] != argb[i+bestLength] {
+				return
+			}
+			n := 0
+			for n < limit && argb[j+n] == argb[i+n] {
+				n++
+			}
+			if n > bestLength {
+				bestLength, bestDistance = n, i-j
+			}
+		}
+		// The pixel above and the one to the left are the likeliest matches
+		// and have the shortest codes
+		try(i - width)
+		try(i - 1)
+		if i+1 < len(argb) {
+			for j, n := int(head[hash(i)]), 0; j >= 0 && n < maxChain && i-j <= chainWindow; j, n = int(prev[j]), n+1 {
+				try(j)
+			}
+		}
+		if bestLength < minMatchLength {
+			symbols = append(symbols, symbol{pixel: argb[i]})
+			insert(i)
+			i++
+			continue
+		}
+		dist, ok := shortCodes[bestDistance]
+		if !ok {
+			dist = bestDistance + len(distanceMap)
+		}
+		symbols = append(symbols, symbol{length: bestLength, dist: dist})
+		for end := i + bestLength; i < end; i++ {
+			insert(i)
+		}
+	}
+	return symbols
+}
+
+// prefixEncode splits a length or distance code into a prefix symbol and
+// extra bits
+func prefixEncode(v int) (prefix, extraBits, extra int) {
+	if v <= 4 {
+		return v - 1, 0, 0
+	}
+	n := v - 1
+	highBit := bits.Len(uint(n)) - 1
+	second := (n >> (highBit - 1)) & 1
+	extraBits = highBit - 1
+	return 2*highBit + second, extraBits, n - (2+second)<<extraBits
+}
+
+// writeImageData writes the five prefix codes of an entropy coded image
+// followed by its pixels, literals as green, red, blue and alpha
+func writeImageData(bw *bitWriter, argb []uint32, width int) {
+	symbols := backwardReferences(argb, width)
+
+	green := make([]int, numLiteralCodes+numLengthCodes)
+	red := make([]int, numLiteralCodes)
+	blue := make([]int, numLiteralCodes)
+	alpha := make([]int, numLiteralCodes)
+	distance := make([]int, numDistanceCode)
+	for _, s := range symbols {
+		if s.length > 0 {
+			prefix, _, _ := prefixEncode(s.length)
+			green[numLiteralCodes+prefix]++
+			prefix, _, _ = prefixEncode(s.dist)
+			distance[prefix]++
+			continue
+		}
+		p := s.pixel
+		green[(p>>8)&0xff]++
+		red[(p>>16)&0xff]++
+		blue[p&0xff]++
+		alpha[p>>24]++
+	}
+
+	greenCode := writePrefixCode(bw, green)
+	redCode := writePrefixCode(bw, red)
+	blueCode := writePrefixCode(bw, blue)
+	alphaCode := writePrefixCode(bw, alpha)
+	distanceCode := writePrefixCode(bw, distance)
+
+	for _, s := range symbols {
+		if s.length > 0 {
+			prefix, extraBits, extra := prefixEncode(s.length)
+			greenCode.write(bw, numLiteralCodes+prefix)
+			bw.writeBits(uint32(extra), extraBits)
+			prefix, extraBits, extra = prefixEncode(s.dist)
+			distanceCode.write(bw, prefix)
+			bw.writeBits(uint32(extra), extraBits)
+			continue
+		}
+		p := s.pixel
+		greenCode.write(bw, int(p>>8)&0xff)
+		redCode.write(bw, int(p>>16)&0xff)
+		blueCode.write(bw, int(p)&0xff)
+		alphaCode.write(bw, int(p>>24))
+	}
+}
+
+// prefixCode is a canonical Huffman code
+type prefixCode struct {
+	lengths []int
+	codes   []uint32 // bit-reversed, ready to be written LSB first
+}
+
+func (c *prefixCode) write(bw *bitWriter, symbol int) {
+	if n := c.lengths[symbol]; n > 0 {
+		bw.writeBits(c.codes[symbol], n)
+	}
+}
+
+// writePrefixCode builds a code for the histogram and writes it. Up to two
+// used symbols below 256 are written as a simple code; a single symbol then
+// takes no bits at all.
+func writePrefixCode(bw *bitWriter, histogram []int) *prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	if len(used) <= 2 && used[len(used)-1] < numLiteralCodes {
+		bw.writeBits(1, 1) // simple code
+		bw.writeBits(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(used[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(used[0]), 8)
+		}
+		lengths := make([]int, len(histogram))
+		if len(used) == 2 {
+			bw.writeBits(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return newPrefixCode(lengths)
+	}
+
+	lengths := huffmanLengths(histogram, maxCodeLength)
+	bw.writeBits(0, 1) // normal code
+	writeCodeLengths(bw, lengths)
+	return newPrefixCode(lengths)
+}
+
+// writeCodeLengths writes the code lengths of a normal code, themselves
+// prefix coded, with runs of zeros as repeat codes 17 and 18
+func writeCodeLengths(bw *bitWriter, lengths []int) {
+	type token struct{ symbol, extra, extraBits int }
+	var tokens []token
+	for i := 0; i < len(lengths); {
+		if lengths[i] != 0 {
+			tokens = append(tokens, token{symbol: lengths[i]})
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == 0 {
+			run++
+		}
+		i += run
+		for run > 0 {
+			switch {
+			case run >= 11:
+				n := min(run, 138)
+				tokens = append(tokens, token{18, n - 11, 7})
+				run -= n
+			case run >= 3:
+				tokens = append(tokens, token{17, run - 3, 3})
+				run = 0
+			default:
+				tokens = append(tokens, token{symbol: 0})
+				run--
+			}
+		}
+	}
+
+	histogram := make([]int, len(codeLengthCodeOrder))
+	for _, t := range tokens {
+		histogram[t.symbol]++
+	}
+	codeLengthLengths := huffmanLengths(histogram, maxCodeLengthCodeLength)
+	codeLengthCode := newPrefixCode(codeLengthLengths)
+
+	count := len(codeLengthCodeOrder)
+	for count > 4 && codeLengthLengths[codeLengthCodeOrder[count-1]] == 0 {
+		count--
+	}
+	bw.writeBits(uint32(count-4), 4)
+	for _, symbol := range codeLengthCodeOrder[:count] {
+		bw.writeBits(uint32(codeLengthLengths[symbol]), 3)
+	}
+
+	bw.writeBits(0, 1) // lengths for every symbol follow
+	for _, t := range tokens {
+		codeLengthCode.write(bw, t.symbol)
+		if t.extraBits > 0 {
+			bw.writeBits(uint32(t.extra), t.extraBits)
+		}
+	}
+}
+
+// huffmanLengths returns code lengths of at most maxLength bits for the
+// histogram. At least two symbols get a code, so the code is complete. When
+// the lengths are too long the small counts are raised until they fit.
+func huffmanLengths(histogram []int, maxLength int) []int {
+	counts := append([]int(nil), histogram...)
+	used := 0
+	for _, c := range counts {
+		if c > 0 {
+			used++
+		}
+	}
+	for i := 0; used < 2 && i < len(counts); i++ {
+		if counts[i] == 0 {
+			counts[i] = 1
+			used++
+		}
+	}
+
+	for minCount := 1; ; minCount *= 2 {
+		for i, c := range counts {
+			if c > 0 && c < minCount {
+				counts[i] = minCount
+			}
+		}
+		lengths := buildHuffman(counts)
+		longest := 0
+		for _, n := range lengths {
+			longest = max(longest, n)
+		}
+		if longest <= maxLength {
+			return lengths
+		}
+	}
+}
+
+// buildHuffman returns the Huffman code lengths of the non-zero counts
+func buildHuffman(counts []int) []int {
+	type node struct {
+		count       int
+		symbol      int // leaf symbol, -1 for internal nodes
+		left, right int
+	}
+	var nodes []node
+	for symbol, c := range counts {
+		if c > 0 {
+			nodes = append(nodes, node{count: c, symbol: symbol})
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].count < nodes[j].count })
+
+	// Two queues: the sorted leaves and the internal nodes, which are
+	// created in increasing count order
+	leaves := len(nodes)
+	nextLeaf, nextInner := 0, leaves
+	pick := func() int {
+		if nextLeaf < leaves && (nextInner >= len(nodes) || nodes[nextLeaf].count <= nodes[nextInner].count) {
+			nextLeaf++
+			return nextLeaf - 1
+		}
+		nextInner++
+		return nextInner - 1
+	}
+	for i := 0; i < leaves-1; i++ {
+		a, b := pick(), pick()
+		nodes = append(nodes, node{count: nodes[a].count + nodes[b].count, symbol: -1, left: a, right: b})
+	}
+
+	lengths := make([]int, len(counts))
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if nodes[i].symbol >= 0 {
+			lengths[nodes[i].symbol] = depth
+			return
+		}
+		walk(nodes[i].left, depth+1)
+		walk(nodes[i].right, depth+1)
+	}
+	walk(len(nodes)-1, 0)
+	return lengths
+}
+
+// newPrefixCode assigns canonical codes: shorter codes first, and by symbol
+// within one length
+func newPrefixCode(lengths []int) *prefixCode {
+	var lengthCount [maxCodeLength + 1]uint32
+	for _, n := range lengths {
+		if n > 0 {
+			lengthCount[n]++
+		}
+	}
+	var nextCode [maxCodeLength + 2]uint32
+	code := uint32(0)
+	for n := 1; n <= maxCodeLength; n++ {
+		code = (code + lengthCount[n-1]) << 1
+		nextCode[n] = code
+	}
+
+	c := &prefixCode{lengths: lengths, codes: make([]uint32, len(lengths))}
+	for symbol, n := range lengths {
+		if n == 0 {
+			continue
+		}
+		c.codes[symbol] = reverseBits(nextCode[n], n)
+		nextCode[n]++
+	}
+	return c
+}
+
+func reverseBits(v uint32, n int) uint32 {
+	var r uint32
+	for i := 0; i < n; i++ {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
+
+// bitWriter packs bits LSB first
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits int
+}
+
+func (w *bitWriter) writeBits(v uint32, n int) {
+	w.acc |= uint64(v) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package imageproc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeWebP_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cases := map[string]*image.NRGBA{
+		"single pixel": fill(1, 1, func(x, y int) [4]uint8 { return [4]uint8{200, 10, 30, 255} }),
+		"solid":        fill(64, 48, func(x, y int) [4]uint8 { return [4]uint8{12, 34, 56, 255} }),
+		"gradient": fill(700, 3, func(x, y int) [4]uint8 {
+			return [4]uint8{uint8(x), uint8(x / 3), uint8(255 - x), 255}
+		}),
+		"two colors": fill(17, 9, func(x, y int) [4]uint8 {
+			if (x+y)%2 == 0 {
+				return [4]uint8{255, 255, 255, 255}
+			}
+			return [4]uint8{0, 0, 0, 255}
+		}),
+		"noise": fill(37, 29, func(x, y int) [4]uint8 {
+			return [4]uint8{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255}
+		}),
+		"alpha": fill(520, 20, func(x, y int) [4]uint8 {
+			return [4]uint8{uint8(x * y), uint8(rng.Intn(4)), 90, uint8(x)}
+		}),
+		// A skewed histogram forces code lengths beyond 15 bits before
+		// limiting
+		"skewed": fill(300, 300, func(x, y int) [4]uint8 {
+			v := uint8(0)
+			for n := rng.Int63(); n&1 == 1 && v < 40; n >>= 1 {
+				v++
+			}
+			return [4]uint8{v, v, v, 255}
+		}),
+	}
+	for name, img := range cases {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, img); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			data := buf.Bytes()
+			if len(data)%2 != 0 || int(binary.LittleEndian.Uint32(data[4:8])) != len(data)-8 {
+				t.Fatalf("bad RIFF size %d for %d bytes", binary.LittleEndian.Uint32(data[4:8]), len(data))
+			}
+			cfg, err := DecodeConfig(data)
+			if err != nil || cfg != (Config{Format: FormatWebP, Width: img.Rect.Dx(), Height: img.Rect.Dy()}) {
+				t.Fatalf("unexpected config %+v %v", cfg, err)
+			}
+
+			// Decoded by golang.org/x/image/webp
+			decoded, format, err := Decode(data, 0)
+			if err != nil || format != FormatWebP {
+				t.Fatalf("decode: %v", err)
+			}
+			got, ok := decoded.(*image.NRGBA)
+			if !ok {
+				t.Fatalf("expected an NRGBA image, got %T", decoded)
+			}
+			if !bytes.Equal(got.Pix, img.Pix) {
+				t.Fatal("decoded pixels differ from the encoded image")
+			}
+		})
+	}
+}
+
+func TestEncodeWebP_BeatsPNG(t *testing.T) {
+	cases := map[string]*image.NRGBA{
+		"gradient": fill(256, 256, func(x, y int) [4]uint8 { return [4]uint8{uint8(x), uint8(y), 128, 255} }),
+		"flat": fill(1280, 640, func(x, y int) [4]uint8 {
+			return [4]uint8{uint8(x / 200 * 40), uint8(y / 200 * 60), 200, 255}
+		}),
+	}
+	for name, img := range cases {
+		var webp, pngData bytes.Buffer
+		if err := EncodeWebP(&webp, img); err != nil {
+			t.Fatal(err)
+		}
+		png.Encode(&pngData, img)
+		if webp.Len() >= pngData.Len() {
+			t.Errorf("%s: expected WebP to be smaller than PNG, got %d and %d bytes", name, webp.Len(), pngData.Len())
+		}
+	}
+}
+
+func TestEncodeWebP_RejectsSize(t *testing.T) {
+	if err := EncodeWebP(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 16385, 1))); err == nil {
+		t.Error("expected an image wider than 16384 pixels to be refused")
+	}
+}
+
+func fill(width, height int, f func(x, y int) [4]uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := f(x, y)
+			copy(img.Pix[img.PixOffset(x, y):], p[:])
+		}
+	}
+	return img
+}
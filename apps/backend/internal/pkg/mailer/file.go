@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every message as an .eml file into a directory
+type FileMailer struct {
+	from string
+	dir  string
+}
+
+// NewFileMailer creates a new FileMailer
+func NewFileMailer(from, dir string) *FileMailer {
+	return &FileMailer{from: from, dir: dir}
+}
+
+// Send writes msg to <dir>/<timestamp>-<id>.eml
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := formatMessage(m.from, msg, now)
+	if err != nil {
+		return fmt.Errorf("FileMailer.Send: %w", err)
+	}
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return fmt.Errorf("FileMailer.Send mkdir: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("FileMailer.Send write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// LogMailer writes every message to the log instead of sending it. Meant for
+// development: the body may contain secrets such as reset links.
+type LogMailer struct {
+	logger zerolog.Logger
+}
+
+// NewLogMailer creates a new LogMailer
+func NewLogMailer(logger zerolog.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Send logs msg
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info().
+		Str("to", msg.To).
+		Str("subject", msg.Subject).
+		Str("body", msg.Body).
+		Msg("email not sent (log mailer)")
+	return nil
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := t.TempDir()
+	m := NewFileMailer("CMS <no-reply@cms.test>", dir)
+
+	msg := Message{To: "editor@cms.test", Subject: "Reset your password", Body: "line one\nline two\n"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".eml") {
+		t.Fatalf("expected one .eml file, got %v", entries)
+	}
+	data, _ := os.ReadFile(dir + "/" + entries[0].Name())
+	for _, want := range []string{
+		"From: CMS <no-reply@cms.test>\r\n",
+		"To: editor@cms.test\r\n",
+		"Subject: Reset your password\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, data)
+		}
+	}
+}
+
+func TestFormatMessage_RejectsHeaderInjection(t *testing.T) {
+	msg := Message{To: "a@cms.test\r\nBcc: victim@example.com", Subject: "hi"}
+	if _, err := formatMessage("no-reply@cms.test", msg, time.Time{}); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader, got: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/config"
+)
+
+// SMTPMailer sends email through an SMTP server, using STARTTLS when the
+// server offers it
+type SMTPMailer struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPMailer creates a new SMTPMailer
+func NewSMTPMailer(cfg config.MailConfig) *SMTPMailer {
+	return &SMTPMailer{
+		addr:     net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:     cfg.SMTPHost,
+		from:     cfg.From,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+	}
+}
+
+// Send delivers msg. net/smtp has no context support, so ctx is only checked
+// before connecting.
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("SMTPMailer.Send: %w", err)
+	}
+
+	data, err := formatMessage(m.from, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("SMTPMailer.Send: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("SMTPMailer.Send: %w", err)
+	}
+	return nil
+}
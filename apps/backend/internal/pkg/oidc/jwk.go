@@ -0,0 +1,180 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// jsonWebKey is a public key as published by a provider (RFC 7517)
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+}
+
+// jsonWebKeySet is a JWKS document
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// publicKey is a parsed signing key of the provider
+type publicKey struct {
+	id        string
+	algorithm string // empty when the JWK does not name one
+	key       interface{}
+}
+
+// keySet holds the parsed signing keys of the provider
+type keySet struct {
+	keys      []publicKey
+	fetchedAt time.Time
+}
+
+// parse decodes the signing keys of the set. Keys of unknown types or meant
+// for encryption are skipped.
+func (s jsonWebKeySet) parse() (*keySet, error) {
+	set := &keySet{fetchedAt: time.Now()}
+	for _, jwk := range s.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", jwk.KeyID, err)
+		}
+		if key == nil {
+			continue
+		}
+		set.keys = append(set.keys, publicKey{id: jwk.KeyID, algorithm: jwk.Algorithm, key: key})
+	}
+	if len(set.keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return set, nil
+}
+
+// find returns the key with the ID that can verify alg. A token without kid
+// matches when the set has a single suitable key.
+func (s *keySet) find(kid, alg string) (interface{}, bool) {
+	var match interface{}
+	matches := 0
+	for _, k := range s.keys {
+		if kid != "" && k.id != kid {
+			continue
+		}
+		if !k.fits(alg) {
+			continue
+		}
+		match = k.key
+		matches++
+	}
+	if matches == 1 || (kid != "" && matches > 0) {
+		return match, true
+	}
+	return nil, false
+}
+
+// fits reports whether the key type and JWK alg allow the token algorithm
+func (k publicKey) fits(alg string) bool {
+	if k.algorithm != "" && k.algorithm != alg {
+		return false
+	}
+	switch k.key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		return strings.HasPrefix(alg, "ES")
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// publicKey decodes the key material. It returns nil for unsupported types.
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		if n.BitLen() < 2048 {
+			return nil, errors.New("RSA key must have at least 2048 bits")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
+
+// decodeBigInt decodes an unpadded base64url big-endian integer
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
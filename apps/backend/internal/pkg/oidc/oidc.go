@@ -0,0 +1,397 @@
+// Package oidc is a minimal OpenID Connect relying party for the
+// authorization code flow with PKCE: provider discovery, the authorization
+// URL, the code exchange and ID token verification against the provider's
+// JWKS.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// discoveryTTL is how long the provider metadata is reused
+	discoveryTTL = time.Hour
+	// keyRefreshInterval limits JWKS refetches triggered by unknown key IDs,
+	// so forged tokens cannot make us hammer the provider
+	keyRefreshInterval = 10 * time.Second
+	// clockSkew is the leeway allowed on exp and iat
+	clockSkew = time.Minute
+	// maxResponseSize caps provider responses
+	maxResponseSize = 1 << 20
+)
+
+// ErrInvalidIDToken is returned when an ID token fails verification
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// signingAlgorithms are the ID token algorithms accepted. "none" and HMAC are
+// never accepted.
+var signingAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Config holds the client registration at the provider
+type Config struct {
+	// IssuerURL is the issuer identifier; discovery is read from
+	// IssuerURL/.well-known/openid-configuration
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered at the provider
+	RedirectURL string
+	// Scopes are requested in addition to openid
+	Scopes []string
+	// HTTPClient defaults to a client with a 10s timeout
+	HTTPClient *http.Client
+}
+
+// Discovery is the part of the provider metadata the client uses
+type Discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	UserinfoEndpoint      string   `json:"userinfo_endpoint"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// IDToken holds the verified claims of an ID token
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Claims        map[string]interface{}
+}
+
+// Client talks to one OpenID provider. Provider metadata and keys are fetched
+// on first use and cached; it is safe for concurrent use.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu           sync.Mutex
+	discovery    *Discovery
+	discoveredAt time.Time
+	keys         *keySet
+}
+
+// NewClient creates a new Client. It does not contact the provider.
+func NewClient(cfg Config) *Client {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	cfg.IssuerURL = strings.TrimSuffix(cfg.IssuerURL, "/")
+	return &Client{cfg: cfg, http: httpClient}
+}
+
+// Issuer returns the configured issuer identifier
+func (c *Client) Issuer() string {
+	return c.cfg.IssuerURL
+}
+
+// Discover returns the provider metadata, fetching it when not cached. The
+// issuer in the metadata must equal the configured one.
+func (c *Client) Discover(ctx context.Context) (*Discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.discoverLocked(ctx)
+}
+
+// discoverLocked is Discover with c.mu held
+func (c *Client) discoverLocked(ctx context.Context) (*Discovery, error) {
+	if c.discovery != nil && time.Since(c.discoveredAt) < discoveryTTL {
+		return c.discovery, nil
+	}
+
+	var doc Discovery
+	if err := c.getJSON(ctx, c.cfg.IssuerURL+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != c.cfg.IssuerURL {
+		return nil, fmt.Errorf("oidc discovery: issuer %q does not match %q", doc.Issuer, c.cfg.IssuerURL)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: authorization, token and jwks endpoints are required")
+	}
+	if len(doc.CodeChallengeMethods) > 0 && !contains(doc.CodeChallengeMethods, "S256") {
+		return nil, errors.New("oidc discovery: provider does not support PKCE S256")
+	}
+
+	c.discovery = &doc
+	c.discoveredAt = time.Now()
+	return c.discovery, nil
+}
+
+// AuthCodeURL returns the authorization URL for a login with the given state,
+// nonce and PKCE verifier
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	doc, err := c.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := []string{"openid"}
+	for _, scope := range c.cfg.Scopes {
+		if scope != "openid" && scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {S256Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// Exchange trades an authorization code and its PKCE verifier for tokens
+func (c *Client) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	doc, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if c.cfg.ClientSecret == "" {
+		// Public client
+		form.Set("client_id", c.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc exchange: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		// client_secret_basic; RFC 6749 2.3.1 form-encodes both parts
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc exchange: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("oidc exchange: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return nil, fmt.Errorf("oidc exchange: %s: %s", oauthErr.Error, oauthErr.Description)
+		}
+		return nil, fmt.Errorf("oidc exchange: token endpoint returned %d", resp.StatusCode)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc exchange: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("oidc exchange: no id_token in response")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken checks the signature of an ID token against the provider's
+// keys, its issuer, audience, expiry and nonce, and returns its claims
+func (c *Client) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	doc, err := c.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingAlgorithms),
+		jwt.WithIssuer(doc.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.verificationKey(ctx, doc.JWKSURI, kid, token.Method.Alg())
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// With several audiences the token must have been issued to us
+	if aud, _ := claims.GetAudience(); len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != c.cfg.ClientID {
+			return nil, fmt.Errorf("%w: azp %q is not this client", ErrInvalidIDToken, azp)
+		}
+	}
+	if got, _ := claims["nonce"].(string); nonce == "" || got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	idToken := &IDToken{Claims: claims}
+	idToken.Subject, _ = claims["sub"].(string)
+	idToken.Email, _ = claims["email"].(string)
+	idToken.Name, _ = claims["name"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		idToken.EmailVerified = v
+	case string:
+		// Some providers send the boolean as a string
+		idToken.EmailVerified = v == "true"
+	}
+	if idToken.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	return idToken, nil
+}
+
+// verificationKey finds the key an ID token was signed with, refetching the
+// JWKS once when the key ID is unknown, so provider key rotation is picked up
+func (c *Client) verificationKey(ctx context.Context, jwksURI, kid, alg string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys != nil {
+		if key, ok := c.keys.find(kid, alg); ok {
+			return key, nil
+		}
+		if time.Since(c.keys.fetchedAt) < keyRefreshInterval {
+			return nil, fmt.Errorf("no key %q for %s", kid, alg)
+		}
+	}
+
+	var set jsonWebKeySet
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys, err := set.parse()
+	if err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	c.keys = keys
+
+	if key, ok := c.keys.find(kid, alg); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("no key %q for %s", kid, alg)
+}
+
+// getJSON fetches a JSON document
+func (c *Client) getJSON(ctx context.Context, rawURL string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// GenerateVerifier returns a random PKCE code verifier
+func GenerateVerifier() (string, error) {
+	return randomString(32)
+}
+
+// GenerateState returns a random value for the state or nonce parameter
+func GenerateState() (string, error) {
+	return randomString(32)
+}
+
+// S256Challenge returns the PKCE S256 code challenge of a verifier
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ClaimStrings returns the string values of a claim, which may be a string
+// or a list. A dotted path reaches into nested objects, e.g.
+// "realm_access.roles".
+func (t *IDToken) ClaimStrings(path string) []string {
+	var value interface{} = t.Claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/oidc/oidctest"
+)
+
+const testRedirectURL = "https://cms.test/api/v1/auth/oidc/callback"
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *Client) {
+	t.Helper()
+	srv := oidctest.NewServer("cms", "secret")
+	t.Cleanup(srv.Close)
+	srv.SetClaims(map[string]interface{}{
+		"sub":            "user-1",
+		"email":          "jane@corp.test",
+		"email_verified": true,
+		"name":           "Jane Doe",
+		"groups":         []interface{}{"cms-editors", "staff"},
+		"realm_access":   map[string]interface{}{"roles": []interface{}{"cms-admin"}},
+	})
+	client := NewClient(Config{
+		IssuerURL:    srv.Issuer(),
+		ClientID:     "cms",
+		ClientSecret: "secret",
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	})
+	return srv, client
+}
+
+// authorize follows the authorization URL and returns the code and state the
+// provider redirects back with
+func authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	defer resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	if !strings.HasPrefix(location.String(), testRedirectURL) {
+		t.Fatalf("expected a redirect to the callback, got %s", location)
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+// login runs the flow up to a verified ID token
+func login(t *testing.T, client *Client, nonce string) (*IDToken, error) {
+	t.Helper()
+	ctx := context.Background()
+	verifier, _ := GenerateVerifier()
+	authURL, err := client.AuthCodeURL(ctx, "state-1", nonce, verifier)
+	if err != nil {
+		t.Fatalf("auth URL: %v", err)
+	}
+	code, _ := authorize(t, authURL)
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	return client.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+func TestClient_AuthorizationCodeFlow(t *testing.T) {
+	_, client := newTestProvider(t)
+	ctx := context.Background()
+
+	verifier, _ := GenerateVerifier()
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	params, _ := url.Parse(authURL)
+	q := params.Query()
+	if q.Get("code_challenge") != S256Challenge(verifier) || q.Get("code_challenge_method") != "S256" {
+		t.Errorf("expected a PKCE S256 challenge, got %v", q)
+	}
+	if q.Get("scope") != "openid email profile" || q.Get("redirect_uri") != testRedirectURL {
+		t.Errorf("unexpected scope or redirect: %v", q)
+	}
+
+	code, state := authorize(t, authURL)
+	if state != "state-1" {
+		t.Errorf("expected the state to come back, got %q", state)
+	}
+
+	if _, err := client.Exchange(ctx, code, "wrong-verifier"); err == nil {
+		t.Error("expected a wrong PKCE verifier to be refused")
+	}
+	code, _ = authorize(t, authURL)
+	token, err := client.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := client.Exchange(ctx, code, verifier); err == nil {
+		t.Error("expected a code to work once")
+	}
+
+	idToken, err := client.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if idToken.Subject != "user-1" || idToken.Email != "jane@corp.test" || !idToken.EmailVerified || idToken.Name != "Jane Doe" {
+		t.Errorf("unexpected claims: %+v", idToken)
+	}
+	if got := idToken.ClaimStrings("groups"); len(got) != 2 || got[0] != "cms-editors" {
+		t.Errorf("expected groups, got %v", got)
+	}
+	if got := idToken.ClaimStrings("realm_access.roles"); len(got) != 1 || got[0] != "cms-admin" {
+		t.Errorf("expected nested roles, got %v", got)
+	}
+	if got := idToken.ClaimStrings("missing.path"); got != nil {
+		t.Errorf("expected nothing for a missing claim, got %v", got)
+	}
+
+	if _, err := client.VerifyIDToken(ctx, token.IDToken, "other-nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("expected a nonce mismatch to be refused, got %v", err)
+	}
+}
+
+func TestClient_VerifyIDTokenRejectsBadTokens(t *testing.T) {
+	srv, client := newTestProvider(t)
+	ctx := context.Background()
+	if _, err := login(t, client, "n"); err != nil {
+		t.Fatalf("expected a valid login, got %v", err)
+	}
+
+	now := time.Now()
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": srv.Issuer(), "aud": "cms", "sub": "user-1", "nonce": "n",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+		}
+	}
+	cases := map[string]func(jwt.MapClaims){
+		"wrong issuer":          func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+		"wrong audience":        func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"expired":               func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"no expiry":             func(c jwt.MapClaims) { delete(c, "exp") },
+		"no subject":            func(c jwt.MapClaims) { delete(c, "sub") },
+		"azp of another client": func(c jwt.MapClaims) { c["aud"] = []string{"cms", "other"}; c["azp"] = "other" },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			claims := valid()
+			mutate(claims)
+			if _, err := client.VerifyIDToken(ctx, srv.SignIDToken(claims), "n"); !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+
+	// Algorithms that do not use the provider's keys are never accepted
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, valid()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	hmac, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString([]byte("secret"))
+	for name, token := range map[string]string{"none": unsigned, "HS256": hmac} {
+		if _, err := client.VerifyIDToken(ctx, token, "n"); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("expected %s to be refused, got %v", name, err)
+		}
+	}
+
+	if _, err := client.VerifyIDToken(ctx, srv.SignIDToken(valid()), "n"); err != nil {
+		t.Errorf("expected the untouched token to verify, got %v", err)
+	}
+}
+
+func TestClient_PicksUpRotatedKeys(t *testing.T) {
+	srv, client := newTestProvider(t)
+	if _, err := login(t, client, "n"); err != nil {
+		t.Fatalf("expected a valid login, got %v", err)
+	}
+
+	srv.RotateKey()
+	if _, err := login(t, client, "n"); err == nil {
+		t.Error("expected an unknown key right after a fetch not to trigger another one")
+	}
+
+	// Once the refresh interval passed, an unknown kid refetches the JWKS
+	client.mu.Lock()
+	client.keys.fetchedAt = time.Now().Add(-2 * keyRefreshInterval)
+	client.mu.Unlock()
+	if _, err := login(t, client, "n"); err != nil {
+		t.Errorf("expected the new key to be fetched, got %v", err)
+	}
+}
+
+func TestClient_DiscoveryIssuerMismatch(t *testing.T) {
+	// A provider must not be able to speak for another issuer
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"issuer":"https://idp.test","authorization_endpoint":"https://idp.test/a","token_endpoint":"https://idp.test/t","jwks_uri":"https://idp.test/k"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(Config{IssuerURL: srv.URL, ClientID: "cms"})
+	if _, err := client.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("expected an issuer mismatch, got %v", err)
+	}
+}
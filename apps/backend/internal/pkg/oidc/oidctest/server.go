@@ -0,0 +1,332 @@
+// Package oidctest runs a local OpenID provider for tests and development.
+// It signs in whoever asks, with the claims it was given, and checks the
+// client credentials, redirect URI and PKCE verifier like a real provider.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Server is a mock OpenID provider
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+	// RedirectURIs lists the allowed callbacks; empty allows any
+	RedirectURIs []string
+
+	mu       sync.Mutex
+	key      *rsa.PrivateKey
+	keyID    string
+	retired  []*rsa.PrivateKey
+	claims   map[string]interface{}
+	codes    map[string]*authorization
+	override map[string]interface{}
+}
+
+// authorization is an issued code waiting to be exchanged
+type authorization struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	claims      map[string]interface{}
+	expiresAt   time.Time
+}
+
+// NewServer starts a provider on a random local port. Close it when done.
+func NewServer(clientID, clientSecret string) *Server {
+	s := NewUnstartedServer(clientID, clientSecret)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a provider whose listener can be replaced before
+// Start, e.g. to serve on a fixed port
+func NewUnstartedServer(clientID, clientSecret string) *Server {
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		claims:       map[string]interface{}{},
+		codes:        make(map[string]*authorization),
+	}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/jwks", s.jwks)
+	s.Server = httptest.NewUnstartedServer(mux)
+	return s
+}
+
+// Issuer returns the issuer identifier, the server URL
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// SetClaims sets the claims of the next sign-ins, e.g. sub, email and
+// groups. iss, aud, exp, iat and nonce are added by the server.
+func (s *Server) SetClaims(claims map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = claims
+}
+
+// OverrideClaims replaces claims of the issued ID tokens after the standard
+// ones are set, to test how a client handles a bad token
+func (s *Server) OverrideClaims(claims map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.override = claims
+}
+
+// RotateKey signs new tokens with a fresh key. The previous keys stay in the
+// JWKS.
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic("oidctest: generate key: " + err.Error())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil {
+		s.retired = append(s.retired, s.key)
+	}
+	s.key = key
+	s.keyID = thumbprint(&key.PublicKey)
+}
+
+// SignIDToken signs arbitrary claims with the current key, for tests that
+// need a token the authorization flow would not produce
+func (s *Server) SignIDToken(claims jwt.MapClaims) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sign(claims)
+}
+
+func (s *Server) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.keyID
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		panic("oidctest: sign: " + err.Error())
+	}
+	return signed
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "none"},
+	})
+}
+
+// authorize approves every request and redirects back with a code
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	switch {
+	case q.Get("client_id") != s.ClientID:
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	case !s.allowedRedirect(redirectURI):
+		http.Error(w, "redirect_uri not registered", http.StatusBadRequest)
+		return
+	case q.Get("response_type") != "code",
+		q.Get("code_challenge_method") != "S256",
+		q.Get("code_challenge") == "",
+		q.Get("state") == "":
+		redirectError(w, r, redirectURI, q.Get("state"), "invalid_request")
+		return
+	}
+
+	code := randomString()
+	s.mu.Lock()
+	claims := make(map[string]interface{}, len(s.claims))
+	for k, v := range s.claims {
+		claims[k] = v
+	}
+	s.codes[code] = &authorization{
+		redirectURI: redirectURI,
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		claims:      claims,
+		expiresAt:   time.Now().Add(time.Minute),
+	}
+	s.mu.Unlock()
+
+	target, _ := url.Parse(redirectURI)
+	params := target.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// token exchanges a code once for an ID token
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, hasBasic := r.BasicAuth()
+	if hasBasic {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != s.ClientID || clientSecret != s.ClientSecret {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := r.PostForm.Get("code")
+	auth, ok := s.codes[code]
+	delete(s.codes, code)
+	if r.PostForm.Get("grant_type") != "authorization_code" || !ok || time.Now().After(auth.expiresAt) {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	if r.PostForm.Get("redirect_uri") != auth.redirectURI {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		tokenError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": s.URL,
+		"aud": s.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+	if auth.nonce != "" {
+		claims["nonce"] = auth.nonce
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+	for k, v := range s.override {
+		claims[k] = v
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     s.sign(claims),
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []map[string]string{publicJWK(&s.key.PublicKey)}
+	for _, key := range s.retired {
+		keys = append(keys, publicJWK(&key.PublicKey))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"keys": keys})
+}
+
+func (s *Server) allowedRedirect(uri string) bool {
+	if uri == "" {
+		return false
+	}
+	if len(s.RedirectURIs) == 0 {
+		return true
+	}
+	for _, allowed := range s.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+func redirectError(w http.ResponseWriter, r *http.Request, redirectURI, state, code string) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, code, http.StatusBadRequest)
+		return
+	}
+	params := target.Query()
+	params.Set("error", code)
+	params.Set("state", state)
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func publicJWK(key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": thumbprint(key),
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// thumbprint returns the RFC 7638 thumbprint of an RSA key, used as its kid
+func thumbprint(key *rsa.PublicKey) string {
+	data, _ := json.Marshal(map[string]string{
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		"kty": "RSA",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic("oidctest: random: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
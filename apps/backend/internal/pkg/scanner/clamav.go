@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamavChunkSize is the size of the chunks a file is streamed to clamd in
+const clamavChunkSize = 64 << 10
+
+// ClamAV scans files with a clamd daemon, streaming them over its socket
+// with the INSTREAM command. Files larger than clamd's StreamMaxLength are
+// refused by clamd and fail to scan.
+type ClamAV struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV creates a ClamAV scanner for a clamd listening on address,
+// host:port or unix:<path>. timeout bounds a whole scan.
+func NewClamAV(address string, timeout time.Duration) *ClamAV {
+	network := "tcp"
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		network, address = "unix", path
+	}
+	return &ClamAV{network: network, address: address, timeout: timeout}
+}
+
+// Scan implements Scanner
+func (s *ClamAV) Scan(ctx context.Context, data []byte) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return fmt.Errorf("ClamAV.Scan: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	w := bufio.NewWriterSize(conn, clamavChunkSize+4)
+	w.WriteString("zINSTREAM\x00")
+	for rest := data; len(rest) > 0; {
+		n := min(len(rest), clamavChunkSize)
+		w.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+		w.Write(rest[:n])
+		rest = rest[n:]
+	}
+	w.Write([]byte{0, 0, 0, 0})
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("ClamAV.Scan: send: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return fmt.Errorf("ClamAV.Scan: read reply: %w", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+// parseClamAVReply reads "stream: OK", "stream: <signature> FOUND" or
+// "<message> ERROR"
+func parseClamAVReply(reply string) error {
+	reply = strings.TrimRight(reply, "\x00\r\n")
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return &InfectedError{Signature: strings.TrimSuffix(result, " FOUND")}
+	}
+	return fmt.Errorf("ClamAV.Scan: clamd replied %q", reply)
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/config"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/scanner/clamdtest"
+)
+
+func TestClamAV_Scan(t *testing.T) {
+	srv := clamdtest.NewServer()
+	defer srv.Close()
+	s := NewClamAV(srv.Addr(), 5*time.Second)
+	ctx := context.Background()
+
+	// Larger than one chunk, to be streamed in several
+	clean := bytes.Repeat([]byte("clean "), 30000)
+	if err := s.Scan(ctx, clean); err != nil {
+		t.Errorf("expected a clean file to pass, got %v", err)
+	}
+
+	infected := append(append([]byte{}, clean...), clamdtest.EICAR...)
+	err := s.Scan(ctx, infected)
+	var infectedErr *InfectedError
+	if !errors.As(err, &infectedErr) || infectedErr.Signature != "Win.Test.EICAR_HDB-1" || !errors.Is(err, ErrInfected) {
+		t.Errorf("expected an InfectedError for EICAR, got %v", err)
+	}
+	if srv.Scanned() != 2 {
+		t.Errorf("expected 2 scans, got %d", srv.Scanned())
+	}
+
+	// A clamd error is a failure to scan, not a verdict
+	srv.StreamMaxLength = 1000
+	if err := s.Scan(ctx, clean); err == nil || errors.Is(err, ErrInfected) || !strings.Contains(err.Error(), "size limit exceeded") {
+		t.Errorf("expected a scan error, got %v", err)
+	}
+}
+
+func TestClamAV_Scan_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clamd.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := clamdtest.Serve(l)
+	defer srv.Close()
+
+	if err := NewClamAV("unix:"+path, time.Second).Scan(context.Background(), []byte(clamdtest.EICAR)); !errors.Is(err, ErrInfected) {
+		t.Errorf("expected ErrInfected, got %v", err)
+	}
+}
+
+func TestClamAV_Scan_Unavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	err = NewClamAV(addr, time.Second).Scan(context.Background(), []byte("data"))
+	if err == nil || errors.Is(err, ErrInfected) {
+		t.Errorf("expected a connection error, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for driver, want := range map[string]string{"": "scanner.Nop", "none": "scanner.Nop", "clamav": "*scanner.ClamAV"} {
+		s, err := New(config.ScanConfig{Driver: driver, ClamAVAddress: "127.0.0.1:3310"})
+		if err != nil {
+			t.Fatalf("%q: %v", driver, err)
+		}
+		switch s.(type) {
+		case Nop:
+			if want != "scanner.Nop" {
+				t.Errorf("%q: got Nop, want %s", driver, want)
+			}
+		case *ClamAV:
+			if want != "*scanner.ClamAV" {
+				t.Errorf("%q: got ClamAV, want %s", driver, want)
+			}
+		}
+	}
+	if _, err := New(config.ScanConfig{Driver: "virustotal"}); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+}
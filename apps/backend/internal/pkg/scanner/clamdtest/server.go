@@ -0,0 +1,161 @@
+// Package clamdtest runs an in-process stand-in for the clamd daemon, for
+// tests and local development. It answers PING and INSTREAM and reports
+// files containing one of its signatures, by default the EICAR test file.
+package clamdtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// EICAR is the standard antivirus test file
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// Server is a fake clamd
+type Server struct {
+	Listener net.Listener
+	// Signatures maps a byte string to the signature name reported for files
+	// containing it
+	Signatures map[string]string
+	// StreamMaxLength is the largest stream accepted, 25 MiB like clamd
+	StreamMaxLength int
+
+	mu      sync.Mutex
+	scanned int
+	wg      sync.WaitGroup
+}
+
+// NewServer starts a server on a local TCP port. Close it when done.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("clamdtest: listen: " + err.Error())
+	}
+	return Serve(l)
+}
+
+// Serve starts a server on l
+func Serve(l net.Listener) *Server {
+	s := &Server{
+		Listener:        l,
+		Signatures:      map[string]string{EICAR: "Win.Test.EICAR_HDB-1"},
+		StreamMaxLength: 25 << 20,
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer conn.Close()
+				s.handle(conn)
+			}()
+		}
+	}()
+	return s
+}
+
+// Addr returns the address to configure the scanner with
+func (s *Server) Addr() string {
+	return s.Listener.Addr().String()
+}
+
+// Scanned returns the number of streams scanned
+func (s *Server) Scanned() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scanned
+}
+
+// Close stops the server and waits for open connections
+func (s *Server) Close() {
+	s.Listener.Close()
+	s.wg.Wait()
+}
+
+// handle answers one command. Commands are prefixed with z and end with a
+// NUL, or with n and end with a newline; replies end the same way.
+func (s *Server) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+	delim := byte(0)
+	if prefix == 'n' {
+		delim = '\n'
+	} else if prefix != 'z' {
+		io.WriteString(conn, "UNKNOWN COMMAND\n")
+		return
+	}
+	command, err := r.ReadString(delim)
+	if err != nil {
+		return
+	}
+	reply := func(msg string) {
+		conn.Write(append([]byte(msg), delim))
+	}
+
+	switch strings.TrimSuffix(command, string(delim)) {
+	case "PING":
+		reply("PONG")
+	case "INSTREAM":
+		data, ok := s.readStream(r)
+		if !ok {
+			reply("INSTREAM size limit exceeded. ERROR")
+			return
+		}
+		s.mu.Lock()
+		s.scanned++
+		s.mu.Unlock()
+		for pattern, name := range s.Signatures {
+			if bytes.Contains(data, []byte(pattern)) {
+				reply("stream: " + name + " FOUND")
+				return
+			}
+		}
+		reply("stream: OK")
+	default:
+		reply("UNKNOWN COMMAND")
+	}
+}
+
+// readStream reads length-prefixed chunks up to a zero length. A stream over
+// StreamMaxLength is read to its end and reported as not ok.
+func (s *Server) readStream(r *bufio.Reader) ([]byte, bool) {
+	var data []byte
+	var size [4]byte
+	tooLarge := false
+	for {
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			return nil, false
+		}
+		n := int(binary.BigEndian.Uint32(size[:]))
+		if n == 0 {
+			return data, !tooLarge
+		}
+		if tooLarge || len(data)+n > s.StreamMaxLength {
+			tooLarge = true
+			data = nil
+			if _, err := r.Discard(n); err != nil {
+				return nil, false
+			}
+			continue
+		}
+		chunk := make([]byte, n)
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, false
+		}
+		data = append(data, chunk...)
+	}
+}
@@ -0,0 +1,53 @@
+// Package scanner checks uploaded files for malware before they are stored
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/config"
+)
+
+// ErrInfected is matched by every InfectedError
+var ErrInfected = errors.New("scanner: malware detected")
+
+// InfectedError names the signature a file matched
+type InfectedError struct {
+	Signature string
+}
+
+// Error implements error
+func (e *InfectedError) Error() string {
+	return ErrInfected.Error() + ": " + e.Signature
+}
+
+// Is makes errors.Is(err, ErrInfected) match
+func (e *InfectedError) Is(target error) bool {
+	return target == ErrInfected
+}
+
+// Scanner scans file contents. Scan returns an *InfectedError for malware
+// and another error when the file could not be scanned.
+type Scanner interface {
+	Scan(ctx context.Context, data []byte) error
+}
+
+// New creates the Scanner selected by cfg.Driver
+func New(cfg config.ScanConfig) (Scanner, error) {
+	switch cfg.Driver {
+	case "clamav":
+		return NewClamAV(cfg.ClamAVAddress, cfg.ClamAVTimeout), nil
+	case "none", "":
+		return Nop{}, nil
+	}
+	return nil, fmt.Errorf("scanner.New: unknown driver %q", cfg.Driver)
+}
+
+// Nop accepts every file
+type Nop struct{}
+
+// Scan implements Scanner
+func (Nop) Scan(ctx context.Context, data []byte) error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores objects as files below a directory. The content type of an
+// object is derived from the extension of its key.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocal creates a new Local storing files in dir. baseURL is where the
+// files are served, e.g. http://localhost:8080/media.
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Put writes the object to a temporary file and renames it into place, so
+// readers never see a partial file
+func (l *Local) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return fmt.Errorf("Local.Put mkdir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("Local.Put: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	written, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Local.Put write: %w", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("Local.Put: wrote %d of %d bytes", written, size)
+	}
+	if err := os.Chmod(tmp.Name(), 0o640); err != nil {
+		return fmt.Errorf("Local.Put chmod: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("Local.Put rename: %w", err)
+	}
+	return nil
+}
+
+// Get opens the file of an object. The returned reader is an *os.File, so it
+// can be seeked for range requests.
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("Local.Get: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("Local.Get stat: %w", err)
+	}
+	if !stat.Mode().IsRegular() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+	return f, l.info(key, stat), nil
+}
+
+// Delete removes the file of an object
+func (l *Local) Delete(ctx context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Local.Delete: %w", err)
+	}
+	return nil
+}
+
+// Stat returns the info of an object's file
+func (l *Local) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := os.Stat(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("Local.Stat: %w", err)
+	}
+	if !stat.Mode().IsRegular() {
+		return nil, ErrNotFound
+	}
+	return l.info(key, stat), nil
+}
+
+// PublicURL returns baseURL/key
+func (l *Local) PublicURL(key string) string {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return ""
+	}
+	return l.baseURL + "/" + escapeKey(clean)
+}
+
+// path maps a key to a file below dir
+func (l *Local) path(key string) (string, error) {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
+
+func (l *Local) info(key string, stat fs.FileInfo) *ObjectInfo {
+	clean, _ := CleanKey(key)
+	contentType := mime.TypeByExtension(path.Ext(clean))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &ObjectInfo{Key: clean, Size: stat.Size(), ContentType: contentType, ModTime: stat.ModTime()}
+}
@@ -0,0 +1,380 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultS3PartSize is the multipart part size when none is configured
+	DefaultS3PartSize = 16 << 20
+	// MinS3PartSize is the smallest part S3 accepts, except for the last one
+	MinS3PartSize = 5 << 20
+	// maxS3Parts is the most parts a multipart upload can have
+	maxS3Parts = 10000
+)
+
+// S3Config configures an S3 backend
+type S3Config struct {
+	// Endpoint is the S3 API URL, e.g. https://s3.eu-west-1.amazonaws.com,
+	// http://127.0.0.1:9000 for MinIO or https://<account>.r2.cloudflarestorage.com
+	Endpoint string
+	// Region is used in the signature; R2 expects "auto"
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key> instead of
+	// <bucket>.<endpoint host>/<key>. MinIO and most self-hosted stores need it.
+	PathStyle bool
+	// PublicURL is where the bucket is readable, e.g. a CDN. Empty uses the
+	// object URL, which needs a bucket policy allowing anonymous reads.
+	PublicURL string
+	// PartSize is the multipart part size. Bodies up to one part are sent in
+	// a single request; defaults to DefaultS3PartSize.
+	PartSize int64
+	// Client defaults to a client with a 5 minute timeout per request
+	Client *http.Client
+}
+
+// S3 stores objects in a bucket of an S3-compatible store, signing requests
+// with SigV4. Large objects are sent as multipart uploads, one part in memory
+// at a time.
+type S3 struct {
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	publicURL string
+	partSize  int64
+	signer    sigV4Signer
+	client    *http.Client
+}
+
+// NewS3 creates a new S3 backend
+func NewS3(cfg S3Config) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage.NewS3: invalid endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage.NewS3: bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.PartSize <= 0 {
+		cfg.PartSize = DefaultS3PartSize
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &S3{
+		endpoint:  endpoint,
+		bucket:    cfg.Bucket,
+		pathStyle: cfg.PathStyle,
+		publicURL: strings.TrimSuffix(cfg.PublicURL, "/"),
+		partSize:  cfg.PartSize,
+		signer: sigV4Signer{
+			accessKeyID:     cfg.AccessKeyID,
+			secretAccessKey: cfg.SecretAccessKey,
+			region:          cfg.Region,
+		},
+		client: cfg.Client,
+	}, nil
+}
+
+// Put uploads an object, overwriting an existing one. Bodies larger than a
+// part are uploaded in parts; a failed multipart upload is aborted.
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	// One byte more than a known size tells a body that is too long apart
+	buf := make([]byte, s.partSize)
+	if size >= 0 && size < s.partSize {
+		buf = make([]byte, size+1)
+	}
+	n, err := io.ReadFull(body, buf)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		if size >= 0 && int64(n) != size {
+			return fmt.Errorf("S3.Put: read %d of %d bytes", n, size)
+		}
+		return s.putObject(ctx, clean, buf[:n], contentType)
+	case err != nil:
+		return fmt.Errorf("S3.Put: %w", err)
+	case size >= 0 && size < s.partSize:
+		return fmt.Errorf("S3.Put: body is longer than %d bytes", size)
+	}
+	return s.putMultipart(ctx, clean, body, buf, size, contentType)
+}
+
+// Get downloads an object
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, clean, nil, nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("S3.Get: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("S3.Get: %w", s3Error(resp))
+	}
+	return resp.Body, objectInfo(clean, resp), nil
+}
+
+// Delete removes an object
+func (s *S3) Delete(ctx context.Context, key string) error {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, clean, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("S3.Delete: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return fmt.Errorf("S3.Delete: %w", s3Error(resp))
+}
+
+// Stat reads the object headers with a HEAD request
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, clean, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("S3.Stat: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("S3.Stat: status %d", resp.StatusCode)
+	}
+	return objectInfo(clean, resp), nil
+}
+
+// PublicURL returns the object URL under the configured public URL, or the
+// object URL of the bucket
+func (s *S3) PublicURL(key string) string {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return ""
+	}
+	if s.publicURL != "" {
+		return s.publicURL + "/" + escapeKey(clean)
+	}
+	return s.objectURL(clean, nil)
+}
+
+func (s *S3) putObject(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, data, contentTypeHeader(contentType))
+	if err != nil {
+		return fmt.Errorf("S3.Put: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("S3.Put: %w", s3Error(resp))
+	}
+	return nil
+}
+
+// putMultipart uploads first, a full part, and the rest of body in parts
+func (s *S3) putMultipart(ctx context.Context, key string, body io.Reader, first []byte, size int64, contentType string) error {
+	uploadID, err := s.createMultipartUpload(ctx, key, contentType)
+	if err != nil {
+		return fmt.Errorf("S3.Put: %w", err)
+	}
+
+	var parts []completedPart
+	var total int64
+	chunk := first
+	for number := 1; ; number++ {
+		if number > maxS3Parts {
+			return s.abortMultipartUpload(ctx, key, uploadID, fmt.Errorf("more than %d parts of %d bytes", maxS3Parts, s.partSize))
+		}
+		etag, err := s.uploadPart(ctx, key, uploadID, number, chunk)
+		if err != nil {
+			return s.abortMultipartUpload(ctx, key, uploadID, err)
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: etag})
+		total += int64(len(chunk))
+		if len(chunk) < len(first) {
+			break
+		}
+
+		n, err := io.ReadFull(body, first)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return s.abortMultipartUpload(ctx, key, uploadID, err)
+		}
+		chunk = first[:n]
+	}
+	if size >= 0 && total != size {
+		return s.abortMultipartUpload(ctx, key, uploadID, fmt.Errorf("read %d of %d bytes", total, size))
+	}
+
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		return s.abortMultipartUpload(ctx, key, uploadID, err)
+	}
+	return nil
+}
+
+func (s *S3) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, contentTypeHeader(contentType))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", s3Error(resp)
+	}
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("create multipart upload: invalid response")
+	}
+	return result.UploadID, nil
+}
+
+func (s *S3) uploadPart(ctx context.Context, key, uploadID string, number int, data []byte) (string, error) {
+	query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadID}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, data, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("part %d: %w", number, s3Error(resp))
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("part %d: no ETag in response", number)
+	}
+	return etag, nil
+}
+
+func (s *S3) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	// S3 may report a failed completion with status 200 and an error body
+	result, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if bytes.Contains(result, []byte("<Error>")) {
+		return parseS3Error(resp.StatusCode, result)
+	}
+	return nil
+}
+
+// abortMultipartUpload discards the uploaded parts, which are billed until
+// then, and returns cause. It runs even when ctx was canceled.
+func (s *S3) abortMultipartUpload(ctx context.Context, key, uploadID string, cause error) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil); err == nil {
+		resp.Body.Close()
+	}
+	return fmt.Errorf("S3.Put: %w", cause)
+}
+
+// objectURL returns the URL of a clean key with optional subresources
+func (s *S3) objectURL(key string, query url.Values) string {
+	u := *s.endpoint
+	base, rawBase := u.Path, u.EscapedPath()
+	if s.pathStyle {
+		u.Path = base + "/" + s.bucket + "/" + key
+		u.RawPath = rawBase + "/" + uriEncode(s.bucket) + "/" + uriEncodeKey(key)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = base + "/" + key
+		u.RawPath = rawBase + "/" + uriEncodeKey(key)
+	}
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// do signs and sends a request for a clean key
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key, query), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.signer.sign(req, hashHex(body), time.Now())
+	return s.client.Do(req)
+}
+
+func contentTypeHeader(contentType string) http.Header {
+	if contentType == "" {
+		return nil
+	}
+	return http.Header{"Content-Type": {contentType}}
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// s3Error reads the error document of a failed response
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return parseS3Error(resp.StatusCode, body)
+}
+
+func parseS3Error(status int, body []byte) error {
+	var doc struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &doc) != nil || doc.Code == "" {
+		return fmt.Errorf("status %d", status)
+	}
+	return fmt.Errorf("status %d: %s: %s", status, doc.Code, doc.Message)
+}
@@ -0,0 +1,394 @@
+// Package s3test runs an in-process S3-compatible object store for tests. It
+// checks SigV4 signatures and payload hashes like a real store and supports
+// the object and multipart upload calls the storage package makes, with
+// path-style and virtual-hosted addressing.
+package s3test
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Object is a stored object
+type Object struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+	// Parts is the number of parts of a multipart upload, 0 for a single PUT
+	Parts int
+}
+
+// Server is a fake S3 endpoint
+type Server struct {
+	*httptest.Server
+
+	AccessKeyID     string
+	SecretAccessKey string
+	// MinPartSize is the smallest part accepted except for the last one,
+	// 5 MiB like S3. Tests lower it to exercise multipart with small bodies.
+	MinPartSize int64
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*Object
+	uploads map[string]*upload
+}
+
+// upload is a multipart upload in progress
+type upload struct {
+	bucket      string
+	key         string
+	contentType string
+	parts       map[int][]byte
+}
+
+// NewServer starts a store with the given buckets. Close it when done.
+func NewServer(accessKeyID, secretAccessKey string, buckets ...string) *Server {
+	s := NewUnstartedServer(accessKeyID, secretAccessKey, buckets...)
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer returns a store whose listener can be replaced before
+// Start
+func NewUnstartedServer(accessKeyID, secretAccessKey string, buckets ...string) *Server {
+	s := &Server{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		MinPartSize:     5 << 20,
+		buckets:         make(map[string]map[string]*Object),
+		uploads:         make(map[string]*upload),
+	}
+	for _, bucket := range buckets {
+		s.buckets[bucket] = make(map[string]*Object)
+	}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Object returns a copy of a stored object
+func (s *Server) Object(bucket, key string) (*Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, false
+	}
+	copied := *obj
+	return &copied, true
+}
+
+// PendingUploads returns the number of multipart uploads neither completed
+// nor aborted
+func (s *Server) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if status, code, msg := s.verify(r, body); status != 0 {
+		writeError(w, status, code, msg)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucketName, key := s.route(r)
+	bucket, ok := s.buckets[bucketName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+	if key == "" {
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "bucket operations are not supported")
+		return
+	}
+
+	q := r.URL.Query()
+	_, initiate := q["uploads"]
+	uploadID := q.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPost && initiate:
+		s.createUpload(w, r, bucketName, key)
+	case r.Method == http.MethodPut && uploadID != "":
+		s.uploadPart(w, q, bucketName, key, body)
+	case r.Method == http.MethodPost && uploadID != "":
+		s.completeUpload(w, uploadID, bucketName, key, body)
+	case r.Method == http.MethodDelete && uploadID != "":
+		s.abortUpload(w, uploadID, bucketName, key)
+	case r.Method == http.MethodPut:
+		bucket[key] = &Object{Data: body, ContentType: r.Header.Get("Content-Type"), ETag: etag(body), ModTime: time.Now()}
+		w.Header().Set("ETag", bucket[key].ETag)
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		obj, ok := bucket[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Type", obj.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.Data)))
+		w.Header().Set("ETag", obj.ETag)
+		w.Header().Set("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(obj.Data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+// route splits a request into bucket and key, virtual-hosted when the host
+// starts with a bucket name
+func (s *Server) route(r *http.Request) (string, string) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for bucket := range s.buckets {
+		if strings.HasPrefix(host, bucket+".") {
+			return bucket, strings.TrimPrefix(r.URL.Path, "/")
+		}
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	return bucket, key
+}
+
+func (s *Server) createUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	id := randomID()
+	s.uploads[id] = &upload{bucket: bucket, key: key, contentType: r.Header.Get("Content-Type"), parts: make(map[int][]byte)}
+	writeXML(w, http.StatusOK, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadID string `xml:"UploadId"`
+	}{Bucket: bucket, Key: key, UploadID: id})
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, q url.Values, bucket, key string, body []byte) {
+	up, ok := s.uploads[q.Get("uploadId")]
+	if !ok || up.bucket != bucket || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return
+	}
+	number, err := strconv.Atoi(q.Get("partNumber"))
+	if err != nil || number < 1 || number > 10000 {
+		writeError(w, http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000")
+		return
+	}
+	up.parts[number] = body
+	w.Header().Set("ETag", etag(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, id, bucket, key string, body []byte) {
+	up, ok := s.uploads[id]
+	if !ok || up.bucket != bucket || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return
+	}
+	var req struct {
+		Parts []struct {
+			PartNumber int
+			ETag       string
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &req); err != nil || len(req.Parts) == 0 {
+		writeError(w, http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed")
+		return
+	}
+
+	var data []byte
+	for i, part := range req.Parts {
+		content, ok := up.parts[part.PartNumber]
+		if !ok || etag(content) != part.ETag {
+			writeError(w, http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found")
+			return
+		}
+		if i > 0 && part.PartNumber <= req.Parts[i-1].PartNumber {
+			writeError(w, http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order")
+			return
+		}
+		if i < len(req.Parts)-1 && int64(len(content)) < s.MinPartSize {
+			writeError(w, http.StatusBadRequest, "EntityTooSmall", "Your proposed upload is smaller than the minimum allowed object size")
+			return
+		}
+		data = append(data, content...)
+	}
+
+	delete(s.uploads, id)
+	obj := &Object{Data: data, ContentType: up.contentType, ETag: fmt.Sprintf(`"%s-%d"`, strings.Trim(etag(data), `"`), len(req.Parts)), ModTime: time.Now(), Parts: len(req.Parts)}
+	s.buckets[bucket][key] = obj
+	writeXML(w, http.StatusOK, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: bucket, Key: key, ETag: obj.ETag})
+}
+
+func (s *Server) abortUpload(w http.ResponseWriter, id, bucket, key string) {
+	up, ok := s.uploads[id]
+	if !ok || up.bucket != bucket || up.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload", "The specified upload does not exist")
+		return
+	}
+	delete(s.uploads, id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verify checks the SigV4 Authorization header and the payload hash. It
+// returns a zero status for a valid request.
+func (s *Server) verify(r *http.Request, body []byte) (int, string, string) {
+	const prefix = "AWS4-HMAC-SHA256 "
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, prefix) {
+		return http.StatusForbidden, "AccessDenied", "Access Denied"
+	}
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(auth, prefix), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		fields[name] = value
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[3] != "s3" || credential[4] != "aws4_request" {
+		return http.StatusBadRequest, "AuthorizationHeaderMalformed", "The authorization header is malformed"
+	}
+	if credential[0] != s.AccessKeyID {
+		return http.StatusForbidden, "InvalidAccessKeyId", "The AWS Access Key Id you provided does not exist in our records."
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || !strings.HasPrefix(amzDate, credential[1]) {
+		return http.StatusForbidden, "AccessDenied", "X-Amz-Date is missing or does not match the credential"
+	}
+	if skew := time.Since(signedAt); skew > 15*time.Minute || skew < -15*time.Minute {
+		return http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large."
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	sum := sha256.Sum256(body)
+	if payloadHash != "UNSIGNED-PAYLOAD" && payloadHash != hex.EncodeToString(sum[:]) {
+		return http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed."
+	}
+
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	var headers strings.Builder
+	hasHost := false
+	for _, name := range signed {
+		value := append([]string(nil), r.Header.Values(name)...)
+		if name == "host" {
+			value, hasHost = []string{r.Host}, true
+		}
+		for i, v := range value {
+			value[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers.WriteString(name + ":" + strings.Join(value, ",") + "\n")
+	}
+	if !hasHost || !sort.StringsAreSorted(signed) {
+		return http.StatusForbidden, "AccessDenied", "SignedHeaders must be sorted and include host"
+	}
+
+	var query []string
+	for name, values := range r.URL.Query() {
+		for _, value := range values {
+			query = append(query, encode(name)+"="+encode(value))
+		}
+	}
+	sort.Strings(query)
+
+	canonical := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		strings.Join(query, "&"),
+		headers.String(),
+		fields["SignedHeaders"],
+		payloadHash,
+	}, "\n")
+	canonicalSum := sha256.Sum256([]byte(canonical))
+	scope := strings.Join(credential[1:], "/")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := []byte("AWS4" + s.SecretAccessKey)
+	for _, part := range credential[1:] {
+		key = mac(key, part)
+	}
+	if !hmac.Equal([]byte(hex.EncodeToString(mac(key, stringToSign))), []byte(fields["Signature"])) {
+		return http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."
+	}
+	return 0, "", ""
+}
+
+// encode is the SigV4 URI encoding: everything but unreserved characters
+func encode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeXML(w, status, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: message})
+}
+
+func writeXML(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("s3test: random: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,96 @@
+// Package storage stores uploaded media files. Backends are selected by
+// STORAGE_DRIVER; keys are slash-separated paths such as "banners/<id>.png".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/config"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey is returned for keys that are empty or leave their root,
+	// e.g. "../secret"
+	ErrInvalidKey = errors.New("storage: invalid object key")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Backend stores objects by key
+type Backend interface {
+	// Put stores an object, replacing any object with the same key. size is
+	// the length of body, or -1 when unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens an object; the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// Stat returns the object info without reading the object
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// PublicURL returns the URL the object is served from
+	PublicURL(key string) string
+}
+
+// New creates the Backend selected by cfg.Driver
+func New(cfg config.StorageConfig, supabase config.SupabaseConfig) (Backend, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocal(cfg.LocalDir, cfg.LocalURL), nil
+	case "supabase", "":
+		return NewSupabase(supabase.URL, supabase.StorageBucket, supabase.ServiceKey, nil), nil
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+			PublicURL:       cfg.S3PublicURL,
+			PartSize:        cfg.S3PartSize,
+		})
+	}
+	return nil, fmt.Errorf("storage.New: unknown driver %q", cfg.Driver)
+}
+
+// CleanKey normalizes a key and rejects keys that could escape the storage
+// root. A leading slash is dropped, so "/a.png" and "a.png" are the same.
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.ContainsAny(key, "\\\x00") {
+		return "", ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	if path.Clean(key) != key {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
+
+// escapeKey escapes each segment of a clean key for use in a URL path
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	valid := map[string]string{
+		"a.png":          "a.png",
+		"/a.png":         "a.png",
+		"banners/a.png":  "banners/a.png",
+		"/banners/x/a.b": "banners/x/a.b",
+	}
+	for in, want := range valid {
+		if got, err := CleanKey(in); err != nil || got != want {
+			t.Errorf("CleanKey(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "/", "../a.png", "a/../../b", "a//b", "a/./b", "a/", `a\b`, "a\x00b"} {
+		if _, err := CleanKey(in); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CleanKey(%q): expected ErrInvalidKey, got %v", in, err)
+		}
+	}
+}
+
+func TestLocal_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocal(dir, "http://cms.test/media/")
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "/banners/hero image.png", strings.NewReader("png-data"), 8, "image/png"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "banners", "hero image.png")); err != nil {
+		t.Fatalf("expected the file below the root, got %v", err)
+	}
+
+	body, info, err := store.Get(ctx, "banners/hero image.png")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "png-data" || info.Size != 8 || info.ContentType != "image/png" || info.Key != "banners/hero image.png" {
+		t.Errorf("unexpected object: %q %+v", data, info)
+	}
+	if _, ok := body.(io.ReadSeeker); !ok {
+		t.Error("expected a seekable body for range requests")
+	}
+
+	if got := store.PublicURL("/banners/hero image.png"); got != "http://cms.test/media/banners/hero%20image.png" {
+		t.Errorf("unexpected public URL %q", got)
+	}
+
+	// Replacing keeps one file
+	if err := store.Put(ctx, "banners/hero image.png", strings.NewReader("new"), -1, "image/png"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info, err := store.Stat(ctx, "banners/hero image.png"); err != nil || info.Size != 3 {
+		t.Errorf("expected the new content, got %+v %v", info, err)
+	}
+
+	if err := store.Delete(ctx, "banners/hero image.png"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := store.Stat(ctx, "banners/hero image.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "banners/hero image.png"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+	if _, _, err := store.Get(ctx, "banners"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a directory not to be an object, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "banners"))
+	if len(entries) != 0 {
+		t.Errorf("expected no temporary files left, got %v", entries)
+	}
+}
+
+func TestLocal_RejectsEscapingKeys(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocal(filepath.Join(root, "media"), "http://cms.test/media")
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "../outside.txt", strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "outside.txt")); err == nil {
+		t.Error("expected nothing to be written outside the root")
+	}
+	if _, _, err := store.Get(ctx, "a/../../etc/passwd"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+	if err := store.Put(ctx, "short.txt", strings.NewReader("x"), 5, "text/plain"); err == nil {
+		t.Error("expected a short body to fail")
+	}
+	if _, err := store.Stat(ctx, "short.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a failed upload to leave nothing, got %v", err)
+	}
+}
+
+// fakeSupabase is a minimal Supabase Storage object API
+type fakeSupabase struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeSupabase) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer service-key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	const prefix = "/storage/v1/object/media/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[key]
+	switch r.Method {
+	case http.MethodPost:
+		if r.Header.Get("x-upsert") != "true" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"statusCode":"404","error":"not_found","message":"Object not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Write(data)
+	case http.MethodDelete:
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"statusCode":"404","error":"not_found","message":"Object not found"}`))
+			return
+		}
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestSupabase_RoundTrip(t *testing.T) {
+	fake := &fakeSupabase{objects: map[string][]byte{}, types: map[string]string{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	store := NewSupabase(srv.URL, "media", "service-key", srv.Client())
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "/hero.png", strings.NewReader("png-data"), 8, "image/png"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if string(fake.objects["hero.png"]) != "png-data" {
+		t.Fatalf("expected the object to be uploaded, got %v", fake.objects)
+	}
+
+	body, info, err := store.Get(ctx, "hero.png")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "png-data" || info.ContentType != "image/png" || info.Size != 8 {
+		t.Errorf("unexpected object: %q %+v", data, info)
+	}
+	if info, err := store.Stat(ctx, "hero.png"); err != nil || info.Size != 8 {
+		t.Errorf("expected stat to succeed, got %+v %v", info, err)
+	}
+	if got := store.PublicURL("hero.png"); got != srv.URL+"/storage/v1/object/public/media/hero.png" {
+		t.Errorf("unexpected public URL %q", got)
+	}
+
+	if err := store.Delete(ctx, "hero.png"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, _, err := store.Get(ctx, "hero.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := store.Delete(ctx, "hero.png"); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+
+	denied := NewSupabase(srv.URL, "media", "wrong-key", srv.Client())
+	if err := denied.Put(ctx, "x.png", strings.NewReader("x"), 1, "image/png"); err == nil {
+		t.Error("expected an unauthorized upload to fail")
+	}
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Supabase stores objects in a Supabase Storage bucket using the service
+// role key. The bucket must be public for PublicURL to be readable.
+type Supabase struct {
+	url        string
+	bucket     string
+	serviceKey string
+	client     *http.Client
+}
+
+// NewSupabase creates a new Supabase backend. client defaults to a client
+// with a 30s timeout.
+func NewSupabase(url, bucket, serviceKey string, client *http.Client) *Supabase {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Supabase{url: strings.TrimSuffix(url, "/"), bucket: bucket, serviceKey: serviceKey, client: client}
+}
+
+// Put uploads an object, overwriting an existing one
+func (s *Supabase) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, objectURL, body)
+	if err != nil {
+		return fmt.Errorf("Supabase.Put: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-upsert", "true")
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("Supabase.Put: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("Supabase.Put: upload failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Get downloads an object
+func (s *Supabase) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	resp, clean, err := s.request(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Supabase.Get: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		if isNotFound(resp) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("Supabase.Get: status %d", resp.StatusCode)
+	}
+	return resp.Body, objectInfo(clean, resp), nil
+}
+
+// Delete removes an object
+func (s *Supabase) Delete(ctx context.Context, key string) error {
+	resp, _, err := s.request(ctx, http.MethodDelete, key)
+	if err != nil {
+		return fmt.Errorf("Supabase.Delete: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent && !isNotFound(resp) {
+		return fmt.Errorf("Supabase.Delete: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Stat reads the object headers with a HEAD request
+func (s *Supabase) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	resp, clean, err := s.request(ctx, http.MethodHead, key)
+	if err != nil {
+		return nil, fmt.Errorf("Supabase.Stat: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if isNotFound(resp) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("Supabase.Stat: status %d", resp.StatusCode)
+	}
+	return objectInfo(clean, resp), nil
+}
+
+// PublicURL returns the public object URL of the bucket
+func (s *Supabase) PublicURL(key string) string {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/storage/v1/object/public/%s/%s", s.url, s.bucket, escapeKey(clean))
+}
+
+func (s *Supabase) objectURL(key string) (string, error) {
+	clean, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/storage/v1/object/%s/%s", s.url, s.bucket, escapeKey(clean)), nil
+}
+
+// request sends a bodyless request for an object and returns the clean key
+func (s *Supabase) request(ctx context.Context, method, key string) (*http.Response, string, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, objectURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	clean, _ := CleanKey(key)
+	return resp, clean, nil
+}
+
+func (s *Supabase) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+s.serviceKey)
+	return s.client.Do(req)
+}
+
+// isNotFound reports a missing object. Supabase answers some of these with
+// 400 and a JSON body whose statusCode is "404".
+func isNotFound(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusNotFound {
+		return true
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return strings.Contains(string(body), `"statusCode":"404"`) || strings.Contains(string(body), `"not_found"`)
+}
+
+func objectInfo(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{Key: key, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+	return info
+}
@@ -0,0 +1,101 @@
+// Package useragent extracts a readable browser, operating system and device
+// type from a User-Agent header. It recognises the common families only and
+// falls back to "Unknown"; it is meant for showing sessions to users, not for
+// feature detection.
+package useragent
+
+import "strings"
+
+// Device types
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+const unknown = "Unknown"
+
+// Info is the parsed form of a User-Agent header
+type Info struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Device  string `json:"device"`
+}
+
+// family maps a substring of the header to a name. Order matters: browsers
+// built on Chrome also send "Chrome" and "Safari".
+type family struct {
+	token string
+	name  string
+}
+
+var browsers = []family{
+	{"edg/", "Edge"},
+	{"edge/", "Edge"},
+	{"opr/", "Opera"},
+	{"opera", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"chromium/", "Chromium"},
+	{"safari/", "Safari"},
+	{"curl/", "curl"},
+	{"postmanruntime/", "Postman"},
+	{"go-http-client/", "Go HTTP client"},
+}
+
+var systems = []family{
+	{"windows", "Windows"},
+	{"iphone", "iOS"},
+	{"ipad", "iPadOS"},
+	{"android", "Android"},
+	{"cros", "ChromeOS"},
+	{"mac os x", "macOS"},
+	{"macintosh", "macOS"},
+	{"linux", "Linux"},
+}
+
+// Parse extracts browser, OS and device type from a User-Agent header
+func Parse(header string) Info {
+	ua := strings.ToLower(header)
+	if strings.TrimSpace(ua) == "" {
+		return Info{Browser: unknown, OS: unknown, Device: DeviceUnknown}
+	}
+
+	return Info{
+		Browser: match(ua, browsers),
+		OS:      match(ua, systems),
+		Device:  device(ua),
+	}
+}
+
+// match returns the name of the first family found in ua
+func match(ua string, families []family) string {
+	for _, f := range families {
+		if strings.Contains(ua, f.token) {
+			return f.name
+		}
+	}
+	return unknown
+}
+
+// device guesses the device type of ua
+func device(ua string) string {
+	switch {
+	case strings.Contains(ua, "bot") || strings.Contains(ua, "spider") || strings.Contains(ua, "crawler"):
+		return DeviceBot
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		(strings.Contains(ua, "android") && !strings.Contains(ua, "mobile")):
+		return DeviceTablet
+	case strings.Contains(ua, "mobile") || strings.Contains(ua, "iphone"):
+		return DeviceMobile
+	case strings.Contains(ua, "windows") || strings.Contains(ua, "macintosh") ||
+		strings.Contains(ua, "linux") || strings.Contains(ua, "cros"):
+		return DeviceDesktop
+	}
+	return DeviceUnknown
+}
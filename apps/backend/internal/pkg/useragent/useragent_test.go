@@ -0,0 +1,49 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		ua   string
+		want Info
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Info{"Chrome", "Windows", DeviceDesktop},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+			Info{"Edge", "Windows", DeviceDesktop},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			Info{"Safari", "macOS", DeviceDesktop},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0 Mobile/15E148 Safari/604.1",
+			Info{"Chrome", "iOS", DeviceMobile},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Info{"Chrome", "Android", DeviceTablet},
+		},
+		{
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			Info{"Firefox", "Linux", DeviceDesktop},
+		},
+		{
+			"curl/8.4.0",
+			Info{"curl", "Unknown", DeviceUnknown},
+		},
+		{
+			"",
+			Info{"Unknown", "Unknown", DeviceUnknown},
+		},
+	}
+
+	for _, tc := range cases {
+		if got := Parse(tc.ua); got != tc.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tc.ua, got, tc.want)
+		}
+	}
+}
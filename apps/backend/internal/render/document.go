@@ -0,0 +1,259 @@
+package render
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// Components holds the site's component records that sections can link to
+type Components struct {
+	Features     []*domain.Feature
+	Testimonials []*domain.Testimonial
+	PricingPlans []*domain.PricingPlan
+	FAQs         []*domain.FAQ
+}
+
+// Document is the data passed to the "page" template
+type Document struct {
+	Site       *domain.Site
+	Settings   domain.SiteSettingsMap
+	Page       *domain.Page
+	Meta       Meta
+	Sections   []*Section
+	Navigation map[string]*domain.NavigationMenu
+	HomeURL    string
+	CustomHead template.HTML
+	Lang       string
+}
+
+// LinkFunc returns the href of a navigation item
+type LinkFunc func(item *domain.NavigationItem) string
+
+// Meta holds the resolved SEO, Open Graph and Twitter meta of a page
+type Meta struct {
+	Title              string
+	Description        string
+	Keywords           string
+	CanonicalURL       string
+	Robots             string
+	FaviconURL         string
+	OGTitle            string
+	OGDescription      string
+	OGImage            string
+	OGType             string
+	OGSiteName         string
+	TwitterCard        string
+	TwitterTitle       string
+	TwitterDescription string
+	TwitterImage       string
+	TwitterSite        string
+	SchemaMarkup       domain.JSONMap
+}
+
+// Section is a visible page section with its contents keyed by content key and
+// the components linked to it
+type Section struct {
+	*domain.PageSection
+	Content      map[string]*domain.SectionContent
+	Features     []*domain.Feature
+	Testimonials []*domain.Testimonial
+	PricingPlans []*domain.PricingPlan
+	FAQs         []*domain.FAQ
+}
+
+// Text returns the value of a content key, or "" when it is missing
+func (s *Section) Text(key string) string {
+	if c, ok := s.Content[key]; ok && c.Value != nil {
+		return *c.Value
+	}
+	return ""
+}
+
+// Has reports whether a content key has a non-empty value
+func (s *Section) Has(key string) bool {
+	return strings.TrimSpace(s.Text(key)) != ""
+}
+
+// HTML returns the value of a content key as trusted markup. Only content of
+// type "html" is trusted; anything else is escaped as usual.
+func (s *Section) HTML(key string) template.HTML {
+	c, ok := s.Content[key]
+	if !ok || c.Value == nil {
+		return ""
+	}
+	if c.Type == domain.ContentTypeHTML {
+		return template.HTML(*c.Value)
+	}
+	return template.HTML(template.HTMLEscapeString(*c.Value))
+}
+
+// Contents returns the section contents in sort order
+func (s *Section) Contents() []*domain.SectionContent {
+	contents := make([]*domain.SectionContent, 0, len(s.Content))
+	for _, c := range s.Content {
+		contents = append(contents, c)
+	}
+	sort.SliceStable(contents, func(i, j int) bool {
+		if contents[i].SortOrder != contents[j].SortOrder {
+			return contents[i].SortOrder < contents[j].SortOrder
+		}
+		return contents[i].Key < contents[j].Key
+	})
+	return contents
+}
+
+// NewDocument assembles the render data for a published page
+func NewDocument(site *domain.Site, settings domain.SiteSettingsMap, page *domain.Page, components Components) *Document {
+	if settings == nil {
+		settings = domain.SiteSettingsMap{}
+	}
+
+	doc := &Document{
+		Site:     site,
+		Settings: settings,
+		Page:     page,
+		Meta:     resolveMeta(site, settings, page),
+		HomeURL:  "/",
+		Lang:     firstNonEmpty(settings["site_language"], "en"),
+	}
+	if page.CustomHead != nil {
+		// Custom head markup is entered by site editors and emitted verbatim
+		doc.CustomHead = template.HTML(*page.CustomHead)
+	}
+
+	sections := make([]*domain.PageSection, 0, len(page.Sections))
+	for _, section := range page.Sections {
+		if section.IsVisible {
+			sections = append(sections, section)
+		}
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].SortOrder < sections[j].SortOrder
+	})
+
+	for _, section := range sections {
+		view := &Section{
+			PageSection: section,
+			Content:     make(map[string]*domain.SectionContent, len(section.Contents)),
+		}
+		for _, content := range section.Contents {
+			view.Content[content.Key] = content
+		}
+
+		switch section.Type {
+		case domain.SectionTypeFeatures:
+			view.Features = linked(components.Features, section.ID, func(f *domain.Feature) *uuid.UUID { return f.SectionID })
+		case domain.SectionTypeTestimonials:
+			view.Testimonials = linked(components.Testimonials, section.ID, func(t *domain.Testimonial) *uuid.UUID { return t.SectionID })
+		case domain.SectionTypePricing:
+			view.PricingPlans = linked(components.PricingPlans, section.ID, func(p *domain.PricingPlan) *uuid.UUID { return p.SectionID })
+		case domain.SectionTypeFAQ:
+			view.FAQs = linked(components.FAQs, section.ID, func(f *domain.FAQ) *uuid.UUID { return f.SectionID })
+		}
+		doc.Sections = append(doc.Sections, view)
+	}
+
+	return doc
+}
+
+// SetNavigation adds the site's active menus, keyed by identifier. When link is
+// non-nil it replaces each item's URL, e.g. to make links relative in an export.
+func (d *Document) SetNavigation(menus []*domain.NavigationMenu, link LinkFunc) {
+	d.Navigation = make(map[string]*domain.NavigationMenu, len(menus))
+	for _, menu := range menus {
+		if !menu.IsActive {
+			continue
+		}
+		copied := *menu
+		copied.Items = copyNavigationItems(menu.Items, link)
+		d.Navigation[menu.Identifier] = &copied
+	}
+}
+
+// copyNavigationItems copies the active items of a menu tree, rewriting URLs
+// with link. The source tree is shared between renders and left untouched.
+func copyNavigationItems(items []*domain.NavigationItem, link LinkFunc) []*domain.NavigationItem {
+	var copies []*domain.NavigationItem
+	for _, item := range items {
+		if !item.IsActive {
+			continue
+		}
+		copied := *item
+		if link != nil {
+			url := link(item)
+			copied.URL = &url
+		}
+		copied.Children = copyNavigationItems(item.Children, link)
+		copies = append(copies, &copied)
+	}
+	return copies
+}
+
+// linked returns the components attached to a section. When none are attached,
+// the site-wide components (no section) are used instead.
+func linked[T any](items []T, sectionID uuid.UUID, sectionOf func(T) *uuid.UUID) []T {
+	var attached, unattached []T
+	for _, item := range items {
+		switch id := sectionOf(item); {
+		case id == nil:
+			unattached = append(unattached, item)
+		case *id == sectionID:
+			attached = append(attached, item)
+		}
+	}
+	if len(attached) > 0 {
+		return attached
+	}
+	return unattached
+}
+
+// resolveMeta applies the fallbacks from page fields to site settings
+func resolveMeta(site *domain.Site, settings domain.SiteSettingsMap, page *domain.Page) Meta {
+	meta := Meta{
+		Title:        firstNonEmpty(deref(page.SEOTitle), page.Title, settings["seo_title"], site.Name),
+		Description:  firstNonEmpty(deref(page.SEODescription), deref(page.Description), settings["seo_description"], deref(site.Description)),
+		Keywords:     firstNonEmpty(deref(page.SEOKeywords), settings["seo_keywords"]),
+		CanonicalURL: deref(page.CanonicalURL),
+		Robots:       firstNonEmpty(deref(page.RobotsMeta), "index, follow"),
+		FaviconURL:   deref(site.FaviconURL),
+		OGSiteName:   firstNonEmpty(settings["site_title"], site.Name),
+		TwitterSite:  settings["twitter_site"],
+		SchemaMarkup: page.SchemaMarkup,
+	}
+
+	meta.OGTitle = firstNonEmpty(deref(page.OGTitle), meta.Title)
+	meta.OGDescription = firstNonEmpty(deref(page.OGDescription), meta.Description)
+	meta.OGImage = deref(page.OGImage)
+	meta.OGType = firstNonEmpty(deref(page.OGType), "website")
+
+	meta.TwitterTitle = firstNonEmpty(deref(page.TwitterTitle), meta.OGTitle)
+	meta.TwitterDescription = firstNonEmpty(deref(page.TwitterDescription), meta.OGDescription)
+	meta.TwitterImage = firstNonEmpty(deref(page.TwitterImage), meta.OGImage)
+	defaultCard := "summary"
+	if meta.TwitterImage != "" {
+		defaultCard = "summary_large_image"
+	}
+	meta.TwitterCard = firstNonEmpty(deref(page.TwitterCard), settings["twitter_card"], defaultCard)
+
+	return meta
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// funcMap returns the helpers available to all templates
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		// Replaced per template set, see sectionRenderer
+		"renderSection": func(*Section) (template.HTML, error) { return "", nil },
+		"deref":         deref,
+		"price":         formatPrice,
+		"items":         jsonArrayItems,
+		"stars":         stars,
+	}
+}
+
+// formatPrice formats an amount with its currency, dropping zero cents
+func formatPrice(amount *float64, currency string) string {
+	if amount == nil {
+		return ""
+	}
+	symbols := map[string]string{"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "IDR": "Rp"}
+	value := fmt.Sprintf("%.2f", *amount)
+	value = strings.TrimSuffix(value, ".00")
+	if symbol, ok := symbols[strings.ToUpper(currency)]; ok {
+		return symbol + value
+	}
+	return strings.TrimSpace(value + " " + strings.ToUpper(currency))
+}
+
+// jsonArrayItems returns the display text of a JSON array of strings or
+// objects with a "text", "name" or "label" field
+func jsonArrayItems(arr domain.JSONArray) []string {
+	items := make([]string, 0, len(arr))
+	for _, v := range arr {
+		switch item := v.(type) {
+		case string:
+			items = append(items, item)
+		case map[string]interface{}:
+			for _, key := range []string{"text", "name", "label"} {
+				if s, ok := item[key].(string); ok {
+					items = append(items, s)
+					break
+				}
+			}
+		}
+	}
+	return items
+}
+
+// stars returns a five character star rating
+func stars(rating *int) string {
+	if rating == nil {
+		return ""
+	}
+	n := min(max(*rating, 0), 5)
+	return strings.Repeat("★", n) + strings.Repeat("☆", 5-n)
+}
@@ -0,0 +1,164 @@
+// Package render turns published pages into HTML documents using html/template.
+//
+// The built-in templates are embedded in the binary. Sites can override any of
+// them by placing files in an overrides directory:
+//
+//	<dir>/<site-slug>/*.html             overrides for every page of the site
+//	<dir>/<site-slug>/<template>/*.html  overrides for pages whose Template is <template>
+//
+// Override files redefine named templates ({{define "section-hero"}}...{{end}}).
+// The document layout is "page"; each section is rendered with
+// "section-<type>", falling back to "section-default".
+package render
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//go:embed templates/*.html
+var builtinTemplates embed.FS
+
+// templateNamePattern restricts site slugs and page templates used as directory names
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Renderer renders documents with the built-in templates and per-site overrides
+type Renderer struct {
+	base         *template.Template
+	overridesDir string
+
+	mu   sync.RWMutex
+	sets map[string]*template.Template
+}
+
+// New creates a Renderer. overridesDir may be empty to disable overrides.
+func New(overridesDir string) (*Renderer, error) {
+	base, err := template.New("render").Funcs(funcMap()).ParseFS(builtinTemplates, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("render.New: %w", err)
+	}
+	return &Renderer{
+		base:         base,
+		overridesDir: overridesDir,
+		sets:         make(map[string]*template.Template),
+	}, nil
+}
+
+// Render writes the HTML document for doc to w
+func (r *Renderer) Render(w io.Writer, doc *Document) error {
+	set, err := r.templateSet(doc.Site.Slug, templateName(doc.Page.Template))
+	if err != nil {
+		return fmt.Errorf("render.Render: %w", err)
+	}
+	if err := set.ExecuteTemplate(w, "page", doc); err != nil {
+		return fmt.Errorf("render.Render: %w", err)
+	}
+	return nil
+}
+
+// Reload drops parsed override sets so template changes on disk are picked up
+func (r *Renderer) Reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sets = make(map[string]*template.Template)
+}
+
+// templateSet returns the parsed templates for a site and page template
+func (r *Renderer) templateSet(siteSlug, pageTemplate string) (*template.Template, error) {
+	key := siteSlug + "/" + pageTemplate
+
+	r.mu.RLock()
+	set, ok := r.sets[key]
+	r.mu.RUnlock()
+	if ok {
+		return set, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if set, ok := r.sets[key]; ok {
+		return set, nil
+	}
+
+	set, err := r.base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range r.overrideDirs(siteSlug, pageTemplate) {
+		if set, err = parseDir(set, dir); err != nil {
+			return nil, err
+		}
+	}
+	set.Funcs(template.FuncMap{"renderSection": sectionRenderer(set)})
+
+	r.sets[key] = set
+	return set, nil
+}
+
+// overrideDirs lists the override directories for a site and page template,
+// least specific first
+func (r *Renderer) overrideDirs(siteSlug, pageTemplate string) []string {
+	if r.overridesDir == "" || !templateNamePattern.MatchString(siteSlug) {
+		return nil
+	}
+	dirs := []string{filepath.Join(r.overridesDir, siteSlug)}
+	if pageTemplate != "" {
+		dirs = append(dirs, filepath.Join(r.overridesDir, siteSlug, pageTemplate))
+	}
+	return dirs
+}
+
+// parseDir parses the *.html files of dir into set; a missing dir is not an error
+func parseDir(set *template.Template, dir string) (*template.Template, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return set, nil
+	}
+	return set.ParseFiles(files...)
+}
+
+// sectionRenderer executes the template for a section's type. Unknown and
+// custom types use "section-default".
+func sectionRenderer(set *template.Template) func(*Section) (template.HTML, error) {
+	return func(s *Section) (template.HTML, error) {
+		name := "section-" + string(s.Type)
+		if set.Lookup(name) == nil {
+			name = "section-default"
+		}
+		var buf bytes.Buffer
+		if err := set.ExecuteTemplate(&buf, name, s); err != nil {
+			return "", err
+		}
+		// The output was produced by html/template and is already escaped
+		return template.HTML(buf.String()), nil
+	}
+}
+
+// templateName returns the page template to look up, or "" for the default
+func templateName(t *string) string {
+	if t == nil {
+		return ""
+	}
+	name := strings.ToLower(strings.TrimSpace(*t))
+	if name == "default" || !templateNamePattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SitemapMaxURLs is the most URLs a single sitemap may list. Larger sites are
+// split into several sitemaps referenced from a sitemap index.
+const SitemapMaxURLs = 50000
+
+// SitemapURL is one <url> entry of a sitemap
+type SitemapURL struct {
+	Loc     string
+	LastMod *time.Time
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name       `xml:"urlset"`
+	Xmlns   string         `xml:"xmlns,attr"`
+	URLs    []sitemapEntry `xml:"url"`
+}
+
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name       `xml:"sitemapindex"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// Sitemap builds a sitemap.xml document. Entries are sorted by location so the
+// output only changes when the content does.
+func Sitemap(urls []SitemapURL) ([]byte, error) {
+	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, u := range urls {
+		entry := sitemapEntry{Loc: u.Loc}
+		if u.LastMod != nil {
+			entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	sort.Slice(set.URLs, func(i, j int) bool { return set.URLs[i].Loc < set.URLs[j].Loc })
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("render.Sitemap: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// SitemapIndex builds a sitemap index pointing at the given sitemaps, in order
+func SitemapIndex(sitemaps []SitemapURL) ([]byte, error) {
+	index := sitemapIndex{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, u := range sitemaps {
+		entry := sitemapEntry{Loc: u.Loc}
+		if u.LastMod != nil {
+			entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		index.Sitemaps = append(index.Sitemaps, entry)
+	}
+
+	out, err := xml.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("render.SitemapIndex: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// RobotsRules describes a robots.txt
+type RobotsRules struct {
+	// Disallow lists the paths closed to all user agents
+	Disallow []string
+	// SitemapURL is the absolute URL of the sitemap, if any
+	SitemapURL string
+	// Extra is appended verbatim, e.g. groups for specific user agents
+	Extra string
+}
+
+// Robots builds a robots.txt that allows crawling except for the disallowed
+// paths and points crawlers at the sitemap
+func Robots(rules RobotsRules) []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(rules.Disallow) == 0 {
+		b.WriteString("Allow: /\n")
+	}
+	sorted := append([]string(nil), rules.Disallow...)
+	sort.Strings(sorted)
+	for _, path := range sorted {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	if extra := strings.TrimSpace(rules.Extra); extra != "" {
+		b.WriteString("\n" + extra + "\n")
+	}
+	if rules.SitemapURL != "" {
+		b.WriteString("\nSitemap: " + rules.SitemapURL + "\n")
+	}
+	return []byte(b.String())
+}
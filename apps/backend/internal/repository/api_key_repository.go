@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// APIKeyRepository defines the interface for API key data access
+type APIKeyRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+	FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	FindAll(ctx context.Context, filter domain.APIKeyFilter) ([]*domain.APIKey, error)
+	Create(ctx context.Context, key *domain.APIKey) error
+	// Revoke marks a key as revoked. It returns ErrNotFound when the key does
+	// not exist or is already revoked.
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// TouchLastUsed records a use of the key, at most once a minute
+	TouchLastUsed(ctx context.Context, id uuid.UUID, ip string) error
+}
+
+// apiKeyRepository implements APIKeyRepository
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAPIKeyRepository creates a new apiKeyRepository
+func NewAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+const apiKeyColumns = `
+	id, user_id, site_id, name, key_prefix, key_hash, scopes, expires_at,
+	last_used_at, last_used_ip, revoked_at, created_by, created_at
+`
+
+// FindByID retrieves an API key by ID
+func (r *apiKeyRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	var key domain.APIKey
+	if err := r.db.GetContext(ctx, &key, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("apiKeyRepository.FindByID: %w", err)
+	}
+	return &key, nil
+}
+
+// FindByHash retrieves an API key by the hash of its secret
+func (r *apiKeyRepository) FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	var key domain.APIKey
+	if err := r.db.GetContext(ctx, &key, query, keyHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("apiKeyRepository.FindByHash: %w", err)
+	}
+	return &key, nil
+}
+
+// FindAll retrieves API keys, newest first, optionally filtered by owner or site
+func (r *apiKeyRepository) FindAll(ctx context.Context, filter domain.APIKeyFilter) ([]*domain.APIKey, error) {
+	args := []interface{}{}
+	argIdx := 1
+	where := "WHERE 1=1"
+
+	if filter.UserID != nil {
+		where += fmt.Sprintf(" AND user_id = $%d", argIdx)
+		args = append(args, *filter.UserID)
+		argIdx++
+	}
+	if filter.SiteID != nil {
+		where += fmt.Sprintf(" AND site_id = $%d", argIdx)
+		args = append(args, *filter.SiteID)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM api_keys %s ORDER BY created_at DESC`, apiKeyColumns, where)
+	var keys []*domain.APIKey
+	if err := r.db.SelectContext(ctx, &keys, query, args...); err != nil {
+		return nil, fmt.Errorf("apiKeyRepository.FindAll: %w", err)
+	}
+	return keys, nil
+}
+
+// Create inserts a new API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, site_id, name, key_prefix, key_hash, scopes, expires_at, created_by)
+		VALUES (:id, :user_id, :site_id, :name, :key_prefix, :key_hash, :scopes, :expires_at, :created_by)
+		RETURNING created_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, key)
+	if err != nil {
+		return fmt.Errorf("apiKeyRepository.Create: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&key.CreatedAt); err != nil {
+			return fmt.Errorf("apiKeyRepository.Create scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// Revoke sets revoked_at on a key that is still active
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("apiKeyRepository.Revoke: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed updates last_used_at and last_used_ip. Busy keys are written
+// at most once a minute.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, ip string) error {
+	query := `
+		UPDATE api_keys SET last_used_at = NOW(), last_used_ip = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`
+	if _, err := r.db.ExecContext(ctx, query, id, ip); err != nil {
+		return fmt.Errorf("apiKeyRepository.TouchLastUsed: %w", err)
+	}
+	return nil
+}
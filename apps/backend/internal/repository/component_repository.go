@@ -59,6 +59,12 @@ type ComponentRepository interface {
 	CreateMedia(ctx context.Context, media *domain.Media) error
 	UpdateMedia(ctx context.Context, media *domain.Media) error
 	DeleteMedia(ctx context.Context, id uuid.UUID) error
+	// CountMediaByFilePath counts the media rows, of any site, that still
+	// use a stored file. Cloned sites share the files of their source.
+	CountMediaByFilePath(ctx context.Context, filePath string) (int, error)
+	// FindMediaByPublicURLs returns the media of a site served at any of the
+	// URLs
+	FindMediaByPublicURLs(ctx context.Context, siteID uuid.UUID, urls []string) ([]*domain.Media, error)
 
 	// Audit Logs
 	FindAuditLogs(ctx context.Context, filter domain.AuditLogFilter) ([]*domain.AuditLog, int, error)
@@ -87,6 +93,11 @@ func (r *componentRepository) FindFeaturesByFilter(ctx context.Context, filter d
 		args = append(args, *filter.SiteID)
 		argIdx++
 	}
+	if filter.SiteIDs != nil {
+		where += fmt.Sprintf(" AND site_id = ANY($%d::uuid[])", argIdx)
+		args = append(args, uuidStrings(filter.SiteIDs))
+		argIdx++
+	}
 	if filter.SectionID != nil {
 		where += fmt.Sprintf(" AND section_id = $%d", argIdx)
 		args = append(args, *filter.SectionID)
@@ -188,6 +199,11 @@ func (r *componentRepository) FindTestimonialsByFilter(ctx context.Context, filt
 		args = append(args, *filter.SiteID)
 		argIdx++
 	}
+	if filter.SiteIDs != nil {
+		where += fmt.Sprintf(" AND site_id = ANY($%d::uuid[])", argIdx)
+		args = append(args, uuidStrings(filter.SiteIDs))
+		argIdx++
+	}
 	if filter.IsActive != nil {
 		where += fmt.Sprintf(" AND is_active = $%d", argIdx)
 		args = append(args, *filter.IsActive)
@@ -281,6 +297,11 @@ func (r *componentRepository) FindPricingPlansByFilter(ctx context.Context, filt
 		args = append(args, *filter.SiteID)
 		argIdx++
 	}
+	if filter.SiteIDs != nil {
+		where += fmt.Sprintf(" AND site_id = ANY($%d::uuid[])", argIdx)
+		args = append(args, uuidStrings(filter.SiteIDs))
+		argIdx++
+	}
 
 	var total int
 	r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT COUNT(*) FROM pricing_plans %s", where), args...)
@@ -372,6 +393,11 @@ func (r *componentRepository) FindFAQsByFilter(ctx context.Context, filter domai
 		args = append(args, *filter.SiteID)
 		argIdx++
 	}
+	if filter.SiteIDs != nil {
+		where += fmt.Sprintf(" AND site_id = ANY($%d::uuid[])", argIdx)
+		args = append(args, uuidStrings(filter.SiteIDs))
+		argIdx++
+	}
 
 	var total int
 	r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT COUNT(*) FROM faqs %s", where), args...)
@@ -644,6 +670,26 @@ func (r *componentRepository) DeleteMedia(ctx context.Context, id uuid.UUID) err
 	return nil
 }
 
+func (r *componentRepository) CountMediaByFilePath(ctx context.Context, filePath string) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM media WHERE file_path = $1 AND deleted_at IS NULL`
+	if err := r.db.GetContext(ctx, &count, query, filePath); err != nil {
+		return 0, fmt.Errorf("componentRepository.CountMediaByFilePath: %w", err)
+	}
+	return count, nil
+}
+
+func (r *componentRepository) FindMediaByPublicURLs(ctx context.Context, siteID uuid.UUID, urls []string) ([]*domain.Media, error) {
+	query := `SELECT id, site_id, name, original_name, file_path, public_url, thumbnail_url, type, mime_type,
+		file_size, width, height, duration, alt_text, caption, tags, folder, is_used, metadata, uploaded_by, created_at, updated_at
+		FROM media WHERE site_id = $1 AND public_url = ANY($2::text[]) AND deleted_at IS NULL`
+	var media []*domain.Media
+	if err := r.db.SelectContext(ctx, &media, query, siteID, urls); err != nil {
+		return nil, fmt.Errorf("componentRepository.FindMediaByPublicURLs: %w", err)
+	}
+	return media, nil
+}
+
 // ─── Audit Logs ───────────────────────────────────────────────────────────────
 
 func (r *componentRepository) FindAuditLogs(ctx context.Context, filter domain.AuditLogFilter) ([]*domain.AuditLog, int, error) {
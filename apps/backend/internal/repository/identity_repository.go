@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// IdentityRepository defines the interface for single sign-on data access
+type IdentityRepository interface {
+	FindIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error)
+	// CreateIdentity links an account at a provider to a user. It returns
+	// ErrAlreadyExists when the account is already linked.
+	CreateIdentity(ctx context.Context, identity *domain.UserIdentity) error
+	// TouchIdentity records a login and the email the provider sent with it
+	TouchIdentity(ctx context.Context, id uuid.UUID, email *string) error
+	// CreateLoginRequest stores a started login and deletes expired ones
+	CreateLoginRequest(ctx context.Context, request *domain.OIDCLoginRequest) error
+	// ConsumeLoginRequest deletes and returns the unexpired login with the
+	// state hash. It returns ErrNotFound otherwise, so a state works once.
+	ConsumeLoginRequest(ctx context.Context, stateHash string) (*domain.OIDCLoginRequest, error)
+}
+
+// identityRepository implements IdentityRepository
+type identityRepository struct {
+	db *sqlx.DB
+}
+
+// NewIdentityRepository creates a new identityRepository
+func NewIdentityRepository(db *sqlx.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+// FindIdentity retrieves the identity of an account at a provider
+func (r *identityRepository) FindIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, issuer, subject, email, created_at, last_login_at
+		FROM user_identities
+		WHERE issuer = $1 AND subject = $2
+	`
+	var identity domain.UserIdentity
+	if err := r.db.GetContext(ctx, &identity, query, issuer, subject); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("identityRepository.FindIdentity: %w", err)
+	}
+	return &identity, nil
+}
+
+// CreateIdentity inserts a new identity
+func (r *identityRepository) CreateIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (id, user_id, issuer, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (issuer, subject) DO NOTHING
+		RETURNING created_at, last_login_at
+	`
+	row := r.db.QueryRowxContext(ctx, query, identity.ID, identity.UserID, identity.Issuer, identity.Subject, identity.Email)
+	if err := row.Scan(&identity.CreatedAt, &identity.LastLoginAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("identityRepository.CreateIdentity: %w", err)
+	}
+	return nil
+}
+
+// TouchIdentity sets last_login_at and the email of an identity
+func (r *identityRepository) TouchIdentity(ctx context.Context, id uuid.UUID, email *string) error {
+	query := `UPDATE user_identities SET last_login_at = NOW(), email = COALESCE($2, email) WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, id, email); err != nil {
+		return fmt.Errorf("identityRepository.TouchIdentity: %w", err)
+	}
+	return nil
+}
+
+// CreateLoginRequest inserts a login request. Abandoned logins are cleaned
+// up here rather than by a janitor since every login creates one.
+func (r *identityRepository) CreateLoginRequest(ctx context.Context, request *domain.OIDCLoginRequest) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oidc_login_requests WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("identityRepository.CreateLoginRequest cleanup: %w", err)
+	}
+
+	query := `
+		INSERT INTO oidc_login_requests (id, state_hash, nonce, code_verifier, expires_at)
+		VALUES (:id, :state_hash, :nonce, :code_verifier, :expires_at)
+		RETURNING created_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, request)
+	if err != nil {
+		return fmt.Errorf("identityRepository.CreateLoginRequest: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&request.CreatedAt); err != nil {
+			return fmt.Errorf("identityRepository.CreateLoginRequest scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// ConsumeLoginRequest deletes a login request and returns it
+func (r *identityRepository) ConsumeLoginRequest(ctx context.Context, stateHash string) (*domain.OIDCLoginRequest, error) {
+	query := `
+		DELETE FROM oidc_login_requests
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING id, state_hash, nonce, code_verifier, expires_at, created_at
+	`
+	var request domain.OIDCLoginRequest
+	if err := r.db.GetContext(ctx, &request, query, stateHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("identityRepository.ConsumeLoginRequest: %w", err)
+	}
+	return &request, nil
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// InvitationRepository defines the interface for user invitation data access
+type InvitationRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Invitation, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Invitation, error)
+	// FindPending lists invitations that were neither accepted nor revoked,
+	// expired ones included, newest first
+	FindPending(ctx context.Context) ([]*domain.Invitation, error)
+	Create(ctx context.Context, invitation *domain.Invitation) error
+	// Renew replaces the token of a pending invitation and counts the send.
+	// It returns ErrNotFound when the invitation is no longer pending.
+	Renew(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) error
+	// Accept marks a valid invitation as accepted and activates its pending
+	// user with passwordHash, in one transaction. It returns ErrNotFound when
+	// the invitation was already accepted or revoked or has expired, or the
+	// user is no longer pending, so a token works once.
+	Accept(ctx context.Context, id uuid.UUID, passwordHash string) error
+	// Revoke marks a pending invitation as revoked. It returns ErrNotFound
+	// when the invitation is no longer pending.
+	Revoke(ctx context.Context, id uuid.UUID) error
+	// RevokeByUser revokes every pending invitation of a user
+	RevokeByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// invitationRepository implements InvitationRepository
+type invitationRepository struct {
+	db *sqlx.DB
+}
+
+// NewInvitationRepository creates a new invitationRepository
+func NewInvitationRepository(db *sqlx.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+// invitationSelect joins the invited user so listings show who was invited
+const invitationSelect = `
+	SELECT i.id, i.user_id, u.email, u.full_name, u.role, i.token_hash, i.expires_at,
+	       i.sent_at, i.send_count, i.accepted_at, i.revoked_at, i.invited_by, i.created_at
+	FROM user_invitations i
+	JOIN users u ON u.id = i.user_id AND u.deleted_at IS NULL
+`
+
+// FindByID retrieves an invitation by ID
+func (r *invitationRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+	query := invitationSelect + ` WHERE i.id = $1`
+	var invitation domain.Invitation
+	if err := r.db.GetContext(ctx, &invitation, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("invitationRepository.FindByID: %w", err)
+	}
+	return &invitation, nil
+}
+
+// FindByTokenHash retrieves an invitation by the hash of its token
+func (r *invitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	query := invitationSelect + ` WHERE i.token_hash = $1`
+	var invitation domain.Invitation
+	if err := r.db.GetContext(ctx, &invitation, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("invitationRepository.FindByTokenHash: %w", err)
+	}
+	return &invitation, nil
+}
+
+// FindPending retrieves the invitations still waiting for an answer
+func (r *invitationRepository) FindPending(ctx context.Context) ([]*domain.Invitation, error) {
+	query := invitationSelect + `
+		WHERE i.accepted_at IS NULL AND i.revoked_at IS NULL
+		ORDER BY i.created_at DESC
+	`
+	var invitations []*domain.Invitation
+	if err := r.db.SelectContext(ctx, &invitations, query); err != nil {
+		return nil, fmt.Errorf("invitationRepository.FindPending: %w", err)
+	}
+	return invitations, nil
+}
+
+// Create inserts a new invitation
+func (r *invitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	query := `
+		INSERT INTO user_invitations (id, user_id, token_hash, expires_at, invited_by)
+		VALUES (:id, :user_id, :token_hash, :expires_at, :invited_by)
+		RETURNING sent_at, send_count, created_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, invitation)
+	if err != nil {
+		return fmt.Errorf("invitationRepository.Create: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&invitation.SentAt, &invitation.SendCount, &invitation.CreatedAt); err != nil {
+			return fmt.Errorf("invitationRepository.Create scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// Renew sets a new token and expiry on a pending invitation
+func (r *invitationRepository) Renew(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `
+		UPDATE user_invitations
+		SET token_hash = $2, expires_at = $3, sent_at = NOW(), send_count = send_count + 1
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, id, tokenHash, expiresAt)
+	if err != nil {
+		return fmt.Errorf("invitationRepository.Renew: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Accept sets accepted_at on a valid invitation and activates its user with
+// the chosen password in one transaction
+func (r *invitationRepository) Accept(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("invitationRepository.Accept begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID uuid.UUID
+	query := `
+		UPDATE user_invitations SET accepted_at = NOW()
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	if err := tx.GetContext(ctx, &userID, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		return fmt.Errorf("invitationRepository.Accept: %w", err)
+	}
+
+	// An admin may have deactivated the user in the meantime
+	query = `
+		UPDATE users
+		SET password_hash = $2, status = 'active', email_verified = true,
+		    failed_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND deleted_at IS NULL
+	`
+	result, err := tx.ExecContext(ctx, query, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("invitationRepository.Accept activate user: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("invitationRepository.Accept commit: %w", err)
+	}
+	return nil
+}
+
+// Revoke sets revoked_at on a pending invitation
+func (r *invitationRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE user_invitations SET revoked_at = NOW() WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("invitationRepository.Revoke: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeByUser sets revoked_at on the pending invitations of a user
+func (r *invitationRepository) RevokeByUser(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE user_invitations SET revoked_at = NOW() WHERE user_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("invitationRepository.RevokeByUser: %w", err)
+	}
+	return nil
+}
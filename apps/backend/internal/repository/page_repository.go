@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -22,6 +23,20 @@ type PageRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	SetHomepage(ctx context.Context, siteID, pageID uuid.UUID) error
 
+	// Published snapshot operations
+	FindPublishedBySlug(ctx context.Context, siteID uuid.UUID, slug string) (*domain.Page, error)
+	FindPublishedHomepage(ctx context.Context, siteID uuid.UUID) (*domain.Page, error)
+	FindSitemapPages(ctx context.Context, siteID uuid.UUID) ([]*domain.Page, error)
+	FindPublishedPages(ctx context.Context, siteID uuid.UUID) ([]*domain.Page, error)
+	Publish(ctx context.Context, page *domain.Page) error
+
+	// Schedule operations
+	UpdateSchedule(ctx context.Context, id uuid.UUID, publishAt, unpublishAt *time.Time) error
+	ClaimDuePublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error)
+	ClaimDueUnpublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error)
+	CompleteSchedule(ctx context.Context, change *domain.ScheduledPageChange) error
+	ReleaseSchedule(ctx context.Context, change *domain.ScheduledPageChange) error
+
 	// Section operations
 	FindSectionsByPageID(ctx context.Context, pageID uuid.UUID) ([]*domain.PageSection, error)
 	FindSectionByID(ctx context.Context, id uuid.UUID) (*domain.PageSection, error)
@@ -36,8 +51,42 @@ type PageRepository interface {
 	UpsertContent(ctx context.Context, content *domain.SectionContent) error
 	DeleteContent(ctx context.Context, id uuid.UUID) error
 	DeleteContentByKey(ctx context.Context, sectionID uuid.UUID, key string) error
+	FindContentByID(ctx context.Context, id uuid.UUID) (*domain.SectionContent, error)
+
+	// Tree operations
+	ReplaceTree(ctx context.Context, page *domain.Page) error
+	// CreateTree inserts a page with its sections, contents and the components
+	// attached to those sections in one transaction
+	CreateTree(ctx context.Context, page *domain.Page, components *domain.ComponentSet) error
 }
 
+// upsertContentQuery inserts a content item or updates it by (section_id, key)
+const upsertContentQuery = `
+		INSERT INTO section_contents (
+			id, section_id, key, value, value_json, type, label, description, placeholder,
+			is_required, sort_order, alt_text, width, height, link_url, link_target, metadata
+		) VALUES (
+			:id, :section_id, :key, :value, :value_json, :type, :label, :description, :placeholder,
+			:is_required, :sort_order, :alt_text, :width, :height, :link_url, :link_target, :metadata
+		)
+		ON CONFLICT (section_id, key) DO UPDATE SET
+			value = EXCLUDED.value,
+			value_json = EXCLUDED.value_json,
+			type = EXCLUDED.type,
+			label = EXCLUDED.label,
+			description = EXCLUDED.description,
+			placeholder = EXCLUDED.placeholder,
+			is_required = EXCLUDED.is_required,
+			sort_order = EXCLUDED.sort_order,
+			alt_text = EXCLUDED.alt_text,
+			width = EXCLUDED.width,
+			height = EXCLUDED.height,
+			link_url = EXCLUDED.link_url,
+			link_target = EXCLUDED.link_target,
+			metadata = EXCLUDED.metadata,
+			updated_at = NOW()
+`
+
 // pageRepository implements PageRepository
 type pageRepository struct {
 	db *sqlx.DB
@@ -56,7 +105,7 @@ func (r *pageRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Pa
 		       og_title, og_description, og_image, og_type,
 		       twitter_title, twitter_description, twitter_image, twitter_card,
 		       schema_markup, custom_head, canonical_url, robots_meta, template,
-		       sort_order, published_at, metadata, created_by, updated_by, created_at, updated_at
+		       sort_order, published_at, publish_at, unpublish_at, metadata, created_by, updated_by, created_at, updated_at
 		FROM pages
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -78,7 +127,7 @@ func (r *pageRepository) FindBySlug(ctx context.Context, siteID uuid.UUID, slug
 		       og_title, og_description, og_image, og_type,
 		       twitter_title, twitter_description, twitter_image, twitter_card,
 		       schema_markup, custom_head, canonical_url, robots_meta, template,
-		       sort_order, published_at, metadata, created_by, updated_by, created_at, updated_at
+		       sort_order, published_at, publish_at, unpublish_at, metadata, created_by, updated_by, created_at, updated_at
 		FROM pages
 		WHERE site_id = $1 AND slug = $2 AND deleted_at IS NULL
 	`
@@ -100,7 +149,7 @@ func (r *pageRepository) FindHomepage(ctx context.Context, siteID uuid.UUID) (*d
 		       og_title, og_description, og_image, og_type,
 		       twitter_title, twitter_description, twitter_image, twitter_card,
 		       schema_markup, custom_head, canonical_url, robots_meta, template,
-		       sort_order, published_at, metadata, created_by, updated_by, created_at, updated_at
+		       sort_order, published_at, publish_at, unpublish_at, metadata, created_by, updated_by, created_at, updated_at
 		FROM pages
 		WHERE site_id = $1 AND is_homepage = true AND status = 'published' AND deleted_at IS NULL
 		LIMIT 1
@@ -126,6 +175,11 @@ func (r *pageRepository) FindAll(ctx context.Context, filter domain.PageFilter)
 		args = append(args, *filter.SiteID)
 		argIdx++
 	}
+	if filter.SiteIDs != nil {
+		where += fmt.Sprintf(" AND site_id = ANY($%d::uuid[])", argIdx)
+		args = append(args, uuidStrings(filter.SiteIDs))
+		argIdx++
+	}
 	if filter.Status != nil {
 		where += fmt.Sprintf(" AND status = $%d", argIdx)
 		args = append(args, *filter.Status)
@@ -158,7 +212,7 @@ func (r *pageRepository) FindAll(ctx context.Context, filter domain.PageFilter)
 		       og_title, og_description, og_image, og_type,
 		       twitter_title, twitter_description, twitter_image, twitter_card,
 		       schema_markup, custom_head, canonical_url, robots_meta, template,
-		       sort_order, published_at, metadata, created_by, updated_by, created_at, updated_at
+		       sort_order, published_at, publish_at, unpublish_at, metadata, created_by, updated_by, created_at, updated_at
 		FROM pages %s
 		ORDER BY sort_order ASC, created_at DESC
 		LIMIT $%d OFFSET $%d
@@ -272,13 +326,235 @@ func (r *pageRepository) SetHomepage(ctx context.Context, siteID, pageID uuid.UU
 	return tx.Commit()
 }
 
+// FindPublishedBySlug retrieves the published snapshot of a page by its published slug
+func (r *pageRepository) FindPublishedBySlug(ctx context.Context, siteID uuid.UUID, slug string) (*domain.Page, error) {
+	query := `
+		SELECT published_snapshot
+		FROM pages
+		WHERE site_id = $1 AND published_snapshot->>'slug' = $2
+		  AND status = 'published' AND published_snapshot IS NOT NULL AND deleted_at IS NULL
+		LIMIT 1
+	`
+	var snapshot domain.PageSnapshot
+	if err := r.db.GetContext(ctx, &snapshot, query, siteID, slug); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("pageRepository.FindPublishedBySlug: %w", err)
+	}
+	page := domain.Page(snapshot)
+	return &page, nil
+}
+
+// FindPublishedHomepage retrieves the published snapshot of a site's homepage
+func (r *pageRepository) FindPublishedHomepage(ctx context.Context, siteID uuid.UUID) (*domain.Page, error) {
+	query := `
+		SELECT published_snapshot
+		FROM pages
+		WHERE site_id = $1 AND is_homepage = true
+		  AND status = 'published' AND published_snapshot IS NOT NULL AND deleted_at IS NULL
+		LIMIT 1
+	`
+	var snapshot domain.PageSnapshot
+	if err := r.db.GetContext(ctx, &snapshot, query, siteID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("pageRepository.FindPublishedHomepage: %w", err)
+	}
+	page := domain.Page(snapshot)
+	return &page, nil
+}
+
+// FindSitemapPages lists the published pages of a site with only the fields a
+// sitemap needs, read from their published snapshots. Sections are not loaded.
+func (r *pageRepository) FindSitemapPages(ctx context.Context, siteID uuid.UUID) ([]*domain.Page, error) {
+	query := `
+		SELECT id, site_id, is_homepage, published_at,
+		       published_snapshot->>'slug' AS slug,
+		       published_snapshot->>'canonical_url' AS canonical_url,
+		       published_snapshot->>'robots_meta' AS robots_meta,
+		       COALESCE((published_snapshot->>'updated_at')::timestamptz, updated_at) AS updated_at
+		FROM pages
+		WHERE site_id = $1 AND status = 'published'
+		  AND published_snapshot IS NOT NULL AND deleted_at IS NULL
+		ORDER BY published_snapshot->>'slug'
+	`
+	var pages []*domain.Page
+	if err := r.db.SelectContext(ctx, &pages, query, siteID); err != nil {
+		return nil, fmt.Errorf("pageRepository.FindSitemapPages: %w", err)
+	}
+	return pages, nil
+}
+
+// FindPublishedPages retrieves the published snapshot of every published page
+// of a site, with its sections. The homepage flag is the page's current one,
+// as for FindPublishedHomepage.
+func (r *pageRepository) FindPublishedPages(ctx context.Context, siteID uuid.UUID) ([]*domain.Page, error) {
+	query := `
+		SELECT published_snapshot, is_homepage
+		FROM pages
+		WHERE site_id = $1 AND status = 'published'
+		  AND published_snapshot IS NOT NULL AND deleted_at IS NULL
+		ORDER BY published_snapshot->>'slug'
+	`
+	var rows []struct {
+		Snapshot   domain.PageSnapshot `db:"published_snapshot"`
+		IsHomepage bool                `db:"is_homepage"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, siteID); err != nil {
+		return nil, fmt.Errorf("pageRepository.FindPublishedPages: %w", err)
+	}
+
+	pages := make([]*domain.Page, 0, len(rows))
+	for _, row := range rows {
+		page := domain.Page(row.Snapshot)
+		page.IsHomepage = row.IsHomepage
+		pages = append(pages, &page)
+	}
+	return pages, nil
+}
+
+// Publish marks a page as published and stores its current tree as the published snapshot
+func (r *pageRepository) Publish(ctx context.Context, page *domain.Page) error {
+	query := `
+		UPDATE pages SET
+			status = 'published', published_at = $2, published_snapshot = $3,
+			updated_by = $4, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	row := r.db.QueryRowxContext(ctx, query, page.ID, page.PublishedAt, domain.PageSnapshot(*page), page.UpdatedBy)
+	if err := row.Scan(&page.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		return fmt.Errorf("pageRepository.Publish: %w", err)
+	}
+	return nil
+}
+
+// UpdateSchedule sets or clears a page's scheduled publish and unpublish times
+func (r *pageRepository) UpdateSchedule(ctx context.Context, id uuid.UUID, publishAt, unpublishAt *time.Time) error {
+	query := `
+		UPDATE pages SET publish_at = $2, unpublish_at = $3,
+			publish_claimed_at = NULL, unpublish_claimed_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, id, publishAt, unpublishAt)
+	if err != nil {
+		return fmt.Errorf("pageRepository.UpdateSchedule: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDuePublishes leases and returns scheduled publishes that are due. The
+// schedule itself is kept until CompleteSchedule, so a claim that is never
+// completed or released can be taken again once it is older than lease.
+// Rows locked by another replica are skipped.
+func (r *pageRepository) ClaimDuePublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error) {
+	query := `
+		WITH due AS (
+			SELECT id, publish_at FROM pages
+			WHERE publish_at <= $1 AND deleted_at IS NULL
+			  AND (publish_claimed_at IS NULL OR publish_claimed_at <= $2)
+			ORDER BY publish_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE pages p SET publish_claimed_at = $1
+		FROM due
+		WHERE p.id = due.id
+		RETURNING p.id, p.site_id, p.slug, 'publish' AS kind, due.publish_at AS scheduled_at
+	`
+	var changes []*domain.ScheduledPageChange
+	if err := r.db.SelectContext(ctx, &changes, query, now, now.Add(-lease), limit); err != nil {
+		return nil, fmt.Errorf("pageRepository.ClaimDuePublishes: %w", err)
+	}
+	return changes, nil
+}
+
+// ClaimDueUnpublishes leases and returns scheduled unpublishes that are due.
+// It follows the same rules as ClaimDuePublishes.
+func (r *pageRepository) ClaimDueUnpublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error) {
+	query := `
+		WITH due AS (
+			SELECT id, unpublish_at FROM pages
+			WHERE unpublish_at <= $1 AND deleted_at IS NULL
+			  AND (unpublish_claimed_at IS NULL OR unpublish_claimed_at <= $2)
+			ORDER BY unpublish_at ASC
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE pages p SET unpublish_claimed_at = $1
+		FROM due
+		WHERE p.id = due.id
+		RETURNING p.id, p.site_id, p.slug, 'unpublish' AS kind, due.unpublish_at AS scheduled_at
+	`
+	var changes []*domain.ScheduledPageChange
+	if err := r.db.SelectContext(ctx, &changes, query, now, now.Add(-lease), limit); err != nil {
+		return nil, fmt.Errorf("pageRepository.ClaimDueUnpublishes: %w", err)
+	}
+	return changes, nil
+}
+
+// CompleteSchedule clears an applied change's schedule and claim. A time set
+// by an editor after the change was claimed is kept.
+func (r *pageRepository) CompleteSchedule(ctx context.Context, change *domain.ScheduledPageChange) error {
+	var query string
+	switch change.Kind {
+	case domain.SchedulePublish:
+		query = `
+			UPDATE pages SET
+				publish_at = CASE WHEN publish_at = $2 THEN NULL ELSE publish_at END,
+				publish_claimed_at = NULL
+			WHERE id = $1
+		`
+	case domain.ScheduleUnpublish:
+		query = `
+			UPDATE pages SET
+				unpublish_at = CASE WHEN unpublish_at = $2 THEN NULL ELSE unpublish_at END,
+				unpublish_claimed_at = NULL
+			WHERE id = $1
+		`
+	default:
+		return fmt.Errorf("pageRepository.CompleteSchedule: unknown schedule kind %q", change.Kind)
+	}
+	if _, err := r.db.ExecContext(ctx, query, change.PageID, change.ScheduledAt); err != nil {
+		return fmt.Errorf("pageRepository.CompleteSchedule: %w", err)
+	}
+	return nil
+}
+
+// ReleaseSchedule drops the claim on a change that could not be applied, so
+// the next run retries it without waiting for the lease to expire.
+func (r *pageRepository) ReleaseSchedule(ctx context.Context, change *domain.ScheduledPageChange) error {
+	var query string
+	switch change.Kind {
+	case domain.SchedulePublish:
+		query = `UPDATE pages SET publish_claimed_at = NULL WHERE id = $1`
+	case domain.ScheduleUnpublish:
+		query = `UPDATE pages SET unpublish_claimed_at = NULL WHERE id = $1`
+	default:
+		return fmt.Errorf("pageRepository.ReleaseSchedule: unknown schedule kind %q", change.Kind)
+	}
+	if _, err := r.db.ExecContext(ctx, query, change.PageID); err != nil {
+		return fmt.Errorf("pageRepository.ReleaseSchedule: %w", err)
+	}
+	return nil
+}
+
 // FindSectionsByPageID retrieves all sections for a page
 func (r *pageRepository) FindSectionsByPageID(ctx context.Context, pageID uuid.UUID) ([]*domain.PageSection, error) {
 	query := `
 		SELECT id, page_id, name, type, identifier, is_visible, sort_order,
 		       bg_color, bg_image, bg_video, bg_overlay, bg_overlay_color, bg_overlay_opacity,
 		       layout, padding_top, padding_bottom, animation, css_class, custom_css,
-		       metadata, created_at, updated_at
+		       content_schema, metadata, created_at, updated_at
 		FROM page_sections
 		WHERE page_id = $1
 		ORDER BY sort_order ASC
@@ -296,7 +572,7 @@ func (r *pageRepository) FindSectionByID(ctx context.Context, id uuid.UUID) (*do
 		SELECT id, page_id, name, type, identifier, is_visible, sort_order,
 		       bg_color, bg_image, bg_video, bg_overlay, bg_overlay_color, bg_overlay_opacity,
 		       layout, padding_top, padding_bottom, animation, css_class, custom_css,
-		       metadata, created_at, updated_at
+		       content_schema, metadata, created_at, updated_at
 		FROM page_sections
 		WHERE id = $1
 	`
@@ -313,8 +589,8 @@ func (r *pageRepository) FindSectionByID(ctx context.Context, id uuid.UUID) (*do
 // CreateSection inserts a new page section
 func (r *pageRepository) CreateSection(ctx context.Context, section *domain.PageSection) error {
 	query := `
-		INSERT INTO page_sections (id, page_id, name, type, identifier, is_visible, sort_order, metadata)
-		VALUES (:id, :page_id, :name, :type, :identifier, :is_visible, :sort_order, :metadata)
+		INSERT INTO page_sections (id, page_id, name, type, identifier, is_visible, sort_order, content_schema, metadata)
+		VALUES (:id, :page_id, :name, :type, :identifier, :is_visible, :sort_order, :content_schema, :metadata)
 		RETURNING created_at, updated_at
 	`
 	rows, err := r.db.NamedQueryContext(ctx, query, section)
@@ -428,33 +704,7 @@ func (r *pageRepository) FindContentByKey(ctx context.Context, sectionID uuid.UU
 
 // UpsertContent creates or updates a content item
 func (r *pageRepository) UpsertContent(ctx context.Context, content *domain.SectionContent) error {
-	query := `
-		INSERT INTO section_contents (
-			id, section_id, key, value, value_json, type, label, description, placeholder,
-			is_required, sort_order, alt_text, width, height, link_url, link_target, metadata
-		) VALUES (
-			:id, :section_id, :key, :value, :value_json, :type, :label, :description, :placeholder,
-			:is_required, :sort_order, :alt_text, :width, :height, :link_url, :link_target, :metadata
-		)
-		ON CONFLICT (section_id, key) DO UPDATE SET
-			value = EXCLUDED.value,
-			value_json = EXCLUDED.value_json,
-			type = EXCLUDED.type,
-			label = EXCLUDED.label,
-			description = EXCLUDED.description,
-			placeholder = EXCLUDED.placeholder,
-			is_required = EXCLUDED.is_required,
-			sort_order = EXCLUDED.sort_order,
-			alt_text = EXCLUDED.alt_text,
-			width = EXCLUDED.width,
-			height = EXCLUDED.height,
-			link_url = EXCLUDED.link_url,
-			link_target = EXCLUDED.link_target,
-			metadata = EXCLUDED.metadata,
-			updated_at = NOW()
-		RETURNING id, created_at, updated_at
-	`
-	rows, err := r.db.NamedQueryContext(ctx, query, content)
+	rows, err := r.db.NamedQueryContext(ctx, upsertContentQuery+" RETURNING id, created_at, updated_at", content)
 	if err != nil {
 		return fmt.Errorf("pageRepository.UpsertContent: %w", err)
 	}
@@ -491,3 +741,151 @@ func (r *pageRepository) DeleteContentByKey(ctx context.Context, sectionID uuid.
 	}
 	return nil
 }
+
+// FindContentByID retrieves a content item by ID
+func (r *pageRepository) FindContentByID(ctx context.Context, id uuid.UUID) (*domain.SectionContent, error) {
+	query := `
+		SELECT id, section_id, key, value, value_json, type, label, description, placeholder,
+		       is_required, sort_order, alt_text, width, height, link_url, link_target,
+		       metadata, created_at, updated_at
+		FROM section_contents
+		WHERE id = $1
+	`
+	var content domain.SectionContent
+	if err := r.db.GetContext(ctx, &content, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("pageRepository.FindContentByID: %w", err)
+	}
+	return &content, nil
+}
+
+// ReplaceTree overwrites a page, its sections and their contents with the given
+// tree in a single transaction. Sections and contents missing from the tree are
+// deleted; existing ones keep their IDs so components linked by section_id survive.
+// Lifecycle fields (status, is_homepage, published_at) are left untouched.
+func (r *pageRepository) ReplaceTree(ctx context.Context, page *domain.Page) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pageRepository.ReplaceTree begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replacePageRow(ctx, tx, page); err != nil {
+		return err
+	}
+
+	sectionIDs := make([]string, 0, len(page.Sections))
+	for _, section := range page.Sections {
+		sectionIDs = append(sectionIDs, section.ID.String())
+	}
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM page_sections WHERE page_id = $1 AND NOT (id = ANY($2::uuid[]))`,
+		page.ID, sectionIDs,
+	)
+	if err != nil {
+		return fmt.Errorf("pageRepository.ReplaceTree delete sections: %w", err)
+	}
+
+	for _, section := range page.Sections {
+		section.PageID = page.ID
+		if err := replaceSection(ctx, tx, section); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateTree inserts a new page tree and its components in a single transaction
+func (r *pageRepository) CreateTree(ctx context.Context, page *domain.Page, components *domain.ComponentSet) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("pageRepository.CreateTree begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertPageTree(ctx, tx, page); err != nil {
+		return fmt.Errorf("pageRepository.CreateTree: %w", err)
+	}
+	if components != nil {
+		if err := insertComponents(ctx, tx, components); err != nil {
+			return fmt.Errorf("pageRepository.CreateTree: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// replacePageRow overwrites the editable columns of a page inside a transaction
+func replacePageRow(ctx context.Context, tx *sqlx.Tx, page *domain.Page) error {
+	query := `
+		UPDATE pages SET
+			title = :title, slug = :slug, description = :description,
+			seo_title = :seo_title, seo_description = :seo_description, seo_keywords = :seo_keywords,
+			og_title = :og_title, og_description = :og_description, og_image = :og_image, og_type = :og_type,
+			twitter_title = :twitter_title, twitter_description = :twitter_description,
+			twitter_image = :twitter_image, twitter_card = :twitter_card,
+			schema_markup = :schema_markup, custom_head = :custom_head, canonical_url = :canonical_url,
+			robots_meta = :robots_meta, template = :template, sort_order = :sort_order,
+			metadata = :metadata, updated_by = :updated_by, updated_at = NOW()
+		WHERE id = :id AND deleted_at IS NULL
+	`
+	result, err := tx.NamedExecContext(ctx, query, page)
+	if err != nil {
+		return fmt.Errorf("pageRepository.ReplaceTree page: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// replaceSection upserts a section by ID and replaces its contents inside a transaction
+func replaceSection(ctx context.Context, tx *sqlx.Tx, section *domain.PageSection) error {
+	query := `
+		INSERT INTO page_sections (
+			id, page_id, name, type, identifier, is_visible, sort_order,
+			bg_color, bg_image, bg_video, bg_overlay, bg_overlay_color, bg_overlay_opacity,
+			layout, padding_top, padding_bottom, animation, css_class, custom_css, content_schema, metadata
+		) VALUES (
+			:id, :page_id, :name, :type, :identifier, :is_visible, :sort_order,
+			:bg_color, :bg_image, :bg_video, :bg_overlay, :bg_overlay_color, :bg_overlay_opacity,
+			:layout, :padding_top, :padding_bottom, :animation, :css_class, :custom_css, :content_schema, :metadata
+		)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name, type = EXCLUDED.type, identifier = EXCLUDED.identifier,
+			is_visible = EXCLUDED.is_visible, sort_order = EXCLUDED.sort_order,
+			bg_color = EXCLUDED.bg_color, bg_image = EXCLUDED.bg_image, bg_video = EXCLUDED.bg_video,
+			bg_overlay = EXCLUDED.bg_overlay, bg_overlay_color = EXCLUDED.bg_overlay_color,
+			bg_overlay_opacity = EXCLUDED.bg_overlay_opacity,
+			layout = EXCLUDED.layout, padding_top = EXCLUDED.padding_top, padding_bottom = EXCLUDED.padding_bottom,
+			animation = EXCLUDED.animation, css_class = EXCLUDED.css_class, custom_css = EXCLUDED.custom_css,
+			content_schema = EXCLUDED.content_schema, metadata = EXCLUDED.metadata, updated_at = NOW()
+		WHERE page_sections.page_id = EXCLUDED.page_id
+	`
+	if _, err := tx.NamedExecContext(ctx, query, section); err != nil {
+		return fmt.Errorf("pageRepository.ReplaceTree section: %w", err)
+	}
+
+	keys := make([]string, 0, len(section.Contents))
+	for _, content := range section.Contents {
+		keys = append(keys, content.Key)
+	}
+	_, err := tx.ExecContext(ctx,
+		`DELETE FROM section_contents WHERE section_id = $1 AND NOT (key = ANY($2::text[]))`,
+		section.ID, keys,
+	)
+	if err != nil {
+		return fmt.Errorf("pageRepository.ReplaceTree delete contents: %w", err)
+	}
+
+	for _, content := range section.Contents {
+		content.SectionID = section.ID
+		if _, err := tx.NamedExecContext(ctx, upsertContentQuery, content); err != nil {
+			return fmt.Errorf("pageRepository.ReplaceTree content: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// RevisionRepository defines the interface for page revision data access
+type RevisionRepository interface {
+	FindByPageID(ctx context.Context, pageID uuid.UUID, pagination domain.Pagination) ([]*domain.PageRevisionSummary, int, error)
+	FindByNumber(ctx context.Context, pageID uuid.UUID, number int) (*domain.PageRevision, error)
+	FindLatest(ctx context.Context, pageID uuid.UUID) (*domain.PageRevision, error)
+	Create(ctx context.Context, revision *domain.PageRevision) error
+}
+
+// revisionRepository implements RevisionRepository
+type revisionRepository struct {
+	db *sqlx.DB
+}
+
+// NewRevisionRepository creates a new revisionRepository
+func NewRevisionRepository(db *sqlx.DB) RevisionRepository {
+	return &revisionRepository{db: db}
+}
+
+// FindByPageID retrieves revision summaries for a page, newest first
+func (r *revisionRepository) FindByPageID(ctx context.Context, pageID uuid.UUID, pagination domain.Pagination) ([]*domain.PageRevisionSummary, int, error) {
+	var total int
+	if err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM page_revisions WHERE page_id = $1`, pageID); err != nil {
+		return nil, 0, fmt.Errorf("revisionRepository.FindByPageID count: %w", err)
+	}
+
+	pagination.Normalize()
+	query := `
+		SELECT id, page_id, revision_number, change_summary, created_by, created_at
+		FROM page_revisions
+		WHERE page_id = $1
+		ORDER BY revision_number DESC
+		LIMIT $2 OFFSET $3
+	`
+	var revisions []*domain.PageRevisionSummary
+	if err := r.db.SelectContext(ctx, &revisions, query, pageID, pagination.PerPage, pagination.Offset()); err != nil {
+		return nil, 0, fmt.Errorf("revisionRepository.FindByPageID: %w", err)
+	}
+	return revisions, total, nil
+}
+
+// FindByNumber retrieves a single revision of a page including its snapshot
+func (r *revisionRepository) FindByNumber(ctx context.Context, pageID uuid.UUID, number int) (*domain.PageRevision, error) {
+	query := `
+		SELECT id, page_id, revision_number, snapshot, change_summary, created_by, created_at
+		FROM page_revisions
+		WHERE page_id = $1 AND revision_number = $2
+	`
+	var revision domain.PageRevision
+	if err := r.db.GetContext(ctx, &revision, query, pageID, number); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("revisionRepository.FindByNumber: %w", err)
+	}
+	return &revision, nil
+}
+
+// FindLatest retrieves the most recent revision of a page
+func (r *revisionRepository) FindLatest(ctx context.Context, pageID uuid.UUID) (*domain.PageRevision, error) {
+	query := `
+		SELECT id, page_id, revision_number, snapshot, change_summary, created_by, created_at
+		FROM page_revisions
+		WHERE page_id = $1
+		ORDER BY revision_number DESC
+		LIMIT 1
+	`
+	var revision domain.PageRevision
+	if err := r.db.GetContext(ctx, &revision, query, pageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("revisionRepository.FindLatest: %w", err)
+	}
+	return &revision, nil
+}
+
+// Create inserts a new revision, assigning the next revision number for the page.
+// The page row is locked for the duration of the insert so concurrent edits
+// cannot be given the same revision number.
+func (r *revisionRepository) Create(ctx context.Context, revision *domain.PageRevision) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("revisionRepository.Create begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM pages WHERE id = $1 FOR UPDATE`, revision.PageID); err != nil {
+		return fmt.Errorf("revisionRepository.Create lock page: %w", err)
+	}
+
+	query := `
+		INSERT INTO page_revisions (id, page_id, revision_number, snapshot, change_summary, created_by)
+		SELECT $1, $2, COALESCE(MAX(revision_number), 0) + 1, $3, $4, $5
+		FROM page_revisions
+		WHERE page_id = $2
+		RETURNING revision_number, created_at
+	`
+	row := tx.QueryRowxContext(ctx, query,
+		revision.ID, revision.PageID, revision.Snapshot, revision.ChangeSummary, revision.CreatedBy,
+	)
+	if err := row.Scan(&revision.RevisionNumber, &revision.CreatedAt); err != nil {
+		return fmt.Errorf("revisionRepository.Create: %w", err)
+	}
+
+	return tx.Commit()
+}
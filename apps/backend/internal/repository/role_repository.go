@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// RoleRepository defines the interface for role data access
+type RoleRepository interface {
+	// FindAll retrieves every role, built-in roles first, with its user count
+	FindAll(ctx context.Context) ([]*domain.Role, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.Role, error)
+	FindByName(ctx context.Context, name domain.UserRole) (*domain.Role, error)
+	Create(ctx context.Context, role *domain.Role) error
+	Update(ctx context.Context, role *domain.Role) error
+	// Delete removes a custom role. It returns ErrRoleInUse when users still
+	// have the role.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// roleRepository implements RoleRepository
+type roleRepository struct {
+	db *sqlx.DB
+}
+
+// NewRoleRepository creates a new roleRepository
+func NewRoleRepository(db *sqlx.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+const roleColumns = `
+	r.id, r.name, r.description, r.permissions, r.is_built_in, r.created_at, r.updated_at,
+	(SELECT COUNT(*) FROM users u WHERE u.role = r.name AND u.deleted_at IS NULL) AS user_count
+`
+
+// FindAll retrieves all roles
+func (r *roleRepository) FindAll(ctx context.Context) ([]*domain.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles r ORDER BY r.is_built_in DESC, r.name ASC`
+	var roles []*domain.Role
+	if err := r.db.SelectContext(ctx, &roles, query); err != nil {
+		return nil, fmt.Errorf("roleRepository.FindAll: %w", err)
+	}
+	return roles, nil
+}
+
+// FindByID retrieves a role by ID
+func (r *roleRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles r WHERE r.id = $1`
+	var role domain.Role
+	if err := r.db.GetContext(ctx, &role, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("roleRepository.FindByID: %w", err)
+	}
+	return &role, nil
+}
+
+// FindByName retrieves a role by name
+func (r *roleRepository) FindByName(ctx context.Context, name domain.UserRole) (*domain.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles r WHERE r.name = $1`
+	var role domain.Role
+	if err := r.db.GetContext(ctx, &role, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("roleRepository.FindByName: %w", err)
+	}
+	return &role, nil
+}
+
+// Create inserts a new role
+func (r *roleRepository) Create(ctx context.Context, role *domain.Role) error {
+	query := `
+		INSERT INTO roles (id, name, description, permissions, is_built_in)
+		VALUES (:id, :name, :description, :permissions, :is_built_in)
+		RETURNING created_at, updated_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, role)
+	if err != nil {
+		return fmt.Errorf("roleRepository.Create: %w", err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&role.CreatedAt, &role.UpdatedAt); err != nil {
+			return fmt.Errorf("roleRepository.Create scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// Update changes the description and permissions of a role
+func (r *roleRepository) Update(ctx context.Context, role *domain.Role) error {
+	query := `
+		UPDATE roles SET description = :description, permissions = :permissions
+		WHERE id = :id
+		RETURNING updated_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, role)
+	if err != nil {
+		return fmt.Errorf("roleRepository.Update: %w", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return domain.ErrNotFound
+	}
+	if err := rows.Scan(&role.UpdatedAt); err != nil {
+		return fmt.Errorf("roleRepository.Update scan: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a custom role that no user has
+func (r *roleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	// Soft-deleted users keep their role, so they block the delete too
+	var inUse bool
+	err := r.db.GetContext(ctx, &inUse,
+		`SELECT EXISTS (SELECT 1 FROM users u JOIN roles r ON r.name = u.role WHERE r.id = $1)`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("roleRepository.Delete check: %w", err)
+	}
+	if inUse {
+		return domain.ErrRoleInUse
+	}
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM roles WHERE id = $1 AND NOT is_built_in`, id)
+	if err != nil {
+		return fmt.Errorf("roleRepository.Delete: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("roleRepository.Delete rows: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
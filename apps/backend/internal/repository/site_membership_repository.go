@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// SiteMembershipRepository defines the interface for site membership data access
+type SiteMembershipRepository interface {
+	Find(ctx context.Context, siteID, userID uuid.UUID) (*domain.SiteMembership, error)
+	// FindBySite lists the members of a site with their email and name
+	FindBySite(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteMembership, error)
+	FindSiteIDsByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	// Upsert creates a membership or changes the role of an existing one
+	Upsert(ctx context.Context, membership *domain.SiteMembership) error
+	// Delete removes a membership. It returns ErrNotFound when there is none.
+	Delete(ctx context.Context, siteID, userID uuid.UUID) error
+	// FindResourceSiteID returns the site a record belongs to
+	FindResourceSiteID(ctx context.Context, resource domain.SiteResource, id uuid.UUID) (uuid.UUID, error)
+	// FindMediaSiteIDsByKey returns the sites of the media items stored under
+	// a storage key, either as their original or as one of their image variants
+	FindMediaSiteIDsByKey(ctx context.Context, key string) ([]uuid.UUID, error)
+}
+
+// siteMembershipRepository implements SiteMembershipRepository
+type siteMembershipRepository struct {
+	db *sqlx.DB
+}
+
+// NewSiteMembershipRepository creates a new siteMembershipRepository
+func NewSiteMembershipRepository(db *sqlx.DB) SiteMembershipRepository {
+	return &siteMembershipRepository{db: db}
+}
+
+// resourceSiteQueries look up the site of a record by its ID
+var resourceSiteQueries = map[domain.SiteResource]string{
+	domain.SiteResourceSite: `SELECT id FROM sites WHERE id = $1 AND deleted_at IS NULL`,
+	domain.SiteResourcePage: `SELECT site_id FROM pages WHERE id = $1 AND deleted_at IS NULL`,
+	domain.SiteResourceSection: `SELECT p.site_id FROM page_sections ps
+		JOIN pages p ON p.id = ps.page_id WHERE ps.id = $1`,
+	domain.SiteResourceContent: `SELECT p.site_id FROM section_contents sc
+		JOIN page_sections ps ON ps.id = sc.section_id
+		JOIN pages p ON p.id = ps.page_id WHERE sc.id = $1`,
+	domain.SiteResourceFeature:        `SELECT site_id FROM features WHERE id = $1 AND deleted_at IS NULL`,
+	domain.SiteResourceTestimonial:    `SELECT site_id FROM testimonials WHERE id = $1 AND deleted_at IS NULL`,
+	domain.SiteResourcePricingPlan:    `SELECT site_id FROM pricing_plans WHERE id = $1 AND deleted_at IS NULL`,
+	domain.SiteResourceFAQ:            `SELECT site_id FROM faqs WHERE id = $1 AND deleted_at IS NULL`,
+	domain.SiteResourceNavigationMenu: `SELECT site_id FROM navigation_menus WHERE id = $1`,
+	domain.SiteResourceNavigationItem: `SELECT nm.site_id FROM navigation_items ni
+		JOIN navigation_menus nm ON nm.id = ni.menu_id WHERE ni.id = $1`,
+	domain.SiteResourceMedia: `SELECT site_id FROM media WHERE id = $1 AND deleted_at IS NULL`,
+}
+
+// Find retrieves the membership of a user on a site
+func (r *siteMembershipRepository) Find(ctx context.Context, siteID, userID uuid.UUID) (*domain.SiteMembership, error) {
+	query := `
+		SELECT id, site_id, user_id, role, created_by, created_at, updated_at
+		FROM site_memberships
+		WHERE site_id = $1 AND user_id = $2
+	`
+	var membership domain.SiteMembership
+	if err := r.db.GetContext(ctx, &membership, query, siteID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("siteMembershipRepository.Find: %w", err)
+	}
+	return &membership, nil
+}
+
+// FindBySite retrieves the members of a site ordered by email
+func (r *siteMembershipRepository) FindBySite(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteMembership, error) {
+	query := `
+		SELECT m.id, m.site_id, m.user_id, m.role, m.created_by, m.created_at, m.updated_at,
+		       u.email, u.full_name
+		FROM site_memberships m
+		JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+		WHERE m.site_id = $1
+		ORDER BY u.email ASC
+	`
+	var memberships []*domain.SiteMembership
+	if err := r.db.SelectContext(ctx, &memberships, query, siteID); err != nil {
+		return nil, fmt.Errorf("siteMembershipRepository.FindBySite: %w", err)
+	}
+	return memberships, nil
+}
+
+// FindSiteIDsByUser retrieves the IDs of the sites a user is a member of
+func (r *siteMembershipRepository) FindSiteIDsByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT m.site_id
+		FROM site_memberships m
+		JOIN sites s ON s.id = m.site_id AND s.deleted_at IS NULL
+		WHERE m.user_id = $1
+	`
+	siteIDs := []uuid.UUID{}
+	if err := r.db.SelectContext(ctx, &siteIDs, query, userID); err != nil {
+		return nil, fmt.Errorf("siteMembershipRepository.FindSiteIDsByUser: %w", err)
+	}
+	return siteIDs, nil
+}
+
+// Upsert creates a membership or updates its role
+func (r *siteMembershipRepository) Upsert(ctx context.Context, membership *domain.SiteMembership) error {
+	query := `
+		INSERT INTO site_memberships (id, site_id, user_id, role, created_by)
+		VALUES (:id, :site_id, :user_id, :role, :created_by)
+		ON CONFLICT (site_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING id, created_by, created_at, updated_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, membership)
+	if err != nil {
+		return fmt.Errorf("siteMembershipRepository.Upsert: %w", err)
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(&membership.ID, &membership.CreatedBy, &membership.CreatedAt, &membership.UpdatedAt); err != nil {
+			return fmt.Errorf("siteMembershipRepository.Upsert scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the membership of a user on a site
+func (r *siteMembershipRepository) Delete(ctx context.Context, siteID, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM site_memberships WHERE site_id = $1 AND user_id = $2`,
+		siteID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("siteMembershipRepository.Delete: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("siteMembershipRepository.Delete rows: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// FindResourceSiteID looks up the site of a page, section, component or media item
+func (r *siteMembershipRepository) FindResourceSiteID(ctx context.Context, resource domain.SiteResource, id uuid.UUID) (uuid.UUID, error) {
+	query, ok := resourceSiteQueries[resource]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("siteMembershipRepository.FindResourceSiteID: unknown resource %q", resource)
+	}
+	var siteID uuid.UUID
+	if err := r.db.GetContext(ctx, &siteID, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, domain.ErrNotFound
+		}
+		return uuid.Nil, fmt.Errorf("siteMembershipRepository.FindResourceSiteID: %w", err)
+	}
+	return siteID, nil
+}
+
+// FindMediaSiteIDsByKey looks up the sites a stored file belongs to. Media of
+// cloned sites share their files, so there can be several.
+func (r *siteMembershipRepository) FindMediaSiteIDsByKey(ctx context.Context, key string) ([]uuid.UUID, error) {
+	query := `
+		SELECT DISTINCT site_id FROM media
+		WHERE deleted_at IS NULL
+		  AND (file_path = $1 OR metadata->'variants' @> jsonb_build_array(jsonb_build_object('key', $1::text)))
+		ORDER BY site_id
+	`
+	siteIDs := []uuid.UUID{}
+	if err := r.db.SelectContext(ctx, &siteIDs, query, key); err != nil {
+		return nil, fmt.Errorf("siteMembershipRepository.FindMediaSiteIDsByKey: %w", err)
+	}
+	return siteIDs, nil
+}
+
+// uuidStrings formats IDs for a uuid[] parameter
+func uuidStrings(ids []uuid.UUID) []string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id.String())
+	}
+	return values
+}
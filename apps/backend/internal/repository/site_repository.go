@@ -21,11 +21,23 @@ type SiteRepository interface {
 	Update(ctx context.Context, site *domain.Site) error
 	Delete(ctx context.Context, id uuid.UUID) error
 
+	// Domains
+	FindDomainsBySiteID(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteDomain, error)
+	CreateDomain(ctx context.Context, siteDomain *domain.SiteDomain) error
+	DeleteDomain(ctx context.Context, siteID, id uuid.UUID) error
+
 	// Settings
 	FindSettingsBySiteID(ctx context.Context, siteID uuid.UUID, publicOnly bool) ([]*domain.SiteSetting, error)
 	FindSettingByKey(ctx context.Context, siteID uuid.UUID, key string) (*domain.SiteSetting, error)
 	UpsertSetting(ctx context.Context, siteID uuid.UUID, key, value string) error
 	BulkUpsertSettings(ctx context.Context, siteID uuid.UUID, settings map[string]string) error
+
+	// Tree operations
+	// CreateTree inserts a site with its settings, pages, components, navigation
+	// and media in one transaction. IDs must already be fresh and references
+	// remapped. With dryRun the transaction is rolled back after the last insert,
+	// so database constraints are still checked.
+	CreateTree(ctx context.Context, tree *domain.SiteBundle, dryRun bool) error
 }
 
 // siteRepository implements SiteRepository
@@ -72,12 +84,16 @@ func (r *siteRepository) FindBySlug(ctx context.Context, slug string) (*domain.S
 	return &site, nil
 }
 
-// FindByDomain retrieves a site by domain
+// FindByDomain retrieves an active site by its primary domain or one of its domain aliases
 func (r *siteRepository) FindByDomain(ctx context.Context, domainName string) (*domain.Site, error) {
 	query := `
-		SELECT id, name, slug, domain, description, logo_url, favicon_url, is_active, metadata, created_by, created_at, updated_at
-		FROM sites
-		WHERE domain = $1 AND deleted_at IS NULL AND is_active = true
+		SELECT s.id, s.name, s.slug, s.domain, s.description, s.logo_url, s.favicon_url, s.is_active,
+		       s.metadata, s.created_by, s.created_at, s.updated_at
+		FROM sites s
+		WHERE s.deleted_at IS NULL AND s.is_active = true
+		  AND (LOWER(s.domain) = LOWER($1)
+		       OR EXISTS (SELECT 1 FROM site_domains d WHERE d.site_id = s.id AND d.domain = LOWER($1)))
+		LIMIT 1
 	`
 	var site domain.Site
 	if err := r.db.GetContext(ctx, &site, query, domainName); err != nil {
@@ -95,6 +111,11 @@ func (r *siteRepository) FindAll(ctx context.Context, filter domain.SiteFilter)
 	argIdx := 1
 	where := "WHERE deleted_at IS NULL"
 
+	if filter.IDs != nil {
+		where += fmt.Sprintf(" AND id = ANY($%d::uuid[])", argIdx)
+		args = append(args, uuidStrings(filter.IDs))
+		argIdx++
+	}
 	if filter.IsActive != nil {
 		where += fmt.Sprintf(" AND is_active = $%d", argIdx)
 		args = append(args, *filter.IsActive)
@@ -191,6 +212,57 @@ func (r *siteRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// FindDomainsBySiteID retrieves all domain aliases of a site
+func (r *siteRepository) FindDomainsBySiteID(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteDomain, error) {
+	query := `
+		SELECT id, site_id, domain, created_at
+		FROM site_domains
+		WHERE site_id = $1
+		ORDER BY domain ASC
+	`
+	var domains []*domain.SiteDomain
+	if err := r.db.SelectContext(ctx, &domains, query, siteID); err != nil {
+		return nil, fmt.Errorf("siteRepository.FindDomainsBySiteID: %w", err)
+	}
+	return domains, nil
+}
+
+// CreateDomain adds a domain alias to a site. Domains already used as another
+// site's primary domain or alias are rejected with ErrAlreadyExists.
+func (r *siteRepository) CreateDomain(ctx context.Context, siteDomain *domain.SiteDomain) error {
+	query := `
+		INSERT INTO site_domains (id, site_id, domain)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM sites WHERE LOWER(domain) = $3 AND deleted_at IS NULL
+		)
+		ON CONFLICT (domain) DO NOTHING
+		RETURNING created_at
+	`
+	row := r.db.QueryRowxContext(ctx, query, siteDomain.ID, siteDomain.SiteID, siteDomain.Domain)
+	if err := row.Scan(&siteDomain.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrAlreadyExists
+		}
+		return fmt.Errorf("siteRepository.CreateDomain: %w", err)
+	}
+	return nil
+}
+
+// DeleteDomain removes a domain alias from a site
+func (r *siteRepository) DeleteDomain(ctx context.Context, siteID, id uuid.UUID) error {
+	query := `DELETE FROM site_domains WHERE id = $1 AND site_id = $2`
+	result, err := r.db.ExecContext(ctx, query, id, siteID)
+	if err != nil {
+		return fmt.Errorf("siteRepository.DeleteDomain: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
 // FindSettingsBySiteID retrieves all settings for a site
 func (r *siteRepository) FindSettingsBySiteID(ctx context.Context, siteID uuid.UUID, publicOnly bool) ([]*domain.SiteSetting, error) {
 	query := `
@@ -263,3 +335,20 @@ func (r *siteRepository) BulkUpsertSettings(ctx context.Context, siteID uuid.UUI
 
 	return tx.Commit()
 }
+
+// CreateTree inserts a whole site in a single transaction
+func (r *siteRepository) CreateTree(ctx context.Context, tree *domain.SiteBundle, dryRun bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("siteRepository.CreateTree begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertSiteTree(ctx, tx, tree); err != nil {
+		return fmt.Errorf("siteRepository.CreateTree: %w", err)
+	}
+	if dryRun {
+		return nil
+	}
+	return tx.Commit()
+}
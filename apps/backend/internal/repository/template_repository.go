@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// TemplateRepository defines the interface for page template data access
+type TemplateRepository interface {
+	FindAll(ctx context.Context) ([]*domain.PageTemplate, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*domain.PageTemplate, error)
+	FindByName(ctx context.Context, name string) (*domain.PageTemplate, error)
+	Create(ctx context.Context, tmpl *domain.PageTemplate) error
+	Update(ctx context.Context, tmpl *domain.PageTemplate) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// templateRepository implements TemplateRepository
+type templateRepository struct {
+	db *sqlx.DB
+}
+
+// NewTemplateRepository creates a new templateRepository
+func NewTemplateRepository(db *sqlx.DB) TemplateRepository {
+	return &templateRepository{db: db}
+}
+
+// FindAll retrieves all page templates ordered by name
+func (r *templateRepository) FindAll(ctx context.Context) ([]*domain.PageTemplate, error) {
+	query := `
+		SELECT id, name, description, layout, sections, created_by, created_at, updated_at
+		FROM page_templates
+		ORDER BY name ASC
+	`
+	var templates []*domain.PageTemplate
+	if err := r.db.SelectContext(ctx, &templates, query); err != nil {
+		return nil, fmt.Errorf("templateRepository.FindAll: %w", err)
+	}
+	return templates, nil
+}
+
+// FindByID retrieves a page template by ID
+func (r *templateRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.PageTemplate, error) {
+	query := `
+		SELECT id, name, description, layout, sections, created_by, created_at, updated_at
+		FROM page_templates
+		WHERE id = $1
+	`
+	var tmpl domain.PageTemplate
+	if err := r.db.GetContext(ctx, &tmpl, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("templateRepository.FindByID: %w", err)
+	}
+	return &tmpl, nil
+}
+
+// FindByName retrieves a page template by name, ignoring case
+func (r *templateRepository) FindByName(ctx context.Context, name string) (*domain.PageTemplate, error) {
+	query := `
+		SELECT id, name, description, layout, sections, created_by, created_at, updated_at
+		FROM page_templates
+		WHERE LOWER(name) = LOWER($1)
+	`
+	var tmpl domain.PageTemplate
+	if err := r.db.GetContext(ctx, &tmpl, query, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("templateRepository.FindByName: %w", err)
+	}
+	return &tmpl, nil
+}
+
+// Create inserts a new page template
+func (r *templateRepository) Create(ctx context.Context, tmpl *domain.PageTemplate) error {
+	query := `
+		INSERT INTO page_templates (id, name, description, layout, sections, created_by)
+		VALUES (:id, :name, :description, :layout, :sections, :created_by)
+		RETURNING created_at, updated_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, tmpl)
+	if err != nil {
+		return fmt.Errorf("templateRepository.Create: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&tmpl.CreatedAt, &tmpl.UpdatedAt); err != nil {
+			return fmt.Errorf("templateRepository.Create scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// Update updates an existing page template
+func (r *templateRepository) Update(ctx context.Context, tmpl *domain.PageTemplate) error {
+	query := `
+		UPDATE page_templates SET
+			name = :name, description = :description, layout = :layout, sections = :sections
+		WHERE id = :id
+		RETURNING updated_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, tmpl)
+	if err != nil {
+		return fmt.Errorf("templateRepository.Update: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return domain.ErrNotFound
+	}
+	if err := rows.Scan(&tmpl.UpdatedAt); err != nil {
+		return fmt.Errorf("templateRepository.Update scan: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a page template. Sections created from it keep their schema.
+func (r *templateRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM page_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("templateRepository.Delete: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
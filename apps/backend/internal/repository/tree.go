@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// insertSiteTree writes the rows of a bundle in dependency order
+func insertSiteTree(ctx context.Context, tx *sqlx.Tx, bundle *domain.SiteBundle) error {
+	if err := insertRow(ctx, tx, "site", `
+		INSERT INTO sites (id, name, slug, domain, description, logo_url, favicon_url, is_active, metadata, created_by)
+		VALUES (:id, :name, :slug, :domain, :description, :logo_url, :favicon_url, :is_active, :metadata, :created_by)
+	`, bundle.Site); err != nil {
+		return err
+	}
+
+	for _, setting := range bundle.Settings {
+		if err := insertRow(ctx, tx, "setting", `
+			INSERT INTO site_settings (id, site_id, key, value, value_json, type, group_name, label, description, is_public, sort_order)
+			VALUES (:id, :site_id, :key, :value, :value_json, :type, :group_name, :label, :description, :is_public, :sort_order)
+		`, setting); err != nil {
+			return err
+		}
+	}
+
+	for _, page := range bundle.Pages {
+		if err := insertPageTree(ctx, tx, page); err != nil {
+			return err
+		}
+	}
+
+	if err := insertComponents(ctx, tx, &bundle.ComponentSet); err != nil {
+		return err
+	}
+
+	for _, menu := range bundle.NavigationMenus {
+		if err := insertRow(ctx, tx, "navigation menu", `
+			INSERT INTO navigation_menus (id, site_id, name, identifier, description, is_active, metadata)
+			VALUES (:id, :site_id, :name, :identifier, :description, :is_active, :metadata)
+		`, menu); err != nil {
+			return err
+		}
+		if err := insertNavigationItems(ctx, tx, menu.Items); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range bundle.Media {
+		if err := insertRow(ctx, tx, "media", `
+			INSERT INTO media (id, site_id, name, original_name, file_path, public_url, thumbnail_url, type, mime_type,
+				file_size, width, height, duration, alt_text, caption, tags, folder, is_used, uploaded_by, metadata)
+			VALUES (:id, :site_id, :name, :original_name, :file_path, :public_url, :thumbnail_url, :type, :mime_type,
+				:file_size, :width, :height, :duration, :alt_text, :caption, :tags, :folder, :is_used, :uploaded_by, :metadata)
+		`, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertPageTree writes a page with its sections and contents. Published pages
+// get their tree as published snapshot.
+func insertPageTree(ctx context.Context, tx *sqlx.Tx, page *domain.Page) error {
+	if err := insertRow(ctx, tx, "page", `
+		INSERT INTO pages (
+			id, site_id, title, slug, description, status, is_homepage,
+			seo_title, seo_description, seo_keywords,
+			og_title, og_description, og_image, og_type,
+			twitter_title, twitter_description, twitter_image, twitter_card,
+			schema_markup, custom_head, canonical_url, robots_meta, template,
+			sort_order, published_at, metadata, created_by, updated_by
+		) VALUES (
+			:id, :site_id, :title, :slug, :description, :status, :is_homepage,
+			:seo_title, :seo_description, :seo_keywords,
+			:og_title, :og_description, :og_image, :og_type,
+			:twitter_title, :twitter_description, :twitter_image, :twitter_card,
+			:schema_markup, :custom_head, :canonical_url, :robots_meta, :template,
+			:sort_order, :published_at, :metadata, :created_by, :updated_by
+		)
+	`, page); err != nil {
+		return err
+	}
+
+	for _, section := range page.Sections {
+		if err := insertRow(ctx, tx, "section", `
+			INSERT INTO page_sections (
+				id, page_id, name, type, identifier, is_visible, sort_order,
+				bg_color, bg_image, bg_video, bg_overlay, bg_overlay_color, bg_overlay_opacity,
+				layout, padding_top, padding_bottom, animation, css_class, custom_css, content_schema, metadata
+			) VALUES (
+				:id, :page_id, :name, :type, :identifier, :is_visible, :sort_order,
+				:bg_color, :bg_image, :bg_video, :bg_overlay, :bg_overlay_color, :bg_overlay_opacity,
+				:layout, :padding_top, :padding_bottom, :animation, :css_class, :custom_css, :content_schema, :metadata
+			)
+		`, section); err != nil {
+			return err
+		}
+		for _, content := range section.Contents {
+			if err := insertRow(ctx, tx, "content", upsertContentQuery, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	if page.Status == domain.PageStatusPublished {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE pages SET published_snapshot = $2 WHERE id = $1`,
+			page.ID, domain.PageSnapshot(*page),
+		)
+		if err != nil {
+			return fmt.Errorf("page snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertComponents writes features, testimonials, pricing plans and FAQs. Their
+// sections must already exist.
+func insertComponents(ctx context.Context, tx *sqlx.Tx, set *domain.ComponentSet) error {
+	for _, feature := range set.Features {
+		if err := insertRow(ctx, tx, "feature", `
+			INSERT INTO features (id, site_id, section_id, title, description, icon, icon_color, image_url, image_alt, link_url, link_text, is_active, sort_order, metadata)
+			VALUES (:id, :site_id, :section_id, :title, :description, :icon, :icon_color, :image_url, :image_alt, :link_url, :link_text, :is_active, :sort_order, :metadata)
+		`, feature); err != nil {
+			return err
+		}
+	}
+	for _, t := range set.Testimonials {
+		if err := insertRow(ctx, tx, "testimonial", `
+			INSERT INTO testimonials (id, site_id, section_id, author_name, author_title, author_company, author_avatar,
+				content, rating, source, source_url, is_featured, is_active, sort_order, metadata)
+			VALUES (:id, :site_id, :section_id, :author_name, :author_title, :author_company, :author_avatar,
+				:content, :rating, :source, :source_url, :is_featured, :is_active, :sort_order, :metadata)
+		`, t); err != nil {
+			return err
+		}
+	}
+	for _, p := range set.PricingPlans {
+		if err := insertRow(ctx, tx, "pricing plan", `
+			INSERT INTO pricing_plans (id, site_id, section_id, name, description, price_monthly, price_yearly, currency,
+				price_label, is_popular, is_custom, badge_text, cta_text, cta_link, features, features_excluded, is_active, sort_order, metadata)
+			VALUES (:id, :site_id, :section_id, :name, :description, :price_monthly, :price_yearly, :currency,
+				:price_label, :is_popular, :is_custom, :badge_text, :cta_text, :cta_link, :features, :features_excluded, :is_active, :sort_order, :metadata)
+		`, p); err != nil {
+			return err
+		}
+	}
+	for _, faq := range set.FAQs {
+		if err := insertRow(ctx, tx, "faq", `
+			INSERT INTO faqs (id, site_id, section_id, question, answer, category, is_active, sort_order, metadata)
+			VALUES (:id, :site_id, :section_id, :question, :answer, :category, :is_active, :sort_order, :metadata)
+		`, faq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertNavigationItems writes a navigation item tree, parents first
+func insertNavigationItems(ctx context.Context, tx *sqlx.Tx, items []*domain.NavigationItem) error {
+	for _, item := range items {
+		if err := insertRow(ctx, tx, "navigation item", `
+			INSERT INTO navigation_items (id, menu_id, parent_id, page_id, label, url, target, icon, css_class, is_active, is_mega_menu, sort_order, depth, metadata)
+			VALUES (:id, :menu_id, :parent_id, :page_id, :label, :url, :target, :icon, :css_class, :is_active, :is_mega_menu, :sort_order, :depth, :metadata)
+		`, item); err != nil {
+			return err
+		}
+		if err := insertNavigationItems(ctx, tx, item.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertRow runs a named insert inside a transaction
+func insertRow(ctx context.Context, tx *sqlx.Tx, what, query string, arg interface{}) error {
+	if _, err := tx.NamedExecContext(ctx, query, arg); err != nil {
+		return fmt.Errorf("insert %s: %w", what, err)
+	}
+	return nil
+}
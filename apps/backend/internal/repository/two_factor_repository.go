@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// TwoFactorRepository defines the interface for two-factor authentication data access
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.UserTOTP, error)
+	// SavePendingSecret stores a secret awaiting confirmation. It returns
+	// ErrAlreadyExists when two-factor authentication is already enabled.
+	SavePendingSecret(ctx context.Context, userID uuid.UUID, secret string) error
+	// Enable confirms the pending secret and replaces the recovery codes
+	Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+	// UseStep records an accepted time step. It returns ErrNotFound when the
+	// step is not newer than the last one used.
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+
+	// Recovery code operations
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// Policy operations
+	FindPolicies(ctx context.Context) ([]*domain.TwoFactorPolicy, error)
+	FindPolicy(ctx context.Context, role domain.UserRole) (*domain.TwoFactorPolicy, error)
+	SavePolicy(ctx context.Context, policy *domain.TwoFactorPolicy) error
+}
+
+// twoFactorRepository implements TwoFactorRepository
+type twoFactorRepository struct {
+	db *sqlx.DB
+}
+
+// NewTwoFactorRepository creates a new twoFactorRepository
+func NewTwoFactorRepository(db *sqlx.DB) TwoFactorRepository {
+	return &twoFactorRepository{db: db}
+}
+
+// FindByUserID retrieves the TOTP enrollment of a user
+func (r *twoFactorRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.UserTOTP, error) {
+	query := `
+		SELECT user_id, secret, is_enabled, last_used_step, enabled_at, created_at, updated_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+	var totp domain.UserTOTP
+	if err := r.db.GetContext(ctx, &totp, query, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("twoFactorRepository.FindByUserID: %w", err)
+	}
+	return &totp, nil
+}
+
+// SavePendingSecret inserts or replaces a secret that is not enabled yet
+func (r *twoFactorRepository) SavePendingSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, enabled_at = NULL
+		WHERE user_totp.is_enabled = false
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.SavePendingSecret: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrAlreadyExists
+	}
+	return nil
+}
+
+// Enable marks the pending secret as confirmed and stores new recovery codes
+// in one transaction
+func (r *twoFactorRepository) Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.Enable begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE user_totp SET is_enabled = true, enabled_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND is_enabled = false
+	`
+	result, err := tx.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.Enable: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("twoFactorRepository.Enable commit: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the TOTP enrollment and recovery codes of a user
+func (r *twoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.Delete begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("twoFactorRepository.Delete codes: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.Delete: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("twoFactorRepository.Delete commit: %w", err)
+	}
+	return nil
+}
+
+// UseStep moves last_used_step forward, failing for a step already used
+func (r *twoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+	result, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.UseStep: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes deletes all recovery codes of a user and stores new ones
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.ReplaceRecoveryCodes begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("twoFactorRepository.ReplaceRecoveryCodes commit: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code as used, or returns ErrNotFound
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	query := `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.UseRecoveryCode: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of a user
+func (r *twoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	if err := r.db.GetContext(ctx, &count, query, userID); err != nil {
+		return 0, fmt.Errorf("twoFactorRepository.CountRecoveryCodes: %w", err)
+	}
+	return count, nil
+}
+
+// FindPolicies retrieves the stored two-factor policies
+func (r *twoFactorRepository) FindPolicies(ctx context.Context) ([]*domain.TwoFactorPolicy, error) {
+	query := `SELECT role, is_required, updated_by, updated_at FROM two_factor_policies ORDER BY role`
+	var policies []*domain.TwoFactorPolicy
+	if err := r.db.SelectContext(ctx, &policies, query); err != nil {
+		return nil, fmt.Errorf("twoFactorRepository.FindPolicies: %w", err)
+	}
+	return policies, nil
+}
+
+// FindPolicy retrieves the two-factor policy of a role
+func (r *twoFactorRepository) FindPolicy(ctx context.Context, role domain.UserRole) (*domain.TwoFactorPolicy, error) {
+	query := `SELECT role, is_required, updated_by, updated_at FROM two_factor_policies WHERE role = $1`
+	var policy domain.TwoFactorPolicy
+	if err := r.db.GetContext(ctx, &policy, query, role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("twoFactorRepository.FindPolicy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SavePolicy inserts or updates the two-factor policy of a role
+func (r *twoFactorRepository) SavePolicy(ctx context.Context, policy *domain.TwoFactorPolicy) error {
+	query := `
+		INSERT INTO two_factor_policies (role, is_required, updated_by)
+		VALUES (:role, :is_required, :updated_by)
+		ON CONFLICT (role) DO UPDATE
+		SET is_required = EXCLUDED.is_required, updated_by = EXCLUDED.updated_by
+		RETURNING updated_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, policy)
+	if err != nil {
+		return fmt.Errorf("twoFactorRepository.SavePolicy: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&policy.UpdatedAt); err != nil {
+			return fmt.Errorf("twoFactorRepository.SavePolicy scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// replaceRecoveryCodes swaps the recovery codes of a user inside tx
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("replaceRecoveryCodes delete: %w", err)
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			return fmt.Errorf("replaceRecoveryCodes insert: %w", err)
+		}
+	}
+	return nil
+}
@@ -24,13 +24,35 @@ type UserRepository interface {
 	IncrementFailedAttempts(ctx context.Context, id uuid.UUID) error
 	ResetFailedAttempts(ctx context.Context, id uuid.UUID) error
 	LockAccount(ctx context.Context, id uuid.UUID, until time.Time) error
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
 
 	// Refresh token operations
 	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error
 	FindRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
 	RevokeRefreshToken(ctx context.Context, tokenHash string) error
 	RevokeAllUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
-	DeleteExpiredRefreshTokens(ctx context.Context) error
+	// FindActiveRefreshTokens lists the unrevoked, unexpired tokens of a user
+	FindActiveRefreshTokens(ctx context.Context, userID uuid.UUID) ([]*domain.RefreshToken, error)
+	// RotateRefreshToken marks an active token as exchanged. It returns
+	// ErrNotFound when the token was already rotated or revoked.
+	RotateRefreshToken(ctx context.Context, tokenHash string) error
+	// RevokeRefreshTokenFamily revokes every active token of a family and
+	// returns how many were revoked
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error)
+	// RevokeUserRefreshTokenFamily revokes a family owned by a user. It
+	// returns ErrNotFound when the family is not theirs or no longer active.
+	RevokeUserRefreshTokenFamily(ctx context.Context, userID, familyID uuid.UUID) error
+	// RevokeOtherUserRefreshTokens revokes every token of a user except
+	// keepHash and returns how many were revoked
+	RevokeOtherUserRefreshTokens(ctx context.Context, userID uuid.UUID, keepHash string) (int64, error)
+	// DeleteExpiredRefreshTokens returns how many tokens were deleted
+	DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
+
+	// Password reset token operations
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error
+	FindPasswordResetToken(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, id uuid.UUID) error
+	InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error
 }
 
 // userRepository implements UserRepository
@@ -48,7 +70,7 @@ func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Us
 	query := `
 		SELECT id, email, password_hash, full_name, avatar_url, role, status,
 		       last_login_at, last_login_ip, failed_attempts, locked_until,
-		       email_verified, metadata, created_at, updated_at, deleted_at
+		       email_verified, password_login_disabled, metadata, created_at, updated_at, deleted_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -67,7 +89,7 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain
 	query := `
 		SELECT id, email, password_hash, full_name, avatar_url, role, status,
 		       last_login_at, last_login_ip, failed_attempts, locked_until,
-		       email_verified, metadata, created_at, updated_at, deleted_at
+		       email_verified, password_login_disabled, metadata, created_at, updated_at, deleted_at
 		FROM users
 		WHERE email = $1 AND deleted_at IS NULL
 	`
@@ -116,7 +138,7 @@ func (r *userRepository) FindAll(ctx context.Context, filter domain.UserFilter)
 	dataQuery := fmt.Sprintf(`
 		SELECT id, email, password_hash, full_name, avatar_url, role, status,
 		       last_login_at, last_login_ip, failed_attempts, locked_until,
-		       email_verified, metadata, created_at, updated_at, deleted_at
+		       email_verified, password_login_disabled, metadata, created_at, updated_at, deleted_at
 		FROM users %s
 		ORDER BY created_at DESC
 		LIMIT $%d OFFSET $%d
@@ -134,8 +156,8 @@ func (r *userRepository) FindAll(ctx context.Context, filter domain.UserFilter)
 // Create inserts a new user into the database
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (id, email, password_hash, full_name, avatar_url, role, status, email_verified, metadata)
-		VALUES (:id, :email, :password_hash, :full_name, :avatar_url, :role, :status, :email_verified, :metadata)
+		INSERT INTO users (id, email, password_hash, full_name, avatar_url, role, status, email_verified, password_login_disabled, metadata)
+		VALUES (:id, :email, :password_hash, :full_name, :avatar_url, :role, :status, :email_verified, :password_login_disabled, :metadata)
 		RETURNING created_at, updated_at
 	`
 	rows, err := r.db.NamedQueryContext(ctx, query, user)
@@ -157,7 +179,8 @@ func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
 		SET full_name = :full_name, avatar_url = :avatar_url, role = :role,
-		    status = :status, email_verified = :email_verified, metadata = :metadata,
+		    status = :status, email_verified = :email_verified,
+		    password_login_disabled = :password_login_disabled, metadata = :metadata,
 		    updated_at = NOW()
 		WHERE id = :id AND deleted_at IS NULL
 		RETURNING updated_at
@@ -230,11 +253,29 @@ func (r *userRepository) LockAccount(ctx context.Context, id uuid.UUID, until ti
 	return nil
 }
 
+// UpdatePassword sets a new password hash and clears any login lockout
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1, failed_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("userRepository.UpdatePassword: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
 // CreateRefreshToken stores a new refresh token
 func (r *userRepository) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
 	query := `
-		INSERT INTO refresh_tokens (id, user_id, token_hash, expires_at, ip_address, user_agent)
-		VALUES (:id, :user_id, :token_hash, :expires_at, :ip_address, :user_agent)
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, family_expires_at, ip_address, user_agent)
+		VALUES (:id, :user_id, :family_id, :token_hash, :expires_at, :family_expires_at, :ip_address, :user_agent)
 		RETURNING created_at
 	`
 	rows, err := r.db.NamedQueryContext(ctx, query, token)
@@ -254,7 +295,8 @@ func (r *userRepository) CreateRefreshToken(ctx context.Context, token *domain.R
 // FindRefreshToken retrieves a refresh token by its hash
 func (r *userRepository) FindRefreshToken(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
 	query := `
-		SELECT id, user_id, token_hash, expires_at, is_revoked, ip_address, user_agent, created_at, revoked_at
+		SELECT id, user_id, family_id, token_hash, expires_at, family_expires_at, is_revoked,
+		       ip_address, user_agent, created_at, revoked_at, rotated_at
 		FROM refresh_tokens
 		WHERE token_hash = $1
 	`
@@ -288,12 +330,156 @@ func (r *userRepository) RevokeAllUserRefreshTokens(ctx context.Context, userID
 	return nil
 }
 
+// FindActiveRefreshTokens retrieves the active refresh tokens of a user, newest first
+func (r *userRepository) FindActiveRefreshTokens(ctx context.Context, userID uuid.UUID) ([]*domain.RefreshToken, error) {
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, family_expires_at, is_revoked,
+		       ip_address, user_agent, created_at, revoked_at, rotated_at
+		FROM refresh_tokens
+		WHERE user_id = $1 AND is_revoked = false AND expires_at > NOW()
+		  AND (family_expires_at IS NULL OR family_expires_at > NOW())
+		ORDER BY created_at DESC
+	`
+	var tokens []*domain.RefreshToken
+	if err := r.db.SelectContext(ctx, &tokens, query, userID); err != nil {
+		return nil, fmt.Errorf("userRepository.FindActiveRefreshTokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RotateRefreshToken revokes a token as part of rotation and records when
+func (r *userRepository) RotateRefreshToken(ctx context.Context, tokenHash string) error {
+	query := `
+		UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW(), rotated_at = NOW()
+		WHERE token_hash = $1 AND is_revoked = false AND rotated_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, tokenHash)
+	if err != nil {
+		return fmt.Errorf("userRepository.RotateRefreshToken: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes the active tokens of a family
+func (r *userRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	query := `UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW() WHERE family_id = $1 AND is_revoked = false`
+	result, err := r.db.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return 0, fmt.Errorf("userRepository.RevokeRefreshTokenFamily: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+// RevokeUserRefreshTokenFamily revokes an active token family owned by userID
+func (r *userRepository) RevokeUserRefreshTokenFamily(ctx context.Context, userID, familyID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW()
+		WHERE family_id = $1 AND user_id = $2 AND is_revoked = false AND expires_at > NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, familyID, userID)
+	if err != nil {
+		return fmt.Errorf("userRepository.RevokeUserRefreshTokenFamily: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// RevokeOtherUserRefreshTokens revokes all tokens of a user but keepHash
+func (r *userRepository) RevokeOtherUserRefreshTokens(ctx context.Context, userID uuid.UUID, keepHash string) (int64, error) {
+	query := `
+		UPDATE refresh_tokens SET is_revoked = true, revoked_at = NOW()
+		WHERE user_id = $1 AND token_hash <> $2 AND is_revoked = false
+	`
+	result, err := r.db.ExecContext(ctx, query, userID, keepHash)
+	if err != nil {
+		return 0, fmt.Errorf("userRepository.RevokeOtherUserRefreshTokens: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
 // DeleteExpiredRefreshTokens removes expired refresh tokens from the database
-func (r *userRepository) DeleteExpiredRefreshTokens(ctx context.Context) error {
-	query := `DELETE FROM refresh_tokens WHERE expires_at < NOW()`
-	_, err := r.db.ExecContext(ctx, query)
+func (r *userRepository) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	query := `DELETE FROM refresh_tokens WHERE expires_at < NOW() OR family_expires_at < NOW()`
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("userRepository.DeleteExpiredRefreshTokens: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token
+func (r *userRepository) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+		VALUES (:id, :user_id, :token_hash, :expires_at)
+		RETURNING created_at
+	`
+	rows, err := r.db.NamedQueryContext(ctx, query, token)
+	if err != nil {
+		return fmt.Errorf("userRepository.CreatePasswordResetToken: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&token.CreatedAt); err != nil {
+			return fmt.Errorf("userRepository.CreatePasswordResetToken scan: %w", err)
+		}
+	}
+	return nil
+}
+
+// FindPasswordResetToken retrieves a password reset token by its hash
+func (r *userRepository) FindPasswordResetToken(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, is_used, created_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`
+	var token domain.PasswordResetToken
+	if err := r.db.GetContext(ctx, &token, query, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("userRepository.FindPasswordResetToken: %w", err)
+	}
+	return &token, nil
+}
+
+// UsePasswordResetToken marks a valid token as used. It returns ErrNotFound
+// when the token was already used or has expired, so two concurrent resets
+// cannot both succeed.
+func (r *userRepository) UsePasswordResetToken(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE password_reset_tokens SET is_used = true, used_at = NOW()
+		WHERE id = $1 AND is_used = false AND expires_at > NOW()
+	`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("userRepository.UsePasswordResetToken: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// InvalidateUserPasswordResetTokens marks all unused reset tokens of a user as used
+func (r *userRepository) InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE password_reset_tokens SET is_used = true, used_at = NOW() WHERE user_id = $1 AND is_used = false`
+	_, err := r.db.ExecContext(ctx, query, userID)
 	if err != nil {
-		return fmt.Errorf("userRepository.DeleteExpiredRefreshTokens: %w", err)
+		return fmt.Errorf("userRepository.InvalidateUserPasswordResetTokens: %w", err)
 	}
 	return nil
 }
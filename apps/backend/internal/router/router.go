@@ -5,26 +5,47 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/jmoiron/sqlx"
-	"github.com/rs/zerolog"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/config"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/handler"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/middleware"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
 )
 
 // Dependencies holds all handler dependencies
 type Dependencies struct {
-	AuthHandler      *handler.AuthHandler
-	PageHandler      *handler.PageHandler
-	SiteHandler      *handler.SiteHandler
-	UserHandler      *handler.UserHandler
-	ComponentHandler *handler.ComponentHandler
-	JWTManager       *auth.JWTManager
-	Config           *config.Config
-	Logger           zerolog.Logger
-	DB               *sqlx.DB // for health check
+	AuthHandler       *handler.AuthHandler
+	PageHandler       *handler.PageHandler
+	RevisionHandler   *handler.RevisionHandler
+	SiteHandler       *handler.SiteHandler
+	UserHandler       *handler.UserHandler
+	ComponentHandler  *handler.ComponentHandler
+	RenderHandler     *handler.RenderHandler
+	SEOHandler        *handler.SEOHandler
+	ExportHandler     *handler.ExportHandler
+	BundleHandler     *handler.BundleHandler
+	TemplateHandler   *handler.TemplateHandler
+	TwoFactorHandler  *handler.TwoFactorHandler
+	APIKeyHandler     *handler.APIKeyHandler
+	SessionHandler    *handler.SessionHandler
+	JWKSHandler       *handler.JWKSHandler
+	SiteMemberHandler *handler.SiteMemberHandler
+	RoleHandler       *handler.RoleHandler
+	InvitationHandler *handler.InvitationHandler
+	MediaFileHandler  *handler.MediaFileHandler // nil without the local storage driver
+	ImageHandler      *handler.ImageHandler
+	APIKeys           middleware.APIKeyAuthenticator
+	Permissions       middleware.PermissionResolver
+	SiteAccess        middleware.SiteAccess
+	MediaKeys         middleware.MediaKeyResolver
+	Audit             middleware.AuditRecorder
+	SiteResolver      middleware.SiteHostResolver
+	JWTManager        *auth.JWTManager
+	Config            *config.Config
+	Logger            zerolog.Logger
+	DB                *sqlx.DB // for health check
 }
 
 // Setup configures and returns the Gin router
@@ -34,6 +55,15 @@ func Setup(deps *Dependencies) *gin.Engine {
 	}
 
 	r := gin.New()
+	// Forwarded headers are only believed from the configured proxies; gin
+	// would otherwise trust every client with them
+	proxies := make([]string, 0, len(deps.Config.Security.TrustedProxies))
+	for _, prefix := range deps.Config.Security.TrustedProxies {
+		proxies = append(proxies, prefix.String())
+	}
+	if err := r.SetTrustedProxies(proxies); err != nil {
+		deps.Logger.Fatal().Err(err).Msg("invalid trusted proxies")
+	}
 
 	// Global middleware
 	r.Use(middleware.RecoveryMiddleware())
@@ -79,6 +109,63 @@ func Setup(deps *Dependencies) *gin.Engine {
 		})
 	})
 
+	// Public keys for verifying access tokens outside this service
+	r.GET("/.well-known/jwks.json", deps.JWKSHandler.JWKS)
+
+	// Server-rendered HTML for published pages
+	render := r.Group("/render")
+	if deps.Config.RateLimit.Enabled {
+		render.Use(middleware.RateLimiter(deps.Config.RateLimit.Requests))
+	}
+	render.Use(middleware.SiteResolver(deps.SiteResolver, deps.Config.Security.TrustedProxies, deps.Logger))
+	{
+		render.GET("", deps.RenderHandler.RenderPage)
+		render.GET("/:slug", deps.RenderHandler.RenderPage)
+	}
+
+	// Per-site crawler files, resolved from the Host header
+	seo := r.Group("")
+	if deps.Config.RateLimit.Enabled {
+		seo.Use(middleware.RateLimiter(deps.Config.RateLimit.Requests))
+	}
+	seo.Use(middleware.SiteResolver(deps.SiteResolver, deps.Config.Security.TrustedProxies, deps.Logger))
+	{
+		seo.GET("/sitemap.xml", deps.SEOHandler.Sitemap)
+		seo.GET("/robots.txt", deps.SEOHandler.Robots)
+	}
+
+	// Uploaded files of the local storage driver. Not rate limited: a page
+	// loads many of them at once. Private files need content.view on the
+	// site of the media item they belong to.
+	if deps.MediaFileHandler != nil {
+		files := r.Group("/media")
+		if !deps.Config.Storage.LocalPublic {
+			files.Use(
+				middleware.AuthMiddleware(deps.JWTManager, deps.APIKeys),
+				middleware.RequireScope(domain.ScopeResourceMedia),
+				middleware.RequireSitePermission(deps.SiteAccess, domain.PermissionContentView,
+					middleware.SiteMediaKey("key", deps.SiteAccess, deps.MediaKeys)),
+			)
+		}
+		files.GET("/*key", deps.MediaFileHandler.ServeFile)
+		files.HEAD("/*key", deps.MediaFileHandler.ServeFile)
+	}
+
+	// Resized, cropped and converted images. Not rate limited either; the
+	// per-site size allowlist and the cache bound the work. They need the
+	// same access as the files they are made from.
+	images := r.Group("/img")
+	if deps.Config.Storage.Driver == "local" && !deps.Config.Storage.LocalPublic {
+		images.Use(
+			middleware.AuthMiddleware(deps.JWTManager, deps.APIKeys),
+			middleware.RequireScope(domain.ScopeResourceMedia),
+			middleware.RequireSitePermission(deps.SiteAccess, domain.PermissionContentView,
+				middleware.SiteParam("mediaId", domain.SiteResourceMedia)),
+		)
+	}
+	images.GET("/:mediaId", deps.ImageHandler.Transform)
+	images.HEAD("/:mediaId", deps.ImageHandler.Transform)
+
 	// API v1 routes
 	v1 := r.Group("/api/v1")
 
@@ -87,10 +174,12 @@ func Setup(deps *Dependencies) *gin.Engine {
 	if deps.Config.RateLimit.Enabled {
 		public.Use(middleware.RateLimiter(deps.Config.RateLimit.Requests))
 	}
+	public.Use(middleware.SiteResolver(deps.SiteResolver, deps.Config.Security.TrustedProxies, deps.Logger))
 	{
 		// Site info (for frontend to get site settings, SEO, etc.)
 		public.GET("/sites/:id", deps.SiteHandler.GetPublicSiteByID)
 		public.GET("/site/:slug", deps.SiteHandler.GetPublicSite)
+		public.GET("/site", deps.SiteHandler.GetPublicSite) // site resolved from Host
 
 		// Pages
 		public.GET("/pages/:slug", deps.PageHandler.GetPublicPage)
@@ -99,6 +188,7 @@ func Setup(deps *Dependencies) *gin.Engine {
 		// Navigation
 		public.GET("/navigation/:siteId/:identifier", deps.ComponentHandler.GetNavigation)
 		public.GET("/navigation/:siteId", deps.ComponentHandler.GetNavigation)
+		public.GET("/navigation", deps.ComponentHandler.GetNavigation) // site resolved from Host
 
 		// Public component endpoints (no auth required for landing page rendering)
 		public.GET("/features", deps.ComponentHandler.ListFeatures)
@@ -116,148 +206,302 @@ func Setup(deps *Dependencies) *gin.Engine {
 		authGroup.POST("/login", deps.AuthHandler.Login)
 		authGroup.POST("/logout", deps.AuthHandler.Logout)
 		authGroup.POST("/refresh", deps.AuthHandler.RefreshToken)
+		authGroup.POST("/forgot-password", deps.AuthHandler.ForgotPassword)
+		authGroup.POST("/reset-password", deps.AuthHandler.ResetPassword)
+		authGroup.POST("/accept-invite", deps.InvitationHandler.AcceptInvitation)
+		authGroup.POST("/2fa/verify", deps.AuthHandler.VerifyTwoFactor)
+		authGroup.POST("/2fa/challenge/setup", deps.AuthHandler.StartTwoFactorSetup)
+		authGroup.GET("/oidc/login", deps.AuthHandler.OIDCLogin)
+		authGroup.GET("/oidc/callback", deps.AuthHandler.OIDCCallback)
 
 		// Protected auth routes
 		authProtected := authGroup.Group("")
-		authProtected.Use(middleware.AuthMiddleware(deps.JWTManager))
+		authProtected.Use(middleware.AuthMiddleware(deps.JWTManager, nil))
 		{
 			authProtected.GET("/me", deps.AuthHandler.Me)
 			authProtected.POST("/change-password", deps.AuthHandler.ChangePassword)
+			authProtected.GET("/2fa", deps.TwoFactorHandler.Status)
+			authProtected.POST("/2fa/setup", deps.TwoFactorHandler.Setup)
+			authProtected.POST("/2fa/enable", deps.TwoFactorHandler.Enable)
+			authProtected.POST("/2fa/disable", deps.TwoFactorHandler.Disable)
+			authProtected.POST("/2fa/recovery-codes", deps.TwoFactorHandler.RegenerateRecoveryCodes)
+			authProtected.GET("/sessions", deps.SessionHandler.ListSessions)
+			authProtected.DELETE("/sessions", deps.SessionHandler.RevokeOtherSessions)
+			authProtected.DELETE("/sessions/:id", deps.SessionHandler.RevokeSession)
 		}
 	}
 
 	// ─── Admin Routes ─────────────────────────────────────────────────────────
 	admin := v1.Group("/admin")
-	admin.Use(middleware.AuthMiddleware(deps.JWTManager))
+	admin.Use(middleware.AuthMiddleware(deps.JWTManager, deps.APIKeys))
 	if deps.Config.RateLimit.Enabled {
 		admin.Use(middleware.RateLimiter(200))
 	}
+	admin.Use(middleware.AuditLogger(deps.Audit, deps.Logger))
 	{
-		// ── Sites (Admin+) ──────────────────────────────────────────────────
+		// Routes require a permission of the caller's role. Site content is
+		// checked on its site: roles with site.all reach every site, others
+		// only the sites they are members of.
+		perms := deps.Permissions
+		access := deps.SiteAccess
+		sitePerm := func(permission domain.Permission, refs ...middleware.SiteRef) gin.HandlerFunc {
+			return middleware.RequireSitePermission(access, permission, refs...)
+		}
+		siteManage := sitePerm(domain.PermissionSiteManage)
+		pageEdit := sitePerm(domain.PermissionPageEdit)
+		pagePublish := sitePerm(domain.PermissionPagePublish)
+		componentEdit := sitePerm(domain.PermissionComponentEdit)
+
+		// ── Sites (content.view, changes site.manage) ───────────────────────
 		sites := admin.Group("/sites")
-		sites.Use(middleware.RequireRole(domain.RoleAdmin))
+		sites.Use(
+			middleware.RequireScope(domain.ScopeResourceSites),
+			sitePerm(domain.PermissionContentView, middleware.SiteParam("id", domain.SiteResourceSite)),
+		)
 		{
+			siteCreate := middleware.RequirePermission(perms, domain.PermissionSiteCreate)
 			sites.GET("", deps.SiteHandler.ListSites)
-			sites.POST("", deps.SiteHandler.CreateSite)
+			sites.POST("", siteCreate, deps.SiteHandler.CreateSite)
+			sites.POST("/import", siteCreate, deps.BundleHandler.ImportBundle)
 			sites.GET("/:id", deps.SiteHandler.GetSite)
-			sites.PUT("/:id", deps.SiteHandler.UpdateSite)
-			sites.DELETE("/:id", middleware.RequireRole(domain.RoleSuperAdmin), deps.SiteHandler.DeleteSite)
+			sites.PUT("/:id", siteManage, deps.SiteHandler.UpdateSite)
+			sites.DELETE("/:id", sitePerm(domain.PermissionSiteDelete), deps.SiteHandler.DeleteSite)
 			sites.GET("/:id/settings", deps.SiteHandler.GetSettings)
-			sites.PUT("/:id/settings", deps.SiteHandler.BulkUpdateSettings)
-			sites.PUT("/:id/settings/:key", deps.SiteHandler.UpdateSetting)
+			sites.PUT("/:id/settings", siteManage, deps.SiteHandler.BulkUpdateSettings)
+			sites.PUT("/:id/settings/:key", siteManage, deps.SiteHandler.UpdateSetting)
+			sites.GET("/:id/domains", deps.SiteHandler.ListDomains)
+			sites.POST("/:id/domains", siteManage, deps.SiteHandler.AddDomain)
+			sites.DELETE("/:id/domains/:domainId", siteManage, deps.SiteHandler.RemoveDomain)
+			sites.POST("/:id/export", siteManage, deps.ExportHandler.ExportSite)
+			sites.GET("/:id/bundle", siteManage, deps.BundleHandler.ExportBundle)
+			sites.POST("/:id/duplicate", siteCreate, deps.BundleHandler.DuplicateSite)
+			sites.GET("/:id/members", siteManage, deps.SiteMemberHandler.ListMembers)
+			sites.PUT("/:id/members/:userId", siteManage, deps.SiteMemberHandler.SetMember)
+			sites.DELETE("/:id/members/:userId", siteManage, deps.SiteMemberHandler.RemoveMember)
 		}
 
-		// ── Pages (Editor+) ─────────────────────────────────────────────────
+		// ── Pages (content.view, changes page.*) ────────────────────────────
 		pages := admin.Group("/pages")
-		pages.Use(middleware.RequireRole(domain.RoleEditor))
+		pages.Use(
+			middleware.RequireScope(domain.ScopeResourcePages),
+			sitePerm(domain.PermissionContentView, middleware.SiteParam("id", domain.SiteResourcePage)),
+		)
 		{
 			pages.GET("", deps.PageHandler.ListPages)
-			pages.POST("", deps.PageHandler.CreatePage)
+			pages.POST("", pageEdit, deps.PageHandler.CreatePage)
+			pages.POST("/from-template/:templateId", pageEdit, deps.TemplateHandler.CreatePageFromTemplate)
 			pages.GET("/:id", deps.PageHandler.GetPage)
-			pages.PUT("/:id", deps.PageHandler.UpdatePage)
-			pages.DELETE("/:id", middleware.RequireRole(domain.RoleAdmin), deps.PageHandler.DeletePage)
-			pages.PATCH("/:id/publish", deps.PageHandler.PublishPage)
-			pages.PATCH("/:id/unpublish", deps.PageHandler.UnpublishPage)
+			pages.PUT("/:id", pageEdit, deps.PageHandler.UpdatePage)
+			pages.DELETE("/:id", sitePerm(domain.PermissionPageDelete), deps.PageHandler.DeletePage)
+			pages.POST("/:id/duplicate", pageEdit, deps.BundleHandler.DuplicatePage)
+			pages.PATCH("/:id/publish", pagePublish, deps.PageHandler.PublishPage)
+			pages.PATCH("/:id/unpublish", pagePublish, deps.PageHandler.UnpublishPage)
+			pages.PUT("/:id/schedule", pagePublish, deps.PageHandler.SchedulePage)
+			pages.DELETE("/:id/schedule", pagePublish, deps.PageHandler.ClearSchedule)
 			pages.GET("/:id/sections", deps.PageHandler.ListSections)
-			pages.POST("/:id/sections", deps.PageHandler.CreateSection)
+			pages.POST("/:id/sections", pageEdit, deps.PageHandler.CreateSection)
+			pages.GET("/:id/revisions", deps.RevisionHandler.ListRevisions)
+			pages.GET("/:id/revisions/diff", deps.RevisionHandler.DiffRevisions)
+			pages.GET("/:id/revisions/:rev", deps.RevisionHandler.GetRevision)
+			pages.POST("/:id/revisions/:rev/restore", pageEdit, deps.RevisionHandler.RestoreRevision)
 		}
 
-		// ── Sections (Editor+) ──────────────────────────────────────────────
+		// ── Sections (content.view, changes page.edit) ──────────────────────
 		sections := admin.Group("/sections")
-		sections.Use(middleware.RequireRole(domain.RoleEditor))
+		sections.Use(
+			middleware.RequireScope(domain.ScopeResourcePages),
+			sitePerm(domain.PermissionContentView,
+				middleware.SiteParam("id", domain.SiteResourceSection),
+				middleware.SiteJSONList("sections", "id", domain.SiteResourceSection),
+			),
+		)
 		{
-			sections.PUT("/:id", deps.PageHandler.UpdateSection)
-			sections.DELETE("/:id", deps.PageHandler.DeleteSection)
-			sections.PATCH("/reorder", deps.PageHandler.ReorderSections)
+			sections.PUT("/:id", pageEdit, deps.PageHandler.UpdateSection)
+			sections.DELETE("/:id", pageEdit, deps.PageHandler.DeleteSection)
+			sections.PATCH("/reorder", pageEdit, deps.PageHandler.ReorderSections)
 			sections.GET("/:id/contents", deps.PageHandler.ListContents)
-			sections.POST("/:id/contents", deps.PageHandler.UpsertContent)
-			sections.POST("/:id/contents/bulk", deps.PageHandler.BulkUpsertContents)
+			sections.POST("/:id/contents", pageEdit, deps.PageHandler.UpsertContent)
+			sections.POST("/:id/contents/bulk", pageEdit, deps.PageHandler.BulkUpsertContents)
 		}
 
-		// ── Contents (Editor+) ──────────────────────────────────────────────
+		// ── Contents (page.edit) ────────────────────────────────────────────
 		contents := admin.Group("/contents")
-		contents.Use(middleware.RequireRole(domain.RoleEditor))
+		contents.Use(
+			middleware.RequireScope(domain.ScopeResourcePages),
+			sitePerm(domain.PermissionPageEdit, middleware.SiteParam("id", domain.SiteResourceContent)),
+		)
 		{
 			contents.DELETE("/:id", deps.PageHandler.DeleteContent)
 		}
 
-		// ── Features (Editor+) ──────────────────────────────────────────────
+		// ── Templates (content.view, changes template.manage) ───────────────
+		templates := admin.Group("/templates")
+		templates.Use(
+			middleware.RequireScope(domain.ScopeResourceTemplates),
+			middleware.RequirePermission(perms, domain.PermissionContentView),
+		)
+		{
+			templateManage := middleware.RequirePermission(perms, domain.PermissionTemplateManage)
+			templates.GET("", deps.TemplateHandler.ListTemplates)
+			templates.GET("/presets", deps.TemplateHandler.ListPresets)
+			templates.GET("/:id", deps.TemplateHandler.GetTemplate)
+			templates.POST("", templateManage, deps.TemplateHandler.CreateTemplate)
+			templates.PUT("/:id", templateManage, deps.TemplateHandler.UpdateTemplate)
+			templates.DELETE("/:id", templateManage, deps.TemplateHandler.DeleteTemplate)
+		}
+
+		// ── Features (content.view, changes component.edit) ─────────────────
 		features := admin.Group("/features")
-		features.Use(middleware.RequireRole(domain.RoleEditor))
+		features.Use(
+			middleware.RequireScope(domain.ScopeResourceComponents),
+			sitePerm(
+				domain.PermissionContentView,
+				middleware.SiteParam("id", domain.SiteResourceFeature),
+				middleware.SiteJSONField("section_id", domain.SiteResourceSection),
+			),
+		)
 		{
 			features.GET("", deps.ComponentHandler.ListFeatures)
-			features.POST("", deps.ComponentHandler.CreateFeature)
-			features.PUT("/:id", deps.ComponentHandler.UpdateFeature)
-			features.DELETE("/:id", deps.ComponentHandler.DeleteFeature)
+			features.POST("", componentEdit, deps.ComponentHandler.CreateFeature)
+			features.PUT("/:id", componentEdit, deps.ComponentHandler.UpdateFeature)
+			features.DELETE("/:id", componentEdit, deps.ComponentHandler.DeleteFeature)
 		}
 
-		// ── Testimonials (Editor+) ──────────────────────────────────────────
+		// ── Testimonials (content.view, changes component.edit) ─────────────
 		testimonials := admin.Group("/testimonials")
-		testimonials.Use(middleware.RequireRole(domain.RoleEditor))
+		testimonials.Use(
+			middleware.RequireScope(domain.ScopeResourceComponents),
+			sitePerm(
+				domain.PermissionContentView,
+				middleware.SiteParam("id", domain.SiteResourceTestimonial),
+				middleware.SiteJSONField("section_id", domain.SiteResourceSection),
+			),
+		)
 		{
 			testimonials.GET("", deps.ComponentHandler.ListTestimonials)
-			testimonials.POST("", deps.ComponentHandler.CreateTestimonial)
-			testimonials.PUT("/:id", deps.ComponentHandler.UpdateTestimonial)
-			testimonials.DELETE("/:id", deps.ComponentHandler.DeleteTestimonial)
+			testimonials.POST("", componentEdit, deps.ComponentHandler.CreateTestimonial)
+			testimonials.PUT("/:id", componentEdit, deps.ComponentHandler.UpdateTestimonial)
+			testimonials.DELETE("/:id", componentEdit, deps.ComponentHandler.DeleteTestimonial)
 		}
 
-		// ── Pricing (Editor+) ───────────────────────────────────────────────
+		// ── Pricing (content.view, changes component.edit) ──────────────────
 		pricing := admin.Group("/pricing")
-		pricing.Use(middleware.RequireRole(domain.RoleEditor))
+		pricing.Use(
+			middleware.RequireScope(domain.ScopeResourceComponents),
+			sitePerm(
+				domain.PermissionContentView,
+				middleware.SiteParam("id", domain.SiteResourcePricingPlan),
+				middleware.SiteJSONField("section_id", domain.SiteResourceSection),
+			),
+		)
 		{
 			pricing.GET("", deps.ComponentHandler.ListPricingPlans)
-			pricing.POST("", deps.ComponentHandler.CreatePricingPlan)
-			pricing.PUT("/:id", deps.ComponentHandler.UpdatePricingPlan)
-			pricing.DELETE("/:id", deps.ComponentHandler.DeletePricingPlan)
+			pricing.POST("", componentEdit, deps.ComponentHandler.CreatePricingPlan)
+			pricing.PUT("/:id", componentEdit, deps.ComponentHandler.UpdatePricingPlan)
+			pricing.DELETE("/:id", componentEdit, deps.ComponentHandler.DeletePricingPlan)
 		}
 
-		// ── FAQs (Editor+) ──────────────────────────────────────────────────
+		// ── FAQs (content.view, changes component.edit) ─────────────────────
 		faqs := admin.Group("/faqs")
-		faqs.Use(middleware.RequireRole(domain.RoleEditor))
+		faqs.Use(
+			middleware.RequireScope(domain.ScopeResourceComponents),
+			sitePerm(
+				domain.PermissionContentView,
+				middleware.SiteParam("id", domain.SiteResourceFAQ),
+				middleware.SiteJSONField("section_id", domain.SiteResourceSection),
+			),
+		)
 		{
 			faqs.GET("", deps.ComponentHandler.ListFAQs)
-			faqs.POST("", deps.ComponentHandler.CreateFAQ)
-			faqs.PUT("/:id", deps.ComponentHandler.UpdateFAQ)
-			faqs.DELETE("/:id", deps.ComponentHandler.DeleteFAQ)
+			faqs.POST("", componentEdit, deps.ComponentHandler.CreateFAQ)
+			faqs.PUT("/:id", componentEdit, deps.ComponentHandler.UpdateFAQ)
+			faqs.DELETE("/:id", componentEdit, deps.ComponentHandler.DeleteFAQ)
 		}
 
-		// ── Navigation (Editor+) ────────────────────────────────────────────
+		// ── Navigation (content.view, changes component.edit) ───────────────
+		// :id is a menu or an item depending on the route, so each route
+		// names its own reference
 		navigation := admin.Group("/navigation")
-		navigation.Use(middleware.RequireRole(domain.RoleEditor))
+		navigation.Use(middleware.RequireScope(domain.ScopeResourceComponents))
 		{
-			navigation.GET("", deps.ComponentHandler.ListNavigation)
-			navigation.POST("", deps.ComponentHandler.CreateNavigationMenu)
-			navigation.PUT("/:id", deps.ComponentHandler.UpdateNavigationMenu)
-			navigation.DELETE("/:id", deps.ComponentHandler.DeleteNavigationMenu)
-			navigation.POST("/:menuId/items", deps.ComponentHandler.CreateNavigationItem)
-			navigation.PUT("/items/:id", deps.ComponentHandler.UpdateNavigationItem)
-			navigation.DELETE("/items/:id", deps.ComponentHandler.DeleteNavigationItem)
+			navMenu := sitePerm(domain.PermissionComponentEdit, middleware.SiteParam("id", domain.SiteResourceNavigationMenu))
+			navItem := sitePerm(domain.PermissionComponentEdit, middleware.SiteParam("id", domain.SiteResourceNavigationItem))
+			navigation.GET("", sitePerm(domain.PermissionContentView), deps.ComponentHandler.ListNavigation)
+			navigation.POST("", componentEdit, deps.ComponentHandler.CreateNavigationMenu)
+			navigation.PUT("/:id", navMenu, deps.ComponentHandler.UpdateNavigationMenu)
+			navigation.DELETE("/:id", navMenu, deps.ComponentHandler.DeleteNavigationMenu)
+			navigation.POST("/:menuId/items",
+				sitePerm(domain.PermissionComponentEdit, middleware.SiteParam("menuId", domain.SiteResourceNavigationMenu)),
+				deps.ComponentHandler.CreateNavigationItem)
+			navigation.PUT("/items/:id", navItem, deps.ComponentHandler.UpdateNavigationItem)
+			navigation.DELETE("/items/:id", navItem, deps.ComponentHandler.DeleteNavigationItem)
 		}
 
-		// ── Media (Editor+) ─────────────────────────────────────────────────
+		// ── Media (content.view, changes media.*) ───────────────────────────
 		media := admin.Group("/media")
-		media.Use(middleware.RequireRole(domain.RoleEditor))
+		media.Use(
+			middleware.RequireScope(domain.ScopeResourceMedia),
+			sitePerm(domain.PermissionContentView, middleware.SiteParam("id", domain.SiteResourceMedia)),
+		)
 		{
+			mediaUpload := sitePerm(domain.PermissionMediaUpload)
 			media.GET("", deps.ComponentHandler.ListMedia)
-			media.POST("/upload", deps.ComponentHandler.UploadMedia)
-			media.PUT("/:id", deps.ComponentHandler.UpdateMedia)
-			media.DELETE("/:id", deps.ComponentHandler.DeleteMedia)
+			media.POST("/upload", mediaUpload, deps.ComponentHandler.UploadMedia)
+			media.PUT("/:id", mediaUpload, deps.ComponentHandler.UpdateMedia)
+			media.DELETE("/:id", sitePerm(domain.PermissionMediaDelete), deps.ComponentHandler.DeleteMedia)
 		}
 
-		// ── Users (Admin+) ──────────────────────────────────────────────────
+		// ── Users (user.manage) ─────────────────────────────────────────────
 		users := admin.Group("/users")
-		users.Use(middleware.RequireRole(domain.RoleAdmin))
+		users.Use(middleware.RequirePermission(perms, domain.PermissionUserManage))
 		{
 			users.GET("", deps.UserHandler.ListUsers)
 			users.POST("", deps.UserHandler.CreateUser)
+			users.POST("/invite", deps.InvitationHandler.InviteUser)
+			users.GET("/invitations", deps.InvitationHandler.ListInvitations)
+			users.POST("/invitations/:id/resend", deps.InvitationHandler.ResendInvitation)
+			users.DELETE("/invitations/:id", deps.InvitationHandler.RevokeInvitation)
 			users.GET("/:id", deps.UserHandler.GetUser)
 			users.PUT("/:id", deps.UserHandler.UpdateUser)
-			users.DELETE("/:id", middleware.RequireRole(domain.RoleSuperAdmin), deps.UserHandler.DeleteUser)
+			users.DELETE("/:id", middleware.RequirePermission(perms, domain.PermissionUserDelete), deps.UserHandler.DeleteUser)
+			users.GET("/:id/sessions", deps.SessionHandler.ListUserSessions)
+			users.DELETE("/:id/sessions", deps.SessionHandler.RevokeUserSessions)
+			users.DELETE("/:id/sessions/:sessionId", deps.SessionHandler.RevokeUserSession)
+		}
+
+		// ── Roles (user.manage, changes role.manage) ────────────────────────
+		roles := admin.Group("/roles")
+		roles.Use(middleware.RequirePermission(perms, domain.PermissionUserManage))
+		{
+			roleManage := middleware.RequirePermission(perms, domain.PermissionRoleManage)
+			roles.GET("", deps.RoleHandler.ListRoles)
+			roles.GET("/permissions", deps.RoleHandler.ListPermissions)
+			roles.GET("/:id", deps.RoleHandler.GetRole)
+			roles.POST("", roleManage, deps.RoleHandler.CreateRole)
+			roles.PUT("/:id", roleManage, deps.RoleHandler.UpdateRole)
+			roles.DELETE("/:id", roleManage, deps.RoleHandler.DeleteRole)
+		}
+
+		// ── Two-Factor Policies (security.manage) ───────────────────────────
+		twoFactor := admin.Group("/2fa")
+		twoFactor.Use(middleware.RequirePermission(perms, domain.PermissionSecurityManage))
+		{
+			twoFactor.GET("/policies", deps.TwoFactorHandler.ListPolicies)
+			twoFactor.PUT("/policies/:role", deps.TwoFactorHandler.SetPolicy)
+		}
+
+		// ── API Keys (apikey.manage) ────────────────────────────────────────
+		apiKeys := admin.Group("/api-keys")
+		apiKeys.Use(middleware.RequirePermission(perms, domain.PermissionAPIKeyManage))
+		{
+			apiKeys.GET("", deps.APIKeyHandler.ListAPIKeys)
+			apiKeys.POST("", deps.APIKeyHandler.CreateAPIKey)
+			apiKeys.DELETE("/:id", deps.APIKeyHandler.RevokeAPIKey)
 		}
 
-		// ── Audit Logs (Admin+) ─────────────────────────────────────────────
+		// ── Audit Logs (audit.view) ─────────────────────────────────────────
 		auditLogs := admin.Group("/audit-logs")
-		auditLogs.Use(middleware.RequireRole(domain.RoleAdmin))
+		auditLogs.Use(middleware.RequirePermission(perms, domain.PermissionAuditView))
 		{
 			auditLogs.GET("", deps.ComponentHandler.ListAuditLogs)
 		}
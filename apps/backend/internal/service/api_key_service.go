@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in clear to recognise it
+const apiKeyPrefixLength = 12
+
+// APIKeyService defines the interface for API key management and authentication
+type APIKeyService interface {
+	ListAPIKeys(ctx context.Context, filter domain.APIKeyFilter) ([]*domain.APIKey, error)
+	// CreateAPIKey stores a new key and returns it with its secret, which is
+	// not retrievable afterwards
+	CreateAPIKey(ctx context.Context, input domain.CreateAPIKeyInput, userID uuid.UUID) (*domain.CreatedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	// AuthenticateAPIKey resolves a key presented by a client. Unknown, revoked
+	// and expired keys, and keys of inactive users, return ErrInvalidToken.
+	AuthenticateAPIKey(ctx context.Context, key, ip string) (*domain.APIKeyPrincipal, error)
+}
+
+// apiKeyService implements APIKeyService
+type apiKeyService struct {
+	apiKeyRepo  repository.APIKeyRepository
+	userRepo    repository.UserRepository
+	siteRepo    repository.SiteRepository
+	roleService RoleService
+	logger      zerolog.Logger
+}
+
+// NewAPIKeyService creates a new APIKeyService
+func NewAPIKeyService(
+	apiKeyRepo repository.APIKeyRepository,
+	userRepo repository.UserRepository,
+	siteRepo repository.SiteRepository,
+	roleService RoleService,
+	logger zerolog.Logger,
+) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		userRepo:    userRepo,
+		siteRepo:    siteRepo,
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// ListAPIKeys retrieves API keys filtered by owner or site
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, filter domain.APIKeyFilter) ([]*domain.APIKey, error) {
+	keys, err := s.apiKeyRepo.FindAll(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("apiKeyService.ListAPIKeys: %w", err)
+	}
+	return keys, nil
+}
+
+// CreateAPIKey validates the input and stores the hash of a new key. A caller
+// cannot create a key for a user whose role has permissions they lack.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, input domain.CreateAPIKeyInput, userID uuid.UUID) (*domain.CreatedAPIKey, error) {
+	caller, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("apiKeyService.CreateAPIKey caller: %w", err)
+	}
+
+	owner := caller
+	if input.UserID != nil && *input.UserID != caller.ID {
+		owner, err = s.userRepo.FindByID(ctx, *input.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return nil, &domain.APIKeyError{Problems: []string{"user_id: user not found"}}
+			}
+			return nil, fmt.Errorf("apiKeyService.CreateAPIKey owner: %w", err)
+		}
+		if err := s.roleService.CheckAssign(ctx, caller.Role, owner.Role); err != nil {
+			if errors.Is(err, domain.ErrForbidden) {
+				return nil, domain.ErrForbidden
+			}
+			return nil, fmt.Errorf("apiKeyService.CreateAPIKey role: %w", err)
+		}
+	}
+
+	var problems []string
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 255 {
+		problems = append(problems, "name: must be between 1 and 255 characters")
+	}
+	scopes, scopeProblems := normalizeScopes(input.Scopes)
+	problems = append(problems, scopeProblems...)
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		problems = append(problems, "expires_at: must be in the future")
+	}
+	if input.SiteID != nil {
+		if _, err := s.siteRepo.FindByID(ctx, *input.SiteID); err != nil {
+			if !errors.Is(err, domain.ErrNotFound) {
+				return nil, fmt.Errorf("apiKeyService.CreateAPIKey site: %w", err)
+			}
+			problems = append(problems, "site_id: site not found")
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &domain.APIKeyError{Problems: problems}
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("apiKeyService.CreateAPIKey generate: %w", err)
+	}
+	secret := domain.APIKeyPrefix + token
+
+	key := &domain.APIKey{
+		ID:        uuid.New(),
+		UserID:    owner.ID,
+		SiteID:    input.SiteID,
+		Name:      name,
+		KeyPrefix: secret[:apiKeyPrefixLength],
+		KeyHash:   auth.HashToken(secret),
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedBy: &caller.ID,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("apiKeyService.CreateAPIKey: %w", err)
+	}
+
+	s.logger.Info().
+		Str("key_id", key.ID.String()).
+		Str("owner_id", owner.ID.String()).
+		Strs("scopes", key.Scopes).
+		Msg("API key created")
+
+	return &domain.CreatedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+// RevokeAPIKey revokes an active key
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if err := s.apiKeyRepo.Revoke(ctx, id); err != nil {
+		return fmt.Errorf("apiKeyService.RevokeAPIKey: %w", err)
+	}
+	s.logger.Info().Str("key_id", id.String()).Msg("API key revoked")
+	return nil
+}
+
+// AuthenticateAPIKey looks a key up by its hash and loads its owner
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key, ip string) (*domain.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(key, domain.APIKeyPrefix) {
+		return nil, domain.ErrInvalidToken
+	}
+
+	apiKey, err := s.apiKeyRepo.FindByHash(ctx, auth.HashToken(key))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("apiKeyService.AuthenticateAPIKey: %w", err)
+	}
+	if !apiKey.IsValid() {
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, apiKey.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("apiKeyService.AuthenticateAPIKey user: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, domain.ErrInvalidToken
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, apiKey.ID, ip); err != nil {
+		s.logger.Warn().Err(err).Str("key_id", apiKey.ID.String()).Msg("failed to record API key use")
+	}
+
+	return &domain.APIKeyPrincipal{Key: apiKey, User: user}, nil
+}
+
+// normalizeScopes trims, de-duplicates and validates requested scopes
+func normalizeScopes(requested []string) (domain.StringArray, []string) {
+	var problems []string
+	seen := make(map[string]bool, len(requested))
+	scopes := domain.StringArray{}
+	for _, raw := range requested {
+		scope := strings.ToLower(strings.TrimSpace(raw))
+		if !domain.APIKeyScope(scope).IsValid() {
+			problems = append(problems, fmt.Sprintf("scopes: unknown scope %q", raw))
+			continue
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(requested) == 0 {
+		problems = append(problems, "scopes: at least one scope is required")
+	}
+	return scopes, problems
+}
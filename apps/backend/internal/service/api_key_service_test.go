@@ -0,0 +1,212 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock APIKeyRepository ────────────────────────────────────────────────────
+
+type mockAPIKeyRepository struct {
+	keys    map[uuid.UUID]*domain.APIKey
+	touches int
+}
+
+func newMockAPIKeyRepository() *mockAPIKeyRepository {
+	return &mockAPIKeyRepository{keys: make(map[uuid.UUID]*domain.APIKey)}
+}
+
+func (m *mockAPIKeyRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	if k, ok := m.keys[id]; ok {
+		return k, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockAPIKeyRepository) FindByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	for _, k := range m.keys {
+		if k.KeyHash == keyHash {
+			return k, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockAPIKeyRepository) FindAll(ctx context.Context, filter domain.APIKeyFilter) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	for _, k := range m.keys {
+		if filter.UserID != nil && k.UserID != *filter.UserID {
+			continue
+		}
+		if filter.SiteID != nil && (k.SiteID == nil || *k.SiteID != *filter.SiteID) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	key.CreatedAt = time.Now()
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *mockAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	k, ok := m.keys[id]
+	if !ok || k.RevokedAt != nil {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return nil
+}
+
+func (m *mockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, ip string) error {
+	m.touches++
+	now := time.Now()
+	m.keys[id].LastUsedAt = &now
+	m.keys[id].LastUsedIP = &ip
+	return nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func setupAPIKeyTest(role domain.UserRole) (service.APIKeyService, *mockAPIKeyRepository, *mockUserRepository, *domain.User) {
+	users := newMockUserRepository()
+	caller := createTestUser("admin@test.com", "password123", role)
+	users.users[caller.Email] = caller
+	keys := newMockAPIKeyRepository()
+	roles, _ := newTestRoleService()
+	svc := service.NewAPIKeyService(keys, users, newMockSiteRepository(), roles, zerolog.Nop())
+	return svc, keys, users, caller
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	svc, keys, _, caller := setupAPIKeyTest(domain.RoleAdmin)
+	ctx := context.Background()
+
+	created, err := svc.CreateAPIKey(ctx, domain.CreateAPIKeyInput{
+		Name:   "CI deploy",
+		Scopes: []string{"pages:write", " Media:Read ", "pages:write"},
+	}, caller.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(created.Key, domain.APIKeyPrefix) {
+		t.Errorf("expected key to start with %q, got %q", domain.APIKeyPrefix, created.Key)
+	}
+	if created.KeyHash == created.Key || strings.Contains(created.KeyHash, created.Key) {
+		t.Error("expected only a hash of the key to be stored")
+	}
+	if len(created.Scopes) != 2 {
+		t.Errorf("expected duplicate scopes to be dropped, got %v", created.Scopes)
+	}
+	if created.UserID != caller.ID {
+		t.Errorf("expected key to be owned by the caller")
+	}
+
+	principal, err := svc.AuthenticateAPIKey(ctx, created.Key, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("expected key to authenticate, got %v", err)
+	}
+	if principal.User.ID != caller.ID {
+		t.Errorf("expected principal to be the owner")
+	}
+	if !principal.Key.HasScope(domain.ReadScope(domain.ScopeResourcePages)) {
+		t.Error("expected pages:write to imply pages:read")
+	}
+	if principal.Key.HasScope(domain.WriteScope(domain.ScopeResourceMedia)) {
+		t.Error("expected media:read not to grant media:write")
+	}
+	if keys.touches != 1 || principal.Key.LastUsedAt == nil {
+		t.Error("expected last use to be recorded")
+	}
+
+	if _, err := svc.AuthenticateAPIKey(ctx, created.Key+"x", "10.0.0.1"); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for an unknown key, got %v", err)
+	}
+}
+
+func TestAPIKeyService_AuthenticateRejectsRevokedExpiredAndInactive(t *testing.T) {
+	svc, keys, _, caller := setupAPIKeyTest(domain.RoleAdmin)
+	ctx := context.Background()
+
+	create := func() *domain.CreatedAPIKey {
+		created, err := svc.CreateAPIKey(ctx, domain.CreateAPIKeyInput{Name: "key", Scopes: []string{"sites:read"}}, caller.ID)
+		if err != nil {
+			t.Fatalf("failed to create key: %v", err)
+		}
+		return created
+	}
+
+	revoked := create()
+	if err := svc.RevokeAPIKey(ctx, revoked.ID); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	if err := svc.RevokeAPIKey(ctx, revoked.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound revoking twice, got %v", err)
+	}
+	if _, err := svc.AuthenticateAPIKey(ctx, revoked.Key, ""); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected revoked key to be rejected, got %v", err)
+	}
+
+	expired := create()
+	past := time.Now().Add(-time.Minute)
+	keys.keys[expired.ID].ExpiresAt = &past
+	if _, err := svc.AuthenticateAPIKey(ctx, expired.Key, ""); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected expired key to be rejected, got %v", err)
+	}
+
+	active := create()
+	caller.Status = domain.StatusSuspended
+	if _, err := svc.AuthenticateAPIKey(ctx, active.Key, ""); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected key of a suspended user to be rejected, got %v", err)
+	}
+}
+
+func TestAPIKeyService_CreateValidation(t *testing.T) {
+	svc, _, _, caller := setupAPIKeyTest(domain.RoleAdmin)
+	past := time.Now().Add(-time.Hour)
+	missingSite := uuid.New()
+
+	_, err := svc.CreateAPIKey(context.Background(), domain.CreateAPIKeyInput{
+		Name:      " ",
+		SiteID:    &missingSite,
+		Scopes:    []string{"pages:read", "users:write", "pages:delete"},
+		ExpiresAt: &past,
+	}, caller.ID)
+
+	var keyErr *domain.APIKeyError
+	if !errors.As(err, &keyErr) || !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("expected APIKeyError, got %v", err)
+	}
+	if len(keyErr.Problems) != 5 {
+		t.Errorf("expected 5 problems, got %v", keyErr.Problems)
+	}
+}
+
+func TestAPIKeyService_CreateForHigherRoleForbidden(t *testing.T) {
+	svc, _, users, caller := setupAPIKeyTest(domain.RoleAdmin)
+	owner := createTestUser("root@test.com", "password123", domain.RoleSuperAdmin)
+	users.users[owner.Email] = owner
+
+	_, err := svc.CreateAPIKey(context.Background(), domain.CreateAPIKeyInput{
+		Name:   "escalation",
+		UserID: &owner.ID,
+		Scopes: []string{"sites:write"},
+	}, caller.ID)
+	if !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected ErrForbidden, got %v", err)
+	}
+}
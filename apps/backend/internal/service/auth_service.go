@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/mailer"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -16,28 +20,107 @@ import (
 const (
 	maxFailedAttempts = 5
 	lockDuration      = 15 * time.Minute
+
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt ignores anything longer
 )
 
 // AuthService defines the interface for authentication operations
 type AuthService interface {
 	Login(ctx context.Context, input domain.LoginInput, ipAddress, userAgent string) (*domain.User, *domain.AuthTokens, error)
 	Logout(ctx context.Context, refreshToken string) error
-	RefreshTokens(ctx context.Context, refreshToken string) (*domain.AuthTokens, error)
+	// RefreshTokens rotates a refresh token. Presenting a token that was
+	// already rotated revokes its whole family and returns ErrTokenReused.
+	RefreshTokens(ctx context.Context, refreshToken, ipAddress, userAgent string) (*domain.AuthTokens, error)
 	ChangePassword(ctx context.Context, userID uuid.UUID, input domain.ChangePasswordInput) error
+	// ForgotPassword emails a single-use reset link. It succeeds whether or
+	// not the email belongs to an account.
+	ForgotPassword(ctx context.Context, input domain.ForgotPasswordInput) error
+	// ResetPassword sets a new password with a reset token and signs the user
+	// out everywhere
+	ResetPassword(ctx context.Context, input domain.ResetPasswordInput) error
+	// CompleteTwoFactorLogin answers a login challenge with a code and issues
+	// tokens. When the challenge required enrollment, the code confirms it and
+	// the new recovery codes are returned.
+	CompleteTwoFactorLogin(ctx context.Context, input domain.TwoFactorLoginInput, ipAddress, userAgent string) (*domain.User, *domain.AuthTokens, []string, error)
+	// StartTwoFactorSetup begins the enrollment demanded by a login challenge
+	StartTwoFactorSetup(ctx context.Context, input domain.TwoFactorChallengeInput) (*domain.TOTPSetup, error)
+	// StartOIDCLogin begins a single sign-on login at the identity provider.
+	// It returns ErrSSODisabled when single sign-on is not configured.
+	StartOIDCLogin(ctx context.Context) (*domain.OIDCLoginStart, error)
+	// CompleteOIDCLogin finishes a single sign-on login from the provider
+	// callback and issues tokens, or returns a domain.TwoFactorRequiredError
+	// like Login
+	CompleteOIDCLogin(ctx context.Context, input domain.OIDCCallbackInput, ipAddress, userAgent string) (*domain.User, *domain.AuthTokens, error)
+}
+
+// AuthOptions holds the settings of AuthService
+type AuthOptions struct {
+	// ResetURL is the page reset links point to, with ?token= appended
+	ResetURL    string
+	ResetExpiry time.Duration
+	// ChallengeExpiry is how long a two-factor login challenge stays valid
+	ChallengeExpiry time.Duration
+	// SessionLifetime caps a token family from login, however often it is
+	// refreshed; 0 means only the sliding refresh expiry applies
+	SessionLifetime time.Duration
+	// ReuseGrace is how long after rotation a token may be presented again
+	// without counting as reuse, so concurrent refreshes from one client do
+	// not revoke the session
+	ReuseGrace time.Duration
+	// OnTokenReuse is called after a family was revoked for reuse
+	OnTokenReuse TokenReuseHook
+	// OIDC enables single sign-on; nil disables it
+	OIDC *OIDCOptions
+	// BcryptCost is the cost of new password hashes
+	BcryptCost int
+}
+
+// TokenReuseHook notifies a user that one of their refresh tokens was reused
+type TokenReuseHook func(ctx context.Context, user *domain.User, event *domain.TokenReuseEvent)
+
+// AuditRecorder stores audit log entries. ComponentRepository implements it.
+type AuditRecorder interface {
+	CreateAuditLog(ctx context.Context, log *domain.AuditLog) error
+}
+
+// auditActionTokenReuse is the audit_action of a refresh token reuse
+const auditActionTokenReuse = "token_reuse"
+
+// tokenFamily identifies the family a new refresh token joins
+type tokenFamily struct {
+	id        uuid.UUID
+	expiresAt *time.Time
 }
 
 // authService implements AuthService
 type authService struct {
 	userRepo   repository.UserRepository
 	jwtManager *auth.JWTManager
+	twoFactor  TwoFactorService
+	mailer     mailer.Mailer
+	audit      AuditRecorder
+	opts       AuthOptions
 	logger     zerolog.Logger
 }
 
 // NewAuthService creates a new authService
-func NewAuthService(userRepo repository.UserRepository, jwtManager *auth.JWTManager, logger zerolog.Logger) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	jwtManager *auth.JWTManager,
+	twoFactor TwoFactorService,
+	mail mailer.Mailer,
+	audit AuditRecorder,
+	opts AuthOptions,
+	logger zerolog.Logger,
+) AuthService {
 	return &authService{
 		userRepo:   userRepo,
 		jwtManager: jwtManager,
+		twoFactor:  twoFactor,
+		mailer:     mail,
+		audit:      audit,
+		opts:       opts,
 		logger:     logger,
 	}
 }
@@ -72,6 +155,11 @@ func (s *authService) Login(ctx context.Context, input domain.LoginInput, ipAddr
 		return nil, nil, domain.ErrAccountLocked
 	}
 
+	// Users restricted to single sign-on have no usable password
+	if user.PasswordLoginDisabled {
+		return nil, nil, domain.ErrPasswordLoginDisabled
+	}
+
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
 		// Increment failed attempts
@@ -94,6 +182,116 @@ func (s *authService) Login(ctx context.Context, input domain.LoginInput, ipAddr
 		return nil, nil, domain.ErrInvalidCredentials
 	}
 
+	// Ask for a second factor before resetting failed attempts, so wrong
+	// codes keep counting towards the lockout
+	if err := s.twoFactorChallenge(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.completeLogin(ctx, user, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("authService.Login: %w", err)
+	}
+	return user, tokens, nil
+}
+
+// twoFactorChallenge returns a domain.TwoFactorRequiredError when the user
+// has two-factor authentication enabled or their role requires it, and nil
+// when the login may complete
+func (s *authService) twoFactorChallenge(ctx context.Context, user *domain.User) error {
+	enabled, required, err := s.twoFactor.Requirement(ctx, user)
+	if err != nil {
+		return fmt.Errorf("authService.twoFactorChallenge: %w", err)
+	}
+	if !enabled && !required {
+		return nil
+	}
+	token, expiresAt, err := s.jwtManager.GenerateChallengeToken(user.ID, !enabled, s.opts.ChallengeExpiry)
+	if err != nil {
+		return fmt.Errorf("authService.twoFactorChallenge: %w", err)
+	}
+	return &domain.TwoFactorRequiredError{
+		ChallengeToken: token,
+		ExpiresAt:      expiresAt,
+		SetupRequired:  !enabled,
+	}
+}
+
+// CompleteTwoFactorLogin verifies the code of a login challenge and issues tokens
+func (s *authService) CompleteTwoFactorLogin(ctx context.Context, input domain.TwoFactorLoginInput, ipAddress, userAgent string) (*domain.User, *domain.AuthTokens, []string, error) {
+	user, setupRequired, err := s.challengeUser(ctx, input.ChallengeToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	enabled, _, err := s.twoFactor.Requirement(ctx, user)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("authService.CompleteTwoFactorLogin: %w", err)
+	}
+
+	var recoveryCodes []string
+	switch {
+	case enabled:
+		err = s.twoFactor.VerifyCode(ctx, user, input.Code)
+	case setupRequired:
+		recoveryCodes, err = s.twoFactor.Enable(ctx, user.ID, input.Code)
+	default:
+		// Two-factor authentication was turned off since the challenge
+		err = domain.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tokens, err := s.completeLogin(ctx, user, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("authService.CompleteTwoFactorLogin: %w", err)
+	}
+	return user, tokens, recoveryCodes, nil
+}
+
+// StartTwoFactorSetup returns a new secret for a user who must enroll to log in
+func (s *authService) StartTwoFactorSetup(ctx context.Context, input domain.TwoFactorChallengeInput) (*domain.TOTPSetup, error) {
+	user, setupRequired, err := s.challengeUser(ctx, input.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !setupRequired {
+		return nil, domain.ErrInvalidToken
+	}
+
+	setup, err := s.twoFactor.Setup(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("authService.StartTwoFactorSetup: %w", err)
+	}
+	return setup, nil
+}
+
+// challengeUser validates a login challenge and loads its user
+func (s *authService) challengeUser(ctx context.Context, challengeToken string) (*domain.User, bool, error) {
+	claims, err := s.jwtManager.ValidateChallengeToken(challengeToken)
+	if err != nil {
+		return nil, false, domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, false, domain.ErrInvalidToken
+		}
+		return nil, false, fmt.Errorf("authService.challengeUser: %w", err)
+	}
+	if !user.IsActive() {
+		return nil, false, domain.ErrAccountInactive
+	}
+	if user.IsLocked() {
+		return nil, false, domain.ErrAccountLocked
+	}
+	return user, claims.SetupRequired, nil
+}
+
+// completeLogin records a successful login and issues tokens
+func (s *authService) completeLogin(ctx context.Context, user *domain.User, ipAddress, userAgent string) (*domain.AuthTokens, error) {
 	// Reset failed attempts on successful login
 	if err := s.userRepo.ResetFailedAttempts(ctx, user.ID); err != nil {
 		s.logger.Error().Err(err).Msg("failed to reset failed attempts")
@@ -105,9 +303,9 @@ func (s *authService) Login(ctx context.Context, input domain.LoginInput, ipAddr
 	}
 
 	// Generate tokens
-	tokens, err := s.generateTokens(ctx, user, ipAddress, userAgent)
+	tokens, err := s.generateTokens(ctx, user, ipAddress, userAgent, s.newTokenFamily())
 	if err != nil {
-		return nil, nil, fmt.Errorf("authService.Login generate tokens: %w", err)
+		return nil, fmt.Errorf("generate tokens: %w", err)
 	}
 
 	s.logger.Info().
@@ -116,7 +314,7 @@ func (s *authService) Login(ctx context.Context, input domain.LoginInput, ipAddr
 		Str("ip", ipAddress).
 		Msg("user logged in successfully")
 
-	return user, tokens, nil
+	return tokens, nil
 }
 
 // Logout invalidates the refresh token
@@ -128,8 +326,8 @@ func (s *authService) Logout(ctx context.Context, refreshToken string) error {
 	return nil
 }
 
-// RefreshTokens generates new access and refresh tokens
-func (s *authService) RefreshTokens(ctx context.Context, refreshToken string) (*domain.AuthTokens, error) {
+// RefreshTokens generates new access and refresh tokens in the same family
+func (s *authService) RefreshTokens(ctx context.Context, refreshToken, ipAddress, userAgent string) (*domain.AuthTokens, error) {
 	// Validate the refresh token JWT
 	claims, err := s.jwtManager.ValidateRefreshToken(refreshToken)
 	if err != nil {
@@ -146,6 +344,13 @@ func (s *authService) RefreshTokens(ctx context.Context, refreshToken string) (*
 		return nil, fmt.Errorf("authService.RefreshTokens find token: %w", err)
 	}
 
+	if storedToken.RotatedAt != nil {
+		if time.Since(*storedToken.RotatedAt) < s.opts.ReuseGrace {
+			return nil, domain.ErrTokenRevoked
+		}
+		return nil, s.handleTokenReuse(ctx, storedToken, ipAddress, userAgent)
+	}
+
 	if !storedToken.IsValid() {
 		return nil, domain.ErrTokenRevoked
 	}
@@ -160,22 +365,27 @@ func (s *authService) RefreshTokens(ctx context.Context, refreshToken string) (*
 		return nil, domain.ErrAccountInactive
 	}
 
-	// Revoke old refresh token (token rotation)
-	if err := s.userRepo.RevokeRefreshToken(ctx, tokenHash); err != nil {
-		s.logger.Error().Err(err).Msg("failed to revoke old refresh token")
+	// Claim the old refresh token (token rotation). Losing the claim means a
+	// concurrent request rotated it first.
+	if err := s.userRepo.RotateRefreshToken(ctx, tokenHash); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrTokenRevoked
+		}
+		return nil, fmt.Errorf("authService.RefreshTokens rotate: %w", err)
 	}
 
-	// Generate new tokens
-	ipAddress := ""
-	userAgent := ""
+	// The session keeps the device it was started from
+	sessionIP := ""
+	sessionUA := ""
 	if storedToken.IPAddress != nil {
-		ipAddress = *storedToken.IPAddress
+		sessionIP = *storedToken.IPAddress
 	}
 	if storedToken.UserAgent != nil {
-		userAgent = *storedToken.UserAgent
+		sessionUA = *storedToken.UserAgent
 	}
 
-	tokens, err := s.generateTokens(ctx, user, ipAddress, userAgent)
+	family := tokenFamily{id: storedToken.FamilyID, expiresAt: storedToken.FamilyExpiresAt}
+	tokens, err := s.generateTokens(ctx, user, sessionIP, sessionUA, family)
 	if err != nil {
 		return nil, fmt.Errorf("authService.RefreshTokens generate tokens: %w", err)
 	}
@@ -183,6 +393,67 @@ func (s *authService) RefreshTokens(ctx context.Context, refreshToken string) (*
 	return tokens, nil
 }
 
+// handleTokenReuse revokes the family of a replayed token, records a security
+// event and notifies the user. It returns ErrTokenReused unless revoking fails.
+func (s *authService) handleTokenReuse(ctx context.Context, token *domain.RefreshToken, ipAddress, userAgent string) error {
+	revoked, err := s.userRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+	if err != nil {
+		return fmt.Errorf("authService.RefreshTokens revoke family: %w", err)
+	}
+
+	event := &domain.TokenReuseEvent{
+		UserID:     token.UserID,
+		FamilyID:   token.FamilyID,
+		TokenID:    token.ID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		Revoked:    revoked,
+		DetectedAt: time.Now(),
+	}
+	s.logger.Warn().
+		Str("user_id", event.UserID.String()).
+		Str("family_id", event.FamilyID.String()).
+		Str("ip", ipAddress).
+		Int64("revoked", revoked).
+		Msg("refresh token reuse detected, session revoked")
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to load user of reused refresh token")
+		return domain.ErrTokenReused
+	}
+
+	log := &domain.AuditLog{
+		ID:           uuid.New(),
+		UserID:       &user.ID,
+		UserEmail:    &user.Email,
+		Action:       auditActionTokenReuse,
+		ResourceType: "session",
+		ResourceID:   &token.FamilyID,
+		Metadata: domain.JSONMap{
+			"token_id":   token.ID,
+			"user_agent": userAgent,
+			"revoked":    revoked,
+		},
+	}
+	role := string(user.Role)
+	log.UserRole = &role
+	if ipAddress != "" {
+		log.IPAddress = &ipAddress
+	}
+	if userAgent != "" {
+		log.UserAgent = &userAgent
+	}
+	if err := s.audit.CreateAuditLog(ctx, log); err != nil {
+		s.logger.Error().Err(err).Msg("failed to record refresh token reuse")
+	}
+
+	if s.opts.OnTokenReuse != nil {
+		s.opts.OnTokenReuse(ctx, user, event)
+	}
+	return domain.ErrTokenReused
+}
+
 // ChangePassword changes a user's password
 func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, input domain.ChangePasswordInput) error {
 	user, err := s.userRepo.FindByID(ctx, userID)
@@ -196,14 +467,14 @@ func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, inpu
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), s.opts.BcryptCost)
 	if err != nil {
 		return fmt.Errorf("authService.ChangePassword hash: %w", err)
 	}
 
 	// Update password
 	user.PasswordHash = string(hashedPassword)
-	if err := s.userRepo.Update(ctx, user); err != nil {
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, user.PasswordHash); err != nil {
 		return fmt.Errorf("authService.ChangePassword update: %w", err)
 	}
 
@@ -219,8 +490,196 @@ func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, inpu
 	return nil
 }
 
-// generateTokens creates access and refresh tokens and stores the refresh token
-func (s *authService) generateTokens(ctx context.Context, user *domain.User, ipAddress, userAgent string) (*domain.AuthTokens, error) {
+// ForgotPassword issues a reset token and emails the reset link. Unknown,
+// inactive and single sign-on accounts are logged and otherwise ignored so
+// the response does not reveal which emails are registered. The link is
+// issued and sent in the background for the same reason: the caller gets
+// the same answer, just as fast, whether or not the account exists.
+func (s *authService) ForgotPassword(ctx context.Context, input domain.ForgotPasswordInput) error {
+	email := strings.TrimSpace(input.Email)
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			s.logger.Info().Str("email", email).Msg("password reset requested for unknown email")
+			return nil
+		}
+		return fmt.Errorf("authService.ForgotPassword find user: %w", err)
+	}
+	if !user.IsActive() {
+		s.logger.Warn().Str("user_id", user.ID.String()).Msg("password reset requested for inactive account")
+		return nil
+	}
+	if user.PasswordLoginDisabled {
+		s.logger.Info().Str("user_id", user.ID.String()).Msg("password reset requested for single sign-on account")
+		return nil
+	}
+
+	go s.sendPasswordReset(context.WithoutCancel(ctx), user)
+	return nil
+}
+
+// sendPasswordReset replaces the user's reset links with a new one and
+// emails it. Failures are only logged; the request has been answered.
+func (s *authService) sendPasswordReset(ctx context.Context, user *domain.User) {
+	// A new link replaces any earlier one
+	if err := s.userRepo.InvalidateUserPasswordResetTokens(ctx, user.ID); err != nil {
+		s.logger.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to invalidate password reset tokens")
+		return
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to generate password reset token")
+		return
+	}
+	expiresAt := time.Now().Add(s.opts.ResetExpiry)
+	resetToken := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.userRepo.CreatePasswordResetToken(ctx, resetToken); err != nil {
+		s.logger.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to store password reset token")
+		return
+	}
+
+	if err := s.mailer.Send(ctx, s.resetMessage(user, token)); err != nil {
+		s.logger.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to send password reset email")
+		return
+	}
+
+	s.logger.Info().
+		Str("user_id", user.ID.String()).
+		Time("expires_at", expiresAt).
+		Msg("password reset email sent")
+}
+
+// ResetPassword consumes a reset token, sets the new password and revokes all
+// refresh tokens of the user
+func (s *authService) ResetPassword(ctx context.Context, input domain.ResetPasswordInput) error {
+	if len(input.NewPassword) < minPasswordLength || len(input.NewPassword) > maxPasswordLength {
+		return fmt.Errorf("authService.ResetPassword: %w: password must be %d to %d characters",
+			domain.ErrValidation, minPasswordLength, maxPasswordLength)
+	}
+
+	resetToken, err := s.userRepo.FindPasswordResetToken(ctx, auth.HashToken(input.Token))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidToken
+		}
+		return fmt.Errorf("authService.ResetPassword find token: %w", err)
+	}
+	if !resetToken.IsValid() {
+		return domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, resetToken.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidToken
+		}
+		return fmt.Errorf("authService.ResetPassword find user: %w", err)
+	}
+	if !user.IsActive() {
+		return domain.ErrAccountInactive
+	}
+	if user.PasswordLoginDisabled {
+		return domain.ErrPasswordLoginDisabled
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), s.opts.BcryptCost)
+	if err != nil {
+		return fmt.Errorf("authService.ResetPassword hash: %w", err)
+	}
+
+	// Claim the token before changing anything; a concurrent reset with the
+	// same token gets ErrNotFound here
+	if err := s.userRepo.UsePasswordResetToken(ctx, resetToken.ID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.ErrInvalidToken
+		}
+		return fmt.Errorf("authService.ResetPassword use token: %w", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword)); err != nil {
+		return fmt.Errorf("authService.ResetPassword update: %w", err)
+	}
+
+	if err := s.userRepo.InvalidateUserPasswordResetTokens(ctx, user.ID); err != nil {
+		s.logger.Error().Err(err).Msg("failed to invalidate password reset tokens")
+	}
+	if err := s.userRepo.RevokeAllUserRefreshTokens(ctx, user.ID); err != nil {
+		return fmt.Errorf("authService.ResetPassword revoke sessions: %w", err)
+	}
+
+	s.logger.Info().
+		Str("user_id", user.ID.String()).
+		Msg("password reset successfully")
+
+	return nil
+}
+
+// resetMessage builds the password reset email
+func (s *authService) resetMessage(user *domain.User, token string) mailer.Message {
+	link := s.opts.ResetURL
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+
+	body := fmt.Sprintf(`Hi %s,
+
+Someone asked to reset the password of your account. Open this link to choose a new one:
+
+%s
+
+The link can be used once and expires in %s. If you did not ask for this, you can ignore this email; your password stays the same.
+`, user.FullName, link, s.opts.ResetExpiry)
+
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body,
+	}
+}
+
+// MailTokenReuseHook returns a TokenReuseHook that emails the user. Mail
+// errors are logged; the session has already been revoked.
+func MailTokenReuseHook(mail mailer.Mailer, logger zerolog.Logger) TokenReuseHook {
+	return func(ctx context.Context, user *domain.User, event *domain.TokenReuseEvent) {
+		body := fmt.Sprintf(`Hi %s,
+
+A sign-in token of your account was used again after it had been replaced, at %s from %s (%s). This usually means the token was copied from one of your devices.
+
+We signed that session out. If this was not you, change your password and review your active sessions.
+`, user.FullName, event.DetectedAt.UTC().Format(time.RFC1123), event.IPAddress, event.UserAgent)
+
+		msg := mailer.Message{
+			To:      user.Email,
+			Subject: "Suspicious sign-in activity on your account",
+			Body:    body,
+		}
+		if err := mail.Send(ctx, msg); err != nil {
+			logger.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to send token reuse notice")
+		}
+	}
+}
+
+// newTokenFamily starts the token family of a new login
+func (s *authService) newTokenFamily() tokenFamily {
+	family := tokenFamily{id: uuid.New()}
+	if s.opts.SessionLifetime > 0 {
+		expiresAt := time.Now().Add(s.opts.SessionLifetime)
+		family.expiresAt = &expiresAt
+	}
+	return family
+}
+
+// generateTokens creates access and refresh tokens and stores the refresh
+// token in family. The refresh token never outlives the family.
+func (s *authService) generateTokens(ctx context.Context, user *domain.User, ipAddress, userAgent string, family tokenFamily) (*domain.AuthTokens, error) {
 	// Generate access token
 	accessToken, expiresAt, err := s.jwtManager.GenerateAccessToken(user)
 	if err != nil {
@@ -235,11 +694,16 @@ func (s *authService) generateTokens(ctx context.Context, user *domain.User, ipA
 
 	// Store refresh token hash in DB
 	tokenHash := auth.HashToken(refreshTokenStr)
+	if family.expiresAt != nil && family.expiresAt.Before(refreshExpiresAt) {
+		refreshExpiresAt = *family.expiresAt
+	}
 	refreshToken := &domain.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		TokenHash: tokenHash,
-		ExpiresAt: refreshExpiresAt,
+		ID:              uuid.New(),
+		UserID:          user.ID,
+		FamilyID:        family.id,
+		TokenHash:       tokenHash,
+		ExpiresAt:       refreshExpiresAt,
+		FamilyExpiresAt: family.expiresAt,
 	}
 	if ipAddress != "" {
 		refreshToken.IPAddress = &ipAddress
@@ -253,9 +717,10 @@ func (s *authService) generateTokens(ctx context.Context, user *domain.User, ipA
 	}
 
 	return &domain.AuthTokens{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenStr,
-		ExpiresAt:    expiresAt,
-		TokenType:    "Bearer",
+		AccessToken:      accessToken,
+		RefreshToken:     refreshTokenStr,
+		ExpiresAt:        expiresAt,
+		TokenType:        "Bearer",
+		RefreshExpiresAt: refreshExpiresAt,
 	}, nil
 }
@@ -3,6 +3,9 @@ package service_test
 import (
 	"context"
 	"errors"
+	"net/url"
+	"regexp"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/mailer"
 	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,12 +23,14 @@ import (
 type mockUserRepository struct {
 	users         map[string]*domain.User
 	refreshTokens map[string]*domain.RefreshToken
+	resetTokens   map[string]*domain.PasswordResetToken
 }
 
 func newMockUserRepository() *mockUserRepository {
 	return &mockUserRepository{
 		users:         make(map[string]*domain.User),
 		refreshTokens: make(map[string]*domain.RefreshToken),
+		resetTokens:   make(map[string]*domain.PasswordResetToken),
 	}
 }
 
@@ -107,6 +113,18 @@ func (m *mockUserRepository) LockAccount(ctx context.Context, id uuid.UUID, unti
 	return nil
 }
 
+func (m *mockUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	for _, u := range m.users {
+		if u.ID == id {
+			u.PasswordHash = passwordHash
+			u.FailedAttempts = 0
+			u.LockedUntil = nil
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
 func (m *mockUserRepository) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
 	m.refreshTokens[token.TokenHash] = token
 	return nil
@@ -136,10 +154,139 @@ func (m *mockUserRepository) RevokeAllUserRefreshTokens(ctx context.Context, use
 	return nil
 }
 
-func (m *mockUserRepository) DeleteExpiredRefreshTokens(ctx context.Context) error {
+func (m *mockUserRepository) FindActiveRefreshTokens(ctx context.Context, userID uuid.UUID) ([]*domain.RefreshToken, error) {
+	var tokens []*domain.RefreshToken
+	for _, t := range m.refreshTokens {
+		if t.UserID == userID && t.IsValid() {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+func (m *mockUserRepository) RotateRefreshToken(ctx context.Context, tokenHash string) error {
+	t, ok := m.refreshTokens[tokenHash]
+	if !ok || t.IsRevoked || t.RotatedAt != nil {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	t.IsRevoked = true
+	t.RotatedAt = &now
+	return nil
+}
+
+func (m *mockUserRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) (int64, error) {
+	var revoked int64
+	for _, t := range m.refreshTokens {
+		if t.FamilyID == familyID && !t.IsRevoked {
+			t.IsRevoked = true
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (m *mockUserRepository) RevokeUserRefreshTokenFamily(ctx context.Context, userID, familyID uuid.UUID) error {
+	found := false
+	for _, t := range m.refreshTokens {
+		if t.FamilyID == familyID && t.UserID == userID && t.IsValid() {
+			t.IsRevoked = true
+			found = true
+		}
+	}
+	if !found {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (m *mockUserRepository) RevokeOtherUserRefreshTokens(ctx context.Context, userID uuid.UUID, keepHash string) (int64, error) {
+	var revoked int64
+	for hash, t := range m.refreshTokens {
+		if t.UserID == userID && hash != keepHash && !t.IsRevoked {
+			t.IsRevoked = true
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (m *mockUserRepository) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	var deleted int64
+	for hash, t := range m.refreshTokens {
+		if time.Now().After(t.ExpiresAt) {
+			delete(m.refreshTokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *mockUserRepository) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	m.resetTokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *mockUserRepository) FindPasswordResetToken(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	if t, ok := m.resetTokens[tokenHash]; ok {
+		return t, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockUserRepository) UsePasswordResetToken(ctx context.Context, id uuid.UUID) error {
+	for _, t := range m.resetTokens {
+		if t.ID == id && t.IsValid() {
+			t.IsUsed = true
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (m *mockUserRepository) InvalidateUserPasswordResetTokens(ctx context.Context, userID uuid.UUID) error {
+	for _, t := range m.resetTokens {
+		if t.UserID == userID {
+			t.IsUsed = true
+		}
+	}
+	return nil
+}
+
+// ─── Mock Mailer ──────────────────────────────────────────────────────────────
+
+type mockMailer struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+	err  error
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
 	return nil
 }
 
+// wait returns the sent messages once there are n of them, for mail that is
+// sent in the background
+func (m *mockMailer) wait(t *testing.T, n int) []mailer.Message {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		m.mu.Lock()
+		sent := append([]mailer.Message(nil), m.sent...)
+		m.mu.Unlock()
+		if len(sent) >= n || time.Now().After(deadline) {
+			return sent
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 // ─── Test helpers ─────────────────────────────────────────────────────────────
 
 func createTestUser(email, password string, role domain.UserRole) *domain.User {
@@ -156,6 +303,27 @@ func createTestUser(email, password string, role domain.UserRole) *domain.User {
 }
 
 func createTestAuthService(repo *mockUserRepository) service.AuthService {
+	return createTestAuthServiceWithMailer(repo, &mockMailer{})
+}
+
+func createTestAuthServiceWithMailer(repo *mockUserRepository, mail mailer.Mailer) service.AuthService {
+	svc, _ := createTestAuthServices(repo, newMockTwoFactorRepository(), mail)
+	return svc
+}
+
+func createTestAuthServices(repo *mockUserRepository, twoFactorRepo *mockTwoFactorRepository, mail mailer.Mailer) (service.AuthService, service.TwoFactorService) {
+	return createTestAuthServicesWithOptions(repo, twoFactorRepo, mail, &mockAuditRepository{}, service.AuthOptions{})
+}
+
+// createTestAuthServicesWithOptions fills in the reset and challenge settings
+// of opts
+func createTestAuthServicesWithOptions(
+	repo *mockUserRepository,
+	twoFactorRepo *mockTwoFactorRepository,
+	mail mailer.Mailer,
+	audit service.AuditRecorder,
+	opts service.AuthOptions,
+) (service.AuthService, service.TwoFactorService) {
 	jwtManager := auth.NewJWTManager(
 		"test-access-secret-key-minimum-32-chars",
 		"test-refresh-secret-key-minimum-32-chars",
@@ -164,7 +332,14 @@ func createTestAuthService(repo *mockUserRepository) service.AuthService {
 		"test-issuer",
 	)
 	logger := zerolog.Nop()
-	return service.NewAuthService(repo, jwtManager, logger)
+	roles, _ := newTestRoleService()
+	twoFactorSvc := service.NewTwoFactorService(twoFactorRepo, repo, roles, "Test CMS", logger)
+	opts.ResetURL = "https://cms.test/reset-password"
+	opts.ResetExpiry = time.Hour
+	opts.ChallengeExpiry = 5 * time.Minute
+	opts.BcryptCost = bcrypt.MinCost
+	authSvc := service.NewAuthService(repo, jwtManager, twoFactorSvc, mail, audit, opts, logger)
+	return authSvc, twoFactorSvc
 }
 
 // ─── Tests ────────────────────────────────────────────────────────────────────
@@ -322,7 +497,7 @@ func TestAuthService_RefreshTokens_Success(t *testing.T) {
 	}
 
 	// Refresh tokens
-	newTokens, err := svc.RefreshTokens(context.Background(), tokens.RefreshToken)
+	newTokens, err := svc.RefreshTokens(context.Background(), tokens.RefreshToken, "127.0.0.1", "test-agent")
 	if err != nil {
 		t.Fatalf("expected no error on refresh, got: %v", err)
 	}
@@ -357,7 +532,7 @@ func TestAuthService_RefreshTokens_RevokedToken(t *testing.T) {
 	svc.Logout(context.Background(), tokens.RefreshToken)
 
 	// Try to refresh with revoked token
-	_, err = svc.RefreshTokens(context.Background(), tokens.RefreshToken)
+	_, err = svc.RefreshTokens(context.Background(), tokens.RefreshToken, "127.0.0.1", "test-agent")
 	if err == nil {
 		t.Error("expected error when refreshing with revoked token")
 	}
@@ -405,3 +580,227 @@ func TestAuthService_ChangePassword_WrongCurrentPassword(t *testing.T) {
 		t.Errorf("expected ErrInvalidCredentials, got: %v", err)
 	}
 }
+
+// resetTokenFrom extracts the token from the reset link of a sent email
+func resetTokenFrom(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link := regexp.MustCompile(`https://cms\.test/reset-password\?\S+`).FindString(msg.Body)
+	u, err := url.Parse(link)
+	if err != nil || u.Query().Get("token") == "" {
+		t.Fatalf("expected a reset link in the email, got: %q", msg.Body)
+	}
+	return u.Query().Get("token")
+}
+
+func TestAuthService_ForgotPassword_SendsSingleUseLink(t *testing.T) {
+	repo := newMockUserRepository()
+	user := createTestUser("editor@test.com", "oldpassword", domain.RoleEditor)
+	repo.users[user.Email] = user
+	mail := &mockMailer{}
+	svc := createTestAuthServiceWithMailer(repo, mail)
+	ctx := context.Background()
+
+	if err := svc.ForgotPassword(ctx, domain.ForgotPasswordInput{Email: "editor@test.com"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	sent := mail.wait(t, 1)
+	if len(sent) != 1 || sent[0].To != user.Email {
+		t.Fatalf("expected one email to %s, got %+v", user.Email, sent)
+	}
+	token := resetTokenFrom(t, sent[0])
+	if _, ok := repo.resetTokens[token]; ok {
+		t.Error("expected only the token hash to be stored")
+	}
+	if _, ok := repo.resetTokens[auth.HashToken(token)]; !ok {
+		t.Error("expected the token hash to be stored")
+	}
+
+	// Log in on a device, then reset: the session must be revoked
+	_, tokens, _ := svc.Login(ctx, domain.LoginInput{Email: user.Email, Password: "oldpassword"}, "127.0.0.1", "test-agent")
+	user.FailedAttempts = 4
+	until := time.Now().Add(time.Hour)
+	user.LockedUntil = &until
+
+	if err := svc.ResetPassword(ctx, domain.ResetPasswordInput{Token: token, NewPassword: "newpassword123"}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := svc.RefreshTokens(ctx, tokens.RefreshToken, "127.0.0.1", "test-agent"); err == nil {
+		t.Error("expected existing sessions to be revoked")
+	}
+	if _, _, err := svc.Login(ctx, domain.LoginInput{Email: user.Email, Password: "newpassword123"}, "127.0.0.1", "test-agent"); err != nil {
+		t.Errorf("expected login with the new password to succeed, got: %v", err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(user.PasswordHash)); cost != bcrypt.MinCost {
+		t.Errorf("expected the configured bcrypt cost %d, got %d", bcrypt.MinCost, cost)
+	}
+
+	err := svc.ResetPassword(ctx, domain.ResetPasswordInput{Token: token, NewPassword: "anotherpassword"})
+	if !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected a used token to be rejected, got: %v", err)
+	}
+}
+
+func TestAuthService_ForgotPassword_UnknownOrInactive(t *testing.T) {
+	repo := newMockUserRepository()
+	user := createTestUser("gone@test.com", "password123", domain.RoleEditor)
+	user.Status = domain.StatusSuspended
+	repo.users[user.Email] = user
+	mail := &mockMailer{}
+	svc := createTestAuthServiceWithMailer(repo, mail)
+
+	for _, email := range []string{"nobody@test.com", "gone@test.com"} {
+		if err := svc.ForgotPassword(context.Background(), domain.ForgotPasswordInput{Email: email}); err != nil {
+			t.Errorf("expected no error for %s, got: %v", email, err)
+		}
+	}
+	if len(mail.sent) != 0 || len(repo.resetTokens) != 0 {
+		t.Error("expected no email and no token")
+	}
+
+	// A failing mailer must not reveal that the account exists
+	user.Status = domain.StatusActive
+	svc = createTestAuthServiceWithMailer(repo, &mockMailer{err: errors.New("smtp down")})
+	if err := svc.ForgotPassword(context.Background(), domain.ForgotPasswordInput{Email: "gone@test.com"}); err != nil {
+		t.Errorf("expected mail errors to be swallowed, got: %v", err)
+	}
+}
+
+func TestAuthService_ResetPassword_Rejected(t *testing.T) {
+	repo := newMockUserRepository()
+	user := createTestUser("editor@test.com", "oldpassword", domain.RoleEditor)
+	repo.users[user.Email] = user
+	mail := &mockMailer{}
+	svc := createTestAuthServiceWithMailer(repo, mail)
+	ctx := context.Background()
+
+	_ = svc.ForgotPassword(ctx, domain.ForgotPasswordInput{Email: user.Email})
+	first := resetTokenFrom(t, mail.wait(t, 1)[0])
+	_ = svc.ForgotPassword(ctx, domain.ForgotPasswordInput{Email: user.Email})
+	second := resetTokenFrom(t, mail.wait(t, 2)[1])
+
+	if err := svc.ResetPassword(ctx, domain.ResetPasswordInput{Token: first, NewPassword: "newpassword123"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected a superseded token to be rejected, got: %v", err)
+	}
+	if err := svc.ResetPassword(ctx, domain.ResetPasswordInput{Token: "made-up", NewPassword: "newpassword123"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected an unknown token to be rejected, got: %v", err)
+	}
+	if err := svc.ResetPassword(ctx, domain.ResetPasswordInput{Token: second, NewPassword: "short"}); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected a short password to be rejected, got: %v", err)
+	}
+
+	repo.resetTokens[auth.HashToken(second)].ExpiresAt = time.Now().Add(-time.Minute)
+	if err := svc.ResetPassword(ctx, domain.ResetPasswordInput{Token: second, NewPassword: "newpassword123"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected an expired token to be rejected, got: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("oldpassword")) != nil {
+		t.Error("expected the password to be unchanged")
+	}
+}
+
+func TestAuthService_RefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	repo := newMockUserRepository()
+	user := createTestUser("admin@test.com", "password123", domain.RoleAdmin)
+	repo.users[user.Email] = user
+	audit := &mockAuditRepository{}
+	var notified *domain.TokenReuseEvent
+	svc, _ := createTestAuthServicesWithOptions(repo, newMockTwoFactorRepository(), &mockMailer{}, audit, service.AuthOptions{
+		OnTokenReuse: func(ctx context.Context, u *domain.User, event *domain.TokenReuseEvent) {
+			notified = event
+		},
+	})
+	ctx := context.Background()
+
+	input := domain.LoginInput{Email: "admin@test.com", Password: "password123"}
+	_, stolen, err := svc.Login(ctx, input, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	rotated, err := svc.RefreshTokens(ctx, stolen.RefreshToken, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	// The old token is replayed by someone else
+	_, err = svc.RefreshTokens(ctx, stolen.RefreshToken, "203.0.113.9", "evil-agent")
+	if !errors.Is(err, domain.ErrTokenReused) {
+		t.Fatalf("expected ErrTokenReused, got %v", err)
+	}
+
+	// The legitimate token of the family is revoked too
+	if _, err := svc.RefreshTokens(ctx, rotated.RefreshToken, "127.0.0.1", "test-agent"); err == nil {
+		t.Error("expected the rest of the family to be revoked")
+	}
+
+	if len(audit.logs) != 1 || audit.logs[0].Action != "token_reuse" {
+		t.Fatalf("expected one token_reuse audit log, got %+v", audit.logs)
+	}
+	if ip := audit.logs[0].IPAddress; ip == nil || *ip != "203.0.113.9" {
+		t.Errorf("expected audit log to record the replaying IP, got %v", ip)
+	}
+	if notified == nil || notified.UserID != user.ID || notified.Revoked != 1 {
+		t.Errorf("expected reuse hook to be called with one revoked token, got %+v", notified)
+	}
+}
+
+func TestAuthService_RefreshTokens_ReuseWithinGrace(t *testing.T) {
+	repo := newMockUserRepository()
+	user := createTestUser("admin@test.com", "password123", domain.RoleAdmin)
+	repo.users[user.Email] = user
+	audit := &mockAuditRepository{}
+	svc, _ := createTestAuthServicesWithOptions(repo, newMockTwoFactorRepository(), &mockMailer{}, audit, service.AuthOptions{
+		ReuseGrace: time.Minute,
+	})
+	ctx := context.Background()
+
+	input := domain.LoginInput{Email: "admin@test.com", Password: "password123"}
+	_, tokens, _ := svc.Login(ctx, input, "127.0.0.1", "test-agent")
+	rotated, err := svc.RefreshTokens(ctx, tokens.RefreshToken, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	// A concurrent refresh with the same token loses the race but does not
+	// sign the session out
+	if _, err := svc.RefreshTokens(ctx, tokens.RefreshToken, "127.0.0.1", "test-agent"); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked within the grace period, got %v", err)
+	}
+	if _, err := svc.RefreshTokens(ctx, rotated.RefreshToken, "127.0.0.1", "test-agent"); err != nil {
+		t.Errorf("expected the session to survive, got %v", err)
+	}
+	if len(audit.logs) != 0 {
+		t.Errorf("expected no security event, got %d", len(audit.logs))
+	}
+}
+
+func TestAuthService_RefreshTokens_AbsoluteLifetime(t *testing.T) {
+	repo := newMockUserRepository()
+	user := createTestUser("admin@test.com", "password123", domain.RoleAdmin)
+	repo.users[user.Email] = user
+	svc, _ := createTestAuthServicesWithOptions(repo, newMockTwoFactorRepository(), &mockMailer{}, &mockAuditRepository{}, service.AuthOptions{
+		SessionLifetime: time.Hour,
+	})
+	ctx := context.Background()
+
+	input := domain.LoginInput{Email: "admin@test.com", Password: "password123"}
+	_, tokens, _ := svc.Login(ctx, input, "127.0.0.1", "test-agent")
+	if until := time.Until(tokens.RefreshExpiresAt); until > time.Hour || until < 59*time.Minute {
+		t.Errorf("expected refresh expiry capped at the session lifetime, got %v", until)
+	}
+
+	rotated, err := svc.RefreshTokens(ctx, tokens.RefreshToken, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	if !rotated.RefreshExpiresAt.Equal(tokens.RefreshExpiresAt) {
+		t.Error("expected refreshing not to extend the session lifetime")
+	}
+
+	// The session reaches its absolute end
+	for _, rt := range repo.refreshTokens {
+		past := time.Now().Add(-time.Second)
+		rt.FamilyExpiresAt = &past
+	}
+	if _, err := svc.RefreshTokens(ctx, rotated.RefreshToken, "127.0.0.1", "test-agent"); !errors.Is(err, domain.ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked after the absolute lifetime, got %v", err)
+	}
+}
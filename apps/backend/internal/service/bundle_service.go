@@ -0,0 +1,671 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// BundleService defines the interface for portable site import/export and for
+// duplicating pages and sites
+type BundleService interface {
+	// ExportBundle serializes a site with all of its content
+	ExportBundle(ctx context.Context, siteID uuid.UUID) (*domain.SiteBundle, error)
+	// ImportBundle recreates a bundle as a new site with fresh IDs
+	ImportBundle(ctx context.Context, bundle *domain.SiteBundle, input domain.ImportSiteBundleInput, userID uuid.UUID) (*domain.SiteBundleImportResult, error)
+	// DuplicatePage copies a page with its sections, contents and attached
+	// components as a new draft on the same site
+	DuplicatePage(ctx context.Context, id uuid.UUID, input domain.DuplicatePageInput, userID uuid.UUID) (*domain.Page, error)
+	// DuplicateSite copies a site with all of its content as a new site
+	DuplicateSite(ctx context.Context, id uuid.UUID, input domain.DuplicateSiteInput, userID uuid.UUID) (*domain.Site, error)
+}
+
+// maxCopySuffix bounds the search for a free "-copy-N" slug
+const maxCopySuffix = 100
+
+// bundleService implements BundleService
+type bundleService struct {
+	siteRepo repository.SiteRepository
+	pageRepo repository.PageRepository
+	compRepo repository.ComponentRepository
+	logger   zerolog.Logger
+}
+
+// NewBundleService creates a new BundleService
+func NewBundleService(
+	siteRepo repository.SiteRepository,
+	pageRepo repository.PageRepository,
+	compRepo repository.ComponentRepository,
+	logger zerolog.Logger,
+) BundleService {
+	return &bundleService{
+		siteRepo: siteRepo,
+		pageRepo: pageRepo,
+		compRepo: compRepo,
+		logger:   logger,
+	}
+}
+
+// ExportBundle serializes a site with its settings, pages (draft content),
+// components, navigation and media metadata
+func (s *bundleService) ExportBundle(ctx context.Context, siteID uuid.UUID) (*domain.SiteBundle, error) {
+	bundle, err := s.loadBundle(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.ExportBundle: %w", err)
+	}
+
+	s.logger.Info().
+		Str("site_id", siteID.String()).
+		Int("pages", len(bundle.Pages)).
+		Msg("site bundle exported")
+
+	return bundle, nil
+}
+
+// loadBundle reads a site and all of its content
+func (s *bundleService) loadBundle(ctx context.Context, siteID uuid.UUID) (*domain.SiteBundle, error) {
+	site, err := s.siteRepo.FindByID(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &domain.SiteBundle{
+		Version:    domain.SiteBundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Site:       site,
+	}
+	if bundle.Settings, err = s.siteRepo.FindSettingsBySiteID(ctx, siteID, false); err != nil {
+		return nil, fmt.Errorf("settings: %w", err)
+	}
+
+	pageFilter := domain.PageFilter{SiteID: &siteID}
+	bundle.Pages, err = collectAll(func(p domain.Pagination) ([]*domain.Page, int, error) {
+		pageFilter.Pagination = p
+		return s.pageRepo.FindAll(ctx, pageFilter)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pages: %w", err)
+	}
+	for _, page := range bundle.Pages {
+		if err := loadPageTree(ctx, s.pageRepo, page); err != nil {
+			return nil, fmt.Errorf("page %s: %w", page.Slug, err)
+		}
+	}
+
+	if err := s.loadComponents(ctx, siteID, &bundle.ComponentSet); err != nil {
+		return nil, err
+	}
+
+	if bundle.NavigationMenus, err = s.compRepo.FindMenusBySiteID(ctx, siteID); err != nil {
+		return nil, fmt.Errorf("menus: %w", err)
+	}
+	for _, menu := range bundle.NavigationMenus {
+		items, err := s.compRepo.FindItemsByMenuID(ctx, menu.ID)
+		if err != nil {
+			return nil, fmt.Errorf("menu items: %w", err)
+		}
+		menu.Items = domain.BuildNavigationTree(items)
+	}
+
+	bundle.Media, err = collectAll(func(p domain.Pagination) ([]*domain.Media, int, error) {
+		return s.compRepo.FindMediaByFilter(ctx, siteID, p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("media: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// loadComponents adds every feature, testimonial, pricing plan and FAQ of a site, active or not
+func (s *bundleService) loadComponents(ctx context.Context, siteID uuid.UUID, set *domain.ComponentSet) error {
+	filter := domain.ComponentFilter{SiteID: &siteID}
+	var err error
+
+	if set.Features, err = collectAll(func(p domain.Pagination) ([]*domain.Feature, int, error) {
+		filter.Pagination = p
+		return s.compRepo.FindFeaturesByFilter(ctx, filter)
+	}); err != nil {
+		return fmt.Errorf("features: %w", err)
+	}
+	if set.Testimonials, err = collectAll(func(p domain.Pagination) ([]*domain.Testimonial, int, error) {
+		filter.Pagination = p
+		return s.compRepo.FindTestimonialsByFilter(ctx, filter)
+	}); err != nil {
+		return fmt.Errorf("testimonials: %w", err)
+	}
+	if set.PricingPlans, err = collectAll(func(p domain.Pagination) ([]*domain.PricingPlan, int, error) {
+		filter.Pagination = p
+		return s.compRepo.FindPricingPlansByFilter(ctx, filter)
+	}); err != nil {
+		return fmt.Errorf("pricing plans: %w", err)
+	}
+	if set.FAQs, err = collectAll(func(p domain.Pagination) ([]*domain.FAQ, int, error) {
+		filter.Pagination = p
+		return s.compRepo.FindFAQsByFilter(ctx, filter)
+	}); err != nil {
+		return fmt.Errorf("faqs: %w", err)
+	}
+	return nil
+}
+
+// ImportBundle validates a bundle, gives every record a fresh ID, remaps the
+// references between them and writes the site in one transaction. A dry run
+// performs the same writes and rolls them back.
+func (s *bundleService) ImportBundle(ctx context.Context, bundle *domain.SiteBundle, input domain.ImportSiteBundleInput, userID uuid.UUID) (*domain.SiteBundleImportResult, error) {
+	if err := validateBundle(bundle); err != nil {
+		return nil, fmt.Errorf("bundleService.ImportBundle: %w", err)
+	}
+
+	imported, err := remapBundle(bundle, userID)
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.ImportBundle: %w", err)
+	}
+	site := imported.Site
+	if input.Name != nil {
+		site.Name = *input.Name
+	}
+	if input.Slug != nil {
+		site.Slug = *input.Slug
+	}
+	if input.Domain != nil {
+		site.Domain = input.Domain
+	}
+	if err := s.checkSiteAvailable(ctx, site); err != nil {
+		return nil, fmt.Errorf("bundleService.ImportBundle: %w", err)
+	}
+
+	if err := s.siteRepo.CreateTree(ctx, imported, input.DryRun); err != nil {
+		return nil, fmt.Errorf("bundleService.ImportBundle: %w", err)
+	}
+
+	result := &domain.SiteBundleImportResult{
+		DryRun: input.DryRun,
+		Site:   site,
+		Counts: countBundle(imported),
+	}
+
+	s.logger.Info().
+		Str("site_id", site.ID.String()).
+		Str("slug", site.Slug).
+		Bool("dry_run", input.DryRun).
+		Int("pages", result.Counts.Pages).
+		Str("user_id", userID.String()).
+		Msg("site bundle imported")
+
+	return result, nil
+}
+
+// checkSiteAvailable rejects a site whose slug or domain is already taken
+func (s *bundleService) checkSiteAvailable(ctx context.Context, site *domain.Site) error {
+	if _, err := s.siteRepo.FindBySlug(ctx, site.Slug); err == nil {
+		return domain.ErrAlreadyExists
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+
+	if site.Domain == nil || *site.Domain == "" {
+		site.Domain = nil
+		return nil
+	}
+	name := normalizeHost(*site.Domain)
+	if !hostnamePattern.MatchString(name) {
+		return domain.ErrInvalidDomain
+	}
+	site.Domain = &name
+	if _, err := s.siteRepo.FindByDomain(ctx, name); err == nil {
+		return domain.ErrAlreadyExists
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// DuplicatePage copies a page's draft tree and the components attached to its
+// sections as a new draft page on the same site, in one transaction
+func (s *bundleService) DuplicatePage(ctx context.Context, id uuid.UUID, input domain.DuplicatePageInput, userID uuid.UUID) (*domain.Page, error) {
+	source, err := s.pageRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage: %w", err)
+	}
+	if err := loadPageTree(ctx, s.pageRepo, source); err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage: %w", err)
+	}
+	var all domain.ComponentSet
+	if err := s.loadComponents(ctx, source.SiteID, &all); err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage: %w", err)
+	}
+
+	page, err := copyJSON(source)
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage copy: %w", err)
+	}
+	components, err := copyJSON(attachedComponents(&all, source.Sections))
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage copy: %w", err)
+	}
+	_, sectionIDs := remapPages([]*domain.Page{page}, source.SiteID, creatorOf(userID))
+	remapComponents(components, source.SiteID, sectionIDs)
+
+	page.Status = domain.PageStatusDraft
+	page.IsHomepage = false
+	page.PublishedAt = nil
+	page.Title = source.Title + " (Copy)"
+	if input.Title != nil {
+		page.Title = *input.Title
+	}
+
+	taken := func(slug string) (bool, error) {
+		if _, err := s.pageRepo.FindBySlug(ctx, source.SiteID, slug); err == nil {
+			return true, nil
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			return false, err
+		}
+		return false, nil
+	}
+	if input.Slug != nil && normalizeSlug(*input.Slug) != "" {
+		page.Slug = normalizeSlug(*input.Slug)
+		if exists, err := taken(page.Slug); err != nil {
+			return nil, fmt.Errorf("bundleService.DuplicatePage: %w", err)
+		} else if exists {
+			return nil, fmt.Errorf("bundleService.DuplicatePage: %w", domain.ErrAlreadyExists)
+		}
+	} else if page.Slug, err = copySlug(source.Slug, taken); err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage: %w", err)
+	}
+
+	if err := s.pageRepo.CreateTree(ctx, page, components); err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicatePage: %w", err)
+	}
+
+	s.logger.Info().
+		Str("page_id", page.ID.String()).
+		Str("source_id", source.ID.String()).
+		Str("slug", page.Slug).
+		Str("user_id", userID.String()).
+		Msg("page duplicated")
+
+	return page, nil
+}
+
+// DuplicateSite copies a site with its settings, pages, components, navigation
+// and media metadata in one transaction. Pages keep their status; published
+// pages are published from their current draft content. Media rows point at
+// the same stored files as the source site's.
+func (s *bundleService) DuplicateSite(ctx context.Context, id uuid.UUID, input domain.DuplicateSiteInput, userID uuid.UUID) (*domain.Site, error) {
+	bundle, err := s.loadBundle(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicateSite: %w", err)
+	}
+	tree, err := remapBundle(bundle, userID)
+	if err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicateSite: %w", err)
+	}
+
+	site := tree.Site
+	site.Name = bundle.Site.Name + " (Copy)"
+	if input.Name != nil {
+		site.Name = *input.Name
+	}
+	site.Domain = input.Domain
+	if input.Slug != nil && *input.Slug != "" {
+		site.Slug = *input.Slug
+	} else {
+		site.Slug, err = copySlug(bundle.Site.Slug, func(slug string) (bool, error) {
+			if _, err := s.siteRepo.FindBySlug(ctx, slug); err == nil {
+				return true, nil
+			} else if !errors.Is(err, domain.ErrNotFound) {
+				return false, err
+			}
+			return false, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("bundleService.DuplicateSite: %w", err)
+		}
+	}
+	if err := s.checkSiteAvailable(ctx, site); err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicateSite: %w", err)
+	}
+
+	if err := s.siteRepo.CreateTree(ctx, tree, false); err != nil {
+		return nil, fmt.Errorf("bundleService.DuplicateSite: %w", err)
+	}
+
+	s.logger.Info().
+		Str("site_id", site.ID.String()).
+		Str("source_id", id.String()).
+		Str("slug", site.Slug).
+		Int("pages", len(tree.Pages)).
+		Str("user_id", userID.String()).
+		Msg("site duplicated")
+
+	return site, nil
+}
+
+// validateBundle checks a bundle's version and internal consistency, collecting
+// every problem found
+func validateBundle(bundle *domain.SiteBundle) error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if bundle.Version != domain.SiteBundleVersion {
+		return &domain.BundleError{Problems: []string{
+			fmt.Sprintf("unsupported version %d (expected %d)", bundle.Version, domain.SiteBundleVersion),
+		}}
+	}
+	if bundle.Site == nil {
+		return &domain.BundleError{Problems: []string{"site is missing"}}
+	}
+	if strings.TrimSpace(bundle.Site.Name) == "" || strings.TrimSpace(bundle.Site.Slug) == "" {
+		addf("site name and slug are required")
+	}
+
+	keys := make(map[string]bool)
+	for _, setting := range bundle.Settings {
+		if setting.Key == "" || keys[setting.Key] {
+			addf("setting key %q is empty or duplicated", setting.Key)
+		}
+		keys[setting.Key] = true
+	}
+
+	pageIDs := make(map[uuid.UUID]bool)
+	sectionIDs := make(map[uuid.UUID]bool)
+	slugs := make(map[string]bool)
+	homepages := 0
+	for _, page := range bundle.Pages {
+		if pageIDs[page.ID] {
+			addf("page ID %s is duplicated", page.ID)
+		}
+		pageIDs[page.ID] = true
+		if page.Slug == "" || slugs[page.Slug] {
+			addf("page slug %q is empty or duplicated", page.Slug)
+		}
+		slugs[page.Slug] = true
+		if page.IsHomepage {
+			homepages++
+		}
+		for _, section := range page.Sections {
+			if sectionIDs[section.ID] {
+				addf("section ID %s is duplicated", section.ID)
+			}
+			sectionIDs[section.ID] = true
+		}
+	}
+	if homepages > 1 {
+		addf("%d pages are marked as homepage", homepages)
+	}
+
+	checkSection := func(kind string, id *uuid.UUID) {
+		if id != nil && !sectionIDs[*id] {
+			addf("%s references unknown section %s", kind, *id)
+		}
+	}
+	for _, f := range bundle.Features {
+		checkSection(fmt.Sprintf("feature %q", f.Title), f.SectionID)
+	}
+	for _, t := range bundle.Testimonials {
+		checkSection(fmt.Sprintf("testimonial by %q", t.AuthorName), t.SectionID)
+	}
+	for _, p := range bundle.PricingPlans {
+		checkSection(fmt.Sprintf("pricing plan %q", p.Name), p.SectionID)
+	}
+	for _, f := range bundle.FAQs {
+		checkSection(fmt.Sprintf("faq %q", f.Question), f.SectionID)
+	}
+
+	identifiers := make(map[string]bool)
+	var checkItems func(items []*domain.NavigationItem)
+	checkItems = func(items []*domain.NavigationItem) {
+		for _, item := range items {
+			if item.PageID != nil && !pageIDs[*item.PageID] {
+				addf("navigation item %q references unknown page %s", item.Label, *item.PageID)
+			}
+			checkItems(item.Children)
+		}
+	}
+	for _, menu := range bundle.NavigationMenus {
+		if identifiers[menu.Identifier] {
+			addf("navigation menu identifier %q is duplicated", menu.Identifier)
+		}
+		identifiers[menu.Identifier] = true
+		checkItems(menu.Items)
+	}
+
+	if len(problems) > 0 {
+		return &domain.BundleError{Problems: problems}
+	}
+	return nil
+}
+
+// remapBundle copies a validated bundle with fresh IDs for every record and
+// rewrites site_id, page_id, section_id, menu_id and parent_id to match. The
+// navigation tree's nesting defines parent_id. Users of the source database
+// are dropped; the importing user becomes the creator.
+func remapBundle(bundle *domain.SiteBundle, userID uuid.UUID) (*domain.SiteBundle, error) {
+	// Deep copy so the caller's bundle is left untouched
+	out, err := copyJSON(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("copy bundle: %w", err)
+	}
+	creator := creatorOf(userID)
+
+	siteID := uuid.New()
+	out.Site.ID = siteID
+	out.Site.CreatedBy = creator
+	out.Site.Settings = nil
+
+	for _, setting := range out.Settings {
+		setting.ID = uuid.New()
+		setting.SiteID = siteID
+	}
+
+	pageIDs, sectionIDs := remapPages(out.Pages, siteID, creator)
+	remapComponents(&out.ComponentSet, siteID, sectionIDs)
+
+	var remapItems func(items []*domain.NavigationItem, menuID uuid.UUID, parentID *uuid.UUID, depth int)
+	remapItems = func(items []*domain.NavigationItem, menuID uuid.UUID, parentID *uuid.UUID, depth int) {
+		for _, item := range items {
+			item.ID = uuid.New()
+			item.MenuID = menuID
+			item.ParentID = parentID
+			item.Depth = depth
+			if item.PageID != nil {
+				mapped := pageIDs[*item.PageID]
+				item.PageID = &mapped
+			}
+			id := item.ID
+			remapItems(item.Children, menuID, &id, depth+1)
+		}
+	}
+	for _, menu := range out.NavigationMenus {
+		menu.ID = uuid.New()
+		menu.SiteID = siteID
+		remapItems(menu.Items, menu.ID, nil, 0)
+	}
+
+	for _, m := range out.Media {
+		m.ID = uuid.New()
+		m.SiteID = siteID
+		m.UploadedBy = creator
+	}
+
+	return out, nil
+}
+
+// remapPages gives pages, sections and contents fresh IDs on the given site and
+// returns the old to new page and section IDs. Schedules are dropped.
+func remapPages(pages []*domain.Page, siteID uuid.UUID, creator *uuid.UUID) (pageIDs, sectionIDs map[uuid.UUID]uuid.UUID) {
+	pageIDs = make(map[uuid.UUID]uuid.UUID, len(pages))
+	sectionIDs = make(map[uuid.UUID]uuid.UUID)
+	for _, page := range pages {
+		pageIDs[page.ID] = uuid.New()
+		page.ID = pageIDs[page.ID]
+		page.SiteID = siteID
+		page.CreatedBy = creator
+		page.UpdatedBy = creator
+		page.PublishAt = nil
+		page.UnpublishAt = nil
+		for _, section := range page.Sections {
+			sectionIDs[section.ID] = uuid.New()
+			section.ID = sectionIDs[section.ID]
+			section.PageID = page.ID
+			for _, content := range section.Contents {
+				content.ID = uuid.New()
+				content.SectionID = section.ID
+			}
+		}
+	}
+	return pageIDs, sectionIDs
+}
+
+// remapComponents gives components fresh IDs on the given site and points
+// attached ones at their remapped sections
+func remapComponents(set *domain.ComponentSet, siteID uuid.UUID, sectionIDs map[uuid.UUID]uuid.UUID) {
+	remapSection := func(id *uuid.UUID) *uuid.UUID {
+		if id == nil {
+			return nil
+		}
+		mapped := sectionIDs[*id]
+		return &mapped
+	}
+	for _, f := range set.Features {
+		f.ID, f.SiteID, f.SectionID = uuid.New(), siteID, remapSection(f.SectionID)
+	}
+	for _, t := range set.Testimonials {
+		t.ID, t.SiteID, t.SectionID = uuid.New(), siteID, remapSection(t.SectionID)
+	}
+	for _, p := range set.PricingPlans {
+		p.ID, p.SiteID, p.SectionID = uuid.New(), siteID, remapSection(p.SectionID)
+	}
+	for _, f := range set.FAQs {
+		f.ID, f.SiteID, f.SectionID = uuid.New(), siteID, remapSection(f.SectionID)
+	}
+}
+
+// attachedComponents keeps the components attached to one of the given sections
+func attachedComponents(set *domain.ComponentSet, sections []*domain.PageSection) *domain.ComponentSet {
+	ids := make(map[uuid.UUID]bool, len(sections))
+	for _, section := range sections {
+		ids[section.ID] = true
+	}
+	attached := func(id *uuid.UUID) bool { return id != nil && ids[*id] }
+
+	out := &domain.ComponentSet{}
+	for _, f := range set.Features {
+		if attached(f.SectionID) {
+			out.Features = append(out.Features, f)
+		}
+	}
+	for _, t := range set.Testimonials {
+		if attached(t.SectionID) {
+			out.Testimonials = append(out.Testimonials, t)
+		}
+	}
+	for _, p := range set.PricingPlans {
+		if attached(p.SectionID) {
+			out.PricingPlans = append(out.PricingPlans, p)
+		}
+	}
+	for _, f := range set.FAQs {
+		if attached(f.SectionID) {
+			out.FAQs = append(out.FAQs, f)
+		}
+	}
+	return out
+}
+
+// creatorOf returns the user to record as creator, if any
+func creatorOf(userID uuid.UUID) *uuid.UUID {
+	if userID == uuid.Nil {
+		return nil
+	}
+	return &userID
+}
+
+// copySlug returns the first of "<base>-copy", "<base>-copy-2", ... that is
+// not taken
+func copySlug(base string, taken func(slug string) (bool, error)) (string, error) {
+	for n := 1; n <= maxCopySuffix; n++ {
+		slug := base + "-copy"
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", slug, n)
+		}
+		ok, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return slug, nil
+		}
+	}
+	return "", domain.ErrAlreadyExists
+}
+
+// copyJSON deep-copies a value through JSON
+func copyJSON[T any](v T) (T, error) {
+	var out T
+	b, err := json.Marshal(v)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+// countBundle counts the records of a bundle
+func countBundle(bundle *domain.SiteBundle) domain.SiteBundleCounts {
+	counts := domain.SiteBundleCounts{
+		Settings:        len(bundle.Settings),
+		Pages:           len(bundle.Pages),
+		Features:        len(bundle.Features),
+		Testimonials:    len(bundle.Testimonials),
+		PricingPlans:    len(bundle.PricingPlans),
+		FAQs:            len(bundle.FAQs),
+		NavigationMenus: len(bundle.NavigationMenus),
+		Media:           len(bundle.Media),
+	}
+	for _, page := range bundle.Pages {
+		counts.Sections += len(page.Sections)
+		for _, section := range page.Sections {
+			counts.Contents += len(section.Contents)
+		}
+	}
+	var countItems func(items []*domain.NavigationItem)
+	countItems = func(items []*domain.NavigationItem) {
+		for _, item := range items {
+			counts.NavigationItems++
+			countItems(item.Children)
+		}
+	}
+	for _, menu := range bundle.NavigationMenus {
+		countItems(menu.Items)
+	}
+	return counts
+}
+
+// collectAll loads every record of a paginated query
+func collectAll[T any](fetch func(p domain.Pagination) ([]T, int, error)) ([]T, error) {
+	p := domain.Pagination{Page: 1, PerPage: 100}
+	var all []T
+	for {
+		batch, total, err := fetch(p)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, batch...)
+		if len(batch) < p.PerPage || p.Page*p.PerPage >= total {
+			return all, nil
+		}
+		p.Page++
+	}
+}
@@ -0,0 +1,406 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+func setupBundleTest(t *testing.T) (*renderTestEnv, service.BundleService) {
+	t.Helper()
+	env := setupRenderTest(t)
+	svc := service.NewBundleService(env.siteRepo, env.pageRepo, env.compRepo, zerolog.Nop())
+	return env, svc
+}
+
+// exportTestBundle fills the test site with linked content and exports it as
+// it would arrive over the wire
+func exportTestBundle(t *testing.T, env *renderTestEnv, svc service.BundleService) *domain.SiteBundle {
+	t.Helper()
+	ctx := context.Background()
+
+	home := env.createPublishedPage(t, domain.CreatePageInput{Title: "Home", Slug: "home", IsHomepage: true},
+		map[domain.SectionType]map[string]string{domain.SectionTypeFeatures: {"title": "Why Acme"}})
+	if _, err := env.pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: env.site.ID, Title: "Draft", Slug: "draft", Status: domain.PageStatusDraft,
+	}, uuid.New()); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+	sections, _ := env.pageRepo.FindSectionsByPageID(ctx, home.ID)
+
+	env.compRepo.features = []*domain.Feature{
+		{ID: uuid.New(), SiteID: env.site.ID, SectionID: &sections[0].ID, Title: "Fast"},
+		{ID: uuid.New(), SiteID: env.site.ID, Title: "Unattached"},
+	}
+	menu := &domain.NavigationMenu{ID: uuid.New(), SiteID: env.site.ID, Identifier: "header", IsActive: true}
+	parent := &domain.NavigationItem{ID: uuid.New(), MenuID: menu.ID, Label: "Product", PageID: &home.ID}
+	child := &domain.NavigationItem{ID: uuid.New(), MenuID: menu.ID, Label: "Docs", ParentID: &parent.ID, Depth: 1}
+	env.compRepo.menus = []*domain.NavigationMenu{menu}
+	env.compRepo.items = []*domain.NavigationItem{parent, child}
+	env.compRepo.media = []*domain.Media{{ID: uuid.New(), SiteID: env.site.ID, FilePath: "acme/logo.png"}}
+
+	bundle, err := svc.ExportBundle(ctx, env.site.ID)
+	if err != nil {
+		t.Fatalf("expected no error exporting, got: %v", err)
+	}
+	b, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("failed to marshal bundle: %v", err)
+	}
+	var decoded domain.SiteBundle
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal bundle: %v", err)
+	}
+	return &decoded
+}
+
+func TestBundleService_ExportBundle(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	bundle := exportTestBundle(t, env, svc)
+
+	if bundle.Version != domain.SiteBundleVersion || bundle.Site.ID != env.site.ID {
+		t.Fatalf("expected version %d bundle of the site, got version %d", domain.SiteBundleVersion, bundle.Version)
+	}
+	if len(bundle.Pages) != 2 || len(bundle.Settings) != 1 || len(bundle.Features) != 2 || len(bundle.Media) != 1 {
+		t.Errorf("expected 2 pages, 1 setting, 2 features and 1 media, got %d, %d, %d and %d",
+			len(bundle.Pages), len(bundle.Settings), len(bundle.Features), len(bundle.Media))
+	}
+	for _, page := range bundle.Pages {
+		if page.Slug == "home" && (len(page.Sections) != 1 || len(page.Sections[0].Contents) != 1) {
+			t.Error("expected the homepage with its section and content")
+		}
+	}
+	items := bundle.NavigationMenus[0].Items
+	if len(items) != 1 || len(items[0].Children) != 1 {
+		t.Fatal("expected the navigation items as a tree")
+	}
+}
+
+func TestBundleService_ImportBundle_RemapsIDs(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	bundle := exportTestBundle(t, env, svc)
+	ctx := context.Background()
+
+	slug := "acme-copy"
+	userID := uuid.New()
+	result, err := svc.ImportBundle(ctx, bundle, domain.ImportSiteBundleInput{Slug: &slug}, userID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	imported := env.siteRepo.tree
+	want := domain.SiteBundleCounts{
+		Settings: 1, Pages: 2, Sections: 1, Contents: 1, Features: 2,
+		NavigationMenus: 1, NavigationItems: 2, Media: 1,
+	}
+	if result.Counts != want {
+		t.Errorf("expected counts %+v, got %+v", want, result.Counts)
+	}
+	if result.DryRun || env.siteRepo.treeDryRun {
+		t.Error("expected a real import")
+	}
+
+	site := imported.Site
+	if site.ID == env.site.ID || site.Slug != slug || *site.CreatedBy != userID {
+		t.Errorf("expected a new site with slug %q created by the importer, got %+v", slug, site)
+	}
+
+	oldIDs := map[uuid.UUID]bool{env.site.ID: true}
+	for _, page := range bundle.Pages {
+		oldIDs[page.ID] = true
+		for _, section := range page.Sections {
+			oldIDs[section.ID] = true
+		}
+	}
+
+	pageIDs := map[uuid.UUID]bool{}
+	sectionIDs := map[uuid.UUID]bool{}
+	for _, page := range imported.Pages {
+		if oldIDs[page.ID] || page.SiteID != site.ID {
+			t.Errorf("expected page %q to get a new ID on the new site", page.Slug)
+		}
+		pageIDs[page.ID] = true
+		for _, section := range page.Sections {
+			if oldIDs[section.ID] || section.PageID != page.ID {
+				t.Errorf("expected section %q to get a new ID under its page", section.Name)
+			}
+			sectionIDs[section.ID] = true
+			for _, content := range section.Contents {
+				if content.SectionID != section.ID {
+					t.Errorf("expected content %q to point at its new section", content.Key)
+				}
+			}
+		}
+	}
+
+	for _, f := range imported.Features {
+		if f.SiteID != site.ID {
+			t.Errorf("expected feature %q on the new site", f.Title)
+		}
+		switch {
+		case f.Title == "Fast" && (f.SectionID == nil || !sectionIDs[*f.SectionID]):
+			t.Error("expected the linked feature to point at the new section")
+		case f.Title == "Unattached" && f.SectionID != nil:
+			t.Error("expected the unattached feature to stay unattached")
+		}
+	}
+
+	menu := imported.NavigationMenus[0]
+	parent := menu.Items[0]
+	child := parent.Children[0]
+	if menu.SiteID != site.ID || parent.MenuID != menu.ID || child.MenuID != menu.ID {
+		t.Error("expected navigation items to belong to the new menu")
+	}
+	if parent.PageID == nil || !pageIDs[*parent.PageID] {
+		t.Error("expected the page link to point at the new page")
+	}
+	if child.ParentID == nil || *child.ParentID != parent.ID || child.Depth != 1 {
+		t.Error("expected the child item to point at its new parent")
+	}
+
+	if imported.Settings[0].SiteID != site.ID || imported.Media[0].SiteID != site.ID {
+		t.Error("expected settings and media on the new site")
+	}
+	if bundle.Site.ID != env.site.ID || bundle.Pages[0].SiteID != env.site.ID {
+		t.Error("expected the input bundle to be left untouched")
+	}
+}
+
+func TestBundleService_ImportBundle_DryRun(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	bundle := exportTestBundle(t, env, svc)
+
+	slug := "acme-staging"
+	result, err := svc.ImportBundle(context.Background(), bundle, domain.ImportSiteBundleInput{Slug: &slug, DryRun: true}, uuid.New())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !result.DryRun || !env.siteRepo.treeDryRun || env.siteRepo.treeCalls != 1 {
+		t.Error("expected the dry run to be passed to the transaction")
+	}
+}
+
+func TestBundleService_ImportBundle_Conflicts(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	bundle := exportTestBundle(t, env, svc)
+	ctx := context.Background()
+
+	taken := "taken.example.com"
+	other := &domain.Site{ID: uuid.New(), Name: "Other", Slug: "other", Domain: &taken, IsActive: true}
+	env.siteRepo.sites[other.ID] = other
+
+	newSlug := "acme-copy"
+	invalid := "not a domain"
+	tests := []struct {
+		name    string
+		input   domain.ImportSiteBundleInput
+		wantErr error
+	}{
+		{name: "slug of the source site", input: domain.ImportSiteBundleInput{}, wantErr: domain.ErrAlreadyExists},
+		{name: "domain in use", input: domain.ImportSiteBundleInput{Slug: &newSlug, Domain: &taken}, wantErr: domain.ErrAlreadyExists},
+		{name: "invalid domain", input: domain.ImportSiteBundleInput{Slug: &newSlug, Domain: &invalid}, wantErr: domain.ErrInvalidDomain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ImportBundle(ctx, bundle, tt.input, uuid.New()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+	if env.siteRepo.treeCalls != 0 {
+		t.Error("expected nothing to be written")
+	}
+}
+
+func TestBundleService_ImportBundle_Invalid(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	valid := exportTestBundle(t, env, svc)
+	slug := "acme-copy"
+
+	tests := []struct {
+		name   string
+		modify func(b *domain.SiteBundle)
+		want   string
+	}{
+		{name: "unsupported version", modify: func(b *domain.SiteBundle) { b.Version = 99 }, want: "unsupported version 99"},
+		{name: "missing site", modify: func(b *domain.SiteBundle) { b.Site = nil }, want: "site is missing"},
+		{
+			name:   "duplicate page slug",
+			modify: func(b *domain.SiteBundle) { b.Pages[1].Slug = b.Pages[0].Slug },
+			want:   "is empty or duplicated",
+		},
+		{
+			name: "unknown section",
+			modify: func(b *domain.SiteBundle) {
+				missing := uuid.New()
+				b.Features[0].SectionID = &missing
+			},
+			want: "references unknown section",
+		},
+		{
+			name: "unknown page",
+			modify: func(b *domain.SiteBundle) {
+				missing := uuid.New()
+				b.NavigationMenus[0].Items[0].Children[0].PageID = &missing
+			},
+			want: `navigation item "Docs" references unknown page`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := json.Marshal(valid)
+			var bundle domain.SiteBundle
+			json.Unmarshal(b, &bundle)
+			tt.modify(&bundle)
+
+			_, err := svc.ImportBundle(context.Background(), &bundle, domain.ImportSiteBundleInput{Slug: &slug}, uuid.New())
+			if !errors.Is(err, domain.ErrInvalidBundle) {
+				t.Fatalf("expected ErrInvalidBundle, got: %v", err)
+			}
+			var bundleErr *domain.BundleError
+			if !errors.As(err, &bundleErr) || !strings.Contains(strings.Join(bundleErr.Problems, "\n"), tt.want) {
+				t.Errorf("expected a problem containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+	if env.siteRepo.treeCalls != 0 {
+		t.Error("expected nothing to be written")
+	}
+}
+
+func TestBundleService_DuplicatePage(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	exportTestBundle(t, env, svc)
+	ctx := context.Background()
+
+	home, _ := env.pageRepo.FindBySlug(ctx, env.site.ID, "home")
+	sourceSections, _ := env.pageRepo.FindSectionsByPageID(ctx, home.ID)
+
+	userID := uuid.New()
+	page, err := svc.DuplicatePage(ctx, home.ID, domain.DuplicatePageInput{}, userID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if page.ID == home.ID || page.SiteID != env.site.ID {
+		t.Error("expected a new page on the same site")
+	}
+	if page.Slug != "home-copy" || page.Title != "Home (Copy)" {
+		t.Errorf("expected slug home-copy and title %q, got %q and %q", "Home (Copy)", page.Slug, page.Title)
+	}
+	if page.Status != domain.PageStatusDraft || page.IsHomepage || page.PublishedAt != nil || *page.CreatedBy != userID {
+		t.Errorf("expected an unpublished draft created by the user, got %+v", page)
+	}
+
+	sections, _ := env.pageRepo.FindSectionsByPageID(ctx, page.ID)
+	if len(sections) != 1 || sections[0].ID == sourceSections[0].ID {
+		t.Fatal("expected the section to be copied with a new ID")
+	}
+	contents, _ := env.pageRepo.FindContentsBySectionID(ctx, sections[0].ID)
+	if len(contents) != 1 || contents[0].Value == nil || *contents[0].Value != "Why Acme" {
+		t.Error("expected the section contents to be copied")
+	}
+
+	features := env.pageRepo.components.Features
+	if len(features) != 1 || features[0].Title != "Fast" {
+		t.Fatalf("expected only the attached feature to be copied, got %d", len(features))
+	}
+	if features[0].ID == env.compRepo.features[0].ID || *features[0].SectionID != sections[0].ID {
+		t.Error("expected the feature copy to point at the new section")
+	}
+
+	if home.Status != domain.PageStatusPublished || !home.IsHomepage || *env.compRepo.features[0].SectionID != sourceSections[0].ID {
+		t.Error("expected the source page and its components to be left untouched")
+	}
+
+	again, err := svc.DuplicatePage(ctx, home.ID, domain.DuplicatePageInput{}, userID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if again.Slug != "home-copy-2" {
+		t.Errorf("expected the next free slug home-copy-2, got %q", again.Slug)
+	}
+}
+
+func TestBundleService_DuplicatePage_Errors(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	exportTestBundle(t, env, svc)
+	ctx := context.Background()
+	home, _ := env.pageRepo.FindBySlug(ctx, env.site.ID, "home")
+
+	taken := "Draft"
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		input   domain.DuplicatePageInput
+		wantErr error
+	}{
+		{name: "missing page", id: uuid.New(), wantErr: domain.ErrNotFound},
+		{name: "slug in use", id: home.ID, input: domain.DuplicatePageInput{Slug: &taken}, wantErr: domain.ErrAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.DuplicatePage(ctx, tt.id, tt.input, uuid.New()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+	if env.pageRepo.components != nil {
+		t.Error("expected nothing to be written")
+	}
+}
+
+func TestBundleService_DuplicateSite(t *testing.T) {
+	env, svc := setupBundleTest(t)
+	exportTestBundle(t, env, svc)
+	ctx := context.Background()
+	siteDomain := "acme.com"
+	env.site.Domain = &siteDomain
+
+	userID := uuid.New()
+	site, err := svc.DuplicateSite(ctx, env.site.ID, domain.DuplicateSiteInput{}, userID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if site.ID == env.site.ID || site.Slug != "acme-copy" || site.Name != "Acme (Copy)" || *site.CreatedBy != userID {
+		t.Errorf("expected a new site acme-copy named %q, got %+v", "Acme (Copy)", site)
+	}
+	if site.Domain != nil {
+		t.Error("expected the copy not to take the source domain")
+	}
+
+	tree := env.siteRepo.tree
+	if env.siteRepo.treeDryRun || env.siteRepo.treeCalls != 1 {
+		t.Error("expected the site to be written in one transaction")
+	}
+	if len(tree.Settings) != 1 || len(tree.Pages) != 2 || len(tree.Features) != 2 || len(tree.NavigationMenus) != 1 || len(tree.Media) != 1 {
+		t.Errorf("expected 1 setting, 2 pages, 2 features, 1 menu and 1 media, got %d, %d, %d, %d and %d",
+			len(tree.Settings), len(tree.Pages), len(tree.Features), len(tree.NavigationMenus), len(tree.Media))
+	}
+	for _, page := range tree.Pages {
+		if page.SiteID != site.ID {
+			t.Errorf("expected page %q on the new site", page.Slug)
+		}
+	}
+	if tree.Settings[0].SiteID != site.ID || tree.NavigationMenus[0].SiteID != site.ID {
+		t.Error("expected settings and navigation on the new site")
+	}
+
+	newDomain := "copy.acme.com"
+	if _, err := svc.DuplicateSite(ctx, env.site.ID, domain.DuplicateSiteInput{Domain: &newDomain}, userID); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if env.siteRepo.tree.Site.Slug != "acme-copy-2" || *env.siteRepo.tree.Site.Domain != newDomain {
+		t.Errorf("expected the next free slug and the given domain, got %+v", env.siteRepo.tree.Site)
+	}
+
+	if _, err := svc.DuplicateSite(ctx, env.site.ID, domain.DuplicateSiteInput{Domain: &siteDomain}, userID); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a domain in use, got: %v", err)
+	}
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+const (
+	// exportMediaDir is the directory media assets are copied to inside an export
+	exportMediaDir = "media"
+	// exportMaxMediaSize bounds a single copied media asset
+	exportMaxMediaSize = 100 << 20
+)
+
+// exportModTime is stamped on every archive entry so identical content always
+// produces an identical archive
+var exportModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ExportService defines the interface for static site exports
+type ExportService interface {
+	// ExportSite renders every published page of a site to static HTML, together
+	// with the media it references, sitemap.xml and robots.txt. Media is read
+	// from storage; if any referenced file is missing an ExportMediaError is
+	// returned instead of an export that still points at it.
+	ExportSite(ctx context.Context, siteID uuid.UUID, input domain.ExportSiteInput) (*SiteExport, error)
+}
+
+// SiteExport is the set of files of a static site export, keyed by relative path
+type SiteExport struct {
+	Site  *domain.Site
+	Files map[string][]byte
+}
+
+// Paths returns the file paths in sorted order
+func (e *SiteExport) Paths() []string {
+	paths := make([]string, 0, len(e.Files))
+	for p := range e.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// WriteZip writes the export as a zip archive. Entries are sorted and carry a
+// fixed timestamp, so the archive is byte-for-byte stable for the same content.
+func (e *SiteExport) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for _, p := range e.Paths() {
+		header := &zip.FileHeader{
+			Name:     p,
+			Method:   zip.Deflate,
+			Modified: exportModTime,
+		}
+		header.SetMode(0o644)
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("SiteExport.WriteZip: %w", err)
+		}
+		if _, err := fw.Write(e.Files[p]); err != nil {
+			return fmt.Errorf("SiteExport.WriteZip: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("SiteExport.WriteZip: %w", err)
+	}
+	return nil
+}
+
+// exportService implements ExportService
+type exportService struct {
+	siteRepo repository.SiteRepository
+	pageRepo repository.PageRepository
+	compRepo repository.ComponentRepository
+	storage  storage.Backend
+	renderer *render.Renderer
+	logger   zerolog.Logger
+}
+
+// NewExportService creates a new ExportService
+func NewExportService(
+	siteRepo repository.SiteRepository,
+	pageRepo repository.PageRepository,
+	compRepo repository.ComponentRepository,
+	store storage.Backend,
+	renderer *render.Renderer,
+	logger zerolog.Logger,
+) ExportService {
+	return &exportService{
+		siteRepo: siteRepo,
+		pageRepo: pageRepo,
+		compRepo: compRepo,
+		storage:  store,
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// exportedPage is a published page and the file it is written to
+type exportedPage struct {
+	page *domain.Page
+	file string
+}
+
+// ExportSite renders every published page of a site to static HTML
+func (s *exportService) ExportSite(ctx context.Context, siteID uuid.UUID, input domain.ExportSiteInput) (*SiteExport, error) {
+	data, err := loadSiteRenderData(ctx, s.siteRepo, s.compRepo, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("exportService.ExportSite: %w", err)
+	}
+
+	baseURL, err := exportBaseURL(data.site, input.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("exportService.ExportSite: %w", err)
+	}
+
+	pages, err := s.publishedPages(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("exportService.ExportSite: %w", err)
+	}
+
+	export := &SiteExport{Site: data.site, Files: make(map[string][]byte)}
+	links := newExportLinks(data.site, baseURL, pages)
+
+	// Render pages
+	html := make(map[string]string, len(pages))
+	for _, p := range pages {
+		doc := data.document(p.page, links.navLink(p.file))
+		doc.HomeURL = relativePath(p.file, "index.html")
+
+		var buf bytes.Buffer
+		if err := s.renderer.Render(&buf, doc); err != nil {
+			return nil, fmt.Errorf("exportService.ExportSite render %s: %w", p.page.Slug, err)
+		}
+		html[p.file] = buf.String()
+	}
+
+	// Copy referenced media and point the pages at the local copies
+	media, err := s.referencedMedia(ctx, siteID, html)
+	if err != nil {
+		return nil, fmt.Errorf("exportService.ExportSite: %w", err)
+	}
+	var missing []string
+	for _, m := range media {
+		body, err := s.readMedia(ctx, m)
+		if errors.Is(err, storage.ErrNotFound) {
+			missing = append(missing, m.FilePath)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("exportService.ExportSite media %s: %w", m.FilePath, err)
+		}
+		file := exportMediaDir + "/" + exportMediaName(m)
+		if _, taken := export.Files[file]; taken {
+			file = exportMediaDir + "/" + m.ID.String() + "-" + exportMediaName(m)
+		}
+		export.Files[file] = body
+		for pageFile, content := range html {
+			// Meta tags such as og:image must stay absolute
+			content = strings.ReplaceAll(content, `content="`+m.PublicURL+`"`, `content="`+baseURL+"/"+file+`"`)
+			html[pageFile] = strings.ReplaceAll(content, m.PublicURL, relativePath(pageFile, file))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &domain.ExportMediaError{Files: missing}
+	}
+	for file, content := range html {
+		export.Files[file] = []byte(content)
+	}
+
+	// sitemap.xml and robots.txt
+	var urls []render.SitemapURL
+	for _, p := range pages {
+		if isNoIndex(p.page) {
+			continue
+		}
+		urls = append(urls, render.SitemapURL{Loc: links.absoluteURL(p.file), LastMod: p.page.PublishedAt})
+	}
+	sitemap, err := render.Sitemap(urls)
+	if err != nil {
+		return nil, fmt.Errorf("exportService.ExportSite: %w", err)
+	}
+	export.Files["sitemap.xml"] = sitemap
+	export.Files["robots.txt"] = render.Robots(render.RobotsRules{SitemapURL: baseURL + "/sitemap.xml"})
+
+	s.logger.Info().
+		Str("site_id", siteID.String()).
+		Int("pages", len(pages)).
+		Int("files", len(export.Files)).
+		Msg("site exported")
+
+	return export, nil
+}
+
+// publishedPages loads the published snapshot of every published page, ordered
+// by file path. Pages are read by row rather than looked up by their draft
+// slug, which may have changed since they were published.
+func (s *exportService) publishedPages(ctx context.Context, siteID uuid.UUID) ([]*exportedPage, error) {
+	published, err := s.pageRepo.FindPublishedPages(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("pages: %w", err)
+	}
+
+	var pages []*exportedPage
+	seen := make(map[string]bool)
+	for _, page := range published {
+		file := exportPageFile(page)
+		if file == "" || seen[file] {
+			continue
+		}
+		seen[file] = true
+		pages = append(pages, &exportedPage{page: page, file: file})
+	}
+
+	sort.Slice(pages, func(i, j int) bool { return pages[i].file < pages[j].file })
+	return pages, nil
+}
+
+// referencedMedia returns the site's media whose public URL appears in any page, ordered by path
+func (s *exportService) referencedMedia(ctx context.Context, siteID uuid.UUID, html map[string]string) ([]*domain.Media, error) {
+	pagination := domain.Pagination{Page: 1, PerPage: 100}
+
+	var referenced []*domain.Media
+	for {
+		batch, total, err := s.compRepo.FindMediaByFilter(ctx, siteID, pagination)
+		if err != nil {
+			return nil, fmt.Errorf("media: %w", err)
+		}
+		for _, m := range batch {
+			if m.PublicURL == "" {
+				continue
+			}
+			for _, content := range html {
+				if strings.Contains(content, m.PublicURL) {
+					referenced = append(referenced, m)
+					break
+				}
+			}
+		}
+		if len(batch) < pagination.PerPage || pagination.Page*pagination.PerPage >= total {
+			break
+		}
+		pagination.Page++
+	}
+
+	sort.Slice(referenced, func(i, j int) bool { return exportMediaName(referenced[i]) < exportMediaName(referenced[j]) })
+	return referenced, nil
+}
+
+// readMedia reads a media asset from storage
+func (s *exportService) readMedia(ctx context.Context, m *domain.Media) ([]byte, error) {
+	body, _, err := s.storage.Get(ctx, m.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, exportMaxMediaSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > exportMaxMediaSize {
+		return nil, fmt.Errorf("media exceeds %d bytes", exportMaxMediaSize)
+	}
+	return data, nil
+}
+
+// exportLinks maps site URLs to files inside an export
+type exportLinks struct {
+	baseURL string
+	hosts   map[string]bool
+	byPath  map[string]string    // "/about" → "about/index.html"
+	byID    map[uuid.UUID]string // page ID → file
+}
+
+// newExportLinks indexes the exported pages by URL path and page ID
+func newExportLinks(site *domain.Site, baseURL string, pages []*exportedPage) *exportLinks {
+	links := &exportLinks{
+		baseURL: baseURL,
+		hosts:   make(map[string]bool),
+		byPath: map[string]string{
+			"/sitemap.xml": "sitemap.xml",
+			"/robots.txt":  "robots.txt",
+		},
+		byID: make(map[uuid.UUID]string),
+	}
+	if u, err := url.Parse(baseURL); err == nil {
+		links.hosts[strings.ToLower(u.Hostname())] = true
+	}
+	if site.Domain != nil {
+		host := normalizeHost(*site.Domain)
+		for _, candidate := range hostCandidates(host) {
+			links.hosts[candidate] = true
+		}
+	}
+
+	for _, p := range pages {
+		links.byID[p.page.ID] = p.file
+		links.byPath["/"+p.page.Slug] = p.file
+		if p.page.IsHomepage {
+			links.byPath["/"] = p.file
+		}
+	}
+	return links
+}
+
+// navLink returns a LinkFunc that makes links to exported pages relative to from
+func (l *exportLinks) navLink(from string) render.LinkFunc {
+	return func(item *domain.NavigationItem) string {
+		raw := ""
+		if item.URL != nil {
+			raw = strings.TrimSpace(*item.URL)
+		}
+		if target, ok := l.resolve(raw); ok {
+			return relativePath(from, target.file) + target.fragment
+		}
+		if item.PageID != nil && raw == "" {
+			if file, ok := l.byID[*item.PageID]; ok {
+				return relativePath(from, file)
+			}
+		}
+		return raw
+	}
+}
+
+// exportTarget is a file inside the export plus the URL fragment to keep
+type exportTarget struct {
+	file     string
+	fragment string
+}
+
+// resolve maps a site-internal URL to an exported file
+func (l *exportLinks) resolve(raw string) (exportTarget, bool) {
+	if raw == "" || strings.HasPrefix(raw, "#") {
+		return exportTarget{}, false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return exportTarget{}, false
+	}
+	if u.Scheme != "" || u.Host != "" {
+		if (u.Scheme != "http" && u.Scheme != "https") || !l.hosts[strings.ToLower(u.Hostname())] {
+			return exportTarget{}, false
+		}
+	} else if !strings.HasPrefix(u.Path, "/") {
+		return exportTarget{}, false
+	}
+
+	p := u.Path
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	if p == "" {
+		p = "/"
+	}
+	file, ok := l.byPath[p]
+	if !ok {
+		return exportTarget{}, false
+	}
+
+	target := exportTarget{file: file}
+	if u.Fragment != "" {
+		target.fragment = "#" + u.Fragment
+	}
+	return target, true
+}
+
+// absoluteURL returns the public URL of an exported page file
+func (l *exportLinks) absoluteURL(file string) string {
+	return l.baseURL + "/" + strings.TrimSuffix(file, "index.html")
+}
+
+// exportBaseURL returns the base URL without a trailing slash
+func exportBaseURL(site *domain.Site, override *string) (string, error) {
+	raw := ""
+	if override != nil {
+		raw = strings.TrimSpace(*override)
+	} else if site.Domain != nil && *site.Domain != "" {
+		raw = "https://" + normalizeHost(*site.Domain)
+	}
+	if raw == "" {
+		return "", domain.ErrExportBaseURL
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", domain.ErrExportBaseURL
+	}
+	return strings.TrimSuffix(raw, "/"), nil
+}
+
+// exportPageFile returns the file a page is written to: index.html for the
+// homepage, <slug>/index.html otherwise. Unsafe slugs return "".
+func exportPageFile(page *domain.Page) string {
+	if page.IsHomepage {
+		return "index.html"
+	}
+	slug := path.Clean("/" + page.Slug)
+	if slug == "/" || strings.Contains(page.Slug, "..") {
+		return ""
+	}
+	return strings.TrimPrefix(slug, "/") + "/index.html"
+}
+
+// exportMediaName returns the file name of a media asset inside the export
+func exportMediaName(m *domain.Media) string {
+	name := path.Base(m.FilePath)
+	if name == "." || name == "/" || name == "" {
+		return m.ID.String()
+	}
+	return name
+}
+
+// relativePath returns the path to target relative to the directory of from.
+// Both are slash-separated paths from the export root.
+func relativePath(from, target string) string {
+	depth := strings.Count(from, "/")
+	return strings.Repeat("../", depth) + target
+}
+
+// isNoIndex reports whether a page asks not to be indexed
+func isNoIndex(page *domain.Page) bool {
+	return page.RobotsMeta != nil && strings.Contains(strings.ToLower(*page.RobotsMeta), "noindex")
+}
@@ -0,0 +1,253 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+func setupExportTest(t *testing.T) (*renderTestEnv, service.ExportService, *storage.Local) {
+	t.Helper()
+	env := setupRenderTest(t)
+	siteDomain := "example.com"
+	env.site.Domain = &siteDomain
+
+	// The local driver serves media from relative URLs, which an export can
+	// only copy by reading storage
+	store := storage.NewLocal(t.TempDir(), "/media")
+	if err := store.Put(context.Background(), "site/hero.png", strings.NewReader("png-bytes"), -1, "image/png"); err != nil {
+		t.Fatalf("failed to store media: %v", err)
+	}
+
+	renderer, err := render.New("")
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	svc := service.NewExportService(env.siteRepo, env.pageRepo, env.compRepo, store, renderer, zerolog.Nop())
+	return env, svc, store
+}
+
+func TestExportService_ExportSite(t *testing.T) {
+	env, svc, store := setupExportTest(t)
+	ctx := context.Background()
+
+	heroURL := store.PublicURL("site/hero.png")
+	env.compRepo.media = []*domain.Media{
+		{ID: uuid.New(), SiteID: env.site.ID, FilePath: "site/hero.png", PublicURL: heroURL},
+		{ID: uuid.New(), SiteID: env.site.ID, FilePath: "site/unused.png", PublicURL: store.PublicURL("site/unused.png")},
+	}
+
+	home := env.createPublishedPage(t, domain.CreatePageInput{
+		Title: "Home", Slug: "home", IsHomepage: true, OGImage: &heroURL,
+	}, map[domain.SectionType]map[string]string{
+		domain.SectionTypeHero: {"title": "Welcome", "hero_image": heroURL},
+	})
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "About", Slug: "about"}, nil)
+	noindex := "noindex, nofollow"
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Thanks", Slug: "thanks", RobotsMeta: &noindex}, nil)
+	if _, err := env.pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: env.site.ID, Title: "Draft", Slug: "draft", Status: domain.PageStatusDraft,
+	}, uuid.New()); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+
+	menu := &domain.NavigationMenu{ID: uuid.New(), SiteID: env.site.ID, Identifier: "header", IsActive: true}
+	env.compRepo.menus = []*domain.NavigationMenu{menu}
+	navURL := func(s string) *string { return &s }
+	env.compRepo.items = []*domain.NavigationItem{
+		{ID: uuid.New(), MenuID: menu.ID, Label: "Home", PageID: &home.ID, IsActive: true, SortOrder: 1},
+		{ID: uuid.New(), MenuID: menu.ID, Label: "Team", URL: navURL("/about#team"), IsActive: true, SortOrder: 2},
+		{ID: uuid.New(), MenuID: menu.ID, Label: "About", URL: navURL("https://www.example.com/about/"), IsActive: true, SortOrder: 3},
+		{ID: uuid.New(), MenuID: menu.ID, Label: "Docs", URL: navURL("https://docs.other.com/"), IsActive: true, SortOrder: 4},
+		{ID: uuid.New(), MenuID: menu.ID, Label: "Pricing", URL: navURL("/#pricing"), IsActive: true, SortOrder: 5},
+	}
+
+	export, err := svc.ExportSite(ctx, env.site.ID, domain.ExportSiteInput{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	wantPaths := []string{"about/index.html", "index.html", "media/hero.png", "robots.txt", "sitemap.xml", "thanks/index.html"}
+	if got := export.Paths(); strings.Join(got, ",") != strings.Join(wantPaths, ",") {
+		t.Fatalf("expected files %v, got %v", wantPaths, got)
+	}
+	if string(export.Files["media/hero.png"]) != "png-bytes" {
+		t.Error("expected referenced media to be copied")
+	}
+
+	index := string(export.Files["index.html"])
+	about := string(export.Files["about/index.html"])
+	for _, tc := range []struct {
+		name, html, want string
+	}{
+		{"relative media", index, `<img src="media/hero.png"`},
+		{"absolute og:image", index, `<meta property="og:image" content="https://example.com/media/hero.png">`},
+		{"homepage by page ID", about, `<a href="../index.html">Home</a>`},
+		{"path with fragment", about, `<a href="../about/index.html#team">Team</a>`},
+		{"absolute URL on the site domain", about, `<a href="../about/index.html">About</a>`},
+		{"external URL", about, `<a href="https://docs.other.com/">Docs</a>`},
+		{"homepage fragment", index, `<a href="index.html#pricing">Pricing</a>`},
+		{"logo link", about, `<a href="../index.html">Acme</a>`},
+	} {
+		if !strings.Contains(tc.html, tc.want) {
+			t.Errorf("%s: expected %q in exported HTML", tc.name, tc.want)
+		}
+	}
+	if strings.Contains(index, heroURL) {
+		t.Error("expected no remaining references to the remote media URL")
+	}
+
+	sitemap := string(export.Files["sitemap.xml"])
+	for _, want := range []string{"<loc>https://example.com/</loc>", "<loc>https://example.com/about/</loc>"} {
+		if !strings.Contains(sitemap, want) {
+			t.Errorf("expected sitemap to contain %q", want)
+		}
+	}
+	if strings.Contains(sitemap, "thanks") || strings.Contains(sitemap, "draft") {
+		t.Error("expected noindex and draft pages to be left out of the sitemap")
+	}
+	if !strings.Contains(string(export.Files["robots.txt"]), "Sitemap: https://example.com/sitemap.xml") {
+		t.Error("expected robots.txt to point at the sitemap")
+	}
+}
+
+func TestExportService_ExportSite_DeterministicZip(t *testing.T) {
+	env, svc, _ := setupExportTest(t)
+	ctx := context.Background()
+
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Home", Slug: "home", IsHomepage: true}, nil)
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "About", Slug: "about"}, nil)
+
+	archive := func() []byte {
+		export, err := svc.ExportSite(ctx, env.site.ID, domain.ExportSiteInput{})
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := export.WriteZip(&buf); err != nil {
+			t.Fatalf("expected no error writing zip, got: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first, second := archive(), archive()
+	if !bytes.Equal(first, second) {
+		t.Fatal("expected identical archives for identical content")
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatalf("expected a valid zip, got: %v", err)
+	}
+	if len(zr.File) != 4 || zr.File[0].Name != "about/index.html" {
+		t.Errorf("expected 4 sorted entries, got %d starting with %q", len(zr.File), zr.File[0].Name)
+	}
+}
+
+func TestExportService_ExportSite_BaseURL(t *testing.T) {
+	env, svc, _ := setupExportTest(t)
+	ctx := context.Background()
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Home", Slug: "home", IsHomepage: true}, nil)
+
+	custom := "https://cdn.example.net/landing/"
+	invalid := "ftp://example.net"
+	tests := []struct {
+		name    string
+		domain  *string
+		baseURL *string
+		want    string
+		wantErr error
+	}{
+		{name: "site domain", domain: env.site.Domain, want: "<loc>https://example.com/</loc>"},
+		{name: "explicit base URL", domain: env.site.Domain, baseURL: &custom, want: "<loc>https://cdn.example.net/landing/</loc>"},
+		{name: "no domain", wantErr: domain.ErrExportBaseURL},
+		{name: "invalid base URL", domain: env.site.Domain, baseURL: &invalid, wantErr: domain.ErrExportBaseURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.site.Domain = tt.domain
+			export, err := svc.ExportSite(ctx, env.site.ID, domain.ExportSiteInput{BaseURL: tt.baseURL})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if !strings.Contains(string(export.Files["sitemap.xml"]), tt.want) {
+				t.Errorf("expected sitemap to contain %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestExportService_ExportSite_RenamedAfterPublishing(t *testing.T) {
+	env, svc, _ := setupExportTest(t)
+	ctx := context.Background()
+
+	about := env.createPublishedPage(t, domain.CreatePageInput{Title: "About", Slug: "about"}, nil)
+	pricing := env.createPublishedPage(t, domain.CreatePageInput{Title: "Pricing", Slug: "pricing"}, nil)
+
+	// Draft edits that are not published yet: the published pages keep their
+	// slugs, even though another draft now uses one of them
+	rename := func(page *domain.Page, slug string) {
+		if _, err := env.pageSvc.UpdatePage(ctx, page.ID, domain.UpdatePageInput{Slug: &slug}, uuid.New()); err != nil {
+			t.Fatalf("failed to rename page: %v", err)
+		}
+	}
+	rename(about, "team")
+	rename(pricing, "about")
+
+	export, err := svc.ExportSite(ctx, env.site.ID, domain.ExportSiteInput{})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if _, ok := export.Files["team/index.html"]; ok {
+		t.Error("expected the unpublished slug to be left out")
+	}
+	for file, title := range map[string]string{"about/index.html": "<title>About", "pricing/index.html": "<title>Pricing"} {
+		if !strings.Contains(string(export.Files[file]), title) {
+			t.Errorf("expected %s to hold the published page %q", file, title)
+		}
+	}
+}
+
+func TestExportService_ExportSite_MissingMedia(t *testing.T) {
+	env, svc, store := setupExportTest(t)
+	ctx := context.Background()
+
+	logoURL := store.PublicURL("site/logo.png")
+	env.compRepo.media = []*domain.Media{
+		{ID: uuid.New(), SiteID: env.site.ID, FilePath: "site/logo.png", PublicURL: logoURL},
+	}
+	env.createPublishedPage(t, domain.CreatePageInput{
+		Title: "Home", Slug: "home", IsHomepage: true,
+	}, map[domain.SectionType]map[string]string{
+		domain.SectionTypeHero: {"title": "Welcome", "hero_image": logoURL},
+	})
+
+	_, err := svc.ExportSite(ctx, env.site.ID, domain.ExportSiteInput{})
+	var mediaErr *domain.ExportMediaError
+	if !errors.As(err, &mediaErr) {
+		t.Fatalf("expected ExportMediaError, got: %v", err)
+	}
+	if len(mediaErr.Files) != 1 || mediaErr.Files[0] != "site/logo.png" {
+		t.Errorf("expected the missing file to be reported, got %v", mediaErr.Files)
+	}
+	if !errors.Is(err, domain.ErrExportMediaMissing) {
+		t.Error("expected errors.Is to match ErrExportMediaMissing")
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/diskcache"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/imageproc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// settingImageSizes replaces the default size allowlist of a site, in the
+// format of domain.ParseImageSizes
+const settingImageSizes = "image_sizes"
+
+// imageSizesTTL bounds how long an allowlist is reused. Changing the setting
+// through this instance drops it at once; other replicas pick it up once
+// the TTL expires.
+const imageSizesTTL = time.Minute
+
+// imageTransformVersion is part of every cache key; bump it when the output
+// of a transform changes, so stale files are no longer served
+const imageTransformVersion = 1
+
+// ImageService defines the interface for on-the-fly image transforms
+type ImageService interface {
+	// Transform returns a resized, cropped or converted copy of an image. It
+	// returns domain.ErrNotFound for missing media or media of an inactive
+	// site, domain.ErrImageSizeNotAllowed for a size missing from the site's
+	// allowlist and domain.ErrImageNotTransformable for images it cannot
+	// decode, such as SVG or an animated GIF or WebP.
+	Transform(ctx context.Context, mediaID uuid.UUID, t domain.ImageTransform) (*domain.TransformedImage, error)
+	// AllowedSizes returns the size allowlist of a site
+	AllowedSizes(ctx context.Context, siteID uuid.UUID) ([]domain.ImageSize, error)
+	// InvalidateSite drops the cached allowlist of a site
+	InvalidateSite(siteID uuid.UUID)
+}
+
+// ImageOptions holds the settings of ImageService
+type ImageOptions struct {
+	// DefaultSizes is the allowlist of sites without the image_sizes setting
+	DefaultSizes []domain.ImageSize
+	// DefaultQuality is the JPEG quality used when none is requested
+	DefaultQuality int
+	// MaxPixels is the largest image decoded
+	MaxPixels int
+}
+
+// imageService implements ImageService
+type imageService struct {
+	compRepo repository.ComponentRepository
+	siteRepo repository.SiteRepository
+	storage  storage.Backend
+	cache    *diskcache.Cache
+	opts     ImageOptions
+	logger   zerolog.Logger
+
+	mu      sync.Mutex
+	sizes   map[uuid.UUID]imageSizesEntry
+	flights map[string]*imageFlight
+}
+
+// imageSizesEntry is the cached allowlist of a site
+type imageSizesEntry struct {
+	sizes     []domain.ImageSize
+	expiresAt time.Time
+}
+
+// imageFlight is a transform in progress; concurrent requests for the same
+// output wait for it instead of decoding the original again
+type imageFlight struct {
+	done  chan struct{}
+	image *domain.TransformedImage
+	err   error
+}
+
+// NewImageService creates a new ImageService
+func NewImageService(
+	compRepo repository.ComponentRepository,
+	siteRepo repository.SiteRepository,
+	store storage.Backend,
+	cache *diskcache.Cache,
+	opts ImageOptions,
+	logger zerolog.Logger,
+) ImageService {
+	return &imageService{
+		compRepo: compRepo,
+		siteRepo: siteRepo,
+		storage:  store,
+		cache:    cache,
+		opts:     opts,
+		logger:   logger,
+		sizes:    make(map[uuid.UUID]imageSizesEntry),
+		flights:  make(map[string]*imageFlight),
+	}
+}
+
+// Transform returns a transformed copy of an image, from the cache when it
+// was generated before
+func (s *imageService) Transform(ctx context.Context, mediaID uuid.UUID, t domain.ImageTransform) (*domain.TransformedImage, error) {
+	media, err := s.compRepo.FindMediaByID(ctx, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("imageService.Transform: %w", err)
+	}
+	sizes, err := s.AllowedSizes(ctx, media.SiteID)
+	if err != nil {
+		return nil, fmt.Errorf("imageService.Transform: %w", err)
+	}
+	if !slices.Contains(sizes, domain.ImageSize{Width: t.Width, Height: t.Height}) {
+		return nil, fmt.Errorf("imageService.Transform: %w", domain.ErrImageSizeNotAllowed)
+	}
+	switch media.MimeType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		return nil, fmt.Errorf("imageService.Transform: %w", domain.ErrImageNotTransformable)
+	}
+
+	if t.Fit == "" {
+		t.Fit = imageproc.FitContain
+	}
+	if t.Quality == 0 {
+		t.Quality = s.opts.DefaultQuality
+	}
+	if t.Format != imageproc.FormatJPEG && !(t.Format == "" && media.MimeType == "image/jpeg") {
+		// Only JPEG output has a quality; one cache entry serves them all
+		t.Quality = 0
+	}
+	format := t.Format
+	switch {
+	case format != "":
+		t.AcceptWebP = false
+	case t.AcceptWebP:
+		format = "auto+webp"
+	default:
+		format = "auto"
+	}
+
+	// The file path is unique per upload, so copies of a media row share
+	// their cache entries
+	key := fmt.Sprintf("v%d|%s|%d|%dx%d|%s|%s|%d",
+		imageTransformVersion, media.FilePath, media.FileSize, t.Width, t.Height, t.Fit, format, t.Quality)
+	if data, entry, ok := s.cache.Get(key); ok {
+		return transformedImage(data, entry, t), nil
+	}
+
+	img, err := s.do(ctx, key, func() (*domain.TransformedImage, error) {
+		// Waiting requests share the result, so one hanging up must not
+		// cancel it
+		return s.generate(context.WithoutCancel(ctx), key, media, t)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("imageService.Transform: %w", err)
+	}
+	return img, nil
+}
+
+// generate reads the original and transforms it, caching the result
+func (s *imageService) generate(ctx context.Context, key string, media *domain.Media, t domain.ImageTransform) (*domain.TransformedImage, error) {
+	body, _, err := s.storage.Get(ctx, media.FilePath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := imageproc.DecodeConfig(data)
+	if err != nil || ((cfg.Format == imageproc.FormatGIF || cfg.Format == imageproc.FormatWebP) && imageproc.IsAnimated(data)) {
+		return nil, domain.ErrImageNotTransformable
+	}
+	src, srcFormat, err := imageproc.Decode(data, s.opts.MaxPixels)
+	if err != nil {
+		return nil, domain.ErrImageNotTransformable
+	}
+	img := imageproc.Transform(src, t.Width, t.Height, t.Fit)
+
+	format := t.Format
+	if format == "" {
+		format = imageproc.EncodedFormat(srcFormat)
+	}
+	var buf bytes.Buffer
+	if err := imageproc.Encode(&buf, img, format, t.Quality); err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	if t.AcceptWebP && format != imageproc.FormatWebP {
+		// The WebP encoder is lossless, which rarely beats JPEG photos
+		var webp bytes.Buffer
+		if err := imageproc.EncodeWebP(&webp, img); err != nil {
+			return nil, err
+		}
+		if webp.Len() < len(out) {
+			format, out = imageproc.FormatWebP, webp.Bytes()
+		}
+	}
+
+	entry, err := s.cache.Put(key, imageproc.MimeType(format), out)
+	if err != nil {
+		// Served uncached; entry still holds the digest
+		s.logger.Error().Err(err).Str("media_id", media.ID.String()).Msg("failed to cache transformed image")
+	}
+	return transformedImage(out, entry, t), nil
+}
+
+// do runs fn once for concurrent calls with the same key
+func (s *imageService) do(ctx context.Context, key string, fn func() (*domain.TransformedImage, error)) (*domain.TransformedImage, error) {
+	s.mu.Lock()
+	if f, ok := s.flights[key]; ok {
+		s.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.image, f.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f := &imageFlight{done: make(chan struct{})}
+	s.flights[key] = f
+	s.mu.Unlock()
+
+	f.image, f.err = fn()
+
+	s.mu.Lock()
+	delete(s.flights, key)
+	s.mu.Unlock()
+	close(f.done)
+	return f.image, f.err
+}
+
+// AllowedSizes returns the image_sizes setting of a site, or the default
+// allowlist when it is unset or invalid
+func (s *imageService) AllowedSizes(ctx context.Context, siteID uuid.UUID) ([]domain.ImageSize, error) {
+	s.mu.Lock()
+	entry, ok := s.sizes[siteID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.sizes, nil
+	}
+
+	site, err := s.siteRepo.FindByID(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("imageService.AllowedSizes: %w", err)
+	}
+	if !site.IsActive {
+		return nil, fmt.Errorf("imageService.AllowedSizes: %w", domain.ErrNotFound)
+	}
+
+	sizes := s.opts.DefaultSizes
+	setting, err := s.siteRepo.FindSettingByKey(ctx, siteID, settingImageSizes)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("imageService.AllowedSizes setting: %w", err)
+	case setting.Value != nil && strings.TrimSpace(*setting.Value) != "":
+		parsed, err := domain.ParseImageSizes(*setting.Value)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("site_id", siteID.String()).Msg("invalid image_sizes setting, using the defaults")
+			break
+		}
+		sizes = parsed
+	}
+
+	s.mu.Lock()
+	s.sizes[siteID] = imageSizesEntry{sizes: sizes, expiresAt: time.Now().Add(imageSizesTTL)}
+	s.mu.Unlock()
+	return sizes, nil
+}
+
+// InvalidateSite drops the cached allowlist of a site
+func (s *imageService) InvalidateSite(siteID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sizes, siteID)
+}
+
+// transformedImage builds the result of a transform. The ETag is a strong
+// tag: it changes with every byte of the output.
+func transformedImage(data []byte, entry diskcache.Entry, t domain.ImageTransform) *domain.TransformedImage {
+	return &domain.TransformedImage{
+		Data:        data,
+		ContentType: entry.ContentType,
+		ETag:        `"` + entry.Digest[:32] + `"`,
+		Negotiated:  t.Format == "",
+	}
+}
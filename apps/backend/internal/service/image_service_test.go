@@ -0,0 +1,240 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/diskcache"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/imageproc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+func (m *mockComponentRepository) FindMediaByID(ctx context.Context, id uuid.UUID) (*domain.Media, error) {
+	for _, item := range m.media {
+		if item.ID == id {
+			return item, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+type imageServiceFixture struct {
+	svc      service.ImageService
+	store    *storage.Local
+	siteRepo *mockSiteRepository
+	compRepo *mockComponentRepository
+	cache    *diskcache.Cache
+	siteID   uuid.UUID
+}
+
+func newImageServiceFixture(t *testing.T) *imageServiceFixture {
+	t.Helper()
+	cache, err := diskcache.Open(t.TempDir(), 1<<24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &imageServiceFixture{
+		store:    storage.NewLocal(t.TempDir(), "/media"),
+		siteRepo: newMockSiteRepository(),
+		compRepo: &mockComponentRepository{},
+		cache:    cache,
+		siteID:   uuid.New(),
+	}
+	f.siteRepo.sites[f.siteID] = &domain.Site{ID: f.siteID, Slug: "acme", IsActive: true}
+	f.svc = service.NewImageService(f.compRepo, f.siteRepo, f.store, cache, service.ImageOptions{
+		DefaultSizes:   []domain.ImageSize{{Width: 480}, {Width: 200, Height: 200}, {Height: 100}},
+		DefaultQuality: 80,
+		MaxPixels:      10_000_000,
+	}, zerolog.Nop())
+	return f
+}
+
+// addImage stores an image and its media row
+func (f *imageServiceFixture) addImage(t *testing.T, format string, data []byte) *domain.Media {
+	t.Helper()
+	key := uuid.NewString() + imageproc.Extension(format)
+	if err := f.store.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), imageproc.MimeType(format)); err != nil {
+		t.Fatal(err)
+	}
+	media := &domain.Media{ID: uuid.New(), SiteID: f.siteID, FilePath: key, MimeType: imageproc.MimeType(format), FileSize: int64(len(data)), Type: "image"}
+	f.compRepo.media = append(f.compRepo.media, media)
+	return media
+}
+
+func TestImageService_Transform(t *testing.T) {
+	f := newImageServiceFixture(t)
+	ctx := context.Background()
+	media := f.addImage(t, "jpeg", encodeTestImage(t, "jpeg", 1000, 500))
+
+	cases := []struct {
+		transform    domain.ImageTransform
+		wantW, wantH int
+		wantType     string
+	}{
+		{domain.ImageTransform{Width: 480}, 480, 240, "image/jpeg"},
+		{domain.ImageTransform{Width: 200, Height: 200, Fit: "cover"}, 200, 200, "image/jpeg"},
+		{domain.ImageTransform{Width: 200, Height: 200}, 200, 100, "image/jpeg"},
+		{domain.ImageTransform{Height: 100, Format: "png"}, 200, 100, "image/png"},
+		{domain.ImageTransform{Width: 480, Format: "webp"}, 480, 240, "image/webp"},
+	}
+	etags := map[string]bool{}
+	for _, tc := range cases {
+		img, err := f.svc.Transform(ctx, media.ID, tc.transform)
+		if err != nil {
+			t.Fatalf("%+v: expected no error, got %v", tc.transform, err)
+		}
+		cfg, err := imageproc.DecodeConfig(img.Data)
+		if err != nil {
+			t.Fatalf("%+v: %v", tc.transform, err)
+		}
+		if cfg.Width != tc.wantW || cfg.Height != tc.wantH || img.ContentType != tc.wantType {
+			t.Errorf("%+v: got %s %dx%d, want %s %dx%d", tc.transform, img.ContentType, cfg.Width, cfg.Height, tc.wantType, tc.wantW, tc.wantH)
+		}
+		if img.Negotiated != (tc.transform.Format == "") {
+			t.Errorf("%+v: unexpected Negotiated %v", tc.transform, img.Negotiated)
+		}
+		if etags[img.ETag] {
+			t.Errorf("%+v: ETag %s reused for different output", tc.transform, img.ETag)
+		}
+		etags[img.ETag] = true
+	}
+	if f.cache.Len() != len(cases) {
+		t.Errorf("expected %d cached images, got %d", len(cases), f.cache.Len())
+	}
+
+	// Cached output no longer needs the original
+	first, _ := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480})
+	f.store.Delete(ctx, media.FilePath)
+	again, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480})
+	if err != nil || again.ETag != first.ETag || !bytes.Equal(again.Data, first.Data) {
+		t.Errorf("expected the cached image, got %v", err)
+	}
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480, Quality: 50}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an uncached transform of a missing file, got %v", err)
+	}
+
+	// WebP originals are decoded too
+	webp := f.addImage(t, "webp", encodeTestImage(t, "webp", 1000, 500))
+	img, err := f.svc.Transform(ctx, webp.ID, domain.ImageTransform{Width: 480})
+	if err != nil {
+		t.Fatalf("webp: expected no error, got %v", err)
+	}
+	if cfg, _ := imageproc.DecodeConfig(img.Data); img.ContentType != "image/webp" || cfg.Width != 480 || cfg.Height != 240 {
+		t.Errorf("webp: got %s %+v", img.ContentType, cfg)
+	}
+}
+
+func TestImageService_Transform_NegotiatesWebP(t *testing.T) {
+	f := newImageServiceFixture(t)
+	ctx := context.Background()
+	// Flat blocks compress better as lossless WebP than as PNG
+	media := f.addImage(t, "png", encodeTestImage(t, "png", 1000, 500))
+
+	plain, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480})
+	if err != nil {
+		t.Fatal(err)
+	}
+	webp, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480, AcceptWebP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.ContentType != "image/png" || webp.ContentType != "image/webp" {
+		t.Errorf("expected PNG without and WebP with Accept, got %s and %s", plain.ContentType, webp.ContentType)
+	}
+	if !plain.Negotiated || !webp.Negotiated || plain.ETag == webp.ETag {
+		t.Errorf("expected negotiated images with different ETags, got %+v %+v", plain.ETag, webp.ETag)
+	}
+
+	// An explicit format ignores Accept and shares one cache entry
+	explicit, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480, Format: "png", AcceptWebP: true})
+	if err != nil || explicit.ContentType != "image/png" || explicit.Negotiated || explicit.ETag != plain.ETag {
+		t.Errorf("unexpected explicit PNG %+v, %v", explicit, err)
+	}
+}
+
+func TestImageService_Transform_Allowlist(t *testing.T) {
+	f := newImageServiceFixture(t)
+	ctx := context.Background()
+	media := f.addImage(t, "png", encodeTestImage(t, "png", 600, 300))
+
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 481}); !errors.Is(err, domain.ErrImageSizeNotAllowed) {
+		t.Errorf("expected ErrImageSizeNotAllowed, got %v", err)
+	}
+	// 480 alone is allowed, 480 within a box is not
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480, Height: 480}); !errors.Is(err, domain.ErrImageSizeNotAllowed) {
+		t.Errorf("expected ErrImageSizeNotAllowed, got %v", err)
+	}
+
+	value := "64x64, 300"
+	f.siteRepo.settings[f.siteID.String()+":image_sizes"] = &domain.SiteSetting{SiteID: f.siteID, Key: "image_sizes", Value: &value}
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 300}); !errors.Is(err, domain.ErrImageSizeNotAllowed) {
+		t.Errorf("expected the cached allowlist until invalidated, got %v", err)
+	}
+	f.svc.InvalidateSite(f.siteID)
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 300}); err != nil {
+		t.Errorf("expected the site's size to be allowed, got %v", err)
+	}
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480}); !errors.Is(err, domain.ErrImageSizeNotAllowed) {
+		t.Errorf("expected the setting to replace the defaults, got %v", err)
+	}
+
+	// An invalid setting falls back to the defaults
+	invalid := "huge"
+	f.siteRepo.settings[f.siteID.String()+":image_sizes"].Value = &invalid
+	f.svc.InvalidateSite(f.siteID)
+	sizes, err := f.svc.AllowedSizes(ctx, f.siteID)
+	if err != nil || len(sizes) != 3 {
+		t.Errorf("expected the 3 default sizes, got %v, %v", sizes, err)
+	}
+}
+
+func TestImageService_Transform_Errors(t *testing.T) {
+	f := newImageServiceFixture(t)
+	ctx := context.Background()
+
+	if _, err := f.svc.Transform(ctx, uuid.New(), domain.ImageTransform{Width: 480}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing media, got %v", err)
+	}
+
+	svg := f.addImage(t, "png", []byte("<svg/>"))
+	svg.MimeType = "image/svg+xml"
+	truncated := f.addImage(t, "webp", []byte("RIFF\x00\x00\x00\x00WEBP"))
+	broken := f.addImage(t, "png", []byte("not a png"))
+	for name, media := range map[string]*domain.Media{"svg": svg, "truncated webp": truncated, "broken": broken} {
+		if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480}); !errors.Is(err, domain.ErrImageNotTransformable) {
+			t.Errorf("%s: expected ErrImageNotTransformable, got %v", name, err)
+		}
+	}
+
+	inactive := uuid.New()
+	f.siteRepo.sites[inactive] = &domain.Site{ID: inactive, IsActive: false}
+	media := f.addImage(t, "png", encodeTestImage(t, "png", 600, 300))
+	media.SiteID = inactive
+	if _, err := f.svc.Transform(ctx, media.ID, domain.ImageTransform{Width: 480}); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an inactive site, got %v", err)
+	}
+}
+
+func TestParseImageSizes(t *testing.T) {
+	sizes, err := domain.ParseImageSizes(" 480, x600 ,320X320,,")
+	want := []domain.ImageSize{{Width: 480}, {Height: 600}, {Width: 320, Height: 320}}
+	if err != nil || len(sizes) != len(want) {
+		t.Fatalf("got %v, %v", sizes, err)
+	}
+	for i := range want {
+		if sizes[i] != want[i] || sizes[i].String() != []string{"480", "x600", "320x320"}[i] {
+			t.Errorf("size %d: got %v, want %v", i, sizes[i], want[i])
+		}
+	}
+	for _, raw := range []string{"x", "0", "0x10", "10x", "-5", "9000", "ax1"} {
+		if _, err := domain.ParseImageSizes(raw); err == nil {
+			t.Errorf("ParseImageSizes(%q): expected an error", raw)
+		}
+	}
+}
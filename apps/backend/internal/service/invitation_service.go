@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/mailer"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// InvitationService defines the interface for inviting users by email
+type InvitationService interface {
+	// InviteUser creates a pending user and emails them an invitation link.
+	// actorRole must be allowed to assign the role. Inviting the email of a
+	// user who is still pending replaces their earlier invitation; changing
+	// their role also needs the permissions of the role they had.
+	InviteUser(ctx context.Context, input domain.InviteUserInput, invitedBy uuid.UUID, actorRole domain.UserRole) (*domain.Invitation, error)
+	// ListPending lists invitations that were neither accepted nor revoked
+	ListPending(ctx context.Context) ([]*domain.Invitation, error)
+	// ResendInvitation emails a new link and restarts the expiry; the old
+	// link stops working
+	ResendInvitation(ctx context.Context, id uuid.UUID) (*domain.Invitation, error)
+	// RevokeInvitation stops a pending invitation. The user stays pending and
+	// cannot sign in until invited again.
+	RevokeInvitation(ctx context.Context, id uuid.UUID) error
+	// AcceptInvitation sets the password of the invited user, verifies their
+	// email and activates the account
+	AcceptInvitation(ctx context.Context, input domain.AcceptInvitationInput) (*domain.User, error)
+}
+
+// InvitationOptions holds the settings of InvitationService
+type InvitationOptions struct {
+	// AcceptURL is the page invitation links point to, with ?token= appended
+	AcceptURL  string
+	Expiry     time.Duration
+	BcryptCost int
+}
+
+// invitationService implements InvitationService
+type invitationService struct {
+	invitationRepo repository.InvitationRepository
+	userRepo       repository.UserRepository
+	roleService    RoleService
+	mailer         mailer.Mailer
+	opts           InvitationOptions
+	logger         zerolog.Logger
+}
+
+// NewInvitationService creates a new InvitationService
+func NewInvitationService(
+	invitationRepo repository.InvitationRepository,
+	userRepo repository.UserRepository,
+	roleService RoleService,
+	mail mailer.Mailer,
+	opts InvitationOptions,
+	logger zerolog.Logger,
+) InvitationService {
+	return &invitationService{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		roleService:    roleService,
+		mailer:         mail,
+		opts:           opts,
+		logger:         logger,
+	}
+}
+
+// InviteUser creates or refreshes a pending user, stores a new invitation and
+// sends it
+func (s *invitationService) InviteUser(ctx context.Context, input domain.InviteUserInput, invitedBy uuid.UUID, actorRole domain.UserRole) (*domain.Invitation, error) {
+	email := strings.TrimSpace(input.Email)
+	fullName := strings.TrimSpace(input.FullName)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, fmt.Errorf("invitationService.InviteUser: %w: invalid email", domain.ErrValidation)
+	}
+	if len(fullName) < 2 || len(fullName) > 255 {
+		return nil, fmt.Errorf("invitationService.InviteUser: %w: full name must be 2 to 255 characters", domain.ErrValidation)
+	}
+
+	if err := s.roleService.CheckAssign(ctx, actorRole, input.Role); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("invitationService.InviteUser: %w: unknown role", domain.ErrValidation)
+		}
+		return nil, fmt.Errorf("invitationService.InviteUser: %w", err)
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if user.Status != domain.StatusPending {
+			return nil, domain.ErrAlreadyExists
+		}
+		// Invited again before accepting: the new invitation wins. Like
+		// changing a user's role, that takes the permissions of both roles.
+		if user.Role != input.Role {
+			if err := s.roleService.CheckAssign(ctx, actorRole, user.Role); err != nil {
+				return nil, fmt.Errorf("invitationService.InviteUser: %w", err)
+			}
+		}
+		user.FullName = fullName
+		user.Role = input.Role
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("invitationService.InviteUser update user: %w", err)
+		}
+		if err := s.invitationRepo.RevokeByUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("invitationService.InviteUser revoke: %w", err)
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		// No password yet: an empty hash never matches, and pending users
+		// cannot sign in anyway
+		user = &domain.User{
+			ID:       uuid.New(),
+			Email:    email,
+			FullName: fullName,
+			Role:     input.Role,
+			Status:   domain.StatusPending,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, fmt.Errorf("invitationService.InviteUser create user: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invitationService.InviteUser find user: %w", err)
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("invitationService.InviteUser: %w", err)
+	}
+	invitation := &domain.Invitation{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		FullName:  user.FullName,
+		Role:      user.Role,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: time.Now().Add(s.opts.Expiry),
+		InvitedBy: &invitedBy,
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("invitationService.InviteUser store: %w", err)
+	}
+
+	// The invitation is kept when sending fails, so it can be resent
+	if err := s.mailer.Send(ctx, s.invitationMessage(invitation, token)); err != nil {
+		return nil, fmt.Errorf("invitationService.InviteUser send: %w", err)
+	}
+
+	s.logger.Info().
+		Str("user_id", user.ID.String()).
+		Str("role", string(user.Role)).
+		Str("invited_by", invitedBy.String()).
+		Time("expires_at", invitation.ExpiresAt).
+		Msg("user invited")
+
+	return invitation, nil
+}
+
+// ListPending retrieves the pending invitations
+func (s *invitationService) ListPending(ctx context.Context) ([]*domain.Invitation, error) {
+	invitations, err := s.invitationRepo.FindPending(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("invitationService.ListPending: %w", err)
+	}
+	return invitations, nil
+}
+
+// ResendInvitation replaces the token of a pending invitation and sends it
+// again
+func (s *invitationService) ResendInvitation(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+	invitation, err := s.invitationRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("invitationService.ResendInvitation: %w", err)
+	}
+	if !invitation.IsPending() {
+		return nil, fmt.Errorf("invitationService.ResendInvitation: %w", domain.ErrNotFound)
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("invitationService.ResendInvitation: %w", err)
+	}
+	tokenHash := auth.HashToken(token)
+	expiresAt := time.Now().Add(s.opts.Expiry)
+	if err := s.invitationRepo.Renew(ctx, id, tokenHash, expiresAt); err != nil {
+		return nil, fmt.Errorf("invitationService.ResendInvitation renew: %w", err)
+	}
+	invitation.TokenHash = tokenHash
+	invitation.ExpiresAt = expiresAt
+	invitation.SentAt = time.Now()
+	invitation.SendCount++
+
+	if err := s.mailer.Send(ctx, s.invitationMessage(invitation, token)); err != nil {
+		return nil, fmt.Errorf("invitationService.ResendInvitation send: %w", err)
+	}
+
+	s.logger.Info().
+		Str("invitation_id", id.String()).
+		Int("send_count", invitation.SendCount).
+		Msg("invitation resent")
+
+	return invitation, nil
+}
+
+// RevokeInvitation revokes a pending invitation
+func (s *invitationService) RevokeInvitation(ctx context.Context, id uuid.UUID) error {
+	if err := s.invitationRepo.Revoke(ctx, id); err != nil {
+		return fmt.Errorf("invitationService.RevokeInvitation: %w", err)
+	}
+	s.logger.Info().Str("invitation_id", id.String()).Msg("invitation revoked")
+	return nil
+}
+
+// AcceptInvitation consumes an invitation token and activates the user with
+// the chosen password
+func (s *invitationService) AcceptInvitation(ctx context.Context, input domain.AcceptInvitationInput) (*domain.User, error) {
+	if len(input.Password) < minPasswordLength || len(input.Password) > maxPasswordLength {
+		return nil, fmt.Errorf("invitationService.AcceptInvitation: %w: password must be %d to %d characters",
+			domain.ErrValidation, minPasswordLength, maxPasswordLength)
+	}
+
+	invitation, err := s.invitationRepo.FindByTokenHash(ctx, auth.HashToken(input.Token))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("invitationService.AcceptInvitation find invitation: %w", err)
+	}
+	if !invitation.IsValid() {
+		return nil, domain.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.FindByID(ctx, invitation.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("invitationService.AcceptInvitation find user: %w", err)
+	}
+	// An admin may have deactivated the user in the meantime
+	if user.Status != domain.StatusPending {
+		return nil, domain.ErrInvalidToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), s.opts.BcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("invitationService.AcceptInvitation hash: %w", err)
+	}
+
+	// Claiming the invitation and activating the user happen together; a
+	// concurrent accept with the same token gets ErrNotFound here
+	if err := s.invitationRepo.Accept(ctx, invitation.ID, string(hashedPassword)); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, domain.ErrInvalidToken
+		}
+		return nil, fmt.Errorf("invitationService.AcceptInvitation accept: %w", err)
+	}
+	user.PasswordHash = string(hashedPassword)
+	user.Status = domain.StatusActive
+	user.EmailVerified = true
+
+	s.logger.Info().
+		Str("user_id", user.ID.String()).
+		Msg("invitation accepted")
+
+	return user, nil
+}
+
+// invitationMessage builds the invitation email
+func (s *invitationService) invitationMessage(invitation *domain.Invitation, token string) mailer.Message {
+	link := s.opts.AcceptURL
+	if strings.Contains(link, "?") {
+		link += "&token=" + url.QueryEscape(token)
+	} else {
+		link += "?token=" + url.QueryEscape(token)
+	}
+
+	body := fmt.Sprintf(`Hi %s,
+
+You have been invited to the CMS as %s. Open this link to choose your password and activate your account:
+
+%s
+
+The link can be used once and expires in %s. If you were not expecting this, you can ignore this email.
+`, invitation.FullName, invitation.Role, link, s.opts.Expiry)
+
+	return mailer.Message{
+		To:      invitation.Email,
+		Subject: "You have been invited",
+		Body:    body,
+	}
+}
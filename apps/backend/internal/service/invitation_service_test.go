@@ -0,0 +1,287 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/mailer"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ─── Mock InvitationRepository ────────────────────────────────────────────────
+
+type mockInvitationRepository struct {
+	invitations map[uuid.UUID]*domain.Invitation
+	users       *mockUserRepository // activated by Accept
+}
+
+func newMockInvitationRepository(users *mockUserRepository) *mockInvitationRepository {
+	return &mockInvitationRepository{invitations: make(map[uuid.UUID]*domain.Invitation), users: users}
+}
+
+func (m *mockInvitationRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Invitation, error) {
+	if invitation, ok := m.invitations[id]; ok {
+		copied := *invitation
+		return &copied, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockInvitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.Invitation, error) {
+	for _, invitation := range m.invitations {
+		if invitation.TokenHash == tokenHash {
+			copied := *invitation
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockInvitationRepository) FindPending(ctx context.Context) ([]*domain.Invitation, error) {
+	var invitations []*domain.Invitation
+	for _, invitation := range m.invitations {
+		if invitation.IsPending() {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (m *mockInvitationRepository) Create(ctx context.Context, invitation *domain.Invitation) error {
+	invitation.SentAt = time.Now()
+	invitation.SendCount = 1
+	invitation.CreatedAt = time.Now()
+	copied := *invitation
+	m.invitations[invitation.ID] = &copied
+	return nil
+}
+
+func (m *mockInvitationRepository) Renew(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	invitation, ok := m.invitations[id]
+	if !ok || !invitation.IsPending() {
+		return domain.ErrNotFound
+	}
+	invitation.TokenHash = tokenHash
+	invitation.ExpiresAt = expiresAt
+	invitation.SendCount++
+	return nil
+}
+
+func (m *mockInvitationRepository) Accept(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	invitation, ok := m.invitations[id]
+	if !ok || !invitation.IsValid() {
+		return domain.ErrNotFound
+	}
+	user, err := m.users.FindByID(ctx, invitation.UserID)
+	if err != nil || user.Status != domain.StatusPending {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	invitation.AcceptedAt = &now
+	user.PasswordHash = passwordHash
+	user.Status = domain.StatusActive
+	user.EmailVerified = true
+	return nil
+}
+
+func (m *mockInvitationRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	invitation, ok := m.invitations[id]
+	if !ok || !invitation.IsPending() {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	invitation.RevokedAt = &now
+	return nil
+}
+
+func (m *mockInvitationRepository) RevokeByUser(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	for _, invitation := range m.invitations {
+		if invitation.UserID == userID && invitation.IsPending() {
+			invitation.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func setupInvitationTest() (service.InvitationService, *mockInvitationRepository, *mockUserRepository, *mockMailer) {
+	users := newMockUserRepository()
+	invitations := newMockInvitationRepository(users)
+	mail := &mockMailer{}
+	roles, _ := newTestRoleService()
+	svc := service.NewInvitationService(invitations, users, roles, mail, service.InvitationOptions{
+		AcceptURL:  "https://cms.test/accept-invite",
+		Expiry:     72 * time.Hour,
+		BcryptCost: bcrypt.MinCost,
+	}, zerolog.Nop())
+	return svc, invitations, users, mail
+}
+
+// inviteTokenFrom extracts the token from the invitation link of a sent email
+func inviteTokenFrom(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	link := regexp.MustCompile(`https://cms\.test/accept-invite\?\S+`).FindString(msg.Body)
+	u, err := url.Parse(link)
+	if err != nil || u.Query().Get("token") == "" {
+		t.Fatalf("expected an invitation link in the email, got: %q", msg.Body)
+	}
+	return u.Query().Get("token")
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestInvitationService_InviteAndAccept(t *testing.T) {
+	svc, _, users, mail := setupInvitationTest()
+	ctx := context.Background()
+
+	invitation, err := svc.InviteUser(ctx, domain.InviteUserInput{
+		Email: "new@test.com", FullName: "New Editor", Role: domain.RoleEditor,
+	}, uuid.New(), domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	user := users.users["new@test.com"]
+	if user == nil || user.Status != domain.StatusPending || user.IsActive() || user.EmailVerified {
+		t.Fatalf("expected a pending, unverified user, got %+v", user)
+	}
+	if invitation.UserID != user.ID || len(mail.sent) != 1 || mail.sent[0].To != "new@test.com" {
+		t.Fatalf("expected one invitation email to the user, got %v", mail.sent)
+	}
+	token := inviteTokenFrom(t, mail.sent[0])
+
+	if _, err := svc.AcceptInvitation(ctx, domain.AcceptInvitationInput{Token: token, Password: "short"}); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected ErrValidation for a short password, got %v", err)
+	}
+
+	accepted, err := svc.AcceptInvitation(ctx, domain.AcceptInvitationInput{Token: token, Password: "newpassword"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !accepted.IsActive() || !accepted.EmailVerified {
+		t.Errorf("expected an active, verified user, got %+v", accepted)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(users.users["new@test.com"].PasswordHash), []byte("newpassword")) != nil {
+		t.Error("expected the chosen password to be stored")
+	}
+
+	if _, err := svc.AcceptInvitation(ctx, domain.AcceptInvitationInput{Token: token, Password: "otherpassword"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected the token to work once, got %v", err)
+	}
+	pending, _ := svc.ListPending(ctx)
+	if len(pending) != 0 {
+		t.Errorf("expected no pending invitations, got %d", len(pending))
+	}
+}
+
+func TestInvitationService_ResendAndRevoke(t *testing.T) {
+	svc, invitations, _, mail := setupInvitationTest()
+	ctx := context.Background()
+
+	invitation, err := svc.InviteUser(ctx, domain.InviteUserInput{
+		Email: "new@test.com", FullName: "New Editor", Role: domain.RoleEditor,
+	}, uuid.New(), domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("failed to invite: %v", err)
+	}
+	oldToken := inviteTokenFrom(t, mail.sent[0])
+
+	resent, err := svc.ResendInvitation(ctx, invitation.ID)
+	if err != nil || resent.SendCount != 2 || len(mail.sent) != 2 {
+		t.Fatalf("expected a second email, got %v %v", resent, err)
+	}
+	newToken := inviteTokenFrom(t, mail.sent[1])
+	if _, err := svc.AcceptInvitation(ctx, domain.AcceptInvitationInput{Token: oldToken, Password: "newpassword"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected the old link to stop working, got %v", err)
+	}
+
+	if err := svc.RevokeInvitation(ctx, invitation.ID); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	if _, err := svc.AcceptInvitation(ctx, domain.AcceptInvitationInput{Token: newToken, Password: "newpassword"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected a revoked invitation to be refused, got %v", err)
+	}
+	if _, err := svc.ResendInvitation(ctx, invitation.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected a revoked invitation not to be resent, got %v", err)
+	}
+
+	// The pending user can be invited again
+	again, err := svc.InviteUser(ctx, domain.InviteUserInput{
+		Email: "new@test.com", FullName: "New Admin", Role: domain.RoleAdmin,
+	}, uuid.New(), domain.RoleSuperAdmin)
+	if err != nil || again.UserID != invitation.UserID || again.Role != domain.RoleAdmin {
+		t.Fatalf("expected a new invitation for the same user, got %v %v", again, err)
+	}
+
+	// An invitation that ran out cannot be accepted until it is resent
+	invitations.invitations[again.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	token := inviteTokenFrom(t, mail.sent[len(mail.sent)-1])
+	if _, err := svc.AcceptInvitation(ctx, domain.AcceptInvitationInput{Token: token, Password: "newpassword"}); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected an expired invitation to be refused, got %v", err)
+	}
+	pending, _ := svc.ListPending(ctx)
+	if len(pending) != 1 || pending[0].ID != again.ID {
+		t.Errorf("expected the expired invitation to stay listed, got %v", pending)
+	}
+}
+
+func TestInvitationService_InviteValidation(t *testing.T) {
+	svc, invitations, users, mail := setupInvitationTest()
+	ctx := context.Background()
+	existing := createTestUser("editor@test.com", "password123", domain.RoleEditor)
+	users.users[existing.Email] = existing
+
+	cases := []struct {
+		name      string
+		input     domain.InviteUserInput
+		actorRole domain.UserRole
+		want      error
+	}{
+		{"existing user", domain.InviteUserInput{Email: "editor@test.com", FullName: "Editor", Role: domain.RoleEditor}, domain.RoleAdmin, domain.ErrAlreadyExists},
+		{"invalid email", domain.InviteUserInput{Email: "not-an-email", FullName: "Someone", Role: domain.RoleEditor}, domain.RoleAdmin, domain.ErrValidation},
+		{"unknown role", domain.InviteUserInput{Email: "new@test.com", FullName: "Someone", Role: "guest"}, domain.RoleAdmin, domain.ErrValidation},
+		{"role above the inviter", domain.InviteUserInput{Email: "new@test.com", FullName: "Someone", Role: domain.RoleSuperAdmin}, domain.RoleAdmin, domain.ErrForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := svc.InviteUser(ctx, tc.input, uuid.New(), tc.actorRole); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+	if len(invitations.invitations) != 0 || len(mail.sent) != 0 || len(users.users) != 1 {
+		t.Errorf("expected nothing to be stored or sent, got %d invitations, %d emails", len(invitations.invitations), len(mail.sent))
+	}
+}
+
+func TestInvitationService_ReinviteKeepsRoleAboveInviter(t *testing.T) {
+	svc, _, users, _ := setupInvitationTest()
+	ctx := context.Background()
+
+	if _, err := svc.InviteUser(ctx, domain.InviteUserInput{
+		Email: "owner@test.com", FullName: "New Owner", Role: domain.RoleSuperAdmin,
+	}, uuid.New(), domain.RoleSuperAdmin); err != nil {
+		t.Fatalf("failed to invite: %v", err)
+	}
+
+	// An admin cannot take the pending super admin's role away by inviting
+	// the same email again
+	_, err := svc.InviteUser(ctx, domain.InviteUserInput{
+		Email: "owner@test.com", FullName: "New Editor", Role: domain.RoleEditor,
+	}, uuid.New(), domain.RoleAdmin)
+	if !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("expected ErrForbidden, got %v", err)
+	}
+	if user := users.users["owner@test.com"]; user.Role != domain.RoleSuperAdmin || user.FullName != "New Owner" {
+		t.Errorf("expected the pending user to be unchanged, got %s %q", user.Role, user.FullName)
+	}
+}
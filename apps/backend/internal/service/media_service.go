@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/filecheck"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/imageproc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/scanner"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// MediaService defines the interface for media processing
+type MediaService interface {
+	// CheckUpload identifies an upload from its content rather than the type
+	// the client declared. It returns the detected MIME type and the data to
+	// store: JPEGs without their metadata and SVGs without active content. A
+	// refused file returns a *domain.UploadError.
+	CheckUpload(ctx context.Context, fileName string, data []byte) (string, []byte, error)
+	// ProcessImage records the dimensions of an uploaded image and stores its
+	// thumbnail and responsive variants next to media.FilePath. Other files
+	// are left alone. It returns domain.ErrInvalidImage for an image MIME type
+	// whose content cannot be decoded.
+	ProcessImage(ctx context.Context, media *domain.Media, data []byte) error
+	// AttachResponsiveImages sets the srcset data of the uploaded images used
+	// by the hero and gallery sections of a page
+	AttachResponsiveImages(ctx context.Context, page *domain.Page) error
+}
+
+// MediaOptions holds the settings of MediaService
+type MediaOptions struct {
+	// ThumbnailSize bounds the width and height of thumbnails
+	ThumbnailSize int
+	// ResponsiveWidths are the widths of the responsive variants; only those
+	// narrower than the original are generated
+	ResponsiveWidths []int
+	JPEGQuality      int
+	// MaxPixels is the largest image decoded; larger images only get their
+	// dimensions recorded
+	MaxPixels int
+	// AllowedMimeTypes are the detected types accepted by CheckUpload
+	AllowedMimeTypes []string
+}
+
+// mediaService implements MediaService
+type mediaService struct {
+	compRepo repository.ComponentRepository
+	storage  storage.Backend
+	scanner  scanner.Scanner
+	opts     MediaOptions
+	logger   zerolog.Logger
+}
+
+// NewMediaService creates a new MediaService
+func NewMediaService(
+	compRepo repository.ComponentRepository,
+	store storage.Backend,
+	scan scanner.Scanner,
+	opts MediaOptions,
+	logger zerolog.Logger,
+) MediaService {
+	return &mediaService{
+		compRepo: compRepo,
+		storage:  store,
+		scanner:  scan,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+// CheckUpload detects, checks, scans and sanitizes an upload
+func (s *mediaService) CheckUpload(ctx context.Context, fileName string, data []byte) (string, []byte, error) {
+	mimeType := filecheck.Detect(data)
+	if !slices.ContainsFunc(s.opts.AllowedMimeTypes, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSpace(allowed), mimeType)
+	}) {
+		return "", nil, &domain.UploadError{
+			Code:    domain.UploadTypeNotAllowed,
+			Message: fmt.Sprintf("file type %s is not allowed", mimeType),
+		}
+	}
+	if !filecheck.ExtensionMatches(fileName, mimeType) {
+		return "", nil, &domain.UploadError{
+			Code:    domain.UploadTypeMismatch,
+			Message: fmt.Sprintf("file extension does not match its content (%s)", mimeType),
+		}
+	}
+
+	if err := s.scanner.Scan(ctx, data); err != nil {
+		var infected *scanner.InfectedError
+		if errors.As(err, &infected) {
+			s.logger.Warn().Str("file_name", fileName).Str("signature", infected.Signature).Msg("upload rejected by malware scanner")
+			return "", nil, &domain.UploadError{
+				Code:    domain.UploadMalwareDetected,
+				Message: "file was flagged by the malware scanner",
+			}
+		}
+		// An unscanned file is not stored
+		return "", nil, fmt.Errorf("mediaService.CheckUpload: %w", err)
+	}
+
+	var err error
+	switch mimeType {
+	case "image/jpeg":
+		data, err = filecheck.StripJPEGMetadata(data)
+	case "image/svg+xml":
+		data, err = filecheck.SanitizeSVG(data)
+	}
+	if err != nil {
+		return "", nil, &domain.UploadError{
+			Code:    domain.UploadInvalidFile,
+			Message: fmt.Sprintf("file is not a valid %s", mimeType),
+		}
+	}
+	return mimeType, data, nil
+}
+
+// ProcessImage records the dimensions of an image and stores its variants
+func (s *mediaService) ProcessImage(ctx context.Context, media *domain.Media, data []byte) error {
+	switch media.MimeType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+	default:
+		// SVG and other formats have no pixels to scale
+		return nil
+	}
+
+	cfg, err := imageproc.DecodeConfig(data)
+	if err != nil {
+		return fmt.Errorf("mediaService.ProcessImage: %w", domain.ErrInvalidImage)
+	}
+	media.Width, media.Height = &cfg.Width, &cfg.Height
+	media.ThumbnailURL = nil
+
+	switch {
+	case (cfg.Format == imageproc.FormatGIF || cfg.Format == imageproc.FormatWebP) && imageproc.IsAnimated(data):
+		// A still variant would lose the animation
+		return nil
+	case cfg.Width*cfg.Height > s.opts.MaxPixels:
+		s.logger.Warn().Str("file_path", media.FilePath).Int("width", cfg.Width).Int("height", cfg.Height).
+			Msg("image too large to generate variants")
+		return nil
+	}
+
+	img, format, err := imageproc.Decode(data, s.opts.MaxPixels)
+	if err != nil {
+		return fmt.Errorf("mediaService.ProcessImage: %w", domain.ErrInvalidImage)
+	}
+	variants, err := s.storeVariants(ctx, media, img, format)
+	if err != nil {
+		return fmt.Errorf("mediaService.ProcessImage: %w", err)
+	}
+
+	media.SetImageVariants(variants)
+	thumbnailURL := media.PublicURL
+	for _, v := range variants {
+		if v.Kind == domain.ImageVariantThumbnail && (v.MimeType != "image/webp" || media.MimeType == "image/webp") {
+			thumbnailURL = v.URL
+		}
+	}
+	media.ThumbnailURL = &thumbnailURL
+	return nil
+}
+
+// variantTarget is a size to generate
+type variantTarget struct {
+	kind          string
+	suffix        string
+	width, height int
+}
+
+// storeVariants scales img to the thumbnail and responsive sizes and stores
+// each in the format of the original, GIF as PNG, and in WebP when that is
+// smaller; a variant without a WebP copy records why. A failure removes the
+// variants already stored.
+func (s *mediaService) storeVariants(ctx context.Context, media *domain.Media, img image.Image, format string) (variants []domain.ImageVariant, err error) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	var targets []variantTarget
+	for _, w := range s.opts.ResponsiveWidths {
+		if w < width {
+			tw, th := imageproc.Fit(width, height, w, 0)
+			targets = append(targets, variantTarget{domain.ImageVariantResponsive, fmt.Sprintf("_w%d", w), tw, th})
+		}
+	}
+	if tw, th := imageproc.Fit(width, height, s.opts.ThumbnailSize, s.opts.ThumbnailSize); tw < width || th < height {
+		targets = append(targets, variantTarget{domain.ImageVariantThumbnail, "_thumb", tw, th})
+	}
+	// Largest first, so smaller sizes can be scaled from a larger variant
+	sort.SliceStable(targets, func(i, j int) bool { return targets[i].width > targets[j].width })
+
+	defer func() {
+		if err != nil {
+			for _, v := range variants {
+				if delErr := s.storage.Delete(context.WithoutCancel(ctx), v.Key); delErr != nil {
+					s.logger.Error().Err(delErr).Str("key", v.Key).Msg("failed to remove image variant")
+				}
+			}
+			variants = nil
+		}
+	}()
+
+	base := strings.TrimSuffix(media.FilePath, path.Ext(media.FilePath))
+	outFormat := imageproc.EncodedFormat(format)
+	var scaled []*image.NRGBA
+	for _, t := range targets {
+		// Scaling from an image at least twice as wide loses nothing visible
+		src := img
+		for i := len(scaled) - 1; i >= 0; i-- {
+			if scaled[i].Rect.Dx() >= 2*t.width {
+				src = scaled[i]
+				break
+			}
+		}
+		resized := imageproc.Resize(src, t.width, t.height)
+		scaled = append(scaled, resized)
+
+		var buf bytes.Buffer
+		if err := imageproc.Encode(&buf, resized, outFormat, s.opts.JPEGQuality); err != nil {
+			return variants, err
+		}
+		v, err := s.putVariant(ctx, t, base+t.suffix+imageproc.Extension(outFormat), imageproc.MimeType(outFormat), buf.Bytes())
+		if err != nil {
+			return variants, err
+		}
+		variants = append(variants, v)
+		if outFormat == imageproc.FormatWebP {
+			continue
+		}
+
+		var webp bytes.Buffer
+		if err := imageproc.EncodeWebP(&webp, resized); err != nil {
+			return variants, err
+		}
+		if webp.Len() >= buf.Len() {
+			// Lossless WebP rarely beats JPEG; only offer it when it helps
+			variants[len(variants)-1].WebPSkipped = domain.WebPSkippedLarger
+			continue
+		}
+		v, err = s.putVariant(ctx, t, base+t.suffix+imageproc.Extension(imageproc.FormatWebP), imageproc.MimeType(imageproc.FormatWebP), webp.Bytes())
+		if err != nil {
+			return variants, err
+		}
+		variants = append(variants, v)
+	}
+	return variants, nil
+}
+
+func (s *mediaService) putVariant(ctx context.Context, t variantTarget, key, mimeType string, data []byte) (domain.ImageVariant, error) {
+	if err := s.storage.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
+		return domain.ImageVariant{}, err
+	}
+	return domain.ImageVariant{
+		Kind:     t.kind,
+		Key:      key,
+		URL:      s.storage.PublicURL(key),
+		MimeType: mimeType,
+		Width:    t.width,
+		Height:   t.height,
+		Size:     int64(len(data)),
+	}, nil
+}
+
+// AttachResponsiveImages sets the srcset data of hero and gallery images
+func (s *mediaService) AttachResponsiveImages(ctx context.Context, page *domain.Page) error {
+	var sections []*domain.PageSection
+	seen := map[string]bool{}
+	var urls []string
+	add := func(url *string) {
+		if url != nil && *url != "" && !seen[*url] {
+			seen[*url] = true
+			urls = append(urls, *url)
+		}
+	}
+	for _, section := range page.Sections {
+		if section.Type != domain.SectionTypeHero && section.Type != domain.SectionTypeGallery {
+			continue
+		}
+		sections = append(sections, section)
+		add(section.BGImage)
+		for _, content := range section.Contents {
+			if content.Type == domain.ContentTypeImage {
+				add(content.Value)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	media, err := s.compRepo.FindMediaByPublicURLs(ctx, page.SiteID, urls)
+	if err != nil {
+		return fmt.Errorf("mediaService.AttachResponsiveImages: %w", err)
+	}
+	images := make(map[string]*domain.ResponsiveImage, len(media))
+	for _, m := range media {
+		if img := m.ResponsiveImage(); img != nil {
+			images[m.PublicURL] = img
+		}
+	}
+	lookup := func(url *string) *domain.ResponsiveImage {
+		if url == nil {
+			return nil
+		}
+		return images[*url]
+	}
+	for _, section := range sections {
+		section.BGImageSet = lookup(section.BGImage)
+		for _, content := range section.Contents {
+			if content.Type == domain.ContentTypeImage {
+				content.Image = lookup(content.Value)
+			}
+		}
+	}
+	return nil
+}
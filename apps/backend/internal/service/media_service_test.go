@@ -0,0 +1,373 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/imageproc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/scanner"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/storage"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+func (m *mockComponentRepository) FindMediaByPublicURLs(ctx context.Context, siteID uuid.UUID, urls []string) ([]*domain.Media, error) {
+	var media []*domain.Media
+	for _, item := range m.media {
+		for _, url := range urls {
+			if item.SiteID == siteID && item.PublicURL == url {
+				media = append(media, item)
+			}
+		}
+	}
+	return media, nil
+}
+
+// failingStore fails every Put after the first n
+type failingStore struct {
+	storage.Backend
+	n int
+}
+
+func (s *failingStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if s.n == 0 {
+		return errors.New("disk full")
+	}
+	s.n--
+	return s.Backend.Put(ctx, key, body, size, contentType)
+}
+
+var testMediaOptions = service.MediaOptions{
+	ThumbnailSize:    320,
+	ResponsiveWidths: []int{480, 1280, 4000},
+	JPEGQuality:      80,
+	MaxPixels:        10_000_000,
+	AllowedMimeTypes: []string{"image/jpeg", "image/png", "image/svg+xml", "application/pdf"},
+}
+
+func newTestMediaService(t *testing.T, store storage.Backend, repo *mockComponentRepository) service.MediaService {
+	t.Helper()
+	return service.NewMediaService(repo, store, scanner.Nop{}, testMediaOptions, zerolog.Nop())
+}
+
+func encodeTestImage(t *testing.T, format string, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Flat blocks, like a logo or a screenshot
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x / 100 * 40), G: uint8(y / 100 * 60), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "png":
+		err = png.Encode(&buf, img)
+	case "webp":
+		err = imageproc.EncodeWebP(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func storedConfig(t *testing.T, dir, key string) imageproc.Config {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, key))
+	if err != nil {
+		t.Fatalf("expected %s to be stored: %v", key, err)
+	}
+	cfg, err := imageproc.DecodeConfig(data)
+	if err != nil {
+		t.Fatalf("%s: %v", key, err)
+	}
+	return cfg
+}
+
+// stubScanner flags files containing "MALWARE" and fails with err when set
+type stubScanner struct {
+	err error
+}
+
+func (s *stubScanner) Scan(ctx context.Context, data []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	if bytes.Contains(data, []byte("MALWARE")) {
+		return &scanner.InfectedError{Signature: "Test.Malware"}
+	}
+	return nil
+}
+
+func TestMediaService_CheckUpload(t *testing.T) {
+	scan := &stubScanner{}
+	svc := service.NewMediaService(&mockComponentRepository{}, storage.NewLocal(t.TempDir(), "/media"), scan, testMediaOptions, zerolog.Nop())
+	ctx := context.Background()
+
+	png := encodeTestImage(t, "png", 40, 20)
+	mimeType, data, err := svc.CheckUpload(ctx, "photo.PNG", png)
+	if err != nil || mimeType != "image/png" || !bytes.Equal(data, png) {
+		t.Errorf("expected the PNG unchanged, got %s, %v", mimeType, err)
+	}
+
+	jpg := encodeTestImage(t, "jpeg", 40, 20)
+	withComment := append([]byte{0xff, 0xd8, 0xff, 0xfe, 0x00, 0x08}, "secret"...)
+	withComment = append(withComment, jpg[2:]...)
+	mimeType, data, err = svc.CheckUpload(ctx, "photo.jpg", withComment)
+	if err != nil || mimeType != "image/jpeg" || bytes.Contains(data, []byte("secret")) {
+		t.Errorf("expected the JPEG without its comment, got %s, %v", mimeType, err)
+	}
+
+	mimeType, data, err = svc.CheckUpload(ctx, "logo.svg", []byte(`<svg onload="alert(1)"><script>alert(2)</script><rect/></svg>`))
+	if err != nil || mimeType != "image/svg+xml" || string(data) != "<svg><rect></rect></svg>" {
+		t.Errorf("expected the sanitized SVG, got %s %q, %v", mimeType, data, err)
+	}
+
+	rejected := []struct {
+		name, fileName string
+		data           []byte
+		code           string
+	}{
+		// The declared type no longer matters, only the content
+		{"html as png", "page.png", []byte("<!DOCTYPE html><script>alert(1)</script>"), domain.UploadTypeNotAllowed},
+		{"gif not allowed", "anim.gif", encodeTestImage(t, "gif", 4, 4), domain.UploadTypeNotAllowed},
+		{"png as jpg", "photo.jpg", png, domain.UploadTypeMismatch},
+		{"pdf as svg", "logo.svg", []byte("%PDF-1.7\n"), domain.UploadTypeMismatch},
+		{"broken jpeg", "photo.jpg", []byte("\xff\xd8\xff\xfe\xff\xff"), domain.UploadInvalidFile},
+		{"broken svg", "logo.svg", []byte("<svg><g></svg>"), domain.UploadInvalidFile},
+		{"malware", "doc.pdf", []byte("%PDF-1.7\nMALWARE"), domain.UploadMalwareDetected},
+	}
+	for _, tc := range rejected {
+		_, _, err := svc.CheckUpload(ctx, tc.fileName, tc.data)
+		var uploadErr *domain.UploadError
+		if !errors.As(err, &uploadErr) || uploadErr.Code != tc.code || !errors.Is(err, domain.ErrUploadRejected) {
+			t.Errorf("%s: expected %s, got %v", tc.name, tc.code, err)
+		}
+	}
+
+	// A file that could not be scanned is not accepted
+	scan.err = errors.New("clamd unavailable")
+	_, _, err = svc.CheckUpload(ctx, "photo.png", png)
+	if err == nil || errors.Is(err, domain.ErrUploadRejected) {
+		t.Errorf("expected a scan failure, got %v", err)
+	}
+}
+
+func TestMediaService_ProcessImage(t *testing.T) {
+	dir := t.TempDir()
+	store := storage.NewLocal(dir, "https://cms.test/media")
+	svc := newTestMediaService(t, store, &mockComponentRepository{})
+
+	for _, format := range []string{"jpeg", "png"} {
+		t.Run(format, func(t *testing.T) {
+			ext := imageproc.Extension(format)
+			media := &domain.Media{FilePath: "hero/banner" + ext, PublicURL: "https://cms.test/media/hero/banner" + ext, MimeType: "image/" + format}
+			if err := svc.ProcessImage(context.Background(), media, encodeTestImage(t, format, 2000, 1000)); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if media.Width == nil || *media.Width != 2000 || media.Height == nil || *media.Height != 1000 {
+				t.Fatalf("expected 2000x1000, got %v x %v", media.Width, media.Height)
+			}
+			if media.ThumbnailURL == nil || *media.ThumbnailURL != "https://cms.test/media/hero/banner_thumb"+ext {
+				t.Errorf("unexpected thumbnail URL %v", media.ThumbnailURL)
+			}
+
+			want := map[string][2]int{"_w480": {480, 240}, "_w1280": {1280, 640}, "_thumb": {320, 160}}
+			sizes := map[string]int64{}
+			for _, v := range media.ImageVariants() {
+				suffix := strings.TrimPrefix(strings.TrimSuffix(v.Key, filepath.Ext(v.Key)), "hero/banner")
+				size, ok := want[suffix]
+				if !ok {
+					t.Errorf("unexpected variant %s", v.Key)
+					continue
+				}
+				cfg := storedConfig(t, dir, v.Key)
+				if cfg.Width != size[0] || cfg.Height != size[1] || v.Width != size[0] || v.Height != size[1] {
+					t.Errorf("%s: expected %v, got %dx%d", v.Key, size, cfg.Width, cfg.Height)
+				}
+				if imageproc.MimeType(cfg.Format) != v.MimeType || v.URL != "https://cms.test/media/"+v.Key {
+					t.Errorf("%s: unexpected variant %+v", v.Key, v)
+				}
+				sizes[v.Key] = v.Size
+			}
+			for suffix := range want {
+				if _, ok := sizes["hero/banner"+suffix+ext]; !ok {
+					t.Errorf("expected a %s variant", suffix)
+				}
+				// WebP is only kept when it is smaller
+				if size, ok := sizes["hero/banner"+suffix+".webp"]; ok && size >= sizes["hero/banner"+suffix+ext] {
+					t.Errorf("expected the %s WebP variant to be smaller", suffix)
+				}
+			}
+			// A variant without a WebP copy says why
+			for _, v := range media.ImageVariants() {
+				_, hasWebP := sizes[strings.TrimSuffix(v.Key, ext)+".webp"]
+				if v.MimeType != "image/webp" && hasWebP == (v.WebPSkipped == domain.WebPSkippedLarger) {
+					t.Errorf("%s: unexpected webp_skipped %q", v.Key, v.WebPSkipped)
+				}
+			}
+			if format == "png" && len(sizes) != 6 {
+				t.Errorf("expected WebP variants of a flat PNG, got %v", sizes)
+			}
+		})
+	}
+}
+
+func TestMediaService_ProcessImage_WebP(t *testing.T) {
+	dir := t.TempDir()
+	svc := newTestMediaService(t, storage.NewLocal(dir, "https://cms.test/media"), &mockComponentRepository{})
+
+	media := &domain.Media{FilePath: "hero/banner.webp", PublicURL: "https://cms.test/media/hero/banner.webp", MimeType: "image/webp"}
+	if err := svc.ProcessImage(context.Background(), media, encodeTestImage(t, "webp", 2000, 1000)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if media.ThumbnailURL == nil || *media.ThumbnailURL != "https://cms.test/media/hero/banner_thumb.webp" {
+		t.Errorf("unexpected thumbnail URL %v", media.ThumbnailURL)
+	}
+
+	// One WebP variant per size, with no copy in another format
+	variants := media.ImageVariants()
+	if len(variants) != 3 {
+		t.Fatalf("expected 3 variants, got %+v", variants)
+	}
+	for _, v := range variants {
+		if cfg := storedConfig(t, dir, v.Key); cfg.Format != imageproc.FormatWebP || v.MimeType != "image/webp" || v.WebPSkipped != "" {
+			t.Errorf("%s: unexpected variant %+v stored as %s", v.Key, v, cfg.Format)
+		}
+	}
+}
+
+func TestMediaService_ProcessImage_DimensionsOnly(t *testing.T) {
+	dir := t.TempDir()
+	svc := newTestMediaService(t, storage.NewLocal(dir, "/media"), &mockComponentRepository{})
+	ctx := context.Background()
+
+	var anim bytes.Buffer
+	frame := image.NewPaletted(image.Rect(0, 0, 600, 400), palette.Plan9)
+	gif.EncodeAll(&anim, &gif.GIF{Image: []*image.Paletted{frame, frame}, Delay: []int{10, 10}})
+	// VP8X with the animation flag
+	webp := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x12\x00\x00\x00\x57\x02\x00\x8f\x01\x00"), make([]byte, 16)...)
+
+	cases := map[string]struct {
+		mimeType string
+		data     []byte
+		width    int
+	}{
+		"animated gif":  {"image/gif", anim.Bytes(), 600},
+		"animated webp": {"image/webp", webp, 600},
+		"too large":     {"image/png", encodeTestImage(t, "png", 4000, 3000), 4000},
+	}
+	for name, tc := range cases {
+		media := &domain.Media{FilePath: "a.bin", MimeType: tc.mimeType}
+		if err := svc.ProcessImage(ctx, media, tc.data); err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if media.Width == nil || *media.Width != tc.width || len(media.ImageVariants()) != 0 {
+			t.Errorf("%s: expected only the dimensions, got %v %v", name, media.Width, media.Metadata)
+		}
+	}
+
+	// Not a raster image, nothing to record
+	svg := &domain.Media{FilePath: "logo.svg", MimeType: "image/svg+xml"}
+	if err := svc.ProcessImage(ctx, svg, []byte("<svg/>")); err != nil || svg.Width != nil {
+		t.Errorf("expected SVG to be left alone, got %v %v", svg.Width, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no stored variants, got %d files", len(entries))
+	}
+}
+
+func TestMediaService_ProcessImage_InvalidImage(t *testing.T) {
+	svc := newTestMediaService(t, storage.NewLocal(t.TempDir(), "/media"), &mockComponentRepository{})
+	for _, data := range [][]byte{[]byte("<?php echo 1; ?>"), encodeTestImage(t, "png", 40, 40)[:60]} {
+		media := &domain.Media{FilePath: "a.png", MimeType: "image/png"}
+		if err := svc.ProcessImage(context.Background(), media, data); !errors.Is(err, domain.ErrInvalidImage) {
+			t.Errorf("expected ErrInvalidImage, got %v", err)
+		}
+	}
+}
+
+func TestMediaService_ProcessImage_RemovesVariantsOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	store := &failingStore{Backend: storage.NewLocal(dir, "/media"), n: 2}
+	svc := newTestMediaService(t, store, &mockComponentRepository{})
+
+	media := &domain.Media{FilePath: "a.jpg", MimeType: "image/jpeg"}
+	err := svc.ProcessImage(context.Background(), media, encodeTestImage(t, "jpeg", 2000, 1000))
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("expected the storage error, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected stored variants to be removed, got %d files", len(entries))
+	}
+}
+
+func TestMediaService_AttachResponsiveImages(t *testing.T) {
+	siteID := uuid.New()
+	width, height := 1600, 900
+	thumb := "https://cdn.test/hero_thumb.jpg"
+	photo := &domain.Media{
+		SiteID: siteID, PublicURL: "https://cdn.test/hero.jpg", MimeType: "image/jpeg",
+		Width: &width, Height: &height, ThumbnailURL: &thumb,
+	}
+	photo.SetImageVariants([]domain.ImageVariant{
+		{Kind: domain.ImageVariantResponsive, URL: "https://cdn.test/hero_w1280.jpg", MimeType: "image/jpeg", Width: 1280, Height: 720},
+		{Kind: domain.ImageVariantResponsive, URL: "https://cdn.test/hero_w480.jpg", MimeType: "image/jpeg", Width: 480, Height: 270},
+		{Kind: domain.ImageVariantResponsive, URL: "https://cdn.test/hero_w480.webp", MimeType: "image/webp", Width: 480, Height: 270},
+		{Kind: domain.ImageVariantThumbnail, URL: thumb, MimeType: "image/jpeg", Width: 320, Height: 180},
+	})
+	repo := &mockComponentRepository{media: []*domain.Media{photo}}
+	svc := newTestMediaService(t, storage.NewLocal(t.TempDir(), "/media"), repo)
+
+	str := func(s string) *string { return &s }
+	hero := &domain.PageSection{Type: domain.SectionTypeHero, BGImage: str(photo.PublicURL), Contents: []*domain.SectionContent{
+		{Key: "image", Type: domain.ContentTypeImage, Value: str(photo.PublicURL)},
+		{Key: "title", Type: domain.ContentTypeText, Value: str(photo.PublicURL)},
+		{Key: "logo", Type: domain.ContentTypeImage, Value: str("https://elsewhere.test/logo.png")},
+	}}
+	features := &domain.PageSection{Type: domain.SectionTypeFeatures, Contents: []*domain.SectionContent{
+		{Key: "image", Type: domain.ContentTypeImage, Value: str(photo.PublicURL)},
+	}}
+	page := &domain.Page{SiteID: siteID, Sections: []*domain.PageSection{hero, features}}
+
+	if err := svc.AttachResponsiveImages(context.Background(), page); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	img := hero.Contents[0].Image
+	if img == nil || hero.BGImageSet != img {
+		t.Fatalf("expected srcset data on the hero image and background, got %+v", img)
+	}
+	want := []domain.ImageSource{
+		{Type: "image/webp", Srcset: "https://cdn.test/hero_w480.webp 480w"},
+		{Type: "image/jpeg", Srcset: "https://cdn.test/hero_w480.jpg 480w, https://cdn.test/hero_w1280.jpg 1280w, https://cdn.test/hero.jpg 1600w"},
+	}
+	if len(img.Sources) != 2 || img.Sources[0] != want[0] || img.Sources[1] != want[1] {
+		t.Errorf("unexpected sources %+v", img.Sources)
+	}
+	if img.Width != 1600 || img.Height != 900 || img.ThumbnailURL == nil || *img.ThumbnailURL != thumb {
+		t.Errorf("unexpected image %+v", img)
+	}
+	if hero.Contents[1].Image != nil || hero.Contents[2].Image != nil || features.Contents[0].Image != nil {
+		t.Error("expected only uploaded images of hero and gallery sections to get srcset data")
+	}
+}
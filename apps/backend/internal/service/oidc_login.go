@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/oidc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// defaultOIDCStateExpiry is how long a started login waits for the callback
+// when OIDCOptions.StateExpiry is not set
+const defaultOIDCStateExpiry = 10 * time.Minute
+
+// OIDCProvider is the OpenID provider used for single sign-on. oidc.Client
+// implements it.
+type OIDCProvider interface {
+	Issuer() string
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier string) (*oidc.Token, error)
+	VerifyIDToken(ctx context.Context, raw, nonce string) (*oidc.IDToken, error)
+}
+
+// OIDCOptions holds the single sign-on settings of AuthService
+type OIDCOptions struct {
+	Provider   OIDCProvider
+	Identities repository.IdentityRepository
+	Roles      RoleService
+	// RoleClaim is the ID token claim roles are mapped from, e.g. "groups";
+	// a dotted path reaches into nested claims
+	RoleClaim string
+	// RoleMappings are tried in order; the first value found in the claim
+	// gives the role. A matching mapping also updates the role of existing
+	// users on every login.
+	RoleMappings []domain.OIDCRoleMapping
+	// DefaultRole is given to new users no mapping matched. Empty refuses
+	// them.
+	DefaultRole domain.UserRole
+	// LinkByEmail lets the first single sign-on of an existing user link
+	// their account when the provider verified the email
+	LinkByEmail bool
+	StateExpiry time.Duration
+}
+
+// StartOIDCLogin begins a single sign-on login. The state must be kept by
+// the browser and passed back with the callback.
+func (s *authService) StartOIDCLogin(ctx context.Context) (*domain.OIDCLoginStart, error) {
+	sso := s.opts.OIDC
+	if sso == nil {
+		return nil, domain.ErrSSODisabled
+	}
+
+	state, err := oidc.GenerateState()
+	if err != nil {
+		return nil, fmt.Errorf("authService.StartOIDCLogin: %w", err)
+	}
+	nonce, err := oidc.GenerateState()
+	if err != nil {
+		return nil, fmt.Errorf("authService.StartOIDCLogin: %w", err)
+	}
+	verifier, err := oidc.GenerateVerifier()
+	if err != nil {
+		return nil, fmt.Errorf("authService.StartOIDCLogin: %w", err)
+	}
+
+	authURL, err := sso.Provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("authService.StartOIDCLogin auth URL: %w", err)
+	}
+
+	expiry := sso.StateExpiry
+	if expiry <= 0 {
+		expiry = defaultOIDCStateExpiry
+	}
+	request := &domain.OIDCLoginRequest{
+		ID:           uuid.New(),
+		StateHash:    auth.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(expiry),
+	}
+	if err := sso.Identities.CreateLoginRequest(ctx, request); err != nil {
+		return nil, fmt.Errorf("authService.StartOIDCLogin store request: %w", err)
+	}
+
+	return &domain.OIDCLoginStart{AuthURL: authURL, State: state, ExpiresAt: request.ExpiresAt}, nil
+}
+
+// CompleteOIDCLogin handles the provider callback: it checks the state,
+// exchanges the code, verifies the ID token and signs in the linked user,
+// creating or linking one on the first login. Users with two-factor
+// authentication enabled, or whose role requires it, get the same
+// domain.TwoFactorRequiredError challenge as a password login.
+func (s *authService) CompleteOIDCLogin(ctx context.Context, input domain.OIDCCallbackInput, ipAddress, userAgent string) (*domain.User, *domain.AuthTokens, error) {
+	sso := s.opts.OIDC
+	if sso == nil {
+		return nil, nil, domain.ErrSSODisabled
+	}
+
+	// The state must come back to the browser that started the login
+	if input.State == "" || subtle.ConstantTimeCompare([]byte(input.State), []byte(input.BrowserState)) != 1 {
+		return nil, nil, domain.ErrInvalidToken
+	}
+	request, err := sso.Identities.ConsumeLoginRequest(ctx, auth.HashToken(input.State))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, nil, domain.ErrInvalidToken
+		}
+		return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin consume request: %w", err)
+	}
+
+	if input.Error != "" || input.Code == "" {
+		s.logger.Warn().Str("error", input.Error).Msg("identity provider refused single sign-on")
+		return nil, nil, domain.ErrSSOFailed
+	}
+	token, err := sso.Provider.Exchange(ctx, input.Code, request.CodeVerifier)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("single sign-on code exchange failed")
+		return nil, nil, domain.ErrSSOFailed
+	}
+	idToken, err := sso.Provider.VerifyIDToken(ctx, token.IDToken, request.Nonce)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("single sign-on ID token rejected")
+		return nil, nil, domain.ErrSSOFailed
+	}
+
+	user, identity, err := s.resolveOIDCUser(ctx, sso, idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !user.IsActive() {
+		return nil, nil, domain.ErrAccountInactive
+	}
+	if user.IsLocked() {
+		return nil, nil, domain.ErrAccountLocked
+	}
+
+	if err := sso.Identities.TouchIdentity(ctx, identity.ID, identity.Email); err != nil {
+		s.logger.Error().Err(err).Msg("failed to record single sign-on")
+	}
+
+	if err := s.twoFactorChallenge(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.completeLogin(ctx, user, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin: %w", err)
+	}
+	return user, tokens, nil
+}
+
+// resolveOIDCUser returns the user linked to the provider account, linking
+// or creating one on the first login, and applies the mapped role
+func (s *authService) resolveOIDCUser(ctx context.Context, sso *OIDCOptions, idToken *oidc.IDToken) (*domain.User, *domain.UserIdentity, error) {
+	role, mapped, err := s.mappedRole(ctx, sso, idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	var email *string
+	if e := strings.TrimSpace(idToken.Email); e != "" {
+		email = &e
+	}
+
+	identity, err := sso.Identities.FindIdentity(ctx, sso.Provider.Issuer(), idToken.Subject)
+	switch {
+	case err == nil:
+		identity.Email = email
+		user, err := s.userRepo.FindByID(ctx, identity.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				// The user was deleted; the identity stays linked to nobody
+				return nil, nil, domain.ErrAccountInactive
+			}
+			return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin find user: %w", err)
+		}
+		if mapped && user.Role != role {
+			s.logger.Info().
+				Str("user_id", user.ID.String()).
+				Str("from", string(user.Role)).
+				Str("to", string(role)).
+				Msg("role updated from single sign-on claims")
+			user.Role = role
+			if err := s.userRepo.Update(ctx, user); err != nil {
+				return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin update role: %w", err)
+			}
+		}
+		return user, identity, nil
+	case !errors.Is(err, domain.ErrNotFound):
+		return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin find identity: %w", err)
+	}
+
+	if email == nil {
+		s.logger.Warn().Str("subject", idToken.Subject).Msg("single sign-on without an email claim")
+		return nil, nil, domain.ErrSSOFailed
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, *email)
+	switch {
+	case err == nil:
+		if !sso.LinkByEmail || !idToken.EmailVerified {
+			return nil, nil, domain.ErrAlreadyExists
+		}
+		if mapped {
+			user.Role = role
+		}
+		// Signing in as an invited user accepts the invitation
+		if user.Status == domain.StatusPending {
+			user.Status = domain.StatusActive
+			user.EmailVerified = true
+		}
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin link user: %w", err)
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		if !mapped {
+			role = sso.DefaultRole
+		}
+		if role == "" {
+			s.logger.Warn().Str("email", *email).Msg("single sign-on refused, no role mapped")
+			return nil, nil, domain.ErrForbidden
+		}
+		fullName := strings.TrimSpace(idToken.Name)
+		if fullName == "" {
+			fullName = *email
+		}
+		// No password: the hash is empty and password login is disabled
+		user = &domain.User{
+			ID:                    uuid.New(),
+			Email:                 *email,
+			FullName:              fullName,
+			Role:                  role,
+			Status:                domain.StatusActive,
+			EmailVerified:         idToken.EmailVerified,
+			PasswordLoginDisabled: true,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin create user: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin find user: %w", err)
+	}
+
+	identity = &domain.UserIdentity{
+		ID:      uuid.New(),
+		UserID:  user.ID,
+		Issuer:  sso.Provider.Issuer(),
+		Subject: idToken.Subject,
+		Email:   email,
+	}
+	if err := sso.Identities.CreateIdentity(ctx, identity); err != nil {
+		return nil, nil, fmt.Errorf("authService.CompleteOIDCLogin link identity: %w", err)
+	}
+
+	s.logger.Info().
+		Str("user_id", user.ID.String()).
+		Str("issuer", identity.Issuer).
+		Str("role", string(user.Role)).
+		Msg("single sign-on identity linked")
+
+	return user, identity, nil
+}
+
+// mappedRole returns the role of the first mapping whose value is in the
+// role claim. Mappings to roles that do not exist are skipped.
+func (s *authService) mappedRole(ctx context.Context, sso *OIDCOptions, idToken *oidc.IDToken) (domain.UserRole, bool, error) {
+	if sso.RoleClaim == "" || len(sso.RoleMappings) == 0 {
+		return "", false, nil
+	}
+	values := make(map[string]bool)
+	for _, v := range idToken.ClaimStrings(sso.RoleClaim) {
+		values[v] = true
+	}
+
+	for _, mapping := range sso.RoleMappings {
+		if !values[mapping.Value] {
+			continue
+		}
+		if _, err := sso.Roles.GetRoleByName(ctx, mapping.Role); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				s.logger.Warn().Str("role", string(mapping.Role)).Msg("single sign-on role mapping names an unknown role")
+				continue
+			}
+			return "", false, fmt.Errorf("authService.CompleteOIDCLogin role: %w", err)
+		}
+		return mapping.Role, true, nil
+	}
+	return "", false, nil
+}
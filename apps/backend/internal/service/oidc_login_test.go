@@ -0,0 +1,356 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/oidc"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/oidc/oidctest"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock IdentityRepository ──────────────────────────────────────────────────
+
+type mockIdentityRepository struct {
+	identities map[string]*domain.UserIdentity // keyed by issuer + " " + subject
+	requests   map[string]*domain.OIDCLoginRequest
+}
+
+func newMockIdentityRepository() *mockIdentityRepository {
+	return &mockIdentityRepository{
+		identities: make(map[string]*domain.UserIdentity),
+		requests:   make(map[string]*domain.OIDCLoginRequest),
+	}
+}
+
+func (m *mockIdentityRepository) FindIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	if identity, ok := m.identities[issuer+" "+subject]; ok {
+		copied := *identity
+		return &copied, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockIdentityRepository) CreateIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	key := identity.Issuer + " " + identity.Subject
+	if _, ok := m.identities[key]; ok {
+		return domain.ErrAlreadyExists
+	}
+	identity.CreatedAt = time.Now()
+	copied := *identity
+	m.identities[key] = &copied
+	return nil
+}
+
+func (m *mockIdentityRepository) TouchIdentity(ctx context.Context, id uuid.UUID, email *string) error {
+	for _, identity := range m.identities {
+		if identity.ID == id {
+			now := time.Now()
+			identity.LastLoginAt = &now
+			identity.Email = email
+		}
+	}
+	return nil
+}
+
+func (m *mockIdentityRepository) CreateLoginRequest(ctx context.Context, request *domain.OIDCLoginRequest) error {
+	request.CreatedAt = time.Now()
+	copied := *request
+	m.requests[request.StateHash] = &copied
+	return nil
+}
+
+func (m *mockIdentityRepository) ConsumeLoginRequest(ctx context.Context, stateHash string) (*domain.OIDCLoginRequest, error) {
+	request, ok := m.requests[stateHash]
+	delete(m.requests, stateHash)
+	if !ok || time.Now().After(request.ExpiresAt) {
+		return nil, domain.ErrNotFound
+	}
+	return request, nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+const testOIDCRedirectURL = "https://cms.test/api/v1/auth/oidc/callback"
+
+type oidcTestEnv struct {
+	svc        service.AuthService
+	provider   *oidctest.Server
+	users      *mockUserRepository
+	identities *mockIdentityRepository
+	twoFactor  *mockTwoFactorRepository
+	mail       *mockMailer
+}
+
+// setupOIDCTest runs a mock provider signing in jane@corp.test and an auth
+// service mapping the cms-admins and staff groups to admin and editor
+func setupOIDCTest(t *testing.T, configure func(*service.OIDCOptions)) *oidcTestEnv {
+	t.Helper()
+	provider := oidctest.NewServer("cms", "secret")
+	t.Cleanup(provider.Close)
+	provider.SetClaims(map[string]interface{}{
+		"sub":            "idp-user-1",
+		"email":          "jane@corp.test",
+		"email_verified": true,
+		"name":           "Jane Doe",
+		"groups":         []interface{}{"cms-admins", "staff"},
+	})
+
+	roles, _ := newTestRoleService()
+	env := &oidcTestEnv{
+		provider:   provider,
+		users:      newMockUserRepository(),
+		identities: newMockIdentityRepository(),
+		twoFactor:  newMockTwoFactorRepository(),
+		mail:       &mockMailer{},
+	}
+	opts := &service.OIDCOptions{
+		Provider: oidc.NewClient(oidc.Config{
+			IssuerURL:    provider.Issuer(),
+			ClientID:     "cms",
+			ClientSecret: "secret",
+			RedirectURL:  testOIDCRedirectURL,
+			Scopes:       []string{"email", "profile"},
+		}),
+		Identities: env.identities,
+		Roles:      roles,
+		RoleClaim:  "groups",
+		RoleMappings: []domain.OIDCRoleMapping{
+			{Value: "cms-admins", Role: domain.RoleAdmin},
+			{Value: "staff", Role: domain.RoleEditor},
+		},
+		LinkByEmail: true,
+		StateExpiry: 10 * time.Minute,
+	}
+	if configure != nil {
+		configure(opts)
+	}
+	env.svc, _ = createTestAuthServicesWithOptions(env.users, env.twoFactor, env.mail, &mockAuditRepository{}, service.AuthOptions{OIDC: opts})
+	return env
+}
+
+// callback starts a login and returns the parameters the provider redirects
+// back with, and the state the browser kept
+func (env *oidcTestEnv) callback(t *testing.T) domain.OIDCCallbackInput {
+	t.Helper()
+	start, err := env.svc.StartOIDCLogin(context.Background())
+	if err != nil {
+		t.Fatalf("failed to start login: %v", err)
+	}
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(start.AuthURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("expected a redirect back, got %d", resp.StatusCode)
+	}
+	q := location.Query()
+	return domain.OIDCCallbackInput{Code: q.Get("code"), State: q.Get("state"), Error: q.Get("error"), BrowserState: start.State}
+}
+
+// login runs a whole single sign-on
+func (env *oidcTestEnv) login(t *testing.T) (*domain.User, *domain.AuthTokens, error) {
+	t.Helper()
+	return env.svc.CompleteOIDCLogin(context.Background(), env.callback(t), "127.0.0.1", "test-agent")
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestAuthService_OIDCLogin_ProvisionsUser(t *testing.T) {
+	env := setupOIDCTest(t, nil)
+
+	user, tokens, err := env.login(t)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tokens == nil || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Fatal("expected tokens")
+	}
+	stored := env.users.users["jane@corp.test"]
+	if stored == nil || stored.ID != user.ID {
+		t.Fatalf("expected the user to be created, got %+v", stored)
+	}
+	if stored.Role != domain.RoleAdmin || stored.FullName != "Jane Doe" || !stored.IsActive() || !stored.EmailVerified {
+		t.Errorf("expected an active admin from the first matching group, got %+v", stored)
+	}
+	if !stored.PasswordLoginDisabled || stored.PasswordHash != "" {
+		t.Error("expected a user without a password")
+	}
+	if len(env.identities.identities) != 1 {
+		t.Fatalf("expected one linked identity, got %d", len(env.identities.identities))
+	}
+
+	_, _, err = env.svc.Login(context.Background(), domain.LoginInput{Email: "jane@corp.test", Password: ""}, "127.0.0.1", "test-agent")
+	if !errors.Is(err, domain.ErrPasswordLoginDisabled) {
+		t.Errorf("expected password login to be disabled, got %v", err)
+	}
+	if err := env.svc.ForgotPassword(context.Background(), domain.ForgotPasswordInput{Email: "jane@corp.test"}); err != nil || len(env.mail.sent) != 0 {
+		t.Errorf("expected no reset link for a single sign-on user, got %v", err)
+	}
+
+	// The next login finds the same user and follows the provider's groups
+	env.provider.SetClaims(map[string]interface{}{
+		"sub": "idp-user-1", "email": "jane@corp.test", "email_verified": true, "groups": []interface{}{"staff"},
+	})
+	again, _, err := env.login(t)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if again.ID != user.ID || again.Role != domain.RoleEditor {
+		t.Errorf("expected the same user demoted to editor, got %+v", again)
+	}
+	if len(env.users.users) != 1 || len(env.identities.identities) != 1 {
+		t.Errorf("expected no new user or identity, got %d users", len(env.users.users))
+	}
+}
+
+func TestAuthService_OIDCLogin_LinksExistingUser(t *testing.T) {
+	env := setupOIDCTest(t, nil)
+	existing := createTestUser("jane@corp.test", "password123", domain.RoleEditor)
+	env.users.users[existing.Email] = existing
+
+	user, _, err := env.login(t)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if user.ID != existing.ID || user.Role != domain.RoleAdmin || len(env.users.users) != 1 {
+		t.Errorf("expected the existing user to be linked and given the mapped role, got %+v", user)
+	}
+
+	// Password login stays available to linked users
+	if _, _, err := env.svc.Login(context.Background(), domain.LoginInput{Email: "jane@corp.test", Password: "password123"}, "", ""); err != nil {
+		t.Errorf("expected password login to keep working, got %v", err)
+	}
+}
+
+func TestAuthService_OIDCLogin_RefusesUnsafeLinking(t *testing.T) {
+	cases := map[string]struct {
+		configure func(*service.OIDCOptions)
+		verified  bool
+	}{
+		"linking disabled": {func(o *service.OIDCOptions) { o.LinkByEmail = false }, true},
+		"unverified email": {nil, false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			env := setupOIDCTest(t, tc.configure)
+			existing := createTestUser("jane@corp.test", "password123", domain.RoleEditor)
+			env.users.users[existing.Email] = existing
+			env.provider.SetClaims(map[string]interface{}{
+				"sub": "idp-user-1", "email": "jane@corp.test", "email_verified": tc.verified,
+			})
+
+			if _, _, err := env.login(t); !errors.Is(err, domain.ErrAlreadyExists) {
+				t.Errorf("expected ErrAlreadyExists, got %v", err)
+			}
+			if len(env.identities.identities) != 0 || existing.Role != domain.RoleEditor {
+				t.Error("expected the existing user to stay untouched")
+			}
+		})
+	}
+}
+
+func TestAuthService_OIDCLogin_RoleRequired(t *testing.T) {
+	env := setupOIDCTest(t, nil)
+	env.provider.SetClaims(map[string]interface{}{
+		"sub": "idp-user-2", "email": "guest@corp.test", "email_verified": true, "groups": []interface{}{"sales"},
+	})
+
+	if _, _, err := env.login(t); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected ErrForbidden without a mapped role, got %v", err)
+	}
+	if len(env.users.users) != 0 {
+		t.Error("expected no user to be created")
+	}
+
+	env = setupOIDCTest(t, func(o *service.OIDCOptions) { o.DefaultRole = domain.RoleEditor })
+	env.provider.SetClaims(map[string]interface{}{
+		"sub": "idp-user-2", "email": "guest@corp.test", "email_verified": true, "groups": []interface{}{"sales"},
+	})
+	user, _, err := env.login(t)
+	if err != nil || user.Role != domain.RoleEditor {
+		t.Errorf("expected the default role, got %v %v", user, err)
+	}
+}
+
+func TestAuthService_OIDCLogin_TwoFactorRequired(t *testing.T) {
+	env := setupOIDCTest(t, nil)
+	env.twoFactor.policies[domain.RoleAdmin] = &domain.TwoFactorPolicy{Role: domain.RoleAdmin, IsRequired: true}
+	existing := createTestUser("jane@corp.test", "password123", domain.RoleEditor)
+	env.users.users[existing.Email] = existing
+
+	// Linked by email and mapped to admin, whose policy requires enrollment
+	user, tokens, err := env.login(t)
+	var challenge *domain.TwoFactorRequiredError
+	if !errors.As(err, &challenge) || user != nil || tokens != nil {
+		t.Fatalf("expected a two-factor challenge instead of tokens, got %v %v", tokens, err)
+	}
+	if !challenge.SetupRequired || challenge.ChallengeToken == "" {
+		t.Errorf("expected a setup challenge, got %+v", challenge)
+	}
+
+	// Roles without the requirement sign in directly
+	delete(env.twoFactor.policies, domain.RoleAdmin)
+	if _, tokens, err := env.login(t); err != nil || tokens == nil {
+		t.Errorf("expected tokens without a policy, got %v", err)
+	}
+}
+
+func TestAuthService_OIDCLogin_RejectsBadCallbacks(t *testing.T) {
+	env := setupOIDCTest(t, nil)
+	ctx := context.Background()
+
+	input := env.callback(t)
+	forged := input
+	forged.BrowserState = "other-browser"
+	if _, _, err := env.svc.CompleteOIDCLogin(ctx, forged, "", ""); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected a state from another browser to be refused, got %v", err)
+	}
+	if _, _, err := env.svc.CompleteOIDCLogin(ctx, input, "", ""); err != nil {
+		t.Fatalf("expected the real callback to work, got %v", err)
+	}
+	if _, _, err := env.svc.CompleteOIDCLogin(ctx, input, "", ""); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected a state to work once, got %v", err)
+	}
+
+	denied := env.callback(t)
+	denied.Code = ""
+	denied.Error = "access_denied"
+	if _, _, err := env.svc.CompleteOIDCLogin(ctx, denied, "", ""); !errors.Is(err, domain.ErrSSOFailed) {
+		t.Errorf("expected a provider error to fail the login, got %v", err)
+	}
+
+	// An ID token for another client is not accepted
+	env.provider.OverrideClaims(map[string]interface{}{"aud": "other-client"})
+	if _, _, err := env.login(t); !errors.Is(err, domain.ErrSSOFailed) {
+		t.Errorf("expected a foreign ID token to be refused, got %v", err)
+	}
+	env.provider.OverrideClaims(nil)
+
+	user := env.users.users["jane@corp.test"]
+	user.Status = domain.StatusSuspended
+	if _, _, err := env.login(t); !errors.Is(err, domain.ErrAccountInactive) {
+		t.Errorf("expected a suspended user to be refused, got %v", err)
+	}
+}
+
+func TestAuthService_OIDCLogin_Disabled(t *testing.T) {
+	svc := createTestAuthService(newMockUserRepository())
+	if _, err := svc.StartOIDCLogin(context.Background()); !errors.Is(err, domain.ErrSSODisabled) {
+		t.Errorf("expected ErrSSODisabled, got %v", err)
+	}
+	if _, _, err := svc.CompleteOIDCLogin(context.Background(), domain.OIDCCallbackInput{State: "s", BrowserState: "s"}, "", ""); !errors.Is(err, domain.ErrSSODisabled) {
+		t.Errorf("expected ErrSSODisabled, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// Audit actions written by the scheduler (values of the audit_action enum)
+const (
+	auditActionPublish   = "publish"
+	auditActionUnpublish = "unpublish"
+)
+
+// scheduleClaimLease is how long a claimed change is reserved for the instance
+// that claimed it. A change still claimed after that, because its instance
+// died before applying it, is claimed again by the next run.
+const scheduleClaimLease = 10 * time.Minute
+
+// PageScheduler applies scheduled page publishes and unpublishes in the background.
+// Due changes are leased atomically in the database, so several API replicas can
+// run a scheduler against the same Postgres without firing a change twice while
+// they are healthy. A schedule is cleared only after its change is applied, so
+// one whose instance dies mid-run is retried once the lease expires.
+type PageScheduler struct {
+	pageSvc   PageService
+	pageRepo  repository.PageRepository
+	compRepo  repository.ComponentRepository
+	interval  time.Duration
+	batchSize int
+	logger    zerolog.Logger
+}
+
+// NewPageScheduler creates a new PageScheduler
+func NewPageScheduler(
+	pageSvc PageService,
+	pageRepo repository.PageRepository,
+	compRepo repository.ComponentRepository,
+	interval time.Duration,
+	batchSize int,
+	logger zerolog.Logger,
+) *PageScheduler {
+	return &PageScheduler{
+		pageSvc:   pageSvc,
+		pageRepo:  pageRepo,
+		compRepo:  compRepo,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// Start runs the scheduler until ctx is cancelled. Schedules live in the database,
+// so changes that fell due while no instance was running fire on the first tick.
+func (s *PageScheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.logger.Info().Dur("interval", s.interval).Msg("page scheduler started")
+	for {
+		if _, err := s.RunDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			s.logger.Error().Err(err).Msg("page scheduler run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info().Msg("page scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue applies one batch of publishes and unpublishes that are due at now
+// and returns the number of changes applied. Once a batch is claimed it is
+// applied, or released, even if ctx is cancelled meanwhile; cancellation only
+// stops the next claim.
+func (s *PageScheduler) RunDue(ctx context.Context, now time.Time) (int, error) {
+	applied := 0
+	claimed := context.WithoutCancel(ctx)
+
+	publishes, err := s.pageRepo.ClaimDuePublishes(ctx, now, scheduleClaimLease, s.batchSize)
+	if err != nil {
+		return applied, fmt.Errorf("PageScheduler.RunDue publishes: %w", err)
+	}
+	for _, change := range publishes {
+		if _, err := s.pageSvc.PublishPage(claimed, change.PageID, uuid.Nil); err != nil {
+			s.release(claimed, change, err)
+			continue
+		}
+		s.complete(claimed, auditActionPublish, change)
+		applied++
+	}
+
+	unpublishes, err := s.pageRepo.ClaimDueUnpublishes(ctx, now, scheduleClaimLease, s.batchSize)
+	if err != nil {
+		return applied, fmt.Errorf("PageScheduler.RunDue unpublishes: %w", err)
+	}
+	for _, change := range unpublishes {
+		if _, err := s.pageSvc.UnpublishPage(claimed, change.PageID, uuid.Nil); err != nil {
+			s.release(claimed, change, err)
+			continue
+		}
+		s.complete(claimed, auditActionUnpublish, change)
+		applied++
+	}
+
+	return applied, nil
+}
+
+// complete clears an applied change's schedule and records it in the audit log.
+// If clearing fails the change fires again after the lease expires, which is
+// harmless because publishing and unpublishing are idempotent.
+func (s *PageScheduler) complete(ctx context.Context, action string, change *domain.ScheduledPageChange) {
+	if err := s.pageRepo.CompleteSchedule(ctx, change); err != nil {
+		s.logger.Error().Err(err).Str("page_id", change.PageID.String()).Msg("failed to complete scheduled page change")
+	}
+	s.audit(ctx, action, change)
+}
+
+// release drops the claim on a change that failed so a later tick can retry
+// it. Pages that were deleted in the meantime are no longer claimable.
+func (s *PageScheduler) release(ctx context.Context, change *domain.ScheduledPageChange, cause error) {
+	s.logger.Error().Err(cause).
+		Str("page_id", change.PageID.String()).
+		Time("scheduled_at", change.ScheduledAt).
+		Msg("failed to apply scheduled page change")
+
+	if errors.Is(cause, domain.ErrNotFound) {
+		return
+	}
+	if err := s.pageRepo.ReleaseSchedule(ctx, change); err != nil {
+		s.logger.Error().Err(err).Str("page_id", change.PageID.String()).Msg("failed to release scheduled page change")
+	}
+}
+
+// audit records an automatic publish or unpublish in the audit log
+func (s *PageScheduler) audit(ctx context.Context, action string, change *domain.ScheduledPageChange) {
+	resourceName := "page:" + change.Slug
+	log := &domain.AuditLog{
+		ID:           uuid.New(),
+		Action:       action,
+		ResourceType: "page",
+		ResourceID:   &change.PageID,
+		ResourceName: &resourceName,
+		SiteID:       &change.SiteID,
+		Metadata: domain.JSONMap{
+			"trigger":      "schedule",
+			"scheduled_at": change.ScheduledAt,
+		},
+	}
+	if err := s.compRepo.CreateAuditLog(ctx, log); err != nil {
+		s.logger.Error().Err(err).Str("page_id", change.PageID.String()).Msg("failed to create audit log")
+	}
+
+	s.logger.Info().
+		Str("page_id", change.PageID.String()).
+		Str("action", action).
+		Time("scheduled_at", change.ScheduledAt).
+		Msg("scheduled page change applied")
+}
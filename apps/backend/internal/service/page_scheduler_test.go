@@ -0,0 +1,273 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock audit log store ─────────────────────────────────────────────────────
+
+// mockAuditRepository only implements the audit log part of ComponentRepository
+type mockAuditRepository struct {
+	repository.ComponentRepository
+	logs []*domain.AuditLog
+}
+
+func (m *mockAuditRepository) CreateAuditLog(ctx context.Context, log *domain.AuditLog) error {
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func setupSchedulerTest(t *testing.T) (*mockPageRepository, *mockAuditRepository, service.PageService, *service.PageScheduler) {
+	t.Helper()
+	pageRepo := newMockPageRepository()
+	auditRepo := &mockAuditRepository{}
+	pageSvc := createTestPageService(pageRepo)
+	scheduler := service.NewPageScheduler(pageSvc, pageRepo, auditRepo, time.Minute, 50, zerolog.Nop())
+	return pageRepo, auditRepo, pageSvc, scheduler
+}
+
+func TestPageScheduler_PublishesAndUnpublishesOnTime(t *testing.T) {
+	pageRepo, auditRepo, pageSvc, scheduler := setupSchedulerTest(t)
+	ctx := context.Background()
+
+	page, _ := pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: uuid.New(), Title: "Launch", Slug: "launch", Status: domain.PageStatusDraft,
+	}, uuid.New())
+
+	publishAt := time.Now().Add(time.Hour)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+	if _, err := pageSvc.SchedulePage(ctx, page.ID, domain.SchedulePageInput{
+		PublishAt: &publishAt, UnpublishAt: &unpublishAt,
+	}, uuid.New()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// Nothing is due yet
+	applied, err := scheduler.RunDue(ctx, time.Now())
+	if err != nil || applied != 0 {
+		t.Fatalf("expected no changes before publish_at, got %d (err: %v)", applied, err)
+	}
+
+	// Publish fires once
+	applied, _ = scheduler.RunDue(ctx, publishAt.Add(time.Second))
+	if applied != 1 {
+		t.Fatalf("expected 1 change at publish_at, got %d", applied)
+	}
+	if pageRepo.pages[page.ID].Status != domain.PageStatusPublished {
+		t.Errorf("expected status 'published', got '%s'", pageRepo.pages[page.ID].Status)
+	}
+	if pageRepo.pages[page.ID].PublishAt != nil {
+		t.Error("expected publish_at to be cleared after firing")
+	}
+	if applied, _ = scheduler.RunDue(ctx, publishAt.Add(time.Minute)); applied != 0 {
+		t.Errorf("expected publish not to fire twice, got %d changes", applied)
+	}
+
+	// Unpublish fires at its own time
+	applied, _ = scheduler.RunDue(ctx, unpublishAt.Add(time.Second))
+	if applied != 1 {
+		t.Fatalf("expected 1 change at unpublish_at, got %d", applied)
+	}
+	if pageRepo.pages[page.ID].Status != domain.PageStatusDraft {
+		t.Errorf("expected status 'draft', got '%s'", pageRepo.pages[page.ID].Status)
+	}
+
+	if len(auditRepo.logs) != 2 {
+		t.Fatalf("expected 2 audit logs, got %d", len(auditRepo.logs))
+	}
+	if auditRepo.logs[0].Action != "publish" || auditRepo.logs[1].Action != "unpublish" {
+		t.Errorf("expected publish then unpublish audit actions, got %s, %s", auditRepo.logs[0].Action, auditRepo.logs[1].Action)
+	}
+	if auditRepo.logs[0].UserID != nil {
+		t.Error("expected scheduled changes to have no user")
+	}
+}
+
+func TestPageService_SchedulePage_Validation(t *testing.T) {
+	_, _, pageSvc, _ := setupSchedulerTest(t)
+	ctx := context.Background()
+
+	page, _ := pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: uuid.New(), Title: "Launch", Slug: "launch", Status: domain.PageStatusDraft,
+	}, uuid.New())
+
+	past := time.Now().Add(-time.Minute)
+	later := time.Now().Add(2 * time.Hour)
+	sooner := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		input   domain.SchedulePageInput
+		wantErr error
+	}{
+		{name: "publish in past", input: domain.SchedulePageInput{PublishAt: &past}, wantErr: domain.ErrScheduleInPast},
+		{name: "unpublish before publish", input: domain.SchedulePageInput{PublishAt: &later, UnpublishAt: &sooner}, wantErr: domain.ErrScheduleOrder},
+		{name: "valid", input: domain.SchedulePageInput{PublishAt: &sooner, UnpublishAt: &later}},
+		{name: "clear", input: domain.SchedulePageInput{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pageSvc.SchedulePage(ctx, page.ID, tt.input, uuid.New())
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// cancellingPageRepository fails like a database driver once the context is
+// cancelled, and cancels it right after a batch of publishes is claimed
+type cancellingPageRepository struct {
+	*mockPageRepository
+	cancel     context.CancelFunc
+	publishErr error
+}
+
+func (r *cancellingPageRepository) ClaimDuePublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error) {
+	changes, err := r.mockPageRepository.ClaimDuePublishes(ctx, now, lease, limit)
+	r.cancel()
+	return changes, err
+}
+
+func (r *cancellingPageRepository) ClaimDueUnpublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.mockPageRepository.ClaimDueUnpublishes(ctx, now, lease, limit)
+}
+
+func (r *cancellingPageRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.mockPageRepository.FindByID(ctx, id)
+}
+
+func (r *cancellingPageRepository) Publish(ctx context.Context, page *domain.Page) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if r.publishErr != nil {
+		return r.publishErr
+	}
+	return r.mockPageRepository.Publish(ctx, page)
+}
+
+func (r *cancellingPageRepository) CompleteSchedule(ctx context.Context, change *domain.ScheduledPageChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.mockPageRepository.CompleteSchedule(ctx, change)
+}
+
+func (r *cancellingPageRepository) ReleaseSchedule(ctx context.Context, change *domain.ScheduledPageChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.mockPageRepository.ReleaseSchedule(ctx, change)
+}
+
+func TestPageScheduler_ClaimedBatchSurvivesCancel(t *testing.T) {
+	tests := []struct {
+		name          string
+		publishErr    error
+		wantPublished bool
+		wantReleased  bool
+		wantAuditLogs int
+	}{
+		{name: "applied", wantPublished: true, wantAuditLogs: 1},
+		{name: "released after failing", publishErr: errors.New("connection reset"), wantReleased: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			mock := newMockPageRepository()
+			pageRepo := &cancellingPageRepository{mockPageRepository: mock, cancel: cancel, publishErr: tt.publishErr}
+			logger := zerolog.Nop()
+			pageSvc := service.NewPageService(pageRepo, service.NewRevisionService(pageRepo, newMockRevisionRepository(), logger), logger)
+			auditRepo := &mockAuditRepository{}
+			scheduler := service.NewPageScheduler(pageSvc, pageRepo, auditRepo, time.Minute, 50, logger)
+
+			page, _ := pageSvc.CreatePage(ctx, domain.CreatePageInput{
+				SiteID: uuid.New(), Title: "Launch", Slug: "launch", Status: domain.PageStatusDraft,
+			}, uuid.New())
+			publishAt := time.Now().Add(time.Hour)
+			if _, err := pageSvc.SchedulePage(ctx, page.ID, domain.SchedulePageInput{PublishAt: &publishAt}, uuid.New()); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+
+			if _, err := scheduler.RunDue(ctx, publishAt.Add(time.Second)); err == nil {
+				t.Error("expected the cancelled context to stop the next claim")
+			}
+
+			if _, published := mock.published[page.ID]; published != tt.wantPublished {
+				t.Errorf("expected published=%v, got %v", tt.wantPublished, published)
+			}
+			live := mock.pages[page.ID]
+			if released := live.PublishAt != nil && live.PublishAt.Equal(publishAt); released != tt.wantReleased {
+				t.Errorf("expected released=%v, got publish_at %v", tt.wantReleased, live.PublishAt)
+			}
+			if len(mock.claims) != 0 {
+				t.Errorf("expected the claim to be cleared, got %d claims", len(mock.claims))
+			}
+			if len(auditRepo.logs) != tt.wantAuditLogs {
+				t.Errorf("expected %d audit logs, got %d", tt.wantAuditLogs, len(auditRepo.logs))
+			}
+		})
+	}
+}
+
+func TestPageScheduler_ReclaimsAbandonedChangeAfterLease(t *testing.T) {
+	pageRepo, _, pageSvc, scheduler := setupSchedulerTest(t)
+	ctx := context.Background()
+
+	page, _ := pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: uuid.New(), Title: "Launch", Slug: "launch", Status: domain.PageStatusDraft,
+	}, uuid.New())
+	publishAt := time.Now().Add(time.Hour)
+	if _, err := pageSvc.SchedulePage(ctx, page.ID, domain.SchedulePageInput{PublishAt: &publishAt}, uuid.New()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// Another instance claims the change and dies before applying it
+	claimedAt := publishAt.Add(time.Second)
+	changes, _ := pageRepo.ClaimDuePublishes(ctx, claimedAt, time.Minute, 50)
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 claimed change, got %d", len(changes))
+	}
+	if pageRepo.pages[page.ID].PublishAt == nil {
+		t.Fatal("expected publish_at to be kept while the change is claimed")
+	}
+
+	// The claim is respected while its lease lasts
+	if applied, _ := scheduler.RunDue(ctx, claimedAt.Add(time.Minute)); applied != 0 {
+		t.Errorf("expected the leased change to be skipped, got %d changes", applied)
+	}
+
+	// and taken over once it has expired
+	if applied, _ := scheduler.RunDue(ctx, claimedAt.Add(time.Hour)); applied != 1 {
+		t.Fatalf("expected the abandoned change to be applied, got %d changes", applied)
+	}
+	if _, published := pageRepo.published[page.ID]; !published {
+		t.Error("expected the page to be published")
+	}
+	if pageRepo.pages[page.ID].PublishAt != nil {
+		t.Error("expected publish_at to be cleared after firing")
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -24,33 +26,40 @@ type PageService interface {
 	DeletePage(ctx context.Context, id uuid.UUID) error
 	PublishPage(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Page, error)
 	UnpublishPage(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Page, error)
+	SchedulePage(ctx context.Context, id uuid.UUID, input domain.SchedulePageInput, userID uuid.UUID) (*domain.Page, error)
+	ClearSchedule(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Page, error)
 
 	// Section operations
 	GetSection(ctx context.Context, id uuid.UUID) (*domain.PageSection, error)
 	ListSections(ctx context.Context, pageID uuid.UUID) ([]*domain.PageSection, error)
-	CreateSection(ctx context.Context, input domain.CreateSectionInput) (*domain.PageSection, error)
-	UpdateSection(ctx context.Context, id uuid.UUID, input domain.UpdateSectionInput) (*domain.PageSection, error)
-	DeleteSection(ctx context.Context, id uuid.UUID) error
-	ReorderSections(ctx context.Context, input domain.ReorderSectionsInput) error
+	CreateSection(ctx context.Context, input domain.CreateSectionInput, userID uuid.UUID) (*domain.PageSection, error)
+	UpdateSection(ctx context.Context, id uuid.UUID, input domain.UpdateSectionInput, userID uuid.UUID) (*domain.PageSection, error)
+	DeleteSection(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	ReorderSections(ctx context.Context, input domain.ReorderSectionsInput, userID uuid.UUID) error
 
 	// Content operations
 	GetSectionContents(ctx context.Context, sectionID uuid.UUID) ([]*domain.SectionContent, error)
-	UpsertContent(ctx context.Context, sectionID uuid.UUID, input domain.UpsertContentInput) (*domain.SectionContent, error)
-	DeleteContent(ctx context.Context, id uuid.UUID) error
-	BulkUpsertContents(ctx context.Context, sectionID uuid.UUID, inputs []domain.UpsertContentInput) ([]*domain.SectionContent, error)
+	UpsertContent(ctx context.Context, sectionID uuid.UUID, input domain.UpsertContentInput, userID uuid.UUID) (*domain.SectionContent, error)
+	DeleteContent(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
+	BulkUpsertContents(ctx context.Context, sectionID uuid.UUID, inputs []domain.UpsertContentInput, userID uuid.UUID) ([]*domain.SectionContent, error)
 }
 
 // pageService implements PageService
 type pageService struct {
-	pageRepo repository.PageRepository
-	logger   zerolog.Logger
+	pageRepo    repository.PageRepository
+	revisionSvc RevisionService
+	listeners   []SiteChangeListener
+	logger      zerolog.Logger
 }
 
-// NewPageService creates a new pageService
-func NewPageService(pageRepo repository.PageRepository, logger zerolog.Logger) PageService {
+// NewPageService creates a new pageService. Listeners are told whenever the
+// published pages of a site change.
+func NewPageService(pageRepo repository.PageRepository, revisionSvc RevisionService, logger zerolog.Logger, listeners ...SiteChangeListener) PageService {
 	return &pageService{
-		pageRepo: pageRepo,
-		logger:   logger,
+		pageRepo:    pageRepo,
+		revisionSvc: revisionSvc,
+		listeners:   listeners,
+		logger:      logger,
 	}
 }
 
@@ -81,37 +90,21 @@ func (s *pageService) GetHomepage(ctx context.Context, siteID uuid.UUID) (*domai
 	return page, nil
 }
 
-// GetPageWithContent retrieves a page with all its sections and content
+// GetPageWithContent retrieves the published snapshot of a page with all its sections
+// and content. Draft edits made after the last publish are never returned.
 func (s *pageService) GetPageWithContent(ctx context.Context, siteID uuid.UUID, slug string) (*domain.Page, error) {
 	var page *domain.Page
 	var err error
 
 	if slug == "" || slug == "home" {
-		page, err = s.pageRepo.FindHomepage(ctx, siteID)
+		page, err = s.pageRepo.FindPublishedHomepage(ctx, siteID)
 	} else {
-		page, err = s.pageRepo.FindBySlug(ctx, siteID, slug)
+		page, err = s.pageRepo.FindPublishedBySlug(ctx, siteID, slug)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("pageService.GetPageWithContent: %w", err)
 	}
-
-	// Load sections
-	sections, err := s.pageRepo.FindSectionsByPageID(ctx, page.ID)
-	if err != nil {
-		return nil, fmt.Errorf("pageService.GetPageWithContent sections: %w", err)
-	}
-
-	// Load content for each section
-	for _, section := range sections {
-		contents, err := s.pageRepo.FindContentsBySectionID(ctx, section.ID)
-		if err != nil {
-			return nil, fmt.Errorf("pageService.GetPageWithContent contents: %w", err)
-		}
-		section.Contents = contents
-	}
-
-	page.Sections = sections
 	return page, nil
 }
 
@@ -122,6 +115,7 @@ func (s *pageService) ListPages(ctx context.Context, filter domain.PageFilter) (
 		return nil, fmt.Errorf("pageService.ListPages: %w", err)
 	}
 
+	filter.Normalize()
 	result := domain.NewPaginatedResult(pages, total, filter.Pagination)
 	return &result, nil
 }
@@ -173,6 +167,19 @@ func (s *pageService) CreatePage(ctx context.Context, input domain.CreatePageInp
 		}
 	}
 
+	if page.Status == domain.PageStatusPublished {
+		if err := s.publishDraft(ctx, page, userID); err != nil {
+			return nil, fmt.Errorf("pageService.CreatePage: %w", err)
+		}
+	}
+
+	if page.Status == domain.PageStatusPublished || page.IsHomepage {
+		s.notifySiteChanged(page.SiteID)
+	}
+	if err := s.recordRevision(ctx, page.ID, userID, "page created"); err != nil {
+		return nil, fmt.Errorf("pageService.CreatePage: %w", err)
+	}
+
 	s.logger.Info().
 		Str("page_id", page.ID.String()).
 		Str("slug", page.Slug).
@@ -188,6 +195,7 @@ func (s *pageService) UpdatePage(ctx context.Context, id uuid.UUID, input domain
 	if err != nil {
 		return nil, fmt.Errorf("pageService.UpdatePage find: %w", err)
 	}
+	wasPublished := page.Status == domain.PageStatusPublished
 
 	// Apply updates
 	if input.Title != nil {
@@ -250,7 +258,10 @@ func (s *pageService) UpdatePage(ctx context.Context, id uuid.UUID, input domain
 	if input.CustomHead != nil {
 		page.CustomHead = input.CustomHead
 	}
-	page.UpdatedBy = &userID
+	// System changes such as scheduled unpublishes carry no user
+	if userID != uuid.Nil {
+		page.UpdatedBy = &userID
+	}
 
 	if err := s.pageRepo.Update(ctx, page); err != nil {
 		return nil, fmt.Errorf("pageService.UpdatePage: %w", err)
@@ -263,21 +274,60 @@ func (s *pageService) UpdatePage(ctx context.Context, id uuid.UUID, input domain
 		}
 	}
 
+	// Switching the status to published promotes the current draft
+	if !wasPublished && page.Status == domain.PageStatusPublished {
+		if err := s.publishDraft(ctx, page, userID); err != nil {
+			return nil, fmt.Errorf("pageService.UpdatePage: %w", err)
+		}
+	}
+
+	if wasPublished || page.Status == domain.PageStatusPublished {
+		s.notifySiteChanged(page.SiteID)
+	}
+	if err := s.recordRevision(ctx, page.ID, userID, "page updated"); err != nil {
+		return nil, fmt.Errorf("pageService.UpdatePage: %w", err)
+	}
+
 	return page, nil
 }
 
 // DeletePage soft-deletes a page
 func (s *pageService) DeletePage(ctx context.Context, id uuid.UUID) error {
+	page, err := s.pageRepo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("pageService.DeletePage find: %w", err)
+	}
 	if err := s.pageRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("pageService.DeletePage: %w", err)
 	}
+	if page.Status == domain.PageStatusPublished {
+		s.notifySiteChanged(page.SiteID)
+	}
 	return nil
 }
 
-// PublishPage publishes a page
+// PublishPage promotes the page's current draft into its published snapshot.
+// Publishing an already published page re-publishes the latest draft.
 func (s *pageService) PublishPage(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Page, error) {
-	status := domain.PageStatusPublished
-	return s.UpdatePage(ctx, id, domain.UpdatePageInput{Status: &status}, userID)
+	page, err := s.pageRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("pageService.PublishPage find: %w", err)
+	}
+
+	if err := s.publishDraft(ctx, page, userID); err != nil {
+		return nil, fmt.Errorf("pageService.PublishPage: %w", err)
+	}
+
+	if err := s.recordRevision(ctx, page.ID, userID, "page published"); err != nil {
+		return nil, fmt.Errorf("pageService.PublishPage: %w", err)
+	}
+
+	s.logger.Info().
+		Str("page_id", page.ID.String()).
+		Str("user_id", userID.String()).
+		Msg("page published")
+
+	return page, nil
 }
 
 // UnpublishPage unpublishes a page
@@ -286,6 +336,39 @@ func (s *pageService) UnpublishPage(ctx context.Context, id uuid.UUID, userID uu
 	return s.UpdatePage(ctx, id, domain.UpdatePageInput{Status: &status}, userID)
 }
 
+// SchedulePage sets the page's scheduled publish and unpublish times, replacing
+// any existing schedule. A nil time clears that side of the schedule.
+func (s *pageService) SchedulePage(ctx context.Context, id uuid.UUID, input domain.SchedulePageInput, userID uuid.UUID) (*domain.Page, error) {
+	now := time.Now()
+	if input.PublishAt != nil && !input.PublishAt.After(now) {
+		return nil, fmt.Errorf("pageService.SchedulePage: %w", domain.ErrScheduleInPast)
+	}
+	if input.UnpublishAt != nil && !input.UnpublishAt.After(now) {
+		return nil, fmt.Errorf("pageService.SchedulePage: %w", domain.ErrScheduleInPast)
+	}
+	if input.PublishAt != nil && input.UnpublishAt != nil && !input.UnpublishAt.After(*input.PublishAt) {
+		return nil, fmt.Errorf("pageService.SchedulePage: %w", domain.ErrScheduleOrder)
+	}
+
+	if err := s.pageRepo.UpdateSchedule(ctx, id, input.PublishAt, input.UnpublishAt); err != nil {
+		return nil, fmt.Errorf("pageService.SchedulePage: %w", err)
+	}
+
+	s.logger.Info().
+		Str("page_id", id.String()).
+		Str("user_id", userID.String()).
+		Interface("publish_at", input.PublishAt).
+		Interface("unpublish_at", input.UnpublishAt).
+		Msg("page schedule updated")
+
+	return s.GetPage(ctx, id)
+}
+
+// ClearSchedule removes any pending scheduled publish or unpublish for a page
+func (s *pageService) ClearSchedule(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*domain.Page, error) {
+	return s.SchedulePage(ctx, id, domain.SchedulePageInput{}, userID)
+}
+
 // GetSection retrieves a section by ID
 func (s *pageService) GetSection(ctx context.Context, id uuid.UUID) (*domain.PageSection, error) {
 	section, err := s.pageRepo.FindSectionByID(ctx, id)
@@ -305,7 +388,7 @@ func (s *pageService) ListSections(ctx context.Context, pageID uuid.UUID) ([]*do
 }
 
 // CreateSection creates a new page section
-func (s *pageService) CreateSection(ctx context.Context, input domain.CreateSectionInput) (*domain.PageSection, error) {
+func (s *pageService) CreateSection(ctx context.Context, input domain.CreateSectionInput, userID uuid.UUID) (*domain.PageSection, error) {
 	section := &domain.PageSection{
 		ID:         uuid.New(),
 		PageID:     input.PageID,
@@ -315,16 +398,23 @@ func (s *pageService) CreateSection(ctx context.Context, input domain.CreateSect
 		IsVisible:  input.IsVisible,
 		SortOrder:  input.SortOrder,
 	}
+	if input.Preset {
+		section.ContentSchema = domain.PresetFields(input.Type)
+	}
 
 	if err := s.pageRepo.CreateSection(ctx, section); err != nil {
 		return nil, fmt.Errorf("pageService.CreateSection: %w", err)
 	}
 
+	if err := s.recordRevision(ctx, section.PageID, userID, "section created: "+section.Name); err != nil {
+		return nil, fmt.Errorf("pageService.CreateSection: %w", err)
+	}
+
 	return section, nil
 }
 
 // UpdateSection updates an existing section
-func (s *pageService) UpdateSection(ctx context.Context, id uuid.UUID, input domain.UpdateSectionInput) (*domain.PageSection, error) {
+func (s *pageService) UpdateSection(ctx context.Context, id uuid.UUID, input domain.UpdateSectionInput, userID uuid.UUID) (*domain.PageSection, error) {
 	section, err := s.pageRepo.FindSectionByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("pageService.UpdateSection find: %w", err)
@@ -383,22 +473,39 @@ func (s *pageService) UpdateSection(ctx context.Context, id uuid.UUID, input dom
 		return nil, fmt.Errorf("pageService.UpdateSection: %w", err)
 	}
 
+	if err := s.recordRevision(ctx, section.PageID, userID, "section updated: "+section.Name); err != nil {
+		return nil, fmt.Errorf("pageService.UpdateSection: %w", err)
+	}
+
 	return section, nil
 }
 
 // DeleteSection deletes a section
-func (s *pageService) DeleteSection(ctx context.Context, id uuid.UUID) error {
+func (s *pageService) DeleteSection(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	section, err := s.pageRepo.FindSectionByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("pageService.DeleteSection find: %w", err)
+	}
+
 	if err := s.pageRepo.DeleteSection(ctx, id); err != nil {
 		return fmt.Errorf("pageService.DeleteSection: %w", err)
 	}
+
+	if err := s.recordRevision(ctx, section.PageID, userID, "section deleted: "+section.Name); err != nil {
+		return fmt.Errorf("pageService.DeleteSection: %w", err)
+	}
 	return nil
 }
 
 // ReorderSections reorders sections
-func (s *pageService) ReorderSections(ctx context.Context, input domain.ReorderSectionsInput) error {
+func (s *pageService) ReorderSections(ctx context.Context, input domain.ReorderSectionsInput, userID uuid.UUID) error {
 	if err := s.pageRepo.ReorderSections(ctx, input.Sections); err != nil {
 		return fmt.Errorf("pageService.ReorderSections: %w", err)
 	}
+
+	if err := s.recordSectionRevision(ctx, input.Sections[0].ID, userID, "sections reordered"); err != nil {
+		return fmt.Errorf("pageService.ReorderSections: %w", err)
+	}
 	return nil
 }
 
@@ -412,7 +519,29 @@ func (s *pageService) GetSectionContents(ctx context.Context, sectionID uuid.UUI
 }
 
 // UpsertContent creates or updates a content item
-func (s *pageService) UpsertContent(ctx context.Context, sectionID uuid.UUID, input domain.UpsertContentInput) (*domain.SectionContent, error) {
+func (s *pageService) UpsertContent(ctx context.Context, sectionID uuid.UUID, input domain.UpsertContentInput, userID uuid.UUID) (*domain.SectionContent, error) {
+	schema, err := s.sectionSchema(ctx, sectionID)
+	if err != nil {
+		return nil, fmt.Errorf("pageService.UpsertContent: %w", err)
+	}
+	inputs := []domain.UpsertContentInput{input}
+	if err := applyContentSchema(schema, inputs); err != nil {
+		return nil, fmt.Errorf("pageService.UpsertContent: %w", err)
+	}
+
+	content, err := s.upsertContent(ctx, sectionID, inputs[0])
+	if err != nil {
+		return nil, fmt.Errorf("pageService.UpsertContent: %w", err)
+	}
+
+	if err := s.recordSectionRevision(ctx, sectionID, userID, "content updated: "+input.Key); err != nil {
+		return nil, fmt.Errorf("pageService.UpsertContent: %w", err)
+	}
+	return content, nil
+}
+
+// upsertContent writes a content item without recording a revision
+func (s *pageService) upsertContent(ctx context.Context, sectionID uuid.UUID, input domain.UpsertContentInput) (*domain.SectionContent, error) {
 	content := &domain.SectionContent{
 		ID:          uuid.New(),
 		SectionID:   sectionID,
@@ -433,33 +562,144 @@ func (s *pageService) UpsertContent(ctx context.Context, sectionID uuid.UUID, in
 	}
 
 	if err := s.pageRepo.UpsertContent(ctx, content); err != nil {
-		return nil, fmt.Errorf("pageService.UpsertContent: %w", err)
+		return nil, err
 	}
 
 	return content, nil
 }
 
 // DeleteContent deletes a content item
-func (s *pageService) DeleteContent(ctx context.Context, id uuid.UUID) error {
+func (s *pageService) DeleteContent(ctx context.Context, id uuid.UUID, userID uuid.UUID) error {
+	content, err := s.pageRepo.FindContentByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("pageService.DeleteContent find: %w", err)
+	}
+
+	schema, err := s.sectionSchema(ctx, content.SectionID)
+	if err != nil {
+		return fmt.Errorf("pageService.DeleteContent: %w", err)
+	}
+	if f, ok := schema.Field(content.Key); ok && f.IsRequired {
+		return fmt.Errorf("pageService.DeleteContent: %w", &domain.ContentSchemaError{
+			Problems: []string{fmt.Sprintf("content key %q is required", content.Key)},
+		})
+	}
+
 	if err := s.pageRepo.DeleteContent(ctx, id); err != nil {
 		return fmt.Errorf("pageService.DeleteContent: %w", err)
 	}
+
+	if err := s.recordSectionRevision(ctx, content.SectionID, userID, "content deleted: "+content.Key); err != nil {
+		return fmt.Errorf("pageService.DeleteContent: %w", err)
+	}
 	return nil
 }
 
 // BulkUpsertContents creates or updates multiple content items
-func (s *pageService) BulkUpsertContents(ctx context.Context, sectionID uuid.UUID, inputs []domain.UpsertContentInput) ([]*domain.SectionContent, error) {
+func (s *pageService) BulkUpsertContents(ctx context.Context, sectionID uuid.UUID, inputs []domain.UpsertContentInput, userID uuid.UUID) ([]*domain.SectionContent, error) {
+	schema, err := s.sectionSchema(ctx, sectionID)
+	if err != nil {
+		return nil, fmt.Errorf("pageService.BulkUpsertContents: %w", err)
+	}
+	// Nothing is written unless every item matches the schema
+	if err := applyContentSchema(schema, inputs); err != nil {
+		return nil, fmt.Errorf("pageService.BulkUpsertContents: %w", err)
+	}
+
 	var results []*domain.SectionContent
 	for _, input := range inputs {
-		content, err := s.UpsertContent(ctx, sectionID, input)
+		content, err := s.upsertContent(ctx, sectionID, input)
 		if err != nil {
 			return nil, fmt.Errorf("pageService.BulkUpsertContents: %w", err)
 		}
 		results = append(results, content)
 	}
+
+	if err := s.recordSectionRevision(ctx, sectionID, userID, fmt.Sprintf("%d contents updated", len(results))); err != nil {
+		return nil, fmt.Errorf("pageService.BulkUpsertContents: %w", err)
+	}
 	return results, nil
 }
 
+// sectionSchema returns the content schema of a section. Content of a missing
+// section fails on the foreign key when written, so a missing section has no
+// schema here.
+func (s *pageService) sectionSchema(ctx context.Context, sectionID uuid.UUID) (domain.ContentSchema, error) {
+	section, err := s.pageRepo.FindSectionByID(ctx, sectionID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return section.ContentSchema, nil
+}
+
+// recordRevision snapshots a page after a change made by userID. The change
+// itself is already persisted when this fails, so the error tells the caller
+// that its edit is missing from the history rather than that it was lost.
+func (s *pageService) recordRevision(ctx context.Context, pageID uuid.UUID, userID uuid.UUID, summary string) error {
+	if _, err := s.revisionSvc.RecordRevision(ctx, pageID, userID, summary); err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+	return nil
+}
+
+// recordSectionRevision snapshots the page that owns a section after a change
+func (s *pageService) recordSectionRevision(ctx context.Context, sectionID uuid.UUID, userID uuid.UUID, summary string) error {
+	section, err := s.pageRepo.FindSectionByID(ctx, sectionID)
+	if err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+	return s.recordRevision(ctx, section.PageID, userID, summary)
+}
+
+// publishDraft loads the page's draft tree and stores it as the published snapshot
+func (s *pageService) publishDraft(ctx context.Context, page *domain.Page, userID uuid.UUID) error {
+	if err := loadPageTree(ctx, s.pageRepo, page); err != nil {
+		return fmt.Errorf("publish draft: %w", err)
+	}
+
+	now := time.Now()
+	page.Status = domain.PageStatusPublished
+	page.PublishedAt = &now
+	if userID != uuid.Nil {
+		page.UpdatedBy = &userID
+	}
+
+	if err := s.pageRepo.Publish(ctx, page); err != nil {
+		return fmt.Errorf("publish draft: %w", err)
+	}
+	s.notifySiteChanged(page.SiteID)
+	return nil
+}
+
+// notifySiteChanged tells the listeners that the published pages of a site changed
+func (s *pageService) notifySiteChanged(siteID uuid.UUID) {
+	for _, listener := range s.listeners {
+		listener(siteID)
+	}
+}
+
+// loadPageTree populates a page's sections and each section's contents
+func loadPageTree(ctx context.Context, pageRepo repository.PageRepository, page *domain.Page) error {
+	sections, err := pageRepo.FindSectionsByPageID(ctx, page.ID)
+	if err != nil {
+		return fmt.Errorf("load sections: %w", err)
+	}
+
+	for _, section := range sections {
+		contents, err := pageRepo.FindContentsBySectionID(ctx, section.ID)
+		if err != nil {
+			return fmt.Errorf("load contents: %w", err)
+		}
+		section.Contents = contents
+	}
+
+	page.Sections = sections
+	return nil
+}
+
 // generateSlug creates a URL-friendly slug from a title
 func generateSlug(title string) string {
 	slug := strings.ToLower(title)
@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -15,16 +16,27 @@ import (
 // ─── Mock PageRepository ──────────────────────────────────────────────────────
 
 type mockPageRepository struct {
-	pages    map[uuid.UUID]*domain.Page
-	sections map[uuid.UUID]*domain.PageSection
-	contents map[uuid.UUID]*domain.SectionContent
+	pages      map[uuid.UUID]*domain.Page
+	sections   map[uuid.UUID]*domain.PageSection
+	contents   map[uuid.UUID]*domain.SectionContent
+	published  map[uuid.UUID]*domain.Page
+	claims     map[scheduleClaimKey]time.Time
+	components *domain.ComponentSet // last set passed to CreateTree
+}
+
+// scheduleClaimKey identifies one side of a page's schedule in the mock's claims
+type scheduleClaimKey struct {
+	pageID uuid.UUID
+	kind   domain.ScheduleKind
 }
 
 func newMockPageRepository() *mockPageRepository {
 	return &mockPageRepository{
-		pages:    make(map[uuid.UUID]*domain.Page),
-		sections: make(map[uuid.UUID]*domain.PageSection),
-		contents: make(map[uuid.UUID]*domain.SectionContent),
+		pages:     make(map[uuid.UUID]*domain.Page),
+		sections:  make(map[uuid.UUID]*domain.PageSection),
+		contents:  make(map[uuid.UUID]*domain.SectionContent),
+		published: make(map[uuid.UUID]*domain.Page),
+		claims:    make(map[scheduleClaimKey]time.Time),
 	}
 }
 
@@ -100,6 +112,136 @@ func (m *mockPageRepository) SetHomepage(ctx context.Context, siteID, pageID uui
 	return nil
 }
 
+func (m *mockPageRepository) FindPublishedBySlug(ctx context.Context, siteID uuid.UUID, slug string) (*domain.Page, error) {
+	for id, p := range m.published {
+		if p.SiteID == siteID && p.Slug == slug && m.pages[id].Status == domain.PageStatusPublished {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockPageRepository) FindPublishedHomepage(ctx context.Context, siteID uuid.UUID) (*domain.Page, error) {
+	for id, p := range m.published {
+		live := m.pages[id]
+		if p.SiteID == siteID && live.IsHomepage && live.Status == domain.PageStatusPublished {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockPageRepository) FindSitemapPages(ctx context.Context, siteID uuid.UUID) ([]*domain.Page, error) {
+	var pages []*domain.Page
+	for id, p := range m.published {
+		live := m.pages[id]
+		if p.SiteID != siteID || live.Status != domain.PageStatusPublished {
+			continue
+		}
+		page := *p
+		page.IsHomepage = live.IsHomepage
+		page.Sections = nil
+		pages = append(pages, &page)
+	}
+	return pages, nil
+}
+
+func (m *mockPageRepository) FindPublishedPages(ctx context.Context, siteID uuid.UUID) ([]*domain.Page, error) {
+	var pages []*domain.Page
+	for id, p := range m.published {
+		live := m.pages[id]
+		if p.SiteID != siteID || live.Status != domain.PageStatusPublished {
+			continue
+		}
+		page := *p
+		page.IsHomepage = live.IsHomepage
+		pages = append(pages, &page)
+	}
+	return pages, nil
+}
+
+func (m *mockPageRepository) Publish(ctx context.Context, page *domain.Page) error {
+	live, ok := m.pages[page.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	live.Status = domain.PageStatusPublished
+	live.PublishedAt = page.PublishedAt
+
+	// Round-trip through JSON like the JSONB column does, so later draft edits
+	// cannot leak into the published snapshot through shared pointers.
+	b, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	var snapshot domain.Page
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return err
+	}
+	m.published[page.ID] = &snapshot
+	return nil
+}
+
+func (m *mockPageRepository) UpdateSchedule(ctx context.Context, id uuid.UUID, publishAt, unpublishAt *time.Time) error {
+	p, ok := m.pages[id]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	p.PublishAt = publishAt
+	p.UnpublishAt = unpublishAt
+	delete(m.claims, scheduleClaimKey{id, domain.SchedulePublish})
+	delete(m.claims, scheduleClaimKey{id, domain.ScheduleUnpublish})
+	return nil
+}
+
+func (m *mockPageRepository) claimDue(now time.Time, lease time.Duration, limit int, kind domain.ScheduleKind) []*domain.ScheduledPageChange {
+	var changes []*domain.ScheduledPageChange
+	for _, p := range m.pages {
+		at := p.PublishAt
+		if kind == domain.ScheduleUnpublish {
+			at = p.UnpublishAt
+		}
+		key := scheduleClaimKey{p.ID, kind}
+		if claimedAt, ok := m.claims[key]; ok && claimedAt.After(now.Add(-lease)) {
+			continue
+		}
+		if at != nil && !at.After(now) && len(changes) < limit {
+			changes = append(changes, &domain.ScheduledPageChange{PageID: p.ID, SiteID: p.SiteID, Slug: p.Slug, Kind: kind, ScheduledAt: *at})
+			m.claims[key] = now
+		}
+	}
+	return changes
+}
+
+func (m *mockPageRepository) ClaimDuePublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error) {
+	return m.claimDue(now, lease, limit, domain.SchedulePublish), nil
+}
+
+func (m *mockPageRepository) ClaimDueUnpublishes(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.ScheduledPageChange, error) {
+	return m.claimDue(now, lease, limit, domain.ScheduleUnpublish), nil
+}
+
+func (m *mockPageRepository) CompleteSchedule(ctx context.Context, change *domain.ScheduledPageChange) error {
+	delete(m.claims, scheduleClaimKey{change.PageID, change.Kind})
+	p, ok := m.pages[change.PageID]
+	if !ok {
+		return nil
+	}
+	at := &p.PublishAt
+	if change.Kind == domain.ScheduleUnpublish {
+		at = &p.UnpublishAt
+	}
+	if *at != nil && (*at).Equal(change.ScheduledAt) {
+		*at = nil
+	}
+	return nil
+}
+
+func (m *mockPageRepository) ReleaseSchedule(ctx context.Context, change *domain.ScheduledPageChange) error {
+	delete(m.claims, scheduleClaimKey{change.PageID, change.Kind})
+	return nil
+}
+
 func (m *mockPageRepository) FindSectionsByPageID(ctx context.Context, pageID uuid.UUID) ([]*domain.PageSection, error) {
 	var sections []*domain.PageSection
 	for _, s := range m.sections {
@@ -205,11 +347,101 @@ func (m *mockPageRepository) DeleteContentByKey(ctx context.Context, sectionID u
 	return nil
 }
 
+func (m *mockPageRepository) FindContentByID(ctx context.Context, id uuid.UUID) (*domain.SectionContent, error) {
+	if c, ok := m.contents[id]; ok {
+		return c, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockPageRepository) ReplaceTree(ctx context.Context, page *domain.Page) error {
+	existing, ok := m.pages[page.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	restored := *page
+	restored.Status = existing.Status
+	restored.IsHomepage = existing.IsHomepage
+	restored.PublishedAt = existing.PublishedAt
+	restored.Sections = nil
+	m.pages[page.ID] = &restored
+
+	for id, s := range m.sections {
+		if s.PageID != page.ID {
+			continue
+		}
+		for contentID, c := range m.contents {
+			if c.SectionID == id {
+				delete(m.contents, contentID)
+			}
+		}
+		delete(m.sections, id)
+	}
+	for _, section := range page.Sections {
+		copied := *section
+		copied.PageID = page.ID
+		copied.Contents = nil
+		m.sections[section.ID] = &copied
+		for _, content := range section.Contents {
+			c := *content
+			c.SectionID = section.ID
+			m.contents[c.ID] = &c
+		}
+	}
+	return nil
+}
+
+func (m *mockPageRepository) CreateTree(ctx context.Context, page *domain.Page, components *domain.ComponentSet) error {
+	created := *page
+	created.Sections = nil
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = time.Now()
+	m.pages[page.ID] = &created
+	for _, section := range page.Sections {
+		copied := *section
+		copied.Contents = nil
+		m.sections[section.ID] = &copied
+		for _, content := range section.Contents {
+			c := *content
+			m.contents[c.ID] = &c
+		}
+	}
+	m.components = components
+	return nil
+}
+
 // ─── Tests ────────────────────────────────────────────────────────────────────
 
 func createTestPageService(repo *mockPageRepository) service.PageService {
 	logger := zerolog.Nop()
-	return service.NewPageService(repo, logger)
+	revisionSvc := service.NewRevisionService(repo, newMockRevisionRepository(), logger)
+	return service.NewPageService(repo, revisionSvc, logger)
+}
+
+func createTestPage(t *testing.T, svc service.PageService) *domain.Page {
+	t.Helper()
+	page, err := svc.CreatePage(context.Background(), domain.CreatePageInput{
+		SiteID: uuid.New(),
+		Title:  "Test Page",
+		Slug:   "test-page",
+		Status: domain.PageStatusDraft,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("create page: %v", err)
+	}
+	return page
+}
+
+func createTestSection(t *testing.T, svc service.PageService) *domain.PageSection {
+	t.Helper()
+	page := createTestPage(t, svc)
+	section, err := svc.CreateSection(context.Background(), domain.CreateSectionInput{
+		PageID: page.ID, Name: "Hero", Type: domain.SectionTypeHero, SortOrder: 1,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("create section: %v", err)
+	}
+	return section
 }
 
 func TestPageService_CreatePage_Success(t *testing.T) {
@@ -370,20 +602,87 @@ func TestPageService_PublishPage_Success(t *testing.T) {
 	}
 }
 
-func TestPageService_CreateSection_Success(t *testing.T) {
+func TestPageService_PublishPage_DraftEditsNotPublic(t *testing.T) {
 	repo := newMockPageRepository()
 	svc := createTestPageService(repo)
+	ctx := context.Background()
 
-	pageID := uuid.New()
+	siteID := uuid.New()
+	userID := uuid.New()
+
+	page, _ := svc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: siteID, Title: "Live Page", Slug: "live-page", Status: domain.PageStatusDraft,
+	}, userID)
+	section, _ := svc.CreateSection(ctx, domain.CreateSectionInput{
+		PageID: page.ID, Name: "Hero", Type: domain.SectionTypeHero, SortOrder: 1,
+	}, uuid.New())
+	headline := "Version 1"
+	svc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{
+		Key: "headline", Value: &headline, Type: domain.ContentTypeText,
+	}, uuid.New())
+
+	// Draft pages are not public
+	if _, err := svc.GetPageWithContent(ctx, siteID, "live-page"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before publish, got: %v", err)
+	}
+
+	if _, err := svc.PublishPage(ctx, page.ID, userID); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	// Edit the draft after publishing
+	headline = "Version 2 (half-typed"
+	svc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{
+		Key: "headline", Value: &headline, Type: domain.ContentTypeText,
+	}, uuid.New())
+	newTitle := "Renamed Draft"
+	svc.UpdatePage(ctx, page.ID, domain.UpdatePageInput{Title: &newTitle}, userID)
+
+	public, err := svc.GetPageWithContent(ctx, siteID, "live-page")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if public.Title != "Live Page" {
+		t.Errorf("expected published title 'Live Page', got '%s'", public.Title)
+	}
+	if got := *public.Sections[0].Contents[0].Value; got != "Version 1" {
+		t.Errorf("expected published headline 'Version 1', got '%s'", got)
+	}
+
+	// Publishing again promotes the draft
+	if _, err := svc.PublishPage(ctx, page.ID, userID); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	public, _ = svc.GetPageWithContent(ctx, siteID, "live-page")
+	if got := *public.Sections[0].Contents[0].Value; got != "Version 2 (half-typed" {
+		t.Errorf("expected republished headline, got '%s'", got)
+	}
+
+	// Unpublished pages disappear from the public API
+	if _, err := svc.UnpublishPage(ctx, page.ID, userID); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := svc.GetPageWithContent(ctx, siteID, "live-page"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after unpublish, got: %v", err)
+	}
+}
+
+func TestPageService_CreateSection_Success(t *testing.T) {
+	repo := newMockPageRepository()
+	revisions := newMockRevisionRepository()
+	svc := service.NewPageService(repo, service.NewRevisionService(repo, revisions, zerolog.Nop()), zerolog.Nop())
+
+	page := createTestPage(t, svc)
+	userID := uuid.New()
 	input := domain.CreateSectionInput{
-		PageID:    pageID,
+		PageID:    page.ID,
 		Name:      "Hero Section",
 		Type:      domain.SectionTypeHero,
 		IsVisible: true,
 		SortOrder: 1,
 	}
 
-	section, err := svc.CreateSection(context.Background(), input)
+	section, err := svc.CreateSection(context.Background(), input, userID)
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -393,13 +692,21 @@ func TestPageService_CreateSection_Success(t *testing.T) {
 	if section.Type != domain.SectionTypeHero {
 		t.Errorf("expected type 'hero', got '%s'", section.Type)
 	}
+
+	revision, err := revisions.FindLatest(context.Background(), page.ID)
+	if err != nil {
+		t.Fatalf("expected a revision, got: %v", err)
+	}
+	if revision.CreatedBy == nil || *revision.CreatedBy != userID {
+		t.Errorf("expected revision by %s, got %v", userID, revision.CreatedBy)
+	}
 }
 
 func TestPageService_UpsertContent_CreateNew(t *testing.T) {
 	repo := newMockPageRepository()
 	svc := createTestPageService(repo)
 
-	sectionID := uuid.New()
+	sectionID := createTestSection(t, svc).ID
 	value := "Hello World"
 	input := domain.UpsertContentInput{
 		Key:   "title",
@@ -407,7 +714,7 @@ func TestPageService_UpsertContent_CreateNew(t *testing.T) {
 		Type:  domain.ContentTypeText,
 	}
 
-	content, err := svc.UpsertContent(context.Background(), sectionID, input)
+	content, err := svc.UpsertContent(context.Background(), sectionID, input, uuid.New())
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -423,7 +730,7 @@ func TestPageService_UpsertContent_UpdateExisting(t *testing.T) {
 	repo := newMockPageRepository()
 	svc := createTestPageService(repo)
 
-	sectionID := uuid.New()
+	sectionID := createTestSection(t, svc).ID
 
 	// Create initial content
 	value1 := "Original Value"
@@ -432,7 +739,7 @@ func TestPageService_UpsertContent_UpdateExisting(t *testing.T) {
 		Value: &value1,
 		Type:  domain.ContentTypeText,
 	}
-	svc.UpsertContent(context.Background(), sectionID, input1)
+	svc.UpsertContent(context.Background(), sectionID, input1, uuid.New())
 
 	// Update content
 	value2 := "Updated Value"
@@ -441,7 +748,7 @@ func TestPageService_UpsertContent_UpdateExisting(t *testing.T) {
 		Value: &value2,
 		Type:  domain.ContentTypeText,
 	}
-	content, err := svc.UpsertContent(context.Background(), sectionID, input2)
+	content, err := svc.UpsertContent(context.Background(), sectionID, input2, uuid.New())
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -460,15 +767,15 @@ func TestPageService_ReorderSections(t *testing.T) {
 	repo := newMockPageRepository()
 	svc := createTestPageService(repo)
 
-	pageID := uuid.New()
+	pageID := createTestPage(t, svc).ID
 
 	// Create sections
 	s1, _ := svc.CreateSection(context.Background(), domain.CreateSectionInput{
 		PageID: pageID, Name: "Section 1", Type: domain.SectionTypeHero, SortOrder: 1,
-	})
+	}, uuid.New())
 	s2, _ := svc.CreateSection(context.Background(), domain.CreateSectionInput{
 		PageID: pageID, Name: "Section 2", Type: domain.SectionTypeFeatures, SortOrder: 2,
-	})
+	}, uuid.New())
 
 	// Reorder
 	err := svc.ReorderSections(context.Background(), domain.ReorderSectionsInput{
@@ -476,7 +783,7 @@ func TestPageService_ReorderSections(t *testing.T) {
 			{ID: s1.ID, SortOrder: 2},
 			{ID: s2.ID, SortOrder: 1},
 		},
-	})
+	}, uuid.New())
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
 	}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// RenderService defines the interface for server-side page rendering
+type RenderService interface {
+	// RenderPage renders the published snapshot of a page as a full HTML document.
+	// An empty slug renders the homepage.
+	RenderPage(ctx context.Context, siteID uuid.UUID, slug string) ([]byte, error)
+}
+
+// renderService implements RenderService
+type renderService struct {
+	siteRepo repository.SiteRepository
+	compRepo repository.ComponentRepository
+	pageSvc  PageService
+	renderer *render.Renderer
+	logger   zerolog.Logger
+}
+
+// NewRenderService creates a new RenderService
+func NewRenderService(
+	siteRepo repository.SiteRepository,
+	compRepo repository.ComponentRepository,
+	pageSvc PageService,
+	renderer *render.Renderer,
+	logger zerolog.Logger,
+) RenderService {
+	return &renderService{
+		siteRepo: siteRepo,
+		compRepo: compRepo,
+		pageSvc:  pageSvc,
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// RenderPage renders the published snapshot of a page as a full HTML document
+func (s *renderService) RenderPage(ctx context.Context, siteID uuid.UUID, slug string) ([]byte, error) {
+	data, err := loadSiteRenderData(ctx, s.siteRepo, s.compRepo, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("renderService.RenderPage: %w", err)
+	}
+	if !data.site.IsActive {
+		return nil, fmt.Errorf("renderService.RenderPage: %w", domain.ErrNotFound)
+	}
+
+	page, err := s.pageSvc.GetPageWithContent(ctx, siteID, slug)
+	if err != nil {
+		return nil, fmt.Errorf("renderService.RenderPage: %w", err)
+	}
+	if page.Status != domain.PageStatusPublished {
+		return nil, fmt.Errorf("renderService.RenderPage: %w", domain.ErrNotFound)
+	}
+
+	var buf bytes.Buffer
+	if err := s.renderer.Render(&buf, data.document(page, nil)); err != nil {
+		return nil, fmt.Errorf("renderService.RenderPage: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// siteRenderData holds the site-wide data shared by every page of a site
+type siteRenderData struct {
+	site       *domain.Site
+	settings   domain.SiteSettingsMap
+	components render.Components
+	menus      []*domain.NavigationMenu
+}
+
+// document builds the render document for a page of the site
+func (d *siteRenderData) document(page *domain.Page, link render.LinkFunc) *render.Document {
+	doc := render.NewDocument(d.site, d.settings, page, d.components)
+	doc.SetNavigation(d.menus, link)
+	return doc
+}
+
+// loadSiteRenderData loads a site with its public settings, active components
+// and navigation menus
+func loadSiteRenderData(ctx context.Context, siteRepo repository.SiteRepository, compRepo repository.ComponentRepository, siteID uuid.UUID) (*siteRenderData, error) {
+	site, err := siteRepo.FindByID(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("site: %w", err)
+	}
+
+	settings, err := siteRepo.FindSettingsBySiteID(ctx, siteID, true)
+	if err != nil {
+		return nil, fmt.Errorf("settings: %w", err)
+	}
+	data := &siteRenderData{
+		site:     site,
+		settings: make(domain.SiteSettingsMap, len(settings)),
+	}
+	for _, setting := range settings {
+		if setting.Value != nil {
+			data.settings[setting.Key] = *setting.Value
+		}
+	}
+
+	active := true
+	filter := domain.ComponentFilter{
+		SiteID:     &siteID,
+		IsActive:   &active,
+		Pagination: domain.Pagination{Page: 1, PerPage: 100},
+	}
+	if data.components.Features, _, err = compRepo.FindFeaturesByFilter(ctx, filter); err != nil {
+		return nil, fmt.Errorf("features: %w", err)
+	}
+	if data.components.Testimonials, _, err = compRepo.FindTestimonialsByFilter(ctx, filter); err != nil {
+		return nil, fmt.Errorf("testimonials: %w", err)
+	}
+	if data.components.PricingPlans, _, err = compRepo.FindPricingPlansByFilter(ctx, filter); err != nil {
+		return nil, fmt.Errorf("pricing plans: %w", err)
+	}
+	if data.components.FAQs, _, err = compRepo.FindFAQsByFilter(ctx, filter); err != nil {
+		return nil, fmt.Errorf("faqs: %w", err)
+	}
+
+	if data.menus, err = compRepo.FindMenusBySiteID(ctx, siteID); err != nil {
+		return nil, fmt.Errorf("menus: %w", err)
+	}
+	for _, menu := range data.menus {
+		items, err := compRepo.FindItemsByMenuID(ctx, menu.ID)
+		if err != nil {
+			return nil, fmt.Errorf("menu items: %w", err)
+		}
+		menu.Items = domain.BuildNavigationTree(items)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock component store ─────────────────────────────────────────────────────
+
+// mockComponentRepository only implements the read side of ComponentRepository used for rendering
+type mockComponentRepository struct {
+	repository.ComponentRepository
+	features     []*domain.Feature
+	testimonials []*domain.Testimonial
+	plans        []*domain.PricingPlan
+	faqs         []*domain.FAQ
+	menus        []*domain.NavigationMenu
+	items        []*domain.NavigationItem
+	media        []*domain.Media
+}
+
+func (m *mockComponentRepository) FindFeaturesByFilter(ctx context.Context, filter domain.ComponentFilter) ([]*domain.Feature, int, error) {
+	return m.features, len(m.features), nil
+}
+
+func (m *mockComponentRepository) FindTestimonialsByFilter(ctx context.Context, filter domain.ComponentFilter) ([]*domain.Testimonial, int, error) {
+	return m.testimonials, len(m.testimonials), nil
+}
+
+func (m *mockComponentRepository) FindPricingPlansByFilter(ctx context.Context, filter domain.ComponentFilter) ([]*domain.PricingPlan, int, error) {
+	return m.plans, len(m.plans), nil
+}
+
+func (m *mockComponentRepository) FindFAQsByFilter(ctx context.Context, filter domain.ComponentFilter) ([]*domain.FAQ, int, error) {
+	return m.faqs, len(m.faqs), nil
+}
+
+func (m *mockComponentRepository) FindMenusBySiteID(ctx context.Context, siteID uuid.UUID) ([]*domain.NavigationMenu, error) {
+	return m.menus, nil
+}
+
+func (m *mockComponentRepository) FindItemsByMenuID(ctx context.Context, menuID uuid.UUID) ([]*domain.NavigationItem, error) {
+	var items []*domain.NavigationItem
+	for _, item := range m.items {
+		if item.MenuID == menuID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (m *mockComponentRepository) FindMediaByFilter(ctx context.Context, siteID uuid.UUID, filter domain.Pagination) ([]*domain.Media, int, error) {
+	return m.media, len(m.media), nil
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+type renderTestEnv struct {
+	site     *domain.Site
+	siteRepo *mockSiteRepository
+	pageRepo *mockPageRepository
+	compRepo *mockComponentRepository
+	pageSvc  service.PageService
+}
+
+func setupRenderTest(t *testing.T) *renderTestEnv {
+	t.Helper()
+	siteRepo := newMockSiteRepository()
+	site := &domain.Site{ID: uuid.New(), Name: "Acme", Slug: "acme", IsActive: true}
+	siteRepo.sites[site.ID] = site
+	siteRepo.UpsertSetting(context.Background(), site.ID, "twitter_site", "@acme")
+	siteRepo.settings[site.ID.String()+":twitter_site"].IsPublic = true
+
+	pageRepo := newMockPageRepository()
+	return &renderTestEnv{
+		site:     site,
+		siteRepo: siteRepo,
+		pageRepo: pageRepo,
+		compRepo: &mockComponentRepository{},
+		pageSvc:  createTestPageService(pageRepo),
+	}
+}
+
+func (e *renderTestEnv) renderService(t *testing.T, overridesDir string) service.RenderService {
+	t.Helper()
+	renderer, err := render.New(overridesDir)
+	if err != nil {
+		t.Fatalf("failed to create renderer: %v", err)
+	}
+	return service.NewRenderService(e.siteRepo, e.compRepo, e.pageSvc, renderer, zerolog.Nop())
+}
+
+// createPublishedPage creates a page with the given sections and publishes it
+func (e *renderTestEnv) createPublishedPage(t *testing.T, input domain.CreatePageInput, sections map[domain.SectionType]map[string]string) *domain.Page {
+	t.Helper()
+	ctx := context.Background()
+	input.SiteID = e.site.ID
+	input.Status = domain.PageStatusDraft
+	page, err := e.pageSvc.CreatePage(ctx, input, uuid.New())
+	if err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+
+	order := 0
+	for sectionType, contents := range sections {
+		order++
+		section, err := e.pageSvc.CreateSection(ctx, domain.CreateSectionInput{
+			PageID: page.ID, Name: string(sectionType), Type: sectionType, IsVisible: true, SortOrder: order,
+		}, uuid.New())
+		if err != nil {
+			t.Fatalf("failed to create section: %v", err)
+		}
+		for key, value := range contents {
+			value := value
+			contentType := domain.ContentTypeText
+			if sectionType == domain.SectionTypeHTML {
+				contentType = domain.ContentTypeHTML
+			}
+			if _, err := e.pageSvc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{Key: key, Value: &value, Type: contentType}, uuid.New()); err != nil {
+				t.Fatalf("failed to upsert content: %v", err)
+			}
+		}
+	}
+
+	if _, err := e.pageSvc.PublishPage(ctx, page.ID, uuid.New()); err != nil {
+		t.Fatalf("failed to publish page: %v", err)
+	}
+	return page
+}
+
+func TestRenderService_RenderPage(t *testing.T) {
+	env := setupRenderTest(t)
+	ctx := context.Background()
+
+	seoTitle := "Acme — Rockets"
+	ogImage := "https://cdn.example.com/og.png"
+	customHead := `<link rel="preconnect" href="https://fonts.example.com">`
+	page := env.createPublishedPage(t, domain.CreatePageInput{
+		Title: "Launch", Slug: "launch", SEOTitle: &seoTitle, OGImage: &ogImage,
+	}, map[domain.SectionType]map[string]string{
+		domain.SectionTypeHero:     {"title": "Fly <script>alert(1)</script>", "cta_primary_text": "Go", "cta_primary_link": "javascript:alert(1)"},
+		domain.SectionTypeFeatures: {"title": "Why Acme"},
+		domain.SectionTypeFAQ:      {"title": "Questions"},
+		domain.SectionTypeHTML:     {"body": "<em>trusted</em>"},
+	})
+	if _, err := env.pageSvc.UpdatePage(ctx, page.ID, domain.UpdatePageInput{CustomHead: &customHead}, uuid.New()); err != nil {
+		t.Fatalf("failed to update page: %v", err)
+	}
+	if _, err := env.pageSvc.PublishPage(ctx, page.ID, uuid.New()); err != nil {
+		t.Fatalf("failed to publish page: %v", err)
+	}
+
+	otherSection := uuid.New()
+	env.compRepo.features = []*domain.Feature{
+		{ID: uuid.New(), SiteID: env.site.ID, Title: "Fast launches", IsActive: true},
+		{ID: uuid.New(), SiteID: env.site.ID, SectionID: &otherSection, Title: "Other page feature", IsActive: true},
+	}
+	env.compRepo.faqs = []*domain.FAQ{
+		{ID: uuid.New(), SiteID: env.site.ID, Question: "Is it safe?", Answer: "Mostly.", IsActive: true},
+	}
+
+	body, err := env.renderService(t, "").RenderPage(ctx, env.site.ID, "launch")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	html := string(body)
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Acme — Rockets</title>",
+		`<meta property="og:title" content="Acme — Rockets">`,
+		`<meta property="og:image" content="https://cdn.example.com/og.png">`,
+		`<meta name="twitter:card" content="summary_large_image">`,
+		`<meta name="twitter:site" content="@acme">`,
+		customHead,
+		"Fly &lt;script&gt;alert(1)&lt;/script&gt;",
+		"Fast launches",
+		"<summary>Is it safe?</summary>",
+		"<em>trusted</em>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected rendered HTML to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"<script>alert(1)</script>", `href="javascript:`, "Other page feature"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("expected rendered HTML not to contain %q", unwanted)
+		}
+	}
+}
+
+func TestRenderService_RenderPage_NotPublished(t *testing.T) {
+	env := setupRenderTest(t)
+	ctx := context.Background()
+
+	if _, err := env.pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: env.site.ID, Title: "Draft", Slug: "draft", Status: domain.PageStatusDraft,
+	}, uuid.New()); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+
+	_, err := env.renderService(t, "").RenderPage(ctx, env.site.ID, "draft")
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a draft page, got: %v", err)
+	}
+
+	_, err = env.renderService(t, "").RenderPage(ctx, uuid.New(), "draft")
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown site, got: %v", err)
+	}
+}
+
+func TestRenderService_RenderPage_TemplateOverrides(t *testing.T) {
+	env := setupRenderTest(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	writeTemplate := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTemplate(filepath.Join(dir, "acme", "footer.html"), `{{define "footer"}}<footer>acme footer</footer>{{end}}`)
+	writeTemplate(filepath.Join(dir, "acme", "minimal", "hero.html"), `{{define "section-hero"}}<h1 class="minimal">{{.Text "title"}}</h1>{{end}}`)
+
+	minimal := "minimal"
+	sections := map[domain.SectionType]map[string]string{domain.SectionTypeHero: {"title": "Hello"}}
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Plain", Slug: "plain"}, sections)
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Minimal", Slug: "minimal", Template: &minimal}, sections)
+
+	svc := env.renderService(t, dir)
+
+	tests := []struct {
+		slug    string
+		want    []string
+		notWant []string
+	}{
+		{slug: "plain", want: []string{"acme footer", "<h1>Hello</h1>"}, notWant: []string{`class="minimal"`}},
+		{slug: "minimal", want: []string{"acme footer", `<h1 class="minimal">Hello</h1>`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.slug, func(t *testing.T) {
+			body, err := svc.RenderPage(ctx, env.site.ID, tt.slug)
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("expected rendered HTML to contain %q", want)
+				}
+			}
+			for _, unwanted := range tt.notWant {
+				if strings.Contains(string(body), unwanted) {
+					t.Errorf("expected rendered HTML not to contain %q", unwanted)
+				}
+			}
+		})
+	}
+}
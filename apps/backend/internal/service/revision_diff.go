@@ -0,0 +1,141 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+// Fields that change on every write or hold nested relations are not part of a diff
+var (
+	pageDiffIgnored    = map[string]bool{"id": true, "sections": true, "created_at": true, "updated_at": true, "created_by": true, "updated_by": true, "publish_at": true, "unpublish_at": true}
+	sectionDiffIgnored = map[string]bool{"id": true, "page_id": true, "contents": true, "created_at": true, "updated_at": true}
+	contentDiffIgnored = map[string]bool{"id": true, "section_id": true, "created_at": true, "updated_at": true}
+)
+
+// diffPages computes the structured difference between two page trees
+func diffPages(from, to *domain.Page) *domain.PageRevisionDiff {
+	diff := &domain.PageRevisionDiff{
+		Page:     diffFields(from, to, pageDiffIgnored),
+		Sections: []domain.SectionDiff{},
+	}
+
+	fromSections := make(map[uuid.UUID]*domain.PageSection, len(from.Sections))
+	for _, section := range from.Sections {
+		fromSections[section.ID] = section
+	}
+
+	seen := make(map[uuid.UUID]bool, len(to.Sections))
+	for _, section := range to.Sections {
+		seen[section.ID] = true
+		previous, ok := fromSections[section.ID]
+		if !ok {
+			diff.Sections = append(diff.Sections, domain.SectionDiff{
+				SectionID: section.ID,
+				Name:      section.Name,
+				Change:    domain.DiffAdded,
+				Contents:  diffContents(nil, section.Contents),
+			})
+			continue
+		}
+
+		fields := diffFields(previous, section, sectionDiffIgnored)
+		contents := diffContents(previous.Contents, section.Contents)
+		if len(fields) > 0 || len(contents) > 0 {
+			diff.Sections = append(diff.Sections, domain.SectionDiff{
+				SectionID: section.ID,
+				Name:      section.Name,
+				Change:    domain.DiffModified,
+				Fields:    fields,
+				Contents:  contents,
+			})
+		}
+	}
+
+	for _, section := range from.Sections {
+		if !seen[section.ID] {
+			diff.Sections = append(diff.Sections, domain.SectionDiff{
+				SectionID: section.ID,
+				Name:      section.Name,
+				Change:    domain.DiffRemoved,
+			})
+		}
+	}
+
+	return diff
+}
+
+// diffContents compares two sets of section contents by key
+func diffContents(from, to []*domain.SectionContent) []domain.ContentDiff {
+	fromByKey := make(map[string]*domain.SectionContent, len(from))
+	for _, content := range from {
+		fromByKey[content.Key] = content
+	}
+
+	var diffs []domain.ContentDiff
+	seen := make(map[string]bool, len(to))
+	for _, content := range to {
+		seen[content.Key] = true
+		previous, ok := fromByKey[content.Key]
+		if !ok {
+			diffs = append(diffs, domain.ContentDiff{
+				Key:    content.Key,
+				Change: domain.DiffAdded,
+				Fields: diffFields(&domain.SectionContent{}, content, contentDiffIgnored),
+			})
+			continue
+		}
+		if fields := diffFields(previous, content, contentDiffIgnored); len(fields) > 0 {
+			diffs = append(diffs, domain.ContentDiff{Key: content.Key, Change: domain.DiffModified, Fields: fields})
+		}
+	}
+
+	for _, content := range from {
+		if !seen[content.Key] {
+			diffs = append(diffs, domain.ContentDiff{Key: content.Key, Change: domain.DiffRemoved})
+		}
+	}
+	return diffs
+}
+
+// diffFields compares two values field by field using their JSON representation
+func diffFields(from, to interface{}, ignored map[string]bool) []domain.FieldChange {
+	fromFields := toFieldMap(from)
+	toFields := toFieldMap(to)
+
+	keys := make([]string, 0, len(toFields))
+	for key := range toFields {
+		keys = append(keys, key)
+	}
+	for key := range fromFields {
+		if _, ok := toFields[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []domain.FieldChange
+	for _, key := range keys {
+		if ignored[key] {
+			continue
+		}
+		if !reflect.DeepEqual(fromFields[key], toFields[key]) {
+			changes = append(changes, domain.FieldChange{Field: key, From: fromFields[key], To: toFields[key]})
+		}
+	}
+	return changes
+}
+
+// toFieldMap converts a struct into a generic map keyed by its JSON field names
+func toFieldMap(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(b, &fields)
+	return fields
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// RevisionService defines the interface for page revision history operations
+type RevisionService interface {
+	RecordRevision(ctx context.Context, pageID uuid.UUID, userID uuid.UUID, summary string) (*domain.PageRevision, error)
+	ListRevisions(ctx context.Context, pageID uuid.UUID, pagination domain.Pagination) (*domain.PaginatedResult[*domain.PageRevisionSummary], error)
+	GetRevision(ctx context.Context, pageID uuid.UUID, number int) (*domain.PageRevision, error)
+	DiffRevisions(ctx context.Context, pageID uuid.UUID, from, to int) (*domain.PageRevisionDiff, error)
+	RestoreRevision(ctx context.Context, pageID uuid.UUID, number int, userID uuid.UUID) (*domain.Page, error)
+}
+
+// revisionService implements RevisionService
+type revisionService struct {
+	pageRepo     repository.PageRepository
+	revisionRepo repository.RevisionRepository
+	logger       zerolog.Logger
+}
+
+// NewRevisionService creates a new revisionService
+func NewRevisionService(pageRepo repository.PageRepository, revisionRepo repository.RevisionRepository, logger zerolog.Logger) RevisionService {
+	return &revisionService{
+		pageRepo:     pageRepo,
+		revisionRepo: revisionRepo,
+		logger:       logger,
+	}
+}
+
+// RecordRevision snapshots the current page tree. If nothing changed since the
+// latest revision, the latest revision is returned and no new row is written.
+func (s *revisionService) RecordRevision(ctx context.Context, pageID uuid.UUID, userID uuid.UUID, summary string) (*domain.PageRevision, error) {
+	page, err := s.pageRepo.FindByID(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.RecordRevision find: %w", err)
+	}
+	if err := loadPageTree(ctx, s.pageRepo, page); err != nil {
+		return nil, fmt.Errorf("revisionService.RecordRevision: %w", err)
+	}
+
+	latest, err := s.revisionRepo.FindLatest(ctx, pageID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("revisionService.RecordRevision latest: %w", err)
+	}
+	if latest != nil {
+		previous := domain.Page(latest.Snapshot)
+		if !diffPages(&previous, page).HasChanges() {
+			return latest, nil
+		}
+	}
+
+	revision := &domain.PageRevision{
+		ID:       uuid.New(),
+		PageID:   pageID,
+		Snapshot: domain.PageSnapshot(*page),
+	}
+	if summary != "" {
+		revision.ChangeSummary = &summary
+	}
+	if userID != uuid.Nil {
+		revision.CreatedBy = &userID
+	}
+
+	if err := s.revisionRepo.Create(ctx, revision); err != nil {
+		return nil, fmt.Errorf("revisionService.RecordRevision: %w", err)
+	}
+	return revision, nil
+}
+
+// ListRevisions retrieves the revision history of a page, newest first
+func (s *revisionService) ListRevisions(ctx context.Context, pageID uuid.UUID, pagination domain.Pagination) (*domain.PaginatedResult[*domain.PageRevisionSummary], error) {
+	if _, err := s.pageRepo.FindByID(ctx, pageID); err != nil {
+		return nil, fmt.Errorf("revisionService.ListRevisions find: %w", err)
+	}
+
+	revisions, total, err := s.revisionRepo.FindByPageID(ctx, pageID, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.ListRevisions: %w", err)
+	}
+
+	pagination.Normalize()
+	result := domain.NewPaginatedResult(revisions, total, pagination)
+	return &result, nil
+}
+
+// GetRevision retrieves a single revision including its snapshot
+func (s *revisionService) GetRevision(ctx context.Context, pageID uuid.UUID, number int) (*domain.PageRevision, error) {
+	revision, err := s.revisionRepo.FindByNumber(ctx, pageID, number)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.GetRevision: %w", err)
+	}
+	return revision, nil
+}
+
+// DiffRevisions computes the structured difference between two revisions of a page
+func (s *revisionService) DiffRevisions(ctx context.Context, pageID uuid.UUID, from, to int) (*domain.PageRevisionDiff, error) {
+	fromRevision, err := s.revisionRepo.FindByNumber(ctx, pageID, from)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.DiffRevisions from: %w", err)
+	}
+	toRevision, err := s.revisionRepo.FindByNumber(ctx, pageID, to)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.DiffRevisions to: %w", err)
+	}
+
+	fromPage := domain.Page(fromRevision.Snapshot)
+	toPage := domain.Page(toRevision.Snapshot)
+	diff := diffPages(&fromPage, &toPage)
+	diff.PageID = pageID
+	diff.FromRevision = from
+	diff.ToRevision = to
+	return diff, nil
+}
+
+// RestoreRevision atomically re-applies a revision's snapshot to the live page
+// and records the result as a new revision
+func (s *revisionService) RestoreRevision(ctx context.Context, pageID uuid.UUID, number int, userID uuid.UUID) (*domain.Page, error) {
+	revision, err := s.revisionRepo.FindByNumber(ctx, pageID, number)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.RestoreRevision find: %w", err)
+	}
+
+	page := domain.Page(revision.Snapshot)
+	page.ID = pageID
+	if userID != uuid.Nil {
+		page.UpdatedBy = &userID
+	}
+
+	if err := s.pageRepo.ReplaceTree(ctx, &page); err != nil {
+		return nil, fmt.Errorf("revisionService.RestoreRevision: %w", err)
+	}
+
+	summary := fmt.Sprintf("restored revision %d", number)
+	if _, err := s.RecordRevision(ctx, pageID, userID, summary); err != nil {
+		s.logger.Error().Err(err).Str("page_id", pageID.String()).Msg("failed to record revision")
+	}
+
+	s.logger.Info().
+		Str("page_id", pageID.String()).
+		Int("revision", number).
+		Str("user_id", userID.String()).
+		Msg("page revision restored")
+
+	restored, err := s.pageRepo.FindByID(ctx, pageID)
+	if err != nil {
+		return nil, fmt.Errorf("revisionService.RestoreRevision reload: %w", err)
+	}
+	if err := loadPageTree(ctx, s.pageRepo, restored); err != nil {
+		return nil, fmt.Errorf("revisionService.RestoreRevision: %w", err)
+	}
+	return restored, nil
+}
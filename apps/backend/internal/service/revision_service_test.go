@@ -0,0 +1,230 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock RevisionRepository ──────────────────────────────────────────────────
+
+type mockRevisionRepository struct {
+	revisions map[uuid.UUID][]*domain.PageRevision
+}
+
+func newMockRevisionRepository() *mockRevisionRepository {
+	return &mockRevisionRepository{
+		revisions: make(map[uuid.UUID][]*domain.PageRevision),
+	}
+}
+
+func (m *mockRevisionRepository) FindByPageID(ctx context.Context, pageID uuid.UUID, pagination domain.Pagination) ([]*domain.PageRevisionSummary, int, error) {
+	var summaries []*domain.PageRevisionSummary
+	for _, r := range m.revisions[pageID] {
+		summaries = append(summaries, &domain.PageRevisionSummary{
+			ID:             r.ID,
+			PageID:         r.PageID,
+			RevisionNumber: r.RevisionNumber,
+			ChangeSummary:  r.ChangeSummary,
+			CreatedBy:      r.CreatedBy,
+			CreatedAt:      r.CreatedAt,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].RevisionNumber > summaries[j].RevisionNumber
+	})
+	return summaries, len(summaries), nil
+}
+
+func (m *mockRevisionRepository) FindByNumber(ctx context.Context, pageID uuid.UUID, number int) (*domain.PageRevision, error) {
+	for _, r := range m.revisions[pageID] {
+		if r.RevisionNumber == number {
+			return r, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockRevisionRepository) FindLatest(ctx context.Context, pageID uuid.UUID) (*domain.PageRevision, error) {
+	revisions := m.revisions[pageID]
+	if len(revisions) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return revisions[len(revisions)-1], nil
+}
+
+func (m *mockRevisionRepository) Create(ctx context.Context, revision *domain.PageRevision) error {
+	// Round-trip the snapshot through JSON like the JSONB column does, so later
+	// in-memory mutations of the page tree do not leak into stored revisions.
+	b, err := json.Marshal(domain.Page(revision.Snapshot))
+	if err != nil {
+		return err
+	}
+	var snapshot domain.Page
+	if err := json.Unmarshal(b, &snapshot); err != nil {
+		return err
+	}
+	revision.Snapshot = domain.PageSnapshot(snapshot)
+	revision.RevisionNumber = len(m.revisions[revision.PageID]) + 1
+	revision.CreatedAt = time.Now()
+	m.revisions[revision.PageID] = append(m.revisions[revision.PageID], revision)
+	return nil
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func setupRevisionTest(t *testing.T) (*mockPageRepository, *mockRevisionRepository, service.PageService, service.RevisionService, *domain.Page) {
+	t.Helper()
+	pageRepo := newMockPageRepository()
+	revisionRepo := newMockRevisionRepository()
+	logger := zerolog.Nop()
+	revisionSvc := service.NewRevisionService(pageRepo, revisionRepo, logger)
+	pageSvc := service.NewPageService(pageRepo, revisionSvc, logger)
+
+	page, err := pageSvc.CreatePage(context.Background(), domain.CreatePageInput{
+		SiteID: uuid.New(),
+		Title:  "Landing",
+		Slug:   "landing",
+		Status: domain.PageStatusDraft,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+	return pageRepo, revisionRepo, pageSvc, revisionSvc, page
+}
+
+func TestRevisionService_RecordsRevisionOnChange(t *testing.T) {
+	_, revisionRepo, pageSvc, _, page := setupRevisionTest(t)
+
+	if got := len(revisionRepo.revisions[page.ID]); got != 1 {
+		t.Fatalf("expected 1 revision after create, got %d", got)
+	}
+
+	newTitle := "Landing v2"
+	if _, err := pageSvc.UpdatePage(context.Background(), page.ID, domain.UpdatePageInput{Title: &newTitle}, uuid.New()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if got := len(revisionRepo.revisions[page.ID]); got != 2 {
+		t.Fatalf("expected 2 revisions after update, got %d", got)
+	}
+	latest := revisionRepo.revisions[page.ID][1]
+	if latest.Snapshot.Title != newTitle {
+		t.Errorf("expected snapshot title %q, got %q", newTitle, latest.Snapshot.Title)
+	}
+}
+
+func TestRevisionService_SkipsUnchangedSnapshot(t *testing.T) {
+	_, revisionRepo, _, revisionSvc, page := setupRevisionTest(t)
+
+	revision, err := revisionSvc.RecordRevision(context.Background(), page.ID, uuid.Nil, "no-op")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if revision.RevisionNumber != 1 {
+		t.Errorf("expected existing revision 1 to be returned, got %d", revision.RevisionNumber)
+	}
+	if got := len(revisionRepo.revisions[page.ID]); got != 1 {
+		t.Errorf("expected 1 revision, got %d", got)
+	}
+}
+
+func TestRevisionService_DiffRevisions(t *testing.T) {
+	_, _, pageSvc, revisionSvc, page := setupRevisionTest(t)
+	ctx := context.Background()
+
+	section, err := pageSvc.CreateSection(ctx, domain.CreateSectionInput{
+		PageID: page.ID, Name: "Hero", Type: domain.SectionTypeHero, SortOrder: 1,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("failed to create section: %v", err)
+	}
+	headline := "Hello"
+	if _, err := pageSvc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{
+		Key: "headline", Value: &headline, Type: domain.ContentTypeText,
+	}, uuid.New()); err != nil {
+		t.Fatalf("failed to upsert content: %v", err)
+	}
+	headline = "Hello, world"
+	if _, err := pageSvc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{
+		Key: "headline", Value: &headline, Type: domain.ContentTypeText,
+	}, uuid.New()); err != nil {
+		t.Fatalf("failed to upsert content: %v", err)
+	}
+
+	diff, err := revisionSvc.DiffRevisions(ctx, page.ID, 3, 4)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(diff.Page) != 0 {
+		t.Errorf("expected no page field changes, got %v", diff.Page)
+	}
+	if len(diff.Sections) != 1 || diff.Sections[0].Change != domain.DiffModified {
+		t.Fatalf("expected 1 modified section, got %+v", diff.Sections)
+	}
+	contents := diff.Sections[0].Contents
+	if len(contents) != 1 || contents[0].Key != "headline" || contents[0].Change != domain.DiffModified {
+		t.Fatalf("expected modified headline content, got %+v", contents)
+	}
+
+	diff, err = revisionSvc.DiffRevisions(ctx, page.ID, 1, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(diff.Sections) != 1 || diff.Sections[0].Change != domain.DiffAdded {
+		t.Errorf("expected 1 added section, got %+v", diff.Sections)
+	}
+}
+
+func TestRevisionService_RestoreRevision(t *testing.T) {
+	pageRepo, revisionRepo, pageSvc, revisionSvc, page := setupRevisionTest(t)
+	ctx := context.Background()
+
+	section, err := pageSvc.CreateSection(ctx, domain.CreateSectionInput{
+		PageID: page.ID, Name: "Hero", Type: domain.SectionTypeHero, SortOrder: 1,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("failed to create section: %v", err)
+	}
+	newTitle := "Changed"
+	if _, err := pageSvc.UpdatePage(ctx, page.ID, domain.UpdatePageInput{Title: &newTitle}, uuid.New()); err != nil {
+		t.Fatalf("failed to update page: %v", err)
+	}
+
+	restored, err := revisionSvc.RestoreRevision(ctx, page.ID, 1, uuid.New())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if restored.Title != "Landing" {
+		t.Errorf("expected restored title 'Landing', got %q", restored.Title)
+	}
+	if len(restored.Sections) != 0 {
+		t.Errorf("expected no sections after restore, got %d", len(restored.Sections))
+	}
+	if _, ok := pageRepo.sections[section.ID]; ok {
+		t.Error("expected section added after revision 1 to be removed")
+	}
+
+	revisions := revisionRepo.revisions[page.ID]
+	latest := revisions[len(revisions)-1]
+	if latest.ChangeSummary == nil || *latest.ChangeSummary != "restored revision 1" {
+		t.Errorf("expected restore to be recorded as a new revision, got %+v", latest.ChangeSummary)
+	}
+}
+
+func TestRevisionService_RestoreRevision_NotFound(t *testing.T) {
+	_, _, _, revisionSvc, page := setupRevisionTest(t)
+
+	_, err := revisionSvc.RestoreRevision(context.Background(), page.ID, 42, uuid.New())
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// roleCacheTTL bounds how long another instance keeps serving a changed role
+const roleCacheTTL = 30 * time.Second
+
+// RoleService defines the interface for roles and their permissions
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]*domain.Role, error)
+	GetRole(ctx context.Context, id uuid.UUID) (*domain.Role, error)
+	GetRoleByName(ctx context.Context, name domain.UserRole) (*domain.Role, error)
+	// CreateRole adds a custom role. actorRole must hold every permission it grants.
+	CreateRole(ctx context.Context, input domain.CreateRoleInput, actorRole domain.UserRole) (*domain.Role, error)
+	// UpdateRole changes a role. actorRole must hold every permission it grants.
+	UpdateRole(ctx context.Context, id uuid.UUID, input domain.UpdateRoleInput, actorRole domain.UserRole) (*domain.Role, error)
+	DeleteRole(ctx context.Context, id uuid.UUID) error
+
+	// Permissions returns the permissions of a role; an unknown role has none
+	Permissions(ctx context.Context, role domain.UserRole) (domain.PermissionSet, error)
+	// CheckAssign returns ErrNotFound when the role does not exist and
+	// ErrForbidden when actorRole lacks any of its permissions
+	CheckAssign(ctx context.Context, actorRole, role domain.UserRole) error
+}
+
+// roleService implements RoleService
+type roleService struct {
+	roleRepo repository.RoleRepository
+	cache    *roleCache
+	logger   zerolog.Logger
+}
+
+// NewRoleService creates a new RoleService. Permissions are cached for a
+// short time and dropped when a role changes.
+func NewRoleService(roleRepo repository.RoleRepository, logger zerolog.Logger) RoleService {
+	return &roleService{
+		roleRepo: roleRepo,
+		cache:    newRoleCache(roleCacheTTL),
+		logger:   logger,
+	}
+}
+
+// ListRoles retrieves all roles
+func (s *roleService) ListRoles(ctx context.Context) ([]*domain.Role, error) {
+	roles, err := s.roleRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("roleService.ListRoles: %w", err)
+	}
+	return roles, nil
+}
+
+// GetRole retrieves a role by ID
+func (s *roleService) GetRole(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
+	role, err := s.roleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("roleService.GetRole: %w", err)
+	}
+	return role, nil
+}
+
+// GetRoleByName retrieves a role by name
+func (s *roleService) GetRoleByName(ctx context.Context, name domain.UserRole) (*domain.Role, error) {
+	role, err := s.roleRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("roleService.GetRoleByName: %w", err)
+	}
+	return role, nil
+}
+
+// CreateRole validates and stores a custom role
+func (s *roleService) CreateRole(ctx context.Context, input domain.CreateRoleInput, actorRole domain.UserRole) (*domain.Role, error) {
+	var problems []string
+	name := strings.TrimSpace(input.Name)
+	if !domain.IsValidRoleName(name) {
+		problems = append(problems, "name: must be 2-50 lowercase letters, digits or underscores, starting with a letter")
+	} else if _, err := s.roleRepo.FindByName(ctx, domain.UserRole(name)); err == nil {
+		problems = append(problems, "name: a role with this name already exists")
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("roleService.CreateRole: %w", err)
+	}
+	permissions, permissionProblems := normalizePermissions(input.Permissions)
+	problems = append(problems, permissionProblems...)
+	if len(problems) > 0 {
+		return nil, &domain.RoleError{Problems: problems}
+	}
+
+	if err := s.checkGrant(ctx, actorRole, permissions); err != nil {
+		return nil, fmt.Errorf("roleService.CreateRole: %w", err)
+	}
+
+	role := &domain.Role{
+		ID:          uuid.New(),
+		Name:        domain.UserRole(name),
+		Description: strings.TrimSpace(input.Description),
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, fmt.Errorf("roleService.CreateRole: %w", err)
+	}
+
+	s.logger.Info().Str("role", name).Strs("permissions", permissions).Msg("role created")
+	return role, nil
+}
+
+// UpdateRole changes the description or permissions of a role. The
+// permissions of super_admin cannot be changed.
+func (s *roleService) UpdateRole(ctx context.Context, id uuid.UUID, input domain.UpdateRoleInput, actorRole domain.UserRole) (*domain.Role, error) {
+	role, err := s.roleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("roleService.UpdateRole: %w", err)
+	}
+
+	if input.Description != nil {
+		role.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Permissions != nil {
+		if role.Name == domain.RoleSuperAdmin {
+			return nil, &domain.RoleError{Problems: []string{"permissions: super_admin always has every permission"}}
+		}
+		permissions, problems := normalizePermissions(input.Permissions)
+		if len(problems) > 0 {
+			return nil, &domain.RoleError{Problems: problems}
+		}
+		if err := s.checkGrant(ctx, actorRole, permissions); err != nil {
+			return nil, fmt.Errorf("roleService.UpdateRole: %w", err)
+		}
+		role.Permissions = permissions
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, fmt.Errorf("roleService.UpdateRole: %w", err)
+	}
+	s.cache.clear()
+
+	s.logger.Info().Str("role", string(role.Name)).Strs("permissions", role.Permissions).Msg("role updated")
+	return role, nil
+}
+
+// DeleteRole removes a custom role that no user has
+func (s *roleService) DeleteRole(ctx context.Context, id uuid.UUID) error {
+	role, err := s.roleRepo.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("roleService.DeleteRole: %w", err)
+	}
+	if role.IsBuiltIn {
+		return &domain.RoleError{Problems: []string{"built-in roles cannot be deleted"}}
+	}
+
+	if err := s.roleRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("roleService.DeleteRole: %w", err)
+	}
+	s.cache.clear()
+
+	s.logger.Info().Str("role", string(role.Name)).Msg("role deleted")
+	return nil
+}
+
+// Permissions returns the cached permissions of a role
+func (s *roleService) Permissions(ctx context.Context, name domain.UserRole) (domain.PermissionSet, error) {
+	if set, ok := s.cache.get(name); ok {
+		return set, nil
+	}
+
+	var set domain.PermissionSet
+	role, err := s.roleRepo.FindByName(ctx, name)
+	switch {
+	case err == nil:
+		set = role.PermissionSet()
+	case errors.Is(err, domain.ErrNotFound):
+		set = domain.PermissionSet{}
+	default:
+		return nil, fmt.Errorf("roleService.Permissions: %w", err)
+	}
+
+	s.cache.set(name, set)
+	return set, nil
+}
+
+// CheckAssign checks that actorRole may give a user role
+func (s *roleService) CheckAssign(ctx context.Context, actorRole, role domain.UserRole) error {
+	target, err := s.roleRepo.FindByName(ctx, role)
+	if err != nil {
+		return fmt.Errorf("roleService.CheckAssign: %w", err)
+	}
+	actor, err := s.Permissions(ctx, actorRole)
+	if err != nil {
+		return fmt.Errorf("roleService.CheckAssign: %w", err)
+	}
+	if !actor.Covers(target.PermissionSet()) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// checkGrant refuses permissions the actor does not hold, so role.manage
+// cannot be used to gain more access
+func (s *roleService) checkGrant(ctx context.Context, actorRole domain.UserRole, permissions []string) error {
+	actor, err := s.Permissions(ctx, actorRole)
+	if err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if !actor.Has(domain.Permission(p)) {
+			return domain.ErrForbidden
+		}
+	}
+	return nil
+}
+
+// normalizePermissions trims and de-duplicates permissions and reports
+// unknown ones
+func normalizePermissions(input []string) ([]string, []string) {
+	var problems []string
+	seen := make(map[string]bool, len(input))
+	permissions := make([]string, 0, len(input))
+	for _, raw := range input {
+		p := strings.ToLower(strings.TrimSpace(raw))
+		if !domain.Permission(p).IsValid() {
+			problems = append(problems, fmt.Sprintf("permissions: unknown permission %q", raw))
+			continue
+		}
+		if !seen[p] {
+			seen[p] = true
+			permissions = append(permissions, p)
+		}
+	}
+	return permissions, problems
+}
+
+// roleCache caches the permissions of each role
+type roleCache struct {
+	mu      sync.RWMutex
+	entries map[domain.UserRole]roleCacheEntry
+	ttl     time.Duration
+}
+
+// roleCacheEntry is the cached permissions of a role
+type roleCacheEntry struct {
+	permissions domain.PermissionSet
+	expiresAt   time.Time
+}
+
+// newRoleCache creates a new roleCache
+func newRoleCache(ttl time.Duration) *roleCache {
+	return &roleCache{
+		entries: make(map[domain.UserRole]roleCacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// get returns the cached permissions and whether a live entry exists
+func (c *roleCache) get(role domain.UserRole) (domain.PermissionSet, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[role]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.permissions, true
+}
+
+// set stores the permissions of a role
+func (c *roleCache) set(role domain.UserRole, permissions domain.PermissionSet) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[role] = roleCacheEntry{permissions: permissions, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// clear drops every cached role
+func (c *roleCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[domain.UserRole]roleCacheEntry)
+}
@@ -0,0 +1,245 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock RoleRepository ──────────────────────────────────────────────────────
+
+type mockRoleRepository struct {
+	roles   map[uuid.UUID]*domain.Role
+	inUse   map[domain.UserRole]bool
+	lookups int
+}
+
+// newMockRoleRepository creates a repository holding the built-in roles
+func newMockRoleRepository() *mockRoleRepository {
+	m := &mockRoleRepository{
+		roles: make(map[uuid.UUID]*domain.Role),
+		inUse: make(map[domain.UserRole]bool),
+	}
+	for name, permissions := range domain.BuiltInRolePermissions() {
+		role := &domain.Role{ID: uuid.New(), Name: name, IsBuiltIn: true}
+		for _, p := range permissions {
+			role.Permissions = append(role.Permissions, string(p))
+		}
+		m.roles[role.ID] = role
+	}
+	return m
+}
+
+func (m *mockRoleRepository) FindAll(ctx context.Context) ([]*domain.Role, error) {
+	var roles []*domain.Role
+	for _, role := range m.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (m *mockRoleRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Role, error) {
+	if role, ok := m.roles[id]; ok {
+		return role, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockRoleRepository) FindByName(ctx context.Context, name domain.UserRole) (*domain.Role, error) {
+	m.lookups++
+	for _, role := range m.roles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockRoleRepository) Create(ctx context.Context, role *domain.Role) error {
+	m.roles[role.ID] = role
+	return nil
+}
+
+func (m *mockRoleRepository) Update(ctx context.Context, role *domain.Role) error {
+	if _, ok := m.roles[role.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	m.roles[role.ID] = role
+	return nil
+}
+
+func (m *mockRoleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	role, ok := m.roles[id]
+	if !ok || role.IsBuiltIn {
+		return domain.ErrNotFound
+	}
+	if m.inUse[role.Name] {
+		return domain.ErrRoleInUse
+	}
+	delete(m.roles, id)
+	return nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func newTestRoleService() (service.RoleService, *mockRoleRepository) {
+	repo := newMockRoleRepository()
+	return service.NewRoleService(repo, zerolog.Nop()), repo
+}
+
+func roleByName(t *testing.T, repo *mockRoleRepository, name domain.UserRole) *domain.Role {
+	t.Helper()
+	role, err := repo.FindByName(context.Background(), name)
+	if err != nil {
+		t.Fatalf("role %q not found", name)
+	}
+	return role
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestRoleService_BuiltInPermissions(t *testing.T) {
+	svc, _ := newTestRoleService()
+	ctx := context.Background()
+
+	editor, _ := svc.Permissions(ctx, domain.RoleEditor)
+	if !editor.Has(domain.PermissionPagePublish) || editor.Has(domain.PermissionPageDelete) || editor.Has(domain.PermissionSiteAll) {
+		t.Errorf("expected editors to publish but not delete pages or reach every site, got %v", editor)
+	}
+	admin, _ := svc.Permissions(ctx, domain.RoleAdmin)
+	if !admin.Covers(editor) || !admin.Has(domain.PermissionUserManage) || admin.Has(domain.PermissionUserDelete) {
+		t.Errorf("expected admins to have editor permissions and manage but not delete users, got %v", admin)
+	}
+	superAdmin, _ := svc.Permissions(ctx, domain.RoleSuperAdmin)
+	if !superAdmin.Covers(domain.AllPermissions()) {
+		t.Error("expected super admins to have every permission")
+	}
+	unknown, err := svc.Permissions(ctx, "guest")
+	if err != nil || len(unknown) != 0 {
+		t.Errorf("expected no permissions for an unknown role, got %v %v", unknown, err)
+	}
+}
+
+func TestRoleService_CreateCustomRole(t *testing.T) {
+	svc, _ := newTestRoleService()
+	ctx := context.Background()
+
+	role, err := svc.CreateRole(ctx, domain.CreateRoleInput{
+		Name:        "publisher",
+		Permissions: []string{"content.view", " Page.Publish ", "content.view"},
+	}, domain.RoleAdmin)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(role.Permissions) != 2 || role.IsBuiltIn {
+		t.Errorf("expected two permissions on a custom role, got %v", role.Permissions)
+	}
+
+	permissions, _ := svc.Permissions(ctx, "publisher")
+	if !permissions.Has(domain.PermissionPagePublish) || permissions.Has(domain.PermissionPageEdit) {
+		t.Errorf("expected publisher to publish only, got %v", permissions)
+	}
+
+	_, err = svc.CreateRole(ctx, domain.CreateRoleInput{
+		Name:        "Publisher!",
+		Permissions: []string{"page.fly"},
+	}, domain.RoleAdmin)
+	var roleErr *domain.RoleError
+	if !errors.As(err, &roleErr) || len(roleErr.Problems) != 2 {
+		t.Errorf("expected name and permission problems, got %v", err)
+	}
+
+	if _, err := svc.CreateRole(ctx, domain.CreateRoleInput{Name: "publisher", Permissions: []string{}}, domain.RoleAdmin); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected a duplicate name to be rejected, got %v", err)
+	}
+}
+
+func TestRoleService_CannotGrantPermissionsNotHeld(t *testing.T) {
+	svc, repo := newTestRoleService()
+	ctx := context.Background()
+
+	_, err := svc.CreateRole(ctx, domain.CreateRoleInput{
+		Name:        "deleter",
+		Permissions: []string{"user.delete"},
+	}, domain.RoleAdmin)
+	if !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected ErrForbidden granting user.delete as admin, got %v", err)
+	}
+
+	editor := roleByName(t, repo, domain.RoleEditor)
+	_, err = svc.UpdateRole(ctx, editor.ID, domain.UpdateRoleInput{Permissions: []string{"role.manage"}}, domain.RoleAdmin)
+	if !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected ErrForbidden granting role.manage as admin, got %v", err)
+	}
+
+	if err := svc.CheckAssign(ctx, domain.RoleAdmin, domain.RoleSuperAdmin); !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected admins not to assign super_admin, got %v", err)
+	}
+	if err := svc.CheckAssign(ctx, domain.RoleAdmin, domain.RoleEditor); err != nil {
+		t.Errorf("expected admins to assign editor, got %v", err)
+	}
+	if err := svc.CheckAssign(ctx, domain.RoleAdmin, "guest"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown role, got %v", err)
+	}
+}
+
+func TestRoleService_UpdateRoleDropsCachedPermissions(t *testing.T) {
+	svc, repo := newTestRoleService()
+	ctx := context.Background()
+
+	before, _ := svc.Permissions(ctx, domain.RoleEditor)
+	if !before.Has(domain.PermissionPagePublish) {
+		t.Fatal("expected editors to publish by default")
+	}
+	lookups := repo.lookups
+	svc.Permissions(ctx, domain.RoleEditor)
+	if repo.lookups != lookups {
+		t.Error("expected permissions to be cached")
+	}
+
+	editor := roleByName(t, repo, domain.RoleEditor)
+	_, err := svc.UpdateRole(ctx, editor.ID, domain.UpdateRoleInput{
+		Permissions: []string{"content.view", "page.edit"},
+	}, domain.RoleSuperAdmin)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	after, _ := svc.Permissions(ctx, domain.RoleEditor)
+	if after.Has(domain.PermissionPagePublish) {
+		t.Error("expected the change to apply immediately")
+	}
+
+	superAdmin := roleByName(t, repo, domain.RoleSuperAdmin)
+	_, err = svc.UpdateRole(ctx, superAdmin.ID, domain.UpdateRoleInput{Permissions: []string{}}, domain.RoleSuperAdmin)
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected super_admin permissions to be fixed, got %v", err)
+	}
+}
+
+func TestRoleService_DeleteRole(t *testing.T) {
+	svc, repo := newTestRoleService()
+	ctx := context.Background()
+
+	if err := svc.DeleteRole(ctx, roleByName(t, repo, domain.RoleEditor).ID); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected built-in roles to be kept, got %v", err)
+	}
+
+	role, err := svc.CreateRole(ctx, domain.CreateRoleInput{Name: "reviewer", Permissions: []string{"content.view"}}, domain.RoleSuperAdmin)
+	if err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	repo.inUse[role.Name] = true
+	if err := svc.DeleteRole(ctx, role.ID); !errors.Is(err, domain.ErrRoleInUse) {
+		t.Errorf("expected ErrRoleInUse, got %v", err)
+	}
+	repo.inUse[role.Name] = false
+	if err := svc.DeleteRole(ctx, role.ID); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
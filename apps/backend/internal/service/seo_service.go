@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// seoCacheTTL bounds how long generated files are reused. Publishing through
+// this instance invalidates them immediately; other replicas pick up changes
+// once the TTL expires.
+const seoCacheTTL = 10 * time.Minute
+
+// Site settings that shape robots.txt
+const (
+	// settingRobotsAllowIndexing set to "false" closes the whole site to crawlers
+	settingRobotsAllowIndexing = "robots_allow_indexing"
+	// settingRobotsDisallow lists disallowed paths, one per line or comma-separated
+	settingRobotsDisallow = "robots_disallow"
+	// settingRobotsExtra is appended to robots.txt verbatim
+	settingRobotsExtra = "robots_extra"
+)
+
+// SEOService defines the interface for the crawler-facing files of a site
+type SEOService interface {
+	// Sitemap returns the sitemap of a site's published, indexable pages. Part 0
+	// is /sitemap.xml; once a site has more than render.SitemapMaxURLs pages it
+	// is a sitemap index pointing at parts 1..n.
+	Sitemap(ctx context.Context, siteID uuid.UUID, part int) ([]byte, error)
+	// Robots returns the robots.txt of a site, assembled from its settings
+	Robots(ctx context.Context, siteID uuid.UUID) ([]byte, error)
+	// InvalidateSite drops the cached files of a site
+	InvalidateSite(siteID uuid.UUID)
+}
+
+// seoService implements SEOService
+type seoService struct {
+	siteRepo repository.SiteRepository
+	pageRepo repository.PageRepository
+	cache    *seoCache
+	logger   zerolog.Logger
+}
+
+// NewSEOService creates a new SEOService
+func NewSEOService(siteRepo repository.SiteRepository, pageRepo repository.PageRepository, logger zerolog.Logger) SEOService {
+	return &seoService{
+		siteRepo: siteRepo,
+		pageRepo: pageRepo,
+		cache:    newSEOCache(seoCacheTTL),
+		logger:   logger,
+	}
+}
+
+// Sitemap returns part of the sitemap of a site
+func (s *seoService) Sitemap(ctx context.Context, siteID uuid.UUID, part int) ([]byte, error) {
+	parts, ok := s.cache.get(siteID, "sitemap")
+	if !ok {
+		var err error
+		if parts, err = s.buildSitemap(ctx, siteID); err != nil {
+			return nil, fmt.Errorf("seoService.Sitemap: %w", err)
+		}
+		s.cache.set(siteID, "sitemap", parts)
+	}
+
+	if part < 0 || part >= len(parts) {
+		return nil, fmt.Errorf("seoService.Sitemap: %w", domain.ErrNotFound)
+	}
+	return parts[part], nil
+}
+
+// Robots returns the robots.txt of a site
+func (s *seoService) Robots(ctx context.Context, siteID uuid.UUID) ([]byte, error) {
+	if files, ok := s.cache.get(siteID, "robots"); ok {
+		return files[0], nil
+	}
+
+	site, err := s.activeSite(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("seoService.Robots: %w", err)
+	}
+	settings, err := s.siteRepo.FindSettingsBySiteID(ctx, siteID, false)
+	if err != nil {
+		return nil, fmt.Errorf("seoService.Robots settings: %w", err)
+	}
+	values := make(domain.SiteSettingsMap, len(settings))
+	for _, setting := range settings {
+		if setting.Value != nil {
+			values[setting.Key] = *setting.Value
+		}
+	}
+
+	var rules render.RobotsRules
+	if baseURL, err := exportBaseURL(site, nil); err == nil {
+		rules.SitemapURL = baseURL + "/sitemap.xml"
+	}
+	if strings.EqualFold(strings.TrimSpace(values[settingRobotsAllowIndexing]), "false") {
+		rules.Disallow = []string{"/"}
+	} else {
+		rules.Disallow = robotsPaths(values[settingRobotsDisallow])
+	}
+	rules.Extra = values[settingRobotsExtra]
+
+	robots := render.Robots(rules)
+	s.cache.set(siteID, "robots", [][]byte{robots})
+	return robots, nil
+}
+
+// InvalidateSite drops the cached files of a site
+func (s *seoService) InvalidateSite(siteID uuid.UUID) {
+	s.cache.clear(siteID)
+}
+
+// buildSitemap generates every part of a site's sitemap
+func (s *seoService) buildSitemap(ctx context.Context, siteID uuid.UUID) ([][]byte, error) {
+	site, err := s.activeSite(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+	// Sitemap locations must be absolute, so a site without a domain has none
+	baseURL, err := exportBaseURL(site, nil)
+	if err != nil {
+		return nil, domain.ErrNotFound
+	}
+
+	pages, err := s.pageRepo.FindSitemapPages(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+	urls := sitemapURLs(baseURL, pages)
+
+	if len(urls) <= render.SitemapMaxURLs {
+		sitemap, err := render.Sitemap(urls)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{sitemap}, nil
+	}
+
+	parts := [][]byte{nil}
+	var index []render.SitemapURL
+	for start := 0; start < len(urls); start += render.SitemapMaxURLs {
+		chunk := urls[start:min(start+render.SitemapMaxURLs, len(urls))]
+		sitemap, err := render.Sitemap(chunk)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, sitemap)
+		index = append(index, render.SitemapURL{
+			Loc:     fmt.Sprintf("%s/sitemap.xml?page=%d", baseURL, len(parts)-1),
+			LastMod: latestLastMod(chunk),
+		})
+	}
+	if parts[0], err = render.SitemapIndex(index); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug().
+		Str("site_id", siteID.String()).
+		Int("urls", len(urls)).
+		Int("sitemaps", len(index)).
+		Msg("sitemap split into index")
+
+	return parts, nil
+}
+
+// activeSite loads a site, treating inactive sites as missing
+func (s *seoService) activeSite(ctx context.Context, siteID uuid.UUID) (*domain.Site, error) {
+	site, err := s.siteRepo.FindByID(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+	if !site.IsActive {
+		return nil, domain.ErrNotFound
+	}
+	return site, nil
+}
+
+// sitemapURLs returns the sitemap entries of the indexable pages, sorted by
+// location. A canonical URL on the site's own host replaces the page's URL;
+// pages canonicalised to another host are left out as duplicates.
+func sitemapURLs(baseURL string, pages []*domain.Page) []render.SitemapURL {
+	base, _ := url.Parse(baseURL)
+
+	seen := make(map[string]bool, len(pages))
+	urls := make([]render.SitemapURL, 0, len(pages))
+	for _, page := range pages {
+		if isNoIndex(page) {
+			continue
+		}
+
+		loc := baseURL + "/" + strings.Trim(page.Slug, "/")
+		if page.IsHomepage {
+			loc = baseURL + "/"
+		}
+		if page.CanonicalURL != nil && strings.TrimSpace(*page.CanonicalURL) != "" {
+			canonical := strings.TrimSpace(*page.CanonicalURL)
+			u, err := url.Parse(canonical)
+			if err != nil || !strings.EqualFold(u.Host, base.Host) {
+				continue
+			}
+			loc = canonical
+		}
+		if seen[loc] {
+			continue
+		}
+		seen[loc] = true
+
+		lastMod := page.UpdatedAt
+		urls = append(urls, render.SitemapURL{Loc: loc, LastMod: &lastMod})
+	}
+
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Loc < urls[j].Loc })
+	return urls
+}
+
+// latestLastMod returns the most recent modification time of a set of URLs
+func latestLastMod(urls []render.SitemapURL) *time.Time {
+	var latest *time.Time
+	for _, u := range urls {
+		if u.LastMod != nil && (latest == nil || u.LastMod.After(*latest)) {
+			latest = u.LastMod
+		}
+	}
+	return latest
+}
+
+// robotsPaths parses the disallowed paths setting
+func robotsPaths(value string) []string {
+	var paths []string
+	for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == ',' }) {
+		path := strings.TrimSpace(field)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// seoCache caches generated files per site and file name
+type seoCache struct {
+	mu      sync.RWMutex
+	entries map[uuid.UUID]map[string]seoCacheEntry
+	ttl     time.Duration
+}
+
+// seoCacheEntry is a cached file, or the parts of a split sitemap
+type seoCacheEntry struct {
+	files     [][]byte
+	expiresAt time.Time
+}
+
+// newSEOCache creates a new seoCache
+func newSEOCache(ttl time.Duration) *seoCache {
+	return &seoCache{
+		entries: make(map[uuid.UUID]map[string]seoCacheEntry),
+		ttl:     ttl,
+	}
+}
+
+// get returns the cached files and whether a live entry exists
+func (c *seoCache) get(siteID uuid.UUID, name string) ([][]byte, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[siteID][name]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.files, true
+}
+
+// set stores the files for a site
+func (c *seoCache) set(siteID uuid.UUID, name string, files [][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries[siteID] == nil {
+		c.entries[siteID] = make(map[string]seoCacheEntry)
+	}
+	c.entries[siteID][name] = seoCacheEntry{files: files, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// clear drops the cached files of a site
+func (c *seoCache) clear(siteID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, siteID)
+}
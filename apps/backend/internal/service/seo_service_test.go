@@ -0,0 +1,223 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/render"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// setupSEOTest wires page and site services that invalidate the SEO cache,
+// like main does
+func setupSEOTest(t *testing.T) (*renderTestEnv, service.SEOService, service.SiteService) {
+	t.Helper()
+	env := setupRenderTest(t)
+	siteDomain := "example.com"
+	env.site.Domain = &siteDomain
+
+	logger := zerolog.Nop()
+	seoSvc := service.NewSEOService(env.siteRepo, env.pageRepo, logger)
+	revisionSvc := service.NewRevisionService(env.pageRepo, newMockRevisionRepository(), logger)
+	env.pageSvc = service.NewPageService(env.pageRepo, revisionSvc, logger, seoSvc.InvalidateSite)
+	siteSvc := service.NewSiteService(env.siteRepo, logger, seoSvc.InvalidateSite)
+	return env, seoSvc, siteSvc
+}
+
+func TestSEOService_Sitemap(t *testing.T) {
+	env, svc, _ := setupSEOTest(t)
+	ctx := context.Background()
+
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Home", Slug: "home", IsHomepage: true}, nil)
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "About", Slug: "about"}, nil)
+	noindex := "noindex"
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Thanks", Slug: "thanks", RobotsMeta: &noindex}, nil)
+	sameHost := "https://example.com/pricing-plans"
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Pricing", Slug: "pricing", CanonicalURL: &sameHost}, nil)
+	otherHost := "https://blog.other.com/post"
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Post", Slug: "post", CanonicalURL: &otherHost}, nil)
+	if _, err := env.pageSvc.CreatePage(ctx, domain.CreatePageInput{
+		SiteID: env.site.ID, Title: "Draft", Slug: "draft", Status: domain.PageStatusDraft,
+	}, uuid.New()); err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+
+	body, err := svc.Sitemap(ctx, env.site.ID, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	sitemap := string(body)
+
+	for _, want := range []string{
+		"<urlset",
+		"<loc>https://example.com/</loc>",
+		"<loc>https://example.com/about</loc>",
+		"<loc>https://example.com/pricing-plans</loc>",
+		"<lastmod>",
+	} {
+		if !strings.Contains(sitemap, want) {
+			t.Errorf("expected sitemap to contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"thanks", "draft", "other.com", "/pricing<"} {
+		if strings.Contains(sitemap, unwanted) {
+			t.Errorf("expected sitemap not to contain %q", unwanted)
+		}
+	}
+
+	if _, err := svc.Sitemap(ctx, env.site.ID, 1); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing part, got: %v", err)
+	}
+}
+
+func TestSEOService_Sitemap_InvalidatedOnPublish(t *testing.T) {
+	env, svc, _ := setupSEOTest(t)
+	ctx := context.Background()
+
+	about := env.createPublishedPage(t, domain.CreatePageInput{Title: "About", Slug: "about"}, nil)
+	sitemap := func() string {
+		body, err := svc.Sitemap(ctx, env.site.ID, 0)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return string(body)
+	}
+	if !strings.Contains(sitemap(), "/about<") {
+		t.Fatal("expected the published page in the sitemap")
+	}
+
+	// Changes that bypass the page service are not seen until invalidation
+	delete(env.pageRepo.published, about.ID)
+	if !strings.Contains(sitemap(), "/about<") {
+		t.Fatal("expected the cached sitemap to be served")
+	}
+
+	env.createPublishedPage(t, domain.CreatePageInput{Title: "Pricing", Slug: "pricing"}, nil)
+	got := sitemap()
+	if !strings.Contains(got, "/pricing<") || strings.Contains(got, "/about<") {
+		t.Fatalf("expected publishing to rebuild the sitemap, got:\n%s", got)
+	}
+
+	pricing, _ := env.pageRepo.FindBySlug(ctx, env.site.ID, "pricing")
+	if _, err := env.pageSvc.UnpublishPage(ctx, pricing.ID, uuid.New()); err != nil {
+		t.Fatalf("failed to unpublish page: %v", err)
+	}
+	if strings.Contains(sitemap(), "/pricing<") {
+		t.Error("expected unpublishing to remove the page from the sitemap")
+	}
+}
+
+func TestSEOService_Sitemap_Index(t *testing.T) {
+	env, svc, _ := setupSEOTest(t)
+	ctx := context.Background()
+
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	total := render.SitemapMaxURLs + 1
+	for i := 0; i < total; i++ {
+		page := &domain.Page{
+			ID: uuid.New(), SiteID: env.site.ID, Slug: fmt.Sprintf("page-%06d", i),
+			Status: domain.PageStatusPublished, UpdatedAt: updated,
+		}
+		env.pageRepo.pages[page.ID] = page
+		published := *page
+		env.pageRepo.published[page.ID] = &published
+	}
+
+	index, err := svc.Sitemap(ctx, env.site.ID, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	for _, want := range []string{
+		"<sitemapindex",
+		"<loc>https://example.com/sitemap.xml?page=1</loc>",
+		"<loc>https://example.com/sitemap.xml?page=2</loc>",
+		"<lastmod>2024-05-01T12:00:00Z</lastmod>",
+	} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("expected sitemap index to contain %q", want)
+		}
+	}
+
+	first, err := svc.Sitemap(ctx, env.site.ID, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	second, err := svc.Sitemap(ctx, env.site.ID, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if n := strings.Count(string(first), "<url>"); n != render.SitemapMaxURLs {
+		t.Errorf("expected %d URLs in the first sitemap, got %d", render.SitemapMaxURLs, n)
+	}
+	if n := strings.Count(string(second), "<url>"); n != 1 {
+		t.Errorf("expected 1 URL in the second sitemap, got %d", n)
+	}
+	if _, err := svc.Sitemap(ctx, env.site.ID, 3); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound past the last part, got: %v", err)
+	}
+}
+
+func TestSEOService_Sitemap_Unavailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(site *domain.Site)
+	}{
+		{name: "no domain", modify: func(site *domain.Site) { site.Domain = nil }},
+		{name: "inactive site", modify: func(site *domain.Site) { site.IsActive = false }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, svc, _ := setupSEOTest(t)
+			tt.modify(env.site)
+			if _, err := svc.Sitemap(context.Background(), env.site.ID, 0); !errors.Is(err, domain.ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestSEOService_Robots(t *testing.T) {
+	env, svc, siteSvc := setupSEOTest(t)
+	ctx := context.Background()
+
+	robots := func() string {
+		body, err := svc.Robots(ctx, env.site.ID)
+		if err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+		return string(body)
+	}
+
+	want := "User-agent: *\nAllow: /\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got := robots(); got != want {
+		t.Fatalf("expected default robots.txt %q, got %q", want, got)
+	}
+
+	err := siteSvc.BulkUpdateSettings(ctx, env.site.ID, domain.BulkUpdateSettingsInput{Settings: map[string]string{
+		"robots_disallow": "/admin\nprivate, /tmp/",
+		"robots_extra":    "User-agent: GPTBot\nDisallow: /",
+	}})
+	if err != nil {
+		t.Fatalf("failed to update settings: %v", err)
+	}
+	want = "User-agent: *\nDisallow: /admin\nDisallow: /private\nDisallow: /tmp/\n\n" +
+		"User-agent: GPTBot\nDisallow: /\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got := robots(); got != want {
+		t.Fatalf("expected settings to rebuild robots.txt as %q, got %q", want, got)
+	}
+
+	disallowAll := "false"
+	if err := siteSvc.UpdateSetting(ctx, env.site.ID, "robots_allow_indexing", domain.UpdateSettingInput{Value: &disallowAll}); err != nil {
+		t.Fatalf("failed to update setting: %v", err)
+	}
+	if got := robots(); !strings.HasPrefix(got, "User-agent: *\nDisallow: /\n") || strings.Contains(got, "/admin") {
+		t.Errorf("expected indexing to be disallowed site-wide, got %q", got)
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// SessionJanitor periodically deletes expired refresh tokens so the table only
+// holds sessions that can still be used or shown. Deleting is idempotent, so
+// several API replicas may run one.
+type SessionJanitor struct {
+	userRepo repository.UserRepository
+	interval time.Duration
+	logger   zerolog.Logger
+}
+
+// NewSessionJanitor creates a new SessionJanitor
+func NewSessionJanitor(userRepo repository.UserRepository, interval time.Duration, logger zerolog.Logger) *SessionJanitor {
+	return &SessionJanitor{
+		userRepo: userRepo,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Start runs the janitor until ctx is cancelled, cleaning up once at start
+func (j *SessionJanitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.logger.Info().Dur("interval", j.interval).Msg("session janitor started")
+	for {
+		if _, err := j.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			j.logger.Error().Err(err).Msg("session janitor run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			j.logger.Info().Msg("session janitor stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce deletes expired refresh tokens and returns how many were removed
+func (j *SessionJanitor) RunOnce(ctx context.Context) (int64, error) {
+	deleted, err := j.userRepo.DeleteExpiredRefreshTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("SessionJanitor.RunOnce: %w", err)
+	}
+	if deleted > 0 {
+		j.logger.Info().Int64("deleted", deleted).Msg("expired refresh tokens deleted")
+	}
+	return deleted, nil
+}
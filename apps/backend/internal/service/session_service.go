@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/useragent"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// SessionService defines the interface for listing and revoking sessions.
+// A session is a refresh token family, identified by its family ID. Revoking
+// one stops it from being refreshed; access tokens already issued stay valid
+// until they expire.
+type SessionService interface {
+	// ListSessions returns the active sessions of a user. currentToken is the
+	// refresh token of the caller, if any, and marks their own session.
+	ListSessions(ctx context.Context, userID uuid.UUID, currentToken string) ([]*domain.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	// RevokeOtherSessions signs a user out everywhere but currentToken and
+	// returns how many sessions were revoked
+	RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentToken string) (int64, error)
+	// RevokeAllSessions signs a user out everywhere
+	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
+}
+
+// sessionService implements SessionService
+type sessionService struct {
+	userRepo repository.UserRepository
+	logger   zerolog.Logger
+}
+
+// NewSessionService creates a new SessionService
+func NewSessionService(userRepo repository.UserRepository, logger zerolog.Logger) SessionService {
+	return &sessionService{
+		userRepo: userRepo,
+		logger:   logger,
+	}
+}
+
+// ListSessions retrieves the active sessions of a user with parsed device info
+func (s *sessionService) ListSessions(ctx context.Context, userID uuid.UUID, currentToken string) ([]*domain.Session, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("sessionService.ListSessions: %w", err)
+	}
+
+	tokens, err := s.userRepo.FindActiveRefreshTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("sessionService.ListSessions: %w", err)
+	}
+
+	currentHash := ""
+	if currentToken != "" {
+		currentHash = auth.HashToken(currentToken)
+	}
+
+	sessions := make([]*domain.Session, 0, len(tokens))
+	for _, token := range tokens {
+		ua := ""
+		if token.UserAgent != nil {
+			ua = *token.UserAgent
+		}
+		info := useragent.Parse(ua)
+		sessions = append(sessions, &domain.Session{
+			ID:              token.FamilyID,
+			IPAddress:       token.IPAddress,
+			UserAgent:       token.UserAgent,
+			Browser:         info.Browser,
+			OS:              info.OS,
+			Device:          info.Device,
+			LastRefreshedAt: token.CreatedAt,
+			ExpiresAt:       token.ExpiresAt,
+			IsCurrent:       currentHash != "" && token.TokenHash == currentHash,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one session of a user
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.userRepo.RevokeUserRefreshTokenFamily(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("sessionService.RevokeSession: %w", err)
+	}
+	s.logger.Info().
+		Str("user_id", userID.String()).
+		Str("session_id", sessionID.String()).
+		Msg("session revoked")
+	return nil
+}
+
+// RevokeOtherSessions revokes every session of a user except the current one.
+// Without a current token all sessions are revoked.
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentToken string) (int64, error) {
+	keepHash := ""
+	if currentToken != "" {
+		keepHash = auth.HashToken(currentToken)
+	}
+	revoked, err := s.userRepo.RevokeOtherUserRefreshTokens(ctx, userID, keepHash)
+	if err != nil {
+		return 0, fmt.Errorf("sessionService.RevokeOtherSessions: %w", err)
+	}
+	s.logger.Info().
+		Str("user_id", userID.String()).
+		Int64("revoked", revoked).
+		Msg("other sessions revoked")
+	return revoked, nil
+}
+
+// RevokeAllSessions revokes every session of a user
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return fmt.Errorf("sessionService.RevokeAllSessions: %w", err)
+	}
+	if err := s.userRepo.RevokeAllUserRefreshTokens(ctx, userID); err != nil {
+		return fmt.Errorf("sessionService.RevokeAllSessions: %w", err)
+	}
+	s.logger.Info().Str("user_id", userID.String()).Msg("all sessions revoked")
+	return nil
+}
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+const (
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	mobileUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"
+)
+
+// setupSessionTest signs a user in from a desktop and a phone and returns the
+// desktop refresh token
+func setupSessionTest(t *testing.T) (*mockUserRepository, service.SessionService, *domain.User, string) {
+	t.Helper()
+	repo := newMockUserRepository()
+	user := createTestUser("admin@test.com", "password123", domain.RoleAdmin)
+	repo.users[user.Email] = user
+	authSvc := createTestAuthService(repo)
+
+	input := domain.LoginInput{Email: "admin@test.com", Password: "password123"}
+	_, desktop, err := authSvc.Login(context.Background(), input, "10.0.0.1", desktopUA)
+	if err != nil {
+		t.Fatalf("desktop login failed: %v", err)
+	}
+	if _, _, err := authSvc.Login(context.Background(), input, "10.0.0.2", mobileUA); err != nil {
+		t.Fatalf("mobile login failed: %v", err)
+	}
+
+	return repo, service.NewSessionService(repo, zerolog.Nop()), user, desktop.RefreshToken
+}
+
+func TestSessionService_ListSessions(t *testing.T) {
+	_, svc, user, current := setupSessionTest(t)
+
+	sessions, err := svc.ListSessions(context.Background(), user.ID, current)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	currentCount := 0
+	for _, s := range sessions {
+		if s.IsCurrent {
+			currentCount++
+			if s.Browser != "Chrome" || s.OS != "Windows" || s.Device != "desktop" {
+				t.Errorf("unexpected device info for current session: %+v", s)
+			}
+		} else if s.OS != "iOS" || s.Device != "mobile" {
+			t.Errorf("unexpected device info for phone session: %+v", s)
+		}
+	}
+	if currentCount != 1 {
+		t.Errorf("expected exactly one current session, got %d", currentCount)
+	}
+
+	if _, err := svc.ListSessions(context.Background(), uuid.New(), ""); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown user, got %v", err)
+	}
+}
+
+func TestSessionService_RevokeSession(t *testing.T) {
+	_, svc, user, current := setupSessionTest(t)
+	ctx := context.Background()
+
+	sessions, _ := svc.ListSessions(ctx, user.ID, current)
+	var other *domain.Session
+	for _, s := range sessions {
+		if !s.IsCurrent {
+			other = s
+		}
+	}
+
+	if err := svc.RevokeSession(ctx, uuid.New(), other.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound revoking another user's session, got %v", err)
+	}
+	if err := svc.RevokeSession(ctx, user.ID, other.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := svc.RevokeSession(ctx, user.ID, other.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound revoking twice, got %v", err)
+	}
+
+	sessions, _ = svc.ListSessions(ctx, user.ID, current)
+	if len(sessions) != 1 || !sessions[0].IsCurrent {
+		t.Errorf("expected only the current session to remain, got %+v", sessions)
+	}
+}
+
+func TestSessionService_RevokeOtherSessions(t *testing.T) {
+	_, svc, user, current := setupSessionTest(t)
+	ctx := context.Background()
+
+	revoked, err := svc.RevokeOtherSessions(ctx, user.ID, current)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if revoked != 1 {
+		t.Errorf("expected 1 session revoked, got %d", revoked)
+	}
+
+	sessions, _ := svc.ListSessions(ctx, user.ID, current)
+	if len(sessions) != 1 || !sessions[0].IsCurrent {
+		t.Errorf("expected the current session to survive, got %+v", sessions)
+	}
+
+	if err := svc.RevokeAllSessions(ctx, user.ID); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	sessions, _ = svc.ListSessions(ctx, user.ID, current)
+	if len(sessions) != 0 {
+		t.Errorf("expected no sessions after force logout, got %d", len(sessions))
+	}
+}
+
+func TestSessionJanitor_RunOnce(t *testing.T) {
+	repo, _, user, _ := setupSessionTest(t)
+	repo.refreshTokens["expired"] = &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: "expired",
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}
+
+	janitor := service.NewSessionJanitor(repo, time.Hour, zerolog.Nop())
+	deleted, err := janitor.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected 1 expired token deleted, got %d", deleted)
+	}
+	if _, ok := repo.refreshTokens["expired"]; ok {
+		t.Error("expected expired token to be gone")
+	}
+	if len(repo.refreshTokens) != 2 {
+		t.Errorf("expected active tokens to be kept, got %d", len(repo.refreshTokens))
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+)
+
+const (
+	// siteHostCacheTTL bounds how long a host → site mapping is reused. Changes made
+	// through this instance invalidate the cache immediately; other replicas pick
+	// them up once the TTL expires.
+	siteHostCacheTTL = time.Minute
+	// siteHostCacheMaxEntries caps memory used by lookups for unknown hosts
+	siteHostCacheMaxEntries = 10000
+)
+
+// hostnamePattern matches a lowercase DNS hostname
+var hostnamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// siteHostEntry is a cached host lookup; a nil site records that no site matched
+type siteHostEntry struct {
+	site      *domain.Site
+	expiresAt time.Time
+}
+
+// siteHostCache caches host → site resolutions, including misses
+type siteHostCache struct {
+	mu      sync.RWMutex
+	entries map[string]siteHostEntry
+	ttl     time.Duration
+}
+
+// newSiteHostCache creates a new siteHostCache
+func newSiteHostCache(ttl time.Duration) *siteHostCache {
+	return &siteHostCache{
+		entries: make(map[string]siteHostEntry),
+		ttl:     ttl,
+	}
+}
+
+// get returns the cached site for host and whether a live entry exists
+func (c *siteHostCache) get(host string) (*domain.Site, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[host]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.site, true
+}
+
+// set stores the resolution for host
+func (c *siteHostCache) set(host string, site *domain.Site) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Simple bound: start over rather than track recency
+	if len(c.entries) >= siteHostCacheMaxEntries {
+		c.entries = make(map[string]siteHostEntry)
+	}
+	c.entries[host] = siteHostEntry{site: site, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// clear drops all cached resolutions
+func (c *siteHostCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]siteHostEntry)
+}
+
+// normalizeHost lowercases a Host header value and strips the port and trailing dot
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	return strings.TrimSuffix(host, ".")
+}
+
+// hostCandidates returns the domains to try for a host: the host itself, then
+// its www/non-www counterpart
+func hostCandidates(host string) []string {
+	if bare, ok := strings.CutPrefix(host, "www."); ok {
+		return []string{host, bare}
+	}
+	return []string{host, "www." + host}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// SiteMemberService defines the interface for per-site roles. Users whose
+// role has site.all act on every site with the permissions of their role;
+// others act only on the sites they are members of, with the permissions of
+// their role and of their membership role. Memberships are looked up on every
+// request, so a change applies without a new token.
+type SiteMemberService interface {
+	// Permissions returns the permissions of a role on every site
+	Permissions(ctx context.Context, role domain.UserRole) (domain.PermissionSet, error)
+	// SitePermissions returns the permissions a user has on a site. ok is
+	// false when the user cannot access the site.
+	SitePermissions(ctx context.Context, userID uuid.UUID, role domain.UserRole, siteID uuid.UUID) (permissions domain.PermissionSet, ok bool, err error)
+	// AccessibleSiteIDs returns the sites a user can access, or nil for all
+	AccessibleSiteIDs(ctx context.Context, userID uuid.UUID, role domain.UserRole) ([]uuid.UUID, error)
+	// ResourceSiteID returns the site a page, section, component or media item belongs to
+	ResourceSiteID(ctx context.Context, resource domain.SiteResource, id uuid.UUID) (uuid.UUID, error)
+	// MediaSiteIDsByKey returns the sites of the media items a stored file
+	// belongs to. Image variants count as files of the item they were made from.
+	MediaSiteIDsByKey(ctx context.Context, key string) ([]uuid.UUID, error)
+
+	ListMembers(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteMembership, error)
+	SetMember(ctx context.Context, siteID, userID uuid.UUID, input domain.SetSiteMemberInput, actorID uuid.UUID) (*domain.SiteMembership, error)
+	RemoveMember(ctx context.Context, siteID, userID uuid.UUID) error
+}
+
+// siteMemberService implements SiteMemberService
+type siteMemberService struct {
+	memberRepo  repository.SiteMembershipRepository
+	userRepo    repository.UserRepository
+	siteRepo    repository.SiteRepository
+	roleService RoleService
+	logger      zerolog.Logger
+}
+
+// NewSiteMemberService creates a new SiteMemberService
+func NewSiteMemberService(
+	memberRepo repository.SiteMembershipRepository,
+	userRepo repository.UserRepository,
+	siteRepo repository.SiteRepository,
+	roleService RoleService,
+	logger zerolog.Logger,
+) SiteMemberService {
+	return &siteMemberService{
+		memberRepo:  memberRepo,
+		userRepo:    userRepo,
+		siteRepo:    siteRepo,
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// Permissions returns the permissions of a role
+func (s *siteMemberService) Permissions(ctx context.Context, role domain.UserRole) (domain.PermissionSet, error) {
+	permissions, err := s.roleService.Permissions(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.Permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+// SitePermissions resolves the permissions of a user on a site
+func (s *siteMemberService) SitePermissions(ctx context.Context, userID uuid.UUID, role domain.UserRole, siteID uuid.UUID) (domain.PermissionSet, bool, error) {
+	global, err := s.roleService.Permissions(ctx, role)
+	if err != nil {
+		return nil, false, fmt.Errorf("siteMemberService.SitePermissions: %w", err)
+	}
+	if global.Has(domain.PermissionSiteAll) {
+		return global, true, nil
+	}
+
+	membership, err := s.memberRepo.Find(ctx, siteID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("siteMemberService.SitePermissions: %w", err)
+	}
+	site, err := s.roleService.Permissions(ctx, membership.Role)
+	if err != nil {
+		return nil, false, fmt.Errorf("siteMemberService.SitePermissions: %w", err)
+	}
+	return global.Union(site), true, nil
+}
+
+// AccessibleSiteIDs lists the sites of a user's memberships
+func (s *siteMemberService) AccessibleSiteIDs(ctx context.Context, userID uuid.UUID, role domain.UserRole) ([]uuid.UUID, error) {
+	global, err := s.roleService.Permissions(ctx, role)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.AccessibleSiteIDs: %w", err)
+	}
+	if global.Has(domain.PermissionSiteAll) {
+		return nil, nil
+	}
+
+	siteIDs, err := s.memberRepo.FindSiteIDsByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.AccessibleSiteIDs: %w", err)
+	}
+	if siteIDs == nil {
+		siteIDs = []uuid.UUID{}
+	}
+	return siteIDs, nil
+}
+
+// ResourceSiteID looks up the site of a record
+func (s *siteMemberService) ResourceSiteID(ctx context.Context, resource domain.SiteResource, id uuid.UUID) (uuid.UUID, error) {
+	siteID, err := s.memberRepo.FindResourceSiteID(ctx, resource, id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("siteMemberService.ResourceSiteID: %w", err)
+	}
+	return siteID, nil
+}
+
+// MediaSiteIDsByKey looks up the sites of a stored file
+func (s *siteMemberService) MediaSiteIDsByKey(ctx context.Context, key string) ([]uuid.UUID, error) {
+	siteIDs, err := s.memberRepo.FindMediaSiteIDsByKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.MediaSiteIDsByKey: %w", err)
+	}
+	return siteIDs, nil
+}
+
+// ListMembers retrieves the members of a site
+func (s *siteMemberService) ListMembers(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteMembership, error) {
+	if _, err := s.siteRepo.FindByID(ctx, siteID); err != nil {
+		return nil, fmt.Errorf("siteMemberService.ListMembers: %w", err)
+	}
+
+	members, err := s.memberRepo.FindBySite(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.ListMembers: %w", err)
+	}
+	return members, nil
+}
+
+// SetMember gives a user a role on a site. Users whose role has site.all
+// already reach every site, so they cannot be made members.
+func (s *siteMemberService) SetMember(ctx context.Context, siteID, userID uuid.UUID, input domain.SetSiteMemberInput, actorID uuid.UUID) (*domain.SiteMembership, error) {
+	if input.Role != domain.RoleAdmin && input.Role != domain.RoleEditor {
+		return nil, fmt.Errorf("siteMemberService.SetMember: %w: role must be admin or editor", domain.ErrValidation)
+	}
+	if _, err := s.siteRepo.FindByID(ctx, siteID); err != nil {
+		return nil, fmt.Errorf("siteMemberService.SetMember site: %w", err)
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.SetMember user: %w", err)
+	}
+	global, err := s.roleService.Permissions(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("siteMemberService.SetMember: %w", err)
+	}
+	if global.Has(domain.PermissionSiteAll) {
+		return nil, fmt.Errorf("siteMemberService.SetMember: %w: %s users already have access to every site", domain.ErrValidation, user.Role)
+	}
+
+	membership := &domain.SiteMembership{
+		ID:        uuid.New(),
+		SiteID:    siteID,
+		UserID:    userID,
+		Role:      input.Role,
+		CreatedBy: &actorID,
+	}
+	if err := s.memberRepo.Upsert(ctx, membership); err != nil {
+		return nil, fmt.Errorf("siteMemberService.SetMember: %w", err)
+	}
+	membership.Email = user.Email
+	membership.FullName = user.FullName
+
+	s.logger.Info().
+		Str("site_id", siteID.String()).
+		Str("user_id", userID.String()).
+		Str("role", string(input.Role)).
+		Msg("site member set")
+	return membership, nil
+}
+
+// RemoveMember takes a user's access to a site away
+func (s *siteMemberService) RemoveMember(ctx context.Context, siteID, userID uuid.UUID) error {
+	if err := s.memberRepo.Delete(ctx, siteID, userID); err != nil {
+		return fmt.Errorf("siteMemberService.RemoveMember: %w", err)
+	}
+	s.logger.Info().
+		Str("site_id", siteID.String()).
+		Str("user_id", userID.String()).
+		Msg("site member removed")
+	return nil
+}
@@ -0,0 +1,227 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock SiteMembershipRepository ────────────────────────────────────────────
+
+type mockSiteMembershipRepository struct {
+	memberships map[string]*domain.SiteMembership // key: siteID+":"+userID
+	resources   map[uuid.UUID]uuid.UUID           // record ID -> site ID
+}
+
+func newMockSiteMembershipRepository() *mockSiteMembershipRepository {
+	return &mockSiteMembershipRepository{
+		memberships: make(map[string]*domain.SiteMembership),
+		resources:   make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+func membershipKey(siteID, userID uuid.UUID) string {
+	return siteID.String() + ":" + userID.String()
+}
+
+func (m *mockSiteMembershipRepository) Find(ctx context.Context, siteID, userID uuid.UUID) (*domain.SiteMembership, error) {
+	if membership, ok := m.memberships[membershipKey(siteID, userID)]; ok {
+		return membership, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockSiteMembershipRepository) FindBySite(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteMembership, error) {
+	var members []*domain.SiteMembership
+	for _, membership := range m.memberships {
+		if membership.SiteID == siteID {
+			members = append(members, membership)
+		}
+	}
+	return members, nil
+}
+
+func (m *mockSiteMembershipRepository) FindSiteIDsByUser(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var siteIDs []uuid.UUID
+	for _, membership := range m.memberships {
+		if membership.UserID == userID {
+			siteIDs = append(siteIDs, membership.SiteID)
+		}
+	}
+	return siteIDs, nil
+}
+
+func (m *mockSiteMembershipRepository) Upsert(ctx context.Context, membership *domain.SiteMembership) error {
+	key := membershipKey(membership.SiteID, membership.UserID)
+	if existing, ok := m.memberships[key]; ok {
+		existing.Role = membership.Role
+		membership.ID = existing.ID
+		return nil
+	}
+	m.memberships[key] = membership
+	return nil
+}
+
+func (m *mockSiteMembershipRepository) Delete(ctx context.Context, siteID, userID uuid.UUID) error {
+	key := membershipKey(siteID, userID)
+	if _, ok := m.memberships[key]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(m.memberships, key)
+	return nil
+}
+
+func (m *mockSiteMembershipRepository) FindResourceSiteID(ctx context.Context, resource domain.SiteResource, id uuid.UUID) (uuid.UUID, error) {
+	if siteID, ok := m.resources[id]; ok {
+		return siteID, nil
+	}
+	return uuid.Nil, domain.ErrNotFound
+}
+
+func (m *mockSiteMembershipRepository) FindMediaSiteIDsByKey(ctx context.Context, key string) ([]uuid.UUID, error) {
+	return []uuid.UUID{}, nil
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func setupSiteMemberTest() (service.SiteMemberService, *mockSiteMembershipRepository, *mockUserRepository, *domain.Site) {
+	members := newMockSiteMembershipRepository()
+	users := newMockUserRepository()
+	sites := newMockSiteRepository()
+	site := &domain.Site{ID: uuid.New(), Name: "Acme", Slug: "acme"}
+	sites.sites[site.ID] = site
+	roles, _ := newTestRoleService()
+	svc := service.NewSiteMemberService(members, users, sites, roles, zerolog.Nop())
+	return svc, members, users, site
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func TestSiteMemberService_SitePermissions(t *testing.T) {
+	svc, _, users, site := setupSiteMemberTest()
+	ctx := context.Background()
+	admin := createTestUser("admin@test.com", "password123", domain.RoleAdmin)
+	editor := createTestUser("editor@test.com", "password123", domain.RoleEditor)
+	users.users[admin.Email] = admin
+	users.users[editor.Email] = editor
+
+	permissions, ok, err := svc.SitePermissions(ctx, admin.ID, admin.Role, site.ID)
+	if err != nil || !ok || !permissions.Has(domain.PermissionSiteManage) {
+		t.Errorf("expected admin to manage every site, got %v %v %v", permissions, ok, err)
+	}
+
+	if _, ok, err := svc.SitePermissions(ctx, editor.ID, editor.Role, site.ID); err != nil || ok {
+		t.Errorf("expected editor without membership to be refused, got %v %v", ok, err)
+	}
+
+	if _, err := svc.SetMember(ctx, site.ID, editor.ID, domain.SetSiteMemberInput{Role: domain.RoleAdmin}, admin.ID); err != nil {
+		t.Fatalf("failed to set member: %v", err)
+	}
+	permissions, ok, err = svc.SitePermissions(ctx, editor.ID, editor.Role, site.ID)
+	if err != nil || !ok || !permissions.Has(domain.PermissionSiteManage) || !permissions.Has(domain.PermissionPageDelete) {
+		t.Errorf("expected editor to get the membership role's permissions, got %v %v %v", permissions, ok, err)
+	}
+
+	if err := svc.RemoveMember(ctx, site.ID, editor.ID); err != nil {
+		t.Fatalf("failed to remove member: %v", err)
+	}
+	if _, ok, _ := svc.SitePermissions(ctx, editor.ID, editor.Role, site.ID); ok {
+		t.Error("expected access to end with the membership")
+	}
+	if err := svc.RemoveMember(ctx, site.ID, editor.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound removing twice, got %v", err)
+	}
+}
+
+func TestSiteMemberService_CustomRoleAddsToMembership(t *testing.T) {
+	members := newMockSiteMembershipRepository()
+	users := newMockUserRepository()
+	sites := newMockSiteRepository()
+	site := &domain.Site{ID: uuid.New(), Name: "Acme", Slug: "acme"}
+	sites.sites[site.ID] = site
+	roles, roleRepo := newTestRoleService()
+	svc := service.NewSiteMemberService(members, users, sites, roles, zerolog.Nop())
+	ctx := context.Background()
+
+	// Editors lose publishing; a publisher role brings it back
+	editor := roleByName(t, roleRepo, domain.RoleEditor)
+	if _, err := roles.UpdateRole(ctx, editor.ID, domain.UpdateRoleInput{Permissions: []string{"content.view", "page.edit"}}, domain.RoleSuperAdmin); err != nil {
+		t.Fatalf("failed to update editor: %v", err)
+	}
+	if _, err := roles.CreateRole(ctx, domain.CreateRoleInput{Name: "publisher", Permissions: []string{"page.publish"}}, domain.RoleSuperAdmin); err != nil {
+		t.Fatalf("failed to create publisher: %v", err)
+	}
+
+	publisher := createTestUser("publisher@test.com", "password123", "publisher")
+	writer := createTestUser("writer@test.com", "password123", domain.RoleEditor)
+	users.users[publisher.Email] = publisher
+	users.users[writer.Email] = writer
+	for _, user := range []*domain.User{publisher, writer} {
+		if _, err := svc.SetMember(ctx, site.ID, user.ID, domain.SetSiteMemberInput{Role: domain.RoleEditor}, uuid.New()); err != nil {
+			t.Fatalf("failed to set member: %v", err)
+		}
+	}
+
+	permissions, _, _ := svc.SitePermissions(ctx, publisher.ID, publisher.Role, site.ID)
+	if !permissions.Has(domain.PermissionPagePublish) || !permissions.Has(domain.PermissionPageEdit) {
+		t.Errorf("expected publisher to edit and publish, got %v", permissions)
+	}
+	permissions, _, _ = svc.SitePermissions(ctx, writer.ID, writer.Role, site.ID)
+	if permissions.Has(domain.PermissionPagePublish) {
+		t.Errorf("expected editor not to publish, got %v", permissions)
+	}
+}
+
+func TestSiteMemberService_AccessibleSiteIDs(t *testing.T) {
+	svc, members, _, site := setupSiteMemberTest()
+	ctx := context.Background()
+
+	siteIDs, err := svc.AccessibleSiteIDs(ctx, uuid.New(), domain.RoleSuperAdmin)
+	if err != nil || siteIDs != nil {
+		t.Errorf("expected nil (every site) for a super admin, got %v %v", siteIDs, err)
+	}
+
+	editorID := uuid.New()
+	siteIDs, err = svc.AccessibleSiteIDs(ctx, editorID, domain.RoleEditor)
+	if err != nil || siteIDs == nil || len(siteIDs) != 0 {
+		t.Errorf("expected an empty, non-nil list for an editor without sites, got %v %v", siteIDs, err)
+	}
+
+	members.memberships[membershipKey(site.ID, editorID)] = &domain.SiteMembership{
+		ID: uuid.New(), SiteID: site.ID, UserID: editorID, Role: domain.RoleEditor,
+	}
+	siteIDs, err = svc.AccessibleSiteIDs(ctx, editorID, domain.RoleEditor)
+	if err != nil || len(siteIDs) != 1 || siteIDs[0] != site.ID {
+		t.Errorf("expected the member site, got %v %v", siteIDs, err)
+	}
+}
+
+func TestSiteMemberService_SetMemberValidation(t *testing.T) {
+	svc, members, users, site := setupSiteMemberTest()
+	ctx := context.Background()
+	admin := createTestUser("admin@test.com", "password123", domain.RoleAdmin)
+	editor := createTestUser("editor@test.com", "password123", domain.RoleEditor)
+	users.users[admin.Email] = admin
+	users.users[editor.Email] = editor
+
+	if _, err := svc.SetMember(ctx, site.ID, admin.ID, domain.SetSiteMemberInput{Role: domain.RoleEditor}, admin.ID); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected ErrValidation for an admin, got %v", err)
+	}
+	if _, err := svc.SetMember(ctx, site.ID, editor.ID, domain.SetSiteMemberInput{Role: domain.RoleSuperAdmin}, admin.ID); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected ErrValidation for super_admin role, got %v", err)
+	}
+	if _, err := svc.SetMember(ctx, site.ID, uuid.New(), domain.SetSiteMemberInput{Role: domain.RoleEditor}, admin.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown user, got %v", err)
+	}
+	if _, err := svc.SetMember(ctx, uuid.New(), editor.ID, domain.SetSiteMemberInput{Role: domain.RoleEditor}, admin.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown site, got %v", err)
+	}
+	if len(members.memberships) != 0 {
+		t.Errorf("expected no memberships to be stored, got %d", len(members.memberships))
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -15,12 +17,18 @@ type SiteService interface {
 	GetSite(ctx context.Context, id uuid.UUID) (*domain.Site, error)
 	GetSiteBySlug(ctx context.Context, slug string) (*domain.Site, error)
 	GetSiteByDomain(ctx context.Context, domain string) (*domain.Site, error)
+	ResolveSiteByHost(ctx context.Context, host string) (*domain.Site, error)
 	GetSiteWithSettings(ctx context.Context, id uuid.UUID, publicOnly bool) (*domain.Site, error)
 	ListSites(ctx context.Context, filter domain.SiteFilter) (*domain.PaginatedResult[*domain.Site], error)
 	CreateSite(ctx context.Context, input domain.CreateSiteInput, userID uuid.UUID) (*domain.Site, error)
 	UpdateSite(ctx context.Context, id uuid.UUID, input domain.UpdateSiteInput) (*domain.Site, error)
 	DeleteSite(ctx context.Context, id uuid.UUID) error
 
+	// Domains
+	ListDomains(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteDomain, error)
+	AddDomain(ctx context.Context, siteID uuid.UUID, input domain.AddSiteDomainInput) (*domain.SiteDomain, error)
+	RemoveDomain(ctx context.Context, siteID, domainID uuid.UUID) error
+
 	// Settings
 	GetSettings(ctx context.Context, siteID uuid.UUID, publicOnly bool) ([]*domain.SiteSetting, error)
 	GetSettingsMap(ctx context.Context, siteID uuid.UUID, publicOnly bool) (domain.SiteSettingsMap, error)
@@ -28,17 +36,26 @@ type SiteService interface {
 	BulkUpdateSettings(ctx context.Context, siteID uuid.UUID, input domain.BulkUpdateSettingsInput) error
 }
 
+// SiteChangeListener is called with the ID of a site whose public output
+// changed, e.g. to drop cached files
+type SiteChangeListener func(siteID uuid.UUID)
+
 // siteService implements SiteService
 type siteService struct {
-	siteRepo repository.SiteRepository
-	logger   zerolog.Logger
+	siteRepo  repository.SiteRepository
+	hostCache *siteHostCache
+	listeners []SiteChangeListener
+	logger    zerolog.Logger
 }
 
-// NewSiteService creates a new siteService
-func NewSiteService(siteRepo repository.SiteRepository, logger zerolog.Logger) SiteService {
+// NewSiteService creates a new siteService. Listeners are told whenever a
+// site, its domains or its settings change.
+func NewSiteService(siteRepo repository.SiteRepository, logger zerolog.Logger, listeners ...SiteChangeListener) SiteService {
 	return &siteService{
-		siteRepo: siteRepo,
-		logger:   logger,
+		siteRepo:  siteRepo,
+		hostCache: newSiteHostCache(siteHostCacheTTL),
+		listeners: listeners,
+		logger:    logger,
 	}
 }
 
@@ -69,6 +86,37 @@ func (s *siteService) GetSiteByDomain(ctx context.Context, domainName string) (*
 	return site, nil
 }
 
+// ResolveSiteByHost maps a request Host header to an active site. The host is
+// matched against primary domains and aliases, trying its www/non-www counterpart
+// as well. Results, including misses, are cached.
+func (s *siteService) ResolveSiteByHost(ctx context.Context, host string) (*domain.Site, error) {
+	host = normalizeHost(host)
+	if host == "" {
+		return nil, fmt.Errorf("siteService.ResolveSiteByHost: %w", domain.ErrNotFound)
+	}
+
+	if site, ok := s.hostCache.get(host); ok {
+		if site == nil {
+			return nil, fmt.Errorf("siteService.ResolveSiteByHost: %w", domain.ErrNotFound)
+		}
+		return site, nil
+	}
+
+	for _, candidate := range hostCandidates(host) {
+		site, err := s.siteRepo.FindByDomain(ctx, candidate)
+		if err == nil {
+			s.hostCache.set(host, site)
+			return site, nil
+		}
+		if !errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("siteService.ResolveSiteByHost: %w", err)
+		}
+	}
+
+	s.hostCache.set(host, nil)
+	return nil, fmt.Errorf("siteService.ResolveSiteByHost: %w", domain.ErrNotFound)
+}
+
 // GetSiteWithSettings retrieves a site with its settings
 func (s *siteService) GetSiteWithSettings(ctx context.Context, id uuid.UUID, publicOnly bool) (*domain.Site, error) {
 	site, err := s.siteRepo.FindByID(ctx, id)
@@ -92,6 +140,7 @@ func (s *siteService) ListSites(ctx context.Context, filter domain.SiteFilter) (
 		return nil, fmt.Errorf("siteService.ListSites: %w", err)
 	}
 
+	filter.Normalize()
 	result := domain.NewPaginatedResult(sites, total, filter.Pagination)
 	return &result, nil
 }
@@ -154,6 +203,8 @@ func (s *siteService) UpdateSite(ctx context.Context, id uuid.UUID, input domain
 	if err := s.siteRepo.Update(ctx, site); err != nil {
 		return nil, fmt.Errorf("siteService.UpdateSite: %w", err)
 	}
+	s.hostCache.clear()
+	s.notifySiteChanged(id)
 
 	return site, nil
 }
@@ -163,6 +214,60 @@ func (s *siteService) DeleteSite(ctx context.Context, id uuid.UUID) error {
 	if err := s.siteRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("siteService.DeleteSite: %w", err)
 	}
+	s.hostCache.clear()
+	s.notifySiteChanged(id)
+	return nil
+}
+
+// ListDomains retrieves the domain aliases of a site
+func (s *siteService) ListDomains(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteDomain, error) {
+	if _, err := s.siteRepo.FindByID(ctx, siteID); err != nil {
+		return nil, fmt.Errorf("siteService.ListDomains find: %w", err)
+	}
+
+	domains, err := s.siteRepo.FindDomainsBySiteID(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("siteService.ListDomains: %w", err)
+	}
+	return domains, nil
+}
+
+// AddDomain adds a domain alias to a site. The "www." prefix is dropped since
+// the www alias of every domain is resolved implicitly.
+func (s *siteService) AddDomain(ctx context.Context, siteID uuid.UUID, input domain.AddSiteDomainInput) (*domain.SiteDomain, error) {
+	name := strings.TrimPrefix(normalizeHost(input.Domain), "www.")
+	if !hostnamePattern.MatchString(name) {
+		return nil, fmt.Errorf("siteService.AddDomain: %w", domain.ErrInvalidDomain)
+	}
+
+	if _, err := s.siteRepo.FindByID(ctx, siteID); err != nil {
+		return nil, fmt.Errorf("siteService.AddDomain find: %w", err)
+	}
+
+	siteDomain := &domain.SiteDomain{
+		ID:     uuid.New(),
+		SiteID: siteID,
+		Domain: name,
+	}
+	if err := s.siteRepo.CreateDomain(ctx, siteDomain); err != nil {
+		return nil, fmt.Errorf("siteService.AddDomain: %w", err)
+	}
+	s.hostCache.clear()
+
+	s.logger.Info().
+		Str("site_id", siteID.String()).
+		Str("domain", name).
+		Msg("site domain added")
+
+	return siteDomain, nil
+}
+
+// RemoveDomain removes a domain alias from a site
+func (s *siteService) RemoveDomain(ctx context.Context, siteID, domainID uuid.UUID) error {
+	if err := s.siteRepo.DeleteDomain(ctx, siteID, domainID); err != nil {
+		return fmt.Errorf("siteService.RemoveDomain: %w", err)
+	}
+	s.hostCache.clear()
 	return nil
 }
 
@@ -200,6 +305,7 @@ func (s *siteService) UpdateSetting(ctx context.Context, siteID uuid.UUID, key s
 	if err := s.siteRepo.UpsertSetting(ctx, siteID, key, value); err != nil {
 		return fmt.Errorf("siteService.UpdateSetting: %w", err)
 	}
+	s.notifySiteChanged(siteID)
 	return nil
 }
 
@@ -208,5 +314,13 @@ func (s *siteService) BulkUpdateSettings(ctx context.Context, siteID uuid.UUID,
 	if err := s.siteRepo.BulkUpsertSettings(ctx, siteID, input.Settings); err != nil {
 		return fmt.Errorf("siteService.BulkUpdateSettings: %w", err)
 	}
+	s.notifySiteChanged(siteID)
 	return nil
 }
+
+// notifySiteChanged tells the listeners that a site changed
+func (s *siteService) notifySiteChanged(siteID uuid.UUID) {
+	for _, listener := range s.listeners {
+		listener(siteID)
+	}
+}
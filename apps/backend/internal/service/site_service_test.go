@@ -3,6 +3,7 @@ package service_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,14 +16,20 @@ import (
 // ─── Mock SiteRepository ──────────────────────────────────────────────────────
 
 type mockSiteRepository struct {
-	sites    map[uuid.UUID]*domain.Site
-	settings map[string]*domain.SiteSetting // key: siteID+":"+key
+	sites         map[uuid.UUID]*domain.Site
+	settings      map[string]*domain.SiteSetting // key: siteID+":"+key
+	domains       map[uuid.UUID]*domain.SiteDomain
+	domainLookups int
+	tree          *domain.SiteBundle // last tree passed to CreateTree
+	treeDryRun    bool
+	treeCalls     int
 }
 
 func newMockSiteRepository() *mockSiteRepository {
 	return &mockSiteRepository{
 		sites:    make(map[uuid.UUID]*domain.Site),
 		settings: make(map[string]*domain.SiteSetting),
+		domains:  make(map[uuid.UUID]*domain.SiteDomain),
 	}
 }
 
@@ -43,11 +50,17 @@ func (m *mockSiteRepository) FindBySlug(ctx context.Context, slug string) (*doma
 }
 
 func (m *mockSiteRepository) FindByDomain(ctx context.Context, domainName string) (*domain.Site, error) {
+	m.domainLookups++
 	for _, s := range m.sites {
-		if s.Domain != nil && *s.Domain == domainName {
+		if s.Domain != nil && strings.EqualFold(*s.Domain, domainName) {
 			return s, nil
 		}
 	}
+	for _, d := range m.domains {
+		if d.Domain == domainName {
+			return m.FindByID(ctx, d.SiteID)
+		}
+	}
 	return nil, domain.ErrNotFound
 }
 
@@ -126,6 +139,50 @@ func (m *mockSiteRepository) BulkUpsertSettings(ctx context.Context, siteID uuid
 	return nil
 }
 
+func (m *mockSiteRepository) CreateTree(ctx context.Context, tree *domain.SiteBundle, dryRun bool) error {
+	m.tree = tree
+	m.treeDryRun = dryRun
+	m.treeCalls++
+	if !dryRun {
+		m.sites[tree.Site.ID] = tree.Site
+	}
+	return nil
+}
+
+func (m *mockSiteRepository) FindDomainsBySiteID(ctx context.Context, siteID uuid.UUID) ([]*domain.SiteDomain, error) {
+	var domains []*domain.SiteDomain
+	for _, d := range m.domains {
+		if d.SiteID == siteID {
+			domains = append(domains, d)
+		}
+	}
+	return domains, nil
+}
+
+func (m *mockSiteRepository) CreateDomain(ctx context.Context, siteDomain *domain.SiteDomain) error {
+	for _, s := range m.sites {
+		if s.Domain != nil && strings.EqualFold(*s.Domain, siteDomain.Domain) {
+			return domain.ErrAlreadyExists
+		}
+	}
+	for _, d := range m.domains {
+		if d.Domain == siteDomain.Domain {
+			return domain.ErrAlreadyExists
+		}
+	}
+	siteDomain.CreatedAt = time.Now()
+	m.domains[siteDomain.ID] = siteDomain
+	return nil
+}
+
+func (m *mockSiteRepository) DeleteDomain(ctx context.Context, siteID, id uuid.UUID) error {
+	if d, ok := m.domains[id]; !ok || d.SiteID != siteID {
+		return domain.ErrNotFound
+	}
+	delete(m.domains, id)
+	return nil
+}
+
 // ─── Tests ────────────────────────────────────────────────────────────────────
 
 func createTestSiteService(repo *mockSiteRepository) service.SiteService {
@@ -323,3 +380,116 @@ func TestSiteService_ListSites_Success(t *testing.T) {
 		t.Errorf("expected 3 sites, got %d", result.Total)
 	}
 }
+
+func TestSiteService_ResolveSiteByHost(t *testing.T) {
+	repo := newMockSiteRepository()
+	svc := createTestSiteService(repo)
+	ctx := context.Background()
+
+	primary := "example.com"
+	site := &domain.Site{ID: uuid.New(), Name: "Example", Slug: "example", Domain: &primary, IsActive: true}
+	repo.sites[site.ID] = site
+	if _, err := svc.AddDomain(ctx, site.ID, domain.AddSiteDomainInput{Domain: "www.Example.org"}); err != nil {
+		t.Fatalf("expected no error adding alias, got: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		host    string
+		wantErr bool
+	}{
+		{name: "primary domain", host: "example.com"},
+		{name: "port and case", host: "EXAMPLE.com:8080"},
+		{name: "www counterpart", host: "www.example.com"},
+		{name: "alias domain", host: "example.org"},
+		{name: "www alias", host: "www.example.org"},
+		{name: "unknown host", host: "other.com", wantErr: true},
+		{name: "empty host", host: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ResolveSiteByHost(ctx, tt.host)
+			if tt.wantErr {
+				if !errors.Is(err, domain.ErrNotFound) {
+					t.Fatalf("expected ErrNotFound, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if got.ID != site.ID {
+				t.Errorf("expected site %s, got %s", site.ID, got.ID)
+			}
+		})
+	}
+}
+
+func TestSiteService_ResolveSiteByHost_Cached(t *testing.T) {
+	repo := newMockSiteRepository()
+	svc := createTestSiteService(repo)
+	ctx := context.Background()
+
+	primary := "example.com"
+	site := &domain.Site{ID: uuid.New(), Name: "Example", Slug: "example", Domain: &primary, IsActive: true}
+	repo.sites[site.ID] = site
+
+	svc.ResolveSiteByHost(ctx, "example.com")
+	svc.ResolveSiteByHost(ctx, "unknown.com")
+	lookups := repo.domainLookups
+
+	svc.ResolveSiteByHost(ctx, "example.com")
+	if _, err := svc.ResolveSiteByHost(ctx, "unknown.com"); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("expected cached miss to return ErrNotFound, got: %v", err)
+	}
+	if repo.domainLookups != lookups {
+		t.Errorf("expected cached hosts not to hit the repository, got %d extra lookups", repo.domainLookups-lookups)
+	}
+
+	// Adding a domain invalidates cached misses
+	if _, err := svc.AddDomain(ctx, site.ID, domain.AddSiteDomainInput{Domain: "unknown.com"}); err != nil {
+		t.Fatalf("expected no error adding alias, got: %v", err)
+	}
+	got, err := svc.ResolveSiteByHost(ctx, "unknown.com")
+	if err != nil {
+		t.Fatalf("expected alias to resolve after cache invalidation, got: %v", err)
+	}
+	if got.ID != site.ID {
+		t.Errorf("expected site %s, got %s", site.ID, got.ID)
+	}
+}
+
+func TestSiteService_AddDomain_Validation(t *testing.T) {
+	repo := newMockSiteRepository()
+	svc := createTestSiteService(repo)
+	ctx := context.Background()
+
+	primary := "example.com"
+	site := &domain.Site{ID: uuid.New(), Name: "Example", Slug: "example", Domain: &primary, IsActive: true}
+	repo.sites[site.ID] = site
+
+	tests := []struct {
+		name    string
+		siteID  uuid.UUID
+		domain  string
+		wantErr error
+	}{
+		{name: "valid", siteID: site.ID, domain: "example.net"},
+		{name: "invalid characters", siteID: site.ID, domain: "exa_mple.net", wantErr: domain.ErrInvalidDomain},
+		{name: "empty", siteID: site.ID, domain: "  ", wantErr: domain.ErrInvalidDomain},
+		{name: "used as primary", siteID: site.ID, domain: "www.example.com", wantErr: domain.ErrAlreadyExists},
+		{name: "duplicate alias", siteID: site.ID, domain: "EXAMPLE.net", wantErr: domain.ErrAlreadyExists},
+		{name: "unknown site", siteID: uuid.New(), domain: "example.io", wantErr: domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.AddDomain(ctx, tt.siteID, domain.AddSiteDomainInput{Domain: tt.domain})
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
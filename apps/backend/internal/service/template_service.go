@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+)
+
+// TemplateService defines the interface for page templates and section presets
+type TemplateService interface {
+	ListTemplates(ctx context.Context) ([]*domain.PageTemplate, error)
+	GetTemplate(ctx context.Context, id uuid.UUID) (*domain.PageTemplate, error)
+	CreateTemplate(ctx context.Context, input domain.CreateTemplateInput, userID uuid.UUID) (*domain.PageTemplate, error)
+	UpdateTemplate(ctx context.Context, id uuid.UUID, input domain.UpdateTemplateInput) (*domain.PageTemplate, error)
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	// ListPresets returns the built-in section presets
+	ListPresets() []domain.SectionPreset
+	// CreatePageFromTemplate creates a draft page with the template's sections
+	// and an empty content item for every expected key
+	CreatePageFromTemplate(ctx context.Context, templateID uuid.UUID, input domain.CreatePageFromTemplateInput, userID uuid.UUID) (*domain.Page, error)
+}
+
+// templateService implements TemplateService
+type templateService struct {
+	templateRepo repository.TemplateRepository
+	pageRepo     repository.PageRepository
+	revisionSvc  RevisionService
+	logger       zerolog.Logger
+}
+
+// NewTemplateService creates a new TemplateService
+func NewTemplateService(
+	templateRepo repository.TemplateRepository,
+	pageRepo repository.PageRepository,
+	revisionSvc RevisionService,
+	logger zerolog.Logger,
+) TemplateService {
+	return &templateService{
+		templateRepo: templateRepo,
+		pageRepo:     pageRepo,
+		revisionSvc:  revisionSvc,
+		logger:       logger,
+	}
+}
+
+// ListTemplates retrieves all page templates
+func (s *templateService) ListTemplates(ctx context.Context) ([]*domain.PageTemplate, error) {
+	templates, err := s.templateRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("templateService.ListTemplates: %w", err)
+	}
+	return templates, nil
+}
+
+// GetTemplate retrieves a page template by ID
+func (s *templateService) GetTemplate(ctx context.Context, id uuid.UUID) (*domain.PageTemplate, error) {
+	tmpl, err := s.templateRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("templateService.GetTemplate: %w", err)
+	}
+	return tmpl, nil
+}
+
+// CreateTemplate validates and stores a new page template
+func (s *templateService) CreateTemplate(ctx context.Context, input domain.CreateTemplateInput, userID uuid.UUID) (*domain.PageTemplate, error) {
+	tmpl := &domain.PageTemplate{
+		ID:          uuid.New(),
+		Name:        strings.TrimSpace(input.Name),
+		Description: input.Description,
+		Layout:      input.Layout,
+		Sections:    input.Sections,
+		CreatedBy:   &userID,
+	}
+	if err := s.checkTemplate(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("templateService.CreateTemplate: %w", err)
+	}
+
+	if err := s.templateRepo.Create(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("templateService.CreateTemplate: %w", err)
+	}
+
+	s.logger.Info().
+		Str("template_id", tmpl.ID.String()).
+		Str("name", tmpl.Name).
+		Msg("page template created")
+
+	return tmpl, nil
+}
+
+// UpdateTemplate updates a page template. Pages created from it keep the
+// sections they were created with.
+func (s *templateService) UpdateTemplate(ctx context.Context, id uuid.UUID, input domain.UpdateTemplateInput) (*domain.PageTemplate, error) {
+	tmpl, err := s.templateRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("templateService.UpdateTemplate find: %w", err)
+	}
+
+	if input.Name != nil {
+		tmpl.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		tmpl.Description = input.Description
+	}
+	if input.Layout != nil {
+		tmpl.Layout = input.Layout
+	}
+	if input.Sections != nil {
+		tmpl.Sections = input.Sections
+	}
+	if err := s.checkTemplate(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("templateService.UpdateTemplate: %w", err)
+	}
+
+	if err := s.templateRepo.Update(ctx, tmpl); err != nil {
+		return nil, fmt.Errorf("templateService.UpdateTemplate: %w", err)
+	}
+	return tmpl, nil
+}
+
+// DeleteTemplate deletes a page template
+func (s *templateService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("templateService.DeleteTemplate: %w", err)
+	}
+	return nil
+}
+
+// ListPresets returns the built-in section presets
+func (s *templateService) ListPresets() []domain.SectionPreset {
+	return domain.SectionPresets()
+}
+
+// CreatePageFromTemplate builds a draft page from a template and writes it in
+// one transaction. Each section keeps its fields as content schema, so only
+// those keys can be saved later.
+func (s *templateService) CreatePageFromTemplate(ctx context.Context, templateID uuid.UUID, input domain.CreatePageFromTemplateInput, userID uuid.UUID) (*domain.Page, error) {
+	tmpl, err := s.templateRepo.FindByID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("templateService.CreatePageFromTemplate: %w", err)
+	}
+
+	title := strings.TrimSpace(input.Title)
+	slug := normalizeSlug(input.Slug)
+	if slug == "" {
+		slug = generateSlug(title)
+	}
+	if title == "" || slug == "" {
+		return nil, fmt.Errorf("templateService.CreatePageFromTemplate: %w: title is required", domain.ErrValidation)
+	}
+	if _, err := s.pageRepo.FindBySlug(ctx, input.SiteID, slug); err == nil {
+		return nil, fmt.Errorf("templateService.CreatePageFromTemplate: %w", domain.ErrAlreadyExists)
+	} else if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("templateService.CreatePageFromTemplate: %w", err)
+	}
+
+	page := &domain.Page{
+		ID:          uuid.New(),
+		SiteID:      input.SiteID,
+		Title:       title,
+		Slug:        slug,
+		Description: input.Description,
+		Status:      domain.PageStatusDraft,
+		Template:    tmpl.Layout,
+		CreatedBy:   &userID,
+		UpdatedBy:   &userID,
+	}
+	for i, ts := range tmpl.Sections {
+		section := &domain.PageSection{
+			ID:            uuid.New(),
+			PageID:        page.ID,
+			Name:          ts.Name,
+			Type:          ts.Type,
+			Identifier:    ts.Identifier,
+			IsVisible:     true,
+			SortOrder:     i + 1,
+			ContentSchema: templateSectionFields(ts),
+		}
+		for j, f := range section.ContentSchema {
+			section.Contents = append(section.Contents, &domain.SectionContent{
+				ID:          uuid.New(),
+				SectionID:   section.ID,
+				Key:         f.Key,
+				Type:        f.Type,
+				Label:       f.Label,
+				Description: f.Description,
+				Placeholder: f.Placeholder,
+				IsRequired:  f.IsRequired,
+				SortOrder:   j + 1,
+			})
+		}
+		page.Sections = append(page.Sections, section)
+	}
+
+	if err := s.pageRepo.CreateTree(ctx, page, nil); err != nil {
+		return nil, fmt.Errorf("templateService.CreatePageFromTemplate: %w", err)
+	}
+
+	if _, err := s.revisionSvc.RecordRevision(ctx, page.ID, userID, "page created from template: "+tmpl.Name); err != nil {
+		s.logger.Error().Err(err).Str("page_id", page.ID.String()).Msg("failed to record revision")
+	}
+
+	s.logger.Info().
+		Str("page_id", page.ID.String()).
+		Str("template_id", tmpl.ID.String()).
+		Str("slug", page.Slug).
+		Str("user_id", userID.String()).
+		Msg("page created from template")
+
+	return page, nil
+}
+
+// checkTemplate validates a template's sections and rejects a name used by
+// another template
+func (s *templateService) checkTemplate(ctx context.Context, tmpl *domain.PageTemplate) error {
+	if err := validateTemplate(tmpl); err != nil {
+		return err
+	}
+	existing, err := s.templateRepo.FindByName(ctx, tmpl.Name)
+	if err == nil && existing.ID != tmpl.ID {
+		return domain.ErrAlreadyExists
+	} else if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// validateTemplate checks a template's name and sections, collecting every
+// problem found. Fields without a type default to text.
+func validateTemplate(tmpl *domain.PageTemplate) error {
+	var problems []string
+	addf := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if tmpl.Name == "" {
+		addf("name is required")
+	}
+	if len(tmpl.Sections) == 0 {
+		addf("at least one section is required")
+	}
+	for i := range tmpl.Sections {
+		ts := &tmpl.Sections[i]
+		if strings.TrimSpace(ts.Name) == "" || ts.Type == "" {
+			addf("section %d needs a name and a type", i+1)
+		}
+		keys := make(map[string]bool, len(ts.Fields))
+		for j := range ts.Fields {
+			f := &ts.Fields[j]
+			if f.Key == "" || keys[f.Key] {
+				addf("section %q: field key %q is empty or duplicated", ts.Name, f.Key)
+			}
+			keys[f.Key] = true
+			if f.Type == "" {
+				f.Type = domain.ContentTypeText
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &domain.ContentSchemaError{Problems: problems}
+	}
+	return nil
+}
+
+// templateSectionFields returns a template section's fields, falling back to
+// the preset of its type
+func templateSectionFields(ts domain.TemplateSection) domain.ContentSchema {
+	if len(ts.Fields) > 0 {
+		return ts.Fields
+	}
+	return domain.PresetFields(ts.Type)
+}
+
+// applyContentSchema checks content against a section's schema and fills in
+// the type, label, description, placeholder and required flag it defines. A
+// nil schema accepts any content.
+func applyContentSchema(schema domain.ContentSchema, inputs []domain.UpsertContentInput) error {
+	if schema == nil {
+		return nil
+	}
+
+	var problems []string
+	for i := range inputs {
+		input := &inputs[i]
+		f, ok := schema.Field(input.Key)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown content key %q", input.Key))
+			continue
+		}
+		if input.Type == "" {
+			input.Type = f.Type
+		}
+		if input.Type != f.Type {
+			problems = append(problems, fmt.Sprintf("content key %q must be of type %s", input.Key, f.Type))
+		}
+		if f.IsRequired && (input.Value == nil || strings.TrimSpace(*input.Value) == "") && len(input.ValueJSON) == 0 {
+			problems = append(problems, fmt.Sprintf("content key %q is required", input.Key))
+		}
+		input.Label = f.Label
+		input.Description = f.Description
+		input.Placeholder = f.Placeholder
+		input.IsRequired = f.IsRequired
+	}
+
+	if len(problems) > 0 {
+		return &domain.ContentSchemaError{Problems: problems}
+	}
+	return nil
+}
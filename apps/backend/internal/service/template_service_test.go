@@ -0,0 +1,294 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock TemplateRepository ──────────────────────────────────────────────────
+
+type mockTemplateRepository struct {
+	templates map[uuid.UUID]*domain.PageTemplate
+}
+
+func newMockTemplateRepository() *mockTemplateRepository {
+	return &mockTemplateRepository{templates: make(map[uuid.UUID]*domain.PageTemplate)}
+}
+
+func (m *mockTemplateRepository) FindAll(ctx context.Context) ([]*domain.PageTemplate, error) {
+	var templates []*domain.PageTemplate
+	for _, t := range m.templates {
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func (m *mockTemplateRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.PageTemplate, error) {
+	if t, ok := m.templates[id]; ok {
+		return t, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockTemplateRepository) FindByName(ctx context.Context, name string) (*domain.PageTemplate, error) {
+	for _, t := range m.templates {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockTemplateRepository) Create(ctx context.Context, tmpl *domain.PageTemplate) error {
+	m.templates[tmpl.ID] = tmpl
+	return nil
+}
+
+func (m *mockTemplateRepository) Update(ctx context.Context, tmpl *domain.PageTemplate) error {
+	if _, ok := m.templates[tmpl.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	m.templates[tmpl.ID] = tmpl
+	return nil
+}
+
+func (m *mockTemplateRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := m.templates[id]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(m.templates, id)
+	return nil
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+func setupTemplateTest(t *testing.T) (*mockPageRepository, service.PageService, service.TemplateService) {
+	t.Helper()
+	logger := zerolog.Nop()
+	pageRepo := newMockPageRepository()
+	revisionSvc := service.NewRevisionService(pageRepo, newMockRevisionRepository(), logger)
+	pageSvc := service.NewPageService(pageRepo, revisionSvc, logger)
+	templateSvc := service.NewTemplateService(newMockTemplateRepository(), pageRepo, revisionSvc, logger)
+	return pageRepo, pageSvc, templateSvc
+}
+
+// launchTemplate is a hero with the built-in preset followed by a custom
+// section with its own fields
+func launchTemplate() domain.CreateTemplateInput {
+	layout := "default"
+	placeholder := "e.g. 3x faster"
+	return domain.CreateTemplateInput{
+		Name:   "Product launch",
+		Layout: &layout,
+		Sections: []domain.TemplateSection{
+			{Name: "Hero", Type: domain.SectionTypeHero},
+			{Name: "Numbers", Type: domain.SectionTypeCustom, Fields: domain.ContentSchema{
+				{Key: "headline", Placeholder: &placeholder, IsRequired: true},
+				{Key: "chart", Type: domain.ContentTypeImage},
+			}},
+		},
+	}
+}
+
+func TestTemplateService_CreateTemplate_Invalid(t *testing.T) {
+	_, _, svc := setupTemplateTest(t)
+	ctx := context.Background()
+
+	if _, err := svc.CreateTemplate(ctx, launchTemplate(), uuid.New()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(in *domain.CreateTemplateInput)
+		wantErr error
+		want    string
+	}{
+		{name: "name taken", modify: func(in *domain.CreateTemplateInput) { in.Name = "product LAUNCH" }, wantErr: domain.ErrAlreadyExists},
+		{name: "no sections", modify: func(in *domain.CreateTemplateInput) { in.Sections = nil }, wantErr: domain.ErrContentSchema, want: "at least one section"},
+		{
+			name:    "duplicate field key",
+			modify:  func(in *domain.CreateTemplateInput) { in.Sections[1].Fields[1].Key = "headline" },
+			wantErr: domain.ErrContentSchema,
+			want:    `field key "headline" is empty or duplicated`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := launchTemplate()
+			input.Name = "Other"
+			tt.modify(&input)
+			_, err := svc.CreateTemplate(ctx, input, uuid.New())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+			}
+			if tt.want != "" && !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected a problem containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestTemplateService_CreatePageFromTemplate(t *testing.T) {
+	pageRepo, _, svc := setupTemplateTest(t)
+	ctx := context.Background()
+
+	tmpl, err := svc.CreateTemplate(ctx, launchTemplate(), uuid.New())
+	if err != nil {
+		t.Fatalf("failed to create template: %v", err)
+	}
+
+	siteID := uuid.New()
+	page, err := svc.CreatePageFromTemplate(ctx, tmpl.ID, domain.CreatePageFromTemplateInput{SiteID: siteID, Title: "Launch Day"}, uuid.New())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if page.Slug != "launch-day" || page.Status != domain.PageStatusDraft || page.Template == nil || *page.Template != "default" {
+		t.Errorf("expected a draft launch-day page with the template layout, got %+v", page)
+	}
+
+	sections, _ := pageRepo.FindSectionsByPageID(ctx, page.ID)
+	if len(sections) != 2 {
+		t.Fatalf("expected 2 sections, got %d", len(sections))
+	}
+	var hero, numbers *domain.PageSection
+	for _, s := range sections {
+		if s.Type == domain.SectionTypeHero {
+			hero = s
+		} else {
+			numbers = s
+		}
+	}
+	if len(hero.ContentSchema) != len(domain.PresetFields(domain.SectionTypeHero)) || hero.SortOrder != 1 {
+		t.Error("expected the hero to use the built-in preset as first section")
+	}
+
+	contents, _ := pageRepo.FindContentsBySectionID(ctx, numbers.ID)
+	if len(contents) != 2 {
+		t.Fatalf("expected an empty content item per field, got %d", len(contents))
+	}
+	for _, c := range contents {
+		switch c.Key {
+		case "headline":
+			if c.Type != domain.ContentTypeText || !c.IsRequired || c.Placeholder == nil || c.Value != nil {
+				t.Errorf("expected an empty required text item with placeholder, got %+v", c)
+			}
+		case "chart":
+			if c.Type != domain.ContentTypeImage {
+				t.Errorf("expected an image item, got %s", c.Type)
+			}
+		default:
+			t.Errorf("unexpected content key %q", c.Key)
+		}
+	}
+
+	_, err = svc.CreatePageFromTemplate(ctx, tmpl.ID, domain.CreatePageFromTemplateInput{SiteID: siteID, Title: "Launch Day"}, uuid.New())
+	if !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists for a taken slug, got: %v", err)
+	}
+	_, err = svc.CreatePageFromTemplate(ctx, uuid.New(), domain.CreatePageFromTemplateInput{SiteID: siteID, Title: "Other"}, uuid.New())
+	if !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing template, got: %v", err)
+	}
+}
+
+func TestPageService_UpsertContent_Schema(t *testing.T) {
+	pageRepo, pageSvc, svc := setupTemplateTest(t)
+	ctx := context.Background()
+
+	tmpl, _ := svc.CreateTemplate(ctx, launchTemplate(), uuid.New())
+	page, err := svc.CreatePageFromTemplate(ctx, tmpl.ID, domain.CreatePageFromTemplateInput{SiteID: uuid.New(), Title: "Launch"}, uuid.New())
+	if err != nil {
+		t.Fatalf("failed to create page: %v", err)
+	}
+	var sectionID uuid.UUID
+	for _, s := range page.Sections {
+		if s.Type == domain.SectionTypeCustom {
+			sectionID = s.ID
+		}
+	}
+
+	value := "3x faster"
+	empty := " "
+	label := "Custom label"
+	tests := []struct {
+		name  string
+		input domain.UpsertContentInput
+		want  string
+	}{
+		{name: "unknown key", input: domain.UpsertContentInput{Key: "footer", Value: &value}, want: `unknown content key "footer"`},
+		{name: "wrong type", input: domain.UpsertContentInput{Key: "chart", Value: &value, Type: domain.ContentTypeText}, want: `"chart" must be of type image`},
+		{name: "empty required", input: domain.UpsertContentInput{Key: "headline", Value: &empty}, want: `"headline" is required`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := pageSvc.UpsertContent(ctx, sectionID, tt.input, uuid.New())
+			var schemaErr *domain.ContentSchemaError
+			if !errors.As(err, &schemaErr) || !strings.Contains(strings.Join(schemaErr.Problems, "\n"), tt.want) {
+				t.Errorf("expected a problem containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+
+	content, err := pageSvc.UpsertContent(ctx, sectionID, domain.UpsertContentInput{Key: "headline", Value: &value, Label: &label}, uuid.New())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if content.Type != domain.ContentTypeText || !content.IsRequired || content.Label != nil {
+		t.Errorf("expected type and flags from the schema, got %+v", content)
+	}
+
+	_, err = pageSvc.BulkUpsertContents(ctx, sectionID, []domain.UpsertContentInput{
+		{Key: "chart", Value: &value},
+		{Key: "footer", Value: &value},
+	}, uuid.New())
+	if !errors.Is(err, domain.ErrContentSchema) {
+		t.Fatalf("expected ErrContentSchema, got: %v", err)
+	}
+	chart, _ := pageRepo.FindContentByKey(ctx, sectionID, "chart")
+	if chart.Value != nil {
+		t.Error("expected nothing to be written when one item is rejected")
+	}
+
+	if err := pageSvc.DeleteContent(ctx, content.ID, uuid.New()); !errors.Is(err, domain.ErrContentSchema) {
+		t.Errorf("expected required content not to be deletable, got: %v", err)
+	}
+	if err := pageSvc.DeleteContent(ctx, chart.ID, uuid.New()); err != nil {
+		t.Errorf("expected optional content to be deletable, got: %v", err)
+	}
+}
+
+func TestPageService_CreateSection_Preset(t *testing.T) {
+	_, pageSvc, _ := setupTemplateTest(t)
+	ctx := context.Background()
+
+	section, err := pageSvc.CreateSection(ctx, domain.CreateSectionInput{
+		PageID: createTestPage(t, pageSvc).ID, Name: "CTA", Type: domain.SectionTypeCTA, Preset: true,
+	}, uuid.New())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, ok := section.ContentSchema.Field("cta_primary_link"); !ok {
+		t.Fatal("expected the cta preset on the section")
+	}
+
+	value := "Join us"
+	if _, err := pageSvc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{Key: "title", Value: &value}, uuid.New()); err != nil {
+		t.Errorf("expected a preset key to be accepted, got: %v", err)
+	}
+	if _, err := pageSvc.UpsertContent(ctx, section.ID, domain.UpsertContentInput{Key: "tagline", Value: &value}, uuid.New()); !errors.Is(err, domain.ErrContentSchema) {
+		t.Errorf("expected an unknown key to be rejected, got: %v", err)
+	}
+
+	free, _ := pageSvc.CreateSection(ctx, domain.CreateSectionInput{PageID: section.PageID, Name: "Free", Type: domain.SectionTypeCTA}, uuid.New())
+	if _, err := pageSvc.UpsertContent(ctx, free.ID, domain.UpsertContentInput{Key: "tagline", Value: &value, Type: domain.ContentTypeText}, uuid.New()); err != nil {
+		t.Errorf("expected a section without preset to stay free-form, got: %v", err)
+	}
+}
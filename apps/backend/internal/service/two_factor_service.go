@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+// TwoFactorService defines the interface for TOTP two-factor authentication
+type TwoFactorService interface {
+	Status(ctx context.Context, userID uuid.UUID) (*domain.TwoFactorStatus, error)
+	// Setup starts enrollment with a new pending secret
+	Setup(ctx context.Context, userID uuid.UUID) (*domain.TOTPSetup, error)
+	// Enable confirms the pending secret with a code and returns the recovery codes
+	Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	Disable(ctx context.Context, userID uuid.UUID, input domain.DisableTwoFactorInput) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	// Requirement reports whether the user has two-factor authentication
+	// enabled and whether the user's role requires it
+	Requirement(ctx context.Context, user *domain.User) (enabled, required bool, err error)
+	// VerifyCode accepts a TOTP code or an unused recovery code. Wrong codes
+	// count towards the login lockout.
+	VerifyCode(ctx context.Context, user *domain.User, code string) error
+	ListPolicies(ctx context.Context) ([]*domain.TwoFactorPolicy, error)
+	SetPolicy(ctx context.Context, role domain.UserRole, input domain.SetTwoFactorPolicyInput, updatedBy uuid.UUID) (*domain.TwoFactorPolicy, error)
+}
+
+// twoFactorService implements TwoFactorService
+type twoFactorService struct {
+	twoFactorRepo repository.TwoFactorRepository
+	userRepo      repository.UserRepository
+	roleService   RoleService
+	issuer        string
+	logger        zerolog.Logger
+}
+
+// NewTwoFactorService creates a new TwoFactorService. issuer is the account
+// name shown by authenticator apps.
+func NewTwoFactorService(
+	twoFactorRepo repository.TwoFactorRepository,
+	userRepo repository.UserRepository,
+	roleService RoleService,
+	issuer string,
+	logger zerolog.Logger,
+) TwoFactorService {
+	return &twoFactorService{
+		twoFactorRepo: twoFactorRepo,
+		userRepo:      userRepo,
+		roleService:   roleService,
+		issuer:        issuer,
+		logger:        logger,
+	}
+}
+
+// Status returns the two-factor state of a user
+func (s *twoFactorService) Status(ctx context.Context, userID uuid.UUID) (*domain.TwoFactorStatus, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Status: %w", err)
+	}
+	required, err := s.isRequired(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Status: %w", err)
+	}
+	status := &domain.TwoFactorStatus{IsRequired: required}
+
+	totp, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && !totp.IsEnabled) {
+		return status, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Status: %w", err)
+	}
+
+	status.IsEnabled = true
+	status.EnabledAt = totp.EnabledAt
+	if status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(ctx, userID); err != nil {
+		return nil, fmt.Errorf("twoFactorService.Status: %w", err)
+	}
+	return status, nil
+}
+
+// Setup stores a new pending secret, replacing an earlier unconfirmed one
+func (s *twoFactorService) Setup(ctx context.Context, userID uuid.UUID) (*domain.TOTPSetup, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Setup: %w", err)
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Setup: %w", err)
+	}
+	if err := s.twoFactorRepo.SavePendingSecret(ctx, userID, secret); err != nil {
+		return nil, fmt.Errorf("twoFactorService.Setup: %w", err)
+	}
+
+	return &domain.TOTPSetup{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms the pending secret. The recovery codes are returned once
+// and only their hashes are stored.
+func (s *twoFactorService) Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Enable: %w", err)
+	}
+	if user.IsLocked() {
+		return nil, domain.ErrAccountLocked
+	}
+	totp, err := s.twoFactorRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Enable: %w", err)
+	}
+	if totp.IsEnabled {
+		return nil, fmt.Errorf("twoFactorService.Enable: %w", domain.ErrAlreadyExists)
+	}
+
+	step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now())
+	if !ok {
+		s.recordFailure(ctx, user)
+		return nil, domain.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.Enable: %w", err)
+	}
+	if err := s.twoFactorRepo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, fmt.Errorf("twoFactorService.Enable: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID.String()).Msg("two-factor authentication enabled")
+	return codes, nil
+}
+
+// Disable turns two-factor authentication off after checking the password and
+// a code. Users whose role requires it cannot turn it off.
+func (s *twoFactorService) Disable(ctx context.Context, userID uuid.UUID, input domain.DisableTwoFactorInput) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("twoFactorService.Disable: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		return domain.ErrInvalidCredentials
+	}
+
+	required, err := s.isRequired(ctx, user.Role)
+	if err != nil {
+		return fmt.Errorf("twoFactorService.Disable: %w", err)
+	}
+	if required {
+		return fmt.Errorf("twoFactorService.Disable: %w: required for role %s", domain.ErrForbidden, user.Role)
+	}
+
+	if err := s.VerifyCode(ctx, user, input.Code); err != nil {
+		return err
+	}
+	if err := s.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("twoFactorService.Disable: %w", err)
+	}
+
+	s.logger.Info().Str("user_id", userID.String()).Msg("two-factor authentication disabled")
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a code
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.RegenerateRecoveryCodes: %w", err)
+	}
+	if err := s.VerifyCode(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.RegenerateRecoveryCodes: %w", err)
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("twoFactorService.RegenerateRecoveryCodes: %w", err)
+	}
+	return codes, nil
+}
+
+// Requirement reports the enrollment of a user and the policy of the user's role
+func (s *twoFactorService) Requirement(ctx context.Context, user *domain.User) (bool, bool, error) {
+	required, err := s.isRequired(ctx, user.Role)
+	if err != nil {
+		return false, false, fmt.Errorf("twoFactorService.Requirement: %w", err)
+	}
+	totp, err := s.twoFactorRepo.FindByUserID(ctx, user.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, required, nil
+	} else if err != nil {
+		return false, false, fmt.Errorf("twoFactorService.Requirement: %w", err)
+	}
+	return totp.IsEnabled, required, nil
+}
+
+// VerifyCode checks a TOTP code, then a recovery code. A TOTP code is accepted
+// once; a recovery code is used up.
+func (s *twoFactorService) VerifyCode(ctx context.Context, user *domain.User, code string) error {
+	if user.IsLocked() {
+		return domain.ErrAccountLocked
+	}
+	totp, err := s.twoFactorRepo.FindByUserID(ctx, user.ID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && !totp.IsEnabled) {
+		return domain.ErrInvalidTwoFactorCode
+	} else if err != nil {
+		return fmt.Errorf("twoFactorService.VerifyCode: %w", err)
+	}
+
+	if step, ok := auth.ValidateTOTP(totp.Secret, code, time.Now()); ok {
+		err = s.twoFactorRepo.UseStep(ctx, user.ID, step)
+	} else {
+		hash := auth.HashToken(auth.NormalizeRecoveryCode(code))
+		if err = s.twoFactorRepo.UseRecoveryCode(ctx, user.ID, hash); err == nil {
+			s.logger.Warn().Str("user_id", user.ID.String()).Msg("recovery code used")
+		}
+	}
+
+	if errors.Is(err, domain.ErrNotFound) {
+		s.recordFailure(ctx, user)
+		return domain.ErrInvalidTwoFactorCode
+	} else if err != nil {
+		return fmt.Errorf("twoFactorService.VerifyCode: %w", err)
+	}
+	return nil
+}
+
+// ListPolicies returns the policy of every role, defaulting to not required
+func (s *twoFactorService) ListPolicies(ctx context.Context) ([]*domain.TwoFactorPolicy, error) {
+	stored, err := s.twoFactorRepo.FindPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.ListPolicies: %w", err)
+	}
+	byRole := make(map[domain.UserRole]*domain.TwoFactorPolicy, len(stored))
+	for _, p := range stored {
+		byRole[p.Role] = p
+	}
+
+	roles, err := s.roleService.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("twoFactorService.ListPolicies: %w", err)
+	}
+	policies := make([]*domain.TwoFactorPolicy, 0, len(roles))
+	for _, role := range roles {
+		if p, ok := byRole[role.Name]; ok {
+			policies = append(policies, p)
+		} else {
+			policies = append(policies, &domain.TwoFactorPolicy{Role: role.Name})
+		}
+	}
+	return policies, nil
+}
+
+// SetPolicy requires or stops requiring two-factor authentication for a role.
+// Users of the role who are not enrolled must enroll at their next login.
+func (s *twoFactorService) SetPolicy(ctx context.Context, role domain.UserRole, input domain.SetTwoFactorPolicyInput, updatedBy uuid.UUID) (*domain.TwoFactorPolicy, error) {
+	if _, err := s.roleService.GetRoleByName(ctx, role); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("twoFactorService.SetPolicy: %w: unknown role %q", domain.ErrValidation, role)
+		}
+		return nil, fmt.Errorf("twoFactorService.SetPolicy: %w", err)
+	}
+
+	policy := &domain.TwoFactorPolicy{Role: role, IsRequired: input.IsRequired, UpdatedBy: &updatedBy}
+	if err := s.twoFactorRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("twoFactorService.SetPolicy: %w", err)
+	}
+
+	s.logger.Info().
+		Str("role", string(role)).
+		Bool("required", input.IsRequired).
+		Str("user_id", updatedBy.String()).
+		Msg("two-factor policy updated")
+
+	return policy, nil
+}
+
+// isRequired returns whether a role requires two-factor authentication
+func (s *twoFactorService) isRequired(ctx context.Context, role domain.UserRole) (bool, error) {
+	policy, err := s.twoFactorRepo.FindPolicy(ctx, role)
+	if errors.Is(err, domain.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return policy.IsRequired, nil
+}
+
+// recordFailure counts a wrong code like a wrong password, locking the
+// account after maxFailedAttempts
+func (s *twoFactorService) recordFailure(ctx context.Context, user *domain.User) {
+	attempts := user.FailedAttempts + 1
+	if err := s.userRepo.IncrementFailedAttempts(ctx, user.ID); err != nil {
+		s.logger.Error().Err(err).Msg("failed to increment failed attempts")
+	}
+	if attempts >= maxFailedAttempts {
+		if err := s.userRepo.LockAccount(ctx, user.ID, time.Now().Add(lockDuration)); err != nil {
+			s.logger.Error().Err(err).Msg("failed to lock account")
+		}
+		s.logger.Warn().
+			Str("user_id", user.ID.String()).
+			Int("attempts", attempts).
+			Msg("account locked due to too many failed two-factor codes")
+	}
+}
+
+// newRecoveryCodes generates recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = auth.HashToken(auth.NormalizeRecoveryCode(c))
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,332 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/domain"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/pkg/auth"
+	"github.com/ilramdhan/goxynhub/apps/backend/internal/service"
+)
+
+// ─── Mock TwoFactorRepository ─────────────────────────────────────────────────
+
+type mockTwoFactorRepository struct {
+	totps         map[uuid.UUID]*domain.UserTOTP
+	recoveryCodes map[uuid.UUID]map[string]bool // hash → used
+	policies      map[domain.UserRole]*domain.TwoFactorPolicy
+}
+
+func newMockTwoFactorRepository() *mockTwoFactorRepository {
+	return &mockTwoFactorRepository{
+		totps:         make(map[uuid.UUID]*domain.UserTOTP),
+		recoveryCodes: make(map[uuid.UUID]map[string]bool),
+		policies:      make(map[domain.UserRole]*domain.TwoFactorPolicy),
+	}
+}
+
+func (m *mockTwoFactorRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*domain.UserTOTP, error) {
+	if t, ok := m.totps[userID]; ok {
+		return t, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockTwoFactorRepository) SavePendingSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	if t, ok := m.totps[userID]; ok && t.IsEnabled {
+		return domain.ErrAlreadyExists
+	}
+	m.totps[userID] = &domain.UserTOTP{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (m *mockTwoFactorRepository) Enable(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	t, ok := m.totps[userID]
+	if !ok || t.IsEnabled {
+		return domain.ErrNotFound
+	}
+	now := time.Now()
+	t.IsEnabled, t.EnabledAt, t.LastUsedStep = true, &now, step
+	return m.ReplaceRecoveryCodes(ctx, userID, codeHashes)
+}
+
+func (m *mockTwoFactorRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	if _, ok := m.totps[userID]; !ok {
+		return domain.ErrNotFound
+	}
+	delete(m.totps, userID)
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+
+func (m *mockTwoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	t, ok := m.totps[userID]
+	if !ok || t.LastUsedStep >= step {
+		return domain.ErrNotFound
+	}
+	t.LastUsedStep = step
+	return nil
+}
+
+func (m *mockTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	m.recoveryCodes[userID] = make(map[string]bool, len(codeHashes))
+	for _, h := range codeHashes {
+		m.recoveryCodes[userID][h] = false
+	}
+	return nil
+}
+
+func (m *mockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	used, ok := m.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return domain.ErrNotFound
+	}
+	m.recoveryCodes[userID][codeHash] = true
+	return nil
+}
+
+func (m *mockTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	count := 0
+	for _, used := range m.recoveryCodes[userID] {
+		if !used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockTwoFactorRepository) FindPolicies(ctx context.Context) ([]*domain.TwoFactorPolicy, error) {
+	var policies []*domain.TwoFactorPolicy
+	for _, p := range m.policies {
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+func (m *mockTwoFactorRepository) FindPolicy(ctx context.Context, role domain.UserRole) (*domain.TwoFactorPolicy, error) {
+	if p, ok := m.policies[role]; ok {
+		return p, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *mockTwoFactorRepository) SavePolicy(ctx context.Context, policy *domain.TwoFactorPolicy) error {
+	m.policies[policy.Role] = policy
+	return nil
+}
+
+// ─── Tests ────────────────────────────────────────────────────────────────────
+
+type twoFactorTestEnv struct {
+	users     *mockUserRepository
+	twoFactor *mockTwoFactorRepository
+	authSvc   service.AuthService
+	svc       service.TwoFactorService
+	user      *domain.User
+}
+
+func setupTwoFactorTest(t *testing.T, role domain.UserRole) *twoFactorTestEnv {
+	t.Helper()
+	env := &twoFactorTestEnv{users: newMockUserRepository(), twoFactor: newMockTwoFactorRepository()}
+	env.user = createTestUser("admin@test.com", "password123", role)
+	env.users.users[env.user.Email] = env.user
+	env.authSvc, env.svc = createTestAuthServices(env.users, env.twoFactor, &mockMailer{})
+	return env
+}
+
+// codeAt returns the TOTP code of a secret a number of steps from now
+func codeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("failed to compute code: %v", err)
+	}
+	return code
+}
+
+// enroll enables two-factor authentication with the previous step's code, so
+// the current code is still unused
+func (env *twoFactorTestEnv) enroll(t *testing.T) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	setup, err := env.svc.Setup(ctx, env.user.ID)
+	if err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	codes, err := env.svc.Enable(ctx, env.user.ID, codeAt(t, setup.Secret, -1))
+	if err != nil {
+		t.Fatalf("enable failed: %v", err)
+	}
+	return setup.Secret, codes
+}
+
+func (env *twoFactorTestEnv) challenge(t *testing.T) *domain.TwoFactorRequiredError {
+	t.Helper()
+	_, tokens, err := env.authSvc.Login(context.Background(),
+		domain.LoginInput{Email: env.user.Email, Password: "password123"}, "127.0.0.1", "test-agent")
+	var challenge *domain.TwoFactorRequiredError
+	if !errors.As(err, &challenge) || tokens != nil {
+		t.Fatalf("expected a two-factor challenge, got tokens=%v err=%v", tokens, err)
+	}
+	return challenge
+}
+
+func TestTwoFactorService_SetupAndEnable(t *testing.T) {
+	env := setupTwoFactorTest(t, domain.RoleAdmin)
+	ctx := context.Background()
+
+	setup, err := env.svc.Setup(ctx, env.user.ID)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	uri, _ := url.Parse(setup.OTPAuthURI)
+	if uri.Scheme != "otpauth" || uri.Query().Get("secret") != setup.Secret || uri.Query().Get("issuer") != "Test CMS" {
+		t.Errorf("unexpected otpauth URI %s", setup.OTPAuthURI)
+	}
+
+	if _, err := env.svc.Enable(ctx, env.user.ID, "000000"); !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Errorf("expected a wrong code to be rejected, got: %v", err)
+	}
+	codes, err := env.svc.Enable(ctx, env.user.ID, codeAt(t, setup.Secret, 0))
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(codes) != 10 {
+		t.Errorf("expected 10 recovery codes, got %d", len(codes))
+	}
+
+	status, _ := env.svc.Status(ctx, env.user.ID)
+	if !status.IsEnabled || status.RecoveryCodesLeft != 10 || status.IsRequired {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if _, err := env.svc.Setup(ctx, env.user.ID); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("expected setup to be refused while enabled, got: %v", err)
+	}
+}
+
+func TestAuthService_Login_TwoFactor(t *testing.T) {
+	env := setupTwoFactorTest(t, domain.RoleSuperAdmin)
+	ctx := context.Background()
+	secret, recoveryCodes := env.enroll(t)
+
+	challenge := env.challenge(t)
+	if challenge.SetupRequired || challenge.ChallengeToken == "" {
+		t.Fatalf("unexpected challenge %+v", challenge)
+	}
+
+	code := codeAt(t, secret, 0)
+	user, tokens, newCodes, err := env.authSvc.CompleteTwoFactorLogin(ctx,
+		domain.TwoFactorLoginInput{ChallengeToken: challenge.ChallengeToken, Code: code}, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if user.ID != env.user.ID || tokens.AccessToken == "" || newCodes != nil {
+		t.Errorf("expected tokens without new recovery codes, got %v %v", tokens, newCodes)
+	}
+
+	// The same code cannot be used twice
+	_, _, _, err = env.authSvc.CompleteTwoFactorLogin(ctx,
+		domain.TwoFactorLoginInput{ChallengeToken: challenge.ChallengeToken, Code: code}, "127.0.0.1", "test-agent")
+	if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		t.Errorf("expected a replayed code to be rejected, got: %v", err)
+	}
+
+	// A recovery code works once, however it is typed
+	recovery := "  " + recoveryCodes[0][:5] + recoveryCodes[0][6:]
+	for i, want := range []error{nil, domain.ErrInvalidTwoFactorCode} {
+		_, _, _, err = env.authSvc.CompleteTwoFactorLogin(ctx,
+			domain.TwoFactorLoginInput{ChallengeToken: challenge.ChallengeToken, Code: recovery}, "127.0.0.1", "test-agent")
+		if !errors.Is(err, want) {
+			t.Errorf("use %d of a recovery code: expected %v, got: %v", i+1, want, err)
+		}
+	}
+
+	_, _, _, err = env.authSvc.CompleteTwoFactorLogin(ctx,
+		domain.TwoFactorLoginInput{ChallengeToken: tokens.AccessToken, Code: codeAt(t, secret, 1)}, "127.0.0.1", "test-agent")
+	if !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected an access token not to work as challenge, got: %v", err)
+	}
+}
+
+func TestAuthService_Login_TwoFactorLockout(t *testing.T) {
+	env := setupTwoFactorTest(t, domain.RoleAdmin)
+	ctx := context.Background()
+	secret, _ := env.enroll(t)
+	challenge := env.challenge(t)
+
+	for i := 0; i < 5; i++ {
+		_, _, _, err := env.authSvc.CompleteTwoFactorLogin(ctx,
+			domain.TwoFactorLoginInput{ChallengeToken: challenge.ChallengeToken, Code: "000000"}, "127.0.0.1", "test-agent")
+		if !errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: expected ErrInvalidTwoFactorCode, got: %v", i+1, err)
+		}
+	}
+
+	_, _, _, err := env.authSvc.CompleteTwoFactorLogin(ctx,
+		domain.TwoFactorLoginInput{ChallengeToken: challenge.ChallengeToken, Code: codeAt(t, secret, 0)}, "127.0.0.1", "test-agent")
+	if !errors.Is(err, domain.ErrAccountLocked) {
+		t.Errorf("expected the account to be locked, got: %v", err)
+	}
+}
+
+func TestAuthService_Login_RoleRequiresTwoFactor(t *testing.T) {
+	env := setupTwoFactorTest(t, domain.RoleEditor)
+	ctx := context.Background()
+
+	if _, err := env.svc.SetPolicy(ctx, domain.RoleEditor, domain.SetTwoFactorPolicyInput{IsRequired: true}, uuid.New()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if _, err := env.svc.SetPolicy(ctx, "guest", domain.SetTwoFactorPolicyInput{IsRequired: true}, uuid.New()); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("expected an unknown role to be rejected, got: %v", err)
+	}
+	policies, _ := env.svc.ListPolicies(ctx)
+	if len(policies) != 3 {
+		t.Errorf("expected a policy per role, got %d", len(policies))
+	}
+
+	challenge := env.challenge(t)
+	if !challenge.SetupRequired {
+		t.Fatal("expected enrollment to be required")
+	}
+
+	setup, err := env.authSvc.StartTwoFactorSetup(ctx, domain.TwoFactorChallengeInput{ChallengeToken: challenge.ChallengeToken})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	_, tokens, codes, err := env.authSvc.CompleteTwoFactorLogin(ctx,
+		domain.TwoFactorLoginInput{ChallengeToken: challenge.ChallengeToken, Code: codeAt(t, setup.Secret, 0)}, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if tokens == nil || len(codes) != 10 {
+		t.Errorf("expected tokens and recovery codes after enrollment, got %v %d", tokens, len(codes))
+	}
+
+	err = env.svc.Disable(ctx, env.user.ID, domain.DisableTwoFactorInput{Password: "password123", Code: codeAt(t, setup.Secret, 1)})
+	if !errors.Is(err, domain.ErrForbidden) {
+		t.Errorf("expected disabling to be forbidden for the role, got: %v", err)
+	}
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	env := setupTwoFactorTest(t, domain.RoleAdmin)
+	ctx := context.Background()
+	secret, _ := env.enroll(t)
+
+	err := env.svc.Disable(ctx, env.user.ID, domain.DisableTwoFactorInput{Password: "wrong", Code: codeAt(t, secret, 0)})
+	if !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected a wrong password to be rejected, got: %v", err)
+	}
+	if err := env.svc.Disable(ctx, env.user.ID, domain.DisableTwoFactorInput{Password: "password123", Code: codeAt(t, secret, 0)}); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	_, tokens, err := env.authSvc.Login(ctx, domain.LoginInput{Email: env.user.Email, Password: "password123"}, "127.0.0.1", "test-agent")
+	if err != nil || tokens == nil {
+		t.Errorf("expected a password-only login after disabling, got: %v", err)
+	}
+}
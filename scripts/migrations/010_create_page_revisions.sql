@@ -0,0 +1,27 @@
+-- Migration: 010_create_page_revisions.sql
+-- Description: Create page_revisions table for immutable page history snapshots
+-- Created: 2024-01-01
+
+-- Page revisions table (append-only, one row per change to a page tree)
+CREATE TABLE IF NOT EXISTS page_revisions (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    page_id         UUID NOT NULL REFERENCES pages(id) ON DELETE CASCADE,
+    revision_number INTEGER NOT NULL,
+    snapshot        JSONB NOT NULL,         -- full page tree: page, sections, contents
+    change_summary  VARCHAR(255),           -- e.g., 'page updated', 'content upserted: title'
+    created_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_page_revisions_page_number ON page_revisions(page_id, revision_number);
+CREATE INDEX idx_page_revisions_created_at ON page_revisions(page_id, created_at DESC);
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('010', 'Create page revisions')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP TABLE IF EXISTS page_revisions CASCADE;
@@ -0,0 +1,43 @@
+-- Migration: 011_add_page_published_snapshot.sql
+-- Description: Separate draft (live tables) from published content via a published snapshot
+-- Created: 2024-01-01
+
+-- Editors always work on pages/page_sections/section_contents (the draft layer).
+-- Publishing copies the full page tree into published_snapshot, which is the only
+-- thing the public API reads.
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS published_snapshot JSONB;
+
+-- Public lookups resolve the slug from the published snapshot, not the draft
+CREATE INDEX IF NOT EXISTS idx_pages_published_slug
+    ON pages(site_id, (published_snapshot->>'slug'))
+    WHERE status = 'published' AND deleted_at IS NULL;
+
+-- Backfill: pages that are already published keep serving their current content
+UPDATE pages p SET published_snapshot =
+    (to_jsonb(p) - 'deleted_at' - 'published_snapshot') || jsonb_build_object(
+        'sections', COALESCE((
+            SELECT jsonb_agg(
+                to_jsonb(s) || jsonb_build_object(
+                    'contents', COALESCE((
+                        SELECT jsonb_agg(to_jsonb(c) ORDER BY c.sort_order, c.key)
+                        FROM section_contents c
+                        WHERE c.section_id = s.id
+                    ), '[]'::jsonb)
+                ) ORDER BY s.sort_order
+            )
+            FROM page_sections s
+            WHERE s.page_id = p.id
+        ), '[]'::jsonb)
+    )
+WHERE p.status = 'published' AND p.deleted_at IS NULL AND p.published_snapshot IS NULL;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('011', 'Add page published snapshot')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP INDEX IF EXISTS idx_pages_published_slug;
+-- ALTER TABLE pages DROP COLUMN IF EXISTS published_snapshot;
@@ -0,0 +1,31 @@
+-- Migration: 012_add_page_schedule.sql
+-- Description: Add scheduled publish/unpublish times to pages
+-- Created: 2024-01-01
+
+-- A non-NULL value means a pending scheduled change. The scheduler clears the
+-- column once it has applied the change (claims are tracked by migration 023).
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;
+
+ALTER TABLE pages ADD CONSTRAINT pages_schedule_order_check
+    CHECK (publish_at IS NULL OR unpublish_at IS NULL OR unpublish_at > publish_at);
+
+-- Partial indexes keep the scheduler's due-change scans cheap
+CREATE INDEX IF NOT EXISTS idx_pages_publish_at ON pages(publish_at)
+    WHERE publish_at IS NOT NULL AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pages_unpublish_at ON pages(unpublish_at)
+    WHERE unpublish_at IS NOT NULL AND deleted_at IS NULL;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('012', 'Add page schedule')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP INDEX IF EXISTS idx_pages_unpublish_at;
+-- DROP INDEX IF EXISTS idx_pages_publish_at;
+-- ALTER TABLE pages DROP CONSTRAINT IF EXISTS pages_schedule_order_check;
+-- ALTER TABLE pages DROP COLUMN IF EXISTS unpublish_at;
+-- ALTER TABLE pages DROP COLUMN IF EXISTS publish_at;
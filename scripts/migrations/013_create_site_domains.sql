@@ -0,0 +1,31 @@
+-- Migration: 013_create_site_domains.sql
+-- Description: Create site_domains table for additional per-site domains (aliases)
+-- Created: 2024-01-01
+
+-- sites.domain remains the primary domain; site_domains holds extra aliases
+-- (e.g., a marketing domain pointing at the same site). Domains are stored
+-- lowercase, without port and without a leading "www." (that alias is implicit).
+CREATE TABLE IF NOT EXISTS site_domains (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    site_id     UUID NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    domain      VARCHAR(255) NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT site_domains_domain_lowercase CHECK (domain = LOWER(domain))
+);
+
+CREATE UNIQUE INDEX idx_site_domains_domain ON site_domains(domain);
+CREATE INDEX idx_site_domains_site_id ON site_domains(site_id);
+
+-- Host resolution compares lowercase domains
+CREATE INDEX IF NOT EXISTS idx_sites_domain_lower ON sites(LOWER(domain)) WHERE deleted_at IS NULL;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('013', 'Create site domains')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP INDEX IF EXISTS idx_sites_domain_lower;
+-- DROP TABLE IF EXISTS site_domains CASCADE;
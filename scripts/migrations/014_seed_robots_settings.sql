@@ -0,0 +1,28 @@
+-- Migration: 014_seed_robots_settings.sql
+-- Description: Seed the robots.txt settings of existing sites
+-- Created: 2024-01-01
+
+-- robots.txt is assembled from these settings (see GET /robots.txt):
+--   robots_allow_indexing  "false" disallows the whole site
+--   robots_disallow        disallowed paths, one per line or comma-separated
+--   robots_extra           lines appended verbatim, e.g. rules for specific bots
+INSERT INTO site_settings (site_id, key, value, type, group_name, label, description, is_public, sort_order)
+SELECT s.id, v.key, v.value, v.type, 'seo', v.label, v.description, false, v.sort_order
+FROM sites s
+CROSS JOIN (VALUES
+    ('robots_allow_indexing', 'true', 'boolean', 'Allow Search Engine Indexing', 'Set to false to disallow crawling of the whole site', 10),
+    ('robots_disallow', '', 'string', 'Disallowed Paths', 'Paths closed to crawlers, one per line', 11),
+    ('robots_extra', '', 'string', 'Additional robots.txt Rules', 'Appended to robots.txt as-is', 12)
+) AS v(key, value, type, label, description, sort_order)
+WHERE s.deleted_at IS NULL
+ON CONFLICT (site_id, key) DO NOTHING;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('014', 'Seed robots settings')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DELETE FROM site_settings WHERE key IN ('robots_allow_indexing', 'robots_disallow', 'robots_extra');
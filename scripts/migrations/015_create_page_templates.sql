@@ -0,0 +1,40 @@
+-- Migration: 015_create_page_templates.sql
+-- Description: Create page_templates and store a content schema per section
+-- Created: 2024-01-01
+
+-- A page template is a named blueprint: an ordered list of sections, each with
+-- the content keys it expects. sections holds a JSON array of
+--   {"name", "type", "identifier", "fields": [{"key", "type", "label",
+--    "description", "placeholder", "is_required"}]}
+-- Sections without fields use the built-in preset of their type.
+CREATE TABLE IF NOT EXISTS page_templates (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(255) NOT NULL,
+    description TEXT,
+    layout      VARCHAR(100),
+    sections    JSONB NOT NULL DEFAULT '[]',
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_page_templates_name ON page_templates(LOWER(name));
+
+CREATE TRIGGER update_page_templates_updated_at
+    BEFORE UPDATE ON page_templates
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Sections created from a template (or with a preset) keep a copy of their
+-- fields; content keys outside it are rejected. NULL means free-form.
+ALTER TABLE page_sections ADD COLUMN IF NOT EXISTS content_schema JSONB;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('015', 'Create page templates')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- ALTER TABLE page_sections DROP COLUMN IF EXISTS content_schema;
+-- DROP TABLE IF EXISTS page_templates CASCADE;
@@ -0,0 +1,55 @@
+-- Migration: 016_create_two_factor.sql
+-- Description: TOTP two-factor authentication, recovery codes and per-role requirement
+-- Created: 2024-01-01
+
+-- One row per user who started enrollment. The secret is pending until the
+-- first code is confirmed (is_enabled). last_used_step stops a code from
+-- being accepted twice.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret         VARCHAR(64) NOT NULL,
+    is_enabled     BOOLEAN NOT NULL DEFAULT false,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    enabled_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_user_totp_updated_at
+    BEFORE UPDATE ON user_totp
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- One-time recovery codes, stored as SHA-256 hashes
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash   VARCHAR(255) NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_user_recovery_codes_hash ON user_recovery_codes(user_id, code_hash);
+
+-- Roles whose users must use two-factor authentication
+CREATE TABLE IF NOT EXISTS two_factor_policies (
+    role        user_role PRIMARY KEY,
+    is_required BOOLEAN NOT NULL DEFAULT false,
+    updated_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_two_factor_policies_updated_at
+    BEFORE UPDATE ON two_factor_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('016', 'Create two-factor authentication tables')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP TABLE IF EXISTS two_factor_policies CASCADE;
+-- DROP TABLE IF EXISTS user_recovery_codes CASCADE;
+-- DROP TABLE IF EXISTS user_totp CASCADE;
@@ -0,0 +1,36 @@
+-- Migration: 017_create_api_keys.sql
+-- Description: Long-lived scoped API keys for machine clients
+-- Created: 2024-01-01
+
+-- A key acts as its owner (user_id) limited to its scopes and, when site_id
+-- is set, to one site. Only the SHA-256 hash of the key is stored; key_prefix
+-- keeps the first characters so a key can be recognised in the list.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    site_id       UUID REFERENCES sites(id) ON DELETE CASCADE,
+    name          VARCHAR(255) NOT NULL,
+    key_prefix    VARCHAR(20) NOT NULL,
+    key_hash      VARCHAR(255) NOT NULL,
+    scopes        JSONB NOT NULL DEFAULT '[]',  -- e.g. ["pages:read", "media:write"]
+    expires_at    TIMESTAMPTZ,
+    last_used_at  TIMESTAMPTZ,
+    last_used_ip  VARCHAR(45),
+    revoked_at    TIMESTAMPTZ,
+    created_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_api_keys_hash ON api_keys(key_hash);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX idx_api_keys_site_id ON api_keys(site_id);
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('017', 'Create api_keys table')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP TABLE IF EXISTS api_keys CASCADE;
@@ -0,0 +1,37 @@
+-- Migration: 018_refresh_token_families.sql
+-- Description: Refresh token families for reuse detection and absolute session lifetime
+-- Created: 2024-01-01
+
+-- Every login starts a family; each refresh rotates the token inside it.
+-- rotated_at marks a token that has been exchanged, so presenting it again
+-- reveals a stolen token. family_expires_at caps the whole session no matter
+-- how often it is refreshed (NULL = no absolute limit).
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS family_id         UUID,
+    ADD COLUMN IF NOT EXISTS family_expires_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS rotated_at        TIMESTAMPTZ;
+
+-- Existing tokens each become their own family
+UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+-- Security event written when a rotated token is replayed
+ALTER TYPE audit_action ADD VALUE IF NOT EXISTS 'token_reuse';
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('018', 'Add refresh token families')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+-- ALTER TABLE refresh_tokens
+--     DROP COLUMN IF EXISTS rotated_at,
+--     DROP COLUMN IF EXISTS family_expires_at,
+--     DROP COLUMN IF EXISTS family_id;
+-- (enum values cannot be dropped; 'token_reuse' stays in audit_action)
@@ -0,0 +1,41 @@
+-- Migration: 019_create_site_memberships.sql
+-- Description: Per-site roles for editors
+-- Created: 2024-01-01
+
+-- Admins and super admins work on every site. Editors only reach the sites
+-- they hold a membership on, with the role given here; a membership can make
+-- an editor the admin of their own site.
+CREATE TABLE IF NOT EXISTS site_memberships (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    site_id     UUID NOT NULL REFERENCES sites(id) ON DELETE CASCADE,
+    user_id     UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role        user_role NOT NULL DEFAULT 'editor',
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT site_memberships_role_check CHECK (role IN ('admin', 'editor'))
+);
+
+CREATE UNIQUE INDEX idx_site_memberships_site_user ON site_memberships(site_id, user_id);
+CREATE INDEX idx_site_memberships_user_id ON site_memberships(user_id);
+
+CREATE TRIGGER update_site_memberships_updated_at
+    BEFORE UPDATE ON site_memberships
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Editors could edit every site before; keep that until admins narrow it down
+INSERT INTO site_memberships (site_id, user_id, role)
+SELECT s.id, u.id, 'editor'
+FROM sites s CROSS JOIN users u
+WHERE s.deleted_at IS NULL AND u.role = 'editor' AND u.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('019', 'Create site_memberships table')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP TABLE IF EXISTS site_memberships CASCADE;
@@ -0,0 +1,68 @@
+-- Migration: 020_create_roles.sql
+-- Description: Roles made of named permissions, replacing the fixed role hierarchy
+-- Created: 2024-01-01
+
+-- A role bundles permissions such as page.publish or user.manage. The three
+-- built-in roles keep the access they had; custom roles can be added through
+-- /admin/roles. super_admin always has every permission.
+CREATE TABLE IF NOT EXISTS roles (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions JSONB NOT NULL DEFAULT '[]',  -- e.g. ["content.view", "page.publish"]
+    is_built_in BOOLEAN NOT NULL DEFAULT false,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_roles_name ON roles(name);
+
+CREATE TRIGGER update_roles_updated_at
+    BEFORE UPDATE ON roles
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO roles (name, description, permissions, is_built_in) VALUES
+('super_admin', 'Full access including deleting sites and users, and managing roles',
+ '["content.view", "page.edit", "page.publish", "page.delete", "component.edit",
+   "media.upload", "media.delete", "site.manage", "site.delete", "site.create",
+   "site.all", "template.manage", "user.manage", "user.delete", "role.manage",
+   "apikey.manage", "security.manage", "audit.view"]', true),
+('admin', 'Manages users, sites and all content',
+ '["content.view", "page.edit", "page.publish", "component.edit", "media.upload",
+   "media.delete", "page.delete", "site.manage", "site.create", "site.all",
+   "template.manage", "user.manage", "apikey.manage", "security.manage", "audit.view"]', true),
+('editor', 'Edits and publishes content on the sites they are members of',
+ '["content.view", "page.edit", "page.publish", "component.edit", "media.upload",
+   "media.delete"]', true)
+ON CONFLICT (name) DO NOTHING;
+
+-- users.role and two_factor_policies.role now name a row of roles
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(50) USING role::text;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'editor';
+ALTER TABLE users ADD CONSTRAINT users_role_fkey
+    FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+
+ALTER TABLE two_factor_policies ALTER COLUMN role TYPE VARCHAR(50) USING role::text;
+ALTER TABLE two_factor_policies ADD CONSTRAINT two_factor_policies_role_fkey
+    FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE;
+
+-- Site memberships keep the built-in admin and editor roles (user_role type)
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('020', 'Create roles table')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- ALTER TABLE two_factor_policies DROP CONSTRAINT IF EXISTS two_factor_policies_role_fkey;
+-- DELETE FROM two_factor_policies WHERE role NOT IN ('super_admin', 'admin', 'editor');
+-- ALTER TABLE two_factor_policies ALTER COLUMN role TYPE user_role USING role::user_role;
+-- ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;
+-- UPDATE users SET role = 'editor' WHERE role NOT IN ('super_admin', 'admin', 'editor');
+-- ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+-- ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::user_role;
+-- ALTER TABLE users ALTER COLUMN role SET DEFAULT 'editor';
+-- DROP TABLE IF EXISTS roles CASCADE;
@@ -0,0 +1,39 @@
+-- Migration: 021_create_user_invitations.sql
+-- Description: Invite users by email instead of choosing their password
+-- Created: 2024-01-01
+
+-- Invited users exist with status 'pending' and cannot sign in until they
+-- accept the invitation and choose a password
+ALTER TYPE user_status ADD VALUE IF NOT EXISTS 'pending';
+
+-- One row per invitation; resending replaces token_hash and expires_at.
+-- Only the SHA-256 hash of the token is stored.
+CREATE TABLE IF NOT EXISTS user_invitations (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash   VARCHAR(255) NOT NULL,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    sent_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    send_count   INTEGER NOT NULL DEFAULT 1,
+    accepted_at  TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    invited_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_user_invitations_token_hash ON user_invitations(token_hash);
+CREATE INDEX idx_user_invitations_user_id ON user_invitations(user_id);
+CREATE INDEX idx_user_invitations_pending ON user_invitations(created_at)
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('021', 'Create user_invitations table')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP TABLE IF EXISTS user_invitations CASCADE;
+-- UPDATE users SET status = 'inactive' WHERE status = 'pending';
+-- Postgres cannot drop an enum value; 'pending' stays in user_status.
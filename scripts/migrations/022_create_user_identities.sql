@@ -0,0 +1,47 @@
+-- Migration: 022_create_user_identities.sql
+-- Description: OpenID Connect single sign-on
+-- Created: 2024-01-01
+
+-- Users who must sign in through the identity provider. Users created by
+-- single sign-on have no password and start with this set.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_login_disabled BOOLEAN NOT NULL DEFAULT false;
+
+-- Accounts at an OpenID provider, identified by issuer and subject. The email
+-- is the one last seen and is informational only.
+CREATE TABLE IF NOT EXISTS user_identities (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer        VARCHAR(500) NOT NULL,
+    subject       VARCHAR(255) NOT NULL,
+    email         VARCHAR(255),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ,
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- Started logins waiting for the provider callback. A row is deleted when
+-- the callback consumes it; only the SHA-256 hash of the state is stored.
+CREATE TABLE IF NOT EXISTS oidc_login_requests (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    state_hash    VARCHAR(255) NOT NULL UNIQUE,
+    nonce         VARCHAR(255) NOT NULL,
+    code_verifier VARCHAR(255) NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_oidc_login_requests_expires_at ON oidc_login_requests(expires_at);
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('022', 'Create user_identities and oidc_login_requests tables')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP TABLE IF EXISTS oidc_login_requests CASCADE;
+-- DROP TABLE IF EXISTS user_identities CASCADE;
+-- ALTER TABLE users DROP COLUMN IF EXISTS password_login_disabled;
@@ -0,0 +1,21 @@
+-- Migration: 023_add_page_schedule_claims.sql
+-- Description: Lease scheduled page changes instead of clearing them on claim
+-- Created: 2024-01-01
+
+-- When a scheduler instance claims a due change it stamps the matching column
+-- and leaves publish_at/unpublish_at alone. The schedule is cleared only once
+-- the change is applied; a claim older than the lease is taken again, so a
+-- change whose instance died before applying it is retried.
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS publish_claimed_at TIMESTAMPTZ;
+ALTER TABLE pages ADD COLUMN IF NOT EXISTS unpublish_claimed_at TIMESTAMPTZ;
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('023', 'Add page schedule claims')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- ALTER TABLE pages DROP COLUMN IF EXISTS unpublish_claimed_at;
+-- ALTER TABLE pages DROP COLUMN IF EXISTS publish_claimed_at;
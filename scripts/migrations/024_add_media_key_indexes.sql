@@ -0,0 +1,21 @@
+-- Migration: 024_add_media_key_indexes.sql
+-- Description: Look up media by storage key
+-- Created: 2024-01-01
+
+-- Private files served from /media/*key are checked against the site of the
+-- media item they belong to, found by its file path or one of its image
+-- variants' keys
+CREATE INDEX IF NOT EXISTS idx_media_file_path ON media(file_path);
+CREATE INDEX IF NOT EXISTS idx_media_variants ON media
+    USING GIN ((metadata->'variants') jsonb_path_ops);
+
+-- Record migration
+INSERT INTO schema_migrations (version, description) VALUES
+('024', 'Add media key indexes')
+ON CONFLICT DO NOTHING;
+
+-- ============================================================
+-- ROLLBACK SCRIPT
+-- ============================================================
+-- DROP INDEX IF EXISTS idx_media_variants;
+-- DROP INDEX IF EXISTS idx_media_file_path;